@@ -0,0 +1,78 @@
+package gocbcore
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+)
+
+// AddressTranslator rewrites the address of every node endpoint found in cluster configs before it is used, allowing
+// clients behind port forwards, bastions or NAT to reach nodes which advertise addresses that are not reachable
+// directly. Translation is applied after any alternate address has been selected. When TLS is in use the translated
+// host is the one verified against the node's certificate.
+// Volatile: This API is subject to change at any time.
+type AddressTranslator interface {
+	TranslateAddress(service ServiceType, host string, port int) (string, int)
+}
+
+// AddressTranslatorFunc allows a function to be used as an AddressTranslator.
+// Volatile: This API is subject to change at any time.
+type AddressTranslatorFunc func(service ServiceType, host string, port int) (string, int)
+
+// TranslateAddress calls f(service, host, port).
+func (f AddressTranslatorFunc) TranslateAddress(service ServiceType, host string, port int) (string, int) {
+	return f(service, host, port)
+}
+
+func translateHostPort(translator AddressTranslator, service ServiceType, hostPort string, logCtx logContext) string {
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		logCtx.logDebugf("Failed to split host port for address translation: %s", err)
+		return hostPort
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		logCtx.logDebugf("Failed to parse port for address translation: %s", err)
+		return hostPort
+	}
+
+	host, port = translator.TranslateAddress(service, host, port)
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func translateEndpoint(translator AddressTranslator, service ServiceType, endpoint string, logCtx logContext) string {
+	epURL, err := url.Parse(endpoint)
+	if err != nil {
+		logCtx.logDebugf("Failed to parse endpoint for address translation: %s", err)
+		return endpoint
+	}
+
+	epURL.Host = translateHostPort(translator, service, epURL.Host, logCtx)
+	return epURL.String()
+}
+
+func translateEndpoints(translator AddressTranslator, service ServiceType, endpoints []string,
+	translateFn func(AddressTranslator, ServiceType, string, logContext) string, logCtx logContext) []string {
+	if endpoints == nil {
+		return nil
+	}
+
+	translated := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		translated[i] = translateFn(translator, service, endpoint, logCtx)
+	}
+
+	return translated
+}
+
+// translateRouteConfig rewrites every endpoint in cfg using translator. The order of the kv server list is preserved
+// so that the vbucket and ketama maps still refer to the correct nodes.
+func translateRouteConfig(translator AddressTranslator, cfg *routeConfig, logCtx logContext) {
+	cfg.kvServerList = translateEndpoints(translator, MemdService, cfg.kvServerList, translateHostPort, logCtx)
+	cfg.capiEpList = translateEndpoints(translator, CapiService, cfg.capiEpList, translateEndpoint, logCtx)
+	cfg.mgmtEpList = translateEndpoints(translator, MgmtService, cfg.mgmtEpList, translateEndpoint, logCtx)
+	cfg.n1qlEpList = translateEndpoints(translator, N1qlService, cfg.n1qlEpList, translateEndpoint, logCtx)
+	cfg.ftsEpList = translateEndpoints(translator, FtsService, cfg.ftsEpList, translateEndpoint, logCtx)
+	cfg.cbasEpList = translateEndpoints(translator, CbasService, cfg.cbasEpList, translateEndpoint, logCtx)
+}
@@ -0,0 +1,43 @@
+package gocbcore
+
+import (
+	"strings"
+)
+
+func (suite *UnitTestSuite) TestAddressTranslator() {
+	raw, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+
+	cfgBk, err := parseConfig(raw, "172.17.0.2")
+	suite.Require().Nil(err)
+
+	var services []ServiceType
+	translator := AddressTranslatorFunc(func(service ServiceType, host string, port int) (string, int) {
+		services = append(services, service)
+		return strings.Replace(host, "172.17.0.", "10.0.0.", 1), port + 10000
+	})
+
+	cm := newConfigManager(configManagerProperties{
+		NetworkType:       "default",
+		AddressTranslator: translator,
+	})
+	cm.OnNewConfig(cfgBk)
+
+	routeCfg := cm.currentConfig
+	suite.Assert().Equal([]string{"10.0.0.2:21210", "10.0.0.3:21210", "10.0.0.4:21210"}, routeCfg.kvServerList)
+	suite.Assert().Equal("http://10.0.0.2:18091", routeCfg.mgmtEpList[0])
+	suite.Assert().Equal("http://10.0.0.2:18092/default", routeCfg.capiEpList[0])
+	suite.Assert().Equal("http://10.0.0.2:18093", routeCfg.n1qlEpList[0])
+	suite.Assert().Contains(services, MemdService)
+	suite.Assert().Contains(services, FtsService)
+
+	// The vbucket map refers to servers by index so is unaffected by translation.
+	srvIdx, err := routeCfg.vbMap.NodeByVbucket(0, 0)
+	suite.Require().Nil(err)
+	suite.Assert().True(srvIdx >= 0 && srvIdx < len(routeCfg.kvServerList))
+
+	suite.Assert().Equal("[::1]:11210", translateHostPort(AddressTranslatorFunc(func(service ServiceType,
+		host string, port int) (string, int) {
+		return "::1", port
+	}), MemdService, "127.0.0.1:11210", nil))
+}
@@ -9,8 +9,11 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
+
+	scram "github.com/couchbase/gocbcore/v9/scram"
 )
 
 // Agent represents the base client handling connections to a Couchbase Server.
@@ -18,14 +21,17 @@ import (
 // it can also be used to perform more advanced operations with a cluster.
 type Agent struct {
 	clientID             string
+	logCtx               logContext
 	bucketName           string
+	bucketLock           sync.Mutex
 	tlsConfig            *dynTLSConfig
 	initFn               memdInitFunc
 	defaultRetryStrategy RetryStrategy
 
-	pollerController *pollerController
-	kvMux            *kvMux
-	httpMux          *httpMux
+	pollerController   *pollerController
+	stopConfigProvider func()
+	kvMux              *kvMux
+	httpMux            *httpMux
 
 	cfgManager   *configManagementComponent
 	errMap       *errMapComponent
@@ -33,6 +39,7 @@ type Agent struct {
 	tracer       *tracerComponent
 	http         *httpComponent
 	diagnostics  *diagnosticsComponent
+	health       *healthComponent
 	crud         *crudComponent
 	observe      *observeComponent
 	stats        *statsComponent
@@ -41,6 +48,23 @@ type Agent struct {
 	search       *searchQueryComponent
 	views        *viewQueryComponent
 	zombieLogger *zombieLoggerComponent
+	timerWheel   *timerWheel
+	kvTimeouts   *kvTimeoutComponent
+
+	bootstrapStatus *bootstrapStatusComponent
+	connectTrigger  *connectTrigger
+	wireCapture     *wireCaptureComponent
+	resourceUnits   *resourceUnitCounters
+	opCounters      *operationCounters
+
+	bootstrapConfig *bootstrapConfigComponent
+
+	// scramSaltedPasswords lets the agent's connections reuse a salted password rather than deriving it again for
+	// every connection in the pool and every time that they reconnect.
+	scramSaltedPasswords *scram.SaltedPasswordCache
+
+	bucketRecreation *bucketRecreationComponent
+	manifestPoller   *collectionsManifestPollerComponent
 }
 
 // HTTPClient returns a pre-configured HTTP Client for communicating with
@@ -60,7 +84,7 @@ type AuthFunc func(client AuthClient, deadline time.Time, continueCb func(), com
 // authFunc wraps AuthFunc to provide a better to the user.
 type authFunc func() (completedCh chan BytesAndError, continueCh chan bool, err error)
 
-type authFuncHandler func(client AuthClient, deadline time.Time, mechanism AuthMechanism) authFunc
+type authFuncHandler func(client AuthClient, deadline time.Time, mechanism AuthMechanism, bucketName string) authFunc
 
 // CreateAgent creates an agent for performing normal operations.
 func CreateAgent(config *AgentConfig) (*Agent, error) {
@@ -75,9 +99,32 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 	logInfof("SDK Version: gocbcore/%s", goCbCoreVersionStr)
 	logInfof("Creating new agent: %+v", config)
 
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	clientID := config.ClientID
+	if clientID == "" {
+		clientID = formatCbUID(randomCbUID())
+	}
+
+	var seedConfig *cfgBucket
+	if config.SeedConfig != nil {
+		var err error
+		seedConfig, err = parseConfig(config.SeedConfig, config.SeedConfigSourceHost)
+		if err != nil {
+			return nil, wrapError(errInvalidArgument, "failed to parse seed config: "+err.Error())
+		}
+	}
+	if seedConfig == nil && config.ClusterConfigStore != nil {
+		seedConfig = loadStoredClusterConfig(config.ClusterConfigStore, config.BucketName,
+			logContext{{Key: "agent", Value: clientID}})
+	}
+
 	var tlsConfig *dynTLSConfig
 	if config.UseTLS {
-		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider)
+		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider, config.TLSSkipVerify,
+			makeVerifyPeerCertificate(config.TLSPinnedPublicKeys, config.TLSVerifyPeerCertificate))
 	}
 
 	httpIdleConnTimeout := 4500 * time.Millisecond
@@ -85,8 +132,15 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		httpIdleConnTimeout = config.HTTPIdleConnectionTimeout
 	}
 
-	httpCli := createHTTPClient(config.HTTPMaxIdleConns, config.HTTPMaxIdleConnsPerHost,
-		httpIdleConnTimeout, tlsConfig)
+	httpClientConfig := HTTPClientConfig{
+		MaxIdleConns:          config.HTTPMaxIdleConns,
+		MaxIdleConnsPerHost:   config.HTTPMaxIdleConnsPerHost,
+		IdleConnectionTimeout: httpIdleConnTimeout,
+		DisableHTTP2:          config.HTTPDisableHTTP2,
+	}
+	httpCli := createHTTPClient(httpClientConfig, tlsConfig, config.HTTPRoundTrippers)
+	serviceHTTPClis := createServiceHTTPClients(httpClientConfig, config.HTTPServiceClientConfigs, tlsConfig,
+		config.HTTPRoundTrippers)
 
 	tracer := config.Tracer
 	if tracer == nil {
@@ -95,7 +149,8 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 	tracerCmpt := newTracerComponent(tracer, config.BucketName, config.NoRootTraceSpans)
 
 	c := &Agent{
-		clientID:   formatCbUID(randomCbUID()),
+		clientID:   clientID,
+		logCtx:     logContext{{Key: "agent", Value: clientID}},
 		bucketName: config.BucketName,
 		tlsConfig:  tlsConfig,
 		initFn:     initFn,
@@ -104,11 +159,19 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		defaultRetryStrategy: config.DefaultRetryStrategy,
 
 		errMap: newErrMapManager(config.BucketName),
+
+		bootstrapStatus: newBootstrapStatusComponent(config.BootstrapAttemptCallback),
+		connectTrigger:  &connectTrigger{},
+		resourceUnits:   &resourceUnitCounters{},
+		opCounters:      &operationCounters{},
+
+		scramSaltedPasswords: scram.NewSaltedPasswordCache(),
 	}
+	c.wireCapture = newWireCaptureComponent(c.logCtx)
 
 	circuitBreakerConfig := config.CircuitBreakerConfig
 	auth := config.Auth
-	userAgent := config.UserAgent
+	userAgent := buildUserAgent(config.UserAgent, config.UserAgentComponents)
 	useMutationTokens := config.UseMutationTokens
 	disableDecompression := config.DisableDecompression
 	useCompression := config.UseCompression
@@ -127,6 +190,17 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 	}
 
 	serverWaitTimeout := 5 * time.Second
+	if config.ServerWaitTimeout > 0 {
+		serverWaitTimeout = config.ServerWaitTimeout
+	} else if config.ServerWaitTimeout < 0 || config.ReconnectBackoffConfig.Calculator != nil {
+		// The reconnect backoff replaces the fixed wait before redialing a server which failed to connect.
+		serverWaitTimeout = 0
+	}
+
+	deadPipelineRetry := config.DeadPipelineRetryConfig
+	if deadPipelineRetry.Calculator == nil {
+		deadPipelineRetry.Calculator = ExponentialBackoff(100*time.Millisecond, 5*time.Second, 2)
+	}
 
 	kvPoolSize := 1
 	if config.KvPoolSize > 0 {
@@ -146,6 +220,9 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 	confHTTPRedialPeriod := 10 * time.Second
 	if config.HTTPRedialPeriod > 0 {
 		confHTTPRedialPeriod = config.HTTPRedialPeriod
+	} else if config.HTTPRedialPeriod < 0 {
+		// A negative period disables redialing, the config stream is only reconnected if it fails.
+		confHTTPRedialPeriod = 0
 	}
 
 	confHTTPMaxWait := 5 * time.Second
@@ -168,9 +245,6 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 	}
 	if config.CompressionMinRatio > 0 {
 		compressionMinRatio = config.CompressionMinRatio
-		if compressionMinRatio >= 1.0 {
-			compressionMinRatio = 1.0
-		}
 	}
 	if c.defaultRetryStrategy == nil {
 		c.defaultRetryStrategy = newFailFastRetryStrategy()
@@ -191,12 +265,12 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		// The user has specified their own mechanisms and not using TLS so we check if they've set PLAIN.
 		for _, mech := range authMechanisms {
 			if mech == PlainAuthMechanism {
-				logWarnf("PLAIN sends credentials in plaintext, this will cause credential leakage on the network")
+				c.logCtx.logWarnf("PLAIN sends credentials in plaintext, this will cause credential leakage on the network")
 			}
 		}
 	}
 
-	authHandler := buildAuthHandler(auth)
+	authHandler := buildAuthHandler(auth, c.scramSaltedPasswords)
 
 	var httpEpList []string
 	for _, hostPort := range config.HTTPAddrs {
@@ -221,15 +295,35 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		go c.zombieLogger.Start()
 	}
 
+	c.timerWheel = newTimerWheel(config.KvTimerResolution, timerWheelDefaultSlots)
+	c.kvTimeouts = newKvTimeoutComponent(config.DefaultKvTimeout, c.timerWheel)
+
 	c.cfgManager = newConfigManager(
 		configManagerProperties{
 			NetworkType:  config.NetworkType,
 			UseSSL:       config.UseTLS,
 			SrcMemdAddrs: config.MemdAddrs,
 			SrcHTTPAddrs: httpEpList,
+
+			AddressTranslator: config.AddressTranslator,
+			NetworkResolver:   config.NetworkResolver,
+			KetamaHasher:      config.KetamaHasher,
+
+			ConfigStore: config.ClusterConfigStore,
+			BucketName:  config.BucketName,
+
+			LogContext: c.logCtx,
 		},
 	)
 
+	var bootstrapConfigHandler func(*memdClient, []byte)
+	var bootstrapConfigWanted func(string) bool
+	if !config.DisableConfigPolling {
+		c.bootstrapConfig = newBootstrapConfigComponent(c.cfgManager)
+		bootstrapConfigHandler = c.onBootstrapConfig
+		bootstrapConfigWanted = c.bootstrapConfig.Wanted
+	}
+
 	dialer := newMemdClientDialerComponent(
 		memdClientDialerProps{
 			ServerWaitTimeout:    serverWaitTimeout,
@@ -239,6 +333,15 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 			CompressionMinSize:   compressionMinSize,
 			CompressionMinRatio:  compressionMinRatio,
 			DisableDecompression: disableDecompression,
+			BootstrapStatus:      c.bootstrapStatus,
+			Dialer:               config.MemdDialer,
+			EventCallback:        config.EndpointEventCallback,
+			KeepAlive:            config.KeepAliveConfig,
+			LatencyProbe:         config.LatencyProbeConfig,
+			WireCapture:          c.wireCapture,
+			ResourceUnits:        c.resourceUnits,
+			OpCounters:           c.opCounters,
+			MaxInFlight:          config.KvMaxInFlight,
 		},
 		bootstrapProps{
 			HelloProps: helloProps{
@@ -246,16 +349,21 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 				MutationTokensEnabled:  useMutationTokens,
 				CompressionEnabled:     useCompression,
 				DurationsEnabled:       useDurations,
+				ResourceUnitsEnabled:   config.UseResourceUnits,
 				OutOfOrderEnabled:      useOutOfOrder,
 				JSONFeatureEnabled:     useJSONHello,
 				XErrorFeatureEnabled:   useXErrorHello,
 				SyncReplicationEnabled: useSyncReplicationHello,
+				ExtraFeatures:          config.EnableHelloFeatures,
+				DisabledFeatures:       config.DisableHelloFeatures,
 			},
 			Bucket:         c.bucketName,
 			UserAgent:      userAgent,
 			AuthMechanisms: authMechanisms,
 			AuthHandler:    authHandler,
 			ErrMapManager:  c.errMap,
+			ConfigHandler:  bootstrapConfigHandler,
+			ConfigWanted:   bootstrapConfigWanted,
 		},
 		circuitBreakerConfig,
 		c.zombieLogger,
@@ -267,7 +375,17 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		kvMuxProps{
 			QueueSize:          maxQueueSize,
 			PoolSize:           kvPoolSize,
+			Backpressure:       config.PipelineBackpressureConfig,
+			ReconnectBackoff:   config.ReconnectBackoffConfig,
+			MaxConnectionAge:   config.MaxConnectionAge,
+			Interceptors:       config.KVInterceptors,
+			RetryClassifier:    config.KVRetryClassifier,
+			StatusOverrides:    copyKVStatusRetryOverrides(config.KVStatusRetryOverrides),
+			ConnectTrigger:     c.connectTrigger,
 			CollectionsEnabled: useCollections,
+			OpCounters:         c.opCounters,
+			DeadPipelineRetry:  deadPipelineRetry,
+			LogContext:         c.logCtx,
 		},
 		c.cfgManager,
 		c.errMap,
@@ -278,6 +396,10 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		collectionIDProps{
 			MaxQueueSize:         config.MaxQueueSize,
 			DefaultRetryStrategy: c.defaultRetryStrategy,
+			// Collection ID lookups are also made on behalf of other operations so only explicit deadlines apply.
+			Timeouts:       newKvTimeoutComponent(0, c.timerWheel),
+			ConnectTrigger: c.connectTrigger,
+			LogContext:     c.logCtx,
 		},
 		c.kvMux,
 		c.tracer,
@@ -288,6 +410,12 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		httpComponentProps{
 			UserAgent:            userAgent,
 			DefaultRetryStrategy: c.defaultRetryStrategy,
+			ConnectTrigger:       c.connectTrigger,
+			ServiceClients:       serviceHTTPClis,
+			DisableCompression:   config.HTTPDisableCompression,
+			OpCounters:           c.opCounters,
+			BucketName:           c.bucketName,
+			LogContext:           c.logCtx,
 		},
 		httpCli,
 		c.httpMux,
@@ -295,17 +423,24 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 		c.tracer,
 	)
 
-	if len(config.MemdAddrs) == 0 && config.BucketName == "" {
-		// The http poller can't run without a bucket. We don't trigger an error for this case
-		// because AgentGroup users who use memcached buckets on non-default ports will end up here.
-		logDebugf("No bucket name specified and only http addresses specified, not running config poller")
-		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy, nil)
+	// This must be registered after the muxes so that the new topology is in use by the time we report it.
+	if config.TopologyChangeCallback != nil {
+		newTopologyEventsComponent(c.cfgManager, config.TopologyChangeCallback)
+	}
+	c.bucketRecreation = newBucketRecreationComponent(c.cfgManager, config.BucketRecreatedCallback, c.logCtx)
+
+	if config.DisableConfigPolling {
+		c.logCtx.logDebugf("Config polling is disabled, not running config poller")
+		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy, nil,
+			c.logCtx)
 	} else {
 		c.pollerController = newPollerController(
 			newCCCPConfigController(
 				cccpPollerProperties{
 					confCccpMaxWait:    confCccpMaxWait,
 					confCccpPollPeriod: confCccpPollPeriod,
+					bootstrapStatus:    c.bootstrapStatus,
+					logCtx:             c.logCtx,
 				},
 				c.kvMux,
 				c.cfgManager,
@@ -317,41 +452,70 @@ func createAgent(config *AgentConfig, initFn memdInitFunc) (*Agent, error) {
 					confHTTPRetryDelay:   confHTTPRetryDelay,
 					confHTTPRedialPeriod: confHTTPRedialPeriod,
 					confHTTPMaxWait:      confHTTPMaxWait,
+					logCtx:               c.logCtx,
 				},
 				c.httpMux,
 				c.cfgManager,
 			),
 			c.cfgManager,
+			c.logCtx,
 		)
-		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy, c.pollerController)
+		c.diagnostics = newDiagnosticsComponent(c.kvMux, c.httpMux, c.http, c.bucketName, c.defaultRetryStrategy,
+			c.pollerController, c.logCtx)
 	}
 
-	c.observe = newObserveComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.kvMux)
-	c.crud = newCRUDComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.errMap, c.kvMux)
-	c.stats = newStatsComponent(c.kvMux, c.defaultRetryStrategy, c.tracer)
+	c.health = newHealthComponent(c.diagnostics, c.cfgManager)
+	c.observe = newObserveComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.kvMux, c.kvTimeouts,
+		c.bucketRecreation)
+	c.crud = newCRUDComponent(c.collections, c.defaultRetryStrategy, c.tracer, c.errMap, c.kvMux, c.kvTimeouts,
+		config.UseGetCoalescing, c.logCtx)
+	c.stats = newStatsComponent(c.kvMux, c.defaultRetryStrategy, c.tracer, c.kvTimeouts)
 	c.n1ql = newN1QLQueryComponent(c.http, c.cfgManager, c.tracer)
 	c.analytics = newAnalyticsQueryComponent(c.http, c.tracer)
 	c.search = newSearchQueryComponent(c.http, c.tracer)
 	c.views = newViewQueryComponent(c.http, c.tracer)
-
-	// Kick everything off.
-	cfg := &routeConfig{
-		kvServerList: config.MemdAddrs,
-		mgmtEpList:   httpEpList,
-		revID:        -1,
+	if config.CollectionManifestPollInterval > 0 && config.CollectionManifestChangeCallback != nil {
+		c.manifestPoller = newCollectionsManifestPollerComponent(c.collections, config.CollectionManifestPollInterval,
+			config.CollectionManifestChangeCallback, c.logCtx)
 	}
 
-	c.httpMux.OnNewRouteConfig(cfg)
-	c.kvMux.OnNewRouteConfig(cfg)
+	c.connectTrigger.connectFn = func() {
+		// Kick everything off.
+		cfg := &routeConfig{
+			kvServerList: config.MemdAddrs,
+			mgmtEpList:   httpEpList,
+			revID:        -1,
+		}
+
+		c.httpMux.OnNewRouteConfig(cfg)
+		c.kvMux.OnNewRouteConfig(cfg)
 
-	if c.pollerController != nil {
-		go c.pollerController.Start()
+		if seedConfig != nil {
+			c.cfgManager.OnNewConfig(seedConfig)
+		}
+
+		if config.ClusterConfigProvider != nil {
+			c.stopConfigProvider = config.ClusterConfigProvider.WatchClusterConfig(c.onProvidedClusterConfig)
+		}
+
+		if c.pollerController != nil {
+			go c.pollerController.Start()
+		}
+
+		if c.manifestPoller != nil {
+			c.manifestPoller.Start()
+		}
+	}
+
+	if !config.LazyConnect {
+		c.connectTrigger.Trigger()
 	}
 
 	return c, nil
 }
 
-func createTLSConfig(auth AuthProvider, caProvider func() *x509.CertPool) *dynTLSConfig {
+func createTLSConfig(auth AuthProvider, caProvider func() *x509.CertPool, skipVerify bool,
+	verifyFn TLSVerifyPeerCertificateFunc) *dynTLSConfig {
 	return &dynTLSConfig{
 		BaseConfig: &tls.Config{
 			GetClientCertificate: func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
@@ -366,15 +530,23 @@ func createTLSConfig(auth AuthProvider, caProvider func() *x509.CertPool) *dynTL
 
 				return cert, nil
 			},
-			MinVersion: tls.VersionTLS12,
+			MinVersion:            tls.VersionTLS12,
+			InsecureSkipVerify:    skipVerify, // nolint: gosec
+			VerifyPeerCertificate: verifyFn,
 		},
 		Provider: caProvider,
 	}
 }
 
-func createHTTPClient(maxIdleConns, maxIdleConnsPerHost int, idleTimeout time.Duration, tlsConfig *dynTLSConfig) *http.Client {
+func createHTTPClient(config HTTPClientConfig, tlsConfig *dynTLSConfig,
+	middleware []HTTPRoundTripperMiddleware) *http.Client {
+	connectTimeout := 30 * time.Second
+	if config.ConnectTimeout > 0 {
+		connectTimeout = config.ConnectTimeout
+	}
+
 	httpDialer := &net.Dialer{
-		Timeout:   30 * time.Second,
+		Timeout:   connectTimeout,
 		KeepAlive: 30 * time.Second,
 	}
 
@@ -391,7 +563,7 @@ func createHTTPClient(maxIdleConns, maxIdleConnsPerHost int, idleTimeout time.Du
 
 	httpTransport := &http.Transport{
 		TLSClientConfig:   httpBaseTLSConfig,
-		ForceAttemptHTTP2: true,
+		ForceAttemptHTTP2: !config.DisableHTTP2,
 
 		Dial: func(network, addr string) (net.Conn, error) {
 			return httpDialer.Dial(network, addr)
@@ -413,13 +585,19 @@ func createHTTPClient(maxIdleConns, maxIdleConnsPerHost int, idleTimeout time.Du
 			tlsConn := tls.Client(tcpConn, srvTLSConfig)
 			return tlsConn, nil
 		},
-		MaxIdleConns:        maxIdleConns,
-		MaxIdleConnsPerHost: maxIdleConnsPerHost,
-		IdleConnTimeout:     idleTimeout,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		IdleConnTimeout:       config.IdleConnectionTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+	}
+	if config.DisableHTTP2 {
+		// A non-nil empty map is how the standard library is told not to upgrade connections to HTTP/2.
+		httpTransport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
 	}
 
 	httpCli := &http.Client{
-		Transport: httpTransport,
+		Transport: wrapHTTPTransport(httpTransport, middleware),
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			// All that we're doing here is setting auth on any redirects.
 			// For that reason we can just pull it off the oldest (first) request.
@@ -440,9 +618,28 @@ func createHTTPClient(maxIdleConns, maxIdleConnsPerHost int, idleTimeout time.Du
 	return httpCli
 }
 
-func buildAuthHandler(auth AuthProvider) authFuncHandler {
-	return func(client AuthClient, deadline time.Time, mechanism AuthMechanism) authFunc {
-		creds, err := getKvAuthCreds(auth, client.Address())
+// createServiceHTTPClients creates a dedicated client for each service with its own transport settings.
+func createServiceHTTPClients(defaults HTTPClientConfig, configs map[ServiceType]HTTPClientConfig,
+	tlsConfig *dynTLSConfig, middleware []HTTPRoundTripperMiddleware) map[ServiceType]*http.Client {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	clis := make(map[ServiceType]*http.Client, len(configs))
+	for service, config := range configs {
+		clis[service] = createHTTPClient(config.withDefaults(defaults), tlsConfig, middleware)
+	}
+
+	return clis
+}
+
+func buildAuthHandler(auth AuthProvider, saltedPasswords *scram.SaltedPasswordCache) authFuncHandler {
+	return func(client AuthClient, deadline time.Time, mechanism AuthMechanism, bucketName string) authFunc {
+		if mechanism == GSSAPIAuthMechanism {
+			return buildGSSAPIAuthFunc(auth, client, deadline, bucketName)
+		}
+
+		creds, err := getKvAuthCreds(auth, client.Address(), bucketName)
 		if err != nil {
 			return nil
 		}
@@ -452,7 +649,7 @@ func buildAuthHandler(auth AuthProvider) authFuncHandler {
 				continueCh := make(chan bool, 1)
 				completedCh := make(chan BytesAndError, 1)
 				hasContinued := int32(0)
-				callErr := saslMethod(mechanism, creds.Username, creds.Password, client, deadline, func() {
+				callErr := saslMethod(mechanism, creds.Username, creds.Password, client, deadline, saltedPasswords, func() {
 					// hasContinued should never be 1 here but let's guard against it.
 					if atomic.CompareAndSwapInt32(&hasContinued, 0, 1) {
 						continueCh <- true
@@ -478,14 +675,74 @@ func buildAuthHandler(auth AuthProvider) authFuncHandler {
 	}
 }
 
+func buildGSSAPIAuthFunc(auth AuthProvider, client AuthClient, deadline time.Time, bucketName string) authFunc {
+	return func() (chan BytesAndError, chan bool, error) {
+		continueCh := make(chan bool, 1)
+		completedCh := make(chan BytesAndError, 1)
+		completed := func(err error) {
+			continueCh <- err == nil
+			completedCh <- BytesAndError{Err: err}
+		}
+
+		// Failures to set up the exchange are reported as auth failures so that the next mechanism can be tried.
+		gssAuth, ok := auth.(GSSAPIAuthProvider)
+		if !ok {
+			completed(wrapError(errNoSupportedMechanisms, "auth provider does not support gssapi"))
+			return completedCh, continueCh, nil
+		}
+
+		multiStepClient, ok := client.(MultiStepAuthClient)
+		if !ok {
+			completed(wrapError(errNoSupportedMechanisms, "auth client does not support multi-step auth"))
+			return completedCh, continueCh, nil
+		}
+
+		secCtx, err := gssAuth.GSSAPISecurityContext(AuthCredsRequest{
+			Service:    MemdService,
+			Endpoint:   client.Address(),
+			BucketName: bucketName,
+		})
+		if err != nil {
+			completed(err)
+			return completedCh, continueCh, nil
+		}
+
+		callErr := SaslAuthGSSAPI(secCtx, "", multiStepClient, deadline, completed)
+		if callErr != nil {
+			return nil, nil, callErr
+		}
+
+		return completedCh, continueCh, nil
+	}
+}
+
 // Close shuts down the agent, disconnecting from all servers and failing
 // any outstanding operations with ErrShutdown.
 func (agent *Agent) Close() error {
+	if !agent.connectTrigger.Disable() {
+		// We never connected so there are no connections or pollers to shut down.
+		if agent.zombieLogger != nil {
+			agent.zombieLogger.Stop()
+		}
+
+		agent.scramSaltedPasswords.Clear()
+		agent.http.Close()
+		return nil
+	}
+
+	if agent.stopConfigProvider != nil {
+		agent.stopConfigProvider()
+	}
+
 	poller := agent.pollerController
 	if poller != nil {
 		poller.Stop()
 	}
 
+	if agent.manifestPoller != nil {
+		agent.manifestPoller.Stop()
+	}
+
 	routeCloseErr := agent.kvMux.Close()
 
 	if agent.zombieLogger != nil {
@@ -502,12 +759,21 @@ func (agent *Agent) Close() error {
 		}
 	}
 
+	agent.cfgManager.WaitForStoredConfigs()
+	agent.scramSaltedPasswords.Clear()
+
 	// Close the transports so that they don't hold open goroutines.
 	agent.http.Close()
 
 	return routeCloseErr
 }
 
+// Connect begins connecting to the cluster if the agent was created with LazyConnect enabled and has not yet
+// connected. This is a no-op otherwise.
+func (agent *Agent) Connect() {
+	agent.connectTrigger.Trigger()
+}
+
 // ClientID returns the unique id for this agent
 func (agent *Agent) ClientID() string {
 	return agent.clientID
@@ -548,13 +814,32 @@ func (agent *Agent) HasCollectionsSupport() bool {
 	return agent.kvMux.SupportsCollections()
 }
 
+// ClusterUUID returns the UUID of the cluster that the Agent is connected to, taken from the most recent cluster
+// config. It is empty until a config has been received or if the cluster does not include it in its config.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ClusterUUID() string {
+	return agent.kvMux.ClusterUUID()
+}
+
+// ClusterName returns the name of the cluster that the Agent is connected to, see ClusterUUID.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ClusterName() string {
+	return agent.kvMux.ClusterName()
+}
+
 // IsSecure returns whether this client is connected via SSL.
 func (agent *Agent) IsSecure() bool {
 	return agent.tlsConfig != nil
 }
 
-// UsingGCCCP returns whether or not the Agent is currently using GCCCP polling.
+// UsingGCCCP returns whether or not the Agent is currently using GCCCP polling. This is false once GCCCP polling has
+// failed and the agent has fallen back to polling for cluster config over HTTP, for example after the cluster was
+// downgraded. Selecting a bucket switches the agent back to polling using CCCP for that bucket's config.
 func (agent *Agent) UsingGCCCP() bool {
+	if agent.pollerController != nil && !agent.pollerController.UsingGCCCP() {
+		return false
+	}
+
 	return agent.kvMux.SupportsGCCCP()
 }
 
@@ -572,6 +857,7 @@ func (agent *Agent) HasSeenConfig() (bool, error) {
 
 // WaitUntilReady returns whether or not the Agent has seen a valid cluster config.
 func (agent *Agent) WaitUntilReady(deadline time.Time, opts WaitUntilReadyOptions, cb WaitUntilReadyCallback) (PendingOp, error) {
+	agent.connectTrigger.Trigger()
 	return agent.diagnostics.WaitUntilReady(deadline, opts, cb)
 }
 
@@ -583,9 +869,206 @@ func (agent *Agent) ConfigSnapshot() (*ConfigSnapshot, error) {
 // BucketName returns the name of the bucket that the agent is using, if any.
 // Uncommitted: This API may change in the future.
 func (agent *Agent) BucketName() string {
+	agent.bucketLock.Lock()
+	defer agent.bucketLock.Unlock()
 	return agent.bucketName
 }
 
+// SelectBucket switches the agent to a different bucket without the agent needing to be recreated. All kv
+// connections are reconnected in order to select the new bucket, kv operations which were dispatched against the
+// previous bucket and have not yet completed will fail with ErrShutdown. Operations should not be dispatched whilst
+// the switch is taking place. This is only supported whilst config is being fetched using CCCP, otherwise
+// ErrUnsupportedOperation is returned.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) SelectBucket(bucketName string) error {
+	agent.bucketLock.Lock()
+	defer agent.bucketLock.Unlock()
+
+	if agent.bucketName == bucketName {
+		return nil
+	}
+
+	if agent.pollerController == nil {
+		return wrapError(errUnsupportedOperation, "bucket selection requires the cccp config poller to be in use")
+	}
+
+	agent.connectTrigger.Trigger()
+
+	// We pause the poller so that we can't receive a config for the old bucket once we've switched.
+	agent.pollerController.Pause(true)
+	defer agent.pollerController.Pause(false)
+
+	err := agent.pollerController.SetBucketName(bucketName)
+	if err != nil {
+		return err
+	}
+
+	err = agent.kvMux.SelectBucket(bucketName)
+	if err != nil {
+		return err
+	}
+
+	agent.cfgManager.ResetConfig()
+	agent.cfgManager.SetBucketName(bucketName)
+	agent.collections.ResetCollectionIDs()
+	agent.errMap.SetBucketName(bucketName)
+	agent.diagnostics.SetBucketName(bucketName)
+	agent.http.SetBucketName(bucketName)
+	agent.bucketName = bucketName
+
+	agent.logCtx.logInfof("Agent switched to bucket %s", logMetaData(bucketName))
+
+	return nil
+}
+
+// UnselectBucket returns the agent to cluster level (GCCCP) mode, tearing down any bucket specific routing.
+// See SelectBucket for how in progress operations are handled.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) UnselectBucket() error {
+	return agent.SelectBucket("")
+}
+
+// BootstrapStatus returns the result of the most recent bootstrap attempt made against each node, along with
+// the number of attempts made. This can be used to find out why an agent is failing to become ready.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) BootstrapStatus() BootstrapStatus {
+	return agent.bootstrapStatus.Status()
+}
+
+// QuarantinedServers returns the kv servers which failed to connect or bootstrap, and which will not be dialed again
+// until their quarantine expires.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) QuarantinedServers() []QuarantinedServer {
+	return agent.kvMux.dialer.QuarantinedServers()
+}
+
+// QuarantineServer prevents the kv server at address, in host:port form, from being dialed for the given duration.
+// This can be used to avoid reconnecting to a node which is under maintenance. It replaces any existing quarantine.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) QuarantineServer(address string, duration time.Duration) {
+	agent.kvMux.dialer.SetQuarantine(address, time.Now().Add(duration))
+}
+
+// ClearServerQuarantine allows the kv server at address, in host:port form, to be dialed again immediately.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ClearServerQuarantine(address string) {
+	agent.kvMux.dialer.SetQuarantine(address, time.Time{})
+}
+
+// RedetectNetworkType discards the detected network type and detects it again using the most recently received
+// cluster config. This can be used after topology changes which may have changed the network that should be used.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) RedetectNetworkType() {
+	agent.cfgManager.RedetectNetworkType()
+}
+
+// ErrorMap returns the error map fetched from the server, which describes the status codes that the server may
+// respond with along with their attributes and advised retry behavior. nil is returned if no connection has fetched
+// the error map yet.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ErrorMap() *ErrorMap {
+	return agent.errMap.ErrorMap()
+}
+
+// FreezeConfig stops new cluster configs from being applied until UnfreezeConfig is called, the agent carries on
+// polling for configs but keeps using the topology that it currently has. This is intended to allow tests to
+// deterministically run against a pinned topology. If the agent has not yet received a config then it cannot connect
+// to the cluster until it is unfrozen.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) FreezeConfig() {
+	agent.cfgManager.Freeze()
+}
+
+// UnfreezeConfig allows new cluster configs to be applied again, the most recently received config is applied
+// immediately if it is newer than the one in use.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) UnfreezeConfig() {
+	agent.cfgManager.Unfreeze()
+}
+
+// EndpointLatencies returns the latency measured for each kv connection, along with a score which can be used to
+// detect nodes which are degraded but not down. Scores are not used for routing, they are for operators to act on.
+// Latencies are measured by the probes configured in AgentConfig.LatencyProbeConfig, as well as by keepalives and
+// circuit breaker canaries.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) EndpointLatencies() []EndpointLatency {
+	return agent.kvMux.EndpointLatencies()
+}
+
+// StartWireCapture starts recording the memd packets sent and received on every kv connection to a ring buffer, for
+// debugging protocol issues. Only one capture can be in progress at a time.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) StartWireCapture(opts WireCaptureOptions) error {
+	return agent.wireCapture.Start(opts)
+}
+
+// StopWireCapture stops the current wire capture, returning the packets which were captured.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) StopWireCapture() []CapturedPacket {
+	return agent.wireCapture.Stop()
+}
+
+// WireCapturePackets returns the packets captured so far by the current, or most recent, wire capture.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) WireCapturePackets() []CapturedPacket {
+	return agent.wireCapture.Packets()
+}
+
+// StartCompositeOperation starts a composite operation, such as a query which is to be consistent with the mutations
+// that precede it. Passing the TraceContext of the returned operation to each of its KV and HTTP operations parents
+// all of their spans under a single span and tags them with a shared operation ID.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) StartCompositeOperation(operationName string, parentContext RequestSpanContext) *CompositeOperation {
+	return agent.tracer.StartCompositeOperation(operationName, parentContext)
+}
+
+// WatchMgmtStream starts watching a streaming management endpoint, such as /poolsStreaming/default, invoking the
+// callback with every update received. If the connection to a node fails then the watcher reconnects to the next
+// node, until the watcher is closed.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) WatchMgmtStream(opts WatchMgmtStreamOptions, cb MgmtStreamCallback) (*MgmtStreamWatcher, error) {
+	return newMgmtStreamWatcher(opts, agent.http, agent.httpMux, cb, agent.logCtx)
+}
+
+// ReconnectOptions are the options available to the Reconnect operation.
+type ReconnectOptions struct {
+	// Rolling reconnects the kv connections one node at a time, waiting for each node to reconnect before moving
+	// on to the next.
+	Rolling bool
+
+	// Deadline is how long to wait for nodes to reconnect when Rolling is set, it must be provided when Rolling is set.
+	Deadline time.Time
+}
+
+// Reconnect closes and re-establishes all kv connections using the current config. This can be used to recover from
+// state held by load balancers or firewalls being reset without recreating the agent. Operations in flight on the
+// closed connections are handled as if the connection had dropped.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) Reconnect(opts ReconnectOptions) error {
+	return agent.kvMux.Reconnect(opts.Rolling, opts.Deadline)
+}
+
+// onBootstrapConfig handles the config fetched whilst a kv connection was bootstrapping, saving a round trip compared
+// to waiting for the config poller to fetch it once the connection is ready.
+func (agent *Agent) onBootstrapConfig(client *memdClient, config []byte) {
+	hostName, err := hostFromHostPort(client.Address())
+	if err != nil {
+		agent.logCtx.logWarnf("Failed to parse source address of bootstrap config. %s", err)
+		return
+	}
+
+	bk, err := parseConfig(config, hostName)
+	if err != nil {
+		agent.logCtx.logWarnf("Failed to parse bootstrap config. %v", err)
+		return
+	}
+	agent.bootstrapConfig.Received(client.Address())
+
+	// The connection is still being bootstrapped by its pipeline so the config is applied separately, applying it
+	// may require the pipeline to be taken over.
+	go agent.cfgManager.OnNewConfig(bk)
+}
+
 func (agent *Agent) onBootstrapFail(err error) {
 	// If this error is a legitimate fallback reason then we should immediately start the http poller.
 	if agent.pollerController != nil && isPollingFallbackError(err) {
@@ -4,11 +4,11 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"strconv"
 	"time"
 
 	"github.com/couchbase/gocbcore/v9/connstr"
+	"github.com/couchbase/gocbcore/v9/memd"
 )
 
 func parseDurationOrInt(valStr string) (time.Duration, error) {
@@ -35,11 +35,49 @@ type AgentConfig struct {
 	NetworkType string
 	Auth        AuthProvider
 
+	// ClientID is the identifier sent to the server in HELLO as the prefix of each connection ID, a random ID is used
+	// if it is not set. The server truncates connection IDs so this should be kept short.
+	ClientID string
+
+	// UserAgentComponents identify the application or library embedding the SDK, they are appended to UserAgent as
+	// product/version and sent both in HELLO and on HTTP requests.
+	UserAgentComponents []UserAgentComponent
+
+	// AddressTranslator, if set, rewrites the address of every node endpoint found in cluster configs.
+	// Volatile: This API is subject to change at any time.
+	AddressTranslator AddressTranslator
+
+	// NetworkResolver, if set, chooses the network type used to connect to each node in a cluster config, overriding
+	// the configured or detected network type on a per node basis.
+	// Volatile: This API is subject to change at any time.
+	NetworkResolver NetworkResolver
+
+	// KetamaHasher, if set, controls how keys are distributed between the servers of memcached buckets. This can be
+	// used to distribute keys in the same way as another client, avoiding redistributing the cache when migrating.
+	// Volatile: This API is subject to change at any time.
+	KetamaHasher KetamaHasher
+
+	// TLSRootCAProvider returns the pool of CAs used to verify server certificates, the system trust store is used
+	// if it is not set or returns nil.
 	TLSRootCAProvider func() *x509.CertPool
 
-	UseMutationTokens      bool
-	UseCompression         bool
-	UseDurations           bool
+	// TLSSkipVerify disables verification of server certificates, pinned public keys are still checked.
+	TLSSkipVerify bool
+
+	// TLSVerifyPeerCertificate, if set, is invoked during every TLS handshake after normal certificate verification.
+	TLSVerifyPeerCertificate TLSVerifyPeerCertificateFunc
+
+	// TLSPinnedPublicKeys contains the SHA-256 hashes of certificate SubjectPublicKeyInfos which the server is
+	// permitted to present, if set then at least one certificate in the chain presented by the server must match.
+	TLSPinnedPublicKeys [][]byte
+
+	UseMutationTokens bool
+	UseCompression    bool
+	UseDurations      bool
+	// UseResourceUnits requests that the server reports the read and write units consumed by each operation, on
+	// metered deployments which support it.
+	// Volatile: This API is subject to change at any time.
+	UseResourceUnits       bool
 	DisableDecompression   bool
 	UseOutOfOrderResponses bool
 	DisableXErrors         bool
@@ -47,8 +85,23 @@ type AgentConfig struct {
 	DisableJSONHello            bool
 	DisableSyncReplicationHello bool
 
+	// EnableHelloFeatures is a list of features to request during HELLO negotiation in addition to those derived
+	// from the other options, e.g. memd.FeatureDuplex.
+	// Volatile: This API is subject to change at any time.
+	EnableHelloFeatures []memd.HelloFeature
+
+	// DisableHelloFeatures is a list of features which will never be requested during HELLO negotiation, this takes
+	// precedence over every other option.
+	// Volatile: This API is subject to change at any time.
+	DisableHelloFeatures []memd.HelloFeature
+
 	UseCollections bool
 
+	// UseGetCoalescing enables satisfying concurrent Get operations for the same document with a single request.
+	// When enabled the GetResult passed to callbacks may be shared between callers and must not be modified. Gets
+	// are only coalesced when they use the same RetryStrategy pointer, or none at all.
+	UseGetCoalescing bool
+
 	CompressionMinSize  int
 	CompressionMinRatio float64
 
@@ -61,13 +114,155 @@ type AgentConfig struct {
 	ConnectTimeout   time.Duration
 	KVConnectTimeout time.Duration
 
+	// DefaultKvTimeout is the timeout applied to kv operations which do not specify a Deadline. If zero then
+	// operations without a Deadline are never timed out.
+	DefaultKvTimeout time.Duration
+
+	// KvTimerResolution is the tick interval of the timer wheel used to time out kv operations, operations may
+	// time out up to this long after their deadline. Defaults to 10ms.
+	// Volatile: This API is subject to change at any time.
+	KvTimerResolution time.Duration
+
+	// LazyConnect defers connecting to the cluster until the first operation is dispatched or Connect is called.
+	LazyConnect bool
+
+	// SeedConfig is a cluster config, in the JSON format returned by the server, which is applied as soon as the agent
+	// connects so that requests can be routed before a config has been fetched from the cluster.
+	// Volatile: This API is subject to change at any time.
+	SeedConfig []byte
+
+	// SeedConfigSourceHost is the host used to replace any $HOST placeholders within SeedConfig.
+	// Volatile: This API is subject to change at any time.
+	SeedConfigSourceHost string
+
+	// ClusterConfigProvider, if set, supplies cluster configs alongside those fetched by the config pollers.
+	// Volatile: This API is subject to change at any time.
+	ClusterConfigProvider ClusterConfigProvider
+
+	// ClusterConfigStore, if set, is used to persist the most recent cluster config. When no SeedConfig is given the
+	// stored config is used in its place, until a config has been fetched from the cluster.
+	// Volatile: This API is subject to change at any time.
+	ClusterConfigStore ClusterConfigStore
+
+	// DisableConfigPolling prevents the agent from fetching cluster configs itself, configs must be supplied using
+	// SeedConfig or ClusterConfigProvider instead. Configs received in not my vbucket responses are still applied.
+	// Bucket selection is not supported when polling is disabled.
+	// Volatile: This API is subject to change at any time.
+	DisableConfigPolling bool
+
+	// BootstrapAttemptCallback is invoked for every attempt made to bootstrap against a node, see also
+	// Agent.BootstrapStatus.
+	BootstrapAttemptCallback BootstrapAttemptCallback
+
+	// EndpointEventCallback is invoked whenever a kv connection is established, authenticated, selects a bucket or
+	// is dropped.
+	// Volatile: This API is subject to change at any time.
+	EndpointEventCallback EndpointEventCallback
+
+	// TopologyChangeCallback is invoked whenever a new cluster config adds or removes kv nodes or moves vbuckets
+	// between them.
+	// Volatile: This API is subject to change at any time.
+	TopologyChangeCallback TopologyChangeCallback
+
+	// BucketRecreatedCallback is invoked whenever the bucket is found to have been deleted and recreated with the same
+	// name, outstanding ObserveVb requests fail with ErrBucketRecreated when this happens.
+	// Volatile: This API is subject to change at any time.
+	BucketRecreatedCallback BucketRecreatedCallback
+
+	// CollectionManifestPollInterval, if set, is how often the collection manifest is fetched so that
+	// CollectionManifestChangeCallback can be invoked whenever scopes or collections are created, dropped or altered.
+	// Requires UseCollections.
+	// Volatile: This API is subject to change at any time.
+	CollectionManifestPollInterval time.Duration
+
+	// CollectionManifestChangeCallback is invoked whenever the collection manifest fetched by the poller enabled with
+	// CollectionManifestPollInterval differs from the previous one.
+	// Volatile: This API is subject to change at any time.
+	CollectionManifestChangeCallback CollectionManifestChangeCallback
+
+	// ServerWaitTimeout is how long a kv server is quarantined for after failing to connect or bootstrap, during
+	// which it will not be dialed. Defaults to 5 seconds, or none when ReconnectBackoffConfig.Calculator is set.
+	// A negative value disables quarantining.
+	// Volatile: This API is subject to change at any time.
+	ServerWaitTimeout time.Duration
+
 	KvPoolSize   int
 	MaxQueueSize int
 
+	// KvMaxInFlight, if set, is the maximum number of requests which may be awaiting a response on each kv
+	// connection. Further requests wait in the queue until a response is received, so that a slow node cannot
+	// accumulate an unbounded number of outstanding requests. Defaults to no limit.
+	// Volatile: This API is subject to change at any time.
+	KvMaxInFlight int
+
+	// MemdDialer, if set, is used in place of the default TCP dialer to open kv connections. This allows the
+	// transport to be swapped out, for example for the in-memory server in the memdmock package.
+	// Volatile: This API is subject to change at any time.
+	MemdDialer MemdDialFunc
+
+	// PipelineBackpressureConfig controls how operations are handled when they are dispatched to a full pipeline.
+	PipelineBackpressureConfig PipelineBackpressureConfig
+
+	// ReconnectBackoffConfig controls how long to wait between consecutive failed attempts to connect to a node.
+	// Volatile: This API is subject to change at any time.
+	ReconnectBackoffConfig ReconnectBackoffConfig
+
+	// DeadPipelineRetryConfig controls how operations which could not be routed to a node are re-dispatched.
+	// Volatile: This API is subject to change at any time.
+	DeadPipelineRetryConfig DeadPipelineRetryConfig
+
+	// MaxConnectionAge, if set, is the maximum lifetime of a kv connection after which it is closed and a new one
+	// dialled. Lifetimes are staggered by up to a quarter of this value so that connections are not all recycled at
+	// once, and requests already in flight are given time to complete before the connection is closed.
+	// Volatile: This API is subject to change at any time.
+	MaxConnectionAge time.Duration
+
+	// KeepAliveConfig controls the sending of NOOPs on idle kv connections to detect connections which have died.
+	// Volatile: This API is subject to change at any time.
+	KeepAliveConfig KeepAliveConfig
+
+	// LatencyProbeConfig controls the periodic probing of kv connections to measure endpoint latencies, see
+	// Agent.EndpointLatencies.
+	// Volatile: This API is subject to change at any time.
+	LatencyProbeConfig LatencyProbeConfig
+
+	// KVInterceptors is a chain of interceptors which see every kv request before it is dispatched and every
+	// response before the operation callback is invoked.
+	// Volatile: This API is subject to change at any time.
+	KVInterceptors []KVInterceptor
+
+	// KVRetryClassifier, if set, is invoked whenever a kv request fails and can cause it to be retried for an
+	// application defined RetryReason, see RegisterRetryReason.
+	// Volatile: This API is subject to change at any time.
+	KVRetryClassifier KVRetryClassifier
+
+	// KVStatusRetryOverrides changes how kv requests which fail with specific statuses are retried, taking precedence
+	// over the error map and the agent's own handling of those statuses.
+	// Volatile: This API is subject to change at any time.
+	KVStatusRetryOverrides map[memd.StatusCode]KVStatusRetryOverride
+
 	HTTPMaxIdleConns          int
 	HTTPMaxIdleConnsPerHost   int
 	HTTPIdleConnectionTimeout time.Duration
 
+	// HTTPDisableHTTP2 prevents HTTP/2 from being negotiated for requests to any service.
+	HTTPDisableHTTP2 bool
+
+	// HTTPDisableCompression stops the query, analytics and search services from being asked to gzip or
+	// deflate their responses.
+	// Volatile: This API is subject to change at any time.
+	HTTPDisableCompression bool
+
+	// HTTPServiceClientConfigs overrides the HTTP transport settings used for individual services, each service
+	// listed gets its own connection pool.
+	// Volatile: This API is subject to change at any time.
+	HTTPServiceClientConfigs map[ServiceType]HTTPClientConfig
+
+	// HTTPRoundTrippers is a chain of middleware which wraps the transport of every HTTP client created by the agent,
+	// the first middleware in the chain being the outermost.
+	// Volatile: This API is subject to change at any time.
+	HTTPRoundTrippers []HTTPRoundTripperMiddleware
+
 	// Uncommitted: Tracer API may change in the future.
 	Tracer           RequestTracer
 	NoRootTraceSpans bool
@@ -79,8 +274,12 @@ type AgentConfig struct {
 	ZombieLoggerInterval   time.Duration
 	ZombieLoggerSampleSize int
 
-	// AuthMechanisms is the list of mechanisms that the SDK can use to attempt authentication.
+	// AuthMechanisms is the list of mechanisms that the SDK can use to attempt authentication. GSSAPIAuthMechanism
+	// is only used when listed here and requires that Auth implements GSSAPIAuthProvider.
 	AuthMechanisms []AuthMechanism
+
+	// tlsRootCAPaths are the paths which TLSRootCAProvider was loaded from, if any, so that they can be serialized.
+	tlsRootCAPaths []string
 }
 
 func (config *AgentConfig) redacted() interface{} {
@@ -110,8 +309,12 @@ func (config *AgentConfig) redacted() interface{} {
 // Supported options are:
 //   bootstrap_on (bool) - Specifies what protocol to bootstrap on (cccp, http).
 //   ca_cert_path (string) - Specifies the path to a CA certificate.
+//   tls_pinned_public_key (string) - A base64 encoded SHA-256 hash of a SubjectPublicKeyInfo to pin, may be repeated.
+//   tls_skip_verify (bool) - Whether to skip verification of server certificates.
 //   network (string) - The network type to use.
 //   kv_connect_timeout (duration) - Maximum period to attempt to connect to cluster in ms.
+//   kv_timeout (duration) - Default timeout for kv operations which do not specify a deadline.
+//   kv_timer_resolution (duration) - The accuracy with which kv operation deadlines are enforced.
 //   config_poll_interval (duration) - Period to wait between CCCP config polling in ms.
 //   config_poll_timeout (duration) - Maximum period of time to wait for a CCCP request.
 //   compression (bool) - Whether to enable network-wise compression of documents.
@@ -121,17 +324,47 @@ func (config *AgentConfig) redacted() interface{} {
 //   max_idle_http_connections (int) - Maximum number of idle http connections in the pool.
 //   max_perhost_idle_http_connections (int) - Maximum number of idle http connections in the pool per host.
 //   idle_http_connection_timeout (duration) - Maximum length of time for an idle connection to stay in the pool in ms.
+//   http_disable_compression (bool) - Whether to stop query, analytics and search responses being compressed.
 //   orphaned_response_logging (bool) - Whether to enable orphaned response logging.
 //   orphaned_response_logging_interval (duration) - How often to print the orphan log records.
 //   orphaned_response_logging_sample_size (int) - The maximum number of orphan log records to track.
 //   dcp_priority (int) - Specifies the priority to request from the Cluster when connecting for DCP.
 //   enable_dcp_expiry (bool) - Whether to enable the feature to distinguish between explicit delete and expired delete on DCP.
-//   http_redial_period (duration) - The maximum length of time for the HTTP poller to stay connected before reconnecting.
+//   http_redial_period (duration) - The maximum length of time for the HTTP poller to stay connected before reconnecting, negative to stay connected.
 //   http_retry_delay (duration) - The length of time to wait between HTTP poller retries if connecting fails.
 //   kv_pool_size (int) - The number of connections to create to each kv node.
 //   max_queue_size (int) - The maximum number of requests that can be queued for sending per connection.
+//   kv_max_in_flight (int) - The maximum number of requests awaiting a response per kv connection.
+//   kv_backpressure (string) - How to handle requests dispatched to a full queue (fail_fast, block).
+//   kv_backpressure_max_wait (duration) - Maximum period to block for when kv_backpressure=block.
+//   server_wait_timeout (duration) - How long to wait before redialing a kv server which failed to connect.
+//   collection_manifest_poll_interval (duration) - How often to poll the collection manifest for changes.
+//   max_connection_age (duration) - Maximum lifetime of a kv connection before it is recycled.
+//   kv_keepalive_interval (duration) - How long a kv connection may be idle before a NOOP is sent on it.
+//   kv_keepalive_timeout (duration) - How long to wait for a keepalive NOOP before closing the connection.
+//   kv_latency_probe_interval (duration) - How often to probe the latency of kv connections.
 //   unordered_execution_enabled (bool) - Whether to enabled the "out of order responses" feature.
+//   config_profile (string) - A config profile to apply before any other options, overridden by GOCBCORE_CONFIG_PROFILE.
+// Unrecognised options are ignored and logged as warnings, see FromConnStrWithOptions.
 func (config *AgentConfig) FromConnStr(connStr string) error {
+	_, err := config.FromConnStrWithOptions(connStr, FromConnStrOptions{})
+	return err
+}
+
+// FromConnStrWithOptions populates the AgentConfig with information from a
+// Couchbase Connection String, see FromConnStr for the supported options.
+// A warning is returned for each unrecognised option, unless opts.Strict is set in
+// which case an error is returned and the AgentConfig is left unmodified.
+func (config *AgentConfig) FromConnStrWithOptions(connStr string, opts FromConnStrOptions) ([]ConnStrWarning, error) {
+	warnings, err := checkConnStrOptions(connStr, agentConnStrOptions, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, config.fromConnStr(connStr)
+}
+
+func (config *AgentConfig) fromConnStr(connStr string) error {
 	baseSpec, err := connstr.Parse(connStr)
 	if err != nil {
 		return err
@@ -143,11 +376,7 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 	}
 
 	fetchOption := func(name string) (string, bool) {
-		optValue := spec.Options[name]
-		if len(optValue) == 0 {
-			return "", false
-		}
-		return optValue[len(optValue)-1], true
+		return lastOptionValue(spec.Options, name)
 	}
 
 	// Grab the resolved hostnames into a set of string arrays
@@ -183,34 +412,62 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 	config.MemdAddrs = memdHosts
 	config.HTTPAddrs = httpHosts
 
-	if spec.UseSsl {
-		cacertpaths := spec.Options["ca_cert_path"]
+	if spec.Bucket != "" {
+		config.BucketName = spec.Bucket
+	}
+
+	// A profile set in the environment is applied here, before the other options, as FromEnv is applied after
+	// them and would otherwise overwrite them with the settings from the profile.
+	if profile, ok := envConfigProfile(); ok {
+		options := make(map[string][]string, len(spec.Options)+1)
+		for name, values := range spec.Options {
+			options[name] = values
+		}
+		options["config_profile"] = []string{profile}
+		spec.Options = options
+	}
+
+	return config.applyOptions(spec.Options, spec.UseSsl)
+}
 
-		if len(cacertpaths) > 0 {
-			roots := x509.NewCertPool()
+// applyOptions applies the options from a connection string, or the environment, which
+// are not related to the addresses to bootstrap against.
+func (config *AgentConfig) applyOptions(options map[string][]string, useTLS bool) error {
+	fetchOption := func(name string) (string, bool) {
+		return lastOptionValue(options, name)
+	}
 
-			for _, path := range cacertpaths {
-				cacert, err := ioutil.ReadFile(path)
-				if err != nil {
-					return err
-				}
+	// The profile is applied before any other options so that they can override the settings within it.
+	if valStr, ok := fetchOption("config_profile"); ok {
+		if err := config.ApplyConfigProfile(valStr); err != nil {
+			return err
+		}
+	}
 
-				ok := roots.AppendCertsFromPEM(cacert)
-				if !ok {
-					return errInvalidCertificate
-				}
+	if useTLS {
+		if cacertpaths := options["ca_cert_path"]; len(cacertpaths) > 0 {
+			if err := config.loadTLSRootCAs(cacertpaths); err != nil {
+				return err
 			}
+		}
 
-			config.TLSRootCAProvider = func() *x509.CertPool {
-				return roots
+		for _, pin := range options["tls_pinned_public_key"] {
+			hash, err := ParseTLSPinnedPublicKey(pin)
+			if err != nil {
+				return err
 			}
+			config.TLSPinnedPublicKeys = append(config.TLSPinnedPublicKeys, hash)
 		}
 
-		config.UseTLS = true
-	}
+		if valStr, ok := fetchOption("tls_skip_verify"); ok {
+			val, err := strconv.ParseBool(valStr)
+			if err != nil {
+				return fmt.Errorf("tls_skip_verify option must be a boolean")
+			}
+			config.TLSSkipVerify = val
+		}
 
-	if spec.Bucket != "" {
-		config.BucketName = spec.Bucket
+		config.UseTLS = true
 	}
 
 	if valStr, ok := fetchOption("network"); ok {
@@ -225,6 +482,22 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 		config.KVConnectTimeout = val
 	}
 
+	if valStr, ok := fetchOption("kv_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("kv_timeout option must be a duration or a number")
+		}
+		config.DefaultKvTimeout = val
+	}
+
+	if valStr, ok := fetchOption("kv_timer_resolution"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("kv_timer_resolution option must be a duration or a number")
+		}
+		config.KvTimerResolution = val
+	}
+
 	if valStr, ok := fetchOption("config_poll_timeout"); ok {
 		val, err := parseDurationOrInt(valStr)
 		if err != nil {
@@ -281,6 +554,14 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 		config.UseDurations = val
 	}
 
+	if valStr, ok := fetchOption("enable_resource_units"); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return fmt.Errorf("enable_resource_units option must be a boolean")
+		}
+		config.UseResourceUnits = val
+	}
+
 	if valStr, ok := fetchOption("max_idle_http_connections"); ok {
 		val, err := strconv.ParseInt(valStr, 10, 64)
 		if err != nil {
@@ -305,6 +586,14 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 		config.HTTPIdleConnectionTimeout = val
 	}
 
+	if valStr, ok := fetchOption("http_disable_compression"); ok {
+		val, err := strconv.ParseBool(valStr)
+		if err != nil {
+			return fmt.Errorf("http_disable_compression option must be a boolean")
+		}
+		config.HTTPDisableCompression = val
+	}
+
 	if valStr, ok := fetchOption("orphaned_response_logging"); ok {
 		val, err := strconv.ParseBool(valStr)
 		if err != nil {
@@ -365,6 +654,89 @@ func (config *AgentConfig) FromConnStr(connStr string) error {
 		config.MaxQueueSize = int(val)
 	}
 
+	if valStr, ok := fetchOption("kv_max_in_flight"); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("kv max in flight option must be a number")
+		}
+		config.KvMaxInFlight = int(val)
+	}
+
+	// This option is experimental
+	switch val, _ := fetchOption("kv_backpressure"); val {
+	case "fail_fast":
+		config.PipelineBackpressureConfig.Mode = PipelineBackpressureFailFast
+	case "block":
+		config.PipelineBackpressureConfig.Mode = PipelineBackpressureBlock
+	case "":
+		// Do nothing
+	default:
+		return errors.New("kv_backpressure={fail_fast,block}")
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("kv_backpressure_max_wait"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("kv_backpressure_max_wait option must be a duration or a number")
+		}
+		config.PipelineBackpressureConfig.MaxWait = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("server_wait_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("server_wait_timeout option must be a duration or a number")
+		}
+		config.ServerWaitTimeout = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("collection_manifest_poll_interval"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("collection_manifest_poll_interval option must be a duration or a number")
+		}
+		config.CollectionManifestPollInterval = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("max_connection_age"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("max_connection_age option must be a duration or a number")
+		}
+		config.MaxConnectionAge = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("kv_keepalive_interval"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("kv_keepalive_interval option must be a duration or a number")
+		}
+		config.KeepAliveConfig.Interval = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("kv_keepalive_timeout"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("kv_keepalive_timeout option must be a duration or a number")
+		}
+		config.KeepAliveConfig.Timeout = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("kv_latency_probe_interval"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("kv_latency_probe_interval option must be a duration or a number")
+		}
+		config.LatencyProbeConfig.Interval = val
+	}
+
 	// This option is experimental
 	if valStr, ok := fetchOption("unordered_execution_enabled"); ok {
 		val, err := strconv.ParseBool(valStr)
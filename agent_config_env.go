@@ -0,0 +1,41 @@
+package gocbcore
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvPrefix is the prefix of the environment variables read by FromEnv.
+const EnvPrefix = "GOCBCORE_"
+
+// FromEnv layers overrides from environment variables on top of the AgentConfig, typically
+// after it has been populated by FromConnStr.
+// Each connection string option supported by FromConnStr, other than bootstrap_on, can be
+// set using an environment variable named by EnvPrefix followed by the upper case option
+// name, e.g. GOCBCORE_KV_TIMEOUT=5s or GOCBCORE_COMPRESSION=false.
+// The TLS options, such as GOCBCORE_CA_CERT_PATH, only apply when UseTLS is already set.
+// GOCBCORE_CONFIG_PROFILE is applied by FromConnStr instead, in place of the config_profile option, so that the
+// profile does not overwrite the other options.
+func (config *AgentConfig) FromEnv() error {
+	return config.applyOptions(envOptions(agentConnStrOptions), config.UseTLS)
+}
+
+func envOptions(known []string) map[string][]string {
+	options := make(map[string][]string)
+	for _, name := range known {
+		// The bootstrap addresses only come from the connection string and the profile is applied by FromConnStr.
+		if name == "bootstrap_on" || name == "config_profile" {
+			continue
+		}
+
+		if val, ok := os.LookupEnv(EnvPrefix + strings.ToUpper(name)); ok {
+			options[name] = []string{val}
+		}
+	}
+
+	return options
+}
+
+func envConfigProfile() (string, bool) {
+	return os.LookupEnv(EnvPrefix + "CONFIG_PROFILE")
+}
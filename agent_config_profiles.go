@@ -0,0 +1,74 @@
+package gocbcore
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// ConfigProfileWanDevelopment is a profile suited to developing against a cluster over a high latency link,
+	// such as a cloud hosted cluster from a local machine. It lengthens timeouts and enables keepalives.
+	ConfigProfileWanDevelopment = "wan-development"
+
+	// ConfigProfileLowLatency is a profile suited to latency sensitive applications running close to the cluster.
+	// It shortens timeouts so that failures are detected, and retried, quickly.
+	ConfigProfileLowLatency = "low-latency"
+)
+
+var configProfiles = map[string]func(config *AgentConfig){
+	ConfigProfileWanDevelopment: func(config *AgentConfig) {
+		config.ConnectTimeout = 20 * time.Second
+		config.KVConnectTimeout = 20 * time.Second
+		config.DefaultKvTimeout = 20 * time.Second
+		config.CccpMaxWait = 20 * time.Second
+		config.HTTPMaxWait = 20 * time.Second
+		config.ReconnectBackoffConfig = ReconnectBackoffConfig{
+			Calculator: ExponentialBackoff(100*time.Millisecond, 10*time.Second, 2),
+			Jitter:     0.5,
+		}
+		config.KeepAliveConfig = KeepAliveConfig{
+			Interval: 30 * time.Second,
+			Timeout:  10 * time.Second,
+		}
+	},
+	ConfigProfileLowLatency: func(config *AgentConfig) {
+		config.ConnectTimeout = 5 * time.Second
+		config.KVConnectTimeout = 2 * time.Second
+		config.DefaultKvTimeout = time.Second
+		config.CccpMaxWait = time.Second
+		config.KvTimerResolution = time.Millisecond
+		config.KvPoolSize = 2
+		config.ReconnectBackoffConfig = ReconnectBackoffConfig{
+			Calculator: ExponentialBackoff(10*time.Millisecond, time.Second, 2),
+			Jitter:     0.25,
+		}
+		config.KeepAliveConfig = KeepAliveConfig{
+			Interval: 5 * time.Second,
+			Timeout:  time.Second,
+		}
+	},
+}
+
+// ConfigProfiles returns the names of the available config profiles.
+// Volatile: This API is subject to change at any time.
+func ConfigProfiles() []string {
+	names := make([]string, 0, len(configProfiles))
+	for name := range configProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyConfigProfile sets a coherent group of timeouts, pool sizes and retry settings suited to a common environment.
+// The fields set by the profile are overwritten, so it should be applied before any individual settings are changed.
+// Volatile: This API is subject to change at any time.
+func (config *AgentConfig) ApplyConfigProfile(name string) error {
+	profile, ok := configProfiles[name]
+	if !ok {
+		return wrapError(errInvalidArgument, "unknown config profile "+name)
+	}
+
+	profile(config)
+	return nil
+}
@@ -0,0 +1,408 @@
+package gocbcore
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// configDuration is a time.Duration which is serialized as a duration string, e.g. "2.5s". When deserializing a
+// number is also accepted and treated as milliseconds, matching the connection string options.
+type configDuration time.Duration
+
+func (d configDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *configDuration) UnmarshalJSON(data []byte) error {
+	var val interface{}
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+
+	return d.set(val)
+}
+
+// MarshalYAML implements the Marshaler interface used by gopkg.in/yaml.
+func (d configDuration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// UnmarshalYAML implements the Unmarshaler interface used by gopkg.in/yaml.
+func (d *configDuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var val interface{}
+	if err := unmarshal(&val); err != nil {
+		return err
+	}
+
+	return d.set(val)
+}
+
+func (d *configDuration) set(val interface{}) error {
+	var valStr string
+	switch v := val.(type) {
+	case string:
+		valStr = v
+	case float64:
+		valStr = fmt.Sprintf("%d", int64(v))
+	case int:
+		valStr = fmt.Sprintf("%d", v)
+	default:
+		return wrapError(errInvalidArgument, fmt.Sprintf("invalid duration %v", val))
+	}
+
+	dur, err := parseDurationOrInt(valStr)
+	if err != nil {
+		return wrapError(errInvalidArgument, fmt.Sprintf("invalid duration %s", valStr))
+	}
+
+	*d = configDuration(dur)
+	return nil
+}
+
+type serializedCircuitBreakerConfig struct {
+	Enabled                  bool           `json:"enabled" yaml:"enabled"`
+	VolumeThreshold          int64          `json:"volume_threshold" yaml:"volume_threshold"`
+	ErrorThresholdPercentage float64        `json:"error_threshold_percentage" yaml:"error_threshold_percentage"`
+	SleepWindow              configDuration `json:"sleep_window" yaml:"sleep_window"`
+	RollingWindow            configDuration `json:"rolling_window" yaml:"rolling_window"`
+	CanaryTimeout            configDuration `json:"canary_timeout" yaml:"canary_timeout"`
+}
+
+// serializedAgentConfig is the serialized form of an AgentConfig. Where an option can also be set in a connection
+// string it uses the same name. Options which cannot be serialized, such as credentials, callbacks and providers,
+// are omitted.
+type serializedAgentConfig struct {
+	MemdAddrs   []string `json:"memd_addrs,omitempty" yaml:"memd_addrs,omitempty"`
+	HTTPAddrs   []string `json:"http_addrs,omitempty" yaml:"http_addrs,omitempty"`
+	BucketName  string   `json:"bucket" yaml:"bucket"`
+	UserAgent   string   `json:"user_agent" yaml:"user_agent"`
+	ClientID    string   `json:"client_id" yaml:"client_id"`
+	NetworkType string   `json:"network" yaml:"network"`
+
+	UseTLS              bool     `json:"use_tls" yaml:"use_tls"`
+	TLSRootCAPaths      []string `json:"ca_cert_path,omitempty" yaml:"ca_cert_path,omitempty"`
+	TLSSkipVerify       bool     `json:"tls_skip_verify" yaml:"tls_skip_verify"`
+	TLSPinnedPublicKeys []string `json:"tls_pinned_public_key,omitempty" yaml:"tls_pinned_public_key,omitempty"`
+
+	UseMutationTokens           bool                `json:"enable_mutation_tokens" yaml:"enable_mutation_tokens"`
+	UseCompression              bool                `json:"compression" yaml:"compression"`
+	UseDurations                bool                `json:"enable_server_durations" yaml:"enable_server_durations"`
+	UseResourceUnits            bool                `json:"enable_resource_units" yaml:"enable_resource_units"`
+	DisableDecompression        bool                `json:"disable_decompression" yaml:"disable_decompression"`
+	UseOutOfOrderResponses      bool                `json:"unordered_execution_enabled" yaml:"unordered_execution_enabled"`
+	DisableXErrors              bool                `json:"disable_xerrors" yaml:"disable_xerrors"`
+	DisableJSONHello            bool                `json:"disable_json_hello" yaml:"disable_json_hello"`
+	DisableSyncReplicationHello bool                `json:"disable_sync_replication_hello" yaml:"disable_sync_replication_hello"`
+	EnableHelloFeatures         []memd.HelloFeature `json:"enable_hello_features,omitempty" yaml:"enable_hello_features,omitempty"`
+	DisableHelloFeatures        []memd.HelloFeature `json:"disable_hello_features,omitempty" yaml:"disable_hello_features,omitempty"`
+	UseCollections              bool                `json:"use_collections" yaml:"use_collections"`
+	UseGetCoalescing            bool                `json:"use_get_coalescing" yaml:"use_get_coalescing"`
+
+	CompressionMinSize  int     `json:"compression_min_size" yaml:"compression_min_size"`
+	CompressionMinRatio float64 `json:"compression_min_ratio" yaml:"compression_min_ratio"`
+
+	HTTPRedialPeriod configDuration `json:"http_redial_period" yaml:"http_redial_period"`
+	HTTPRetryDelay   configDuration `json:"http_retry_delay" yaml:"http_retry_delay"`
+	HTTPMaxWait      configDuration `json:"http_config_poll_timeout" yaml:"http_config_poll_timeout"`
+	CccpMaxWait      configDuration `json:"config_poll_timeout" yaml:"config_poll_timeout"`
+	CccpPollPeriod   configDuration `json:"config_poll_interval" yaml:"config_poll_interval"`
+
+	ConnectTimeout    configDuration `json:"connect_timeout" yaml:"connect_timeout"`
+	KVConnectTimeout  configDuration `json:"kv_connect_timeout" yaml:"kv_connect_timeout"`
+	DefaultKvTimeout  configDuration `json:"kv_timeout" yaml:"kv_timeout"`
+	KvTimerResolution configDuration `json:"kv_timer_resolution" yaml:"kv_timer_resolution"`
+
+	LazyConnect          bool   `json:"lazy_connect" yaml:"lazy_connect"`
+	SeedConfigSourceHost string `json:"seed_config_source_host" yaml:"seed_config_source_host"`
+	DisableConfigPolling bool   `json:"disable_config_polling" yaml:"disable_config_polling"`
+
+	ServerWaitTimeout      configDuration `json:"server_wait_timeout" yaml:"server_wait_timeout"`
+	ManifestPollInterval   configDuration `json:"collection_manifest_poll_interval" yaml:"collection_manifest_poll_interval"`
+	KvPoolSize             int            `json:"kv_pool_size" yaml:"kv_pool_size"`
+	MaxQueueSize           int            `json:"max_queue_size" yaml:"max_queue_size"`
+	KvMaxInFlight          int            `json:"kv_max_in_flight" yaml:"kv_max_in_flight"`
+	Backpressure           string         `json:"kv_backpressure" yaml:"kv_backpressure"`
+	BackpressureMaxWait    configDuration `json:"kv_backpressure_max_wait" yaml:"kv_backpressure_max_wait"`
+	ReconnectBackoffJitter float64        `json:"reconnect_backoff_jitter" yaml:"reconnect_backoff_jitter"`
+	MaxConnectionAge       configDuration `json:"max_connection_age" yaml:"max_connection_age"`
+	KeepAliveInterval      configDuration `json:"kv_keepalive_interval" yaml:"kv_keepalive_interval"`
+	KeepAliveTimeout       configDuration `json:"kv_keepalive_timeout" yaml:"kv_keepalive_timeout"`
+	LatencyProbeInterval   configDuration `json:"kv_latency_probe_interval" yaml:"kv_latency_probe_interval"`
+	LatencyProbeTimeout    configDuration `json:"kv_latency_probe_timeout" yaml:"kv_latency_probe_timeout"`
+
+	HTTPMaxIdleConns          int            `json:"max_idle_http_connections" yaml:"max_idle_http_connections"`
+	HTTPMaxIdleConnsPerHost   int            `json:"max_perhost_idle_http_connections" yaml:"max_perhost_idle_http_connections"`
+	HTTPIdleConnectionTimeout configDuration `json:"idle_http_connection_timeout" yaml:"idle_http_connection_timeout"`
+	HTTPDisableHTTP2          bool           `json:"http_disable_http2" yaml:"http_disable_http2"`
+	HTTPDisableCompression    bool           `json:"http_disable_compression" yaml:"http_disable_compression"`
+
+	NoRootTraceSpans     bool                           `json:"no_root_trace_spans" yaml:"no_root_trace_spans"`
+	CircuitBreakerConfig serializedCircuitBreakerConfig `json:"circuit_breaker" yaml:"circuit_breaker"`
+
+	UseZombieLogger        bool           `json:"orphaned_response_logging" yaml:"orphaned_response_logging"`
+	ZombieLoggerInterval   configDuration `json:"orphaned_response_logging_interval" yaml:"orphaned_response_logging_interval"`
+	ZombieLoggerSampleSize int            `json:"orphaned_response_logging_sample_size" yaml:"orphaned_response_logging_sample_size"`
+
+	AuthMechanisms []AuthMechanism `json:"auth_mechanisms,omitempty" yaml:"auth_mechanisms,omitempty"`
+}
+
+func backpressureModeToString(mode PipelineBackpressureMode) string {
+	switch mode {
+	case PipelineBackpressureFailFast:
+		return "fail_fast"
+	case PipelineBackpressureBlock:
+		return "block"
+	case PipelineBackpressureCallback:
+		return "callback"
+	}
+
+	return fmt.Sprintf("%d", mode)
+}
+
+func backpressureModeFromString(mode string) (PipelineBackpressureMode, error) {
+	switch mode {
+	case "fail_fast", "":
+		return PipelineBackpressureFailFast, nil
+	case "block":
+		return PipelineBackpressureBlock, nil
+	case "callback":
+		return PipelineBackpressureCallback, nil
+	}
+
+	return 0, errors.New("kv_backpressure={fail_fast,block,callback}")
+}
+
+func (config *AgentConfig) toSerialized() serializedAgentConfig {
+	pins := make([]string, len(config.TLSPinnedPublicKeys))
+	for i, pin := range config.TLSPinnedPublicKeys {
+		pins[i] = base64.StdEncoding.EncodeToString(pin)
+	}
+
+	return serializedAgentConfig{
+		MemdAddrs:                   config.MemdAddrs,
+		HTTPAddrs:                   config.HTTPAddrs,
+		BucketName:                  config.BucketName,
+		UserAgent:                   config.UserAgent,
+		ClientID:                    config.ClientID,
+		NetworkType:                 config.NetworkType,
+		UseTLS:                      config.UseTLS,
+		TLSRootCAPaths:              config.tlsRootCAPaths,
+		TLSSkipVerify:               config.TLSSkipVerify,
+		TLSPinnedPublicKeys:         pins,
+		UseMutationTokens:           config.UseMutationTokens,
+		UseCompression:              config.UseCompression,
+		UseDurations:                config.UseDurations,
+		UseResourceUnits:            config.UseResourceUnits,
+		DisableDecompression:        config.DisableDecompression,
+		UseOutOfOrderResponses:      config.UseOutOfOrderResponses,
+		DisableXErrors:              config.DisableXErrors,
+		DisableJSONHello:            config.DisableJSONHello,
+		DisableSyncReplicationHello: config.DisableSyncReplicationHello,
+		EnableHelloFeatures:         config.EnableHelloFeatures,
+		DisableHelloFeatures:        config.DisableHelloFeatures,
+		UseCollections:              config.UseCollections,
+		UseGetCoalescing:            config.UseGetCoalescing,
+		CompressionMinSize:          config.CompressionMinSize,
+		CompressionMinRatio:         config.CompressionMinRatio,
+		HTTPRedialPeriod:            configDuration(config.HTTPRedialPeriod),
+		HTTPRetryDelay:              configDuration(config.HTTPRetryDelay),
+		HTTPMaxWait:                 configDuration(config.HTTPMaxWait),
+		CccpMaxWait:                 configDuration(config.CccpMaxWait),
+		CccpPollPeriod:              configDuration(config.CccpPollPeriod),
+		ConnectTimeout:              configDuration(config.ConnectTimeout),
+		KVConnectTimeout:            configDuration(config.KVConnectTimeout),
+		DefaultKvTimeout:            configDuration(config.DefaultKvTimeout),
+		KvTimerResolution:           configDuration(config.KvTimerResolution),
+		LazyConnect:                 config.LazyConnect,
+		SeedConfigSourceHost:        config.SeedConfigSourceHost,
+		DisableConfigPolling:        config.DisableConfigPolling,
+		ServerWaitTimeout:           configDuration(config.ServerWaitTimeout),
+		ManifestPollInterval:        configDuration(config.CollectionManifestPollInterval),
+		KvPoolSize:                  config.KvPoolSize,
+		MaxQueueSize:                config.MaxQueueSize,
+		KvMaxInFlight:               config.KvMaxInFlight,
+		Backpressure:                backpressureModeToString(config.PipelineBackpressureConfig.Mode),
+		BackpressureMaxWait:         configDuration(config.PipelineBackpressureConfig.MaxWait),
+		ReconnectBackoffJitter:      config.ReconnectBackoffConfig.Jitter,
+		MaxConnectionAge:            configDuration(config.MaxConnectionAge),
+		KeepAliveInterval:           configDuration(config.KeepAliveConfig.Interval),
+		KeepAliveTimeout:            configDuration(config.KeepAliveConfig.Timeout),
+		LatencyProbeInterval:        configDuration(config.LatencyProbeConfig.Interval),
+		LatencyProbeTimeout:         configDuration(config.LatencyProbeConfig.Timeout),
+		HTTPMaxIdleConns:            config.HTTPMaxIdleConns,
+		HTTPMaxIdleConnsPerHost:     config.HTTPMaxIdleConnsPerHost,
+		HTTPIdleConnectionTimeout:   configDuration(config.HTTPIdleConnectionTimeout),
+		HTTPDisableHTTP2:            config.HTTPDisableHTTP2,
+		HTTPDisableCompression:      config.HTTPDisableCompression,
+		NoRootTraceSpans:            config.NoRootTraceSpans,
+		CircuitBreakerConfig: serializedCircuitBreakerConfig{
+			Enabled:                  config.CircuitBreakerConfig.Enabled,
+			VolumeThreshold:          config.CircuitBreakerConfig.VolumeThreshold,
+			ErrorThresholdPercentage: config.CircuitBreakerConfig.ErrorThresholdPercentage,
+			SleepWindow:              configDuration(config.CircuitBreakerConfig.SleepWindow),
+			RollingWindow:            configDuration(config.CircuitBreakerConfig.RollingWindow),
+			CanaryTimeout:            configDuration(config.CircuitBreakerConfig.CanaryTimeout),
+		},
+		UseZombieLogger:        config.UseZombieLogger,
+		ZombieLoggerInterval:   configDuration(config.ZombieLoggerInterval),
+		ZombieLoggerSampleSize: config.ZombieLoggerSampleSize,
+		AuthMechanisms:         config.AuthMechanisms,
+	}
+}
+
+func (config *AgentConfig) fromSerialized(s serializedAgentConfig) error {
+	var pins [][]byte
+	for _, pin := range s.TLSPinnedPublicKeys {
+		hash, err := ParseTLSPinnedPublicKey(pin)
+		if err != nil {
+			return err
+		}
+		pins = append(pins, hash)
+	}
+
+	backpressureMode, err := backpressureModeFromString(s.Backpressure)
+	if err != nil {
+		return err
+	}
+
+	if len(s.TLSRootCAPaths) > 0 {
+		if err := config.loadTLSRootCAs(s.TLSRootCAPaths); err != nil {
+			return err
+		}
+	}
+
+	config.MemdAddrs = s.MemdAddrs
+	config.HTTPAddrs = s.HTTPAddrs
+	config.BucketName = s.BucketName
+	config.UserAgent = s.UserAgent
+	config.ClientID = s.ClientID
+	config.NetworkType = s.NetworkType
+	config.UseTLS = s.UseTLS
+	config.TLSSkipVerify = s.TLSSkipVerify
+	config.TLSPinnedPublicKeys = pins
+	config.UseMutationTokens = s.UseMutationTokens
+	config.UseCompression = s.UseCompression
+	config.UseDurations = s.UseDurations
+	config.UseResourceUnits = s.UseResourceUnits
+	config.DisableDecompression = s.DisableDecompression
+	config.UseOutOfOrderResponses = s.UseOutOfOrderResponses
+	config.DisableXErrors = s.DisableXErrors
+	config.DisableJSONHello = s.DisableJSONHello
+	config.DisableSyncReplicationHello = s.DisableSyncReplicationHello
+	config.EnableHelloFeatures = s.EnableHelloFeatures
+	config.DisableHelloFeatures = s.DisableHelloFeatures
+	config.UseCollections = s.UseCollections
+	config.UseGetCoalescing = s.UseGetCoalescing
+	config.CompressionMinSize = s.CompressionMinSize
+	config.CompressionMinRatio = s.CompressionMinRatio
+	config.HTTPRedialPeriod = time.Duration(s.HTTPRedialPeriod)
+	config.HTTPRetryDelay = time.Duration(s.HTTPRetryDelay)
+	config.HTTPMaxWait = time.Duration(s.HTTPMaxWait)
+	config.CccpMaxWait = time.Duration(s.CccpMaxWait)
+	config.CccpPollPeriod = time.Duration(s.CccpPollPeriod)
+	config.ConnectTimeout = time.Duration(s.ConnectTimeout)
+	config.KVConnectTimeout = time.Duration(s.KVConnectTimeout)
+	config.DefaultKvTimeout = time.Duration(s.DefaultKvTimeout)
+	config.KvTimerResolution = time.Duration(s.KvTimerResolution)
+	config.LazyConnect = s.LazyConnect
+	config.SeedConfigSourceHost = s.SeedConfigSourceHost
+	config.DisableConfigPolling = s.DisableConfigPolling
+	config.ServerWaitTimeout = time.Duration(s.ServerWaitTimeout)
+	config.CollectionManifestPollInterval = time.Duration(s.ManifestPollInterval)
+	config.KvPoolSize = s.KvPoolSize
+	config.MaxQueueSize = s.MaxQueueSize
+	config.KvMaxInFlight = s.KvMaxInFlight
+	config.PipelineBackpressureConfig.Mode = backpressureMode
+	config.PipelineBackpressureConfig.MaxWait = time.Duration(s.BackpressureMaxWait)
+	config.ReconnectBackoffConfig.Jitter = s.ReconnectBackoffJitter
+	config.MaxConnectionAge = time.Duration(s.MaxConnectionAge)
+	config.KeepAliveConfig.Interval = time.Duration(s.KeepAliveInterval)
+	config.KeepAliveConfig.Timeout = time.Duration(s.KeepAliveTimeout)
+	config.LatencyProbeConfig.Interval = time.Duration(s.LatencyProbeInterval)
+	config.LatencyProbeConfig.Timeout = time.Duration(s.LatencyProbeTimeout)
+	config.HTTPMaxIdleConns = s.HTTPMaxIdleConns
+	config.HTTPMaxIdleConnsPerHost = s.HTTPMaxIdleConnsPerHost
+	config.HTTPIdleConnectionTimeout = time.Duration(s.HTTPIdleConnectionTimeout)
+	config.HTTPDisableHTTP2 = s.HTTPDisableHTTP2
+	config.HTTPDisableCompression = s.HTTPDisableCompression
+	config.NoRootTraceSpans = s.NoRootTraceSpans
+	config.CircuitBreakerConfig.Enabled = s.CircuitBreakerConfig.Enabled
+	config.CircuitBreakerConfig.VolumeThreshold = s.CircuitBreakerConfig.VolumeThreshold
+	config.CircuitBreakerConfig.ErrorThresholdPercentage = s.CircuitBreakerConfig.ErrorThresholdPercentage
+	config.CircuitBreakerConfig.SleepWindow = time.Duration(s.CircuitBreakerConfig.SleepWindow)
+	config.CircuitBreakerConfig.RollingWindow = time.Duration(s.CircuitBreakerConfig.RollingWindow)
+	config.CircuitBreakerConfig.CanaryTimeout = time.Duration(s.CircuitBreakerConfig.CanaryTimeout)
+	config.UseZombieLogger = s.UseZombieLogger
+	config.ZombieLoggerInterval = time.Duration(s.ZombieLoggerInterval)
+	config.ZombieLoggerSampleSize = s.ZombieLoggerSampleSize
+	config.AuthMechanisms = s.AuthMechanisms
+
+	return nil
+}
+
+// loadTLSRootCAs sets TLSRootCAProvider to return a pool of the CA certificates read from paths.
+func (config *AgentConfig) loadTLSRootCAs(paths []string) error {
+	roots := x509.NewCertPool()
+
+	for _, path := range paths {
+		cacert, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		ok := roots.AppendCertsFromPEM(cacert)
+		if !ok {
+			return errInvalidCertificate
+		}
+	}
+
+	config.TLSRootCAProvider = func() *x509.CertPool {
+		return roots
+	}
+	config.tlsRootCAPaths = paths
+
+	return nil
+}
+
+// MarshalJSON serializes the AgentConfig, with durations as strings and CA certificates referenced by the paths they
+// were loaded from. Options which cannot be serialized, such as Auth and any callbacks or providers, are omitted.
+// Volatile: This API is subject to change at any time.
+func (config AgentConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(config.toSerialized())
+}
+
+// UnmarshalJSON populates the AgentConfig from the output of MarshalJSON. Options missing from data are left
+// unchanged, so a file need only contain the options which it overrides.
+// Volatile: This API is subject to change at any time.
+func (config *AgentConfig) UnmarshalJSON(data []byte) error {
+	s := config.toSerialized()
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	return config.fromSerialized(s)
+}
+
+// MarshalYAML implements the Marshaler interface used by gopkg.in/yaml, the options are the same as for MarshalJSON.
+// Volatile: This API is subject to change at any time.
+func (config AgentConfig) MarshalYAML() (interface{}, error) {
+	return config.toSerialized(), nil
+}
+
+// UnmarshalYAML implements the Unmarshaler interface used by gopkg.in/yaml, see UnmarshalJSON.
+// Volatile: This API is subject to change at any time.
+func (config *AgentConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	s := config.toSerialized()
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	return config.fromSerialized(s)
+}
@@ -1,8 +1,17 @@
 package gocbcore
 
 import (
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
 )
 
 func (suite *StandardTestSuite) TestAgentConfig_FromConnStr() {
@@ -223,6 +232,54 @@ func (suite *StandardTestSuite) TestAgentConfig_KVConnectTimeout() {
 	}
 }
 
+func (suite *StandardTestSuite) TestAgentConfig_KvTimeouts() {
+	tests := []struct {
+		name               string
+		connStr            string
+		expectedTimeout    time.Duration
+		expectedResolution time.Duration
+		wantErr            bool
+	}{
+		{
+			name:               "duration",
+			connStr:            "couchbase://10.112.192.101?kv_timeout=2500ms&kv_timer_resolution=1ms",
+			expectedTimeout:    2500 * time.Millisecond,
+			expectedResolution: time.Millisecond,
+		},
+		{
+			name:               "ms",
+			connStr:            "couchbase://10.112.192.101?kv_timeout=2500&kv_timer_resolution=5",
+			expectedTimeout:    2500 * time.Millisecond,
+			expectedResolution: 5 * time.Millisecond,
+		},
+		{
+			name:    "invalid timeout",
+			connStr: "couchbase://10.112.192.101?kv_timeout=squirrel",
+			wantErr: true,
+		},
+		{
+			name:    "invalid resolution",
+			connStr: "couchbase://10.112.192.101?kv_timer_resolution=squirrel",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		suite.T().Run(tt.name, func(t *testing.T) {
+			config := &AgentConfig{}
+			if err := config.FromConnStr(tt.connStr); (err != nil) != tt.wantErr {
+				t.Errorf("FromConnStr() error = %v, wanted error = %t", err, tt.wantErr)
+			}
+
+			if config.DefaultKvTimeout != tt.expectedTimeout {
+				suite.T().Fatalf("Expected %d but was %d", tt.expectedTimeout, config.DefaultKvTimeout)
+			}
+			if config.KvTimerResolution != tt.expectedResolution {
+				suite.T().Fatalf("Expected %d but was %d", tt.expectedResolution, config.KvTimerResolution)
+			}
+		})
+	}
+}
+
 func (suite *StandardTestSuite) TestAgentConfig_ConfigPollTimeout() {
 	tests := []struct {
 		name     string
@@ -880,3 +937,237 @@ func (suite *StandardTestSuite) TestAgentConfig_MaxQueueSize() {
 		})
 	}
 }
+
+func (suite *UnitTestSuite) TestAgentConfigProfiles() {
+	suite.Assert().Equal([]string{ConfigProfileLowLatency, ConfigProfileWanDevelopment}, ConfigProfiles())
+
+	config := &AgentConfig{KVConnectTimeout: time.Second}
+	suite.Require().Nil(config.ApplyConfigProfile(ConfigProfileWanDevelopment))
+	suite.Assert().Equal(20*time.Second, config.KVConnectTimeout)
+	suite.Assert().Equal(20*time.Second, config.DefaultKvTimeout)
+	suite.Assert().NotNil(config.ReconnectBackoffConfig.Calculator)
+	suite.Assert().Equal(30*time.Second, config.KeepAliveConfig.Interval)
+
+	suite.Assert().NotNil(config.ApplyConfigProfile("squirrel"))
+
+	// Options in the connection string take precedence over the profile regardless of their order.
+	config = &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101?kv_connect_timeout=3s&config_profile=low-latency"))
+	suite.Assert().Equal(3*time.Second, config.KVConnectTimeout)
+	suite.Assert().Equal(time.Second, config.DefaultKvTimeout)
+	suite.Assert().Equal(2, config.KvPoolSize)
+
+	suite.Assert().NotNil(config.FromConnStr("couchbase://10.112.192.101?config_profile=squirrel"))
+}
+
+func (suite *UnitTestSuite) TestAgentConfigValidate() {
+	config := &AgentConfig{MemdAddrs: []string{"10.112.192.101:11210"}}
+	suite.Assert().Nil(config.Validate())
+
+	config = &AgentConfig{
+		TLSSkipVerify:       true,
+		CompressionMinRatio: 1.5,
+		KvPoolSize:          -1,
+		DefaultKvTimeout:    -time.Second,
+		KeepAliveConfig:     KeepAliveConfig{Timeout: time.Second},
+		KVStatusRetryOverrides: map[memd.StatusCode]KVStatusRetryOverride{
+			memd.StatusBusy:    {Behavior: KVStatusRetryConstant, Interval: -time.Second},
+			memd.StatusTmpFail: {},
+		},
+	}
+	err := config.Validate()
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	var validationErr ConfigValidationError
+	suite.Require().True(errors.As(err, &validationErr))
+	suite.Assert().Equal([]string{
+		"at least one of MemdAddrs, HTTPAddrs, SeedConfig or ClusterConfigProvider must be set",
+		"TLSSkipVerify requires UseTLS",
+		"CompressionMinRatio must be between 0 and 1",
+		"KvPoolSize must not be negative",
+		"DefaultKvTimeout must not be negative",
+		"KeepAliveConfig.Timeout requires KeepAliveConfig.Interval",
+		"KVStatusRetryOverrides[0x85].Interval must not be negative",
+		"KVStatusRetryOverrides[0x86].Behavior is not a valid behavior",
+	}, validationErr.Problems)
+
+	_, err = CreateAgent(config)
+	suite.Assert().True(errors.As(err, &validationErr))
+}
+
+func (suite *UnitTestSuite) TestAgentConfigFromConnStrUnknownOptions() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	connStr := "couchbase://10.112.192.101?compresion=true&kv_pool_size=2&squirrel=1"
+
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr(connStr))
+	suite.Assert().Equal(2, config.KvPoolSize)
+
+	config = &AgentConfig{}
+	warnings, err := config.FromConnStrWithOptions(connStr, FromConnStrOptions{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]ConnStrWarning{
+		{Option: "compresion", Suggestion: "compression"},
+		{Option: "squirrel"},
+	}, warnings)
+	suite.Assert().Equal(2, config.KvPoolSize)
+
+	config = &AgentConfig{}
+	_, err = config.FromConnStrWithOptions(connStr, FromConnStrOptions{Strict: true})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+	suite.Assert().Contains(err.Error(), "did you mean compression?")
+	suite.Assert().Equal(0, config.KvPoolSize)
+
+	dcpConfig := &DCPAgentConfig{}
+	warnings, err = dcpConfig.FromConnStrWithOptions("couchbase://10.112.192.101?dcp_buffer_size=1024&kv_timeout=1s",
+		FromConnStrOptions{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]ConnStrWarning{{Option: "kv_timeout"}}, warnings)
+}
+
+func (suite *UnitTestSuite) TestAgentConfigFromEnv() {
+	env := map[string]string{
+		"GOCBCORE_KV_TIMEOUT":      "5s",
+		"GOCBCORE_KV_POOL_SIZE":    "4",
+		"GOCBCORE_COMPRESSION":     "false",
+		"GOCBCORE_BOOTSTRAP_ON":    "http",
+		"GOCBCORE_TLS_SKIP_VERIFY": "true",
+	}
+	for k, v := range env {
+		suite.Require().Nil(os.Setenv(k, v))
+	}
+	defer func() {
+		for k := range env {
+			os.Unsetenv(k)
+		}
+	}()
+
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101?kv_timeout=1s&compression=true"))
+	suite.Require().Nil(config.FromEnv())
+
+	suite.Assert().Equal([]string{"10.112.192.101:11210"}, config.MemdAddrs)
+	suite.Assert().Equal(5*time.Second, config.DefaultKvTimeout)
+	suite.Assert().Equal(4, config.KvPoolSize)
+	suite.Assert().False(config.UseCompression)
+	// TLS options are ignored unless the connection string enabled TLS.
+	suite.Assert().False(config.TLSSkipVerify)
+	suite.Assert().False(config.UseTLS)
+
+	suite.Require().Nil(os.Setenv("GOCBCORE_KV_POOL_SIZE", "squirrel"))
+	suite.Assert().NotNil(config.FromEnv())
+	os.Unsetenv("GOCBCORE_KV_POOL_SIZE")
+
+	// The profile from the environment is applied before the connection string options, rather than over them.
+	suite.Require().Nil(os.Setenv("GOCBCORE_CONFIG_PROFILE", ConfigProfileLowLatency))
+	defer os.Unsetenv("GOCBCORE_CONFIG_PROFILE")
+
+	config = &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101?kv_connect_timeout=3s&config_profile=wan-development"))
+	suite.Require().Nil(config.FromEnv())
+	suite.Assert().Equal(3*time.Second, config.KVConnectTimeout)
+	suite.Assert().Equal(5*time.Second, config.DefaultKvTimeout)
+	suite.Assert().Equal(5*time.Second, config.ConnectTimeout)
+}
+
+func (suite *UnitTestSuite) TestAgentConfigSerialize() {
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101/default?kv_timeout=2500ms&kv_pool_size=2&kv_backpressure=block"))
+	config.TLSPinnedPublicKeys = [][]byte{make([]byte, 32)}
+	config.Auth = PasswordAuthProvider{Username: "Administrator", Password: "password"}
+
+	data, err := json.Marshal(config)
+	suite.Require().Nil(err)
+
+	var raw map[string]interface{}
+	suite.Require().Nil(json.Unmarshal(data, &raw))
+	suite.Assert().Equal("2.5s", raw["kv_timeout"])
+	suite.Assert().Equal("block", raw["kv_backpressure"])
+	suite.Assert().NotContains(string(data), "password")
+
+	decoded := &AgentConfig{}
+	suite.Require().Nil(json.Unmarshal(data, decoded))
+	suite.Assert().Equal(config.MemdAddrs, decoded.MemdAddrs)
+	suite.Assert().Equal("default", decoded.BucketName)
+	suite.Assert().Equal(2500*time.Millisecond, decoded.DefaultKvTimeout)
+	suite.Assert().Equal(2, decoded.KvPoolSize)
+	suite.Assert().Equal(PipelineBackpressureBlock, decoded.PipelineBackpressureConfig.Mode)
+	suite.Assert().Equal(config.TLSPinnedPublicKeys, decoded.TLSPinnedPublicKeys)
+
+	// Options missing from the document are left as they were, numbers are durations in milliseconds.
+	suite.Require().Nil(json.Unmarshal([]byte(`{"kv_connect_timeout":"3s","max_connection_age":60000}`), decoded))
+	suite.Assert().Equal(3*time.Second, decoded.KVConnectTimeout)
+	suite.Assert().Equal(time.Minute, decoded.MaxConnectionAge)
+	suite.Assert().Equal(2, decoded.KvPoolSize)
+
+	suite.Assert().NotNil(json.Unmarshal([]byte(`{"kv_timeout":"squirrel"}`), decoded))
+	suite.Assert().NotNil(json.Unmarshal([]byte(`{"ca_cert_path":["/does/not/exist"]}`), decoded))
+
+	// YAML libraries hand UnmarshalYAML a function to decode into the serialized form.
+	yamlConfig := &AgentConfig{}
+	err = yamlConfig.UnmarshalYAML(func(out interface{}) error {
+		return json.Unmarshal([]byte(`{"kv_timeout":"1s","compression":true}`), out)
+	})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(time.Second, yamlConfig.DefaultKvTimeout)
+	suite.Assert().True(yamlConfig.UseCompression)
+}
+
+// This test checks that every option parsed from a connection string is known, so that the list used to warn about
+// unknown options can't drift from the parsers.
+func (suite *UnitTestSuite) TestConnStrOptionsMatchParsers() {
+	parsedOptions := func(filename string) []string {
+		file, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+		suite.Require().Nil(err)
+
+		// Options are either fetched with fetchOption or read directly from the options map.
+		var names []string
+		ast.Inspect(file, func(node ast.Node) bool {
+			var arg ast.Expr
+			switch expr := node.(type) {
+			case *ast.CallExpr:
+				if fn, ok := expr.Fun.(*ast.Ident); ok && fn.Name == "fetchOption" && len(expr.Args) == 1 {
+					arg = expr.Args[0]
+				}
+			case *ast.IndexExpr:
+				if ident, ok := expr.X.(*ast.Ident); ok && ident.Name == "options" {
+					arg = expr.Index
+				} else if sel, ok := expr.X.(*ast.SelectorExpr); ok && sel.Sel.Name == "Options" {
+					arg = expr.Index
+				}
+			}
+
+			if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				name, err := strconv.Unquote(lit.Value)
+				suite.Require().Nil(err)
+				names = append(names, name)
+			}
+			return true
+		})
+		return names
+	}
+
+	agentOptions := parsedOptions("agent_config.go")
+	suite.Require().NotEmpty(agentOptions)
+	suite.Assert().ElementsMatch(agentConnStrOptions, uniqueStrings(agentOptions))
+
+	dcpOptions := parsedOptions("dcpagent_config.go")
+	suite.Require().NotEmpty(dcpOptions)
+	suite.Assert().ElementsMatch(dcpAgentConnStrOptions, uniqueStrings(dcpOptions))
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	var unique []string
+	for _, value := range values {
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		unique = append(unique, value)
+	}
+	return unique
+}
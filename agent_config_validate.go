@@ -0,0 +1,129 @@
+package gocbcore
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+type configValidator struct {
+	problems []string
+}
+
+func (v *configValidator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *configValidator) nonNegativeDuration(name string, val time.Duration) {
+	if val < 0 {
+		v.addf("%s must not be negative", name)
+	}
+}
+
+func (v *configValidator) nonNegativeInt(name string, val int) {
+	if val < 0 {
+		v.addf("%s must not be negative", name)
+	}
+}
+
+func (v *configValidator) fraction(name string, val float64) {
+	if val < 0 || val > 1 {
+		v.addf("%s must be between 0 and 1", name)
+	}
+}
+
+func (v *configValidator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	return ConfigValidationError{Problems: v.problems}
+}
+
+// Validate checks the config for invalid or conflicting settings, returning a ConfigValidationError listing every
+// problem found. It is called by CreateAgent.
+// Volatile: This API is subject to change at any time.
+func (config *AgentConfig) Validate() error {
+	var v configValidator
+
+	if len(config.MemdAddrs) == 0 && len(config.HTTPAddrs) == 0 && config.SeedConfig == nil &&
+		config.ClusterConfigProvider == nil {
+		v.addf("at least one of MemdAddrs, HTTPAddrs, SeedConfig or ClusterConfigProvider must be set")
+	}
+	if config.DisableConfigPolling && config.SeedConfig == nil && config.ClusterConfigProvider == nil {
+		v.addf("a seed config or config provider is required when config polling is disabled")
+	}
+
+	if !config.UseTLS {
+		if config.TLSSkipVerify {
+			v.addf("TLSSkipVerify requires UseTLS")
+		}
+		if len(config.TLSPinnedPublicKeys) > 0 {
+			v.addf("TLSPinnedPublicKeys requires UseTLS")
+		}
+		if config.TLSRootCAProvider != nil {
+			v.addf("TLSRootCAProvider requires UseTLS")
+		}
+		if config.TLSVerifyPeerCertificate != nil {
+			v.addf("TLSVerifyPeerCertificate requires UseTLS")
+		}
+	}
+
+	v.nonNegativeInt("CompressionMinSize", config.CompressionMinSize)
+	v.fraction("CompressionMinRatio", config.CompressionMinRatio)
+
+	v.nonNegativeInt("KvPoolSize", config.KvPoolSize)
+	v.nonNegativeInt("MaxQueueSize", config.MaxQueueSize)
+	v.nonNegativeInt("KvMaxInFlight", config.KvMaxInFlight)
+	v.nonNegativeInt("HTTPMaxIdleConns", config.HTTPMaxIdleConns)
+	v.nonNegativeInt("HTTPMaxIdleConnsPerHost", config.HTTPMaxIdleConnsPerHost)
+	if config.HTTPMaxIdleConns > 0 && config.HTTPMaxIdleConnsPerHost > config.HTTPMaxIdleConns {
+		v.addf("HTTPMaxIdleConnsPerHost must not be greater than HTTPMaxIdleConns")
+	}
+
+	v.nonNegativeDuration("ConnectTimeout", config.ConnectTimeout)
+	v.nonNegativeDuration("KVConnectTimeout", config.KVConnectTimeout)
+	v.nonNegativeDuration("DefaultKvTimeout", config.DefaultKvTimeout)
+	v.nonNegativeDuration("KvTimerResolution", config.KvTimerResolution)
+	v.nonNegativeDuration("HTTPRetryDelay", config.HTTPRetryDelay)
+	v.nonNegativeDuration("HTTPMaxWait", config.HTTPMaxWait)
+	v.nonNegativeDuration("HTTPIdleConnectionTimeout", config.HTTPIdleConnectionTimeout)
+	v.nonNegativeDuration("CccpMaxWait", config.CccpMaxWait)
+	v.nonNegativeDuration("CccpPollPeriod", config.CccpPollPeriod)
+	v.nonNegativeDuration("MaxConnectionAge", config.MaxConnectionAge)
+	v.nonNegativeDuration("CollectionManifestPollInterval", config.CollectionManifestPollInterval)
+	if config.CollectionManifestPollInterval > 0 && !config.UseCollections {
+		v.addf("CollectionManifestPollInterval requires UseCollections")
+	}
+
+	v.fraction("ReconnectBackoffConfig.Jitter", config.ReconnectBackoffConfig.Jitter)
+
+	v.nonNegativeDuration("KeepAliveConfig.Interval", config.KeepAliveConfig.Interval)
+	v.nonNegativeDuration("KeepAliveConfig.Timeout", config.KeepAliveConfig.Timeout)
+	if config.KeepAliveConfig.Timeout > 0 && config.KeepAliveConfig.Interval == 0 {
+		v.addf("KeepAliveConfig.Timeout requires KeepAliveConfig.Interval")
+	}
+
+	statuses := make([]int, 0, len(config.KVStatusRetryOverrides))
+	for status := range config.KVStatusRetryOverrides {
+		statuses = append(statuses, int(status))
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		override := config.KVStatusRetryOverrides[memd.StatusCode(status)]
+		if override.Behavior < KVStatusNeverRetry || override.Behavior > KVStatusFailFast {
+			v.addf("KVStatusRetryOverrides[0x%x].Behavior is not a valid behavior", uint16(status))
+		}
+		v.nonNegativeDuration(fmt.Sprintf("KVStatusRetryOverrides[0x%x].Interval", uint16(status)), override.Interval)
+	}
+
+	v.nonNegativeDuration("LatencyProbeConfig.Interval", config.LatencyProbeConfig.Interval)
+	v.nonNegativeDuration("LatencyProbeConfig.Timeout", config.LatencyProbeConfig.Timeout)
+	if config.LatencyProbeConfig.Timeout > 0 && config.LatencyProbeConfig.Interval == 0 {
+		v.addf("LatencyProbeConfig.Timeout requires LatencyProbeConfig.Interval")
+	}
+
+	return v.err()
+}
@@ -1,6 +1,10 @@
 package gocbcore
 
-import "github.com/couchbase/gocbcore/v9/memd"
+import (
+	"context"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
 
 // GetCallback is invoked upon completion of a Get operation.
 type GetCallback func(*GetResult, error)
@@ -10,6 +14,16 @@ func (agent *Agent) Get(opts GetOptions, cb GetCallback) (PendingOp, error) {
 	return agent.crud.Get(opts, cb)
 }
 
+// GetStreamingCallback is invoked upon completion of a GetStreaming operation.
+type GetStreamingCallback func(*GetStreamingResult, error)
+
+// GetStreaming retrieves a document, writing its value to a writer as it is read from the network rather than
+// buffering it in memory first. This is intended for very large documents.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetStreaming(opts GetStreamingOptions, cb GetStreamingCallback) (PendingOp, error) {
+	return agent.crud.GetStreaming(opts, cb)
+}
+
 // GetAndTouchCallback is invoked upon completion of a GetAndTouch operation.
 type GetAndTouchCallback func(*GetAndTouchResult, error)
 
@@ -118,6 +132,18 @@ func (agent *Agent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (PendingOp,
 	return agent.crud.GetMeta(opts, cb)
 }
 
+// ExistsMultiCallback is invoked upon completion of a ExistsMulti operation.
+type ExistsMultiCallback func(*ExistsMultiResult, error)
+
+// ExistsMulti checks whether each of a set of documents exists without fetching their values. Keys are not batched
+// per node, a GetMeta request is dispatched for each key and these are pipelined over the node's connections. The
+// callback is invoked once all keys have been checked, a failure to check an individual key is reported in its
+// ExistsResult. If every key fails to dispatch then the error is returned and the callback is not invoked.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ExistsMulti(opts ExistsMultiOptions, cb ExistsMultiCallback) (PendingOp, error) {
+	return agent.crud.ExistsMulti(opts, cb)
+}
+
 // SetMetaCallback is invoked upon completion of a SetMeta operation.
 type SetMetaCallback func(*SetMetaResult, error)
 
@@ -143,9 +169,26 @@ type StatsCallback func(*StatsResult, error)
 // represented in the results, or there may be conflicting information between
 // multiple nodes (a vbucket active on two separate nodes at once).
 func (agent *Agent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp, error) {
+	agent.connectTrigger.Trigger()
 	return agent.stats.Stats(opts, cb)
 }
 
+// StatsStreamValueCallback is invoked for each stat as it is received from a server.
+// Invocations are never concurrent with each other.
+type StatsStreamValueCallback func(serverAddress string, key, value []byte)
+
+// StatsStreamCallback is invoked once every server has finished streaming its stats.
+type StatsStreamCallback func(*StatsStreamResult, error)
+
+// StatsStream retrieves statistics information from the server, passing each stat to valueCb as it arrives rather
+// than buffering every stat in memory. This is useful for stats groups which can be very large, such as
+// vbucket-details on a large cluster.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) StatsStream(opts StatsOptions, valueCb StatsStreamValueCallback, cb StatsStreamCallback) (PendingOp, error) {
+	agent.connectTrigger.Trigger()
+	return agent.stats.StatsStream(opts, valueCb, cb)
+}
+
 // ObserveCallback is invoked upon completion of a Observe operation.
 type ObserveCallback func(*ObserveResult, error)
 
@@ -209,6 +252,84 @@ func (agent *Agent) AnalyticsQuery(opts AnalyticsQueryOptions, cb AnalyticsQuery
 	return agent.analytics.AnalyticsQuery(opts, cb)
 }
 
+// CreateAnalyticsDataverseCallback is invoked upon completion of a CreateAnalyticsDataverse operation.
+type CreateAnalyticsDataverseCallback func(*CreateAnalyticsDataverseResult, error)
+
+// CreateAnalyticsDataverse creates an analytics dataverse.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) CreateAnalyticsDataverse(opts CreateAnalyticsDataverseOptions, cb CreateAnalyticsDataverseCallback) (PendingOp, error) {
+	return agent.analytics.CreateAnalyticsDataverse(opts, cb)
+}
+
+// DropAnalyticsDataverseCallback is invoked upon completion of a DropAnalyticsDataverse operation.
+type DropAnalyticsDataverseCallback func(*DropAnalyticsDataverseResult, error)
+
+// DropAnalyticsDataverse drops an analytics dataverse.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) DropAnalyticsDataverse(opts DropAnalyticsDataverseOptions, cb DropAnalyticsDataverseCallback) (PendingOp, error) {
+	return agent.analytics.DropAnalyticsDataverse(opts, cb)
+}
+
+// CreateAnalyticsDatasetCallback is invoked upon completion of a CreateAnalyticsDataset operation.
+type CreateAnalyticsDatasetCallback func(*CreateAnalyticsDatasetResult, error)
+
+// CreateAnalyticsDataset creates an analytics dataset over a bucket.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) CreateAnalyticsDataset(opts CreateAnalyticsDatasetOptions, cb CreateAnalyticsDatasetCallback) (PendingOp, error) {
+	return agent.analytics.CreateAnalyticsDataset(opts, cb)
+}
+
+// DropAnalyticsDatasetCallback is invoked upon completion of a DropAnalyticsDataset operation.
+type DropAnalyticsDatasetCallback func(*DropAnalyticsDatasetResult, error)
+
+// DropAnalyticsDataset drops an analytics dataset.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) DropAnalyticsDataset(opts DropAnalyticsDatasetOptions, cb DropAnalyticsDatasetCallback) (PendingOp, error) {
+	return agent.analytics.DropAnalyticsDataset(opts, cb)
+}
+
+// GetAllAnalyticsDatasetsCallback is invoked upon completion of a GetAllAnalyticsDatasets operation.
+type GetAllAnalyticsDatasetsCallback func(*GetAllAnalyticsDatasetsResult, error)
+
+// GetAllAnalyticsDatasets fetches all of the analytics datasets.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetAllAnalyticsDatasets(opts GetAllAnalyticsDatasetsOptions, cb GetAllAnalyticsDatasetsCallback) (PendingOp, error) {
+	return agent.analytics.GetAllAnalyticsDatasets(opts, cb)
+}
+
+// UpsertAnalyticsLinkCallback is invoked upon completion of a CreateAnalyticsLink or ReplaceAnalyticsLink operation.
+type UpsertAnalyticsLinkCallback func(*UpsertAnalyticsLinkResult, error)
+
+// CreateAnalyticsLink creates an analytics link.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) CreateAnalyticsLink(opts UpsertAnalyticsLinkOptions, cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return agent.analytics.CreateAnalyticsLink(opts, cb)
+}
+
+// ReplaceAnalyticsLink replaces an existing analytics link.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ReplaceAnalyticsLink(opts UpsertAnalyticsLinkOptions, cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return agent.analytics.ReplaceAnalyticsLink(opts, cb)
+}
+
+// DropAnalyticsLinkCallback is invoked upon completion of a DropAnalyticsLink operation.
+type DropAnalyticsLinkCallback func(*DropAnalyticsLinkResult, error)
+
+// DropAnalyticsLink drops an analytics link.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) DropAnalyticsLink(opts DropAnalyticsLinkOptions, cb DropAnalyticsLinkCallback) (PendingOp, error) {
+	return agent.analytics.DropAnalyticsLink(opts, cb)
+}
+
+// GetAnalyticsLinksCallback is invoked upon completion of a GetAnalyticsLinks operation.
+type GetAnalyticsLinksCallback func(*GetAnalyticsLinksResult, error)
+
+// GetAnalyticsLinks fetches analytics links.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetAnalyticsLinks(opts GetAnalyticsLinksOptions, cb GetAnalyticsLinksCallback) (PendingOp, error) {
+	return agent.analytics.GetAnalyticsLinks(opts, cb)
+}
+
 // SearchQueryCallback is invoked upon completion of a SearchQuery operation.
 type SearchQueryCallback func(*SearchRowReader, error)
 
@@ -217,6 +338,51 @@ func (agent *Agent) SearchQuery(opts SearchQueryOptions, cb SearchQueryCallback)
 	return agent.search.SearchQuery(opts, cb)
 }
 
+// UpsertSearchIndexCallback is invoked upon completion of a UpsertSearchIndex operation.
+type UpsertSearchIndexCallback func(*UpsertSearchIndexResult, error)
+
+// UpsertSearchIndex creates or updates a search index definition.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) UpsertSearchIndex(opts UpsertSearchIndexOptions, cb UpsertSearchIndexCallback) (PendingOp, error) {
+	return agent.search.UpsertSearchIndex(opts, cb)
+}
+
+// GetSearchIndexCallback is invoked upon completion of a GetSearchIndex operation.
+type GetSearchIndexCallback func(*GetSearchIndexResult, error)
+
+// GetSearchIndex fetches a search index definition.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetSearchIndex(opts GetSearchIndexOptions, cb GetSearchIndexCallback) (PendingOp, error) {
+	return agent.search.GetSearchIndex(opts, cb)
+}
+
+// GetAllSearchIndexesCallback is invoked upon completion of a GetAllSearchIndexes operation.
+type GetAllSearchIndexesCallback func(*GetAllSearchIndexesResult, error)
+
+// GetAllSearchIndexes fetches all of the search index definitions.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetAllSearchIndexes(opts GetAllSearchIndexesOptions, cb GetAllSearchIndexesCallback) (PendingOp, error) {
+	return agent.search.GetAllSearchIndexes(opts, cb)
+}
+
+// DropSearchIndexCallback is invoked upon completion of a DropSearchIndex operation.
+type DropSearchIndexCallback func(*DropSearchIndexResult, error)
+
+// DropSearchIndex deletes a search index definition.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) DropSearchIndex(opts DropSearchIndexOptions, cb DropSearchIndexCallback) (PendingOp, error) {
+	return agent.search.DropSearchIndex(opts, cb)
+}
+
+// GetSearchIndexStatusCallback is invoked upon completion of a GetSearchIndexStatus operation.
+type GetSearchIndexStatusCallback func(*GetSearchIndexStatusResult, error)
+
+// GetSearchIndexStatus fetches the partitions that a search index has been planned into.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) GetSearchIndexStatus(opts GetSearchIndexStatusOptions, cb GetSearchIndexStatusCallback) (PendingOp, error) {
+	return agent.search.GetSearchIndexStatus(opts, cb)
+}
+
 // ViewQueryCallback is invoked upon completion of a ViewQuery operation.
 type ViewQueryCallback func(*ViewQueryRowReader, error)
 
@@ -240,6 +406,7 @@ type GetCollectionManifestCallback func(*GetCollectionManifestResult, error)
 // GetCollectionManifest fetches the current server manifest. This function will not update the client's collection
 // id cache.
 func (agent *Agent) GetCollectionManifest(opts GetCollectionManifestOptions, cb GetCollectionManifestCallback) (PendingOp, error) {
+	opts.Deadline = agent.kvTimeouts.Deadline(opts.Deadline)
 	return agent.collections.GetCollectionManifest(opts, cb)
 }
 
@@ -251,6 +418,7 @@ type GetAllCollectionManifestsCallback func(*GetAllCollectionManifestsResult, er
 // client's collection id cache.
 func (agent *Agent) GetAllCollectionManifests(opts GetAllCollectionManifestsOptions,
 	cb GetAllCollectionManifestsCallback) (PendingOp, error) {
+	opts.Deadline = agent.kvTimeouts.Deadline(opts.Deadline)
 	return agent.collections.GetAllCollectionManifests(opts, cb)
 }
 
@@ -260,6 +428,7 @@ type GetCollectionIDCallback func(*GetCollectionIDResult, error)
 // GetCollectionID fetches the collection id and manifest id that the collection belongs to, given a scope name
 // and collection name. This function will also prime the client's collection id cache.
 func (agent *Agent) GetCollectionID(scopeName string, collectionName string, opts GetCollectionIDOptions, cb GetCollectionIDCallback) (PendingOp, error) {
+	opts.Deadline = agent.kvTimeouts.Deadline(opts.Deadline)
 	return agent.collections.GetCollectionID(scopeName, collectionName, opts, cb)
 }
 
@@ -269,6 +438,7 @@ type PingCallback func(*PingResult, error)
 // Ping pings all of the servers we are connected to and returns
 // a report regarding the pings that were performed.
 func (agent *Agent) Ping(opts PingOptions, cb PingCallback) (PendingOp, error) {
+	agent.connectTrigger.Trigger()
 	return agent.diagnostics.Ping(opts, cb)
 }
 
@@ -279,5 +449,25 @@ func (agent *Agent) Diagnostics(opts DiagnosticsOptions) (*DiagnosticInfo, error
 	return agent.diagnostics.Diagnostics(opts)
 }
 
+// KvEndpointStats returns queue and connection counters for each kv endpoint that the agent is connected to.
+// This can be used to identify which node is the bottleneck when operations are timing out.
+func (agent *Agent) KvEndpointStats() ([]KvEndpointStats, error) {
+	return agent.diagnostics.KvEndpointStats()
+}
+
+// KvEndpointInfo returns the server version and negotiated HELLO features for each kv endpoint that the agent is
+// connected to. This can be used to decide whether functionality is available without needing to send a request.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) KvEndpointInfo() ([]KvEndpointInfo, error) {
+	return agent.diagnostics.KvEndpointInfo()
+}
+
+// Healthy checks whether the agent currently meets the supplied health requirements. When it does not then false is
+// returned along with an error wrapping ErrUnhealthy describing the requirements which were not met. Any deadline
+// on ctx is used as the deadline for pinging services.
+func (agent *Agent) Healthy(ctx context.Context, reqs HealthRequirements) (bool, error) {
+	return agent.health.Healthy(ctx, reqs)
+}
+
 // WaitUntilReadyCallback is invoked upon completion of a WaitUntilReady operation.
 type WaitUntilReadyCallback func(*WaitUntilReadyResult, error)
@@ -39,13 +39,20 @@ func CreateAgentGroup(config *AgentGroupConfig) (*AgentGroup, error) {
 
 	ag.clusterAgent = createClusterAgent(&clusterAgentConfig{
 		HTTPAddrs:                 config.HTTPAddrs,
-		UserAgent:                 config.UserAgent,
+		UserAgent:                 buildUserAgent(config.UserAgent, config.UserAgentComponents),
 		UseTLS:                    config.UseTLS,
 		Auth:                      config.Auth,
 		TLSRootCAProvider:         config.TLSRootCAProvider,
+		TLSSkipVerify:             config.TLSSkipVerify,
+		TLSVerifyPeerCertificate:  config.TLSVerifyPeerCertificate,
+		TLSPinnedPublicKeys:       config.TLSPinnedPublicKeys,
 		HTTPMaxIdleConns:          config.HTTPMaxIdleConns,
 		HTTPMaxIdleConnsPerHost:   config.HTTPMaxIdleConnsPerHost,
 		HTTPIdleConnectionTimeout: config.HTTPIdleConnectionTimeout,
+		HTTPDisableHTTP2:          config.HTTPDisableHTTP2,
+		HTTPDisableCompression:    config.HTTPDisableCompression,
+		HTTPServiceClientConfigs:  config.HTTPServiceClientConfigs,
+		HTTPRoundTrippers:         config.HTTPRoundTrippers,
 		Tracer:                    config.Tracer,
 		NoRootTraceSpans:          config.NoRootTraceSpans,
 		DefaultRetryStrategy:      config.DefaultRetryStrategy,
@@ -124,6 +131,22 @@ func (ag *AgentGroup) Close() error {
 	return firstError
 }
 
+// StartCompositeOperation starts a composite operation, such as a query which is to be consistent with the mutations
+// that precede it. Passing the TraceContext of the returned operation to each of its KV and HTTP operations parents
+// all of their spans under a single span and tags them with a shared operation ID.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) StartCompositeOperation(operationName string, parentContext RequestSpanContext) *CompositeOperation {
+	return ag.clusterAgent.tracer.StartCompositeOperation(operationName, parentContext)
+}
+
+// WatchMgmtStream starts watching a streaming management endpoint, such as /poolsStreaming/default, invoking the
+// callback with every update received. If the connection to a node fails then the watcher reconnects to the next
+// node, until the watcher is closed.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) WatchMgmtStream(opts WatchMgmtStreamOptions, cb MgmtStreamCallback) (*MgmtStreamWatcher, error) {
+	return newMgmtStreamWatcher(opts, ag.clusterAgent.http, ag.clusterAgent.httpMux, cb, nil)
+}
+
 // N1QLQuery executes a N1QL query against a random connected agent.
 // If no agent is connected then this will block until one is available or the deadline is reached.
 func (ag *AgentGroup) N1QLQuery(opts N1QLQueryOptions, cb N1QLQueryCallback) (PendingOp, error) {
@@ -142,12 +165,110 @@ func (ag *AgentGroup) AnalyticsQuery(opts AnalyticsQueryOptions, cb AnalyticsQue
 	return ag.clusterAgent.AnalyticsQuery(opts, cb)
 }
 
+// CreateAnalyticsDataverse creates an analytics dataverse using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) CreateAnalyticsDataverse(opts CreateAnalyticsDataverseOptions, cb CreateAnalyticsDataverseCallback) (PendingOp, error) {
+	return ag.clusterAgent.CreateAnalyticsDataverse(opts, cb)
+}
+
+// DropAnalyticsDataverse drops an analytics dataverse using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) DropAnalyticsDataverse(opts DropAnalyticsDataverseOptions, cb DropAnalyticsDataverseCallback) (PendingOp, error) {
+	return ag.clusterAgent.DropAnalyticsDataverse(opts, cb)
+}
+
+// CreateAnalyticsDataset creates an analytics dataset over a bucket using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) CreateAnalyticsDataset(opts CreateAnalyticsDatasetOptions, cb CreateAnalyticsDatasetCallback) (PendingOp, error) {
+	return ag.clusterAgent.CreateAnalyticsDataset(opts, cb)
+}
+
+// DropAnalyticsDataset drops an analytics dataset using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) DropAnalyticsDataset(opts DropAnalyticsDatasetOptions, cb DropAnalyticsDatasetCallback) (PendingOp, error) {
+	return ag.clusterAgent.DropAnalyticsDataset(opts, cb)
+}
+
+// GetAllAnalyticsDatasets fetches all of the analytics datasets using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) GetAllAnalyticsDatasets(opts GetAllAnalyticsDatasetsOptions, cb GetAllAnalyticsDatasetsCallback) (PendingOp, error) {
+	return ag.clusterAgent.GetAllAnalyticsDatasets(opts, cb)
+}
+
+// CreateAnalyticsLink creates an analytics link using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) CreateAnalyticsLink(opts UpsertAnalyticsLinkOptions, cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return ag.clusterAgent.CreateAnalyticsLink(opts, cb)
+}
+
+// ReplaceAnalyticsLink replaces an existing analytics link using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) ReplaceAnalyticsLink(opts UpsertAnalyticsLinkOptions, cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return ag.clusterAgent.ReplaceAnalyticsLink(opts, cb)
+}
+
+// DropAnalyticsLink drops an analytics link using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) DropAnalyticsLink(opts DropAnalyticsLinkOptions, cb DropAnalyticsLinkCallback) (PendingOp, error) {
+	return ag.clusterAgent.DropAnalyticsLink(opts, cb)
+}
+
+// GetAnalyticsLinks fetches analytics links using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) GetAnalyticsLinks(opts GetAnalyticsLinksOptions, cb GetAnalyticsLinksCallback) (PendingOp, error) {
+	return ag.clusterAgent.GetAnalyticsLinks(opts, cb)
+}
+
 // SearchQuery executes a Search query against a random connected agent.
 // If no agent is connected then this will block until one is available or the deadline is reached.
 func (ag *AgentGroup) SearchQuery(opts SearchQueryOptions, cb SearchQueryCallback) (PendingOp, error) {
 	return ag.clusterAgent.SearchQuery(opts, cb)
 }
 
+// UpsertSearchIndex creates or updates a search index definition using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) UpsertSearchIndex(opts UpsertSearchIndexOptions, cb UpsertSearchIndexCallback) (PendingOp, error) {
+	return ag.clusterAgent.UpsertSearchIndex(opts, cb)
+}
+
+// GetSearchIndex fetches a search index definition using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) GetSearchIndex(opts GetSearchIndexOptions, cb GetSearchIndexCallback) (PendingOp, error) {
+	return ag.clusterAgent.GetSearchIndex(opts, cb)
+}
+
+// GetAllSearchIndexes fetches all of the search index definitions using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) GetAllSearchIndexes(opts GetAllSearchIndexesOptions, cb GetAllSearchIndexesCallback) (PendingOp, error) {
+	return ag.clusterAgent.GetAllSearchIndexes(opts, cb)
+}
+
+// DropSearchIndex deletes a search index definition using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) DropSearchIndex(opts DropSearchIndexOptions, cb DropSearchIndexCallback) (PendingOp, error) {
+	return ag.clusterAgent.DropSearchIndex(opts, cb)
+}
+
+// GetSearchIndexStatus fetches the partitions that a search index has been planned into using a random connected agent.
+// If no agent is connected then this will block until one is available or the deadline is reached.
+// Volatile: This API is subject to change at any time.
+func (ag *AgentGroup) GetSearchIndexStatus(opts GetSearchIndexStatusOptions, cb GetSearchIndexStatusCallback) (PendingOp, error) {
+	return ag.clusterAgent.GetSearchIndexStatus(opts, cb)
+}
+
 // ViewQuery executes a view query against a random connected agent.
 // If no agent is connected then this will block until one is available or the deadline is reached.
 func (ag *AgentGroup) ViewQuery(opts ViewQueryOptions, cb ViewQueryCallback) (PendingOp, error) {
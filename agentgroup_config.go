@@ -15,42 +15,90 @@ func (config *AgentGroupConfig) FromConnStr(connStr string) error {
 	return config.AgentConfig.FromConnStr(connStr)
 }
 
+// FromConnStrWithOptions populates the AgentGroupConfig with information from a
+// Couchbase Connection String, see AgentConfig.FromConnStrWithOptions.
+func (config *AgentGroupConfig) FromConnStrWithOptions(connStr string, opts FromConnStrOptions) ([]ConnStrWarning, error) {
+	return config.AgentConfig.FromConnStrWithOptions(connStr, opts)
+}
+
 func (config *AgentGroupConfig) toAgentConfig() *AgentConfig {
 	return &AgentConfig{
-		MemdAddrs:                 config.MemdAddrs,
-		HTTPAddrs:                 config.HTTPAddrs,
-		BucketName:                config.BucketName,
-		UserAgent:                 config.UserAgent,
-		UseTLS:                    config.UseTLS,
-		NetworkType:               config.NetworkType,
-		Auth:                      config.Auth,
-		TLSRootCAProvider:         config.TLSRootCAProvider,
-		UseMutationTokens:         config.UseMutationTokens,
-		UseCompression:            config.UseCompression,
-		UseDurations:              config.UseDurations,
-		DisableDecompression:      config.DisableDecompression,
-		UseOutOfOrderResponses:    config.UseOutOfOrderResponses,
-		UseCollections:            config.UseCollections,
-		CompressionMinSize:        config.CompressionMinSize,
-		CompressionMinRatio:       config.CompressionMinRatio,
-		HTTPRedialPeriod:          config.HTTPRedialPeriod,
-		HTTPRetryDelay:            config.HTTPRetryDelay,
-		CccpMaxWait:               config.CccpMaxWait,
-		CccpPollPeriod:            config.CccpPollPeriod,
-		ConnectTimeout:            config.ConnectTimeout,
-		KVConnectTimeout:          config.KVConnectTimeout,
-		KvPoolSize:                config.KvPoolSize,
-		MaxQueueSize:              config.MaxQueueSize,
-		HTTPMaxIdleConns:          config.HTTPMaxIdleConns,
-		HTTPMaxIdleConnsPerHost:   config.HTTPMaxIdleConnsPerHost,
-		HTTPIdleConnectionTimeout: config.HTTPIdleConnectionTimeout,
-		Tracer:                    config.Tracer,
-		NoRootTraceSpans:          config.NoRootTraceSpans,
-		DefaultRetryStrategy:      config.DefaultRetryStrategy,
-		CircuitBreakerConfig:      config.CircuitBreakerConfig,
-		UseZombieLogger:           config.UseZombieLogger,
-		ZombieLoggerInterval:      config.ZombieLoggerInterval,
-		ZombieLoggerSampleSize:    config.ZombieLoggerSampleSize,
-		AuthMechanisms:            config.AuthMechanisms,
+		MemdAddrs:                        config.MemdAddrs,
+		HTTPAddrs:                        config.HTTPAddrs,
+		BucketName:                       config.BucketName,
+		UserAgent:                        config.UserAgent,
+		ClientID:                         config.ClientID,
+		UserAgentComponents:              config.UserAgentComponents,
+		UseTLS:                           config.UseTLS,
+		NetworkType:                      config.NetworkType,
+		AddressTranslator:                config.AddressTranslator,
+		NetworkResolver:                  config.NetworkResolver,
+		KetamaHasher:                     config.KetamaHasher,
+		Auth:                             config.Auth,
+		TLSRootCAProvider:                config.TLSRootCAProvider,
+		TLSSkipVerify:                    config.TLSSkipVerify,
+		TLSVerifyPeerCertificate:         config.TLSVerifyPeerCertificate,
+		TLSPinnedPublicKeys:              config.TLSPinnedPublicKeys,
+		UseMutationTokens:                config.UseMutationTokens,
+		UseCompression:                   config.UseCompression,
+		UseDurations:                     config.UseDurations,
+		UseResourceUnits:                 config.UseResourceUnits,
+		DisableDecompression:             config.DisableDecompression,
+		UseOutOfOrderResponses:           config.UseOutOfOrderResponses,
+		UseCollections:                   config.UseCollections,
+		CompressionMinSize:               config.CompressionMinSize,
+		CompressionMinRatio:              config.CompressionMinRatio,
+		HTTPRedialPeriod:                 config.HTTPRedialPeriod,
+		HTTPRetryDelay:                   config.HTTPRetryDelay,
+		CccpMaxWait:                      config.CccpMaxWait,
+		CccpPollPeriod:                   config.CccpPollPeriod,
+		ConnectTimeout:                   config.ConnectTimeout,
+		KVConnectTimeout:                 config.KVConnectTimeout,
+		KvPoolSize:                       config.KvPoolSize,
+		MaxQueueSize:                     config.MaxQueueSize,
+		KvMaxInFlight:                    config.KvMaxInFlight,
+		MemdDialer:                       config.MemdDialer,
+		HTTPMaxIdleConns:                 config.HTTPMaxIdleConns,
+		HTTPMaxIdleConnsPerHost:          config.HTTPMaxIdleConnsPerHost,
+		HTTPIdleConnectionTimeout:        config.HTTPIdleConnectionTimeout,
+		HTTPDisableHTTP2:                 config.HTTPDisableHTTP2,
+		HTTPDisableCompression:           config.HTTPDisableCompression,
+		HTTPServiceClientConfigs:         config.HTTPServiceClientConfigs,
+		Tracer:                           config.Tracer,
+		NoRootTraceSpans:                 config.NoRootTraceSpans,
+		DefaultRetryStrategy:             config.DefaultRetryStrategy,
+		CircuitBreakerConfig:             config.CircuitBreakerConfig,
+		UseZombieLogger:                  config.UseZombieLogger,
+		ZombieLoggerInterval:             config.ZombieLoggerInterval,
+		ZombieLoggerSampleSize:           config.ZombieLoggerSampleSize,
+		AuthMechanisms:                   config.AuthMechanisms,
+		EnableHelloFeatures:              config.EnableHelloFeatures,
+		DisableHelloFeatures:             config.DisableHelloFeatures,
+		UseGetCoalescing:                 config.UseGetCoalescing,
+		DefaultKvTimeout:                 config.DefaultKvTimeout,
+		KvTimerResolution:                config.KvTimerResolution,
+		LazyConnect:                      config.LazyConnect,
+		SeedConfig:                       config.SeedConfig,
+		SeedConfigSourceHost:             config.SeedConfigSourceHost,
+		ClusterConfigProvider:            config.ClusterConfigProvider,
+		ClusterConfigStore:               config.ClusterConfigStore,
+		DisableConfigPolling:             config.DisableConfigPolling,
+		BootstrapAttemptCallback:         config.BootstrapAttemptCallback,
+		EndpointEventCallback:            config.EndpointEventCallback,
+		TopologyChangeCallback:           config.TopologyChangeCallback,
+		BucketRecreatedCallback:          config.BucketRecreatedCallback,
+		CollectionManifestPollInterval:   config.CollectionManifestPollInterval,
+		CollectionManifestChangeCallback: config.CollectionManifestChangeCallback,
+		PipelineBackpressureConfig:       config.PipelineBackpressureConfig,
+		ReconnectBackoffConfig:           config.ReconnectBackoffConfig,
+		DeadPipelineRetryConfig:          config.DeadPipelineRetryConfig,
+		MaxConnectionAge:                 config.MaxConnectionAge,
+		KeepAliveConfig:                  config.KeepAliveConfig,
+		LatencyProbeConfig:               config.LatencyProbeConfig,
+		KVInterceptors:                   config.KVInterceptors,
+		KVRetryClassifier:                config.KVRetryClassifier,
+		KVStatusRetryOverrides:           config.KVStatusRetryOverrides,
+		HTTPRoundTrippers:                config.HTTPRoundTrippers,
+		ServerWaitTimeout:                config.ServerWaitTimeout,
 	}
 }
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -34,6 +38,13 @@ func (q *AnalyticsRowReader) Close() error {
 	return q.streamer.Close()
 }
 
+// WriteRowsTo streams the remaining rows into w as newline delimited JSON, returning the number of rows written.
+// Rows are only read from the network as quickly as w accepts them.
+// Volatile: This API is subject to change at any time.
+func (q *AnalyticsRowReader) WriteRowsTo(w io.Writer) (int, error) {
+	return q.streamer.WriteRowsTo(w)
+}
+
 // AnalyticsQueryOptions represents the various options available for an analytics query.
 type AnalyticsQueryOptions struct {
 	Payload       []byte
@@ -71,6 +82,8 @@ type jsonAnalyticsError struct {
 	Msg  string `json:"msg"`
 }
 
+var analyticsErrorCodeRegexp = regexp.MustCompile(`\b2[0-5]\d{3}\b`)
+
 type jsonAnalyticsErrorResponse struct {
 	Errors []jsonAnalyticsError
 }
@@ -91,6 +104,13 @@ func parseAnalyticsError(req *httpRequest, statement string, resp *HTTPResponse)
 					Message: jsonErr.Msg,
 				})
 			}
+		} else if errCode := analyticsErrorCodeRegexp.FindString(string(respBody)); errCode != "" {
+			// Some endpoints, such as link management, report errors as plain text which embeds the error code.
+			code, _ := strconv.ParseUint(errCode, 10, 32)
+			errorDescs = append(errorDescs, AnalyticsErrorDesc{
+				Code:    uint32(code),
+				Message: strings.TrimSpace(string(respBody)),
+			})
 		}
 	}
 
@@ -139,10 +159,20 @@ func parseAnalyticsError(req *httpRequest, statement string, resp *HTTPResponse)
 		if errCode == 24006 {
 			err = errLinkNotFound
 		}
+		if errCode == 24055 {
+			err = errLinkExists
+		}
+	}
+
+	if resp.StatusCode == 429 {
+		err = errRateLimitedFailure
 	}
 
 	errOut := wrapAnalyticsError(req, statement, err)
 	errOut.Errors = errorDescs
+	if errors.Is(err, ErrRateLimitedFailure) {
+		errOut.RetryAfter = rateLimitedRetryAfter(resp)
+	}
 	return errOut
 }
 
@@ -232,6 +262,9 @@ func (aqc *analyticsQueryComponent) AnalyticsQuery(opts AnalyticsQueryOptions, c
 						retryReason = AnalyticsTemporaryFailureRetryReason
 					}
 				}
+				if errors.Is(analyticsErr.InnerError, ErrRateLimitedFailure) {
+					retryReason = RateLimitedRetryReason
+				}
 
 				if retryReason == nil {
 					cancel()
@@ -240,7 +273,9 @@ func (aqc *analyticsQueryComponent) AnalyticsQuery(opts AnalyticsQueryOptions, c
 					return
 				}
 
+				ireq.retryAfter = analyticsErr.RetryAfter
 				shouldRetry, retryTime := retryOrchMaybeRetry(ireq, retryReason)
+				ireq.retryAfter = 0
 				if !shouldRetry {
 					cancel()
 					// analyticsErr is already wrapped here
@@ -0,0 +1,680 @@
+package gocbcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AnalyticsLinkType specifies the type of an analytics link.
+// Volatile: This API is subject to change at any time.
+type AnalyticsLinkType string
+
+const (
+	// AnalyticsLinkTypeCouchbaseRemote indicates a link to a remote Couchbase cluster.
+	AnalyticsLinkTypeCouchbaseRemote = AnalyticsLinkType("couchbase")
+
+	// AnalyticsLinkTypeS3External indicates a link to an AWS S3 compatible service.
+	AnalyticsLinkTypeS3External = AnalyticsLinkType("s3")
+)
+
+// AnalyticsEncryptionLevel specifies how a remote Couchbase analytics link secures its connection.
+// Volatile: This API is subject to change at any time.
+type AnalyticsEncryptionLevel string
+
+const (
+	// AnalyticsEncryptionLevelNone indicates that no encryption is used.
+	AnalyticsEncryptionLevelNone = AnalyticsEncryptionLevel("none")
+
+	// AnalyticsEncryptionLevelHalf indicates that only credentials are encrypted.
+	AnalyticsEncryptionLevelHalf = AnalyticsEncryptionLevel("half")
+
+	// AnalyticsEncryptionLevelFull indicates that all traffic is encrypted.
+	AnalyticsEncryptionLevelFull = AnalyticsEncryptionLevel("full")
+)
+
+// AnalyticsLink represents an analytics link, which fields apply depends on the Type of the link.
+// Secrets are never returned by the server and so are always empty on links that have been fetched.
+// Volatile: This API is subject to change at any time.
+type AnalyticsLink struct {
+	// Scope is the dataverse that the link belongs to, in the form "bucket/scope" or "dataverse".
+	Scope string
+	Name  string
+	Type  AnalyticsLinkType
+
+	// Used by AnalyticsLinkTypeCouchbaseRemote links.
+	Hostname          string
+	EncryptionLevel   AnalyticsEncryptionLevel
+	Username          string
+	Password          string
+	Certificate       string
+	ClientCertificate string
+	ClientKey         string
+
+	// Used by AnalyticsLinkTypeS3External links.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	ServiceEndpoint string
+}
+
+// AnalyticsDataset represents an analytics dataset.
+// Volatile: This API is subject to change at any time.
+type AnalyticsDataset struct {
+	Name          string `json:"DatasetName"`
+	DataverseName string `json:"DataverseName"`
+	LinkName      string `json:"LinkName"`
+	BucketName    string `json:"BucketName"`
+}
+
+// CreateAnalyticsDataverseOptions encapsulates the parameters for a CreateAnalyticsDataverse operation.
+// Volatile: This API is subject to change at any time.
+type CreateAnalyticsDataverseOptions struct {
+	// DataverseName is the name of the dataverse, in the form "bucket/scope" or "dataverse".
+	DataverseName  string
+	IgnoreIfExists bool
+	RetryStrategy  RetryStrategy
+	Deadline       time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// CreateAnalyticsDataverseResult encapsulates the result of a CreateAnalyticsDataverse operation.
+// Volatile: This API is subject to change at any time.
+type CreateAnalyticsDataverseResult struct {
+}
+
+// DropAnalyticsDataverseOptions encapsulates the parameters for a DropAnalyticsDataverse operation.
+// Volatile: This API is subject to change at any time.
+type DropAnalyticsDataverseOptions struct {
+	DataverseName     string
+	IgnoreIfNotExists bool
+	RetryStrategy     RetryStrategy
+	Deadline          time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// DropAnalyticsDataverseResult encapsulates the result of a DropAnalyticsDataverse operation.
+// Volatile: This API is subject to change at any time.
+type DropAnalyticsDataverseResult struct {
+}
+
+// CreateAnalyticsDatasetOptions encapsulates the parameters for a CreateAnalyticsDataset operation.
+// Volatile: This API is subject to change at any time.
+type CreateAnalyticsDatasetOptions struct {
+	DatasetName string
+	// DataverseName is optional, the default dataverse is used if it is not set.
+	DataverseName string
+	BucketName    string
+	// Condition is an optional WHERE clause used to filter the documents included in the dataset.
+	Condition      string
+	IgnoreIfExists bool
+	RetryStrategy  RetryStrategy
+	Deadline       time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// CreateAnalyticsDatasetResult encapsulates the result of a CreateAnalyticsDataset operation.
+// Volatile: This API is subject to change at any time.
+type CreateAnalyticsDatasetResult struct {
+}
+
+// DropAnalyticsDatasetOptions encapsulates the parameters for a DropAnalyticsDataset operation.
+// Volatile: This API is subject to change at any time.
+type DropAnalyticsDatasetOptions struct {
+	DatasetName       string
+	DataverseName     string
+	IgnoreIfNotExists bool
+	RetryStrategy     RetryStrategy
+	Deadline          time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// DropAnalyticsDatasetResult encapsulates the result of a DropAnalyticsDataset operation.
+// Volatile: This API is subject to change at any time.
+type DropAnalyticsDatasetResult struct {
+}
+
+// GetAllAnalyticsDatasetsOptions encapsulates the parameters for a GetAllAnalyticsDatasets operation.
+// Volatile: This API is subject to change at any time.
+type GetAllAnalyticsDatasetsOptions struct {
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// GetAllAnalyticsDatasetsResult encapsulates the result of a GetAllAnalyticsDatasets operation.
+// Volatile: This API is subject to change at any time.
+type GetAllAnalyticsDatasetsResult struct {
+	Datasets []AnalyticsDataset
+}
+
+// UpsertAnalyticsLinkOptions encapsulates the parameters for a CreateAnalyticsLink or ReplaceAnalyticsLink operation.
+// Volatile: This API is subject to change at any time.
+type UpsertAnalyticsLinkOptions struct {
+	Link          AnalyticsLink
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// UpsertAnalyticsLinkResult encapsulates the result of a CreateAnalyticsLink or ReplaceAnalyticsLink operation.
+// Volatile: This API is subject to change at any time.
+type UpsertAnalyticsLinkResult struct {
+}
+
+// DropAnalyticsLinkOptions encapsulates the parameters for a DropAnalyticsLink operation.
+// Volatile: This API is subject to change at any time.
+type DropAnalyticsLinkOptions struct {
+	Scope         string
+	Name          string
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// DropAnalyticsLinkResult encapsulates the result of a DropAnalyticsLink operation.
+// Volatile: This API is subject to change at any time.
+type DropAnalyticsLinkResult struct {
+}
+
+// GetAnalyticsLinksOptions encapsulates the parameters for a GetAnalyticsLinks operation.
+// Volatile: This API is subject to change at any time.
+type GetAnalyticsLinksOptions struct {
+	// Scope restricts the links returned to a single dataverse, all links are returned if it is not set.
+	Scope string
+	// Name restricts the links returned to a single link, Scope must also be set.
+	Name string
+	// Type restricts the links returned to a single type.
+	Type          AnalyticsLinkType
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// GetAnalyticsLinksResult encapsulates the result of a GetAnalyticsLinks operation.
+// Volatile: This API is subject to change at any time.
+type GetAnalyticsLinksResult struct {
+	Links []AnalyticsLink
+}
+
+type jsonAnalyticsLink struct {
+	Scope             string `json:"scope"`
+	Dataverse         string `json:"dataverse"`
+	Name              string `json:"name"`
+	Type              string `json:"type"`
+	ActiveHostname    string `json:"activeHostname"`
+	Encryption        string `json:"encryption"`
+	Username          string `json:"username"`
+	Certificate       string `json:"certificate"`
+	ClientCertificate string `json:"clientCertificate"`
+	AccessKeyID       string `json:"accessKeyId"`
+	Region            string `json:"region"`
+	ServiceEndpoint   string `json:"serviceEndpoint"`
+}
+
+func (link jsonAnalyticsLink) toAnalyticsLink() AnalyticsLink {
+	scope := link.Scope
+	if scope == "" {
+		scope = link.Dataverse
+	}
+
+	return AnalyticsLink{
+		Scope:             scope,
+		Name:              link.Name,
+		Type:              AnalyticsLinkType(link.Type),
+		Hostname:          link.ActiveHostname,
+		EncryptionLevel:   AnalyticsEncryptionLevel(link.Encryption),
+		Username:          link.Username,
+		Certificate:       link.Certificate,
+		ClientCertificate: link.ClientCertificate,
+		AccessKeyID:       link.AccessKeyID,
+		Region:            link.Region,
+		ServiceEndpoint:   link.ServiceEndpoint,
+	}
+}
+
+func analyticsLinkFormValues(link AnalyticsLink) (url.Values, error) {
+	if link.Scope == "" {
+		return nil, wrapError(errInvalidArgument, "link scope cannot be empty")
+	}
+	if link.Name == "" {
+		return nil, wrapError(errInvalidArgument, "link name cannot be empty")
+	}
+
+	values := url.Values{}
+	values.Set("type", string(link.Type))
+	addIfSet := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+
+	switch link.Type {
+	case AnalyticsLinkTypeCouchbaseRemote:
+		if link.Hostname == "" {
+			return nil, wrapError(errInvalidArgument, "hostname cannot be empty for couchbase links")
+		}
+		encryption := link.EncryptionLevel
+		if encryption == "" {
+			encryption = AnalyticsEncryptionLevelNone
+		}
+		values.Set("hostname", link.Hostname)
+		values.Set("encryption", string(encryption))
+		addIfSet("username", link.Username)
+		addIfSet("password", link.Password)
+		addIfSet("certificate", link.Certificate)
+		addIfSet("clientCertificate", link.ClientCertificate)
+		addIfSet("clientKey", link.ClientKey)
+	case AnalyticsLinkTypeS3External:
+		if link.AccessKeyID == "" || link.SecretAccessKey == "" || link.Region == "" {
+			return nil, wrapError(errInvalidArgument, "access key id, secret access key and region must be set for s3 links")
+		}
+		values.Set("accessKeyId", link.AccessKeyID)
+		values.Set("secretAccessKey", link.SecretAccessKey)
+		values.Set("region", link.Region)
+		addIfSet("sessionToken", link.SessionToken)
+		addIfSet("serviceEndpoint", link.ServiceEndpoint)
+	default:
+		return nil, wrapError(errInvalidArgument, fmt.Sprintf("unsupported link type %s", link.Type))
+	}
+
+	return values, nil
+}
+
+func analyticsLinkPath(scope, name string) string {
+	path := "/analytics/link"
+	if scope != "" {
+		path += "/" + url.PathEscape(scope)
+		if name != "" {
+			path += "/" + url.PathEscape(name)
+		}
+	}
+	return path
+}
+
+// analyticsIdentifier quotes a dataverse, or dataset, name for use in a statement. Names of the form
+// "bucket/scope" are split into their parts, any backticks within a part are escaped by doubling them.
+func analyticsIdentifier(names ...string) string {
+	var parts []string
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		for _, part := range strings.Split(name, "/") {
+			parts = append(parts, "`"+strings.ReplaceAll(part, "`", "``")+"`")
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// CreateAnalyticsDataverse creates an analytics dataverse.
+func (aqc *analyticsQueryComponent) CreateAnalyticsDataverse(opts CreateAnalyticsDataverseOptions,
+	cb CreateAnalyticsDataverseCallback) (PendingOp, error) {
+	if opts.DataverseName == "" {
+		return nil, wrapAnalyticsError(nil, "", wrapError(errInvalidArgument, "dataverse name cannot be empty"))
+	}
+
+	statement := "CREATE DATAVERSE " + analyticsIdentifier(opts.DataverseName)
+	if opts.IgnoreIfExists {
+		statement += " IF NOT EXISTS"
+	}
+
+	return aqc.executeManagementStatement("CreateAnalyticsDataverse", statement, opts.Deadline, opts.RetryStrategy,
+		opts.TraceContext, func(_ [][]byte, err error) {
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			cb(&CreateAnalyticsDataverseResult{}, nil)
+		})
+}
+
+// DropAnalyticsDataverse drops an analytics dataverse.
+func (aqc *analyticsQueryComponent) DropAnalyticsDataverse(opts DropAnalyticsDataverseOptions,
+	cb DropAnalyticsDataverseCallback) (PendingOp, error) {
+	if opts.DataverseName == "" {
+		return nil, wrapAnalyticsError(nil, "", wrapError(errInvalidArgument, "dataverse name cannot be empty"))
+	}
+
+	statement := "DROP DATAVERSE " + analyticsIdentifier(opts.DataverseName)
+	if opts.IgnoreIfNotExists {
+		statement += " IF EXISTS"
+	}
+
+	return aqc.executeManagementStatement("DropAnalyticsDataverse", statement, opts.Deadline, opts.RetryStrategy,
+		opts.TraceContext, func(_ [][]byte, err error) {
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			cb(&DropAnalyticsDataverseResult{}, nil)
+		})
+}
+
+// CreateAnalyticsDataset creates an analytics dataset over a bucket.
+func (aqc *analyticsQueryComponent) CreateAnalyticsDataset(opts CreateAnalyticsDatasetOptions,
+	cb CreateAnalyticsDatasetCallback) (PendingOp, error) {
+	if opts.DatasetName == "" {
+		return nil, wrapAnalyticsError(nil, "", wrapError(errInvalidArgument, "dataset name cannot be empty"))
+	}
+	if opts.BucketName == "" {
+		return nil, wrapAnalyticsError(nil, "", wrapError(errInvalidArgument, "bucket name cannot be empty"))
+	}
+
+	statement := "CREATE DATASET "
+	if opts.IgnoreIfExists {
+		statement += "IF NOT EXISTS "
+	}
+	statement += analyticsIdentifier(opts.DataverseName, opts.DatasetName) + " ON " +
+		analyticsIdentifier(opts.BucketName)
+	if opts.Condition != "" {
+		statement += " WHERE " + opts.Condition
+	}
+
+	return aqc.executeManagementStatement("CreateAnalyticsDataset", statement, opts.Deadline, opts.RetryStrategy,
+		opts.TraceContext, func(_ [][]byte, err error) {
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			cb(&CreateAnalyticsDatasetResult{}, nil)
+		})
+}
+
+// DropAnalyticsDataset drops an analytics dataset.
+func (aqc *analyticsQueryComponent) DropAnalyticsDataset(opts DropAnalyticsDatasetOptions,
+	cb DropAnalyticsDatasetCallback) (PendingOp, error) {
+	if opts.DatasetName == "" {
+		return nil, wrapAnalyticsError(nil, "", wrapError(errInvalidArgument, "dataset name cannot be empty"))
+	}
+
+	statement := "DROP DATASET " + analyticsIdentifier(opts.DataverseName, opts.DatasetName)
+	if opts.IgnoreIfNotExists {
+		statement += " IF EXISTS"
+	}
+
+	return aqc.executeManagementStatement("DropAnalyticsDataset", statement, opts.Deadline, opts.RetryStrategy,
+		opts.TraceContext, func(_ [][]byte, err error) {
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			cb(&DropAnalyticsDatasetResult{}, nil)
+		})
+}
+
+// GetAllAnalyticsDatasets fetches all of the analytics datasets, excluding those in the Metadata dataverse.
+func (aqc *analyticsQueryComponent) GetAllAnalyticsDatasets(opts GetAllAnalyticsDatasetsOptions,
+	cb GetAllAnalyticsDatasetsCallback) (PendingOp, error) {
+	statement := "SELECT d.* FROM Metadata.`Dataset` d WHERE d.DataverseName <> \"Metadata\""
+
+	return aqc.executeManagementStatement("GetAllAnalyticsDatasets", statement, opts.Deadline, opts.RetryStrategy,
+		opts.TraceContext, func(rows [][]byte, err error) {
+			if err != nil {
+				cb(nil, err)
+				return
+			}
+
+			datasets := make([]AnalyticsDataset, 0, len(rows))
+			for _, row := range rows {
+				var dataset AnalyticsDataset
+				err := json.Unmarshal(row, &dataset)
+				if err != nil {
+					cb(nil, wrapAnalyticsError(nil, statement, wrapError(err, "failed to parse dataset")))
+					return
+				}
+				datasets = append(datasets, dataset)
+			}
+
+			cb(&GetAllAnalyticsDatasetsResult{
+				Datasets: datasets,
+			}, nil)
+		})
+}
+
+// CreateAnalyticsLink creates an analytics link.
+func (aqc *analyticsQueryComponent) CreateAnalyticsLink(opts UpsertAnalyticsLinkOptions,
+	cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return aqc.upsertAnalyticsLink("CreateAnalyticsLink", "POST", opts, cb)
+}
+
+// ReplaceAnalyticsLink replaces an existing analytics link.
+func (aqc *analyticsQueryComponent) ReplaceAnalyticsLink(opts UpsertAnalyticsLinkOptions,
+	cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return aqc.upsertAnalyticsLink("ReplaceAnalyticsLink", "PUT", opts, cb)
+}
+
+func (aqc *analyticsQueryComponent) upsertAnalyticsLink(opName, method string, opts UpsertAnalyticsLinkOptions,
+	cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	tracer := aqc.tracer.CreateOpTrace(opName, opts.TraceContext)
+	defer tracer.Finish()
+
+	values, err := analyticsLinkFormValues(opts.Link)
+	if err != nil {
+		return nil, wrapAnalyticsError(nil, "", err)
+	}
+
+	ireq := newAnalyticsManagementRequest(method, analyticsLinkPath(opts.Link.Scope, opts.Link.Name),
+		[]byte(values.Encode()), false, opts.Deadline, opts.RetryStrategy, tracer.RootContext())
+	ireq.ContentType = "application/x-www-form-urlencoded"
+
+	aqc.executeManagementRequest(opName, ireq, func(_ []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		cb(&UpsertAnalyticsLinkResult{}, nil)
+	})
+
+	return ireq, nil
+}
+
+// DropAnalyticsLink drops an analytics link.
+func (aqc *analyticsQueryComponent) DropAnalyticsLink(opts DropAnalyticsLinkOptions,
+	cb DropAnalyticsLinkCallback) (PendingOp, error) {
+	tracer := aqc.tracer.CreateOpTrace("DropAnalyticsLink", opts.TraceContext)
+	defer tracer.Finish()
+
+	if opts.Scope == "" || opts.Name == "" {
+		return nil, wrapAnalyticsError(nil, "", wrapError(errInvalidArgument, "link scope and name cannot be empty"))
+	}
+
+	ireq := newAnalyticsManagementRequest("DELETE", analyticsLinkPath(opts.Scope, opts.Name), nil, false,
+		opts.Deadline, opts.RetryStrategy, tracer.RootContext())
+
+	aqc.executeManagementRequest("DropAnalyticsLink", ireq, func(_ []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		cb(&DropAnalyticsLinkResult{}, nil)
+	})
+
+	return ireq, nil
+}
+
+// GetAnalyticsLinks fetches analytics links.
+func (aqc *analyticsQueryComponent) GetAnalyticsLinks(opts GetAnalyticsLinksOptions,
+	cb GetAnalyticsLinksCallback) (PendingOp, error) {
+	tracer := aqc.tracer.CreateOpTrace("GetAnalyticsLinks", opts.TraceContext)
+	defer tracer.Finish()
+
+	if opts.Name != "" && opts.Scope == "" {
+		return nil, wrapAnalyticsError(nil, "", wrapError(errInvalidArgument, "link scope must be set when name is set"))
+	}
+
+	path := analyticsLinkPath(opts.Scope, opts.Name)
+	if opts.Type != "" {
+		path += "?" + url.Values{"type": []string{string(opts.Type)}}.Encode()
+	}
+
+	ireq := newAnalyticsManagementRequest("GET", path, nil, true, opts.Deadline, opts.RetryStrategy,
+		tracer.RootContext())
+
+	aqc.executeManagementRequest("GetAnalyticsLinks", ireq, func(body []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		var jsonLinks []jsonAnalyticsLink
+		err = json.Unmarshal(body, &jsonLinks)
+		if err != nil {
+			cb(nil, wrapAnalyticsError(ireq, "", wrapError(err, "failed to parse links")))
+			return
+		}
+
+		links := make([]AnalyticsLink, len(jsonLinks))
+		for i, link := range jsonLinks {
+			links[i] = link.toAnalyticsLink()
+		}
+
+		cb(&GetAnalyticsLinksResult{
+			Links: links,
+		}, nil)
+	})
+
+	return ireq, nil
+}
+
+// executeManagementStatement runs a management statement as an analytics query and invokes cb with all of the rows
+// that it returned.
+func (aqc *analyticsQueryComponent) executeManagementStatement(opName, statement string, deadline time.Time,
+	retryStrat RetryStrategy, traceCtx RequestSpanContext, cb func([][]byte, error)) (PendingOp, error) {
+	tracer := aqc.tracer.CreateOpTrace(opName, traceCtx)
+	defer tracer.Finish()
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"statement": statement,
+	})
+	if err != nil {
+		return nil, wrapAnalyticsError(nil, statement, wrapError(err, "failed to produce payload"))
+	}
+
+	return aqc.AnalyticsQuery(AnalyticsQueryOptions{
+		Payload:       payload,
+		RetryStrategy: retryStrat,
+		Deadline:      deadline,
+		TraceContext:  tracer.RootContext(),
+	}, func(reader *AnalyticsRowReader, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		var rows [][]byte
+		for row := reader.NextRow(); row != nil; row = reader.NextRow() {
+			rows = append(rows, row)
+		}
+
+		err = reader.Err()
+		if err != nil {
+			cb(nil, wrapAnalyticsError(nil, statement, err))
+			return
+		}
+
+		cb(rows, nil)
+	})
+}
+
+func newAnalyticsManagementRequest(method, path string, body []byte, idempotent bool, deadline time.Time,
+	retryStrat RetryStrategy, traceCtx RequestSpanContext) *httpRequest {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &httpRequest{
+		Service:          CbasService,
+		Method:           method,
+		Path:             path,
+		Body:             body,
+		IsIdempotent:     idempotent,
+		Deadline:         deadline,
+		RetryStrategy:    retryStrat,
+		RootTraceContext: traceCtx,
+		Context:          ctx,
+		CancelFunc:       cancel,
+	}
+}
+
+// executeManagementRequest dispatches a management request, retrying it whilst the service is rate limiting us,
+// and invokes cb with the body of the successful response.
+func (aqc *analyticsQueryComponent) executeManagementRequest(opName string, ireq *httpRequest, cb func([]byte, error)) {
+	start := time.Now()
+
+	go func() {
+		defer ireq.CancelFunc()
+
+		for {
+			resp, err := aqc.httpComponent.DoInternalHTTPRequest(ireq, false)
+			if err != nil {
+				cb(nil, wrapAnalyticsError(ireq, "", err))
+				return
+			}
+
+			if resp.StatusCode != 200 {
+				analyticsErr := parseAnalyticsError(ireq, "", resp)
+				if closeErr := resp.Body.Close(); closeErr != nil {
+					logDebugf("Failed to close analytics management response body: %s", closeErr)
+				}
+
+				if resp.StatusCode != 429 {
+					cb(nil, analyticsErr)
+					return
+				}
+
+				ireq.retryAfter = analyticsErr.RetryAfter
+				shouldRetry, retryTime := retryOrchMaybeRetry(ireq, RateLimitedRetryReason)
+				ireq.retryAfter = 0
+				if !shouldRetry {
+					cb(nil, analyticsErr)
+					return
+				}
+
+				err := waitForHTTPRetry(ireq.Context, ireq, opName, ireq.Endpoint, retryTime, start)
+				if err != nil {
+					cb(nil, wrapAnalyticsError(ireq, "", err))
+					return
+				}
+
+				continue
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if closeErr := resp.Body.Close(); closeErr != nil {
+				logDebugf("Failed to close analytics management response body: %s", closeErr)
+			}
+			if err != nil {
+				cb(nil, wrapAnalyticsError(ireq, "", err))
+				return
+			}
+
+			cb(body, nil)
+			return
+		}
+	}()
+}
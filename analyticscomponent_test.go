@@ -4,7 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -331,3 +336,215 @@ func (suite *StandardTestSuite) TestAnalyticsTimeout() {
 		}
 	}
 }
+
+func (suite *UnitTestSuite) TestAnalyticsIdentifier() {
+	suite.Assert().Equal("`travel`.`inventory`", analyticsIdentifier("travel/inventory"))
+	suite.Assert().Equal("`travel`.`inventory`.`ds`", analyticsIdentifier("travel/inventory", "ds"))
+	suite.Assert().Equal("`a``b`", analyticsIdentifier("", "a`b"))
+	suite.Assert().Equal("```; DROP DATAVERSE x; --`", analyticsIdentifier("`; DROP DATAVERSE x; --"))
+}
+
+func (suite *UnitTestSuite) TestAnalyticsManagement() {
+	var lock sync.Mutex
+	var statements []string
+	links := make(map[string]url.Values)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		body, err := ioutil.ReadAll(r.Body)
+		suite.Assert().Nil(err)
+
+		if r.URL.Path == "/query/service" {
+			var payload map[string]interface{}
+			suite.Assert().Nil(json.Unmarshal(body, &payload))
+			statement := payload["statement"].(string)
+			statements = append(statements, statement)
+
+			if statement == "CREATE DATAVERSE `exists`" {
+				w.WriteHeader(500)
+				_, _ = w.Write([]byte(`{"errors":[{"code":24039,"msg":"A dataverse with this name exists already"}]}`))
+				return
+			}
+
+			results := "[]"
+			if strings.HasPrefix(statement, "SELECT") {
+				results = `[{"DatasetName":"ds","DataverseName":"travel/inventory","LinkName":"Local","BucketName":"travel"}]`
+			}
+			_, _ = w.Write([]byte(`{"results":` + results + `,"status":"success"}`))
+			return
+		}
+
+		// The scope is escaped so the path cannot be split before being unescaped.
+		parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/analytics/link/"), "/")
+		scope, _ := url.PathUnescape(parts[0])
+		key := scope + "." + parts[1]
+		switch r.Method {
+		case "POST":
+			if _, ok := links[key]; ok {
+				w.WriteHeader(409)
+				_, _ = w.Write([]byte("24055: Link [" + key + "] already exists"))
+				return
+			}
+			suite.Assert().Equal("application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+			values, err := url.ParseQuery(string(body))
+			suite.Assert().Nil(err)
+			links[key] = values
+		case "GET":
+			values := links[key]
+			suite.Assert().Equal("s3", r.URL.Query().Get("type"))
+			_, _ = w.Write([]byte(`[{"scope":"` + scope + `","name":"` + parts[1] + `","type":"` + values.Get("type") +
+				`","accessKeyId":"` + values.Get("accessKeyId") + `","region":"` + values.Get("region") + `"}]`))
+		case "DELETE":
+			if _, ok := links[key]; !ok {
+				w.WriteHeader(404)
+				_, _ = w.Write([]byte("24006: Link [" + key + "] does not exist"))
+				return
+			}
+			delete(links, key)
+		}
+	}))
+	defer srv.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, cbasEpList: []string{srv.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	httpCpt := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, tracer)
+	analyticsCpt := newAnalyticsQueryComponent(httpCpt, tracer)
+	deadline := time.Now().Add(5 * time.Second)
+
+	errCh := make(chan error, 1)
+	_, err := analyticsCpt.CreateAnalyticsDataverse(CreateAnalyticsDataverseOptions{
+		DataverseName:  "travel/inventory",
+		IgnoreIfExists: true,
+		Deadline:       deadline,
+	}, func(res *CreateAnalyticsDataverseResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-errCh)
+
+	_, err = analyticsCpt.CreateAnalyticsDataverse(CreateAnalyticsDataverseOptions{
+		DataverseName: "exists",
+		Deadline:      deadline,
+	}, func(res *CreateAnalyticsDataverseResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	err = <-errCh
+	suite.Assert().True(errors.Is(err, ErrDataverseExists), err)
+
+	_, err = analyticsCpt.CreateAnalyticsDataset(CreateAnalyticsDatasetOptions{
+		DatasetName:   "ds",
+		DataverseName: "travel/inventory",
+		BucketName:    "travel",
+		Condition:     "`type` = \"airline\"",
+		Deadline:      deadline,
+	}, func(res *CreateAnalyticsDatasetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-errCh)
+
+	datasetsCh := make(chan *GetAllAnalyticsDatasetsResult, 1)
+	_, err = analyticsCpt.GetAllAnalyticsDatasets(GetAllAnalyticsDatasetsOptions{
+		Deadline: deadline,
+	}, func(res *GetAllAnalyticsDatasetsResult, err error) {
+		suite.Assert().Nil(err)
+		datasetsCh <- res
+	})
+	suite.Require().Nil(err)
+	datasetsRes := <-datasetsCh
+	suite.Require().NotNil(datasetsRes)
+	suite.Assert().Equal([]AnalyticsDataset{{
+		Name:          "ds",
+		DataverseName: "travel/inventory",
+		LinkName:      "Local",
+		BucketName:    "travel",
+	}}, datasetsRes.Datasets)
+
+	_, err = analyticsCpt.DropAnalyticsDataset(DropAnalyticsDatasetOptions{
+		DatasetName:       "ds",
+		DataverseName:     "travel/inventory",
+		IgnoreIfNotExists: true,
+		Deadline:          deadline,
+	}, func(res *DropAnalyticsDatasetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-errCh)
+
+	lock.Lock()
+	suite.Assert().Equal([]string{
+		"CREATE DATAVERSE `travel`.`inventory` IF NOT EXISTS",
+		"CREATE DATAVERSE `exists`",
+		"CREATE DATASET `travel`.`inventory`.`ds` ON `travel` WHERE `type` = \"airline\"",
+		"SELECT d.* FROM Metadata.`Dataset` d WHERE d.DataverseName <> \"Metadata\"",
+		"DROP DATASET `travel`.`inventory`.`ds` IF EXISTS",
+	}, statements)
+	lock.Unlock()
+
+	link := AnalyticsLink{
+		Scope:           "travel/inventory",
+		Name:            "s3link",
+		Type:            AnalyticsLinkTypeS3External,
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		Region:          "us-east-1",
+	}
+	for i := 0; i < 2; i++ {
+		_, err = analyticsCpt.CreateAnalyticsLink(UpsertAnalyticsLinkOptions{
+			Link:     link,
+			Deadline: deadline,
+		}, func(res *UpsertAnalyticsLinkResult, err error) {
+			errCh <- err
+		})
+		suite.Require().Nil(err)
+		err = <-errCh
+		if i == 0 {
+			suite.Require().Nil(err)
+		} else {
+			suite.Assert().True(errors.Is(err, ErrLinkExists), err)
+		}
+	}
+
+	linksCh := make(chan *GetAnalyticsLinksResult, 1)
+	_, err = analyticsCpt.GetAnalyticsLinks(GetAnalyticsLinksOptions{
+		Scope:    "travel/inventory",
+		Name:     "s3link",
+		Type:     AnalyticsLinkTypeS3External,
+		Deadline: deadline,
+	}, func(res *GetAnalyticsLinksResult, err error) {
+		suite.Assert().Nil(err)
+		linksCh <- res
+	})
+	suite.Require().Nil(err)
+	linksRes := <-linksCh
+	suite.Require().NotNil(linksRes)
+	// Secrets are never returned by the server.
+	link.SecretAccessKey = ""
+	suite.Assert().Equal([]AnalyticsLink{link}, linksRes.Links)
+
+	for i := 0; i < 2; i++ {
+		_, err = analyticsCpt.DropAnalyticsLink(DropAnalyticsLinkOptions{
+			Scope:    "travel/inventory",
+			Name:     "s3link",
+			Deadline: deadline,
+		}, func(res *DropAnalyticsLinkResult, err error) {
+			errCh <- err
+		})
+		suite.Require().Nil(err)
+		err = <-errCh
+		if i == 0 {
+			suite.Require().Nil(err)
+		} else {
+			suite.Assert().True(errors.Is(err, ErrLinkNotFound), err)
+		}
+	}
+
+	_, err = analyticsCpt.CreateAnalyticsLink(UpsertAnalyticsLinkOptions{
+		Link: AnalyticsLink{Scope: "travel/inventory", Name: "cblink", Type: AnalyticsLinkTypeCouchbaseRemote},
+	}, func(*UpsertAnalyticsLinkResult, error) {})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
@@ -1,6 +1,9 @@
 package gocbcore
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+	"fmt"
+)
 
 // UserPassPair represents a username and password pair.
 type UserPassPair struct {
@@ -12,6 +15,10 @@ type UserPassPair struct {
 type AuthCredsRequest struct {
 	Service  ServiceType
 	Endpoint string
+
+	// BucketName is the bucket which the agent is using, this is empty when the agent is not using a bucket. This
+	// allows providers to return different credentials per bucket, for example bucket passwords on legacy clusters.
+	BucketName string
 }
 
 // AuthCertRequest represents a certificate details request from the agent.
@@ -29,6 +36,24 @@ type AuthProvider interface {
 	Credentials(req AuthCredsRequest) ([]UserPassPair, error)
 }
 
+// GSSAPISecurityContext is a GSS-API security context for a single connection, typically backed by a Kerberos
+// library.
+// Volatile: This API is subject to change at any time.
+type GSSAPISecurityContext interface {
+	// InitSecContext processes a token received from the server, which is nil on the first call, and returns the
+	// token to send to the server along with whether the context has now been established.
+	InitSecContext(inputToken []byte) (outputToken []byte, established bool, err error)
+	Unwrap(token []byte) ([]byte, error)
+	Wrap(payload []byte) ([]byte, error)
+}
+
+// GSSAPIAuthProvider is implemented by AuthProviders which support the GSSAPI auth mechanism. A new security context
+// is requested for every connection that is authenticated.
+// Volatile: This API is subject to change at any time.
+type GSSAPIAuthProvider interface {
+	GSSAPISecurityContext(req AuthCredsRequest) (GSSAPISecurityContext, error)
+}
+
 func getSingleAuthCreds(auth AuthProvider, req AuthCredsRequest) (UserPassPair, error) {
 	creds, err := auth.Credentials(req)
 	if err != nil {
@@ -42,10 +67,11 @@ func getSingleAuthCreds(auth AuthProvider, req AuthCredsRequest) (UserPassPair,
 	return creds[0], nil
 }
 
-func getKvAuthCreds(auth AuthProvider, endpoint string) (UserPassPair, error) {
+func getKvAuthCreds(auth AuthProvider, endpoint, bucketName string) (UserPassPair, error) {
 	return getSingleAuthCreds(auth, AuthCredsRequest{
-		Service:  MemdService,
-		Endpoint: endpoint,
+		Service:    MemdService,
+		Endpoint:   endpoint,
+		BucketName: bucketName,
 	})
 }
 
@@ -78,3 +104,13 @@ func (auth PasswordAuthProvider) Credentials(req AuthCredsRequest) ([]UserPassPa
 		Password: auth.Password,
 	}}, nil
 }
+
+// String returns a representation of the provider which is safe to log, the password is never included.
+func (auth PasswordAuthProvider) String() string {
+	username := auth.Username
+	if !isLogRedactionLevelNone() {
+		username = redactUserData(username)
+	}
+
+	return fmt.Sprintf("{Username:%s Password:***}", username)
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha1" // nolint: gosec
 	"crypto/sha256"
 	"crypto/sha512"
+	"errors"
 	"hash"
 	"time"
 
@@ -27,6 +28,11 @@ const (
 
 	// ScramSha512AuthMechanism represents that SCRAM SHA512 auth should be performed.
 	ScramSha512AuthMechanism = AuthMechanism("SCRAM-SHA512")
+
+	// GSSAPIAuthMechanism represents that GSSAPI (Kerberos) auth should be performed, the AuthProvider used by the
+	// agent must implement GSSAPIAuthProvider.
+	// Volatile: This API is subject to change at any time.
+	GSSAPIAuthMechanism = AuthMechanism("GSSAPI")
 )
 
 // AuthClient exposes an interface for performing authentication on a
@@ -40,6 +46,14 @@ type AuthClient interface {
 	SaslStep(k, v []byte, deadline time.Time, cb func(err error)) error
 }
 
+// MultiStepAuthClient is an AuthClient which can also return the server challenge from SASL steps, as required by
+// mechanisms which need more than a single continuation such as GSSAPI.
+// Volatile: This API is subject to change at any time.
+type MultiStepAuthClient interface {
+	AuthClient
+	SaslContinue(k, v []byte, deadline time.Time, cb func(b []byte, err error)) error
+}
+
 // SaslListMechsCompleted is used to contain the result and/or error from a SaslListMechs operation.
 type SaslListMechsCompleted struct {
 	Err   error
@@ -72,9 +86,20 @@ func SaslAuthPlain(username, password string, client AuthClient, deadline time.T
 	return nil
 }
 
+// saslAuthScram performs SCRAM SASL authentication, reusing salted passwords from saltedPasswords if it is not nil.
 func saslAuthScram(saslName []byte, newHash func() hash.Hash, username, password string, client AuthClient,
-	deadline time.Time, continueCb func(), completedCb func(err error)) error {
+	deadline time.Time, saltedPasswords *scram.SaltedPasswordCache, continueCb func(), completedCb func(err error)) error {
 	scramMgr := scram.NewClient(newHash, username, password)
+	scramMgr.SetSaltedPasswordCache(saltedPasswords)
+
+	userCompletedCb := completedCb
+	completedCb = func(err error) {
+		if errors.Is(err, ErrAuthenticationFailure) {
+			// The salted password may be stale, such as if the user was recreated, so the next attempt derives it again.
+			saltedPasswords.Invalidate(username)
+		}
+		userCompletedCb(err)
+	}
 
 	// Perform the initial SASL step
 	scramMgr.Step(nil)
@@ -113,29 +138,110 @@ func saslAuthScram(saslName []byte, newHash func() hash.Hash, username, password
 
 // SaslAuthScramSha1 performs SCRAM-SHA1 SASL authentication against an AuthClient.
 func SaslAuthScramSha1(username, password string, client AuthClient, deadline time.Time, continueCb func(), completedCb func(err error)) error {
-	return saslAuthScram([]byte("SCRAM-SHA1"), sha1.New, username, password, client, deadline, continueCb, completedCb)
+	return saslAuthScram([]byte("SCRAM-SHA1"), sha1.New, username, password, client, deadline, nil, continueCb,
+		completedCb)
 }
 
 // SaslAuthScramSha256 performs SCRAM-SHA256 SASL authentication against an AuthClient.
 func SaslAuthScramSha256(username, password string, client AuthClient, deadline time.Time, continueCb func(), completedCb func(err error)) error {
-	return saslAuthScram([]byte("SCRAM-SHA256"), sha256.New, username, password, client, deadline, continueCb, completedCb)
+	return saslAuthScram([]byte("SCRAM-SHA256"), sha256.New, username, password, client, deadline, nil, continueCb,
+		completedCb)
 }
 
 // SaslAuthScramSha512 performs SCRAM-SHA512 SASL authentication against an AuthClient.
 func SaslAuthScramSha512(username, password string, client AuthClient, deadline time.Time, continueCb func(), completedCb func(err error)) error {
-	return saslAuthScram([]byte("SCRAM-SHA512"), sha512.New, username, password, client, deadline, continueCb, completedCb)
+	return saslAuthScram([]byte("SCRAM-SHA512"), sha512.New, username, password, client, deadline, nil, continueCb,
+		completedCb)
+}
+
+// SaslAuthGSSAPI performs GSSAPI SASL authentication against an AuthClient as described by RFC 4752. No security
+// layer is negotiated, authzID may be empty to act as the identity authenticated by secCtx.
+// Volatile: This API is subject to change at any time.
+func SaslAuthGSSAPI(secCtx GSSAPISecurityContext, authzID string, client MultiStepAuthClient, deadline time.Time,
+	completedCb func(err error)) error {
+	saslName := []byte(GSSAPIAuthMechanism)
+
+	token, established, err := secCtx.InitSecContext(nil)
+	if err != nil {
+		return err
+	}
+
+	var handleChallenge func(challenge []byte, err error)
+	handleChallenge = func(challenge []byte, err error) {
+		if err == nil {
+			if !established {
+				completedCb(wrapError(errAuthenticationFailure, "server completed gssapi auth before the security context was established"))
+				return
+			}
+
+			completedCb(nil)
+			return
+		}
+
+		if !isErrorStatus(err, memd.StatusAuthContinue) {
+			completedCb(err)
+			return
+		}
+
+		var resp []byte
+		if !established {
+			resp, established, err = secCtx.InitSecContext(challenge)
+		} else {
+			resp, err = gssapiSecurityLayerResponse(secCtx, challenge, authzID)
+		}
+		if err != nil {
+			completedCb(err)
+			return
+		}
+
+		err = client.SaslContinue(saslName, resp, deadline, handleChallenge)
+		if err != nil {
+			completedCb(err)
+		}
+	}
+
+	return client.SaslAuth(saslName, token, deadline, handleChallenge)
+}
+
+// gssapiSecurityLayerResponse builds the final response of the GSSAPI exchange, the server challenge contains the
+// security layers that it supports and we always select none.
+func gssapiSecurityLayerResponse(secCtx GSSAPISecurityContext, challenge []byte, authzID string) ([]byte, error) {
+	const gssapiNoSecurityLayer = 0x01
+
+	layers, err := secCtx.Unwrap(challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(layers) != 4 {
+		return nil, wrapError(errAuthenticationFailure, "invalid gssapi security layer challenge")
+	}
+
+	if layers[0]&gssapiNoSecurityLayer == 0 {
+		return nil, wrapError(errAuthenticationFailure, "server requires a gssapi security layer")
+	}
+
+	resp := make([]byte, 4+len(authzID))
+	resp[0] = gssapiNoSecurityLayer
+	copy(resp[4:], authzID)
+
+	return secCtx.Wrap(resp)
 }
 
-func saslMethod(method AuthMechanism, username, password string, client AuthClient, deadline time.Time, continueCb func(), completedCb func(err error)) error {
+func saslMethod(method AuthMechanism, username, password string, client AuthClient, deadline time.Time,
+	saltedPasswords *scram.SaltedPasswordCache, continueCb func(), completedCb func(err error)) error {
 	switch method {
 	case PlainAuthMechanism:
 		return SaslAuthPlain(username, password, client, deadline, completedCb)
 	case ScramSha1AuthMechanism:
-		return SaslAuthScramSha1(username, password, client, deadline, continueCb, completedCb)
+		return saslAuthScram([]byte("SCRAM-SHA1"), sha1.New, username, password, client, deadline, saltedPasswords,
+			continueCb, completedCb)
 	case ScramSha256AuthMechanism:
-		return SaslAuthScramSha256(username, password, client, deadline, continueCb, completedCb)
+		return saslAuthScram([]byte("SCRAM-SHA256"), sha256.New, username, password, client, deadline, saltedPasswords,
+			continueCb, completedCb)
 	case ScramSha512AuthMechanism:
-		return SaslAuthScramSha512(username, password, client, deadline, continueCb, completedCb)
+		return saslAuthScram([]byte("SCRAM-SHA512"), sha512.New, username, password, client, deadline, saltedPasswords,
+			continueCb, completedCb)
 	default:
 		return errNoSupportedMechanisms
 	}
@@ -0,0 +1,278 @@
+package gocbcore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	scram "github.com/couchbase/gocbcore/v9/scram"
+)
+
+type mockGSSAPISecurityContext struct {
+	tokens [][]byte
+	inputs [][]byte
+}
+
+func (ctx *mockGSSAPISecurityContext) InitSecContext(inputToken []byte) ([]byte, bool, error) {
+	ctx.inputs = append(ctx.inputs, inputToken)
+	token := ctx.tokens[0]
+	ctx.tokens = ctx.tokens[1:]
+	return token, len(ctx.tokens) == 0, nil
+}
+
+func (ctx *mockGSSAPISecurityContext) Unwrap(token []byte) ([]byte, error) {
+	return bytes.TrimPrefix(token, []byte("wrapped:")), nil
+}
+
+func (ctx *mockGSSAPISecurityContext) Wrap(payload []byte) ([]byte, error) {
+	return append([]byte("wrapped:"), payload...), nil
+}
+
+type mockSaslExchange struct {
+	challenge []byte
+	complete  bool
+}
+
+type mockMultiStepAuthClient struct {
+	exchanges []mockSaslExchange
+	received  [][]byte
+}
+
+func (client *mockMultiStepAuthClient) Address() string {
+	return "127.0.0.1:11210"
+}
+
+func (client *mockMultiStepAuthClient) SupportsFeature(feature memd.HelloFeature) bool {
+	return false
+}
+
+func (client *mockMultiStepAuthClient) SaslListMechs(deadline time.Time, cb func(mechs []AuthMechanism, err error)) error {
+	return errors.New("not implemented")
+}
+
+func (client *mockMultiStepAuthClient) respond(v []byte, cb func(b []byte, err error)) error {
+	client.received = append(client.received, v)
+	exchange := client.exchanges[0]
+	client.exchanges = client.exchanges[1:]
+	if exchange.complete {
+		cb(nil, nil)
+		return nil
+	}
+
+	cb(exchange.challenge, &KeyValueError{
+		StatusCode: memd.StatusAuthContinue,
+	})
+	return nil
+}
+
+func (client *mockMultiStepAuthClient) SaslAuth(k, v []byte, deadline time.Time, cb func(b []byte, err error)) error {
+	return client.respond(v, cb)
+}
+
+func (client *mockMultiStepAuthClient) SaslStep(k, v []byte, deadline time.Time, cb func(err error)) error {
+	return errors.New("not implemented")
+}
+
+func (client *mockMultiStepAuthClient) SaslContinue(k, v []byte, deadline time.Time, cb func(b []byte, err error)) error {
+	return client.respond(v, cb)
+}
+
+func (suite *UnitTestSuite) TestSaslAuthGSSAPI() {
+	secCtx := &mockGSSAPISecurityContext{
+		tokens: [][]byte{[]byte("token1"), []byte("token2")},
+	}
+	client := &mockMultiStepAuthClient{
+		exchanges: []mockSaslExchange{
+			{challenge: []byte("challenge1")},
+			{challenge: []byte("wrapped:\x07\x00\x10\x00")},
+			{complete: true},
+		},
+	}
+
+	var completedErr error
+	completed := false
+	err := SaslAuthGSSAPI(secCtx, "user", client, time.Now().Add(time.Second), func(err error) {
+		completed = true
+		completedErr = err
+	})
+	suite.Require().Nil(err)
+	suite.Require().True(completed)
+	suite.Require().Nil(completedErr)
+
+	suite.Assert().Equal([][]byte{nil, []byte("challenge1")}, secCtx.inputs)
+	suite.Assert().Equal([][]byte{
+		[]byte("token1"),
+		[]byte("token2"),
+		[]byte("wrapped:\x01\x00\x00\x00user"),
+	}, client.received)
+}
+
+func (suite *UnitTestSuite) TestSaslAuthGSSAPIRequiresNoSecurityLayer() {
+	secCtx := &mockGSSAPISecurityContext{
+		tokens: [][]byte{[]byte("token1")},
+	}
+	client := &mockMultiStepAuthClient{
+		exchanges: []mockSaslExchange{
+			{challenge: []byte("wrapped:\x06\x00\x10\x00")},
+		},
+	}
+
+	var completedErr error
+	err := SaslAuthGSSAPI(secCtx, "", client, time.Now().Add(time.Second), func(err error) {
+		completedErr = err
+	})
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(completedErr, ErrAuthenticationFailure))
+}
+
+// mockScramAuthClient plays the server side of a SCRAM exchange, always sending the same salt and iteration count.
+type mockScramAuthClient struct {
+	stepErr error
+}
+
+func (client *mockScramAuthClient) Address() string {
+	return "127.0.0.1:11210"
+}
+
+func (client *mockScramAuthClient) SupportsFeature(feature memd.HelloFeature) bool {
+	return false
+}
+
+func (client *mockScramAuthClient) SaslListMechs(deadline time.Time, cb func(mechs []AuthMechanism, err error)) error {
+	return errors.New("not implemented")
+}
+
+func (client *mockScramAuthClient) SaslAuth(k, v []byte, deadline time.Time, cb func(b []byte, err error)) error {
+	clientNonce := v[bytes.Index(v, []byte(",r="))+3:]
+	challenge := append(append([]byte("r="), clientNonce...), []byte("srv,s=c2FsdHNhbHQ=,i=4096")...)
+	cb(challenge, &KeyValueError{
+		StatusCode: memd.StatusAuthContinue,
+	})
+	return nil
+}
+
+func (client *mockScramAuthClient) SaslStep(k, v []byte, deadline time.Time, cb func(err error)) error {
+	cb(client.stepErr)
+	return nil
+}
+
+func (suite *UnitTestSuite) TestSaslAuthScramSaltedPasswordCache() {
+	var hashes int32
+	newHash := func() hash.Hash {
+		atomic.AddInt32(&hashes, 1)
+		return sha256.New()
+	}
+
+	saltedPasswords := scram.NewSaltedPasswordCache()
+	auth := func(password string, stepErr error) (int32, error) {
+		atomic.StoreInt32(&hashes, 0)
+		errCh := make(chan error, 1)
+		err := saslAuthScram([]byte("SCRAM-SHA256"), newHash, "scramcacheuser", password,
+			&mockScramAuthClient{stepErr: stepErr}, time.Now().Add(time.Second), saltedPasswords, func() {}, func(err error) {
+				errCh <- err
+			})
+		suite.Require().Nil(err)
+		err = <-errCh
+		return atomic.LoadInt32(&hashes), err
+	}
+
+	uncachedHashes, err := auth("password", nil)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(1, saltedPasswords.Len())
+
+	// The second connection reuses the salted password rather than deriving it again.
+	cachedHashes, err := auth("password", nil)
+	suite.Require().Nil(err)
+	suite.Assert().Less(cachedHashes, uncachedHashes)
+	suite.Assert().Equal(1, saltedPasswords.Len())
+
+	// A changed password isn't served from the cache.
+	changedHashes, err := auth("changed", nil)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uncachedHashes, changedHashes)
+
+	// Failing to authenticate removes the user's salted passwords.
+	_, err = auth("changed", errAuthenticationFailure)
+	suite.Assert().True(errors.Is(err, ErrAuthenticationFailure))
+	suite.Assert().Zero(saltedPasswords.Len())
+
+	hashesAfterFailure, err := auth("changed", nil)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uncachedHashes, hashesAfterFailure)
+
+	// Agents clear their cache when they are closed.
+	saltedPasswords.Clear()
+	suite.Assert().Zero(saltedPasswords.Len())
+}
+
+// recordingAuthProvider returns a different username for every bucket, recording the requests that it receives.
+type recordingAuthProvider struct {
+	PasswordAuthProvider
+	lock     sync.Mutex
+	requests []AuthCredsRequest
+}
+
+func (auth *recordingAuthProvider) Credentials(req AuthCredsRequest) ([]UserPassPair, error) {
+	auth.lock.Lock()
+	auth.requests = append(auth.requests, req)
+	auth.lock.Unlock()
+
+	return []UserPassPair{{Username: req.BucketName, Password: "password"}}, nil
+}
+
+func (auth *recordingAuthProvider) lastRequest() AuthCredsRequest {
+	auth.lock.Lock()
+	defer auth.lock.Unlock()
+	return auth.requests[len(auth.requests)-1]
+}
+
+func (suite *UnitTestSuite) TestAuthCredsRequestBucketName() {
+	auth := &recordingAuthProvider{}
+
+	authFn := buildAuthHandler(auth, nil)(&mockScramAuthClient{}, time.Now().Add(time.Second), PlainAuthMechanism,
+		"legacy")
+	suite.Assert().NotNil(authFn)
+	suite.Assert().Equal(AuthCredsRequest{
+		Service:    MemdService,
+		Endpoint:   "127.0.0.1:11210",
+		BucketName: "legacy",
+	}, auth.lastRequest())
+
+	var usernames []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, _, _ := r.BasicAuth()
+		usernames = append(usernames, username)
+	}))
+	defer srv.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, mgmtEpList: []string{srv.URL}})
+	hc := newHTTPComponent(httpComponentProps{BucketName: "default"}, &http.Client{}, mux, auth,
+		newTracerComponent(&noopTracer{}, "", true))
+
+	doRequest := func() {
+		resp, err := hc.DoInternalHTTPRequest(&httpRequest{
+			Service:  MgmtService,
+			Method:   "GET",
+			Path:     "/pools",
+			Deadline: time.Now().Add(5 * time.Second),
+		}, false)
+		suite.Require().Nil(err)
+		suite.Require().Nil(resp.Body.Close())
+	}
+
+	doRequest()
+	suite.Assert().Equal("default", auth.lastRequest().BucketName)
+
+	hc.SetBucketName("other")
+	doRequest()
+	suite.Assert().Equal("other", auth.lastRequest().BucketName)
+	suite.Assert().Equal([]string{"default", "other"}, usernames)
+}
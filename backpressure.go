@@ -0,0 +1,41 @@
+package gocbcore
+
+import "time"
+
+const defaultPipelineBackpressureMaxWait = 2500 * time.Millisecond
+
+// PipelineBackpressureMode specifies how the agent behaves when an operation is dispatched to a pipeline
+// whose queue is already full.
+type PipelineBackpressureMode uint32
+
+const (
+	// PipelineBackpressureFailFast causes the operation to fail immediately with ErrOverload.
+	PipelineBackpressureFailFast = PipelineBackpressureMode(0)
+
+	// PipelineBackpressureBlock causes the dispatching goroutine to block until the pipeline has space for
+	// the operation or MaxWait is reached, in which case the operation fails with ErrOverload.
+	PipelineBackpressureBlock = PipelineBackpressureMode(1)
+
+	// PipelineBackpressureCallback causes Handler to be invoked to decide whether dispatching the operation
+	// should be attempted again.
+	PipelineBackpressureCallback = PipelineBackpressureMode(2)
+)
+
+// PipelineBackpressureHandler is invoked when an operation could not be queued because the pipeline for address
+// is full. Attempt is the number of times that the operation has found the pipeline full. Returning true causes
+// the operation to be dispatched again, returning false causes it to fail with ErrOverload.
+// The handler is invoked on the dispatching goroutine so may block, e.g. to sleep before trying again.
+type PipelineBackpressureHandler func(address string, attempt uint32) bool
+
+// PipelineBackpressureConfig is the set of configuration settings for controlling how operations are handled
+// when they are dispatched to a full pipeline.
+type PipelineBackpressureConfig struct {
+	Mode PipelineBackpressureMode
+
+	// MaxWait is the maximum length of time to block for when using PipelineBackpressureBlock.
+	// Defaults to 2500ms.
+	MaxWait time.Duration
+
+	// Handler is required when using PipelineBackpressureCallback.
+	Handler PipelineBackpressureHandler
+}
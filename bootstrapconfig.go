@@ -0,0 +1,55 @@
+package gocbcore
+
+import (
+	"sync"
+)
+
+// bootstrapConfigComponent tracks which nodes have already had their config fetched whilst a kv connection was
+// bootstrapping, so that only the first connection to each node does so. Any others would almost always fetch a
+// config which is already applied.
+type bootstrapConfigComponent struct {
+	lock  sync.Mutex
+	nodes map[string]struct{}
+}
+
+func newBootstrapConfigComponent(cfgMgr configManager) *bootstrapConfigComponent {
+	bcc := &bootstrapConfigComponent{
+		nodes: make(map[string]struct{}),
+	}
+	cfgMgr.AddConfigWatcher(bcc)
+
+	return bcc
+}
+
+// Wanted returns whether a kv connection to address should fetch the config whilst bootstrapping.
+func (bcc *bootstrapConfigComponent) Wanted(address string) bool {
+	bcc.lock.Lock()
+	_, ok := bcc.nodes[address]
+	bcc.lock.Unlock()
+
+	return !ok
+}
+
+// Received records that the config has been fetched from address. Nodes are only marked once their config has
+// actually been received so that a node whose first connection fails to bootstrap fetches it on the next.
+func (bcc *bootstrapConfigComponent) Received(address string) {
+	bcc.lock.Lock()
+	bcc.nodes[address] = struct{}{}
+	bcc.lock.Unlock()
+}
+
+func (bcc *bootstrapConfigComponent) OnNewRouteConfig(cfg *routeConfig) {
+	current := make(map[string]struct{}, len(cfg.kvServerList))
+	for _, address := range cfg.kvServerList {
+		current[address] = struct{}{}
+	}
+
+	// Nodes which have left the cluster are forgotten, should one rejoin then its config is fetched again.
+	bcc.lock.Lock()
+	for address := range bcc.nodes {
+		if _, ok := current[address]; !ok {
+			delete(bcc.nodes, address)
+		}
+	}
+	bcc.lock.Unlock()
+}
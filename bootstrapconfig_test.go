@@ -0,0 +1,18 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestBootstrapConfigNodes() {
+	bcc := newBootstrapConfigComponent(newConfigManager(configManagerProperties{}))
+
+	// A node is only marked once its config has been received, a failed bootstrap leaves it wanting the config.
+	suite.Assert().True(bcc.Wanted("node1:11210"))
+	bcc.Received("node1:11210")
+	bcc.Received("node2:11210")
+	suite.Assert().False(bcc.Wanted("node1:11210"))
+	suite.Assert().False(bcc.Wanted("node2:11210"))
+
+	// Nodes which leave the cluster are forgotten.
+	bcc.OnNewRouteConfig(&routeConfig{kvServerList: []string{"node1:11210"}})
+	suite.Assert().False(bcc.Wanted("node1:11210"))
+	suite.Assert().True(bcc.Wanted("node2:11210"))
+	suite.Assert().Len(bcc.nodes, 1)
+}
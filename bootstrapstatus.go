@@ -0,0 +1,137 @@
+package gocbcore
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BootstrapResult describes the outcome of an attempt to bootstrap against a node.
+type BootstrapResult uint32
+
+const (
+	// BootstrapResultSuccess indicates that the connection was established and bootstrapped successfully.
+	BootstrapResultSuccess = BootstrapResult(1)
+
+	// BootstrapResultDialFailed indicates that a connection could not be established to the node.
+	BootstrapResultDialFailed = BootstrapResult(2)
+
+	// BootstrapResultAuthFailed indicates that the node rejected our credentials.
+	BootstrapResultAuthFailed = BootstrapResult(3)
+
+	// BootstrapResultHandshakeFailed indicates that a bootstrap step other than authentication failed, such as
+	// HELLO negotiation or selecting the bucket.
+	BootstrapResultHandshakeFailed = BootstrapResult(4)
+
+	// BootstrapResultCCCPUnsupported indicates that the node does not support fetching config over the kv service.
+	BootstrapResultCCCPUnsupported = BootstrapResult(5)
+
+	// BootstrapResultConfigInvalid indicates that the config returned by the node could not be parsed.
+	BootstrapResultConfigInvalid = BootstrapResult(6)
+)
+
+// BootstrapAttempt describes a single attempt to bootstrap against a node.
+type BootstrapAttempt struct {
+	Address string
+	Result  BootstrapResult
+	Error   error
+	Time    time.Time
+}
+
+// BootstrapAttemptCallback is invoked for every bootstrap attempt made by an agent. It is invoked from the goroutine
+// which made the attempt and so must not block.
+type BootstrapAttemptCallback func(attempt BootstrapAttempt)
+
+// BootstrapAddressStatus contains the bootstrap history for a single node.
+type BootstrapAddressStatus struct {
+	Address     string
+	NumAttempts uint32
+	LastAttempt BootstrapAttempt
+	LastSuccess time.Time
+}
+
+// QuarantinedServer describes a kv server which will not be dialed until Until, either because it recently failed
+// to connect or bootstrap or because it was manually quarantined.
+type QuarantinedServer struct {
+	Address string
+	Until   time.Time
+}
+
+// BootstrapStatus contains the bootstrap history for every node that the agent has attempted to connect to.
+type BootstrapStatus struct {
+	Addresses []BootstrapAddressStatus
+}
+
+type bootstrapStatusComponent struct {
+	lock      sync.Mutex
+	addresses map[string]*BootstrapAddressStatus
+	callback  BootstrapAttemptCallback
+}
+
+func newBootstrapStatusComponent(callback BootstrapAttemptCallback) *bootstrapStatusComponent {
+	return &bootstrapStatusComponent{
+		addresses: make(map[string]*BootstrapAddressStatus),
+		callback:  callback,
+	}
+}
+
+// RecordAttempt records the result of a bootstrap attempt against address, it is safe to call on a nil component.
+func (bsc *bootstrapStatusComponent) RecordAttempt(address string, result BootstrapResult, err error) {
+	if bsc == nil {
+		return
+	}
+
+	attempt := BootstrapAttempt{
+		Address: address,
+		Result:  result,
+		Error:   err,
+		Time:    time.Now(),
+	}
+
+	bsc.lock.Lock()
+	status, ok := bsc.addresses[address]
+	if !ok {
+		status = &BootstrapAddressStatus{
+			Address: address,
+		}
+		bsc.addresses[address] = status
+	}
+	status.NumAttempts++
+	status.LastAttempt = attempt
+	if result == BootstrapResultSuccess {
+		status.LastSuccess = attempt.Time
+	}
+	bsc.lock.Unlock()
+
+	if bsc.callback != nil {
+		bsc.callback(attempt)
+	}
+}
+
+// RecordBootstrapError records a failed bootstrap against address, categorising the failure from err.
+func (bsc *bootstrapStatusComponent) RecordBootstrapError(address string, err error) {
+	result := BootstrapResultHandshakeFailed
+	if errors.Is(err, ErrAuthenticationFailure) {
+		result = BootstrapResultAuthFailed
+	}
+
+	bsc.RecordAttempt(address, result, err)
+}
+
+func (bsc *bootstrapStatusComponent) Status() BootstrapStatus {
+	bsc.lock.Lock()
+	addresses := make([]BootstrapAddressStatus, 0, len(bsc.addresses))
+	for _, status := range bsc.addresses {
+		addresses = append(addresses, *status)
+	}
+	bsc.lock.Unlock()
+
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Address < addresses[j].Address
+	})
+
+	return BootstrapStatus{
+		Addresses: addresses,
+	}
+}
@@ -0,0 +1,42 @@
+package gocbcore
+
+import "errors"
+
+func (suite *UnitTestSuite) TestBootstrapStatusRecordsAttempts() {
+	var attempts []BootstrapAttempt
+	bsc := newBootstrapStatusComponent(func(attempt BootstrapAttempt) {
+		attempts = append(attempts, attempt)
+	})
+
+	dialErr := errors.New("connection refused")
+	bsc.RecordAttempt("b:11210", BootstrapResultDialFailed, dialErr)
+	bsc.RecordBootstrapError("a:11210", errAuthenticationFailure)
+	bsc.RecordBootstrapError("a:11210", errBucketNotFound)
+	bsc.RecordAttempt("a:11210", BootstrapResultSuccess, nil)
+
+	suite.Require().Len(attempts, 4)
+	suite.Assert().Equal(BootstrapResultAuthFailed, attempts[1].Result)
+	suite.Assert().Equal(BootstrapResultHandshakeFailed, attempts[2].Result)
+
+	status := bsc.Status()
+	suite.Require().Len(status.Addresses, 2)
+
+	a := status.Addresses[0]
+	suite.Assert().Equal("a:11210", a.Address)
+	suite.Assert().Equal(uint32(3), a.NumAttempts)
+	suite.Assert().Equal(BootstrapResultSuccess, a.LastAttempt.Result)
+	suite.Assert().False(a.LastSuccess.IsZero())
+
+	b := status.Addresses[1]
+	suite.Assert().Equal("b:11210", b.Address)
+	suite.Assert().Equal(uint32(1), b.NumAttempts)
+	suite.Assert().Equal(BootstrapResultDialFailed, b.LastAttempt.Result)
+	suite.Assert().Equal(dialErr, b.LastAttempt.Error)
+	suite.Assert().True(b.LastSuccess.IsZero())
+}
+
+func (suite *UnitTestSuite) TestBootstrapStatusNil() {
+	var bsc *bootstrapStatusComponent
+	bsc.RecordAttempt("a:11210", BootstrapResultSuccess, nil)
+	bsc.RecordBootstrapError("a:11210", errAuthenticationFailure)
+}
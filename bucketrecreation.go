@@ -0,0 +1,94 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+)
+
+// BucketRecreatedEvent describes the bucket that the agent is using being deleted and recreated with the same name,
+// which is detected by the bucket UUID in a new config differing from the previous one.
+type BucketRecreatedEvent struct {
+	BucketName   string
+	PreviousUUID string
+	UUID         string
+	Time         time.Time
+}
+
+// BucketRecreatedCallback is invoked when the bucket that the agent is using is found to have been recreated. Any
+// mutation tokens obtained before the event refer to the previous bucket and must not be used with the new one. It is
+// invoked from the goroutine which applies configs and so must not block.
+// Volatile: This API is subject to change at any time.
+type BucketRecreatedCallback func(event BucketRecreatedEvent)
+
+// bucketRecreationComponent watches for the bucket UUID changing and fails the requests which depend on mutation
+// tokens from before the change with errBucketRecreated.
+type bucketRecreationComponent struct {
+	callback BucketRecreatedCallback
+	logCtx   logContext
+
+	lock     sync.Mutex
+	name     string
+	uuid     string
+	requests map[*memdQRequest]struct{}
+}
+
+func newBucketRecreationComponent(cfgMgr configManager, callback BucketRecreatedCallback,
+	logCtx logContext) *bucketRecreationComponent {
+	brc := &bucketRecreationComponent{
+		callback: callback,
+		logCtx:   logCtx,
+		requests: make(map[*memdQRequest]struct{}),
+	}
+	cfgMgr.AddConfigWatcher(brc)
+
+	return brc
+}
+
+// Track registers a request which depends on a mutation token, it must be untracked once it completes.
+func (brc *bucketRecreationComponent) Track(req *memdQRequest) {
+	brc.lock.Lock()
+	brc.requests[req] = struct{}{}
+	brc.lock.Unlock()
+}
+
+func (brc *bucketRecreationComponent) Untrack(req *memdQRequest) {
+	brc.lock.Lock()
+	delete(brc.requests, req)
+	brc.lock.Unlock()
+}
+
+func (brc *bucketRecreationComponent) OnNewRouteConfig(cfg *routeConfig) {
+	// Cluster level configs don't have a bucket UUID.
+	if cfg.uuid == "" {
+		return
+	}
+
+	// A config for a different bucket means that the agent has switched buckets rather than the bucket being recreated.
+	brc.lock.Lock()
+	previousName, previousUUID := brc.name, brc.uuid
+	brc.name, brc.uuid = cfg.name, cfg.uuid
+	if previousName != cfg.name || previousUUID == cfg.uuid {
+		brc.lock.Unlock()
+		return
+	}
+
+	requests := brc.requests
+	brc.requests = make(map[*memdQRequest]struct{})
+	brc.lock.Unlock()
+
+	brc.logCtx.logWarnf("Bucket %s has been recreated, its uuid changed from %s to %s", logMetaData(cfg.name), previousUUID,
+		cfg.uuid)
+
+	if brc.callback != nil {
+		brc.callback(BucketRecreatedEvent{
+			BucketName:   cfg.name,
+			PreviousUUID: previousUUID,
+			UUID:         cfg.uuid,
+			Time:         time.Now(),
+		})
+	}
+
+	for req := range requests {
+		req.cancelWithCallback(errBucketRecreated)
+	}
+}
@@ -0,0 +1,55 @@
+package gocbcore
+
+import "errors"
+
+func (suite *UnitTestSuite) TestBucketRecreation() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	cm := newConfigManager(configManagerProperties{})
+
+	var events []BucketRecreatedEvent
+	brc := newBucketRecreationComponent(cm, func(event BucketRecreatedEvent) {
+		events = append(events, event)
+	}, nil)
+
+	applies := func(name, uuid string, revID int64) bool {
+		return cm.updateRouteConfig(&routeConfig{name: name, uuid: uuid, revID: revID, bktType: bktTypeCouchbase})
+	}
+	apply := func(name, uuid string, revID int64) {
+		brc.OnNewRouteConfig(&routeConfig{name: name, uuid: uuid, revID: revID, bktType: bktTypeCouchbase})
+	}
+
+	suite.Require().True(applies("default", "uuid1", 100))
+	apply("default", "uuid1", 100)
+
+	reqErrCh := make(chan error, 1)
+	req := &memdQRequest{
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			reqErrCh <- err
+		},
+	}
+	brc.Track(req)
+	completedReq := &memdQRequest{}
+	brc.Track(completedReq)
+	brc.Untrack(completedReq)
+
+	apply("default", "uuid1", 101)
+	suite.Assert().Empty(events)
+
+	// The recreated bucket's revisions start again but its config must still be used.
+	suite.Assert().True(applies("default", "uuid2", 5))
+	apply("default", "uuid2", 5)
+	suite.Require().Len(events, 1)
+	suite.Assert().Equal("default", events[0].BucketName)
+	suite.Assert().Equal("uuid1", events[0].PreviousUUID)
+	suite.Assert().Equal("uuid2", events[0].UUID)
+
+	suite.Require().Len(reqErrCh, 1)
+	suite.Assert().True(errors.Is(<-reqErrCh, ErrBucketRecreated))
+
+	// Switching to another bucket isn't a recreation.
+	apply("other", "uuid3", 1)
+	suite.Assert().Len(events, 1)
+}
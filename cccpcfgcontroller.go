@@ -9,11 +9,15 @@ import (
 	"github.com/couchbase/gocbcore/v9/memd"
 )
 
+// cccpBootstrapParallelism is the maximum number of nodes which are asked for config at once before we have a config.
+const cccpBootstrapParallelism = 4
+
 type cccpConfigController struct {
 	muxer              dispatcher
 	cfgMgr             *configManagementComponent
 	confCccpPollPeriod time.Duration
 	confCccpMaxWait    time.Duration
+	bootstrapStatus    *bootstrapStatusComponent
 
 	// Used exclusively for testing to overcome GOCBC-780. It allows a test to pause the cccp looper preventing
 	// unwanted requests from being sent to the mock once it has been setup for error map testing.
@@ -24,6 +28,8 @@ type cccpConfigController struct {
 
 	fetchErr error
 	errLock  sync.Mutex
+
+	logCtx logContext
 }
 
 func newCCCPConfigController(props cccpPollerProperties, muxer dispatcher, cfgMgr *configManagementComponent) *cccpConfigController {
@@ -32,6 +38,8 @@ func newCCCPConfigController(props cccpPollerProperties, muxer dispatcher, cfgMg
 		cfgMgr:             cfgMgr,
 		confCccpPollPeriod: props.confCccpPollPeriod,
 		confCccpMaxWait:    props.confCccpMaxWait,
+		bootstrapStatus:    props.bootstrapStatus,
+		logCtx:             props.logCtx,
 
 		looperPauseSig: make(chan bool),
 		looperStopSig:  make(chan struct{}),
@@ -42,6 +50,8 @@ func newCCCPConfigController(props cccpPollerProperties, muxer dispatcher, cfgMg
 type cccpPollerProperties struct {
 	confCccpPollPeriod time.Duration
 	confCccpMaxWait    time.Duration
+	bootstrapStatus    *bootstrapStatusComponent
+	logCtx             logContext
 }
 
 func (ccc *cccpConfigController) Error() error {
@@ -57,7 +67,11 @@ func (ccc *cccpConfigController) setError(err error) {
 }
 
 func (ccc *cccpConfigController) Pause(paused bool) {
-	ccc.looperPauseSig <- paused
+	// The looper may exit at any point so make sure that we don't block forever waiting for it.
+	select {
+	case ccc.looperPauseSig <- paused:
+	case <-ccc.looperDoneSig:
+	}
 }
 
 func (ccc *cccpConfigController) Stop() {
@@ -78,7 +92,7 @@ func (ccc *cccpConfigController) DoLoop() error {
 	tickTime := ccc.confCccpPollPeriod
 	paused := false
 
-	logDebugf("CCCP Looper starting.")
+	ccc.logCtx.logDebugf("CCCP Looper starting.")
 	nodeIdx := -1
 	// The first time that we loop we want to skip any sleep so that we can try get a config and bootstrapped ASAP.
 	firstLoop := true
@@ -109,7 +123,7 @@ Looper:
 
 		numNodes := iter.NumPipelines()
 		if numNodes == 0 {
-			logInfof("CCCPPOLL: No nodes available to poll, return upstream")
+			ccc.logCtx.logInfof("CCCPPOLL: No nodes available to poll, return upstream")
 			return errNoCCCPHosts
 		}
 
@@ -117,49 +131,26 @@ Looper:
 			nodeIdx = rand.Intn(numNodes) // #nosec G404
 		}
 
+		// Cluster level config polling can stop working once we've started using it, for example if the cluster is
+		// downgraded or a node running a version before 6.5 is added. Once a node tells us that it doesn't support it
+		// we return upstream so that the poller controller can fall back to polling over HTTP, other failures such as
+		// timeouts are retried as they are for bucket level config.
+		usingGCCCP := iter.BucketType() == bktTypeNone && iter.RevID() > -1
+
 		var foundConfig *cfgBucket
 		var foundErr error
-		iter.Iterate(nodeIdx, func(pipeline *memdPipeline) bool {
-			nodeIdx = (nodeIdx + 1) % numNodes
-			cccpBytes, err := ccc.getClusterConfig(pipeline)
-			if err != nil {
-				if isPollingFallbackError(err) {
-					// This error is indicative of a memcached bucket which we can't handle so return the error.
-					logInfof("CCCPPOLL: CCCP not supported, returning error upstream.")
-					foundErr = err
-					return true
-				}
-
-				// Only log the error at warn if it's unexpected.
-				// If we cancelled the request or we're shutting down the connection then it's not really unexpected.
-				ccc.setError(err)
-				if errors.Is(err, ErrRequestCanceled) || errors.Is(err, ErrShutdown) {
-					logDebugf("CCCPPOLL: CCCP request was cancelled or connection was shutdown: %v", err)
-					return true
-				}
-
-				logWarnf("CCCPPOLL: Failed to retrieve CCCP config. %s", err)
-				return false
-			}
-			ccc.setError(nil)
-
-			logDebugf("CCCPPOLL: Got Block: %v", string(cccpBytes))
-
-			hostName, err := hostFromHostPort(pipeline.Address())
-			if err != nil {
-				logWarnf("CCCPPOLL: Failed to parse source address. %s", err)
-				return false
-			}
-
-			bk, err := parseConfig(cccpBytes, hostName)
-			if err != nil {
-				logWarnf("CCCPPOLL: Failed to parse CCCP config. %v", err)
-				return false
-			}
-
-			foundConfig = bk
-			return true
-		})
+		if iter.RevID() < 0 {
+			// Until we have a config we ask several nodes at once, so that seed nodes which are down or slow to
+			// respond don't each delay bootstrap by the maximum wait time in turn.
+			foundConfig, foundErr = ccc.getFirstConfig(iter, nodeIdx, usingGCCCP)
+		} else {
+			iter.Iterate(nodeIdx, func(pipeline *memdPipeline) bool {
+				nodeIdx = (nodeIdx + 1) % numNodes
+				var done bool
+				foundConfig, done, foundErr = ccc.fetchConfig(pipeline, usingGCCCP, nil)
+				return done
+			})
+		}
 		if foundErr != nil {
 			return foundErr
 		}
@@ -168,14 +159,14 @@ Looper:
 			// Only log the error at warn if it's unexpected.
 			// If we cancelled the request then we're shutting down and this isn't unexpected.
 			if errors.Is(ccc.Error(), ErrRequestCanceled) || errors.Is(ccc.Error(), ErrShutdown) {
-				logDebugf("CCCPPOLL: CCCP request was cancelled.")
+				ccc.logCtx.logDebugf("CCCPPOLL: CCCP request was cancelled.")
 			} else {
-				logWarnf("CCCPPOLL: Failed to retrieve config from any node.")
+				ccc.logCtx.logWarnf("CCCPPOLL: Failed to retrieve config from any node.")
 			}
 			continue
 		}
 
-		logDebugf("CCCPPOLL: Received new config")
+		ccc.logCtx.logDebugf("CCCPPOLL: Received new config")
 		ccc.cfgMgr.OnNewConfig(foundConfig)
 	}
 
@@ -183,7 +174,119 @@ Looper:
 	return nil
 }
 
-func (ccc *cccpConfigController) getClusterConfig(pipeline *memdPipeline) (cfgOut []byte, errOut error) {
+// fetchConfig fetches and parses the config from a single node. Done is true if no more nodes should be tried, either
+// because a config was found or because of an error which means that the poller should stop.
+func (ccc *cccpConfigController) fetchConfig(pipeline *memdPipeline, usingGCCCP bool,
+	cancelSig <-chan struct{}) (*cfgBucket, bool, error) {
+	logCtx := ccc.logCtx.With("endpoint", logSystemData(pipeline.Address()))
+	cccpBytes, err := ccc.getClusterConfig(pipeline, cancelSig)
+	if err != nil {
+		select {
+		case <-cancelSig:
+			// Another node already provided a config.
+			return nil, true, nil
+		default:
+		}
+
+		if isPollingFallbackError(err) {
+			// This error is indicative of a memcached bucket which we can't handle so return the error.
+			logCtx.logInfof("CCCPPOLL: CCCP not supported, returning error upstream.")
+			ccc.bootstrapStatus.RecordAttempt(pipeline.Address(), BootstrapResultCCCPUnsupported, err)
+			return nil, true, err
+		}
+
+		if usingGCCCP && errors.Is(err, ErrMemdNoBucket) {
+			logCtx.logInfof("CCCPPOLL: GCCCP not supported, returning error upstream.")
+			ccc.bootstrapStatus.RecordAttempt(pipeline.Address(), BootstrapResultCCCPUnsupported, err)
+			return nil, true, wrapError(errGCCCPUnavailable, err.Error())
+		}
+
+		// Only log the error at warn if it's unexpected.
+		// If we cancelled the request or we're shutting down the connection then it's not really unexpected.
+		ccc.setError(err)
+		if errors.Is(err, ErrRequestCanceled) || errors.Is(err, ErrShutdown) {
+			logCtx.logDebugf("CCCPPOLL: CCCP request was cancelled or connection was shutdown: %v", err)
+			return nil, true, nil
+		}
+
+		logCtx.logWarnf("CCCPPOLL: Failed to retrieve CCCP config. %s", err)
+		return nil, false, nil
+	}
+	ccc.setError(nil)
+
+	logCtx.logDebugf("CCCPPOLL: Got Block: %v", string(cccpBytes))
+
+	hostName, err := hostFromHostPort(pipeline.Address())
+	if err != nil {
+		logCtx.logWarnf("CCCPPOLL: Failed to parse source address. %s", err)
+		return nil, false, nil
+	}
+
+	bk, err := parseConfig(cccpBytes, hostName)
+	if err != nil {
+		logCtx.logWarnf("CCCPPOLL: Failed to parse CCCP config. %v", err)
+		ccc.bootstrapStatus.RecordAttempt(pipeline.Address(), BootstrapResultConfigInvalid, err)
+		return nil, false, nil
+	}
+
+	return bk, true, nil
+}
+
+type cccpFetchResult struct {
+	config *cfgBucket
+	err    error
+}
+
+// getFirstConfig fetches config from up to cccpBootstrapParallelism nodes at a time, returning the first config or
+// fallback error received and cancelling the remaining requests.
+func (ccc *cccpConfigController) getFirstConfig(iter *pipelineSnapshot, offset int,
+	usingGCCCP bool) (*cfgBucket, error) {
+	var pipelines []*memdPipeline
+	iter.Iterate(offset, func(pipeline *memdPipeline) bool {
+		pipelines = append(pipelines, pipeline)
+		return false
+	})
+
+	cancelSig := make(chan struct{})
+	limiter := make(chan struct{}, cccpBootstrapParallelism)
+	resultCh := make(chan cccpFetchResult, len(pipelines))
+	var wg sync.WaitGroup
+	for _, pipeline := range pipelines {
+		wg.Add(1)
+		go func(pipeline *memdPipeline) {
+			defer wg.Done()
+
+			select {
+			case limiter <- struct{}{}:
+			case <-cancelSig:
+				return
+			}
+			defer func() {
+				<-limiter
+			}()
+
+			config, _, err := ccc.fetchConfig(pipeline, usingGCCCP, cancelSig)
+			resultCh <- cccpFetchResult{config: config, err: err}
+		}(pipeline)
+	}
+
+	var found cccpFetchResult
+	for range pipelines {
+		res := <-resultCh
+		if res.config != nil || res.err != nil {
+			found = res
+			break
+		}
+	}
+
+	close(cancelSig)
+	wg.Wait()
+
+	return found.config, found.err
+}
+
+func (ccc *cccpConfigController) getClusterConfig(pipeline *memdPipeline,
+	cancelSig <-chan struct{}) (cfgOut []byte, errOut error) {
 	signal := make(chan struct{}, 1)
 	req := &memdQRequest{
 		Packet: memd.Packet{
@@ -230,6 +333,10 @@ func (ccc *cccpConfigController) getClusterConfig(pipeline *memdPipeline) (cfgOu
 		req.cancelWithCallback(errRequestCanceled)
 		<-signal
 		return
-
+	case <-cancelSig:
+		ReleaseTimer(timeoutTmr, false)
+		req.cancelWithCallback(errRequestCanceled)
+		<-signal
+		return
 	}
 }
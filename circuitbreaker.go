@@ -4,6 +4,8 @@ import (
 	"errors"
 	"sync/atomic"
 	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
 )
 
 const (
@@ -38,6 +40,17 @@ type CircuitBreakerConfig struct {
 	RollingWindow            time.Duration
 	CompletionCallback       CircuitBreakerCallback
 	CanaryTimeout            time.Duration
+
+	// CanaryRequest, if set, returns the request which is sent on a kv connection to test whether its open circuit
+	// breaker can be closed, in place of a NOOP. A NOOP only tests the connection itself, whereas something like a
+	// Get of a document which is known to exist also tests the data path of the bucket.
+	// Volatile: This API is subject to change at any time.
+	CanaryRequest func() *memd.Packet
+
+	// CanaryCheck, if set, decides whether a canary succeeded from its response, which is nil if it failed without
+	// one, and error. By default a canary succeeds if there is no error.
+	// Volatile: This API is subject to change at any time.
+	CanaryCheck func(resp *memd.Packet, err error) bool
 }
 
 type noopCircuitBreaker struct {
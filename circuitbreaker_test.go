@@ -1,8 +1,12 @@
 package gocbcore
 
 import (
+	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
 )
 
 func (suite *StandardTestSuite) TestLazyCircuitBreakerSuccessfulCanary() {
@@ -209,3 +213,57 @@ func (suite *StandardTestSuite) TestLazyCircuitBreakerReset() {
 		suite.T().Fatalf("Circuit breaker should have allowed request")
 	}
 }
+
+func (suite *UnitTestSuite) TestCircuitBreakerCustomCanary() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var commands []memd.CmdCode
+	var lock sync.Mutex
+	var keyExists uint32
+	for _, cmd := range []memd.CmdCode{memd.CmdNoop, memd.CmdGet} {
+		var h memdmock.HandlerFunc
+		h = server.Handle(cmd, func(req *memd.Packet) *memd.Packet {
+			lock.Lock()
+			commands = append(commands, req.Command)
+			lock.Unlock()
+			if req.Command == memd.CmdGet && atomic.LoadUint32(&keyExists) == 1 {
+				return &memd.Packet{Status: memd.StatusSuccess, Extras: make([]byte, 4)}
+			}
+			return h(req)
+		})
+	}
+
+	conn, err := server.Dial(server.Address())
+	suite.Require().Nil(err)
+
+	canaryKey := []byte("canary")
+	client := newMemdClient(memdClientProps{ClientID: "test"}, conn, CircuitBreakerConfig{
+		Enabled: true,
+		CanaryRequest: func() *memd.Packet {
+			return &memd.Packet{Command: memd.CmdGet, Key: canaryKey}
+		},
+		CanaryCheck: func(resp *memd.Packet, err error) bool {
+			return err == nil && resp != nil && resp.Status == memd.StatusSuccess
+		},
+	}, func(resp *memdQResponse, req *memdQRequest, err error) (bool, error) {
+		return false, err
+	}, newTracerComponent(&noopTracer{}, "", true), nil)
+	defer client.Close()
+
+	breaker := client.breaker.(*lazyCircuitBreaker)
+
+	// The canary key doesn't exist yet so the data path is considered broken, even though the server responded.
+	atomic.StoreUint32(&breaker.state, circuitBreakerStateHalfOpen)
+	client.sendCanary()
+	suite.Assert().Equal(circuitBreakerStateOpen, breaker.State())
+
+	atomic.StoreUint32(&keyExists, 1)
+	atomic.StoreUint32(&breaker.state, circuitBreakerStateHalfOpen)
+	client.sendCanary()
+	suite.Assert().Equal(circuitBreakerStateClosed, breaker.State())
+
+	lock.Lock()
+	suite.Assert().Equal([]memd.CmdCode{memd.CmdGet, memd.CmdGet}, commands)
+	lock.Unlock()
+}
@@ -19,8 +19,9 @@ type clusterAgent struct {
 	search      *searchQueryComponent
 	views       *viewQueryComponent
 
-	revLock sync.Mutex
-	revID   int64
+	revLock  sync.Mutex
+	revID    int64
+	revEpoch int64
 
 	configWatchLock sync.Mutex
 	configWatchers  []routeConfigWatcher
@@ -29,11 +30,19 @@ type clusterAgent struct {
 func createClusterAgent(config *clusterAgentConfig) *clusterAgent {
 	var tlsConfig *dynTLSConfig
 	if config.UseTLS {
-		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider)
+		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider, config.TLSSkipVerify,
+			makeVerifyPeerCertificate(config.TLSPinnedPublicKeys, config.TLSVerifyPeerCertificate))
 	}
 
-	httpCli := createHTTPClient(config.HTTPMaxIdleConns, config.HTTPMaxIdleConnsPerHost,
-		config.HTTPIdleConnectionTimeout, tlsConfig)
+	httpClientConfig := HTTPClientConfig{
+		MaxIdleConns:          config.HTTPMaxIdleConns,
+		MaxIdleConnsPerHost:   config.HTTPMaxIdleConnsPerHost,
+		IdleConnectionTimeout: config.HTTPIdleConnectionTimeout,
+		DisableHTTP2:          config.HTTPDisableHTTP2,
+	}
+	httpCli := createHTTPClient(httpClientConfig, tlsConfig, config.HTTPRoundTrippers)
+	serviceHTTPClis := createServiceHTTPClients(httpClientConfig, config.HTTPServiceClientConfigs, tlsConfig,
+		config.HTTPRoundTrippers)
 
 	tracer := config.Tracer
 	if tracer == nil {
@@ -69,6 +78,8 @@ func createClusterAgent(config *clusterAgentConfig) *clusterAgent {
 		httpComponentProps{
 			UserAgent:            userAgent,
 			DefaultRetryStrategy: c.defaultRetryStrategy,
+			ServiceClients:       serviceHTTPClis,
+			DisableCompression:   config.HTTPDisableCompression,
 		},
 		httpCli,
 		c.httpMux,
@@ -81,7 +92,7 @@ func createClusterAgent(config *clusterAgentConfig) *clusterAgent {
 	c.views = newViewQueryComponent(c.http, c.tracer)
 	// diagnostics at this level will never need to hook KV. There are no persistent connections
 	// so Diagnostics calls should be blocked. Ping and WaitUntilReady will only try HTTP services.
-	c.diagnostics = newDiagnosticsComponent(nil, c.httpMux, c.http, "", c.defaultRetryStrategy, nil)
+	c.diagnostics = newDiagnosticsComponent(nil, c.httpMux, c.http, "", c.defaultRetryStrategy, nil, nil)
 
 	// Kick everything off.
 	cfg := &routeConfig{
@@ -128,14 +139,15 @@ func (agent *clusterAgent) RemoveConfigWatcher(watcher routeConfigWatcher) {
 func (agent *clusterAgent) OnNewRouteConfig(cfg *routeConfig) {
 	agent.revLock.Lock()
 	// This could be coming from multiple agents so we need to make sure that it's up to date with what we've seen.
-	if cfg.revID <= agent.revID {
+	if !cfg.IsNewerThan(&routeConfig{revID: agent.revID, revEpoch: agent.revEpoch}) {
 		agent.revLock.Unlock()
 		return
 	}
 
-	logDebugf("Cluster agent applying config rev id: %d\n", cfg.revID)
+	logDebugf("Cluster agent applying config rev id: %d, epoch: %d\n", cfg.revID, cfg.revEpoch)
 
 	agent.revID = cfg.revID
+	agent.revEpoch = cfg.revEpoch
 	agent.revLock.Unlock()
 	agent.configWatchLock.Lock()
 	watchers := agent.configWatchers
@@ -161,11 +173,81 @@ func (agent *clusterAgent) AnalyticsQuery(opts AnalyticsQueryOptions, cb Analyti
 	return agent.analytics.AnalyticsQuery(opts, cb)
 }
 
+// CreateAnalyticsDataverse creates an analytics dataverse using a random connected agent.
+func (agent *clusterAgent) CreateAnalyticsDataverse(opts CreateAnalyticsDataverseOptions, cb CreateAnalyticsDataverseCallback) (PendingOp, error) {
+	return agent.analytics.CreateAnalyticsDataverse(opts, cb)
+}
+
+// DropAnalyticsDataverse drops an analytics dataverse using a random connected agent.
+func (agent *clusterAgent) DropAnalyticsDataverse(opts DropAnalyticsDataverseOptions, cb DropAnalyticsDataverseCallback) (PendingOp, error) {
+	return agent.analytics.DropAnalyticsDataverse(opts, cb)
+}
+
+// CreateAnalyticsDataset creates an analytics dataset over a bucket using a random connected agent.
+func (agent *clusterAgent) CreateAnalyticsDataset(opts CreateAnalyticsDatasetOptions, cb CreateAnalyticsDatasetCallback) (PendingOp, error) {
+	return agent.analytics.CreateAnalyticsDataset(opts, cb)
+}
+
+// DropAnalyticsDataset drops an analytics dataset using a random connected agent.
+func (agent *clusterAgent) DropAnalyticsDataset(opts DropAnalyticsDatasetOptions, cb DropAnalyticsDatasetCallback) (PendingOp, error) {
+	return agent.analytics.DropAnalyticsDataset(opts, cb)
+}
+
+// GetAllAnalyticsDatasets fetches all of the analytics datasets using a random connected agent.
+func (agent *clusterAgent) GetAllAnalyticsDatasets(opts GetAllAnalyticsDatasetsOptions, cb GetAllAnalyticsDatasetsCallback) (PendingOp, error) {
+	return agent.analytics.GetAllAnalyticsDatasets(opts, cb)
+}
+
+// CreateAnalyticsLink creates an analytics link using a random connected agent.
+func (agent *clusterAgent) CreateAnalyticsLink(opts UpsertAnalyticsLinkOptions, cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return agent.analytics.CreateAnalyticsLink(opts, cb)
+}
+
+// ReplaceAnalyticsLink replaces an existing analytics link using a random connected agent.
+func (agent *clusterAgent) ReplaceAnalyticsLink(opts UpsertAnalyticsLinkOptions, cb UpsertAnalyticsLinkCallback) (PendingOp, error) {
+	return agent.analytics.ReplaceAnalyticsLink(opts, cb)
+}
+
+// DropAnalyticsLink drops an analytics link using a random connected agent.
+func (agent *clusterAgent) DropAnalyticsLink(opts DropAnalyticsLinkOptions, cb DropAnalyticsLinkCallback) (PendingOp, error) {
+	return agent.analytics.DropAnalyticsLink(opts, cb)
+}
+
+// GetAnalyticsLinks fetches analytics links using a random connected agent.
+func (agent *clusterAgent) GetAnalyticsLinks(opts GetAnalyticsLinksOptions, cb GetAnalyticsLinksCallback) (PendingOp, error) {
+	return agent.analytics.GetAnalyticsLinks(opts, cb)
+}
+
 // SearchQuery executes a Search query against a random connected agent.
 func (agent *clusterAgent) SearchQuery(opts SearchQueryOptions, cb SearchQueryCallback) (PendingOp, error) {
 	return agent.search.SearchQuery(opts, cb)
 }
 
+// UpsertSearchIndex creates or updates a search index definition using a random connected agent.
+func (agent *clusterAgent) UpsertSearchIndex(opts UpsertSearchIndexOptions, cb UpsertSearchIndexCallback) (PendingOp, error) {
+	return agent.search.UpsertSearchIndex(opts, cb)
+}
+
+// GetSearchIndex fetches a search index definition using a random connected agent.
+func (agent *clusterAgent) GetSearchIndex(opts GetSearchIndexOptions, cb GetSearchIndexCallback) (PendingOp, error) {
+	return agent.search.GetSearchIndex(opts, cb)
+}
+
+// GetAllSearchIndexes fetches all of the search index definitions using a random connected agent.
+func (agent *clusterAgent) GetAllSearchIndexes(opts GetAllSearchIndexesOptions, cb GetAllSearchIndexesCallback) (PendingOp, error) {
+	return agent.search.GetAllSearchIndexes(opts, cb)
+}
+
+// DropSearchIndex deletes a search index definition using a random connected agent.
+func (agent *clusterAgent) DropSearchIndex(opts DropSearchIndexOptions, cb DropSearchIndexCallback) (PendingOp, error) {
+	return agent.search.DropSearchIndex(opts, cb)
+}
+
+// GetSearchIndexStatus fetches the partitions that a search index has been planned into using a random connected agent.
+func (agent *clusterAgent) GetSearchIndexStatus(opts GetSearchIndexStatusOptions, cb GetSearchIndexStatusCallback) (PendingOp, error) {
+	return agent.search.GetSearchIndexStatus(opts, cb)
+}
+
 // ViewQuery executes a view query against a random connected agent.
 func (agent *clusterAgent) ViewQuery(opts ViewQueryOptions, cb ViewQueryCallback) (PendingOp, error) {
 	return agent.views.ViewQuery(opts, cb)
@@ -11,11 +11,18 @@ type clusterAgentConfig struct {
 	UseTLS    bool
 	Auth      AuthProvider
 
-	TLSRootCAProvider func() *x509.CertPool
+	TLSRootCAProvider        func() *x509.CertPool
+	TLSSkipVerify            bool
+	TLSVerifyPeerCertificate TLSVerifyPeerCertificateFunc
+	TLSPinnedPublicKeys      [][]byte
 
 	HTTPMaxIdleConns          int
 	HTTPMaxIdleConnsPerHost   int
 	HTTPIdleConnectionTimeout time.Duration
+	HTTPDisableHTTP2          bool
+	HTTPDisableCompression    bool
+	HTTPServiceClientConfigs  map[ServiceType]HTTPClientConfig
+	HTTPRoundTrippers         []HTTPRoundTripperMiddleware
 
 	// Volatile: Tracer API is subject to change.
 	Tracer           RequestTracer
@@ -0,0 +1,22 @@
+package gocbcore
+
+// ClusterConfigProvider supplies cluster configs to an agent from an external source, such as a config proxy or a
+// process which already watches the cluster map.
+// Volatile: This API is subject to change at any time.
+type ClusterConfigProvider interface {
+	// WatchClusterConfig is called once when the agent connects. The provider should invoke cb with every new config,
+	// in the JSON format returned by the server, along with the host that the config was fetched from which is used
+	// to replace any $HOST placeholders. The returned function is called when the agent is closed, cb must not be
+	// invoked once it has returned.
+	WatchClusterConfig(cb func(config []byte, srcHost string)) (stop func())
+}
+
+func (agent *Agent) onProvidedClusterConfig(config []byte, srcHost string) {
+	bk, err := parseConfig(config, srcHost)
+	if err != nil {
+		agent.logCtx.logWarnf("Failed to parse cluster config from provider: %v", err)
+		return
+	}
+
+	agent.cfgManager.OnNewConfig(bk)
+}
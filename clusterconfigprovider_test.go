@@ -0,0 +1,38 @@
+package gocbcore
+
+import (
+	"errors"
+)
+
+func (suite *UnitTestSuite) TestAgentStaticConfig() {
+	_, err := CreateAgent(&AgentConfig{
+		MemdAddrs:            []string{"10.112.192.101:11210"},
+		BucketName:           "default",
+		DisableConfigPolling: true,
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	_, err = CreateAgent(&AgentConfig{
+		MemdAddrs:  []string{"10.112.192.101:11210"},
+		BucketName: "default",
+		SeedConfig: []byte("{"),
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	seedConfig, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+
+	agent, err := CreateAgent(&AgentConfig{
+		MemdAddrs:            []string{"10.112.192.101:11210"},
+		BucketName:           "default",
+		SeedConfig:           seedConfig,
+		SeedConfigSourceHost: "10.112.192.101",
+		DisableConfigPolling: true,
+		LazyConnect:          true,
+	})
+	suite.Require().Nil(err)
+	defer agent.Close()
+
+	suite.Assert().Nil(agent.pollerController)
+	suite.Assert().True(errors.Is(agent.SelectBucket("other"), ErrUnsupportedOperation))
+}
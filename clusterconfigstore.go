@@ -0,0 +1,113 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// ClusterConfigStore persists the most recent cluster config so that it can be used to route requests as soon as an
+// agent is next created, before a config has been fetched from the cluster. This also allows the agent to bootstrap
+// when none of the seed nodes are part of the cluster any more, so long as one of the nodes in the stored config is.
+// Volatile: This API is subject to change at any time.
+type ClusterConfigStore interface {
+	// LoadClusterConfig returns the config last stored for the bucket, in the JSON format returned by the server, or
+	// nil if there is none. The bucket name is empty for agents which are not connected to a bucket.
+	LoadClusterConfig(bucketName string) ([]byte, error)
+
+	// StoreClusterConfig is called whenever a newer config is applied. It is called in the background, configs which
+	// are applied whilst a previous config is being stored replace one another so only the most recent is stored.
+	StoreClusterConfig(bucketName string, config []byte) error
+}
+
+// loadStoredClusterConfig fetches the config from store for use as a seed config. The config is unversioned so that
+// it is superseded by the first config fetched from the cluster, even if the cluster has been recreated since the
+// config was stored.
+func loadStoredClusterConfig(store ClusterConfigStore, bucketName string, logCtx logContext) *cfgBucket {
+	data, err := store.LoadClusterConfig(bucketName)
+	if err != nil {
+		logCtx.logWarnf("Failed to load stored cluster config: %v", err)
+		return nil
+	}
+
+	if data == nil {
+		return nil
+	}
+
+	bk, err := parseConfig(data, "")
+	if err != nil {
+		logCtx.logWarnf("Failed to parse stored cluster config: %v", err)
+		return nil
+	}
+
+	bk.Rev = 0
+	bk.RevEpoch = 0
+
+	return bk
+}
+
+// clusterConfigStorer stores configs in the background so that a slow store can't hold up configs being applied.
+type clusterConfigStorer struct {
+	store  ClusterConfigStore
+	logCtx logContext
+
+	lock          sync.Mutex
+	pending       *cfgBucket
+	pendingBucket string
+	running       bool
+	wg            sync.WaitGroup
+}
+
+func newClusterConfigStorer(store ClusterConfigStore, logCtx logContext) *clusterConfigStorer {
+	return &clusterConfigStorer{
+		store:  store,
+		logCtx: logCtx,
+	}
+}
+
+// Store queues cfg to be stored, replacing any config which is still waiting to be stored.
+func (s *clusterConfigStorer) Store(bucketName string, cfg *cfgBucket) {
+	s.lock.Lock()
+	s.pending = cfg
+	s.pendingBucket = bucketName
+	if s.running {
+		s.lock.Unlock()
+		return
+	}
+	s.running = true
+	s.wg.Add(1)
+	s.lock.Unlock()
+
+	go s.loop()
+}
+
+// Wait waits for any queued configs to be stored.
+func (s *clusterConfigStorer) Wait() {
+	s.wg.Wait()
+}
+
+func (s *clusterConfigStorer) loop() {
+	defer s.wg.Done()
+
+	for {
+		s.lock.Lock()
+		cfg := s.pending
+		bucketName := s.pendingBucket
+		s.pending = nil
+		if cfg == nil {
+			s.running = false
+			s.lock.Unlock()
+			return
+		}
+		s.lock.Unlock()
+
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			s.logCtx.logWarnf("Failed to serialize cluster config for storing: %v", err)
+			continue
+		}
+
+		if err := s.store.StoreClusterConfig(bucketName, data); err != nil {
+			s.logCtx.logWarnf("Failed to store cluster config: %v", err)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync"
+)
+
+type testClusterConfigStore struct {
+	lock    sync.Mutex
+	configs map[string][]byte
+}
+
+func (s *testClusterConfigStore) LoadClusterConfig(bucketName string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.configs == nil {
+		return nil, errors.New("store unavailable")
+	}
+	return s.configs[bucketName], nil
+}
+
+func (s *testClusterConfigStore) StoreClusterConfig(bucketName string, config []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.configs[bucketName] = config
+	return nil
+}
+
+func (suite *UnitTestSuite) TestClusterConfigStore() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	raw, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+	cfgBk, err := parseConfig(raw, "localhost")
+	suite.Require().Nil(err)
+	suite.Require().True(cfgBk.Rev > 0)
+
+	store := &testClusterConfigStore{configs: make(map[string][]byte)}
+	cm := newConfigManager(configManagerProperties{
+		NetworkType: "default",
+		ConfigStore: store,
+		BucketName:  "default",
+	})
+	cm.OnNewConfig(cfgBk)
+	cm.WaitForStoredConfigs()
+	suite.Require().Contains(store.configs, "default")
+
+	// The next agent routes using the stored config until it fetches one from the cluster.
+	stored := loadStoredClusterConfig(store, "default", nil)
+	suite.Require().NotNil(stored)
+	suite.Assert().Equal(int64(0), stored.Rev)
+
+	mgr := &testAlternateAddressesRouteConfigMgr{}
+	cm = newConfigManager(configManagerProperties{
+		NetworkType:  "default",
+		SrcMemdAddrs: []string{"renamed:11210"},
+		ConfigStore:  store,
+		BucketName:   "default",
+	})
+	cm.AddConfigWatcher(mgr)
+	cm.OnNewConfig(stored)
+	suite.Require().True(mgr.cfgCalled)
+	suite.Assert().Equal([]string{"172.17.0.2:11210", "172.17.0.3:11210", "172.17.0.4:11210"}, mgr.cfg.kvServerList)
+
+	// Any config from the cluster supersedes it.
+	mgr.cfgCalled = false
+	cm.OnNewConfig(cfgBk)
+	suite.Assert().True(mgr.cfgCalled)
+	suite.Assert().Equal(cfgBk.Rev, mgr.cfg.revID)
+
+	suite.Assert().Nil(loadStoredClusterConfig(store, "other", nil))
+	suite.Assert().Nil(loadStoredClusterConfig(&testClusterConfigStore{}, "default", nil))
+
+	// Once the bucket has been switched configs are stored against the new bucket.
+	cm.ResetConfig()
+	cm.SetBucketName("other")
+	cm.OnNewConfig(cfgBk)
+	cm.WaitForStoredConfigs()
+	suite.Assert().NotNil(loadStoredClusterConfig(store, "other", nil))
+}
+
+type blockingClusterConfigStore struct {
+	testClusterConfigStore
+	storingCh chan struct{}
+	releaseCh chan struct{}
+}
+
+func (s *blockingClusterConfigStore) StoreClusterConfig(bucketName string, config []byte) error {
+	s.storingCh <- struct{}{}
+	<-s.releaseCh
+	return s.testClusterConfigStore.StoreClusterConfig(bucketName, config)
+}
+
+func (suite *UnitTestSuite) TestClusterConfigStoreDoesNotBlockApply() {
+	raw, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+	cfgBk, err := parseConfig(raw, "localhost")
+	suite.Require().Nil(err)
+
+	store := &blockingClusterConfigStore{
+		testClusterConfigStore: testClusterConfigStore{configs: make(map[string][]byte)},
+		storingCh:              make(chan struct{}, 1),
+		releaseCh:              make(chan struct{}),
+	}
+	mgr := &testAlternateAddressesRouteConfigMgr{}
+	cm := newConfigManager(configManagerProperties{
+		NetworkType: "default",
+		ConfigStore: store,
+		BucketName:  "default",
+	})
+	cm.AddConfigWatcher(mgr)
+
+	// The config is applied even though the store is blocked.
+	cm.OnNewConfig(cfgBk)
+	suite.Assert().True(mgr.cfgCalled)
+	<-store.storingCh
+
+	// Configs applied whilst one is being stored replace each other, only the latest is stored.
+	newer := *cfgBk
+	newer.Rev++
+	cm.OnNewConfig(&newer)
+	newest := *cfgBk
+	newest.Rev += 2
+	cm.OnNewConfig(&newest)
+
+	close(store.releaseCh)
+	<-store.storingCh
+	cm.WaitForStoredConfigs()
+
+	stored, err := parseConfig(store.configs["default"], "localhost")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(newest.Rev, stored.Rev)
+	suite.Assert().Empty(store.storingCh)
+}
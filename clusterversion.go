@@ -0,0 +1,196 @@
+package gocbcore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ClusterVersion is the version of Couchbase Server running on a node.
+// Volatile: This API is subject to change at any time.
+type ClusterVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// String returns the version in major.minor.patch form.
+func (v ClusterVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less returns whether v is an earlier version than other.
+func (v ClusterVersion) Less(other ClusterVersion) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+
+	return v.Patch < other.Patch
+}
+
+// parseClusterVersion parses the version reported by a server, such as 7.0.2-6703-enterprise, ignoring everything
+// after the patch version.
+func parseClusterVersion(version string) (ClusterVersion, bool) {
+	if idx := strings.IndexByte(version, '-'); idx >= 0 {
+		version = version[:idx]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return ClusterVersion{}, false
+	}
+
+	var nums [3]int
+	for i, part := range parts {
+		num, err := strconv.Atoi(part)
+		if err != nil || num < 0 {
+			return ClusterVersion{}, false
+		}
+		nums[i] = num
+	}
+
+	return ClusterVersion{Major: nums[0], Minor: nums[1], Patch: nums[2]}, true
+}
+
+// ClusterFeature is a protocol feature which is only available from a particular server version.
+// Volatile: This API is subject to change at any time.
+type ClusterFeature uint32
+
+const (
+	// ClusterFeatureDurableWrites is support for durability requirements on mutations.
+	ClusterFeatureDurableWrites ClusterFeature = iota + 1
+
+	// ClusterFeatureCollections is support for scopes and collections.
+	ClusterFeatureCollections
+
+	// ClusterFeatureOSOBackfill is support for DCP out of order snapshots.
+	ClusterFeatureOSOBackfill
+)
+
+var clusterFeatureVersions = map[ClusterFeature]ClusterVersion{
+	ClusterFeatureDurableWrites: {Major: 6, Minor: 5},
+	ClusterFeatureCollections:   {Major: 7},
+	ClusterFeatureOSOBackfill:   {Major: 7},
+}
+
+// String returns the name of the feature.
+func (feature ClusterFeature) String() string {
+	switch feature {
+	case ClusterFeatureDurableWrites:
+		return "durable writes"
+	case ClusterFeatureCollections:
+		return "collections"
+	case ClusterFeatureOSOBackfill:
+		return "OSO backfill"
+	}
+
+	return fmt.Sprintf("unknown feature (%d)", uint32(feature))
+}
+
+// MinVersion returns the earliest server version which supports the feature.
+func (feature ClusterFeature) MinVersion() ClusterVersion {
+	return clusterFeatureVersions[feature]
+}
+
+// checkClusterFeature returns a FeatureNotAvailableError wrapping innerErr if version is known and is too old to
+// support feature. An unknown version is assumed to support every feature, leaving the server to reject it.
+func checkClusterFeature(feature ClusterFeature, version ClusterVersion, versionKnown bool, innerErr error) error {
+	if !versionKnown || !version.Less(feature.MinVersion()) {
+		return nil
+	}
+
+	return FeatureNotAvailableError{
+		Feature:         feature,
+		RequiredVersion: feature.MinVersion(),
+		ServerVersion:   version,
+		InnerError:      innerErr,
+	}
+}
+
+// clusterVersionCache is the oldest version reported by the kv nodes in the current config.
+type clusterVersionCache struct {
+	version ClusterVersion
+	known   bool
+}
+
+// MinClusterVersion returns the version of the oldest kv node which this mux has connected to, or false if no
+// versions are known yet. The version is cached as clients connect and configs change, so this never blocks.
+func (mux *kvMux) MinClusterVersion() (ClusterVersion, bool) {
+	cached, ok := mux.minVersion.Load().(clusterVersionCache)
+	if !ok {
+		return ClusterVersion{}, false
+	}
+
+	return cached.version, cached.known
+}
+
+// CheckClusterFeature checks whether the feature can be used, returning a FeatureNotAvailableError wrapping innerErr
+// if any node which the mux has connected to is too old to support it.
+func (mux *kvMux) CheckClusterFeature(feature ClusterFeature, innerErr error) error {
+	version, ok := mux.MinClusterVersion()
+	return checkClusterFeature(feature, version, ok, innerErr)
+}
+
+// recordServerVersion stores the version reported by a newly connected client, versions from nodes which are no
+// longer in the config are ignored.
+func (mux *kvMux) recordServerVersion(address string, banner string) {
+	version, ok := parseClusterVersion(banner)
+	if !ok {
+		return
+	}
+
+	mux.serverVersionsLock.Lock()
+	defer mux.serverVersionsLock.Unlock()
+
+	clientMux := mux.getState()
+	if clientMux == nil || !clientMux.hasKvServer(address) {
+		return
+	}
+
+	if mux.serverVersions == nil {
+		mux.serverVersions = make(map[string]ClusterVersion)
+	}
+	mux.serverVersions[address] = version
+	mux.updateMinVersionLocked()
+}
+
+// pruneServerVersions forgets the versions of any nodes which have left the config.
+func (mux *kvMux) pruneServerVersions(clientMux *kvMuxState) {
+	mux.serverVersionsLock.Lock()
+	defer mux.serverVersionsLock.Unlock()
+
+	for address := range mux.serverVersions {
+		if !clientMux.hasKvServer(address) {
+			delete(mux.serverVersions, address)
+		}
+	}
+	mux.updateMinVersionLocked()
+}
+
+func (mux *kvMux) updateMinVersionLocked() {
+	var cached clusterVersionCache
+	for _, version := range mux.serverVersions {
+		if !cached.known || version.Less(cached.version) {
+			cached = clusterVersionCache{version: version, known: true}
+		}
+	}
+
+	mux.minVersion.Store(cached)
+}
+
+// MinClusterVersion returns the version of the oldest kv node which the agent has connected to, or false if the
+// agent has not yet connected to any nodes.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) MinClusterVersion() (ClusterVersion, bool) {
+	return agent.kvMux.MinClusterVersion()
+}
+
+// CheckClusterFeature returns a FeatureNotAvailableError if the feature is known not to be supported by the cluster,
+// nil is returned if the feature is supported or if it is not yet known whether it is.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) CheckClusterFeature(feature ClusterFeature) error {
+	return agent.kvMux.CheckClusterFeature(feature, nil)
+}
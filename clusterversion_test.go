@@ -0,0 +1,95 @@
+package gocbcore
+
+import (
+	"errors"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestParseClusterVersion() {
+	version, ok := parseClusterVersion("7.0.2-6703-enterprise")
+	suite.Require().True(ok)
+	suite.Assert().Equal(ClusterVersion{Major: 7, Minor: 0, Patch: 2}, version)
+
+	version, ok = parseClusterVersion("6.5.1")
+	suite.Require().True(ok)
+	suite.Assert().Equal(ClusterVersion{Major: 6, Minor: 5, Patch: 1}, version)
+
+	for _, invalid := range []string{"", "7.0", "7.x.0-1234", "memdmock"} {
+		_, ok = parseClusterVersion(invalid)
+		suite.Assert().False(ok, invalid)
+	}
+
+	suite.Assert().True(ClusterVersion{Major: 6, Minor: 6}.Less(ClusterVersion{Major: 7}))
+	suite.Assert().False(ClusterVersion{Major: 7, Patch: 1}.Less(ClusterVersion{Major: 7}))
+}
+
+func (suite *UnitTestSuite) TestClusterFeatureGating() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	server.Handle(memd.CmdVersion, func(req *memd.Packet) *memd.Packet {
+		return &memd.Packet{Status: memd.StatusSuccess, Value: []byte("6.0.4-3082-enterprise")}
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:     "default",
+		UseCollections: true,
+	})
+	defer agent.Close()
+
+	suite.mustSet(agent, "key")
+
+	version, ok := agent.MinClusterVersion()
+	suite.Require().True(ok)
+	suite.Assert().Equal(ClusterVersion{Major: 6, Minor: 0, Patch: 4}, version)
+
+	_, err := agent.Set(SetOptions{
+		Key:             []byte("key"),
+		Value:           []byte("value"),
+		DurabilityLevel: memd.DurabilityLevelMajority,
+	}, func(res *StoreResult, err error) {})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable))
+	var featureErr FeatureNotAvailableError
+	suite.Require().True(errors.As(err, &featureErr))
+	suite.Assert().Equal(ClusterFeatureDurableWrites, featureErr.Feature)
+	suite.Assert().Equal(ClusterVersion{Major: 6, Minor: 5}, featureErr.RequiredVersion)
+	suite.Assert().Equal(version, featureErr.ServerVersion)
+
+	_, err = agent.Get(GetOptions{
+		Key:            []byte("key"),
+		ScopeName:      "scope",
+		CollectionName: "collection",
+	}, func(res *GetResult, err error) {})
+	suite.Assert().True(errors.Is(err, ErrFeatureNotAvailable))
+	suite.Assert().True(errors.Is(err, ErrCollectionsUnsupported))
+	suite.Require().True(errors.As(err, &featureErr))
+	suite.Assert().Equal(ClusterFeatureCollections, featureErr.Feature)
+
+	suite.Assert().True(errors.Is(agent.CheckClusterFeature(ClusterFeatureOSOBackfill), ErrFeatureNotAvailable))
+}
+
+func (suite *UnitTestSuite) TestKvMuxMinClusterVersionCache() {
+	mux := &kvMux{}
+	_, ok := mux.MinClusterVersion()
+	suite.Assert().False(ok)
+
+	state := newKVMuxState(&routeConfig{kvServerList: []string{"a:11210", "b:11210"}}, nil, nil)
+	mux.updateState(nil, state)
+
+	mux.recordServerVersion("a:11210", "7.0.2-6703-enterprise")
+	mux.recordServerVersion("b:11210", "6.6.0-7909-enterprise")
+	mux.recordServerVersion("c:11210", "6.0.4-3082-enterprise")
+	version, ok := mux.MinClusterVersion()
+	suite.Require().True(ok)
+	suite.Assert().Equal(ClusterVersion{Major: 6, Minor: 6}, version)
+
+	// Once the older node leaves the config only the remaining node is considered.
+	newState := newKVMuxState(&routeConfig{kvServerList: []string{"a:11210"}}, nil, nil)
+	mux.updateState(state, newState)
+	mux.pruneServerVersions(newState)
+	version, ok = mux.MinClusterVersion()
+	suite.Require().True(ok)
+	suite.Assert().Equal(ClusterVersion{Major: 7, Patch: 2}, version)
+}
@@ -3,6 +3,7 @@ package gocbcore
 import (
 	"encoding/json"
 	"strconv"
+	"time"
 )
 
 const (
@@ -99,6 +100,7 @@ type GetCollectionManifestOptions struct {
 	// Volatile: Tracer API is subject to change.
 	TraceContext  RequestSpanContext
 	RetryStrategy RetryStrategy
+	Deadline      time.Time
 }
 
 // GetAllCollectionManifestsOptions are the options available to the GetAllCollectionManifests command.
@@ -106,6 +108,7 @@ type GetAllCollectionManifestsOptions struct {
 	// Volatile: Tracer API is subject to change.
 	TraceContext  RequestSpanContext
 	RetryStrategy RetryStrategy
+	Deadline      time.Time
 }
 
 // GetCollectionIDOptions are the options available to the GetCollectionID command.
@@ -113,6 +116,7 @@ type GetCollectionIDOptions struct {
 	RetryStrategy RetryStrategy
 	// Volatile: Tracer API is subject to change.
 	TraceContext RequestSpanContext
+	Deadline     time.Time
 }
 
 // GetCollectionIDResult encapsulates the result of a GetCollectionID operation.
@@ -23,16 +23,23 @@ type collectionsComponent struct {
 	tracer               tracerManager
 	defaultRetryStrategy RetryStrategy
 	cfgMgr               configManager
+	timeouts             *kvTimeoutComponent
+	connectTrigger       *connectTrigger
 
 	// pendingOpQueue is used when collections are enabled but we've not yet seen a cluster config to confirm
 	// whether or not collections are supported.
 	pendingOpQueue *memdOpQueue
 	configSeen     uint32
+
+	logCtx logContext
 }
 
 type collectionIDProps struct {
 	MaxQueueSize         int
 	DefaultRetryStrategy RetryStrategy
+	Timeouts             *kvTimeoutComponent
+	ConnectTrigger       *connectTrigger
+	LogContext           logContext
 }
 
 func newCollectionIDManager(props collectionIDProps, dispatcher dispatcher, tracer tracerManager,
@@ -44,7 +51,10 @@ func newCollectionIDManager(props collectionIDProps, dispatcher dispatcher, trac
 		tracer:               tracer,
 		defaultRetryStrategy: props.DefaultRetryStrategy,
 		cfgMgr:               cfgMgr,
+		timeouts:             props.Timeouts,
+		connectTrigger:       props.ConnectTrigger,
 		pendingOpQueue:       newMemdOpQueue(),
+		logCtx:               props.LogContext,
 	}
 
 	cfgMgr.AddConfigWatcher(cidMgr)
@@ -65,7 +75,7 @@ func (cidMgr *collectionsComponent) OnNewRouteConfig(cfg *routeConfig) {
 		// Anything in this queue is here because collections were present so if we definitely don't support collections
 		// then fail them.
 		if !colsSupported {
-			request.tryCallback(nil, errCollectionsUnsupported)
+			request.tryCallback(nil, cidMgr.collectionsUnsupportedError())
 			return
 		}
 		cidMgr.requeue(request)
@@ -135,12 +145,21 @@ func (cidMgr *collectionsComponent) GetCollectionManifest(opts GetCollectionMani
 		Callback:         handler,
 		RetryStrategy:    opts.RetryStrategy,
 		RootTraceContext: opts.TraceContext,
+		Deadline:         cidMgr.timeouts.Deadline(opts.Deadline),
+	}
+
+	op, err := cidMgr.dispatcher.DispatchDirect(req)
+	if err != nil {
+		return nil, err
 	}
 
-	return cidMgr.dispatcher.DispatchDirect(req)
+	cidMgr.timeouts.Track(req, req.Deadline, "GetCollectionManifest", errUnambiguousTimeout)
+
+	return op, nil
 }
 
 func (cidMgr *collectionsComponent) GetAllCollectionManifests(opts GetAllCollectionManifestsOptions, cb GetAllCollectionManifestsCallback) (PendingOp, error) {
+	cidMgr.connectTrigger.Trigger()
 	tracer := cidMgr.tracer.CreateOpTrace("GetAllCollectionManifests", opts.TraceContext)
 
 	if opts.RetryStrategy == nil {
@@ -192,10 +211,12 @@ func (cidMgr *collectionsComponent) GetAllCollectionManifests(opts GetAllCollect
 			Callback:         handler,
 			RetryStrategy:    opts.RetryStrategy,
 			RootTraceContext: opts.TraceContext,
+			Deadline:         cidMgr.timeouts.Deadline(opts.Deadline),
 		}
 
 		curOp, err := cidMgr.dispatcher.DispatchDirectToAddress(req, pipeline)
 		if err == nil {
+			cidMgr.timeouts.Track(req, req.Deadline, "GetAllCollectionManifests", errUnambiguousTimeout)
 			op.ops = append(op.ops, curOp)
 			return false
 		}
@@ -266,11 +287,19 @@ func (cidMgr *collectionsComponent) GetCollectionID(scopeName string, collection
 		ReplicaIdx:       -1,
 		RetryStrategy:    opts.RetryStrategy,
 		RootTraceContext: opts.TraceContext,
+		Deadline:         cidMgr.timeouts.Deadline(opts.Deadline),
 	}
 
 	req.Callback = handler
 
-	return cidMgr.dispatcher.DispatchDirect(req)
+	op, err := cidMgr.dispatcher.DispatchDirect(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cidMgr.timeouts.Track(req, req.Deadline, "GetCollectionID", errUnambiguousTimeout)
+
+	return op, nil
 }
 
 func (cidMgr *collectionsComponent) upsert(scopeName, collectionName string, value uint32) *collectionIDCache {
@@ -306,8 +335,17 @@ func (cidMgr *collectionsComponent) getAndMaybeInsert(scopeName, collectionName
 	return id
 }
 
+// ResetCollectionIDs discards every cached collection ID, this is used when the bucket that we're connected to
+// changes as the IDs are only valid for the bucket that they were fetched from.
+func (cidMgr *collectionsComponent) ResetCollectionIDs() {
+	cidMgr.logCtx.logDebugf("Resetting collection ID cache")
+	cidMgr.mapLock.Lock()
+	cidMgr.idMap = make(map[string]*collectionIDCache)
+	cidMgr.mapLock.Unlock()
+}
+
 func (cidMgr *collectionsComponent) remove(scopeName, collectionName string) {
-	logDebugf("Removing cache entry for %s.%s", scopeName, collectionName)
+	cidMgr.logCtx.logDebugf("Removing cache entry for %s.%s", logMetaData(scopeName), logMetaData(collectionName))
 	cidMgr.mapLock.Lock()
 	delete(cidMgr.idMap, cidMgr.createKey(scopeName, collectionName))
 	cidMgr.mapLock.Unlock()
@@ -353,7 +391,7 @@ func (cid *collectionIDCache) queueRequest(req *memdQRequest) error {
 }
 
 func (cid *collectionIDCache) setID(id uint32) {
-	logDebugf("Setting cache ID to %d for %s.%s", id, cid.scopeName, cid.collectionName)
+	cid.parent.logCtx.logDebugf("Setting cache ID to %d for %s.%s", id, logMetaData(cid.scopeName), logMetaData(cid.collectionName))
 	cid.id = id
 }
 
@@ -363,7 +401,7 @@ func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
 		return err
 	}
 
-	logDebugf("Refreshing collection ID for %s.%s", req.ScopeName, req.CollectionName)
+	cid.parent.logCtx.logDebugf("Refreshing collection ID for %s.%s", logMetaData(req.ScopeName), logMetaData(req.CollectionName))
 	_, err = cid.parent.GetCollectionID(req.ScopeName, req.CollectionName, GetCollectionIDOptions{TraceContext: req.RootTraceContext},
 		func(result *GetCollectionIDResult, err error) {
 			if err != nil {
@@ -372,7 +410,7 @@ func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
 					// Retrying the request will requeue it in the cid manager so either it will pick up the unknown cid
 					// and cause a refresh or another request will and this one will get queued within the cache.
 					// Either the collection will eventually come online or this request will timeout.
-					logDebugf("Collection %s.%s not found, attempting retry", req.ScopeName, req.CollectionName)
+					cid.parent.logCtx.logDebugf("Collection %s.%s not found, attempting retry", logMetaData(req.ScopeName), logMetaData(req.CollectionName))
 					cid.lock.Lock()
 					cid.setID(unknownCid)
 					cid.lock.Unlock()
@@ -381,11 +419,11 @@ func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
 							return
 						}
 					} else {
-						logDebugf("Request no longer existed in op queue, possibly cancelled?",
-							req.Opaque, req.CollectionName)
+						cid.parent.logCtx.logDebugf("Request no longer existed in op queue, possibly cancelled? Opaque=%d. Collection=%s",
+							req.Opaque, logMetaData(req.CollectionName))
 					}
 				} else {
-					logDebugf("Collection ID refresh failed: %v", err)
+					cid.parent.logCtx.logDebugf("Collection ID refresh failed: %v", err)
 				}
 
 				// There was an error getting this collection ID so lets remove the cache from the manager and try to
@@ -400,7 +438,7 @@ func (cid *collectionIDCache) refreshCid(req *memdQRequest) error {
 
 			// We successfully got the cid, the GetCollectionID itself will have handled setting the ID on this cache,
 			// so lets reset the op queue and requeue all of our requests.
-			logDebugf("Collection %s.%s refresh succeeded, requeuing requests", req.ScopeName, req.CollectionName)
+			cid.parent.logCtx.logDebugf("Collection %s.%s refresh succeeded, requeuing requests", logMetaData(req.ScopeName), logMetaData(req.CollectionName))
 			cid.lock.Lock()
 			opQueue := cid.opQueue
 			cid.opQueue = newMemdOpQueue()
@@ -424,7 +462,7 @@ func (cid *collectionIDCache) dispatch(req *memdQRequest) error {
 	// otherwise send the request
 	switch cid.id {
 	case unknownCid:
-		logDebugf("Collection %s.%s unknown, refreshing id", req.ScopeName, req.CollectionName)
+		cid.parent.logCtx.logDebugf("Collection %s.%s unknown, refreshing id", logMetaData(req.ScopeName), logMetaData(req.CollectionName))
 		cid.setID(pendingCid)
 		cid.opQueue = newMemdOpQueue()
 
@@ -443,7 +481,7 @@ func (cid *collectionIDCache) dispatch(req *memdQRequest) error {
 		cid.lock.Unlock()
 		return nil
 	case pendingCid:
-		logDebugf("Collection %s.%s pending, queueing request OP=0x%x", req.ScopeName, req.CollectionName, req.Command)
+		cid.parent.logCtx.logDebugf("Collection %s.%s pending, queueing request OP=0x%x", logMetaData(req.ScopeName), logMetaData(req.CollectionName), req.Command)
 		cid.lock.Unlock()
 		return cid.queueRequest(req)
 	default:
@@ -452,7 +490,22 @@ func (cid *collectionIDCache) dispatch(req *memdQRequest) error {
 	}
 }
 
+// collectionsUnsupportedError returns the error for a request which uses collections when the bucket doesn't support
+// them, this is a FeatureNotAvailableError if that is because the cluster is too old.
+func (cidMgr *collectionsComponent) collectionsUnsupportedError() error {
+	if checker, ok := cidMgr.dispatcher.(bucketCapabilityVerifier); ok {
+		if err := checker.CheckClusterFeature(ClusterFeatureCollections, errCollectionsUnsupported); err != nil {
+			return err
+		}
+	}
+
+	return errCollectionsUnsupported
+}
+
 func (cidMgr *collectionsComponent) Dispatch(req *memdQRequest) (PendingOp, error) {
+	// Requests may be queued here until a config has been seen, which will never happen if we've not connected.
+	cidMgr.connectTrigger.Trigger()
+
 	noCollection := req.CollectionName == "" && req.ScopeName == ""
 	defaultCollection := req.CollectionName == "_default" && req.ScopeName == "_default"
 	collectionIDPresent := req.CollectionID > 0
@@ -475,7 +528,7 @@ func (cidMgr *collectionsComponent) Dispatch(req *memdQRequest) (PendingOp, erro
 	}
 
 	if atomic.LoadUint32(&cidMgr.configSeen) == 0 {
-		logDebugf("Collections are enabled but we've not yet seen a config so queueing request")
+		cidMgr.logCtx.logDebugf("Collections are enabled but we've not yet seen a config so queueing request")
 		err := cidMgr.pendingOpQueue.Push(req, cidMgr.maxQueueSize)
 		if err != nil {
 			return nil, err
@@ -485,7 +538,7 @@ func (cidMgr *collectionsComponent) Dispatch(req *memdQRequest) (PendingOp, erro
 	}
 
 	if !cidMgr.dispatcher.SupportsCollections() {
-		return nil, errCollectionsUnsupported
+		return nil, cidMgr.collectionsUnsupportedError()
 	}
 
 	cidCache := cidMgr.getAndMaybeInsert(req.ScopeName, req.CollectionName, unknownCid)
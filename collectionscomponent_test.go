@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -800,3 +801,43 @@ func (suite *UnitTestSuite) TestCollectionsComponentCollectionsSupportedCollecti
 	cfgMgr.AssertExpectations(suite.T())
 	dispatcher.AssertExpectations(suite.T())
 }
+
+// When the agent connects lazily requests for a named collection are queued until a config has been seen, so
+// dispatching them must trigger the connection. Reporting on connections must not.
+func (suite *UnitTestSuite) TestCollectionsComponentLazyConnect() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:     "default",
+		UseCollections: true,
+		LazyConnect:    true,
+	})
+	defer agent.Close()
+
+	_, err := agent.Diagnostics(DiagnosticsOptions{})
+	suite.Assert().True(errors.Is(err, ErrNotConnected), err)
+	_, err = agent.KvEndpointStats()
+	suite.Assert().True(errors.Is(err, ErrNotConnected), err)
+	suite.Assert().Empty(agent.EndpointLatencies())
+	suite.Assert().False(agent.connectTrigger.Connected())
+
+	errCh := make(chan error, 1)
+	_, err = agent.Get(GetOptions{
+		Key:            []byte("key"),
+		ScopeName:      "scope",
+		CollectionName: "collection",
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Assert().True(agent.connectTrigger.Connected())
+
+	select {
+	case err := <-errCh:
+		// The mock server doesn't support collections.
+		suite.Assert().True(errors.Is(err, ErrCollectionsUnsupported), err)
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Timed out waiting for the queued request to be dispatched")
+	}
+}
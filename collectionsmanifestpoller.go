@@ -0,0 +1,277 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// CollectionChangeType describes how a scope or collection differs between two collection manifests.
+type CollectionChangeType string
+
+const (
+	// ScopeCreated indicates that a scope has been created.
+	ScopeCreated = CollectionChangeType("scope_created")
+
+	// ScopeDropped indicates that a scope has been dropped.
+	ScopeDropped = CollectionChangeType("scope_dropped")
+
+	// CollectionCreated indicates that a collection has been created.
+	CollectionCreated = CollectionChangeType("collection_created")
+
+	// CollectionDropped indicates that a collection has been dropped.
+	CollectionDropped = CollectionChangeType("collection_dropped")
+
+	// CollectionMaxTTLChanged indicates that the maximum expiry of a collection has changed.
+	CollectionMaxTTLChanged = CollectionChangeType("collection_max_ttl_changed")
+)
+
+// CollectionChange describes a single scope or collection which differs between two collection manifests. A
+// collection which is dropped and recreated with the same name is reported as being dropped and then created, as its
+// ID changes.
+type CollectionChange struct {
+	Type      CollectionChangeType
+	ScopeName string
+	ScopeID   uint32
+
+	// CollectionName and CollectionID are empty for scope changes.
+	CollectionName string
+	CollectionID   uint32
+
+	// PreviousMaxTTL and MaxTTL are the maximum expiry of the collection, in seconds, before and after the change.
+	PreviousMaxTTL uint32
+	MaxTTL         uint32
+}
+
+// CollectionManifestChange describes the differences between two consecutive collection manifests fetched by an
+// agent. The first manifest fetched is diffed against an empty manifest, so reports every scope and collection.
+type CollectionManifestChange struct {
+	PreviousUID uint64
+	UID         uint64
+	Changes     []CollectionChange
+	Time        time.Time
+}
+
+// CollectionManifestChangeCallback is invoked whenever the collection manifest UID changes. It is invoked from the
+// goroutine which polls the manifest, the next poll does not happen until it returns.
+// Volatile: This API is subject to change at any time.
+type CollectionManifestChangeCallback func(change CollectionManifestChange)
+
+type collectionManifestFetcher interface {
+	GetCollectionManifest(opts GetCollectionManifestOptions, cb GetCollectionManifestCallback) (PendingOp, error)
+}
+
+type collectionsManifestPollerComponent struct {
+	fetcher  collectionManifestFetcher
+	interval time.Duration
+	callback CollectionManifestChangeCallback
+
+	// lastManifest is nil until the first manifest has been fetched.
+	lastManifest *Manifest
+
+	stopSig chan struct{}
+	doneSig chan struct{}
+
+	logCtx logContext
+}
+
+func newCollectionsManifestPollerComponent(fetcher collectionManifestFetcher, interval time.Duration,
+	callback CollectionManifestChangeCallback, logCtx logContext) *collectionsManifestPollerComponent {
+	return &collectionsManifestPollerComponent{
+		fetcher:  fetcher,
+		interval: interval,
+		callback: callback,
+		stopSig:  make(chan struct{}),
+		doneSig:  make(chan struct{}),
+		logCtx:   logCtx,
+	}
+}
+
+func (cmp *collectionsManifestPollerComponent) Start() {
+	go cmp.loop()
+}
+
+func (cmp *collectionsManifestPollerComponent) Stop() {
+	close(cmp.stopSig)
+	<-cmp.doneSig
+}
+
+func (cmp *collectionsManifestPollerComponent) loop() {
+	defer close(cmp.doneSig)
+
+	ticker := time.NewTicker(cmp.interval)
+	defer ticker.Stop()
+
+	for {
+		cmp.poll()
+
+		select {
+		case <-cmp.stopSig:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the collection manifest, giving up if it has not been fetched by the time the next poll is due.
+func (cmp *collectionsManifestPollerComponent) poll() {
+	type fetchResult struct {
+		manifest []byte
+		err      error
+	}
+	resultCh := make(chan fetchResult, 1)
+
+	op, err := cmp.fetcher.GetCollectionManifest(GetCollectionManifestOptions{
+		Deadline: time.Now().Add(cmp.interval),
+	}, func(res *GetCollectionManifestResult, err error) {
+		if err != nil {
+			resultCh <- fetchResult{err: err}
+			return
+		}
+		resultCh <- fetchResult{manifest: res.Manifest}
+	})
+	if err != nil {
+		cmp.logCtx.logDebugf("Failed to fetch collection manifest (%s)", err)
+		return
+	}
+
+	var res fetchResult
+	select {
+	case res = <-resultCh:
+	case <-cmp.stopSig:
+		op.Cancel()
+		return
+	}
+
+	if res.err != nil {
+		cmp.logCtx.logDebugf("Failed to fetch collection manifest (%s)", res.err)
+		return
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(res.manifest, &manifest); err != nil {
+		cmp.logCtx.logDebugf("Failed to parse collection manifest (%s)", err)
+		return
+	}
+
+	cmp.record(&manifest, time.Now())
+}
+
+func (cmp *collectionsManifestPollerComponent) record(manifest *Manifest, now time.Time) {
+	lastManifest := cmp.lastManifest
+	if lastManifest == nil {
+		lastManifest = &Manifest{}
+	} else if manifest.UID == lastManifest.UID {
+		return
+	}
+
+	change := diffCollectionManifests(lastManifest, manifest)
+	change.Time = now
+	cmp.lastManifest = manifest
+
+	if len(change.Changes) == 0 {
+		return
+	}
+
+	cmp.callback(change)
+}
+
+type manifestCollectionEntry struct {
+	scope      ManifestScope
+	collection ManifestCollection
+}
+
+func diffCollectionManifests(oldManifest, newManifest *Manifest) CollectionManifestChange {
+	change := CollectionManifestChange{
+		PreviousUID: oldManifest.UID,
+		UID:         newManifest.UID,
+	}
+
+	oldScopes := make(map[uint32]ManifestScope)
+	oldCollections := make(map[uint32]manifestCollectionEntry)
+	for _, scope := range oldManifest.Scopes {
+		oldScopes[scope.UID] = scope
+		for _, collection := range scope.Collections {
+			oldCollections[collection.UID] = manifestCollectionEntry{scope: scope, collection: collection}
+		}
+	}
+
+	newScopes := make(map[uint32]ManifestScope)
+	newCollections := make(map[uint32]manifestCollectionEntry)
+	for _, scope := range newManifest.Scopes {
+		newScopes[scope.UID] = scope
+		for _, collection := range scope.Collections {
+			newCollections[collection.UID] = manifestCollectionEntry{scope: scope, collection: collection}
+		}
+	}
+
+	// Drops are reported first so that a collection recreated with the same name is seen to be dropped before it
+	// is created again.
+	for uid, scope := range oldScopes {
+		if _, ok := newScopes[uid]; !ok {
+			change.Changes = append(change.Changes, CollectionChange{
+				Type:      ScopeDropped,
+				ScopeName: scope.Name,
+				ScopeID:   scope.UID,
+			})
+		}
+	}
+	for uid, entry := range oldCollections {
+		if _, ok := newCollections[uid]; !ok {
+			change.Changes = append(change.Changes, collectionChangeFor(CollectionDropped, entry))
+		}
+	}
+	for uid, scope := range newScopes {
+		if _, ok := oldScopes[uid]; !ok {
+			change.Changes = append(change.Changes, CollectionChange{
+				Type:      ScopeCreated,
+				ScopeName: scope.Name,
+				ScopeID:   scope.UID,
+			})
+		}
+	}
+	for uid, entry := range newCollections {
+		oldEntry, ok := oldCollections[uid]
+		if !ok {
+			change.Changes = append(change.Changes, collectionChangeFor(CollectionCreated, entry))
+			continue
+		}
+
+		if oldEntry.collection.MaxTTL != entry.collection.MaxTTL {
+			ttlChange := collectionChangeFor(CollectionMaxTTLChanged, entry)
+			ttlChange.PreviousMaxTTL = oldEntry.collection.MaxTTL
+			change.Changes = append(change.Changes, ttlChange)
+		}
+	}
+
+	changeOrder := map[CollectionChangeType]int{
+		ScopeDropped:            0,
+		CollectionDropped:       1,
+		ScopeCreated:            2,
+		CollectionCreated:       3,
+		CollectionMaxTTLChanged: 4,
+	}
+	sort.SliceStable(change.Changes, func(i, j int) bool {
+		a, b := change.Changes[i], change.Changes[j]
+		if a.Type != b.Type {
+			return changeOrder[a.Type] < changeOrder[b.Type]
+		}
+		if a.ScopeID != b.ScopeID {
+			return a.ScopeID < b.ScopeID
+		}
+		return a.CollectionID < b.CollectionID
+	})
+
+	return change
+}
+
+func collectionChangeFor(changeType CollectionChangeType, entry manifestCollectionEntry) CollectionChange {
+	return CollectionChange{
+		Type:           changeType,
+		ScopeName:      entry.scope.Name,
+		ScopeID:        entry.scope.UID,
+		CollectionName: entry.collection.Name,
+		CollectionID:   entry.collection.UID,
+		MaxTTL:         entry.collection.MaxTTL,
+	}
+}
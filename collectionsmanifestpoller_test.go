@@ -0,0 +1,108 @@
+package gocbcore
+
+import (
+	"errors"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestDiffCollectionManifests() {
+	oldManifest := &Manifest{
+		UID: 2,
+		Scopes: []ManifestScope{
+			{UID: 0, Name: "_default", Collections: []ManifestCollection{
+				{UID: 0, Name: "_default"},
+				{UID: 8, Name: "users", MaxTTL: 60},
+				{UID: 9, Name: "sessions"},
+			}},
+			{UID: 10, Name: "archive", Collections: []ManifestCollection{
+				{UID: 11, Name: "old"},
+			}},
+		},
+	}
+	newManifest := &Manifest{
+		UID: 5,
+		Scopes: []ManifestScope{
+			{UID: 0, Name: "_default", Collections: []ManifestCollection{
+				{UID: 0, Name: "_default"},
+				{UID: 8, Name: "users", MaxTTL: 120},
+				{UID: 12, Name: "sessions"},
+			}},
+			{UID: 13, Name: "inventory", Collections: []ManifestCollection{
+				{UID: 14, Name: "items", MaxTTL: 30},
+			}},
+		},
+	}
+
+	change := diffCollectionManifests(oldManifest, newManifest)
+	suite.Assert().Equal(uint64(2), change.PreviousUID)
+	suite.Assert().Equal(uint64(5), change.UID)
+	suite.Assert().Equal([]CollectionChange{
+		{Type: ScopeDropped, ScopeName: "archive", ScopeID: 10},
+		{Type: CollectionDropped, ScopeName: "_default", CollectionName: "sessions", CollectionID: 9},
+		{Type: CollectionDropped, ScopeName: "archive", ScopeID: 10, CollectionName: "old", CollectionID: 11},
+		{Type: ScopeCreated, ScopeName: "inventory", ScopeID: 13},
+		{Type: CollectionCreated, ScopeName: "_default", CollectionName: "sessions", CollectionID: 12},
+		{Type: CollectionCreated, ScopeName: "inventory", ScopeID: 13, CollectionName: "items", CollectionID: 14,
+			MaxTTL: 30},
+		{Type: CollectionMaxTTLChanged, ScopeName: "_default", CollectionName: "users", CollectionID: 8,
+			PreviousMaxTTL: 60, MaxTTL: 120},
+	}, change.Changes)
+
+	suite.Assert().Empty(diffCollectionManifests(newManifest, newManifest).Changes)
+}
+
+type fakeManifestFetcher struct {
+	manifests chan []byte
+}
+
+func (f *fakeManifestFetcher) GetCollectionManifest(opts GetCollectionManifestOptions,
+	cb GetCollectionManifestCallback) (PendingOp, error) {
+	select {
+	case manifest := <-f.manifests:
+		cb(&GetCollectionManifestResult{Manifest: manifest}, nil)
+	default:
+		cb(nil, errors.New("no manifest"))
+	}
+
+	return &multiPendingOp{}, nil
+}
+
+func (suite *UnitTestSuite) TestCollectionsManifestPoller() {
+	fetcher := &fakeManifestFetcher{manifests: make(chan []byte, 3)}
+	fetcher.manifests <- []byte(`{"uid":"1","scopes":[{"uid":"0","name":"_default",` +
+		`"collections":[{"uid":"0","name":"_default"}]}]}`)
+	fetcher.manifests <- []byte(`{"uid":"1","scopes":[{"uid":"0","name":"_default",` +
+		`"collections":[{"uid":"0","name":"_default"}]}]}`)
+	fetcher.manifests <- []byte(`{"uid":"2","scopes":[{"uid":"0","name":"_default",` +
+		`"collections":[{"uid":"0","name":"_default"},{"uid":"8","name":"users","maxTTL":60}]}]}`)
+
+	changes := make(chan CollectionManifestChange, 3)
+	poller := newCollectionsManifestPollerComponent(fetcher, time.Millisecond, func(change CollectionManifestChange) {
+		changes <- change
+	}, nil)
+	poller.Start()
+
+	var initial, created CollectionManifestChange
+	select {
+	case initial = <-changes:
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Timed out waiting for the initial manifest")
+	}
+	select {
+	case created = <-changes:
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Timed out waiting for the manifest change")
+	}
+	poller.Stop()
+
+	suite.Assert().Equal(uint64(1), initial.UID)
+	suite.Assert().Len(initial.Changes, 2)
+
+	// The repeated manifest with the same UID must not have been reported.
+	suite.Assert().Equal(uint64(1), created.PreviousUID)
+	suite.Assert().Equal(uint64(2), created.UID)
+	suite.Assert().Equal([]CollectionChange{
+		{Type: CollectionCreated, ScopeName: "_default", CollectionName: "users", CollectionID: 8, MaxTTL: 60},
+	}, created.Changes)
+	suite.Assert().Empty(changes)
+}
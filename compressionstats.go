@@ -0,0 +1,99 @@
+package gocbcore
+
+import "sync/atomic"
+
+// KvCompressionStats contains counters describing how effective compression has been on kv connections.
+// Volatile: This API is subject to change at any time.
+type KvCompressionStats struct {
+	// CompressedValues is the number of values which were sent compressed.
+	CompressedValues uint64
+
+	// BytesBeforeCompression and BytesAfterCompression are the total sizes of the values which were sent compressed,
+	// before and after they were compressed.
+	BytesBeforeCompression uint64
+	BytesAfterCompression  uint64
+
+	// SkippedMinSize is the number of values which were sent uncompressed because they were no larger than
+	// CompressionMinSize.
+	SkippedMinSize uint64
+
+	// SkippedMinRatio is the number of values which were compressed but then sent uncompressed because they did not
+	// compress to within CompressionMinRatio of their original size.
+	SkippedMinRatio uint64
+
+	// DecompressedValues is the number of compressed values received from the server which were decompressed.
+	DecompressedValues uint64
+}
+
+// Ratio returns the average size of the values which were sent compressed relative to their original size, or 0 if
+// no values have been sent compressed.
+func (stats KvCompressionStats) Ratio() float64 {
+	if stats.BytesBeforeCompression == 0 {
+		return 0
+	}
+
+	return float64(stats.BytesAfterCompression) / float64(stats.BytesBeforeCompression)
+}
+
+func (stats *KvCompressionStats) add(other KvCompressionStats) {
+	stats.CompressedValues += other.CompressedValues
+	stats.BytesBeforeCompression += other.BytesBeforeCompression
+	stats.BytesAfterCompression += other.BytesAfterCompression
+	stats.SkippedMinSize += other.SkippedMinSize
+	stats.SkippedMinRatio += other.SkippedMinRatio
+	stats.DecompressedValues += other.DecompressedValues
+}
+
+// compressionCounters counts the compression performed by a single connection. All methods are safe to call on a
+// nil receiver.
+type compressionCounters struct {
+	compressedValues       uint64
+	bytesBeforeCompression uint64
+	bytesAfterCompression  uint64
+	skippedMinSize         uint64
+	skippedMinRatio        uint64
+	decompressedValues     uint64
+}
+
+func (cc *compressionCounters) RecordCompressed(before, after int) {
+	if cc == nil {
+		return
+	}
+
+	atomic.AddUint64(&cc.compressedValues, 1)
+	atomic.AddUint64(&cc.bytesBeforeCompression, uint64(before))
+	atomic.AddUint64(&cc.bytesAfterCompression, uint64(after))
+}
+
+func (cc *compressionCounters) RecordSkippedMinSize() {
+	if cc != nil {
+		atomic.AddUint64(&cc.skippedMinSize, 1)
+	}
+}
+
+func (cc *compressionCounters) RecordSkippedMinRatio() {
+	if cc != nil {
+		atomic.AddUint64(&cc.skippedMinRatio, 1)
+	}
+}
+
+func (cc *compressionCounters) RecordDecompressed() {
+	if cc != nil {
+		atomic.AddUint64(&cc.decompressedValues, 1)
+	}
+}
+
+func (cc *compressionCounters) Stats() KvCompressionStats {
+	if cc == nil {
+		return KvCompressionStats{}
+	}
+
+	return KvCompressionStats{
+		CompressedValues:       atomic.LoadUint64(&cc.compressedValues),
+		BytesBeforeCompression: atomic.LoadUint64(&cc.bytesBeforeCompression),
+		BytesAfterCompression:  atomic.LoadUint64(&cc.bytesAfterCompression),
+		SkippedMinSize:         atomic.LoadUint64(&cc.skippedMinSize),
+		SkippedMinRatio:        atomic.LoadUint64(&cc.skippedMinRatio),
+		DecompressedValues:     atomic.LoadUint64(&cc.decompressedValues),
+	}
+}
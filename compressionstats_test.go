@@ -0,0 +1,81 @@
+package gocbcore
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestKvCompressionStats() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	server.Handle(memd.CmdHello, func(req *memd.Packet) *memd.Packet {
+		features := make([]byte, 2)
+		binary.BigEndian.PutUint16(features, uint16(memd.FeatureSnappy))
+		return &memd.Packet{Status: memd.StatusSuccess, Value: features}
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:     "default",
+		UseCompression: true,
+	})
+	defer agent.Close()
+
+	randomValue := make([]byte, 1024)
+	_, err := rand.Read(randomValue)
+	suite.Require().Nil(err)
+
+	values := map[string][]byte{
+		"small":        []byte("value"),
+		"compressible": bytes.Repeat([]byte("abcd"), 256),
+		"random":       randomValue,
+	}
+	for key, value := range values {
+		setCh := make(chan error, 1)
+		_, err = agent.Set(SetOptions{
+			Key:      []byte(key),
+			Value:    value,
+			Deadline: time.Now().Add(5 * time.Second),
+		}, func(res *StoreResult, err error) {
+			setCh <- err
+		})
+		suite.Require().Nil(err)
+		suite.Require().Nil(<-setCh)
+	}
+
+	// The mock stores the value as it was sent, so this is returned compressed.
+	getCh := make(chan *GetResult, 1)
+	_, err = agent.Get(GetOptions{Key: []byte("compressible"), Deadline: time.Now().Add(5 * time.Second)},
+		func(res *GetResult, err error) {
+			suite.Assert().Nil(err)
+			getCh <- res
+		})
+	suite.Require().Nil(err)
+	res := <-getCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal(values["compressible"], res.Value)
+
+	endpoints, err := agent.KvEndpointStats()
+	suite.Require().Nil(err)
+	suite.Require().Len(endpoints, 1)
+
+	stats := endpoints[0].Compression
+	suite.Assert().Equal(uint64(1), stats.CompressedValues)
+	suite.Assert().Equal(uint64(1024), stats.BytesBeforeCompression)
+	suite.Assert().Less(stats.BytesAfterCompression, uint64(1024))
+	suite.Assert().Less(stats.Ratio(), 0.83)
+	suite.Assert().Equal(uint64(1), stats.SkippedMinSize)
+	suite.Assert().Equal(uint64(1), stats.SkippedMinRatio)
+	suite.Assert().Equal(uint64(1), stats.DecompressedValues)
+
+	var connTotal KvCompressionStats
+	for _, conn := range endpoints[0].Connections {
+		connTotal.add(conn.Compression)
+	}
+	suite.Assert().Equal(stats, connTotal)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
@@ -64,6 +65,7 @@ type cfgVBucketServerMap struct {
 // Bucket is the primary entry point for most data operations.
 type cfgBucket struct {
 	Rev                 int64 `json:"rev"`
+	RevEpoch            int64 `json:"revEpoch"`
 	SourceHostname      string
 	Capabilities        []string `json:"bucketCapabilities"`
 	CapabilitiesVersion string   `json:"bucketCapabilitiesVer"`
@@ -72,6 +74,8 @@ type cfgBucket struct {
 	URI                 string   `json:"uri"`
 	StreamingURI        string   `json:"streamingUri"`
 	UUID                string   `json:"uuid"`
+	ClusterUUID         string   `json:"clusterUUID,omitempty"`
+	ClusterName         string   `json:"clusterName,omitempty"`
 	DDocs               struct {
 		URI string `json:"uri"`
 	} `json:"ddocs,omitempty"`
@@ -86,6 +90,11 @@ type cfgBucket struct {
 }
 
 func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstConnect bool) *routeConfig {
+	return cfg.buildRouteConfig(useSsl, networkType, firstConnect, nil, nil)
+}
+
+func (cfg *cfgBucket) buildRouteConfig(useSsl bool, networkType string, firstConnect bool,
+	resolver NetworkResolver, hasher KetamaHasher) *routeConfig {
 	var kvServerList []string
 	var capiEpList []string
 	var mgmtEpList []string
@@ -110,19 +119,34 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 
 	if cfg.NodesExt != nil {
 		lenNodes := len(cfg.Nodes)
+
+		// Clusters can contain nodes which don't run the data service, such as query only nodes, and these can be
+		// listed in nodesExt before the data nodes. Where possible the kv servers are ordered by their position in the
+		// bucket's server list so that the vbucket map indexes the right servers however the nodes are ordered.
+		kvNodeIndexes := cfg.kvNodeIndexes()
+		indexedKvServers := make([]string, len(kvNodeIndexes))
+		numIndexedKvServers := 0
+
 		for i, node := range cfg.NodesExt {
 			hostname := node.Hostname
 			ports := node.Services
 
-			if networkType != "default" {
-				if altAddr, ok := node.AltAddresses[networkType]; ok {
+			var defaultKvServer string
+			if node.Services.Kv > 0 {
+				defaultKvServer = joinKvNodeAddress(getHostname(node.Hostname, cfg.SourceHostname),
+					strconv.Itoa(int(node.Services.Kv)))
+			}
+
+			nodeNetworkType := node.resolveNetworkType(networkType, resolver)
+			if nodeNetworkType != "default" {
+				if altAddr, ok := node.AltAddresses[nodeNetworkType]; ok {
 					hostname = altAddr.Hostname
 					if altAddr.Ports != nil {
 						ports = *altAddr.Ports
 					}
 				} else {
 					if !firstConnect {
-						logDebugf("Invalid config network type %s", networkType)
+						logDebugf("Invalid config network type %s", nodeNetworkType)
 					}
 					continue
 				}
@@ -132,8 +156,13 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 
 			endpoints := endpointsFromPorts(useSsl, ports, cfg.Name, hostname)
 			if endpoints.kvServer != "" {
+				if idx, ok := kvNodeIndexes[defaultKvServer]; ok && indexedKvServers[idx] == "" {
+					indexedKvServers[idx] = endpoints.kvServer
+					numIndexedKvServers++
+				}
+
 				if bktType > bktTypeInvalid && i >= lenNodes {
-					logDebugf("KV node present in nodesext but not in nodes for %s", endpoints.kvServer)
+					logDebugf("KV node present in nodesext but not in nodes for %s", logSystemData(endpoints.kvServer))
 				} else {
 					kvServerList = append(kvServerList, endpoints.kvServer)
 				}
@@ -154,6 +183,14 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 				cbasEpList = append(cbasEpList, endpoints.cbasEp)
 			}
 		}
+
+		if numIndexedKvServers > 0 {
+			if numIndexedKvServers == len(indexedKvServers) {
+				kvServerList = indexedKvServers
+			} else {
+				logDebugf("Not all of the nodes in the server list were found in nodesExt, ordering kv nodes by nodesExt")
+			}
+		}
 	} else {
 		if useSsl {
 			logErrorf("Received config without nodesExt while SSL is enabled.  Generating invalid config.")
@@ -191,8 +228,11 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 
 	rc := &routeConfig{
 		revID:                  cfg.Rev,
+		revEpoch:               cfg.RevEpoch,
 		uuid:                   cfg.UUID,
 		name:                   cfg.Name,
+		clusterUUID:            cfg.ClusterUUID,
+		clusterName:            cfg.ClusterName,
 		kvServerList:           kvServerList,
 		capiEpList:             capiEpList,
 		mgmtEpList:             mgmtEpList,
@@ -211,12 +251,65 @@ func (cfg *cfgBucket) BuildRouteConfig(useSsl bool, networkType string, firstCon
 		numReplicas := cfg.VBucketServerMap.NumReplicas
 		rc.vbMap = newVbucketMap(vbMap, numReplicas)
 	} else if bktType == bktTypeMemcached {
-		rc.ketamaMap = newKetamaContinuum(kvServerList)
+		if hasher != nil {
+			rc.ketamaMap = newKetamaContinuumWithHasher(kvServerList, hasher)
+		} else {
+			rc.ketamaMap = newKetamaContinuum(kvServerList)
+		}
 	}
 
 	return rc
 }
 
+// kvNodeIndexes returns the position of each of the bucket's data nodes in its server list, keyed by the node's kv
+// address on the default network. Nil is returned for cluster level configs and for server lists which are not usable.
+func (cfg *cfgBucket) kvNodeIndexes() map[string]int {
+	var servers []string
+	switch cfg.NodeLocator {
+	case "vbucket":
+		for _, server := range cfg.VBucketServerMap.ServerList {
+			// The port follows the last colon, whether or not an IPv6 host is wrapped in brackets.
+			idx := strings.LastIndexByte(server, ':')
+			if idx < 0 {
+				servers = append(servers, server)
+				continue
+			}
+
+			servers = append(servers, joinKvNodeAddress(server[:idx], server[idx+1:]))
+		}
+	case "ketama":
+		for _, node := range cfg.Nodes {
+			host, _, err := net.SplitHostPort(node.Hostname)
+			if err != nil || node.Ports["direct"] == 0 {
+				continue
+			}
+
+			servers = append(servers, joinKvNodeAddress(host, strconv.Itoa(node.Ports["direct"])))
+		}
+	}
+
+	if len(servers) == 0 {
+		return nil
+	}
+
+	indexes := make(map[string]int, len(servers))
+	for i, server := range servers {
+		if _, ok := indexes[server]; ok {
+			logDebugf("Server %s appears in the server list more than once", logSystemData(server))
+			return nil
+		}
+		indexes[server] = i
+	}
+
+	return indexes
+}
+
+// joinKvNodeAddress builds the address used to key kvNodeIndexes, hosts may or may not already be wrapped in brackets
+// if they are IPv6 addresses.
+func joinKvNodeAddress(host, port string) string {
+	return net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), port)
+}
+
 type serverEps struct {
 	kvServer string
 	capiEp   string
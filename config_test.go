@@ -0,0 +1,108 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestConfigWithoutKvNodes() {
+	// The query only node is listed first in nodesExt, and the data nodes are listed in a different order to the
+	// server list. The last node is still being added to the bucket so is not in the server list.
+	cfg, err := parseConfig([]byte(`{
+		"rev": 10,
+		"name": "default",
+		"uuid": "b1",
+		"nodeLocator": "vbucket",
+		"nodes": [
+			{"hostname": "10.0.0.2:8091", "ports": {"direct": 11210}},
+			{"hostname": "10.0.0.3:8091", "ports": {"direct": 11210}}
+		],
+		"nodesExt": [
+			{"hostname": "10.0.0.1", "services": {"mgmt": 8091, "n1ql": 8093}},
+			{"hostname": "10.0.0.3", "services": {"mgmt": 8091, "kv": 11210, "capi": 8092}},
+			{"hostname": "10.0.0.2", "services": {"mgmt": 8091, "kv": 11210, "capi": 8092}},
+			{"hostname": "10.0.0.4", "services": {"mgmt": 8091, "kv": 11210}}
+		],
+		"vBucketServerMap": {
+			"numReplicas": 1,
+			"serverList": ["10.0.0.2:11210", "10.0.0.3:11210"],
+			"vBucketMap": [[0, 1], [1, 0]]
+		}
+	}`), "10.0.0.1")
+	suite.Require().Nil(err)
+
+	rc := cfg.BuildRouteConfig(false, "default", false)
+	suite.Require().True(rc.IsValid())
+	suite.Assert().Equal([]string{"10.0.0.2:11210", "10.0.0.3:11210"}, rc.kvServerList)
+	suite.Assert().Equal([]string{"http://10.0.0.1:8093"}, rc.n1qlEpList)
+	suite.Assert().Equal([]string{"http://10.0.0.1:8091", "http://10.0.0.3:8091", "http://10.0.0.2:8091",
+		"http://10.0.0.4:8091"}, rc.mgmtEpList)
+
+	// The same cluster's cluster level config still includes every data node but no pipeline for the query node.
+	cfg, err = parseConfig([]byte(`{
+		"rev": 10,
+		"nodesExt": [
+			{"hostname": "10.0.0.1", "services": {"mgmt": 8091, "n1ql": 8093}},
+			{"hostname": "10.0.0.3", "services": {"mgmt": 8091, "kv": 11210}},
+			{"hostname": "10.0.0.2", "services": {"mgmt": 8091, "kv": 11210}}
+		]
+	}`), "10.0.0.1")
+	suite.Require().Nil(err)
+
+	rc = cfg.BuildRouteConfig(false, "default", false)
+	suite.Require().True(rc.IsValid())
+	suite.Assert().True(rc.IsGCCCPConfig())
+	suite.Assert().Equal([]string{"10.0.0.3:11210", "10.0.0.2:11210"}, rc.kvServerList)
+	suite.Assert().Len(rc.mgmtEpList, 3)
+}
+
+func (suite *UnitTestSuite) TestConfigWithoutKvNodesMemcached() {
+	cfg, err := parseConfig([]byte(`{
+		"rev": 10,
+		"name": "memd",
+		"uuid": "b2",
+		"nodeLocator": "ketama",
+		"nodes": [
+			{"hostname": "10.0.0.2:8091", "ports": {"direct": 11210}},
+			{"hostname": "10.0.0.3:8091", "ports": {"direct": 11210}}
+		],
+		"nodesExt": [
+			{"hostname": "10.0.0.1", "services": {"mgmt": 8091, "n1ql": 8093}},
+			{"hostname": "10.0.0.2", "services": {"mgmt": 8091, "kv": 11210}},
+			{"hostname": "10.0.0.3", "services": {"mgmt": 8091, "kv": 11210}}
+		]
+	}`), "10.0.0.1")
+	suite.Require().Nil(err)
+
+	rc := cfg.BuildRouteConfig(false, "default", false)
+	suite.Require().True(rc.IsValid())
+	suite.Assert().Equal([]string{"10.0.0.2:11210", "10.0.0.3:11210"}, rc.kvServerList)
+	suite.Assert().Equal([]string{"http://10.0.0.1:8093"}, rc.n1qlEpList)
+}
+
+func (suite *UnitTestSuite) TestConfigWithoutKvNodesIPv6() {
+	// The server list wraps the IPv6 hosts in brackets whereas nodesExt does not.
+	cfg, err := parseConfig([]byte(`{
+		"rev": 10,
+		"name": "default",
+		"uuid": "b1",
+		"nodeLocator": "vbucket",
+		"nodes": [
+			{"hostname": "[fd00::2]:8091", "ports": {"direct": 11210}},
+			{"hostname": "[fd00::3]:8091", "ports": {"direct": 11210}}
+		],
+		"nodesExt": [
+			{"hostname": "fd00::1", "services": {"mgmt": 8091, "n1ql": 8093}},
+			{"hostname": "fd00::3", "services": {"mgmt": 8091, "kv": 11210}},
+			{"hostname": "fd00::2", "services": {"mgmt": 8091, "kv": 11210}}
+		],
+		"vBucketServerMap": {
+			"numReplicas": 1,
+			"serverList": ["[fd00::2]:11210", "fd00::3:11210"],
+			"vBucketMap": [[0, 1], [1, 0]]
+		}
+	}`), "[fd00::1]")
+	suite.Require().Nil(err)
+
+	rc := cfg.BuildRouteConfig(false, "default", false)
+	suite.Require().True(rc.IsValid())
+	suite.Assert().Equal([]string{"[fd00::2]:11210", "[fd00::3]:11210"}, rc.kvServerList)
+
+	cfg.NodeLocator = "ketama"
+	suite.Assert().Equal(map[string]int{"[fd00::2]:11210": 0, "[fd00::3]:11210": 1}, cfg.kvNodeIndexes())
+}
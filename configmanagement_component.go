@@ -2,20 +2,45 @@ package gocbcore
 
 import (
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type configManagementComponent struct {
-	useSSL      bool
-	networkType string
+	useSSL                bool
+	networkType           string
+	configuredNetworkType string
 
 	currentConfig *routeConfig
+	configLock    sync.Mutex
 
 	cfgChangeWatchers []routeConfigWatcher
 	watchersLock      sync.Mutex
 
 	srcServers []string
 
+	addressTranslator AddressTranslator
+	networkResolver   NetworkResolver
+	ketamaHasher      KetamaHasher
+
+	configStorer *clusterConfigStorer
+	bucketName   string
+
 	seenConfig bool
+
+	// frozen prevents received configs from being applied, the most recent is applied when it is unset.
+	frozen bool
+
+	// frozenConfig is the most recently received config whilst frozen, it is applied when unfrozen.
+	frozenConfig *cfgBucket
+
+	// lastConfig is the config which the current route config was built from, it is kept so that the route config
+	// can be rebuilt.
+	lastConfig *cfgBucket
+
+	lastConfigTime int64
+
+	logCtx logContext
 }
 
 type configManagerProperties struct {
@@ -23,6 +48,15 @@ type configManagerProperties struct {
 	NetworkType  string
 	SrcMemdAddrs []string
 	SrcHTTPAddrs []string
+
+	AddressTranslator AddressTranslator
+	NetworkResolver   NetworkResolver
+	KetamaHasher      KetamaHasher
+
+	ConfigStore ClusterConfigStore
+	BucketName  string
+
+	LogContext logContext
 }
 
 type routeConfigWatcher interface {
@@ -35,38 +69,108 @@ type configManager interface {
 }
 
 func newConfigManager(props configManagerProperties) *configManagementComponent {
+	var configStorer *clusterConfigStorer
+	if props.ConfigStore != nil {
+		configStorer = newClusterConfigStorer(props.ConfigStore, props.LogContext)
+	}
+
 	return &configManagementComponent{
-		useSSL:      props.UseSSL,
-		networkType: props.NetworkType,
-		srcServers:  append(props.SrcMemdAddrs, props.SrcHTTPAddrs...),
+		useSSL:                props.UseSSL,
+		networkType:           props.NetworkType,
+		configuredNetworkType: props.NetworkType,
+		srcServers:            append(props.SrcMemdAddrs, props.SrcHTTPAddrs...),
 		currentConfig: &routeConfig{
 			revID: -1,
 		},
+
+		addressTranslator: props.AddressTranslator,
+		networkResolver:   props.NetworkResolver,
+		ketamaHasher:      props.KetamaHasher,
+
+		configStorer: configStorer,
+		bucketName:   props.BucketName,
+
+		logCtx: props.LogContext,
 	}
 }
 
 func (cm *configManagementComponent) OnNewConfig(cfg *cfgBucket) {
-	var routeCfg *routeConfig
-	if cm.seenConfig {
-		routeCfg = cfg.BuildRouteConfig(cm.useSSL, cm.networkType, false)
-	} else {
-		routeCfg = cm.buildFirstRouteConfig(cfg)
-		logDebugf("Using network type %s for connections", cm.networkType)
+	cm.applyConfig(cfg, false)
+}
+
+// applyConfig builds a route config from cfg and sends it to the watchers if it should be applied. When rebuild is set
+// cfg is the config which is already applied, its route config is rebuilt regardless of its revision so that a
+// redetected network type takes effect.
+func (cm *configManagementComponent) applyConfig(cfg *cfgBucket, rebuild bool) {
+	cm.configLock.Lock()
+	networkType := cm.networkType
+	seenConfig := cm.seenConfig
+	cm.configLock.Unlock()
+
+	if !seenConfig {
+		networkType = cm.detectNetworkType(cfg)
+		cm.logCtx.logDebugf("Using network type %s for connections", networkType)
+
+		cm.configLock.Lock()
+		cm.networkType = networkType
+		cm.configLock.Unlock()
+	}
+
+	routeCfg := cfg.buildRouteConfig(cm.useSSL, networkType, !seenConfig, cm.networkResolver, cm.ketamaHasher)
+	if cm.addressTranslator != nil {
+		translateRouteConfig(cm.addressTranslator, routeCfg, cm.logCtx)
 	}
+
 	if !routeCfg.IsValid() {
-		logDebugf("Routing data is not valid, skipping update: \n%s", routeCfg.DebugString())
+		cm.logCtx.logDebugf("Routing data is not valid, skipping update: \n%s", routeCfg.DebugString())
 		return
 	}
 
-	// There's something wrong with this route config so don't send it to the watchers.
-	if !cm.updateRouteConfig(routeCfg) {
+	if !rebuild {
+		// Any valid config counts as evidence that config fetching is alive, even if we don't end up applying it.
+		atomic.StoreInt64(&cm.lastConfigTime, time.Now().UnixNano())
+	}
+
+	cm.configLock.Lock()
+	if cm.frozen {
+		if !rebuild {
+			cm.frozenConfig = cfg
+		}
+		cm.configLock.Unlock()
+		cm.logCtx.logDebugf("Config updates are frozen, not applying config with revision %d", routeCfg.revID)
 		return
 	}
 
-	logDebugf("Sending out mux routing data (update)...")
-	logDebugf("New Routing Data:\n%s", routeCfg.DebugString())
+	var applied bool
+	if rebuild {
+		// If a newer config has been applied in the meantime then it was already built using the redetected network
+		// type, so there's nothing left to do.
+		applied = cm.lastConfig == cfg
+		if applied {
+			cm.currentConfig = routeCfg
+		}
+	} else {
+		// There's something wrong with this route config so don't send it to the watchers.
+		applied = cm.updateRouteConfigLocked(routeCfg)
+	}
+	if applied {
+		cm.lastConfig = cfg
+		cm.seenConfig = true
+	}
+	bucketName := cm.bucketName
+	cm.configLock.Unlock()
 
-	cm.seenConfig = true
+	if !applied {
+		return
+	}
+
+	cm.logCtx.logDebugf("Sending out mux routing data (update)...")
+	cm.logCtx.logDebugf("New Routing Data:\n%s", routeCfg.DebugString())
+
+	// Unversioned configs, such as one loaded from the store, aren't worth storing.
+	if !rebuild && cm.configStorer != nil && cfg.Rev > 0 {
+		cm.configStorer.Store(bucketName, cfg)
+	}
 
 	// We can end up deadlocking if we iterate whilst in the lock and a watcher decides to remove itself.
 	cm.watchersLock.Lock()
@@ -79,6 +183,30 @@ func (cm *configManagementComponent) OnNewConfig(cfg *cfgBucket) {
 	}
 }
 
+// SetBucketName sets the bucket which configs are stored against, it must be called when the agent switches bucket.
+func (cm *configManagementComponent) SetBucketName(bucketName string) {
+	cm.configLock.Lock()
+	cm.bucketName = bucketName
+	cm.configLock.Unlock()
+}
+
+// WaitForStoredConfigs waits for configs which are being stored in the background.
+func (cm *configManagementComponent) WaitForStoredConfigs() {
+	if cm.configStorer != nil {
+		cm.configStorer.Wait()
+	}
+}
+
+// LastConfigTime returns the time at which a valid config was last received, or the zero time if none has been seen.
+func (cm *configManagementComponent) LastConfigTime() time.Time {
+	lastConfigTime := atomic.LoadInt64(&cm.lastConfigTime)
+	if lastConfigTime == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, lastConfigTime)
+}
+
 func (cm *configManagementComponent) AddConfigWatcher(watcher routeConfigWatcher) {
 	cm.watchersLock.Lock()
 	cm.cfgChangeWatchers = append(cm.cfgChangeWatchers, watcher)
@@ -102,20 +230,28 @@ func (cm *configManagementComponent) RemoveConfigWatcher(watcher routeConfigWatc
 	cm.watchersLock.Unlock()
 }
 
-// We should never be receiving concurrent updates and nothing should be accessing
-// our internal route config so we shouldn't need to lock here.
+// Nothing outside of this component should be accessing our internal route config, we only lock so that a reset
+// can't race with an update.
 func (cm *configManagementComponent) updateRouteConfig(cfg *routeConfig) bool {
+	cm.configLock.Lock()
+	defer cm.configLock.Unlock()
+
+	return cm.updateRouteConfigLocked(cfg)
+}
+
+// updateRouteConfigLocked is updateRouteConfig for callers which already hold configLock.
+func (cm *configManagementComponent) updateRouteConfigLocked(cfg *routeConfig) bool {
 	oldCfg := cm.currentConfig
 
 	// Check some basic things to ensure consistency!
 	if oldCfg.revID > -1 {
 		if (cfg.vbMap == nil) != (oldCfg.vbMap == nil) {
-			logErrorf("Received a configuration with a different number of vbuckets.  Ignoring.")
+			cm.logCtx.logErrorf("Received a configuration with a different number of vbuckets.  Ignoring.")
 			return false
 		}
 
 		if cfg.vbMap != nil && cfg.vbMap.NumVbuckets() != oldCfg.vbMap.NumVbuckets() {
-			logErrorf("Received a configuration with a different number of vbuckets.  Ignoring.")
+			cm.logCtx.logErrorf("Received a configuration with a different number of vbuckets.  Ignoring.")
 			return false
 		}
 	}
@@ -124,14 +260,20 @@ func (cm *configManagementComponent) updateRouteConfig(cfg *routeConfig) bool {
 	// against an existing connection then the revisions could be the same. In that case the configuration still
 	// needs to be applied.
 	if cfg.revID == 0 {
-		logDebugf("Unversioned configuration data, switching.")
+		cm.logCtx.logDebugf("Unversioned configuration data, switching.")
 	} else if cfg.bktType != oldCfg.bktType {
-		logDebugf("Configuration data changed bucket type, switching.")
-	} else if cfg.revID == oldCfg.revID {
-		logDebugf("Ignoring configuration with identical revision number")
+		cm.logCtx.logDebugf("Configuration data changed bucket type, switching.")
+	} else if cfg.uuid != "" && cfg.name == oldCfg.name && cfg.uuid != oldCfg.uuid {
+		// The revisions of a recreated bucket start again so can't be compared to those of the previous bucket.
+		cm.logCtx.logDebugf("Configuration data changed bucket uuid, switching.")
+	} else if cfg.revEpoch < oldCfg.revEpoch {
+		cm.logCtx.logDebugf("Ignoring new configuration as it has an older revision epoch")
 		return false
-	} else if cfg.revID < oldCfg.revID {
-		logDebugf("Ignoring new configuration as it has an older revision id")
+	} else if cfg.revEpoch == oldCfg.revEpoch && cfg.revID == oldCfg.revID {
+		cm.logCtx.logDebugf("Ignoring configuration with identical revision number")
+		return false
+	} else if !cfg.IsNewerThan(oldCfg) {
+		cm.logCtx.logDebugf("Ignoring new configuration as it has an older revision id")
 		return false
 	}
 
@@ -140,9 +282,23 @@ func (cm *configManagementComponent) updateRouteConfig(cfg *routeConfig) bool {
 	return true
 }
 
-func (cm *configManagementComponent) buildFirstRouteConfig(config *cfgBucket) *routeConfig {
-	if cm.networkType != "" && cm.networkType != "auto" {
-		return config.BuildRouteConfig(cm.useSSL, cm.networkType, true)
+// ResetConfig discards the current route config so that the next config received is applied regardless of its
+// revision, this is used when the bucket that we're connected to changes.
+func (cm *configManagementComponent) ResetConfig() {
+	cm.configLock.Lock()
+	cm.currentConfig = &routeConfig{
+		revID: -1,
+	}
+	cm.lastConfig = nil
+	cm.frozenConfig = nil
+	cm.configLock.Unlock()
+}
+
+// detectNetworkType returns the network type to use for connections, if it has not been configured explicitly then
+// it is detected by checking which network the addresses we bootstrapped against belong to.
+func (cm *configManagementComponent) detectNetworkType(config *cfgBucket) string {
+	if cm.configuredNetworkType != "" && cm.configuredNetworkType != "auto" {
+		return cm.configuredNetworkType
 	}
 
 	defaultRouteConfig := config.BuildRouteConfig(cm.useSSL, "default", true)
@@ -162,23 +318,72 @@ func (cm *configManagementComponent) buildFirstRouteConfig(config *cfgBucket) *r
 			}
 		}
 		if srcInDefaultConfig {
-			cm.networkType = "default"
-			return defaultRouteConfig
+			return "default"
 		}
 
 		// Next lets see if we have an external config, if so, default to that
 		externalRouteCfg := config.BuildRouteConfig(cm.useSSL, "external", true)
 		if externalRouteCfg.IsValid() {
-			cm.networkType = "external"
-			return externalRouteCfg
+			return "external"
 		}
 	}
 
 	// If all else fails, default to the implicit default config
-	cm.networkType = "default"
-	return defaultRouteConfig
+	return "default"
+}
+
+// RedetectNetworkType discards the detected network type and rebuilds the route config from the applied config,
+// detecting the network type again. This can be used after topology changes which may have changed which network the
+// agent should be using. Configs older than the applied config are still ignored afterwards.
+func (cm *configManagementComponent) RedetectNetworkType() {
+	cm.configLock.Lock()
+	cm.networkType = cm.configuredNetworkType
+	cm.seenConfig = false
+	lastConfig := cm.lastConfig
+	cm.configLock.Unlock()
+
+	if lastConfig != nil {
+		cm.applyConfig(lastConfig, true)
+	}
+}
+
+// Freeze stops received configs from being applied until Unfreeze is called, config polling carries on as normal.
+func (cm *configManagementComponent) Freeze() {
+	cm.configLock.Lock()
+	cm.frozen = true
+	cm.configLock.Unlock()
+}
+
+// Unfreeze allows received configs to be applied again, the most recently received config is applied immediately.
+func (cm *configManagementComponent) Unfreeze() {
+	cm.configLock.Lock()
+	wasFrozen := cm.frozen
+	cm.frozen = false
+	frozenConfig := cm.frozenConfig
+	cm.frozenConfig = nil
+	cm.configLock.Unlock()
+
+	if !wasFrozen {
+		return
+	}
+
+	if frozenConfig != nil {
+		cm.OnNewConfig(frozenConfig)
+	}
+
+	// A network type redetection whilst frozen has to be applied to the existing config if nothing newer arrived.
+	cm.configLock.Lock()
+	lastConfig := cm.lastConfig
+	redetected := !cm.seenConfig && lastConfig != nil
+	cm.configLock.Unlock()
+
+	if redetected {
+		cm.applyConfig(lastConfig, true)
+	}
 }
 
 func (cm *configManagementComponent) NetworkType() string {
+	cm.configLock.Lock()
+	defer cm.configLock.Unlock()
 	return cm.networkType
 }
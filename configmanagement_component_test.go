@@ -0,0 +1,127 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestConfigManagementRevEpoch() {
+	cm := newConfigManager(configManagerProperties{})
+
+	applies := func(revEpoch, revID int64) bool {
+		return cm.updateRouteConfig(&routeConfig{revEpoch: revEpoch, revID: revID, bktType: bktTypeNone})
+	}
+
+	suite.Assert().True(applies(1, 10))
+	suite.Assert().False(applies(1, 10))
+	suite.Assert().False(applies(1, 9))
+	suite.Assert().True(applies(1, 11))
+
+	// A rebuilt cluster restarts revision ids from a higher epoch.
+	suite.Assert().True(applies(2, 1))
+	suite.Assert().False(applies(1, 100))
+	suite.Assert().True(applies(2, 2))
+
+	cfg, err := parseConfig([]byte(`{"rev":5,"revEpoch":3}`), "127.0.0.1")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(int64(3), cfg.RevEpoch)
+	suite.Assert().Equal(int64(3), cfg.BuildRouteConfig(false, "default", false).revEpoch)
+}
+
+func (suite *UnitTestSuite) TestConfigManagementNetworkResolver() {
+	raw, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+	cfgBk, err := parseConfig(raw, "localhost")
+	suite.Require().Nil(err)
+
+	useExternal := false
+	mgr := &testAlternateAddressesRouteConfigMgr{}
+	cm := newConfigManager(configManagerProperties{
+		NetworkType:  "auto",
+		SrcMemdAddrs: []string{"172.17.0.2:11210"},
+		NetworkResolver: func(node NodeNetworkInfo) string {
+			if useExternal && node.Hostname == "172.17.0.3" {
+				suite.Assert().Equal("192.168.132.234", node.AlternateAddresses["external"])
+				return "external"
+			}
+			return ""
+		},
+	})
+	cm.AddConfigWatcher(mgr)
+	cm.OnNewConfig(cfgBk)
+
+	suite.Assert().Equal("default", cm.NetworkType())
+	suite.Require().True(mgr.cfgCalled)
+	suite.Assert().Equal([]string{"172.17.0.2:11210", "172.17.0.3:11210", "172.17.0.4:11210"}, mgr.cfg.kvServerList)
+
+	// Redetection rebuilds the route config from the last config, even though its revision has not changed.
+	useExternal = true
+	mgr.cfgCalled = false
+	cm.RedetectNetworkType()
+
+	suite.Assert().Equal("default", cm.NetworkType())
+	suite.Require().True(mgr.cfgCalled)
+	suite.Assert().Equal([]string{"172.17.0.2:11210", "192.168.132.234:32799", "172.17.0.4:11210"}, mgr.cfg.kvServerList)
+}
+
+func (suite *UnitTestSuite) TestConfigManagementRedetectKeepsAppliedConfig() {
+	raw, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+	cfgBk, err := parseConfig(raw, "localhost")
+	suite.Require().Nil(err)
+
+	mgr := &testAlternateAddressesRouteConfigMgr{}
+	cm := newConfigManager(configManagerProperties{})
+	cm.AddConfigWatcher(mgr)
+	cm.OnNewConfig(cfgBk)
+	suite.Require().True(mgr.cfgCalled)
+	rev := mgr.cfg.revID
+
+	// An older config is ignored, so it must not be what redetection rebuilds from.
+	olderBk, err := parseConfig(raw, "localhost")
+	suite.Require().Nil(err)
+	olderBk.Rev = rev - 1
+
+	mgr.cfgCalled = false
+	cm.OnNewConfig(olderBk)
+	suite.Assert().False(mgr.cfgCalled)
+
+	cm.RedetectNetworkType()
+	suite.Require().True(mgr.cfgCalled)
+	suite.Assert().Equal(rev, mgr.cfg.revID)
+
+	// Redetection doesn't reset the revision check.
+	mgr.cfgCalled = false
+	cm.OnNewConfig(olderBk)
+	suite.Assert().False(mgr.cfgCalled)
+}
+
+func (suite *UnitTestSuite) TestConfigManagementFreeze() {
+	raw, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+	cfgBk, err := parseConfig(raw, "localhost")
+	suite.Require().Nil(err)
+
+	mgr := &testAlternateAddressesRouteConfigMgr{}
+	cm := newConfigManager(configManagerProperties{})
+	cm.AddConfigWatcher(mgr)
+	cm.OnNewConfig(cfgBk)
+	suite.Require().True(mgr.cfgCalled)
+	rev := mgr.cfg.revID
+
+	cm.Freeze()
+	for i := int64(1); i <= 2; i++ {
+		newerBk, err := parseConfig(raw, "localhost")
+		suite.Require().Nil(err)
+		newerBk.Rev = rev + i
+
+		mgr.cfgCalled = false
+		cm.OnNewConfig(newerBk)
+		suite.Assert().False(mgr.cfgCalled)
+	}
+	suite.Assert().False(cm.LastConfigTime().IsZero())
+
+	// Only the most recently received config is applied.
+	cm.Unfreeze()
+	suite.Require().True(mgr.cfgCalled)
+	suite.Assert().Equal(rev+2, mgr.cfg.revID)
+
+	mgr.cfgCalled = false
+	cm.Unfreeze()
+	suite.Assert().False(mgr.cfgCalled)
+}
@@ -10,7 +10,15 @@ func (pi ConfigSnapshot) RevID() int64 {
 	return pi.state.revID
 }
 
+// RevEpoch returns the config revision epoch for this snapshot.
+func (pi ConfigSnapshot) RevEpoch() int64 {
+	return pi.state.revEpoch
+}
+
 // KeyToVbucket translates a particular key to its assigned vbucket.
+//
+// Deprecated: Use VbucketForKey, which returns ErrNoConfig rather than ErrUnsupportedOperation when no config has been
+// received.
 func (pi ConfigSnapshot) KeyToVbucket(key []byte) (uint16, error) {
 	if pi.state.vbMap == nil {
 		return 0, errUnsupportedOperation
@@ -19,6 +27,8 @@ func (pi ConfigSnapshot) KeyToVbucket(key []byte) (uint16, error) {
 }
 
 // KeyToServer translates a particular key to its assigned server index.
+//
+// Deprecated: Use ServerForKey, which returns an error rather than -1 when the vbucket is not assigned to a server.
 func (pi ConfigSnapshot) KeyToServer(key []byte, replicaIdx uint32) (int, error) {
 	if pi.state.vbMap != nil {
 		serverIdx, err := pi.state.vbMap.NodeByKey(key, replicaIdx)
@@ -42,6 +52,9 @@ func (pi ConfigSnapshot) KeyToServer(key []byte, replicaIdx uint32) (int, error)
 }
 
 // VbucketToServer returns the server index for a particular vbucket.
+//
+// Deprecated: Use ServerForVbucket, which returns an error rather than -1 when the vbucket is not assigned to a
+// server.
 func (pi ConfigSnapshot) VbucketToServer(vbID uint16, replicaIdx uint32) (int, error) {
 	if pi.state.vbMap == nil {
 		return 0, errUnsupportedOperation
@@ -93,3 +106,70 @@ func (pi ConfigSnapshot) NumServers() (int, error) {
 func (pi ConfigSnapshot) BucketUUID() string {
 	return pi.state.uuid
 }
+
+// hasConfig returns whether the snapshot was taken from a config received from the cluster, rather than from the
+// placeholder used until the first config is received.
+func (pi ConfigSnapshot) hasConfig() bool {
+	return pi.state.revID >= 0
+}
+
+// VbucketForKey translates a particular key to its assigned vbucket. ErrNoConfig is returned if no config has been
+// received yet, and ErrUnsupportedOperation if the bucket does not use vbuckets.
+// Volatile: This API is subject to change at any time.
+func (pi ConfigSnapshot) VbucketForKey(key []byte) (uint16, error) {
+	if !pi.hasConfig() {
+		return 0, errNoConfig
+	}
+	if pi.state.vbMap == nil {
+		return 0, errUnsupportedOperation
+	}
+
+	return pi.state.vbMap.VbucketByKey(key), nil
+}
+
+// ServerForVbucket returns the index of the server holding a copy of a vbucket, replicaIdx 0 is the active copy.
+// ErrNoConfig is returned if no config has been received yet, ErrUnsupportedOperation if the bucket does not use
+// vbuckets, ErrInvalidVBucket or ErrInvalidReplica if either index is out of range, and ErrVbucketNotAssigned if the
+// copy is not currently assigned to a server.
+// Volatile: This API is subject to change at any time.
+func (pi ConfigSnapshot) ServerForVbucket(vbID uint16, replicaIdx uint32) (int, error) {
+	if !pi.hasConfig() {
+		return 0, errNoConfig
+	}
+	if pi.state.vbMap == nil {
+		return 0, errUnsupportedOperation
+	}
+
+	serverIdx, err := pi.state.vbMap.NodeByVbucket(vbID, replicaIdx)
+	if err != nil {
+		return 0, err
+	}
+	if serverIdx < 0 {
+		return 0, errVbucketNotAssigned
+	}
+
+	return serverIdx, nil
+}
+
+// ServerForKey returns the index of the server to which a particular key is routed, replicaIdx 0 is the active copy
+// and must be 0 for memcached buckets. The errors returned are the same as for ServerForVbucket.
+// Volatile: This API is subject to change at any time.
+func (pi ConfigSnapshot) ServerForKey(key []byte, replicaIdx uint32) (int, error) {
+	if !pi.hasConfig() {
+		return 0, errNoConfig
+	}
+
+	if pi.state.vbMap != nil {
+		return pi.ServerForVbucket(pi.state.vbMap.VbucketByKey(key), replicaIdx)
+	}
+
+	if pi.state.ketamaMap != nil {
+		if replicaIdx > 0 {
+			return 0, errInvalidReplica
+		}
+
+		return pi.state.ketamaMap.NodeByKey(key)
+	}
+
+	return 0, errUnsupportedOperation
+}
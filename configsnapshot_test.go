@@ -0,0 +1,55 @@
+package gocbcore
+
+import "errors"
+
+func (suite *UnitTestSuite) TestConfigSnapshotRoutingErrors() {
+	placeholder := &ConfigSnapshot{state: newKVMuxState(&routeConfig{revID: -1}, nil, nil)}
+
+	_, err := placeholder.VbucketForKey([]byte("key"))
+	suite.Assert().True(errors.Is(err, ErrNoConfig))
+	_, err = placeholder.ServerForVbucket(0, 0)
+	suite.Assert().True(errors.Is(err, ErrNoConfig))
+	_, err = placeholder.ServerForKey([]byte("key"), 0)
+	suite.Assert().True(errors.Is(err, ErrNoConfig))
+
+	snapshot := &ConfigSnapshot{state: newKVMuxState(&routeConfig{
+		revID:        1,
+		bktType:      bktTypeCouchbase,
+		kvServerList: []string{"a:11210", "b:11210"},
+		vbMap:        newVbucketMap([][]int{{1, -1}, {0, 1}}, 1),
+	}, nil, nil)}
+
+	serverIdx, err := snapshot.ServerForVbucket(0, 0)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(1, serverIdx)
+
+	_, err = snapshot.ServerForVbucket(0, 1)
+	suite.Assert().True(errors.Is(err, ErrVbucketNotAssigned))
+	_, err = snapshot.ServerForVbucket(2, 0)
+	suite.Assert().True(errors.Is(err, ErrInvalidVBucket))
+	_, err = snapshot.ServerForVbucket(1, 2)
+	suite.Assert().True(errors.Is(err, ErrInvalidReplica))
+
+	vbID, err := snapshot.VbucketForKey([]byte("key"))
+	suite.Require().Nil(err)
+	serverIdx, err = snapshot.ServerForKey([]byte("key"), 0)
+	suite.Require().Nil(err)
+	expectedIdx, err := snapshot.ServerForVbucket(vbID, 0)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(expectedIdx, serverIdx)
+
+	memcached := &ConfigSnapshot{state: newKVMuxState(&routeConfig{
+		revID:        1,
+		bktType:      bktTypeMemcached,
+		kvServerList: []string{"a:11210"},
+		ketamaMap:    newKetamaContinuum([]string{"a:11210"}),
+	}, nil, nil)}
+
+	_, err = memcached.VbucketForKey([]byte("key"))
+	suite.Assert().True(errors.Is(err, ErrUnsupportedOperation))
+	serverIdx, err = memcached.ServerForKey([]byte("key"), 0)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(0, serverIdx)
+	_, err = memcached.ServerForKey([]byte("key"), 1)
+	suite.Assert().True(errors.Is(err, ErrInvalidReplica))
+}
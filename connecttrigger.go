@@ -0,0 +1,46 @@
+package gocbcore
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// connectTrigger is used to defer establishing connections until they are first required when an agent is
+// created in lazy connect mode. A nil connectTrigger is valid and does nothing.
+type connectTrigger struct {
+	once      sync.Once
+	connectFn func()
+	connected uint32
+}
+
+// Trigger runs the connect function if it has not already been run or disabled. Concurrent callers block until
+// the connect function has returned.
+func (t *connectTrigger) Trigger() {
+	if t == nil {
+		return
+	}
+
+	t.once.Do(func() {
+		atomic.StoreUint32(&t.connected, 1)
+		t.connectFn()
+	})
+}
+
+// Connected returns whether the connect function has been run.
+func (t *connectTrigger) Connected() bool {
+	if t == nil {
+		return true
+	}
+
+	return atomic.LoadUint32(&t.connected) == 1
+}
+
+// Disable prevents the connect function from ever being run, returning whether it had already been run.
+func (t *connectTrigger) Disable() bool {
+	if t == nil {
+		return true
+	}
+
+	t.once.Do(func() {})
+	return atomic.LoadUint32(&t.connected) == 1
+}
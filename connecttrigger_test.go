@@ -0,0 +1,34 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestConnectTriggerRunsOnce() {
+	calls := 0
+	trigger := &connectTrigger{
+		connectFn: func() {
+			calls++
+		},
+	}
+
+	trigger.Trigger()
+	trigger.Trigger()
+	suite.Assert().Equal(1, calls)
+	suite.Assert().True(trigger.Disable())
+}
+
+func (suite *UnitTestSuite) TestConnectTriggerDisable() {
+	calls := 0
+	trigger := &connectTrigger{
+		connectFn: func() {
+			calls++
+		},
+	}
+
+	suite.Assert().False(trigger.Disable())
+	trigger.Trigger()
+	suite.Assert().Equal(0, calls)
+}
+
+func (suite *UnitTestSuite) TestConnectTriggerNil() {
+	var trigger *connectTrigger
+	trigger.Trigger()
+	suite.Assert().True(trigger.Disable())
+}
@@ -0,0 +1,208 @@
+package gocbcore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/couchbase/gocbcore/v9/connstr"
+)
+
+// The maximum edit distance between an unknown option and a known one for the known option to be suggested.
+const connStrSuggestionMaxDistance = 2
+
+var agentConnStrOptions = []string{
+	"bootstrap_on",
+	"ca_cert_path",
+	"tls_pinned_public_key",
+	"tls_skip_verify",
+	"network",
+	"kv_connect_timeout",
+	"kv_timeout",
+	"kv_timer_resolution",
+	"config_poll_interval",
+	"config_poll_timeout",
+	"compression",
+	"compression_min_size",
+	"compression_min_ratio",
+	"enable_mutation_tokens",
+	"enable_server_durations",
+	"enable_resource_units",
+	"max_idle_http_connections",
+	"max_perhost_idle_http_connections",
+	"idle_http_connection_timeout",
+	"http_disable_compression",
+	"orphaned_response_logging",
+	"orphaned_response_logging_interval",
+	"orphaned_response_logging_sample_size",
+	"http_redial_period",
+	"http_retry_delay",
+	"http_config_poll_timeout",
+	"kv_pool_size",
+	"kv_max_in_flight",
+	"max_queue_size",
+	"kv_backpressure",
+	"kv_backpressure_max_wait",
+	"server_wait_timeout",
+	"max_connection_age",
+	"kv_keepalive_interval",
+	"kv_keepalive_timeout",
+	"kv_latency_probe_interval",
+	"unordered_execution_enabled",
+	"collection_manifest_poll_interval",
+	"config_profile",
+}
+
+var dcpAgentConnStrOptions = []string{
+	"bootstrap_on",
+	"ca_cert_path",
+	"tls_pinned_public_key",
+	"tls_skip_verify",
+	"network",
+	"kv_connect_timeout",
+	"config_poll_interval",
+	"config_poll_timeout",
+	"compression",
+	"compression_min_size",
+	"compression_min_ratio",
+	"dcp_priority",
+	"enable_dcp_expiry",
+	"dcp_buffer_size",
+	"kv_pool_size",
+	"max_queue_size",
+	"max_idle_http_connections",
+	"max_perhost_idle_http_connections",
+	"idle_http_connection_timeout",
+	"http_redial_period",
+	"http_retry_delay",
+	"http_config_poll_timeout",
+	"high_seqno_poll_interval",
+	"dcp_decompression_workers",
+	"dcp_backfill_order",
+	"dcp_vbucket_open_order",
+	"dcp_noop_interval",
+	"dcp_missed_noop_limit",
+}
+
+// FromConnStrOptions specifies how a connection string should be applied to a config.
+type FromConnStrOptions struct {
+	// Strict causes an error to be returned if the connection string contains any options
+	// which are not recognised, rather than those options being ignored.
+	Strict bool
+}
+
+// ConnStrWarning describes an option in a connection string which was not recognised.
+type ConnStrWarning struct {
+	Option string
+	// Suggestion is the name of a recognised option which is similar to Option, or empty
+	// if there is no such option.
+	Suggestion string
+}
+
+func (w ConnStrWarning) String() string {
+	if w.Suggestion == "" {
+		return fmt.Sprintf("unknown connection string option %s", w.Option)
+	}
+
+	return fmt.Sprintf("unknown connection string option %s (did you mean %s?)", w.Option, w.Suggestion)
+}
+
+// checkConnStrOptions returns a warning for each option in the connection string which is not one of known, in strict
+// mode those warnings are returned as an error instead.
+func checkConnStrOptions(connStr string, known []string, strict bool) ([]ConnStrWarning, error) {
+	spec, err := connstr.Parse(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	knownSet := make(map[string]struct{}, len(known))
+	for _, name := range known {
+		knownSet[name] = struct{}{}
+	}
+
+	var warnings []ConnStrWarning
+	for name := range spec.Options {
+		if _, ok := knownSet[name]; ok {
+			continue
+		}
+
+		warnings = append(warnings, ConnStrWarning{
+			Option:     name,
+			Suggestion: suggestConnStrOption(name, known),
+		})
+	}
+
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].Option < warnings[j].Option
+	})
+
+	if strict && len(warnings) > 0 {
+		msgs := make([]string, len(warnings))
+		for i, warning := range warnings {
+			msgs[i] = warning.String()
+		}
+
+		return nil, wrapError(errInvalidArgument, strings.Join(msgs, "; "))
+	}
+
+	for _, warning := range warnings {
+		logWarnf("%s", warning)
+	}
+
+	return warnings, nil
+}
+
+func suggestConnStrOption(name string, known []string) string {
+	lowerName := strings.ToLower(name)
+
+	bestName := ""
+	bestDistance := connStrSuggestionMaxDistance + 1
+	for _, candidate := range known {
+		distance := editDistance(lowerName, candidate)
+		if distance < bestDistance {
+			bestName = candidate
+			bestDistance = distance
+		}
+	}
+
+	return bestName
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// lastOptionValue returns the last value given for the named option, later values take precedence over earlier ones.
+func lastOptionValue(options map[string][]string, name string) (string, bool) {
+	optValue := options[name]
+	if len(optValue) == 0 {
+		return "", false
+	}
+	return optValue[len(optValue)-1], true
+}
@@ -50,12 +50,25 @@ const (
 	DcpAgentPriorityHigh = DcpAgentPriority(2)
 )
 
+// OperationPriority specifies the priority with which an operation is queued for sending to a kv node.
+type OperationPriority uint8
+
+const (
+	// OperationPriorityNormal queues the operation in order with all other normal priority operations.
+	OperationPriorityNormal = OperationPriority(0)
+
+	// OperationPriorityHigh queues the operation ahead of normal priority operations, normal priority operations
+	// are still periodically sent to prevent them being starved.
+	OperationPriorityHigh = OperationPriority(1)
+)
+
 type BucketCapability uint32
 
 const (
 	BucketCapabilityDurableWrites        BucketCapability = 0x00
 	BucketCapabilityCreateAsDeleted      BucketCapability = 0x01
 	BucketCapabilityReplaceBodyWithXattr BucketCapability = 0x02
+	BucketCapabilityReviveDocument       BucketCapability = 0x03
 )
 
 type BucketCapabilityStatus uint32
@@ -87,6 +100,20 @@ const (
 	DCPBackfillOrderSequential
 )
 
+// DCPVbucketOpenOrder represents the order in which DCPAgent.OrderVbuckets arranges vBuckets for their streams to be
+// opened in.
+type DCPVbucketOpenOrder uint8
+
+const (
+	// DCPVbucketOpenOrderSequential means that vBuckets are opened in ascending order of their IDs. This is the
+	// default behaviour.
+	DCPVbucketOpenOrderSequential DCPVbucketOpenOrder = iota + 1
+
+	// DCPVbucketOpenOrderNodeRoundRobin means that vBuckets are interleaved by the node holding their active copy, so
+	// that every node has streams opened against it from the start rather than one node at a time.
+	DCPVbucketOpenOrderNodeRoundRobin
+)
+
 const (
 	spanNameDispatchToServer    = "dispatch_to_server"
 	spanAttribDBSystemKey       = "db.system"
@@ -100,4 +127,6 @@ const (
 	spanAttribNetPeerNameKey    = "net.peer.name"
 	spanAttribNetPeerPortKey    = "net.peer.port"
 	spanAttribServerDurationKey = "db.couchbase.server_duration"
+
+	spanAttribCompositeOperationIDKey = "db.couchbase.composite_operation_id"
 )
@@ -1,6 +1,7 @@
 package gocbcore
 
 import (
+	"io"
 	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
@@ -15,6 +16,38 @@ type GetOptions struct {
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
 
+	// Priority specifies how the operation is queued relative to other operations on the same connection.
+	Priority OperationPriority
+
+	// HedgeDelay, if set, enables hedged reads. If the active has not responded after this delay then the get is
+	// also sent to the first replica and whichever succeeds first is returned, see GetResult.IsReplica. Hedged gets
+	// are not coalesced.
+	// Volatile: This API is subject to change at any time.
+	HedgeDelay time.Duration
+
+	// Internal: This should never be used and is not supported.
+	User []byte
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// GetStreamingOptions encapsulates the parameters for a GetStreaming operation.
+// Volatile: This API is subject to change at any time.
+type GetStreamingOptions struct {
+	Key            []byte
+	CollectionName string
+	ScopeName      string
+	CollectionID   uint32
+	RetryStrategy  RetryStrategy
+	Deadline       time.Time
+	Priority       OperationPriority
+
+	// Writer receives the value of the document as it is read from the network. Writes happen on the goroutine
+	// reading from the connection so should not block for long. If the operation fails then a partial value may
+	// have been written, once any of the value has been written the operation is never retried.
+	Writer io.Writer
+
 	// Internal: This should never be used and is not supported.
 	User []byte
 
@@ -31,6 +64,7 @@ type GetAndTouchOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -48,6 +82,7 @@ type GetAndLockOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -81,6 +116,7 @@ type GetOneReplicaOptions struct {
 	RetryStrategy  RetryStrategy
 	ReplicaIdx     int
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -98,6 +134,7 @@ type TouchOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -115,6 +152,7 @@ type UnlockOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -134,6 +172,7 @@ type DeleteOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -156,6 +195,7 @@ type AddOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -178,6 +218,7 @@ type storeOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -200,6 +241,7 @@ type SetOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -223,6 +265,7 @@ type ReplaceOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -243,6 +286,7 @@ type AdjoinOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -265,6 +309,7 @@ type CounterOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -277,6 +322,7 @@ type CounterOptions struct {
 type GetRandomOptions struct {
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
+	Priority      OperationPriority
 
 	CollectionName string
 	ScopeName      string
@@ -297,6 +343,7 @@ type GetMetaOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -305,6 +352,20 @@ type GetMetaOptions struct {
 	TraceContext RequestSpanContext
 }
 
+// ExistsMultiOptions encapsulates the parameters for a ExistsMulti operation.
+type ExistsMultiOptions struct {
+	Keys           [][]byte
+	CollectionName string
+	ScopeName      string
+	CollectionID   uint32
+	RetryStrategy  RetryStrategy
+	Deadline       time.Time
+	Priority       OperationPriority
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
 // SetMetaOptions encapsulates the parameters for a SetMetaEx operation.
 type SetMetaOptions struct {
 	Key            []byte
@@ -321,6 +382,7 @@ type SetMetaOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -345,6 +407,7 @@ type DeleteMetaOptions struct {
 	CollectionID   uint32
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
+	Priority       OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -1,11 +1,39 @@
 package gocbcore
 
+import "time"
+
 // GetResult encapsulates the result of a GetEx operation.
 type GetResult struct {
 	Value    []byte
 	Flags    uint32
 	Datatype uint8
 	Cas      Cas
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
+
+	// IsReplica indicates that the result was returned by a replica in response to a hedged read, see
+	// GetOptions.HedgeDelay.
+	IsReplica bool
+}
+
+// GetStreamingResult encapsulates the result of a GetStreaming operation.
+// Volatile: This API is subject to change at any time.
+type GetStreamingResult struct {
+	// BytesWritten is the length of the value written to GetStreamingOptions.Writer.
+	BytesWritten int64
+	Flags        uint32
+	Datatype     uint8
+	Cas          Cas
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // GetAndTouchResult encapsulates the result of a GetAndTouchEx operation.
@@ -14,6 +42,12 @@ type GetAndTouchResult struct {
 	Flags    uint32
 	Datatype uint8
 	Cas      Cas
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // GetAndLockResult encapsulates the result of a GetAndLockEx operation.
@@ -22,6 +56,12 @@ type GetAndLockResult struct {
 	Flags    uint32
 	Datatype uint8
 	Cas      Cas
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // GetReplicaResult encapsulates the result of a GetReplica operation.
@@ -30,36 +70,72 @@ type GetReplicaResult struct {
 	Flags    uint32
 	Datatype uint8
 	Cas      Cas
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // TouchResult encapsulates the result of a TouchEx operation.
 type TouchResult struct {
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // UnlockResult encapsulates the result of a UnlockEx operation.
 type UnlockResult struct {
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // DeleteResult encapsulates the result of a DeleteEx operation.
 type DeleteResult struct {
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // StoreResult encapsulates the result of a AddEx, SetEx or ReplaceEx operation.
 type StoreResult struct {
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // AdjoinResult encapsulates the result of a AppendEx or PrependEx operation.
 type AdjoinResult struct {
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // CounterResult encapsulates the result of a IncrementEx or DecrementEx operation.
@@ -67,6 +143,12 @@ type CounterResult struct {
 	Value         uint64
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // GetRandomResult encapsulates the result of a GetRandomEx operation.
@@ -76,6 +158,12 @@ type GetRandomResult struct {
 	Flags    uint32
 	Datatype uint8
 	Cas      Cas
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // GetMetaResult encapsulates the result of a GetMetaEx operation.
@@ -87,16 +175,55 @@ type GetMetaResult struct {
 	SeqNo    SeqNo
 	Datatype uint8
 	Deleted  uint32
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
+}
+
+// ExistsResult encapsulates the result of checking the existence of a single document within an ExistsMulti operation.
+type ExistsResult struct {
+	Key []byte
+
+	// Exists is whether the document exists and is not deleted.
+	Exists bool
+
+	// Deleted is whether a tombstone remains for the document, in which case Cas is that of the deletion.
+	Deleted bool
+	Cas     Cas
+
+	// Err is set if the existence of the document could not be determined.
+	Err error
+}
+
+// ExistsMultiResult encapsulates the result of a ExistsMulti operation.
+type ExistsMultiResult struct {
+	// Results contains the result for each key, in the same order as the keys in the options.
+	Results []ExistsResult
 }
 
 // SetMetaResult encapsulates the result of a SetMetaEx operation.
 type SetMetaResult struct {
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
 
 // DeleteMetaResult encapsulates the result of a DeleteMetaEx operation.
 type DeleteMetaResult struct {
 	Cas           Cas
 	MutationToken MutationToken
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
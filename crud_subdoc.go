@@ -17,6 +17,9 @@ type LookupInOptions struct {
 	RetryStrategy  RetryStrategy
 	Deadline       time.Time
 
+	// Priority specifies how the operation is queued relative to other operations on the same connection.
+	Priority OperationPriority
+
 	// Internal: This should never be used and is not supported.
 	User []byte
 
@@ -38,6 +41,7 @@ type MutateInOptions struct {
 	DurabilityLevelTimeout time.Duration
 	CollectionID           uint32
 	Deadline               time.Time
+	Priority               OperationPriority
 
 	// Internal: This should never be used and is not supported.
 	User []byte
@@ -57,6 +61,12 @@ type LookupInResult struct {
 	Cas Cas
 	Ops []SubDocResult
 
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
+
 	// Internal: This should never be used and is not supported.
 	Internal struct {
 		IsDeleted bool
@@ -68,4 +78,10 @@ type MutateInResult struct {
 	Cas           Cas
 	MutationToken MutationToken
 	Ops           []SubDocResult
+
+	// ServerDuration is the time the server reported spending on the operation, if UseDurations is enabled.
+	ServerDuration time.Duration
+
+	// ResourceUnits are the resources the server reported the operation consuming, if UseResourceUnits is enabled.
+	ResourceUnits *ResourceUnitResult
 }
@@ -2,7 +2,6 @@ package gocbcore
 
 import (
 	"encoding/binary"
-	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
 )
@@ -13,20 +12,58 @@ type crudComponent struct {
 	tracer               *tracerComponent
 	errMapManager        *errMapComponent
 	featureVerifier      bucketCapabilityVerifier
+	getCoalescer         *getCoalescer
+	timeouts             *kvTimeoutComponent
+	logCtx               logContext
 }
 
 func newCRUDComponent(cidMgr *collectionsComponent, defaultRetryStrategy RetryStrategy, tracerCmpt *tracerComponent,
-	errMapManager *errMapComponent, featureVerifier bucketCapabilityVerifier) *crudComponent {
-	return &crudComponent{
+	errMapManager *errMapComponent, featureVerifier bucketCapabilityVerifier, timeouts *kvTimeoutComponent,
+	coalesceGets bool, logCtx logContext) *crudComponent {
+	crud := &crudComponent{
 		cidMgr:               cidMgr,
 		defaultRetryStrategy: defaultRetryStrategy,
 		tracer:               tracerCmpt,
 		errMapManager:        errMapManager,
 		featureVerifier:      featureVerifier,
+		timeouts:             timeouts,
+		logCtx:               logCtx,
 	}
+
+	if coalesceGets {
+		crud.getCoalescer = newGetCoalescer(crud.get, timeouts)
+	}
+
+	return crud
+}
+
+// verifyDurableWrites returns an error if durability requirements are known not to be supported, we still send the
+// request if support is not yet known.
+func (crud *crudComponent) verifyDurableWrites() error {
+	if err := crud.featureVerifier.CheckClusterFeature(ClusterFeatureDurableWrites, nil); err != nil {
+		return err
+	}
+
+	if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, BucketCapabilityStatusUnsupported) {
+		return errFeatureNotAvailable
+	}
+
+	return nil
 }
 
 func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, error) {
+	if opts.HedgeDelay > 0 {
+		return crud.hedgedGet(opts, cb)
+	}
+
+	if crud.getCoalescer != nil {
+		return crud.getCoalescer.Get(opts, cb)
+	}
+
+	return crud.get(opts, cb)
+}
+
+func (crud *crudComponent) get(opts GetOptions, cb GetCallback) (PendingOp, error) {
 	tracer := crud.tracer.CreateOpTrace("Get", opts.TraceContext)
 
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
@@ -47,6 +84,8 @@ func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, erro
 		res.Flags = binary.BigEndian.Uint32(resp.Extras[0:])
 		res.Cas = Cas(resp.Cas)
 		res.Datatype = resp.Datatype
+		res.ServerDuration = resp.ServerDuration()
+		res.ResourceUnits = resp.ResourceUnits()
 
 		tracer.Finish()
 		cb(&res, nil)
@@ -80,6 +119,8 @@ func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, erro
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Priority:         opts.Priority,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -87,24 +128,7 @@ func (crud *crudComponent) Get(opts GetOptions, cb GetCallback) (PendingOp, erro
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errUnambiguousTimeout,
-				OperationID:        "Get",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "Get", errUnambiguousTimeout)
 
 	return op, nil
 }
@@ -129,10 +153,12 @@ func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCa
 
 		tracer.Finish()
 		cb(&GetAndTouchResult{
-			Value:    resp.Value,
-			Flags:    flags,
-			Cas:      Cas(resp.Cas),
-			Datatype: resp.Datatype,
+			Value:          resp.Value,
+			Flags:          flags,
+			Cas:            Cas(resp.Cas),
+			Datatype:       resp.Datatype,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -167,6 +193,8 @@ func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCa
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -174,24 +202,7 @@ func (crud *crudComponent) GetAndTouch(opts GetAndTouchOptions, cb GetAndTouchCa
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "GetAndTouch",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "GetAndTouch", errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -216,10 +227,12 @@ func (crud *crudComponent) GetAndLock(opts GetAndLockOptions, cb GetAndLockCallb
 
 		tracer.Finish()
 		cb(&GetAndLockResult{
-			Value:    resp.Value,
-			Flags:    flags,
-			Cas:      Cas(resp.Cas),
-			Datatype: resp.Datatype,
+			Value:          resp.Value,
+			Flags:          flags,
+			Cas:            Cas(resp.Cas),
+			Datatype:       resp.Datatype,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -254,6 +267,8 @@ func (crud *crudComponent) GetAndLock(opts GetAndLockOptions, cb GetAndLockCallb
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -261,24 +276,7 @@ func (crud *crudComponent) GetAndLock(opts GetAndLockOptions, cb GetAndLockCallb
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "GetAndLock",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "GetAndLock", errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -308,10 +306,12 @@ func (crud *crudComponent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplic
 
 		tracer.Finish()
 		cb(&GetReplicaResult{
-			Value:    resp.Value,
-			Flags:    flags,
-			Cas:      Cas(resp.Cas),
-			Datatype: resp.Datatype,
+			Value:          resp.Value,
+			Flags:          flags,
+			Cas:            Cas(resp.Cas),
+			Datatype:       resp.Datatype,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -344,6 +344,8 @@ func (crud *crudComponent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplic
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -351,24 +353,7 @@ func (crud *crudComponent) GetOneReplica(opts GetOneReplicaOptions, cb GetReplic
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errUnambiguousTimeout,
-				OperationID:        "GetOneReplica",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "GetOneReplica", errUnambiguousTimeout)
 
 	return op, nil
 }
@@ -392,8 +377,10 @@ func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp
 
 		tracer.Finish()
 		cb(&TouchResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -428,6 +415,8 @@ func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -435,24 +424,7 @@ func (crud *crudComponent) Touch(opts TouchOptions, cb TouchCallback) (PendingOp
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "Touch",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "Touch", errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -476,8 +448,10 @@ func (crud *crudComponent) Unlock(opts UnlockOptions, cb UnlockCallback) (Pendin
 
 		tracer.Finish()
 		cb(&UnlockResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -509,6 +483,8 @@ func (crud *crudComponent) Unlock(opts UnlockOptions, cb UnlockCallback) (Pendin
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -516,24 +492,7 @@ func (crud *crudComponent) Unlock(opts UnlockOptions, cb UnlockCallback) (Pendin
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "Unlock",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "Unlock", errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -557,16 +516,18 @@ func (crud *crudComponent) Delete(opts DeleteOptions, cb DeleteCallback) (Pendin
 
 		tracer.Finish()
 		cb(&DeleteResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
 	var duraLevelFrame *memd.DurabilityLevelFrame
 	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
 	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, BucketCapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
+		if err := crud.verifyDurableWrites(); err != nil {
+			return nil, err
 		}
 		duraLevelFrame = &memd.DurabilityLevelFrame{
 			DurabilityLevel: opts.DurabilityLevel,
@@ -606,6 +567,8 @@ func (crud *crudComponent) Delete(opts DeleteOptions, cb DeleteCallback) (Pendin
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -613,24 +576,7 @@ func (crud *crudComponent) Delete(opts DeleteOptions, cb DeleteCallback) (Pendin
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "Delete",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "Delete", errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -654,16 +600,18 @@ func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeO
 
 		tracer.Finish()
 		cb(&StoreResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
 	var duraLevelFrame *memd.DurabilityLevelFrame
 	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
 	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, BucketCapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
+		if err := crud.verifyDurableWrites(); err != nil {
+			return nil, err
 		}
 		duraLevelFrame = &memd.DurabilityLevelFrame{
 			DurabilityLevel: opts.DurabilityLevel,
@@ -706,6 +654,8 @@ func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeO
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -713,24 +663,7 @@ func (crud *crudComponent) store(opName string, opcode memd.CmdCode, opts storeO
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        opName,
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, opName, errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -752,6 +685,7 @@ func (crud *crudComponent) Set(opts SetOptions, cb StoreCallback) (PendingOp, er
 		CollectionID:           opts.CollectionID,
 		Deadline:               opts.Deadline,
 		User:                   opts.User,
+		Priority:               opts.Priority,
 	}, cb)
 }
 
@@ -772,6 +706,7 @@ func (crud *crudComponent) Add(opts AddOptions, cb StoreCallback) (PendingOp, er
 		CollectionID:           opts.CollectionID,
 		Deadline:               opts.Deadline,
 		User:                   opts.User,
+		Priority:               opts.Priority,
 	}, cb)
 }
 
@@ -798,16 +733,18 @@ func (crud *crudComponent) adjoin(opName string, opcode memd.CmdCode, opts Adjoi
 
 		tracer.Finish()
 		cb(&AdjoinResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
 	var duraLevelFrame *memd.DurabilityLevelFrame
 	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
 	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, BucketCapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
+		if err := crud.verifyDurableWrites(); err != nil {
+			return nil, err
 		}
 		duraLevelFrame = &memd.DurabilityLevelFrame{
 			DurabilityLevel: opts.DurabilityLevel,
@@ -847,6 +784,8 @@ func (crud *crudComponent) adjoin(opName string, opcode memd.CmdCode, opts Adjoi
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -854,24 +793,7 @@ func (crud *crudComponent) adjoin(opName string, opcode memd.CmdCode, opts Adjoi
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        opName,
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, opName, errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -910,9 +832,11 @@ func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts Coun
 
 		tracer.Finish()
 		cb(&CounterResult{
-			Value:         intVal,
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Value:          intVal,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -924,8 +848,8 @@ func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts Coun
 	var duraLevelFrame *memd.DurabilityLevelFrame
 	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
 	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, BucketCapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
+		if err := crud.verifyDurableWrites(); err != nil {
+			return nil, err
 		}
 		duraLevelFrame = &memd.DurabilityLevelFrame{
 			DurabilityLevel: opts.DurabilityLevel,
@@ -975,6 +899,8 @@ func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts Coun
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -982,24 +908,7 @@ func (crud *crudComponent) counter(opName string, opcode memd.CmdCode, opts Coun
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        opName,
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, opName, errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -1032,11 +941,13 @@ func (crud *crudComponent) GetRandom(opts GetRandomOptions, cb GetRandomCallback
 
 		tracer.Finish()
 		cb(&GetRandomResult{
-			Key:      resp.Key,
-			Value:    resp.Value,
-			Flags:    flags,
-			Cas:      Cas(resp.Cas),
-			Datatype: resp.Datatype,
+			Key:            resp.Key,
+			Value:          resp.Value,
+			Flags:          flags,
+			Cas:            Cas(resp.Cas),
+			Datatype:       resp.Datatype,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -1068,6 +979,8 @@ func (crud *crudComponent) GetRandom(opts GetRandomOptions, cb GetRandomCallback
 		RetryStrategy:    opts.RetryStrategy,
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -1075,24 +988,7 @@ func (crud *crudComponent) GetRandom(opts GetRandomOptions, cb GetRandomCallback
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errUnambiguousTimeout,
-				OperationID:        "GetRandom",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "GetRandom", errUnambiguousTimeout)
 
 	return op, nil
 }
@@ -1121,13 +1017,15 @@ func (crud *crudComponent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (Pen
 
 		tracer.Finish()
 		cb(&GetMetaResult{
-			Value:    resp.Value,
-			Flags:    flags,
-			Cas:      Cas(resp.Cas),
-			Expiry:   expTime,
-			SeqNo:    seqNo,
-			Datatype: dataType,
-			Deleted:  deleted,
+			Value:          resp.Value,
+			Flags:          flags,
+			Cas:            Cas(resp.Cas),
+			Expiry:         expTime,
+			SeqNo:          seqNo,
+			Datatype:       dataType,
+			Deleted:        deleted,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -1162,6 +1060,8 @@ func (crud *crudComponent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (Pen
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -1169,24 +1069,7 @@ func (crud *crudComponent) GetMeta(opts GetMetaOptions, cb GetMetaCallback) (Pen
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errUnambiguousTimeout,
-				OperationID:        "GetMeta",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "GetMeta", errUnambiguousTimeout)
 
 	return op, nil
 }
@@ -1210,8 +1093,10 @@ func (crud *crudComponent) SetMeta(opts SetMetaOptions, cb SetMetaCallback) (Pen
 
 		tracer.Finish()
 		cb(&SetMetaResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -1252,6 +1137,8 @@ func (crud *crudComponent) SetMeta(opts SetMetaOptions, cb SetMetaCallback) (Pen
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -1259,24 +1146,7 @@ func (crud *crudComponent) SetMeta(opts SetMetaOptions, cb SetMetaCallback) (Pen
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "SetMeta",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "SetMeta", errAmbiguousTimeout)
 
 	return op, nil
 }
@@ -1300,8 +1170,10 @@ func (crud *crudComponent) DeleteMeta(opts DeleteMetaOptions, cb DeleteMetaCallb
 
 		tracer.Finish()
 		cb(&DeleteMetaResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -1342,6 +1214,8 @@ func (crud *crudComponent) DeleteMeta(opts DeleteMetaOptions, cb DeleteMetaCallb
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -1349,24 +1223,7 @@ func (crud *crudComponent) DeleteMeta(opts DeleteMetaOptions, cb DeleteMetaCallb
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "DeleteMeta",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "DeleteMeta", errAmbiguousTimeout)
 
 	return op, nil
 }
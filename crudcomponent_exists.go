@@ -0,0 +1,72 @@
+package gocbcore
+
+import "errors"
+
+func (crud *crudComponent) ExistsMulti(opts ExistsMultiOptions, cb ExistsMultiCallback) (PendingOp, error) {
+	if len(opts.Keys) == 0 {
+		return nil, wrapError(errInvalidArgument, "at least one key must be specified")
+	}
+
+	tracer := crud.tracer.CreateOpTrace("ExistsMulti", opts.TraceContext)
+
+	results := make([]ExistsResult, len(opts.Keys))
+	op := &multiPendingOp{
+		isIdempotent: true,
+	}
+
+	opComplete := func() {
+		completed := op.IncrementCompletedOps()
+		if int(completed) == len(opts.Keys) {
+			tracer.Finish()
+			cb(&ExistsMultiResult{Results: results}, nil)
+		}
+	}
+
+	// Keys which fail to dispatch are only counted as complete once every key has been dispatched, otherwise the
+	// callback could be invoked before we've returned.
+	var syncErrs int
+	for i, key := range opts.Keys {
+		idx := i
+		results[idx].Key = key
+
+		subOp, err := crud.GetMeta(GetMetaOptions{
+			Key:            key,
+			CollectionName: opts.CollectionName,
+			ScopeName:      opts.ScopeName,
+			CollectionID:   opts.CollectionID,
+			RetryStrategy:  opts.RetryStrategy,
+			Deadline:       opts.Deadline,
+			Priority:       opts.Priority,
+			TraceContext:   tracer.RootContext(),
+		}, func(res *GetMetaResult, err error) {
+			switch {
+			case errors.Is(err, ErrDocumentNotFound):
+			case err != nil:
+				results[idx].Err = err
+			default:
+				results[idx].Cas = res.Cas
+				results[idx].Deleted = res.Deleted != 0
+				results[idx].Exists = res.Deleted == 0
+			}
+			opComplete()
+		})
+		if err != nil {
+			results[idx].Err = err
+			syncErrs++
+			continue
+		}
+
+		op.ops = append(op.ops, subOp)
+	}
+
+	if syncErrs == len(opts.Keys) {
+		tracer.Finish()
+		return nil, results[0].Err
+	}
+
+	for i := 0; i < syncErrs; i++ {
+		opComplete()
+	}
+
+	return op, nil
+}
@@ -0,0 +1,64 @@
+package gocbcore
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestExistsMulti() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	// The mock doesn't keep tombstones so report one for a specific key.
+	var defaultGetMeta memdmock.HandlerFunc
+	defaultGetMeta = server.Handle(memd.CmdGetMeta, func(req *memd.Packet) *memd.Packet {
+		if string(req.Key) != "tombstone" {
+			return defaultGetMeta(req)
+		}
+
+		extras := make([]byte, 21)
+		binary.BigEndian.PutUint32(extras, 1)
+		return &memd.Packet{Status: memd.StatusSuccess, Extras: extras, Cas: 99}
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	setRes := suite.mustSet(agent, "exists")
+
+	_, err := agent.ExistsMulti(ExistsMultiOptions{}, func(*ExistsMultiResult, error) {})
+	suite.Assert().NotNil(err)
+
+	resCh := make(chan *ExistsMultiResult, 1)
+	_, err = agent.ExistsMulti(ExistsMultiOptions{
+		Keys:     [][]byte{[]byte("missing"), []byte("exists"), []byte("tombstone")},
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *ExistsMultiResult, err error) {
+		suite.Assert().Nil(err)
+		resCh <- res
+	})
+	suite.Require().Nil(err)
+
+	res := <-resCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal([]ExistsResult{
+		{Key: []byte("missing")},
+		{Key: []byte("exists"), Exists: true, Cas: setRes.Cas},
+		{Key: []byte("tombstone"), Deleted: true, Cas: 99},
+	}, res.Results)
+
+	// When every key fails to dispatch the error is returned rather than the callback being invoked.
+	_, err = agent.ExistsMulti(ExistsMultiOptions{
+		Keys:           [][]byte{[]byte("missing"), []byte("exists")},
+		CollectionName: "collection",
+		ScopeName:      "scope",
+		Deadline:       time.Now().Add(5 * time.Second),
+	}, func(res *ExistsMultiResult, err error) {
+		suite.T().Errorf("Callback should not have been invoked")
+	})
+	suite.Assert().True(errors.Is(err, ErrCollectionsUnsupported), "Expected collections unsupported error but was %v", err)
+}
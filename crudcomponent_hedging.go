@@ -0,0 +1,146 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+)
+
+type hedgedGetFn func(opts GetOptions, cb GetCallback) (PendingOp, error)
+type hedgedGetReplicaFn func(opts GetOneReplicaOptions, cb GetReplicaCallback) (PendingOp, error)
+
+// hedgedGetOp issues a get to the active node and, if no response has arrived after a delay, a duplicate get to the
+// first replica. Whichever successful response arrives first is returned and the other request is cancelled. A
+// response from the active is always authoritative, so errors from the active are returned whilst errors from the
+// replica are ignored.
+type hedgedGetOp struct {
+	lock      sync.Mutex
+	completed bool
+	active    PendingOp
+	replica   PendingOp
+	timer     *time.Timer
+	cb        GetCallback
+	logCtx    logContext
+}
+
+func (crud *crudComponent) hedgedGet(opts GetOptions, cb GetCallback) (PendingOp, error) {
+	return startHedgedGet(opts, cb, crud.get, crud.GetOneReplica, crud.logCtx)
+}
+
+func startHedgedGet(opts GetOptions, cb GetCallback, getFn hedgedGetFn, replicaFn hedgedGetReplicaFn,
+	logCtx logContext) (PendingOp, error) {
+	op := &hedgedGetOp{
+		cb:     cb,
+		logCtx: logCtx,
+	}
+
+	activeOp, err := getFn(opts, func(res *GetResult, err error) {
+		op.complete(res, err)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	op.lock.Lock()
+	op.active = activeOp
+	if !op.completed {
+		op.timer = time.AfterFunc(opts.HedgeDelay, func() {
+			op.hedge(opts, replicaFn)
+		})
+	}
+	op.lock.Unlock()
+
+	return op, nil
+}
+
+func (op *hedgedGetOp) hedge(opts GetOptions, replicaFn hedgedGetReplicaFn) {
+	op.lock.Lock()
+	completed := op.completed
+	op.lock.Unlock()
+	if completed {
+		return
+	}
+
+	replicaOp, err := replicaFn(GetOneReplicaOptions{
+		Key:            opts.Key,
+		CollectionName: opts.CollectionName,
+		ScopeName:      opts.ScopeName,
+		CollectionID:   opts.CollectionID,
+		RetryStrategy:  opts.RetryStrategy,
+		ReplicaIdx:     1,
+		Deadline:       opts.Deadline,
+		User:           opts.User,
+		TraceContext:   opts.TraceContext,
+	}, func(res *GetReplicaResult, err error) {
+		if err != nil {
+			op.logCtx.logDebugf("Hedged replica get failed, waiting on active: %v", err)
+			return
+		}
+
+		op.complete(&GetResult{
+			Value:          res.Value,
+			Flags:          res.Flags,
+			Datatype:       res.Datatype,
+			Cas:            res.Cas,
+			ServerDuration: res.ServerDuration,
+			ResourceUnits:  res.ResourceUnits,
+			IsReplica:      true,
+		}, nil)
+	})
+	if err != nil {
+		// The bucket may not have any replicas, in which case we just wait on the active.
+		op.logCtx.logDebugf("Failed to dispatch hedged replica get: %v", err)
+		return
+	}
+
+	op.lock.Lock()
+	op.replica = replicaOp
+	completed = op.completed
+	op.lock.Unlock()
+
+	if completed {
+		replicaOp.Cancel()
+	}
+}
+
+func (op *hedgedGetOp) complete(res *GetResult, err error) {
+	op.lock.Lock()
+	if op.completed {
+		op.lock.Unlock()
+		return
+	}
+	op.completed = true
+	timer := op.timer
+	loser := op.replica
+	if res != nil && res.IsReplica {
+		loser = op.active
+	}
+	op.lock.Unlock()
+
+	if timer != nil {
+		timer.Stop()
+	}
+	if loser != nil {
+		loser.Cancel()
+	}
+
+	op.cb(res, err)
+}
+
+// Cancel cancels both the active and replica requests.
+func (op *hedgedGetOp) Cancel() {
+	op.lock.Lock()
+	active := op.active
+	replica := op.replica
+	timer := op.timer
+	op.lock.Unlock()
+
+	if timer != nil {
+		timer.Stop()
+	}
+	if replica != nil {
+		replica.Cancel()
+	}
+	if active != nil {
+		active.Cancel()
+	}
+}
@@ -0,0 +1,105 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+type testHedgedPendingOp struct {
+	cancelled uint32
+	cancelFn  func()
+}
+
+func (op *testHedgedPendingOp) Cancel() {
+	if atomic.CompareAndSwapUint32(&op.cancelled, 0, 1) && op.cancelFn != nil {
+		op.cancelFn()
+	}
+}
+
+func (suite *UnitTestSuite) runHedgedGet(activeFn func(cb GetCallback) *testHedgedPendingOp,
+	replicaFn func(cb GetReplicaCallback) *testHedgedPendingOp) (*GetResult, uint32, error) {
+	var replicaDispatches uint32
+	resCh := make(chan *GetResult, 1)
+	errCh := make(chan error, 1)
+	_, err := startHedgedGet(GetOptions{Key: []byte("key"), HedgeDelay: 10 * time.Millisecond},
+		func(res *GetResult, err error) {
+			resCh <- res
+			errCh <- err
+		},
+		func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+			return activeFn(cb), nil
+		},
+		func(opts GetOneReplicaOptions, cb GetReplicaCallback) (PendingOp, error) {
+			suite.Assert().Equal(1, opts.ReplicaIdx)
+			suite.Assert().Equal([]byte("key"), opts.Key)
+			atomic.AddUint32(&replicaDispatches, 1)
+			return replicaFn(cb), nil
+		}, nil)
+	suite.Require().Nil(err)
+
+	select {
+	case res := <-resCh:
+		return res, atomic.LoadUint32(&replicaDispatches), <-errCh
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Timed out waiting for hedged get")
+		return nil, 0, nil
+	}
+}
+
+func (suite *UnitTestSuite) TestHedgedGetActiveRespondsFirst() {
+	res, replicaDispatches, err := suite.runHedgedGet(func(cb GetCallback) *testHedgedPendingOp {
+		cb(&GetResult{Value: []byte("active")}, nil)
+		return &testHedgedPendingOp{}
+	}, func(cb GetReplicaCallback) *testHedgedPendingOp {
+		return &testHedgedPendingOp{}
+	})
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]byte("active"), res.Value)
+	suite.Assert().False(res.IsReplica)
+
+	time.Sleep(20 * time.Millisecond)
+	suite.Assert().Zero(replicaDispatches)
+}
+
+func (suite *UnitTestSuite) TestHedgedGetReplicaRespondsFirst() {
+	activeOp := &testHedgedPendingOp{}
+	var activeCb GetCallback
+	activeOp.cancelFn = func() {
+		activeCb(nil, errRequestCanceled)
+	}
+
+	res, replicaDispatches, err := suite.runHedgedGet(func(cb GetCallback) *testHedgedPendingOp {
+		activeCb = cb
+		return activeOp
+	}, func(cb GetReplicaCallback) *testHedgedPendingOp {
+		go cb(&GetReplicaResult{Value: []byte("replica"), Cas: 5}, nil)
+		return &testHedgedPendingOp{}
+	})
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]byte("replica"), res.Value)
+	suite.Assert().Equal(Cas(5), res.Cas)
+	suite.Assert().True(res.IsReplica)
+	suite.Assert().Equal(uint32(1), replicaDispatches)
+	suite.Assert().Equal(uint32(1), atomic.LoadUint32(&activeOp.cancelled))
+}
+
+func (suite *UnitTestSuite) TestHedgedGetActiveErrorIsAuthoritative() {
+	replicaOp := &testHedgedPendingOp{}
+	replicaDispatched := make(chan struct{})
+
+	res, _, err := suite.runHedgedGet(func(cb GetCallback) *testHedgedPendingOp {
+		go func() {
+			<-replicaDispatched
+			cb(nil, errDocumentNotFound)
+		}()
+		return &testHedgedPendingOp{}
+	}, func(cb GetReplicaCallback) *testHedgedPendingOp {
+		// Errors from the replica are ignored in favour of waiting on the active.
+		cb(nil, errors.New("replica failed"))
+		close(replicaDispatched)
+		return replicaOp
+	})
+	suite.Assert().Nil(res)
+	suite.Assert().True(errors.Is(err, ErrDocumentNotFound))
+}
@@ -0,0 +1,160 @@
+package gocbcore
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// getStreamWriter sits between the connection and the user's writer so that the value stops being written once the
+// operation has completed, for example if it timed out part way through the value.
+type getStreamWriter struct {
+	lock    sync.Mutex
+	w       io.Writer
+	written int64
+	err     error
+	closed  bool
+}
+
+func (gsw *getStreamWriter) Write(p []byte) (int, error) {
+	gsw.lock.Lock()
+	defer gsw.lock.Unlock()
+
+	if gsw.closed {
+		return 0, errRequestCanceled
+	}
+	if gsw.err != nil {
+		return 0, gsw.err
+	}
+
+	n, err := gsw.w.Write(p)
+	gsw.written += int64(n)
+	gsw.err = err
+
+	return n, err
+}
+
+func (gsw *getStreamWriter) Written() int64 {
+	gsw.lock.Lock()
+	defer gsw.lock.Unlock()
+	return gsw.written
+}
+
+// Close prevents any further writes, returning how much was written and the error from the writer, if any.
+func (gsw *getStreamWriter) Close() (int64, error) {
+	gsw.lock.Lock()
+	defer gsw.lock.Unlock()
+
+	gsw.closed = true
+	return gsw.written, gsw.err
+}
+
+// getStreamRetryStrategy prevents a streaming get from being retried once any of the value has been written, as the
+// value would otherwise be written again.
+type getStreamRetryStrategy struct {
+	wrapped RetryStrategy
+	writer  *getStreamWriter
+}
+
+func (rs *getStreamRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
+	if rs.writer.Written() > 0 {
+		return &NoRetryRetryAction{}
+	}
+
+	return rs.wrapped.RetryAfter(req, reason)
+}
+
+func (crud *crudComponent) GetStreaming(opts GetStreamingOptions, cb GetStreamingCallback) (PendingOp, error) {
+	if opts.Writer == nil {
+		return nil, wrapError(errInvalidArgument, "writer cannot be nil")
+	}
+
+	tracer := crud.tracer.CreateOpTrace("GetStreaming", opts.TraceContext)
+	writer := &getStreamWriter{w: opts.Writer}
+
+	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
+		written, writeErr := writer.Close()
+		if err != nil {
+			tracer.Finish()
+			cb(nil, err)
+			return
+		}
+
+		if len(resp.Extras) != 4 {
+			tracer.Finish()
+			cb(nil, errProtocol)
+			return
+		}
+
+		// Values which were compressed, or read by a connection which can't stream, are buffered as usual.
+		if written == 0 && len(resp.Value) > 0 {
+			var n int
+			n, writeErr = opts.Writer.Write(resp.Value)
+			written = int64(n)
+		}
+
+		if writeErr != nil {
+			tracer.Finish()
+			cb(nil, writeErr)
+			return
+		}
+
+		res := GetStreamingResult{}
+		res.BytesWritten = written
+		res.Flags = binary.BigEndian.Uint32(resp.Extras[0:])
+		res.Cas = Cas(resp.Cas)
+		res.Datatype = resp.Datatype
+		res.ServerDuration = resp.ServerDuration()
+		res.ResourceUnits = resp.ResourceUnits()
+
+		tracer.Finish()
+		cb(&res, nil)
+	}
+
+	var userFrame *memd.UserImpersonationFrame
+	if len(opts.User) > 0 {
+		userFrame = &memd.UserImpersonationFrame{
+			User: opts.User,
+		}
+	}
+
+	if opts.RetryStrategy == nil {
+		opts.RetryStrategy = crud.defaultRetryStrategy
+	}
+
+	req := &memdQRequest{
+		Packet: memd.Packet{
+			Magic:                  memd.CmdMagicReq,
+			Command:                memd.CmdGet,
+			Datatype:               0,
+			Cas:                    0,
+			Extras:                 nil,
+			Key:                    opts.Key,
+			Value:                  nil,
+			CollectionID:           opts.CollectionID,
+			UserImpersonationFrame: userFrame,
+		},
+		Callback:         handler,
+		RootTraceContext: tracer.RootContext(),
+		CollectionName:   opts.CollectionName,
+		ScopeName:        opts.ScopeName,
+		ValueWriter:      writer,
+		RetryStrategy: &getStreamRetryStrategy{
+			wrapped: opts.RetryStrategy,
+			writer:  writer,
+		},
+		Deadline: crud.timeouts.Deadline(opts.Deadline),
+		Priority: opts.Priority,
+	}
+
+	op, err := crud.cidMgr.Dispatch(req)
+	if err != nil {
+		return nil, err
+	}
+
+	crud.timeouts.Track(req, req.Deadline, "GetStreaming", errUnambiguousTimeout)
+
+	return op, nil
+}
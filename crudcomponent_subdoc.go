@@ -2,7 +2,6 @@ package gocbcore
 
 import (
 	"encoding/binary"
-	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
 )
@@ -79,6 +78,8 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 				IsDeleted: isErrorStatus(err, memd.StatusSubDocSuccessDeleted) ||
 					isErrorStatus(err, memd.StatusSubDocMultiPathFailureDeleted),
 			},
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
@@ -147,6 +148,8 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Priority:         opts.Priority,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -154,24 +157,7 @@ func (crud *crudComponent) LookupIn(opts LookupInOptions, cb LookupInCallback) (
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errUnambiguousTimeout,
-				OperationID:        "LookupIn",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "LookupIn", errUnambiguousTimeout)
 
 	return op, nil
 }
@@ -234,17 +220,19 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 
 		tracer.Finish()
 		cb(&MutateInResult{
-			Cas:           Cas(resp.Cas),
-			MutationToken: mutToken,
-			Ops:           results,
+			Cas:            Cas(resp.Cas),
+			MutationToken:  mutToken,
+			Ops:            results,
+			ServerDuration: resp.ServerDuration(),
+			ResourceUnits:  resp.ResourceUnits(),
 		}, nil)
 	}
 
 	var duraLevelFrame *memd.DurabilityLevelFrame
 	var duraTimeoutFrame *memd.DurabilityTimeoutFrame
 	if opts.DurabilityLevel > 0 {
-		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityDurableWrites, BucketCapabilityStatusUnsupported) {
-			return nil, errFeatureNotAvailable
+		if err := crud.verifyDurableWrites(); err != nil {
+			return nil, err
 		}
 		duraLevelFrame = &memd.DurabilityLevelFrame{
 			DurabilityLevel: opts.DurabilityLevel,
@@ -269,6 +257,17 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 		}
 	}
 
+	if opts.Flags&memd.SubdocDocFlagReviveDocument != 0 {
+		// The server will only find the document to revive if we are allowed to access deleted documents.
+		if opts.Flags&memd.SubdocDocFlagAccessDeleted == 0 {
+			return nil, wrapError(errInvalidArgument, "revive document requires the access deleted flag")
+		}
+
+		if crud.featureVerifier.HasBucketCapabilityStatus(BucketCapabilityReviveDocument, BucketCapabilityStatusUnsupported) {
+			return nil, errFeatureNotAvailable
+		}
+	}
+
 	subdocs.Reorder(opts.Ops)
 
 	pathBytesList := make([][]byte, len(opts.Ops))
@@ -295,6 +294,10 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 			return nil, errInvalidArgument
 		}
 
+		if op.Flags&memd.SubdocFlagExpandMacros != 0 && op.Flags&memd.SubdocFlagXattrPath == 0 {
+			return nil, wrapError(errInvalidArgument, "macros can only be expanded within xattr paths")
+		}
+
 		if op.Op == memd.SubDocOpReplaceBodyWithXattr {
 			// We can get here before support status is actually known, we'll send the request unless we know for a fact
 			// that this is unsupported.
@@ -349,6 +352,8 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         crud.timeouts.Deadline(opts.Deadline),
+		Priority:         opts.Priority,
 	}
 
 	op, err := crud.cidMgr.Dispatch(req)
@@ -356,24 +361,7 @@ func (crud *crudComponent) MutateIn(opts MutateInOptions, cb MutateInCallback) (
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errAmbiguousTimeout,
-				OperationID:        "MutateIn",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	crud.timeouts.Track(req, req.Deadline, "MutateIn", errAmbiguousTimeout)
 
 	return op, nil
 }
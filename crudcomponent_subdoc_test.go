@@ -339,6 +339,101 @@ func (suite *StandardTestSuite) TestTombstones() {
 	s.Wait(0)
 }
 
+func (suite *StandardTestSuite) TestReviveDocument() {
+	suite.EnsureSupportsFeature(TestFeatureReviveDocument)
+
+	agent, s := suite.GetAgentAndHarness()
+
+	s.PushOp(agent.MutateIn(MutateInOptions{
+		Key:   []byte("TestReviveDocument"),
+		Flags: memd.SubdocDocFlagCreateAsDeleted | memd.SubdocDocFlagAccessDeleted | memd.SubdocDocFlagMkDoc,
+		Ops: []SubDocOp{
+			{
+				Op:    memd.SubDocOpDictSet,
+				Value: []byte("{\"test\":\"test\"}"),
+				Path:  "test",
+				Flags: memd.SubdocFlagXattrPath,
+			},
+		},
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+	}, func(res *MutateInResult, err error) {
+		s.Wrap(func() {
+			if err != nil {
+				s.Fatalf("Set operation failed: %v", err)
+			}
+		})
+	}))
+	s.Wait(0)
+
+	s.PushOp(agent.MutateIn(MutateInOptions{
+		Key:   []byte("TestReviveDocument"),
+		Flags: memd.SubdocDocFlagReviveDocument | memd.SubdocDocFlagAccessDeleted,
+		Ops: []SubDocOp{
+			{
+				Op:    memd.SubDocOpDictSet,
+				Value: []byte("\"revived\""),
+				Path:  "revived",
+				Flags: memd.SubdocFlagXattrPath,
+			},
+		},
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+	}, func(res *MutateInResult, err error) {
+		s.Wrap(func() {
+			if err != nil {
+				s.Fatalf("Revive operation failed: %v", err)
+			}
+		})
+	}))
+	s.Wait(0)
+
+	s.PushOp(agent.LookupIn(LookupInOptions{
+		Key: []byte("TestReviveDocument"),
+		Ops: []SubDocOp{
+			{
+				Op:    memd.SubDocOpGet,
+				Path:  "revived",
+				Flags: memd.SubdocFlagXattrPath,
+			},
+		},
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+	}, func(res *LookupInResult, err error) {
+		s.Wrap(func() {
+			if err != nil {
+				s.Fatalf("Get operation failed: %v", err)
+			}
+			if res.Internal.IsDeleted {
+				s.Fatalf("LookupIn operation should have returned IsDeleted==false")
+			}
+		})
+	}))
+	s.Wait(0)
+}
+
+func (suite *StandardTestSuite) TestReviveDocumentRequiresAccessDeleted() {
+	agent, _ := suite.GetAgentAndHarness()
+
+	_, err := agent.MutateIn(MutateInOptions{
+		Key:   []byte("TestReviveDocumentRequiresAccessDeleted"),
+		Flags: memd.SubdocDocFlagReviveDocument,
+		Ops: []SubDocOp{
+			{
+				Op:    memd.SubDocOpDictSet,
+				Value: []byte("\"revived\""),
+				Path:  "revived",
+				Flags: memd.SubdocFlagXattrPath,
+			},
+		},
+		CollectionName: suite.CollectionName,
+		ScopeName:      suite.ScopeName,
+	}, func(res *MutateInResult, err error) {})
+	if !errors.Is(err, ErrInvalidArgument) {
+		suite.T().Fatalf("Expected invalid argument error but was %v", err)
+	}
+}
+
 func (suite *StandardTestSuite) TestReplaceBodyWithXattr() {
 	suite.EnsureSupportsFeature(TestFeatureReplaceBodyWithXattr)
 
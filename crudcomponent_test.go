@@ -0,0 +1,169 @@
+package gocbcore
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+	"github.com/golang/snappy"
+)
+
+func (suite *UnitTestSuite) TestServerDurationOnResults() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		resp := defaultGet(req)
+		resp.ServerDurationFrame = &memd.ServerDurationFrame{ServerDuration: 120 * time.Microsecond}
+		return resp
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:   "default",
+		UseDurations: true,
+	})
+	defer agent.Close()
+
+	setRes := suite.mustSet(agent, "key")
+	suite.Assert().Zero(setRes.ServerDuration)
+
+	getCh := make(chan *GetResult, 1)
+	_, err := agent.Get(GetOptions{
+		Key:      []byte("key"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err)
+		getCh <- res
+	})
+	suite.Require().Nil(err)
+	getRes := <-getCh
+	suite.Require().NotNil(getRes)
+
+	// The duration is encoded lossily on the wire.
+	suite.Assert().InDelta(float64(120*time.Microsecond), float64(getRes.ServerDuration), float64(10*time.Microsecond))
+}
+
+func (suite *UnitTestSuite) TestResourceUnitsOnResults() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var defaultSet memdmock.HandlerFunc
+	defaultSet = server.Handle(memd.CmdSet, func(req *memd.Packet) *memd.Packet {
+		resp := defaultSet(req)
+		resp.WriteUnitsFrame = &memd.WriteUnitsFrame{WriteUnits: 2}
+		return resp
+	})
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		resp := defaultGet(req)
+		resp.ReadUnitsFrame = &memd.ReadUnitsFrame{ReadUnits: 1}
+		resp.ThrottleDurationFrame = &memd.ThrottleDurationFrame{ThrottleDuration: 5 * time.Millisecond}
+		return resp
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:       "default",
+		UseResourceUnits: true,
+	})
+	defer agent.Close()
+
+	setRes := suite.mustSet(agent, "key")
+	suite.Assert().Equal(&ResourceUnitResult{WriteUnits: 2}, setRes.ResourceUnits)
+
+	for i := 0; i < 2; i++ {
+		getCh := make(chan *GetResult, 1)
+		_, err := agent.Get(GetOptions{
+			Key:      []byte("key"),
+			Deadline: time.Now().Add(5 * time.Second),
+		}, func(res *GetResult, err error) {
+			suite.Assert().Nil(err)
+			getCh <- res
+		})
+		suite.Require().Nil(err)
+		getRes := <-getCh
+		suite.Require().NotNil(getRes)
+		suite.Require().NotNil(getRes.ResourceUnits)
+		suite.Assert().Equal(uint16(1), getRes.ResourceUnits.ReadUnits)
+		suite.Assert().Equal(5*time.Millisecond, getRes.ResourceUnits.ThrottleDuration)
+	}
+
+	suite.Assert().Equal(ResourceUnitTotals{
+		ReadUnits:           2,
+		WriteUnits:          2,
+		ThrottledOperations: 2,
+		ThrottleDuration:    10 * time.Millisecond,
+	}, agent.ResourceUnits())
+
+	features := (&memdClient{}).helloFeatures(helloProps{ResourceUnitsEnabled: true})
+	suite.Assert().True(checkSupportsFeature(features, memd.FeatureReportUnitUsage))
+}
+
+func (suite *UnitTestSuite) TestGetStreaming() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	value := bytes.Repeat([]byte("0123456789"), 100000)
+
+	// Compressed values can't be streamed, so these are decompressed and written once the response is complete.
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		resp := defaultGet(req)
+		if string(req.Key) == "compressed" {
+			resp.Status = memd.StatusSuccess
+			resp.Extras = []byte{0, 0, 0, 0}
+			resp.Value = snappy.Encode(nil, value)
+			resp.Datatype = uint8(memd.DatatypeFlagCompressed)
+		}
+		return resp
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	setCh := make(chan error, 1)
+	_, err := agent.Set(SetOptions{
+		Key:      []byte("key"),
+		Value:    value,
+		Flags:    1,
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *StoreResult, err error) {
+		setCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-setCh)
+
+	for _, key := range []string{"key", "compressed"} {
+		var buf bytes.Buffer
+		getCh := make(chan *GetStreamingResult, 1)
+		_, err = agent.GetStreaming(GetStreamingOptions{
+			Key:      []byte(key),
+			Writer:   &buf,
+			Deadline: time.Now().Add(5 * time.Second),
+		}, func(res *GetStreamingResult, err error) {
+			suite.Assert().Nil(err)
+			getCh <- res
+		})
+		suite.Require().Nil(err)
+		res := <-getCh
+		suite.Require().NotNil(res)
+		suite.Assert().Equal(int64(len(value)), res.BytesWritten)
+		suite.Assert().True(bytes.Equal(value, buf.Bytes()))
+	}
+
+	errCh := make(chan error, 1)
+	_, err = agent.GetStreaming(GetStreamingOptions{
+		Key:      []byte("key"),
+		Writer:   &testFailingWriter{},
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetStreamingResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(<-errCh, errTestWriteFailed))
+
+	_, err = agent.GetStreaming(GetStreamingOptions{Key: []byte("key")}, func(*GetStreamingResult, error) {})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
@@ -56,6 +56,52 @@ func (s SnapshotState) HasOnDisk() bool {
 	return uint32(s)&2 != 0
 }
 
+// HasCheckpoint returns whether this snapshot starts a new checkpoint.
+func (s SnapshotState) HasCheckpoint() bool {
+	return uint32(s)&4 != 0
+}
+
+// RequiresAck returns whether the server requires this snapshot to be acknowledged once it has been received.
+func (s SnapshotState) RequiresAck() bool {
+	return uint32(s)&8 != 0
+}
+
+// DCPSnapshotMarker is the full payload of a snapshot marker. MaxVisibleSeqNo and HighCompletedSeqNo are only sent
+// in version 2.0 markers and later, and Timestamp is only sent in version 2.1 markers and later, otherwise they are 0.
+type DCPSnapshotMarker struct {
+	StartSeqNo   uint64
+	EndSeqNo     uint64
+	VbID         uint16
+	StreamID     uint16
+	SnapshotType SnapshotState
+
+	// MaxVisibleSeqNo is the seqno of the last item in the snapshot which is visible to consumers not handling
+	// sync writes, such as committed items.
+	MaxVisibleSeqNo uint64
+
+	// HighCompletedSeqNo is the seqno of the most recently committed or aborted sync write, it is only sent for
+	// snapshots which are on disk.
+	HighCompletedSeqNo uint64
+
+	Timestamp uint64
+}
+
+// SnapshotMarkerObserver can optionally be implemented by a StreamObserver to receive the full payload of every
+// snapshot marker, in which case DetailedSnapshotMarker is invoked in place of SnapshotMarker.
+// Volatile: This API is subject to change at any time.
+type SnapshotMarkerObserver interface {
+	DetailedSnapshotMarker(marker DCPSnapshotMarker)
+}
+
+func dispatchSnapshotMarker(observer StreamObserver, marker DCPSnapshotMarker) {
+	if detailed, ok := observer.(SnapshotMarkerObserver); ok {
+		detailed.DetailedSnapshotMarker(marker)
+		return
+	}
+
+	observer.SnapshotMarker(marker.StartSeqNo, marker.EndSeqNo, marker.VbID, marker.StreamID, marker.SnapshotType)
+}
+
 // FailoverEntry represents a single entry in the server fail-over log.
 type FailoverEntry struct {
 	VbUUID VbUUID
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
+	scram "github.com/couchbase/gocbcore/v9/scram"
 )
 
 // DCPAgent represents the base client handling DCP connections to a Couchbase Server.
@@ -14,6 +15,8 @@ type DCPAgent struct {
 	tlsConfig  *dynTLSConfig
 	initFn     memdInitFunc
 
+	vbucketOpenOrder DCPVbucketOpenOrder
+
 	pollerController *pollerController
 	kvMux            *kvMux
 	httpMux          *httpMux
@@ -24,6 +27,12 @@ type DCPAgent struct {
 	diagnostics *diagnosticsComponent
 	dcp         *dcpComponent
 	http        *httpComponent
+	seqnos      *seqnoMonitorComponent
+	streams     *DCPStreamManager
+
+	decompressor *dcpDecompressionPool
+
+	scramSaltedPasswords *scram.SaltedPasswordCache
 }
 
 // CreateDcpAgent creates an agent for performing DCP operations.
@@ -52,6 +61,20 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 
 	serverWaitTimeout := 5 * time.Second
 
+	// The server only supports noop intervals of whole seconds.
+	noopInterval := 180 * time.Second
+	if config.NoopInterval > 0 {
+		noopInterval = config.NoopInterval.Truncate(time.Second)
+		if noopInterval < time.Second {
+			noopInterval = time.Second
+		}
+	}
+
+	var deadConnectionTimeout time.Duration
+	if config.MissedNoopLimit > 0 {
+		deadConnectionTimeout = time.Duration(config.MissedNoopLimit) * noopInterval
+	}
+
 	kvPoolSize := 1
 	if config.KvPoolSize > 0 {
 		kvPoolSize = config.KvPoolSize
@@ -132,11 +155,16 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 
 	var tlsConfig *dynTLSConfig
 	if config.UseTLS {
-		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider)
+		tlsConfig = createTLSConfig(config.Auth, config.TLSRootCAProvider, config.TLSSkipVerify,
+			makeVerifyPeerCertificate(config.TLSPinnedPublicKeys, config.TLSVerifyPeerCertificate))
 	}
 
-	httpCli := createHTTPClient(config.HTTPMaxIdleConns, config.HTTPMaxIdleConnsPerHost,
-		config.HTTPIdleConnectionTimeout, tlsConfig)
+	httpCli := createHTTPClient(HTTPClientConfig{
+		MaxIdleConns:          config.HTTPMaxIdleConns,
+		MaxIdleConnsPerHost:   config.HTTPMaxIdleConnsPerHost,
+		IdleConnectionTimeout: config.HTTPIdleConnectionTimeout,
+		DisableHTTP2:          config.HTTPDisableHTTP2,
+	}, tlsConfig, config.HTTPRoundTrippers)
 
 	tracerCmpt := newTracerComponent(noopTracer{}, config.BucketName, false)
 
@@ -148,7 +176,7 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			return err
 		}
 
-		if err := sclient.ExecEnableDcpNoop(180*time.Second, deadline); err != nil {
+		if err := sclient.ExecEnableDcpNoop(noopInterval, deadline); err != nil {
 			return err
 		}
 
@@ -171,6 +199,11 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 		}
 
 		if config.UseOSOBackfill {
+			version, ok := parseClusterVersion(client.ServerVersion())
+			if err := checkClusterFeature(ClusterFeatureOSOBackfill, version, ok, nil); err != nil {
+				return err
+			}
+
 			if err := sclient.ExecDcpControl("enable_out_of_order_snapshots", "true", deadline); err != nil {
 				return err
 			}
@@ -198,14 +231,19 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 		initFn:     initFn,
 		tracer:     tracerCmpt,
 
+		vbucketOpenOrder: config.VbucketOpenOrder,
+
 		errMap: newErrMapManager(config.BucketName),
+
+		scramSaltedPasswords: scram.NewSaltedPasswordCache(),
 	}
+	logCtx := logContext{{Key: "agent", Value: c.clientID}}
 
 	circuitBreakerConfig := CircuitBreakerConfig{
 		Enabled: false,
 	}
 
-	authHandler := buildAuthHandler(auth)
+	authHandler := buildAuthHandler(auth, c.scramSaltedPasswords)
 
 	var httpEpList []string
 	for _, hostPort := range config.HTTPAddrs {
@@ -222,9 +260,18 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			UseSSL:       config.UseTLS,
 			SrcMemdAddrs: config.MemdAddrs,
 			SrcHTTPAddrs: []string{},
+
+			AddressTranslator: config.AddressTranslator,
+			NetworkResolver:   config.NetworkResolver,
+
+			LogContext: logCtx,
 		},
 	)
 
+	if config.DCPDecompressionWorkers > 0 && !disableDecompression {
+		c.decompressor = newDCPDecompressionPool(config.DCPDecompressionWorkers)
+	}
+
 	dialer := newMemdClientDialerComponent(
 		memdClientDialerProps{
 			ServerWaitTimeout:    serverWaitTimeout,
@@ -235,6 +282,9 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			CompressionMinSize:   compressionMinSize,
 			CompressionMinRatio:  compressionMinRatio,
 			DisableDecompression: disableDecompression,
+			DCPDecompressor:      c.decompressor,
+			EventCallback:        config.EndpointEventCallback,
+			DeadConnTimeout:      deadConnectionTimeout,
 		},
 		bootstrapProps{
 			HelloProps: helloProps{
@@ -261,6 +311,7 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 			QueueSize:          maxQueueSize,
 			PoolSize:           kvPoolSize,
 			CollectionsEnabled: useCollections,
+			LogContext:         logCtx,
 		},
 		c.cfgManager,
 		c.errMap,
@@ -272,6 +323,8 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 		httpComponentProps{
 			UserAgent:            userAgent,
 			DefaultRetryStrategy: &failFastRetryStrategy{},
+			BucketName:           c.bucketName,
+			LogContext:           logCtx,
 		},
 		httpCli,
 		c.httpMux,
@@ -279,11 +332,17 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 		c.tracer,
 	)
 
+	// This must be registered after the muxes so that the new topology is in use by the time we report it.
+	if config.TopologyChangeCallback != nil {
+		newTopologyEventsComponent(c.cfgManager, config.TopologyChangeCallback)
+	}
+
 	c.pollerController = newPollerController(
 		newCCCPConfigController(
 			cccpPollerProperties{
 				confCccpMaxWait:    confCccpMaxWait,
 				confCccpPollPeriod: confCccpPollPeriod,
+				logCtx:             logCtx,
 			},
 			c.kvMux,
 			c.cfgManager,
@@ -295,15 +354,24 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 				confHTTPRetryDelay:   confHTTPRetryDelay,
 				confHTTPRedialPeriod: confHTTPRedialPeriod,
 				confHTTPMaxWait:      confHTTPMaxWait,
+				logCtx:               logCtx,
 			},
 			c.httpMux,
 			c.cfgManager,
 		),
 		c.cfgManager,
+		logCtx,
 	)
 
-	c.diagnostics = newDiagnosticsComponent(c.kvMux, nil, nil, c.bucketName, newFailFastRetryStrategy(), c.pollerController)
+	c.diagnostics = newDiagnosticsComponent(c.kvMux, nil, nil, c.bucketName, newFailFastRetryStrategy(),
+		c.pollerController, logCtx)
 	c.dcp = newDcpComponent(c.kvMux, config.UseStreamID)
+	if config.UseStreamID {
+		c.streams = newDCPStreamManager(c.dcp)
+	}
+	if config.HighSeqnoPollInterval > 0 {
+		c.seqnos = newSeqnoMonitorComponent(c.dcp, c.kvMux, config.HighSeqnoPollInterval, logCtx)
+	}
 
 	// Kick everything off.
 	cfg := &routeConfig{
@@ -316,6 +384,9 @@ func CreateDcpAgent(config *DCPAgentConfig, dcpStreamName string, openFlags memd
 	c.kvMux.OnNewRouteConfig(cfg)
 
 	go c.pollerController.Start()
+	if c.seqnos != nil {
+		c.seqnos.Start()
+	}
 
 	return c, nil
 }
@@ -328,6 +399,10 @@ func (agent *DCPAgent) IsSecure() bool {
 // Close shuts down the agent, disconnecting from all servers and failing
 // any outstanding operations with ErrShutdown.
 func (agent *DCPAgent) Close() error {
+	if agent.seqnos != nil {
+		agent.seqnos.Stop()
+	}
+
 	routeCloseErr := agent.kvMux.Close()
 	agent.pollerController.Stop()
 
@@ -336,6 +411,13 @@ func (agent *DCPAgent) Close() error {
 	// will be an open channel till its closed to signal completion.
 	<-agent.pollerController.Done()
 
+	// Any connections still shutting down fall back to decompressing inline once the pool is closed.
+	if agent.decompressor != nil {
+		agent.decompressor.Close()
+	}
+
+	agent.scramSaltedPasswords.Clear()
+
 	return routeCloseErr
 }
 
@@ -370,6 +452,39 @@ func (agent *DCPAgent) GetVbucketSeqnos(serverIdx int, state memd.VbucketState,
 	return agent.dcp.GetVbucketSeqnos(serverIdx, state, opts, cb)
 }
 
+// HighSeqnos returns the most recently polled high seqno of every active vbucket, along with how far each advanced
+// between the last two polls. This can be compared against the seqnos processed from each stream to compute lag.
+// Returns nil unless DCPAgentConfig.HighSeqnoPollInterval is set.
+// Volatile: This API is subject to change at any time.
+func (agent *DCPAgent) HighSeqnos() []VbucketHighSeqno {
+	if agent.seqnos == nil {
+		return nil
+	}
+
+	return agent.seqnos.HighSeqnos()
+}
+
+// StreamManager returns the manager used to allocate stream IDs and multiplex filtered streams over this agent.
+// Returns nil unless DCPAgentConfig.UseStreamID is set.
+// Volatile: This API is subject to change at any time.
+func (agent *DCPAgent) StreamManager() *DCPStreamManager {
+	return agent.streams
+}
+
+// OrderVbuckets returns vbIDs arranged in the order which their streams should be opened in, according to
+// DCPAgentConfig.VbucketOpenOrder. The current cluster config is used to find the node holding each vBucket.
+// Volatile: This API is subject to change at any time.
+func (agent *DCPAgent) OrderVbuckets(vbIDs []uint16) ([]uint16, error) {
+	snapshot, err := agent.kvMux.ConfigSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	return orderVbuckets(agent.vbucketOpenOrder, vbIDs, func(vbID uint16) (int, error) {
+		return snapshot.VbucketToServer(vbID, 0)
+	})
+}
+
 // HasCollectionsSupport verifies whether or not collections are available on the agent.
 func (agent *DCPAgent) HasCollectionsSupport() bool {
 	return agent.kvMux.SupportsCollections()
@@ -380,6 +495,24 @@ func (agent *DCPAgent) ConfigSnapshot() (*ConfigSnapshot, error) {
 	return agent.kvMux.ConfigSnapshot()
 }
 
+// ErrorMap returns the error map fetched from the server, see Agent.ErrorMap.
+// Volatile: This API is subject to change at any time.
+func (agent *DCPAgent) ErrorMap() *ErrorMap {
+	return agent.errMap.ErrorMap()
+}
+
+// FreezeConfig stops new cluster configs from being applied until UnfreezeConfig is called, see Agent.FreezeConfig.
+// Volatile: This API is subject to change at any time.
+func (agent *DCPAgent) FreezeConfig() {
+	agent.cfgManager.Freeze()
+}
+
+// UnfreezeConfig allows new cluster configs to be applied again, see Agent.UnfreezeConfig.
+// Volatile: This API is subject to change at any time.
+func (agent *DCPAgent) UnfreezeConfig() {
+	agent.cfgManager.Unfreeze()
+}
+
 func (agent *DCPAgent) onBootstrapFail(err error) {
 	// If this error is a legitimate fallback reason then we should immediately start the http poller.
 	if agent.pollerController != nil && isPollingFallbackError(err) {
@@ -21,8 +21,29 @@ type DCPAgentConfig struct {
 	NetworkType string
 	Auth        AuthProvider
 
+	// AddressTranslator, if set, rewrites the address of every node endpoint found in cluster configs.
+	// Volatile: This API is subject to change at any time.
+	AddressTranslator AddressTranslator
+
+	// NetworkResolver, if set, chooses the network type used to connect to each node in a cluster config, overriding
+	// the configured or detected network type on a per node basis.
+	// Volatile: This API is subject to change at any time.
+	NetworkResolver NetworkResolver
+
+	// TLSRootCAProvider returns the pool of CAs used to verify server certificates, the system trust store is used
+	// if it is not set or returns nil.
 	TLSRootCAProvider func() *x509.CertPool
 
+	// TLSSkipVerify disables verification of server certificates, pinned public keys are still checked.
+	TLSSkipVerify bool
+
+	// TLSVerifyPeerCertificate, if set, is invoked during every TLS handshake after normal certificate verification.
+	TLSVerifyPeerCertificate TLSVerifyPeerCertificateFunc
+
+	// TLSPinnedPublicKeys contains the SHA-256 hashes of certificate SubjectPublicKeyInfos which the server is
+	// permitted to present, if set then at least one certificate in the chain presented by the server must match.
+	TLSPinnedPublicKeys [][]byte
+
 	UseCompression       bool
 	DisableDecompression bool
 
@@ -50,14 +71,59 @@ type DCPAgentConfig struct {
 	HTTPMaxIdleConnsPerHost   int
 	HTTPIdleConnectionTimeout time.Duration
 
+	// HTTPDisableHTTP2 prevents HTTP/2 from being negotiated for requests to any service.
+	HTTPDisableHTTP2 bool
+
+	// HTTPRoundTrippers is a chain of middleware which wraps the transport of the HTTP client created by the agent,
+	// the first middleware in the chain being the outermost.
+	// Volatile: This API is subject to change at any time.
+	HTTPRoundTrippers []HTTPRoundTripperMiddleware
+
 	AgentPriority   DcpAgentPriority
 	UseExpiryOpcode bool
 	UseStreamID     bool
 	UseOSOBackfill  bool
 	BackfillOrder   DCPBackfillOrder
 
+	// VbucketOpenOrder is the order in which DCPAgent.OrderVbuckets arranges vBuckets, consumers which need each
+	// vBucket to complete in turn should pair DCPVbucketOpenOrderSequential with DCPBackfillOrderSequential.
+	// Volatile: This API is subject to change at any time.
+	VbucketOpenOrder DCPVbucketOpenOrder
+
 	DCPBufferSize                int
 	DisableBufferAcknowledgement bool
+
+	// NoopInterval is how long a connection must be idle for before the server sends a noop on it, in whole
+	// seconds. Defaults to 180 seconds.
+	// Volatile: This API is subject to change at any time.
+	NoopInterval time.Duration
+
+	// MissedNoopLimit, if set, is how many noop intervals a connection may go without receiving anything before it
+	// is considered dead. Dead connections are closed and reopened, any streams on them end as they would if the
+	// connection had been dropped by the server.
+	// Volatile: This API is subject to change at any time.
+	MissedNoopLimit int
+
+	// EndpointEventCallback is invoked whenever a kv connection is established, authenticated, selects a bucket, is
+	// found to be dead or is dropped.
+	// Volatile: This API is subject to change at any time.
+	EndpointEventCallback EndpointEventCallback
+
+	// DCPDecompressionWorkers, if set, is the number of goroutines shared by all connections to decompress DCP
+	// payloads on, rather than decompressing them on the goroutine processing each connection's packets. Packets
+	// are still delivered in order. Has no effect when DisableDecompression is set.
+	// Volatile: This API is subject to change at any time.
+	DCPDecompressionWorkers int
+
+	// TopologyChangeCallback is invoked whenever a new cluster config adds or removes kv nodes or moves vbuckets
+	// between them.
+	// Volatile: This API is subject to change at any time.
+	TopologyChangeCallback TopologyChangeCallback
+
+	// HighSeqnoPollInterval, if set, is how often the high seqno of every active vbucket is fetched, see
+	// DCPAgent.HighSeqnos.
+	// Volatile: This API is subject to change at any time.
+	HighSeqnoPollInterval time.Duration
 }
 
 func (config *DCPAgentConfig) redacted() interface{} {
@@ -86,6 +152,8 @@ func (config *DCPAgentConfig) redacted() interface{} {
 // Couchbase Connection String.
 // Supported options are:
 //   ca_cert_path (string) - Specifies the path to a CA certificate.
+//   tls_pinned_public_key (string) - A base64 encoded SHA-256 hash of a SubjectPublicKeyInfo to pin, may be repeated.
+//   tls_skip_verify (bool) - Whether to skip verification of server certificates.
 //   network (string) - The network type to use.
 //   kv_connect_timeout (duration) - Maximum period to attempt to connect to cluster in ms.
 //   config_poll_interval (duration) - Period to wait between CCCP config polling in ms.
@@ -105,7 +173,32 @@ func (config *DCPAgentConfig) redacted() interface{} {
 //   idle_http_connection_timeout (duration) - Maximum length of time for an idle connection to stay in the pool in ms.
 //   http_redial_period (duration) - The maximum length of time for the HTTP poller to stay connected before reconnecting.
 //   http_retry_delay (duration) - The length of time to wait between HTTP poller retries if connecting fails.
+//   high_seqno_poll_interval (duration) - How often to fetch the high seqno of every vbucket, disabled by default.
+//   dcp_decompression_workers (int) - The number of goroutines to decompress DCP payloads on, disabled by default.
+//   dcp_backfill_order (string) - The order the cluster backfills vbuckets in, either round-robin or sequential.
+//   dcp_vbucket_open_order (string) - The order to open vbucket streams in, either sequential or node-round-robin.
+//   dcp_noop_interval (duration) - How long a connection must be idle for before the server sends a noop on it.
+//   dcp_missed_noop_limit (int) - How many noop intervals without data before a connection is reopened.
+// Unrecognised options are ignored and logged as warnings, see FromConnStrWithOptions.
 func (config *DCPAgentConfig) FromConnStr(connStr string) error {
+	_, err := config.FromConnStrWithOptions(connStr, FromConnStrOptions{})
+	return err
+}
+
+// FromConnStrWithOptions populates the DCPAgentConfig with information from a
+// Couchbase Connection String, see FromConnStr for the supported options.
+// A warning is returned for each unrecognised option, unless opts.Strict is set in
+// which case an error is returned and the DCPAgentConfig is left unmodified.
+func (config *DCPAgentConfig) FromConnStrWithOptions(connStr string, opts FromConnStrOptions) ([]ConnStrWarning, error) {
+	warnings, err := checkConnStrOptions(connStr, dcpAgentConnStrOptions, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	return warnings, config.fromConnStr(connStr)
+}
+
+func (config *DCPAgentConfig) fromConnStr(connStr string) error {
 	baseSpec, err := connstr.Parse(connStr)
 	if err != nil {
 		return err
@@ -180,6 +273,22 @@ func (config *DCPAgentConfig) FromConnStr(connStr string) error {
 			}
 		}
 
+		for _, pin := range spec.Options["tls_pinned_public_key"] {
+			hash, err := ParseTLSPinnedPublicKey(pin)
+			if err != nil {
+				return err
+			}
+			config.TLSPinnedPublicKeys = append(config.TLSPinnedPublicKeys, hash)
+		}
+
+		if valStr, ok := fetchOption("tls_skip_verify"); ok {
+			val, err := strconv.ParseBool(valStr)
+			if err != nil {
+				return fmt.Errorf("tls_skip_verify option must be a boolean")
+			}
+			config.TLSSkipVerify = val
+		}
+
 		config.UseTLS = true
 	}
 
@@ -281,6 +390,24 @@ func (config *DCPAgentConfig) FromConnStr(connStr string) error {
 		config.HTTPRetryDelay = val
 	}
 
+	// This option is experimental
+	if valStr, ok := fetchOption("high_seqno_poll_interval"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("high seqno poll interval option must be a duration or a number")
+		}
+		config.HighSeqnoPollInterval = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("dcp_decompression_workers"); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("dcp decompression workers option must be a number")
+		}
+		config.DCPDecompressionWorkers = int(val)
+	}
+
 	// This option is experimental
 	if valStr, ok := fetchOption("dcp_priority"); ok {
 		var priority DcpAgentPriority
@@ -299,6 +426,48 @@ func (config *DCPAgentConfig) FromConnStr(connStr string) error {
 		config.AgentPriority = priority
 	}
 
+	// This option is experimental
+	if valStr, ok := fetchOption("dcp_backfill_order"); ok {
+		switch valStr {
+		case "round-robin":
+			config.BackfillOrder = DCPBackfillOrderRoundRobin
+		case "sequential":
+			config.BackfillOrder = DCPBackfillOrderSequential
+		default:
+			return fmt.Errorf("dcp_backfill_order must be one of round-robin or sequential")
+		}
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("dcp_vbucket_open_order"); ok {
+		switch valStr {
+		case "sequential":
+			config.VbucketOpenOrder = DCPVbucketOpenOrderSequential
+		case "node-round-robin":
+			config.VbucketOpenOrder = DCPVbucketOpenOrderNodeRoundRobin
+		default:
+			return fmt.Errorf("dcp_vbucket_open_order must be one of sequential or node-round-robin")
+		}
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("dcp_noop_interval"); ok {
+		val, err := parseDurationOrInt(valStr)
+		if err != nil {
+			return fmt.Errorf("dcp noop interval option must be a duration or a number")
+		}
+		config.NoopInterval = val
+	}
+
+	// This option is experimental
+	if valStr, ok := fetchOption("dcp_missed_noop_limit"); ok {
+		val, err := strconv.ParseInt(valStr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("dcp missed noop limit option must be a number")
+		}
+		config.MissedNoopLimit = int(val)
+	}
+
 	// This option is experimental
 	if valStr, ok := fetchOption("dcp_buffer_size"); ok {
 		val, err := strconv.ParseInt(valStr, 10, 64)
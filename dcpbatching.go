@@ -0,0 +1,309 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+)
+
+// DCPDocumentEventType represents the kind of document change carried by a DCPDocumentEvent.
+type DCPDocumentEventType uint8
+
+const (
+	// DCPDocumentEventMutation indicates that a document was created or updated.
+	DCPDocumentEventMutation DCPDocumentEventType = iota + 1
+
+	// DCPDocumentEventDeletion indicates that a document was deleted.
+	DCPDocumentEventDeletion
+
+	// DCPDocumentEventExpiration indicates that a document was deleted because it expired.
+	DCPDocumentEventExpiration
+)
+
+// DCPDocumentEvent is a single mutation, deletion or expiration delivered as part of a batch.
+type DCPDocumentEvent struct {
+	Type         DCPDocumentEventType
+	SeqNo        uint64
+	RevNo        uint64
+	Cas          uint64
+	Datatype     uint8
+	VbID         uint16
+	CollectionID uint32
+	StreamID     uint16
+	Key          []byte
+	Value        []byte
+
+	// Flags, Expiry and LockTime are only set for mutations.
+	Flags    uint32
+	Expiry   uint32
+	LockTime uint32
+
+	// DeleteTime is only set for deletions and expirations.
+	DeleteTime uint32
+}
+
+// BatchStreamObserver receives the events from DCP streams which are opened with the observer returned by
+// NewBatchingStreamObserver. Document changes are delivered in batches, all other events are delivered individually
+// once any document changes received before them have been delivered.
+// Volatile: This API is subject to change at any time.
+type BatchStreamObserver interface {
+	DocumentEvents(events []DCPDocumentEvent)
+	SnapshotMarker(startSeqNo, endSeqNo uint64, vbID uint16, streamID uint16, snapshotType SnapshotState)
+	End(vbID uint16, streamID uint16, err error)
+	CreateCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64, scopeID uint32, collectionID uint32, ttl uint32, streamID uint16, key []byte)
+	DeleteCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64, scopeID uint32, collectionID uint32, streamID uint16)
+	FlushCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64, collectionID uint32)
+	CreateScope(seqNo uint64, version uint8, vbID uint16, manifestUID uint64, scopeID uint32, streamID uint16, key []byte)
+	DeleteScope(seqNo uint64, version uint8, vbID uint16, manifestUID uint64, scopeID uint32, streamID uint16)
+	ModifyCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64, collectionID uint32, ttl uint32, streamID uint16)
+	OSOSnapshot(vbID uint16, snapshotType uint32, streamID uint16)
+	SeqNoAdvanced(vbID uint16, bySeqno uint64, streamID uint16)
+}
+
+// DCPBatchOptions bounds the batches delivered to a BatchStreamObserver, a batch is delivered as soon as any one of
+// the limits is reached. Limits which are not set are not applied, although at least one must be.
+type DCPBatchOptions struct {
+	// MaxEvents is the maximum number of document changes in a batch.
+	MaxEvents int
+
+	// MaxBytes is the maximum total size of the keys and values of the document changes in a batch.
+	MaxBytes int
+
+	// MaxDelay is the maximum length of time that a document change is held for before its batch is delivered.
+	MaxDelay time.Duration
+}
+
+// BatchingStreamObserver is a StreamObserver which collects document changes into batches for a BatchStreamObserver.
+// A single BatchingStreamObserver may be used for many streams, the events of every stream are batched together and
+// their order is preserved.
+// Volatile: This API is subject to change at any time.
+type BatchingStreamObserver struct {
+	observer BatchStreamObserver
+	opts     DCPBatchOptions
+
+	lock       sync.Mutex
+	batch      []DCPDocumentEvent
+	batchBytes int
+	delayTimer *time.Timer
+}
+
+// NewBatchingStreamObserver creates a StreamObserver which delivers the document changes of the streams it is used
+// with to observer in batches bounded by opts.
+// Volatile: This API is subject to change at any time.
+func NewBatchingStreamObserver(observer BatchStreamObserver, opts DCPBatchOptions) (*BatchingStreamObserver, error) {
+	if opts.MaxEvents < 0 || opts.MaxBytes < 0 || opts.MaxDelay < 0 {
+		return nil, wrapError(errInvalidArgument, "batch limits must not be negative")
+	}
+	if opts.MaxEvents == 0 && opts.MaxBytes == 0 && opts.MaxDelay == 0 {
+		return nil, wrapError(errInvalidArgument, "at least one batch limit must be set")
+	}
+
+	return &BatchingStreamObserver{
+		observer: observer,
+		opts:     opts,
+	}, nil
+}
+
+// Flush delivers any document changes which are waiting for their batch to fill up.
+func (bso *BatchingStreamObserver) Flush() {
+	bso.lock.Lock()
+	bso.flushLocked()
+	bso.lock.Unlock()
+}
+
+func (bso *BatchingStreamObserver) flushLocked() {
+	if bso.delayTimer != nil {
+		bso.delayTimer.Stop()
+		bso.delayTimer = nil
+	}
+
+	if len(bso.batch) == 0 {
+		return
+	}
+
+	batch := bso.batch
+	bso.batch = nil
+	bso.batchBytes = 0
+
+	bso.observer.DocumentEvents(batch)
+}
+
+func (bso *BatchingStreamObserver) addEvent(event DCPDocumentEvent) {
+	bso.lock.Lock()
+	defer bso.lock.Unlock()
+
+	bso.batch = append(bso.batch, event)
+	bso.batchBytes += len(event.Key) + len(event.Value)
+
+	if (bso.opts.MaxEvents > 0 && len(bso.batch) >= bso.opts.MaxEvents) ||
+		(bso.opts.MaxBytes > 0 && bso.batchBytes >= bso.opts.MaxBytes) {
+		bso.flushLocked()
+		return
+	}
+
+	if bso.opts.MaxDelay > 0 && bso.delayTimer == nil {
+		var timer *time.Timer
+		timer = time.AfterFunc(bso.opts.MaxDelay, func() {
+			bso.lock.Lock()
+			// The batch this timer was started for may already have been delivered.
+			if bso.delayTimer == timer {
+				bso.flushLocked()
+			}
+			bso.lock.Unlock()
+		})
+		bso.delayTimer = timer
+	}
+}
+
+// passThrough delivers an event which isn't batched, after delivering the batch of any changes received before it.
+func (bso *BatchingStreamObserver) passThrough(fn func()) {
+	bso.lock.Lock()
+	defer bso.lock.Unlock()
+
+	bso.flushLocked()
+	fn()
+}
+
+// Mutation implements StreamObserver.
+func (bso *BatchingStreamObserver) Mutation(seqNo, revNo uint64, flags, expiry, lockTime uint32, cas uint64,
+	datatype uint8, vbID uint16, collectionID uint32, streamID uint16, key, value []byte) {
+	bso.addEvent(DCPDocumentEvent{
+		Type:         DCPDocumentEventMutation,
+		SeqNo:        seqNo,
+		RevNo:        revNo,
+		Cas:          cas,
+		Datatype:     datatype,
+		VbID:         vbID,
+		CollectionID: collectionID,
+		StreamID:     streamID,
+		Key:          key,
+		Value:        value,
+		Flags:        flags,
+		Expiry:       expiry,
+		LockTime:     lockTime,
+	})
+}
+
+// Deletion implements StreamObserver.
+func (bso *BatchingStreamObserver) Deletion(seqNo, revNo uint64, deleteTime uint32, cas uint64, datatype uint8,
+	vbID uint16, collectionID uint32, streamID uint16, key, value []byte) {
+	bso.addEvent(DCPDocumentEvent{
+		Type:         DCPDocumentEventDeletion,
+		SeqNo:        seqNo,
+		RevNo:        revNo,
+		Cas:          cas,
+		Datatype:     datatype,
+		VbID:         vbID,
+		CollectionID: collectionID,
+		StreamID:     streamID,
+		Key:          key,
+		Value:        value,
+		DeleteTime:   deleteTime,
+	})
+}
+
+// Expiration implements StreamObserver.
+func (bso *BatchingStreamObserver) Expiration(seqNo, revNo uint64, deleteTime uint32, cas uint64, vbID uint16,
+	collectionID uint32, streamID uint16, key []byte) {
+	bso.addEvent(DCPDocumentEvent{
+		Type:         DCPDocumentEventExpiration,
+		SeqNo:        seqNo,
+		RevNo:        revNo,
+		Cas:          cas,
+		VbID:         vbID,
+		CollectionID: collectionID,
+		StreamID:     streamID,
+		Key:          key,
+		DeleteTime:   deleteTime,
+	})
+}
+
+// SnapshotMarker implements StreamObserver.
+func (bso *BatchingStreamObserver) SnapshotMarker(startSeqNo, endSeqNo uint64, vbID uint16, streamID uint16,
+	snapshotType SnapshotState) {
+	bso.passThrough(func() {
+		bso.observer.SnapshotMarker(startSeqNo, endSeqNo, vbID, streamID, snapshotType)
+	})
+}
+
+// DetailedSnapshotMarker implements SnapshotMarkerObserver, the marker is passed on in full if the BatchStreamObserver
+// also implements SnapshotMarkerObserver.
+func (bso *BatchingStreamObserver) DetailedSnapshotMarker(marker DCPSnapshotMarker) {
+	bso.passThrough(func() {
+		if detailed, ok := bso.observer.(SnapshotMarkerObserver); ok {
+			detailed.DetailedSnapshotMarker(marker)
+			return
+		}
+
+		bso.observer.SnapshotMarker(marker.StartSeqNo, marker.EndSeqNo, marker.VbID, marker.StreamID,
+			marker.SnapshotType)
+	})
+}
+
+// End implements StreamObserver.
+func (bso *BatchingStreamObserver) End(vbID uint16, streamID uint16, err error) {
+	bso.passThrough(func() {
+		bso.observer.End(vbID, streamID, err)
+	})
+}
+
+// CreateCollection implements StreamObserver.
+func (bso *BatchingStreamObserver) CreateCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64,
+	scopeID uint32, collectionID uint32, ttl uint32, streamID uint16, key []byte) {
+	bso.passThrough(func() {
+		bso.observer.CreateCollection(seqNo, version, vbID, manifestUID, scopeID, collectionID, ttl, streamID, key)
+	})
+}
+
+// DeleteCollection implements StreamObserver.
+func (bso *BatchingStreamObserver) DeleteCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64,
+	scopeID uint32, collectionID uint32, streamID uint16) {
+	bso.passThrough(func() {
+		bso.observer.DeleteCollection(seqNo, version, vbID, manifestUID, scopeID, collectionID, streamID)
+	})
+}
+
+// FlushCollection implements StreamObserver.
+func (bso *BatchingStreamObserver) FlushCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64,
+	collectionID uint32) {
+	bso.passThrough(func() {
+		bso.observer.FlushCollection(seqNo, version, vbID, manifestUID, collectionID)
+	})
+}
+
+// CreateScope implements StreamObserver.
+func (bso *BatchingStreamObserver) CreateScope(seqNo uint64, version uint8, vbID uint16, manifestUID uint64,
+	scopeID uint32, streamID uint16, key []byte) {
+	bso.passThrough(func() {
+		bso.observer.CreateScope(seqNo, version, vbID, manifestUID, scopeID, streamID, key)
+	})
+}
+
+// DeleteScope implements StreamObserver.
+func (bso *BatchingStreamObserver) DeleteScope(seqNo uint64, version uint8, vbID uint16, manifestUID uint64,
+	scopeID uint32, streamID uint16) {
+	bso.passThrough(func() {
+		bso.observer.DeleteScope(seqNo, version, vbID, manifestUID, scopeID, streamID)
+	})
+}
+
+// ModifyCollection implements StreamObserver.
+func (bso *BatchingStreamObserver) ModifyCollection(seqNo uint64, version uint8, vbID uint16, manifestUID uint64,
+	collectionID uint32, ttl uint32, streamID uint16) {
+	bso.passThrough(func() {
+		bso.observer.ModifyCollection(seqNo, version, vbID, manifestUID, collectionID, ttl, streamID)
+	})
+}
+
+// OSOSnapshot implements StreamObserver.
+func (bso *BatchingStreamObserver) OSOSnapshot(vbID uint16, snapshotType uint32, streamID uint16) {
+	bso.passThrough(func() {
+		bso.observer.OSOSnapshot(vbID, snapshotType, streamID)
+	})
+}
+
+// SeqNoAdvanced implements StreamObserver.
+func (bso *BatchingStreamObserver) SeqNoAdvanced(vbID uint16, bySeqno uint64, streamID uint16) {
+	bso.passThrough(func() {
+		bso.observer.SeqNoAdvanced(vbID, bySeqno, streamID)
+	})
+}
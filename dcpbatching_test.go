@@ -0,0 +1,86 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+type recordingBatchObserver struct {
+	BatchStreamObserver
+
+	lock    sync.Mutex
+	batches [][]DCPDocumentEvent
+	events  []string
+}
+
+func (o *recordingBatchObserver) DocumentEvents(events []DCPDocumentEvent) {
+	o.lock.Lock()
+	o.batches = append(o.batches, events)
+	o.events = append(o.events, "batch")
+	o.lock.Unlock()
+}
+
+func (o *recordingBatchObserver) SnapshotMarker(startSeqNo, endSeqNo uint64, vbID uint16, streamID uint16,
+	snapshotType SnapshotState) {
+	o.lock.Lock()
+	o.events = append(o.events, "snapshot")
+	o.lock.Unlock()
+}
+
+func (o *recordingBatchObserver) numBatches() int {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return len(o.batches)
+}
+
+func (suite *UnitTestSuite) TestBatchingStreamObserverLimits() {
+	_, err := NewBatchingStreamObserver(&recordingBatchObserver{}, DCPBatchOptions{})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	observer := &recordingBatchObserver{}
+	bso, err := NewBatchingStreamObserver(observer, DCPBatchOptions{MaxEvents: 3, MaxBytes: 10})
+	suite.Require().Nil(err)
+
+	bso.SnapshotMarker(1, 10, 0, 0, 0)
+	bso.Mutation(1, 1, 0, 0, 0, 1, 0, 0, 0, 0, []byte("a"), []byte("1"))
+	bso.Deletion(2, 1, 0, 2, 0, 0, 0, 0, []byte("b"), nil)
+	suite.Assert().Equal(0, observer.numBatches())
+
+	bso.Expiration(3, 1, 0, 3, 0, 0, 0, []byte("c"))
+	suite.Require().Equal(1, observer.numBatches())
+	suite.Assert().Equal([]DCPDocumentEventType{DCPDocumentEventMutation, DCPDocumentEventDeletion,
+		DCPDocumentEventExpiration}, []DCPDocumentEventType{observer.batches[0][0].Type, observer.batches[0][1].Type,
+		observer.batches[0][2].Type})
+	suite.Assert().Equal([]byte("1"), observer.batches[0][0].Value)
+
+	// The byte limit is reached before the event limit.
+	bso.Mutation(4, 1, 0, 0, 0, 4, 0, 0, 0, 0, []byte("d"), []byte("0123456789"))
+	suite.Assert().Equal(2, observer.numBatches())
+
+	// Other events deliver the pending batch first so that ordering is preserved.
+	bso.Mutation(5, 1, 0, 0, 0, 5, 0, 0, 0, 0, []byte("e"), nil)
+	bso.SnapshotMarker(11, 20, 0, 0, 0)
+	suite.Assert().Equal([]string{"snapshot", "batch", "batch", "batch", "snapshot"}, observer.events)
+	suite.Assert().Len(observer.batches[2], 1)
+
+	bso.Flush()
+	suite.Assert().Equal(3, observer.numBatches())
+}
+
+func (suite *UnitTestSuite) TestBatchingStreamObserverMaxDelay() {
+	observer := &recordingBatchObserver{}
+	bso, err := NewBatchingStreamObserver(observer, DCPBatchOptions{MaxDelay: 10 * time.Millisecond})
+	suite.Require().Nil(err)
+
+	bso.Mutation(1, 1, 0, 0, 0, 1, 0, 0, 0, 0, []byte("a"), nil)
+	bso.Mutation(2, 1, 0, 0, 0, 2, 0, 0, 0, 0, []byte("b"), nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for observer.numBatches() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	suite.Require().Equal(1, observer.numBatches())
+	suite.Assert().Len(observer.batches[0], 2)
+}
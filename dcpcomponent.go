@@ -28,7 +28,7 @@ func (dcp *dcpComponent) OpenStream(vbID uint16, flags memd.DcpStreamAddFlag, vb
 	var openHandled uint32
 	handler := func(resp *memdQResponse, _ *memdQRequest, err error) {
 		if resp == nil && err == nil {
-			logWarnf("DCP event occurred with no error and no response")
+			dcp.kvMux.logCtx.logWarnf("DCP event occurred with no error and no response")
 			return
 		}
 
@@ -76,15 +76,12 @@ func (dcp *dcpComponent) OpenStream(vbID uint16, flags memd.DcpStreamAddFlag, vb
 		// This is one of the stream events
 		switch resp.Command {
 		case memd.CmdDcpSnapshotMarker:
-			vbID := resp.Vbucket
-			newStartSeqNo := binary.BigEndian.Uint64(resp.Extras[0:])
-			newEndSeqNo := binary.BigEndian.Uint64(resp.Extras[8:])
-			snapshotType := binary.BigEndian.Uint32(resp.Extras[16:])
-			var streamID uint16
-			if resp.StreamIDFrame != nil {
-				streamID = resp.StreamIDFrame.StreamID
+			marker, err := parseSnapshotMarker(resp)
+			if err != nil {
+				dcp.kvMux.logCtx.logWarnf("Failed to parse snapshot marker for vbucket %d (%s)", resp.Vbucket, err)
+				return
 			}
-			evtHandler.SnapshotMarker(newStartSeqNo, newEndSeqNo, vbID, streamID, SnapshotState(snapshotType))
+			dispatchSnapshotMarker(evtHandler, marker)
 		case memd.CmdDcpMutation:
 			vbID := resp.Vbucket
 			seqNo := binary.BigEndian.Uint64(resp.Extras[0:])
@@ -255,6 +252,45 @@ func (dcp *dcpComponent) OpenStream(vbID uint16, flags memd.DcpStreamAddFlag, vb
 	return dcp.kvMux.DispatchDirect(req)
 }
 
+// parseSnapshotMarker parses either a version 1 marker, which is held entirely in the extras, or a version 2.x
+// marker, which has a single byte of extras giving its version and holds its payload in the value.
+func parseSnapshotMarker(resp *memdQResponse) (DCPSnapshotMarker, error) {
+	marker := DCPSnapshotMarker{
+		VbID: resp.Vbucket,
+	}
+	if resp.StreamIDFrame != nil {
+		marker.StreamID = resp.StreamIDFrame.StreamID
+	}
+
+	payload := resp.Extras
+	payloadLen := 20
+	if len(resp.Extras) == 1 {
+		payload = resp.Value
+		payloadLen = 36
+		if resp.Extras[0] > 0 {
+			// Later versions only append fields so the fields that we know of can still be parsed.
+			payloadLen = 44
+		}
+	}
+	if len(payload) < payloadLen {
+		return DCPSnapshotMarker{}, wrapError(errProtocol, fmt.Sprintf("snapshot marker payload is %d bytes, "+
+			"expected at least %d", len(payload), payloadLen))
+	}
+
+	marker.StartSeqNo = binary.BigEndian.Uint64(payload[0:])
+	marker.EndSeqNo = binary.BigEndian.Uint64(payload[8:])
+	marker.SnapshotType = SnapshotState(binary.BigEndian.Uint32(payload[16:]))
+	if payloadLen >= 36 {
+		marker.HighCompletedSeqNo = binary.BigEndian.Uint64(payload[20:])
+		marker.MaxVisibleSeqNo = binary.BigEndian.Uint64(payload[28:])
+	}
+	if payloadLen >= 44 {
+		marker.Timestamp = binary.BigEndian.Uint64(payload[36:])
+	}
+
+	return marker, nil
+}
+
 func (dcp *dcpComponent) CloseStream(vbID uint16, opts CloseStreamOptions, cb CloseStreamCallback) (PendingOp, error) {
 	handler := func(_ *memdQResponse, _ *memdQRequest, err error) {
 		cb(err)
@@ -0,0 +1,73 @@
+package gocbcore
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+type detailedSnapshotObserver struct {
+	StreamObserver
+	markers []DCPSnapshotMarker
+}
+
+func (o *detailedSnapshotObserver) DetailedSnapshotMarker(marker DCPSnapshotMarker) {
+	o.markers = append(o.markers, marker)
+}
+
+func (suite *UnitTestSuite) TestParseSnapshotMarker() {
+	payload := make([]byte, 44)
+	binary.BigEndian.PutUint64(payload[0:], 10)
+	binary.BigEndian.PutUint64(payload[8:], 20)
+	binary.BigEndian.PutUint32(payload[16:], 0x0a)
+	binary.BigEndian.PutUint64(payload[20:], 15)
+	binary.BigEndian.PutUint64(payload[28:], 18)
+	binary.BigEndian.PutUint64(payload[36:], 1600000000)
+
+	newResp := func(extras, value []byte) *memdQResponse {
+		return &memdQResponse{Packet: &memd.Packet{
+			Command:       memd.CmdDcpSnapshotMarker,
+			Vbucket:       7,
+			Extras:        extras,
+			Value:         value,
+			StreamIDFrame: &memd.StreamIDFrame{StreamID: 3},
+		}}
+	}
+
+	marker, err := parseSnapshotMarker(newResp(payload[:20], nil))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(DCPSnapshotMarker{StartSeqNo: 10, EndSeqNo: 20, VbID: 7, StreamID: 3, SnapshotType: 0x0a},
+		marker)
+	suite.Assert().True(marker.SnapshotType.HasOnDisk())
+	suite.Assert().True(marker.SnapshotType.RequiresAck())
+	suite.Assert().False(marker.SnapshotType.HasCheckpoint())
+
+	marker, err = parseSnapshotMarker(newResp([]byte{0}, payload[:36]))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint64(15), marker.HighCompletedSeqNo)
+	suite.Assert().Equal(uint64(18), marker.MaxVisibleSeqNo)
+	suite.Assert().Zero(marker.Timestamp)
+
+	marker, err = parseSnapshotMarker(newResp([]byte{1}, payload))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint64(20), marker.EndSeqNo)
+	suite.Assert().Equal(uint64(18), marker.MaxVisibleSeqNo)
+	suite.Assert().Equal(uint64(1600000000), marker.Timestamp)
+
+	_, err = parseSnapshotMarker(newResp([]byte{1}, payload[:36]))
+	suite.Assert().True(errors.Is(err, ErrProtocol))
+}
+
+func (suite *UnitTestSuite) TestDispatchSnapshotMarker() {
+	marker := DCPSnapshotMarker{StartSeqNo: 1, EndSeqNo: 2, VbID: 3, MaxVisibleSeqNo: 2}
+
+	detailed := &detailedSnapshotObserver{}
+	dispatchSnapshotMarker(detailed, marker)
+	suite.Assert().Equal([]DCPSnapshotMarker{marker}, detailed.markers)
+
+	// Wrapping observers must still pass the full marker through.
+	detailed = &detailedSnapshotObserver{}
+	dispatchSnapshotMarker(&managedDCPStreamObserver{StreamObserver: detailed}, marker)
+	suite.Assert().Equal([]DCPSnapshotMarker{marker}, detailed.markers)
+}
@@ -0,0 +1,77 @@
+package gocbcore
+
+import (
+	"sync"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/golang/snappy"
+)
+
+// dcpDecompressionPool decompresses DCP packets on a set of workers shared by every connection, rather than on the
+// goroutine processing each connection's DCP queue. Packets are still handed to the application in the order that
+// they were received, the processing goroutine just waits for each packet's decompression to complete.
+type dcpDecompressionPool struct {
+	jobs    chan *dcpBuffer
+	stopSig chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newDCPDecompressionPool(numWorkers int) *dcpDecompressionPool {
+	pool := &dcpDecompressionPool{
+		// This must be unbuffered so that a job can never be left in the queue once the workers have stopped.
+		jobs:    make(chan *dcpBuffer),
+		stopSig: make(chan struct{}),
+	}
+
+	pool.wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go pool.worker()
+	}
+
+	return pool
+}
+
+func (pool *dcpDecompressionPool) worker() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case buf := <-pool.jobs:
+			decompressDcpBuffer(buf)
+		case <-pool.stopSig:
+			return
+		}
+	}
+}
+
+// Submit queues the buffer for decompression, blocking if every worker is busy. The buffer must not be used until
+// its decompressed channel is closed. If the pool has been closed then the buffer is decompressed inline.
+func (pool *dcpDecompressionPool) Submit(buf *dcpBuffer) {
+	buf.decompressed = make(chan struct{})
+
+	select {
+	case pool.jobs <- buf:
+	case <-pool.stopSig:
+		decompressDcpBuffer(buf)
+	}
+}
+
+func (pool *dcpDecompressionPool) Close() {
+	close(pool.stopSig)
+	pool.wg.Wait()
+}
+
+func decompressDcpBuffer(buf *dcpBuffer) {
+	defer close(buf.decompressed)
+
+	resp := buf.resp
+	newValue, err := snappy.Decode(nil, resp.Value)
+	if err != nil {
+		// We leave the value compressed so that the failure is handled in the same way as for any other packet.
+		return
+	}
+
+	resp.Value = newValue
+	resp.Datatype = resp.Datatype & ^uint8(memd.DatatypeFlagCompressed)
+	buf.compression.RecordDecompressed()
+}
@@ -0,0 +1,53 @@
+package gocbcore
+
+import (
+	"fmt"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/golang/snappy"
+)
+
+func (suite *UnitTestSuite) TestDCPDecompressionPool() {
+	pool := newDCPDecompressionPool(4)
+
+	newBuf := func(value []byte) *dcpBuffer {
+		resp := &memdQResponse{}
+		resp.Packet = &memd.Packet{
+			Command:  memd.CmdDcpMutation,
+			Datatype: uint8(memd.DatatypeFlagCompressed | memd.DatatypeFlagJSON),
+			Value:    value,
+		}
+		return &dcpBuffer{resp: resp}
+	}
+
+	var bufs []*dcpBuffer
+	for i := 0; i < 100; i++ {
+		buf := newBuf(snappy.Encode(nil, []byte(fmt.Sprintf(`{"i":%d}`, i))))
+		pool.Submit(buf)
+		bufs = append(bufs, buf)
+	}
+
+	for i, buf := range bufs {
+		<-buf.decompressed
+		suite.Assert().Equal(fmt.Sprintf(`{"i":%d}`, i), string(buf.resp.Value))
+		suite.Assert().Equal(uint8(memd.DatatypeFlagJSON), buf.resp.Datatype)
+	}
+
+	// A value which fails to decompress is left untouched.
+	invalid := newBuf([]byte("not snappy"))
+	pool.Submit(invalid)
+	<-invalid.decompressed
+	suite.Assert().Equal("not snappy", string(invalid.resp.Value))
+	suite.Assert().NotZero(invalid.resp.Datatype & uint8(memd.DatatypeFlagCompressed))
+
+	// Once closed packets are decompressed inline.
+	pool.Close()
+	inline := newBuf(snappy.Encode(nil, []byte("inline")))
+	pool.Submit(inline)
+	select {
+	case <-inline.decompressed:
+	default:
+		suite.T().Fatalf("Expected packet to be decompressed inline")
+	}
+	suite.Assert().Equal("inline", string(inline.resp.Value))
+}
@@ -0,0 +1,193 @@
+package gocbcore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+type dcpStreamOpener interface {
+	OpenStream(vbID uint16, flags memd.DcpStreamAddFlag, vbUUID VbUUID, startSeqNo, endSeqNo, snapStartSeqNo,
+		snapEndSeqNo SeqNo, evtHandler StreamObserver, opts OpenStreamOptions, cb OpenStreamCallback) (PendingOp, error)
+	CloseStream(vbID uint16, opts CloseStreamOptions, cb CloseStreamCallback) (PendingOp, error)
+}
+
+type managedDCPStream struct {
+	filter   *OpenStreamFilterOptions
+	observer StreamObserver
+
+	// vbuckets holds every vbucket which has a stream open, or being opened, for this stream ID.
+	vbuckets map[uint16]struct{}
+	removed  bool
+}
+
+// DCPStreamManager allocates stream IDs to filters and routes the events for each stream ID to the observer
+// registered with it, so that many filtered streams can be multiplexed over the same connections. A stream ID is
+// recycled once it has been removed and all of its vbucket streams have ended.
+// Volatile: This API is subject to change at any time.
+type DCPStreamManager struct {
+	opener dcpStreamOpener
+
+	lock    sync.Mutex
+	nextID  uint32
+	freeIDs []uint16
+	streams map[uint16]*managedDCPStream
+}
+
+func newDCPStreamManager(opener dcpStreamOpener) *DCPStreamManager {
+	return &DCPStreamManager{
+		opener:  opener,
+		nextID:  1,
+		streams: make(map[uint16]*managedDCPStream),
+	}
+}
+
+// AddStream allocates a stream ID for filter, events for any vbucket stream opened with the ID are sent to observer.
+// A nil filter streams every collection.
+func (m *DCPStreamManager) AddStream(filter *OpenStreamFilterOptions, observer StreamObserver) (uint16, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var streamID uint16
+	if len(m.freeIDs) > 0 {
+		streamID = m.freeIDs[0]
+		m.freeIDs = m.freeIDs[1:]
+	} else {
+		// Stream ID 0 is reserved by the server to mean that no stream ID is present.
+		if m.nextID > 0xffff {
+			return 0, errStreamIDsExhausted
+		}
+
+		streamID = uint16(m.nextID)
+		m.nextID++
+	}
+
+	m.streams[streamID] = &managedDCPStream{
+		filter:   filter,
+		observer: observer,
+		vbuckets: make(map[uint16]struct{}),
+	}
+
+	return streamID, nil
+}
+
+// RemoveStream stops any further vbucket streams from being opened with streamID. The ID is recycled once every
+// vbucket stream already open with it has ended, these are not closed automatically.
+func (m *DCPStreamManager) RemoveStream(streamID uint16) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stream, ok := m.streams[streamID]
+	if !ok || stream.removed {
+		return wrapError(errInvalidArgument, fmt.Sprintf("stream ID %d is not in use", streamID))
+	}
+
+	stream.removed = true
+	m.recycleLocked(streamID, stream)
+
+	return nil
+}
+
+// Filter returns the filter which streamID was allocated for.
+func (m *DCPStreamManager) Filter(streamID uint16) (*OpenStreamFilterOptions, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stream, ok := m.streams[streamID]
+	if !ok || stream.removed {
+		return nil, wrapError(errInvalidArgument, fmt.Sprintf("stream ID %d is not in use", streamID))
+	}
+
+	return stream.filter, nil
+}
+
+// OpenStream opens a stream for a vbucket using the filter and observer that streamID was allocated for. Only one
+// stream can be open for each vbucket and stream ID at a time.
+func (m *DCPStreamManager) OpenStream(streamID uint16, vbID uint16, flags memd.DcpStreamAddFlag, vbUUID VbUUID,
+	startSeqNo, endSeqNo, snapStartSeqNo, snapEndSeqNo SeqNo, manifestOpts *OpenStreamManifestOptions,
+	cb OpenStreamCallback) (PendingOp, error) {
+	m.lock.Lock()
+	stream, ok := m.streams[streamID]
+	if !ok || stream.removed {
+		m.lock.Unlock()
+		return nil, wrapError(errInvalidArgument, fmt.Sprintf("stream ID %d is not in use", streamID))
+	}
+	if _, ok := stream.vbuckets[vbID]; ok {
+		m.lock.Unlock()
+		return nil, wrapError(errInvalidArgument, fmt.Sprintf("stream ID %d already has a stream open for vbucket %d",
+			streamID, vbID))
+	}
+	stream.vbuckets[vbID] = struct{}{}
+	m.lock.Unlock()
+
+	opts := OpenStreamOptions{
+		FilterOptions:   stream.filter,
+		StreamOptions:   &OpenStreamStreamOptions{StreamID: streamID},
+		ManifestOptions: manifestOpts,
+	}
+	observer := &managedDCPStreamObserver{
+		StreamObserver: stream.observer,
+		manager:        m,
+	}
+
+	op, err := m.opener.OpenStream(vbID, flags, vbUUID, startSeqNo, endSeqNo, snapStartSeqNo, snapEndSeqNo, observer,
+		opts, func(entries []FailoverEntry, err error) {
+			// The server won't send a stream end for a stream which failed to open.
+			if err != nil {
+				m.streamEnded(vbID, streamID)
+			}
+			cb(entries, err)
+		})
+	if err != nil {
+		m.streamEnded(vbID, streamID)
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// CloseStream closes the stream open for a vbucket with streamID, the observer is sent End once it has closed.
+func (m *DCPStreamManager) CloseStream(streamID uint16, vbID uint16, cb CloseStreamCallback) (PendingOp, error) {
+	return m.opener.CloseStream(vbID, CloseStreamOptions{
+		StreamOptions: &CloseStreamStreamOptions{StreamID: streamID},
+	}, cb)
+}
+
+func (m *DCPStreamManager) streamEnded(vbID, streamID uint16) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stream, ok := m.streams[streamID]
+	if !ok {
+		return
+	}
+
+	delete(stream.vbuckets, vbID)
+	m.recycleLocked(streamID, stream)
+}
+
+func (m *DCPStreamManager) recycleLocked(streamID uint16, stream *managedDCPStream) {
+	if !stream.removed || len(stream.vbuckets) > 0 {
+		return
+	}
+
+	delete(m.streams, streamID)
+	m.freeIDs = append(m.freeIDs, streamID)
+}
+
+// managedDCPStreamObserver passes events through to the observer registered for a stream ID, tracking when each
+// vbucket stream ends so that the ID can be recycled.
+type managedDCPStreamObserver struct {
+	StreamObserver
+	manager *DCPStreamManager
+}
+
+func (o *managedDCPStreamObserver) End(vbID uint16, streamID uint16, err error) {
+	o.StreamObserver.End(vbID, streamID, err)
+	o.manager.streamEnded(vbID, streamID)
+}
+
+func (o *managedDCPStreamObserver) DetailedSnapshotMarker(marker DCPSnapshotMarker) {
+	dispatchSnapshotMarker(o.StreamObserver, marker)
+}
@@ -0,0 +1,102 @@
+package gocbcore
+
+import (
+	"errors"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+type fakeDCPStreamOpener struct {
+	opts      []OpenStreamOptions
+	observers []StreamObserver
+	openErr   error
+}
+
+func (f *fakeDCPStreamOpener) OpenStream(vbID uint16, flags memd.DcpStreamAddFlag, vbUUID VbUUID, startSeqNo,
+	endSeqNo, snapStartSeqNo, snapEndSeqNo SeqNo, evtHandler StreamObserver, opts OpenStreamOptions,
+	cb OpenStreamCallback) (PendingOp, error) {
+	f.opts = append(f.opts, opts)
+	f.observers = append(f.observers, evtHandler)
+	cb(nil, f.openErr)
+
+	return &multiPendingOp{}, nil
+}
+
+func (f *fakeDCPStreamOpener) CloseStream(vbID uint16, opts CloseStreamOptions,
+	cb CloseStreamCallback) (PendingOp, error) {
+	return &multiPendingOp{}, nil
+}
+
+type endRecordingObserver struct {
+	StreamObserver
+	ends []uint16
+}
+
+func (o *endRecordingObserver) End(vbID uint16, streamID uint16, err error) {
+	o.ends = append(o.ends, vbID)
+}
+
+func (suite *UnitTestSuite) TestDCPStreamManager() {
+	opener := &fakeDCPStreamOpener{}
+	mgr := newDCPStreamManager(opener)
+
+	usersFilter := &OpenStreamFilterOptions{CollectionIDs: []uint32{8}}
+	usersObserver := &endRecordingObserver{}
+	usersID, err := mgr.AddStream(usersFilter, usersObserver)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint16(1), usersID)
+
+	itemsID, err := mgr.AddStream(&OpenStreamFilterOptions{CollectionIDs: []uint32{9}}, &endRecordingObserver{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint16(2), itemsID)
+
+	filter, err := mgr.Filter(usersID)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(usersFilter, filter)
+
+	noopCb := func([]FailoverEntry, error) {}
+	_, err = mgr.OpenStream(usersID, 4, 0, 0, 0, 0xffffffffffffffff, 0, 0, nil, noopCb)
+	suite.Require().Nil(err)
+	suite.Require().Len(opener.opts, 1)
+	suite.Assert().Equal(usersFilter, opener.opts[0].FilterOptions)
+	suite.Assert().Equal(usersID, opener.opts[0].StreamOptions.StreamID)
+
+	_, err = mgr.OpenStream(usersID, 4, 0, 0, 0, 0xffffffffffffffff, 0, 0, nil, noopCb)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	// The ID isn't recycled until the stream open with it has ended.
+	suite.Require().Nil(mgr.RemoveStream(usersID))
+	_, err = mgr.OpenStream(usersID, 5, 0, 0, 0, 0xffffffffffffffff, 0, 0, nil, noopCb)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+	nextID, err := mgr.AddStream(nil, &endRecordingObserver{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint16(3), nextID)
+
+	opener.observers[0].End(4, usersID, ErrDCPStreamClosed)
+	suite.Assert().Equal([]uint16{4}, usersObserver.ends)
+
+	recycledID, err := mgr.AddStream(nil, &endRecordingObserver{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(usersID, recycledID)
+
+	// Streams which fail to open are never ended by the server.
+	opener.openErr = errors.New("open failed")
+	_, err = mgr.OpenStream(itemsID, 4, 0, 0, 0, 0xffffffffffffffff, 0, 0, nil, noopCb)
+	suite.Require().Nil(err)
+	suite.Require().Nil(mgr.RemoveStream(itemsID))
+	recycledID, err = mgr.AddStream(nil, &endRecordingObserver{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(itemsID, recycledID)
+}
+
+func (suite *UnitTestSuite) TestDCPStreamManagerExhausted() {
+	mgr := newDCPStreamManager(&fakeDCPStreamOpener{})
+	mgr.nextID = 0xffff
+
+	streamID, err := mgr.AddStream(nil, &endRecordingObserver{})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint16(0xffff), streamID)
+
+	_, err = mgr.AddStream(nil, &endRecordingObserver{})
+	suite.Assert().True(errors.Is(err, ErrStreamIDsExhausted))
+}
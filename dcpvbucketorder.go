@@ -0,0 +1,46 @@
+package gocbcore
+
+import (
+	"sort"
+)
+
+// orderVbuckets arranges vbIDs into the order that their streams should be opened in, serverFor returns the index of
+// the node holding the active copy of a vBucket.
+func orderVbuckets(order DCPVbucketOpenOrder, vbIDs []uint16, serverFor func(vbID uint16) (int, error)) ([]uint16,
+	error) {
+	ordered := append([]uint16(nil), vbIDs...)
+	sort.Slice(ordered, func(i, j int) bool {
+		return ordered[i] < ordered[j]
+	})
+
+	if order != DCPVbucketOpenOrderNodeRoundRobin {
+		return ordered, nil
+	}
+
+	var servers []int
+	byServer := make(map[int][]uint16)
+	for _, vbID := range ordered {
+		srvIdx, err := serverFor(vbID)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := byServer[srvIdx]; !ok {
+			servers = append(servers, srvIdx)
+		}
+		byServer[srvIdx] = append(byServer[srvIdx], vbID)
+	}
+	sort.Ints(servers)
+
+	ordered = ordered[:0]
+	for len(ordered) < len(vbIDs) {
+		for _, srvIdx := range servers {
+			if vbs := byServer[srvIdx]; len(vbs) > 0 {
+				ordered = append(ordered, vbs[0])
+				byServer[srvIdx] = vbs[1:]
+			}
+		}
+	}
+
+	return ordered, nil
+}
@@ -0,0 +1,43 @@
+package gocbcore
+
+import (
+	"errors"
+)
+
+func (suite *UnitTestSuite) TestOrderVbuckets() {
+	// vBuckets 0-2 are on server 0, 3-5 on server 1 and 6-7 on server 2.
+	serverFor := func(vbID uint16) (int, error) {
+		return int(vbID / 3), nil
+	}
+	vbIDs := []uint16{7, 6, 5, 4, 3, 2, 1, 0}
+
+	ordered, err := orderVbuckets(DCPVbucketOpenOrderSequential, vbIDs, serverFor)
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]uint16{0, 1, 2, 3, 4, 5, 6, 7}, ordered)
+
+	ordered, err = orderVbuckets(0, vbIDs, serverFor)
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]uint16{0, 1, 2, 3, 4, 5, 6, 7}, ordered)
+
+	ordered, err = orderVbuckets(DCPVbucketOpenOrderNodeRoundRobin, vbIDs, serverFor)
+	suite.Require().Nil(err)
+	suite.Assert().Equal([]uint16{0, 3, 6, 1, 4, 7, 2, 5}, ordered)
+	suite.Assert().Equal([]uint16{7, 6, 5, 4, 3, 2, 1, 0}, vbIDs)
+
+	_, err = orderVbuckets(DCPVbucketOpenOrderNodeRoundRobin, vbIDs, func(uint16) (int, error) {
+		return 0, errInvalidVBucket
+	})
+	suite.Assert().True(errors.Is(err, ErrInvalidVBucket))
+}
+
+func (suite *UnitTestSuite) TestDCPAgentConfigOrderingOptions() {
+	config := &DCPAgentConfig{}
+	err := config.FromConnStr("couchbase://localhost?dcp_backfill_order=sequential&" +
+		"dcp_vbucket_open_order=node-round-robin")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(DCPBackfillOrderSequential, config.BackfillOrder)
+	suite.Assert().Equal(DCPVbucketOpenOrderNodeRoundRobin, config.VbucketOpenOrder)
+
+	suite.Assert().NotNil(config.FromConnStr("couchbase://localhost?dcp_backfill_order=random"))
+	suite.Assert().NotNil(config.FromConnStr("couchbase://localhost?dcp_vbucket_open_order=random"))
+}
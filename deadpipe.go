@@ -0,0 +1,185 @@
+package gocbcore
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DeadPipelineCallback is invoked after each attempt to re-dispatch the operations which could not be routed to a
+// node, with the number of operations which still could not be. It is invoked from the agent's own goroutine and so
+// must not block.
+// Volatile: This API is subject to change at any time.
+type DeadPipelineCallback func(queuedOps int)
+
+// DeadPipelineRetryConfig controls how operations which could not be routed to a node are retried. Operations end up
+// in the dead pipeline when they are dispatched before a config has been received, or when the config does not
+// assign the vbucket that they belong to to a node. They are always re-dispatched when a new config is applied, and
+// are also periodically re-dispatched in case the routing has since been fixed without the config changing.
+// Volatile: This API is subject to change at any time.
+type DeadPipelineRetryConfig struct {
+	// Calculator returns the delay before the next attempt given the number of consecutive attempts which have left
+	// operations unroutable. Defaults to ExponentialBackoff(100ms, 5s, 2).
+	Calculator BackoffCalculator
+
+	// Disabled stops operations from being re-dispatched other than when a new config is applied.
+	Disabled bool
+
+	// Callback, if set, is invoked after every attempt to re-dispatch the operations.
+	Callback DeadPipelineCallback
+}
+
+// DeadPipelineStats describes the operations which could not be routed to a node.
+// Volatile: This API is subject to change at any time.
+type DeadPipelineStats struct {
+	// QueuedOps is the number of operations which are currently waiting to be routed to a node.
+	QueuedOps int
+
+	// Redispatched is the number of times that operations have been periodically re-dispatched, not including those
+	// re-dispatched because a new config was applied.
+	Redispatched uint64
+}
+
+// startDeadPipeRetry starts re-dispatching the requests in the dead pipeline, if it is enabled. This is started once
+// the mux has its first state, rather than when it is created, so that agents which never connect do not leak it.
+func (mux *kvMux) startDeadPipeRetry() {
+	cfg := mux.deadPipeRetry
+	if cfg.Disabled || cfg.Calculator == nil {
+		return
+	}
+
+	mux.deadPipeStartOnce.Do(func() {
+		go mux.deadPipeRetryLoop(cfg)
+	})
+}
+
+// deadPipeRetryLoop periodically re-dispatches the requests in the dead pipeline until the mux is closed. The backoff
+// starts again whenever a new config is applied, and the loop sleeps whilst there is nothing to re-dispatch.
+func (mux *kvMux) deadPipeRetryLoop(cfg DeadPipelineRetryConfig) {
+	var attempt uint32
+	for {
+		select {
+		case <-mux.deadPipeStopSig:
+			return
+		case <-mux.deadPipeResetSig:
+			attempt = 0
+			continue
+		case <-time.After(cfg.Calculator(attempt)):
+		}
+
+		queuedOps, redispatched := mux.redispatchDeadPipe()
+		if redispatched {
+			if cfg.Callback != nil {
+				cfg.Callback(queuedOps)
+			}
+
+			if queuedOps > 0 {
+				attempt++
+				continue
+			}
+		}
+
+		if !mux.waitForDeadPipe() {
+			return
+		}
+		attempt = 0
+	}
+}
+
+// waitForDeadPipe blocks until a request is queued in the dead pipeline or a new config is applied, returning false
+// if the mux is closed first.
+func (mux *kvMux) waitForDeadPipe() bool {
+	atomic.StoreUint32(&mux.deadPipeIdle, 1)
+
+	// A request may have been queued before we were marked as idle, in which case nothing will wake us.
+	deadPipe := mux.routableDeadPipe()
+	if deadPipe != nil && deadPipe.queue.Stats().NumItems > 0 && atomic.CompareAndSwapUint32(&mux.deadPipeIdle, 1, 0) {
+		return true
+	}
+
+	select {
+	case <-mux.deadPipeStopSig:
+		return false
+	case <-mux.deadPipeResetSig:
+		atomic.StoreUint32(&mux.deadPipeIdle, 0)
+		return true
+	}
+}
+
+// deadPipeQueued wakes the retry loop if it is idle and the request was just queued in the dead pipeline.
+func (mux *kvMux) deadPipeQueued(pipeline *memdPipeline) {
+	if atomic.LoadUint32(&mux.deadPipeIdle) == 0 {
+		return
+	}
+
+	if pipeline != mux.routableDeadPipe() {
+		return
+	}
+
+	if atomic.CompareAndSwapUint32(&mux.deadPipeIdle, 1, 0) {
+		mux.resetDeadPipeBackoff()
+	}
+}
+
+// routableDeadPipe returns the dead pipeline if there is a config to route its requests with, otherwise nil is
+// returned as the requests are only re-dispatched once a config is applied.
+func (mux *kvMux) routableDeadPipe() *memdPipeline {
+	clientMux := mux.getState()
+	if clientMux == nil || clientMux.revID < 0 {
+		return nil
+	}
+
+	return clientMux.deadPipe
+}
+
+// redispatchDeadPipe re-dispatches every request in the dead pipeline, returning how many are in the dead pipeline
+// afterwards. False is returned if there was nothing to re-dispatch or no config to route the requests with.
+func (mux *kvMux) redispatchDeadPipe() (int, bool) {
+	deadPipe := mux.routableDeadPipe()
+	if deadPipe == nil {
+		return 0, false
+	}
+
+	reqs := deadPipe.queue.TakeAll()
+	if len(reqs) == 0 {
+		return 0, false
+	}
+
+	mux.logCtx.logDebugf("Re-dispatching %d requests from the dead pipeline", len(reqs))
+	atomic.AddUint64(&mux.deadPipeRedispatched, uint64(len(reqs)))
+
+	// TakeAll returns the high priority requests first, so they are re-dispatched in the order they were taken.
+	for _, req := range reqs {
+		stopCmdTrace(req)
+		mux.RequeueDirect(req, false)
+	}
+
+	return mux.DeadPipelineStats().QueuedOps, true
+}
+
+// resetDeadPipeBackoff restarts the dead pipeline backoff, this is called when a new config is applied as every
+// request in the dead pipeline is re-dispatched at that point anyway.
+func (mux *kvMux) resetDeadPipeBackoff() {
+	select {
+	case mux.deadPipeResetSig <- struct{}{}:
+	default:
+	}
+}
+
+func (mux *kvMux) DeadPipelineStats() DeadPipelineStats {
+	stats := DeadPipelineStats{
+		Redispatched: atomic.LoadUint64(&mux.deadPipeRedispatched),
+	}
+
+	clientMux := mux.getState()
+	if clientMux != nil && clientMux.deadPipe != nil {
+		stats.QueuedOps = clientMux.deadPipe.queue.Stats().NumItems
+	}
+
+	return stats
+}
+
+// DeadPipelineStats returns the number of operations which are waiting because they could not be routed to a node.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) DeadPipelineStats() DeadPipelineStats {
+	return agent.kvMux.DeadPipelineStats()
+}
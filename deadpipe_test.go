@@ -0,0 +1,106 @@
+package gocbcore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+func (suite *UnitTestSuite) TestDeadPipelineRetry() {
+	queuedCh := make(chan int, 100)
+	mux := newKVMux(kvMuxProps{
+		QueueSize: 10,
+		DeadPipelineRetry: DeadPipelineRetryConfig{
+			Calculator: func(retryAttempts uint32) time.Duration {
+				return 10 * time.Millisecond
+			},
+			Callback: func(queuedOps int) {
+				queuedCh <- queuedOps
+			},
+		},
+	}, newConfigManager(configManagerProperties{}), newErrMapManager("default"),
+		newTracerComponent(&noopTracer{}, "", true), nil)
+
+	// The only vbucket has no active copy, so requests can't be routed.
+	unroutable := mux.newKVMuxState(&routeConfig{
+		revID:        1,
+		bktType:      bktTypeCouchbase,
+		kvServerList: []string{"127.0.0.1:11210"},
+		vbMap:        newVbucketMap([][]int{{-1}}, 0),
+	})
+	suite.Require().True(mux.updateState(nil, unroutable))
+	mux.startDeadPipeRetry()
+
+	errCh := make(chan error, 2)
+	mux.RequeueDirect(&memdQRequest{
+		Packet: memd.Packet{
+			Magic:   memd.CmdMagicReq,
+			Command: memd.CmdGet,
+			Key:     []byte("key"),
+		},
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			errCh <- err
+		},
+	}, false)
+	suite.Assert().Equal(1, mux.DeadPipelineStats().QueuedOps)
+
+	// The request keeps landing back in the dead pipeline.
+	for i := 0; i < 2; i++ {
+		select {
+		case queuedOps := <-queuedCh:
+			suite.Assert().Equal(1, queuedOps)
+		case <-time.After(time.Second):
+			suite.T().Fatal("timed out waiting for the dead pipeline to be retried")
+		}
+	}
+	suite.Assert().GreaterOrEqual(mux.DeadPipelineStats().Redispatched, uint64(2))
+
+	// Fix the routing without requeueing the requests, as if only the vbucket map had been corrected.
+	routable := mux.newKVMuxState(&routeConfig{
+		revID:        1,
+		bktType:      bktTypeCouchbase,
+		kvServerList: []string{"127.0.0.1:11210"},
+		vbMap:        newVbucketMap([][]int{{0}}, 0),
+	})
+	routable.deadPipe = unroutable.deadPipe
+	suite.Require().True(mux.updateState(unroutable, routable))
+
+	for queuedOps := range queuedCh {
+		if queuedOps == 0 {
+			break
+		}
+	}
+	suite.Assert().Equal(0, mux.DeadPipelineStats().QueuedOps)
+	suite.Assert().Equal(1, routable.pipelines[0].queue.Stats().NumItems)
+
+	// Nothing is retried whilst the dead pipeline is empty.
+	select {
+	case queuedOps := <-queuedCh:
+		suite.T().Fatalf("dead pipeline retried whilst empty, %d ops queued", queuedOps)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A request landing in the dead pipeline wakes the retries back up.
+	suite.Require().True(mux.updateState(routable, unroutable))
+	mux.RequeueDirect(&memdQRequest{
+		Packet: memd.Packet{
+			Magic:   memd.CmdMagicReq,
+			Command: memd.CmdGet,
+			Key:     []byte("key"),
+		},
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			errCh <- err
+		},
+	}, false)
+
+	select {
+	case queuedOps := <-queuedCh:
+		suite.Assert().Equal(1, queuedOps)
+	case <-time.After(time.Second):
+		suite.T().Fatal("timed out waiting for the dead pipeline to be retried")
+	}
+
+	suite.Require().Nil(mux.Close())
+	suite.Assert().True(errors.Is(<-errCh, ErrShutdown))
+}
@@ -127,6 +127,11 @@ type DiagnosticInfo struct {
 	ConfigRev int64
 	MemdConns []MemdConnInfo
 	State     ClusterState
+
+	// ClusterUUID and ClusterName identify the cluster that the config was received from, they are empty if the
+	// cluster does not include them in its config.
+	ClusterUUID string
+	ClusterName string
 }
 
 // ClusterState is used to describe the state of a cluster.
@@ -21,12 +21,14 @@ type diagnosticsComponent struct {
 	httpMux             *httpMux
 	httpComponent       *httpComponent
 	bucket              string
+	bucketLock          sync.Mutex
 	defaultRetry        RetryStrategy
 	pollerErrorProvider pollerErrorProvider
+	logCtx              logContext
 }
 
 func newDiagnosticsComponent(kvMux *kvMux, httpMux *httpMux, httpComponent *httpComponent, bucket string,
-	defaultRetry RetryStrategy, pollerErrorProvider pollerErrorProvider) *diagnosticsComponent {
+	defaultRetry RetryStrategy, pollerErrorProvider pollerErrorProvider, logCtx logContext) *diagnosticsComponent {
 	return &diagnosticsComponent{
 		kvMux:               kvMux,
 		httpMux:             httpMux,
@@ -34,9 +36,22 @@ func newDiagnosticsComponent(kvMux *kvMux, httpMux *httpMux, httpComponent *http
 		httpComponent:       httpComponent,
 		defaultRetry:        defaultRetry,
 		pollerErrorProvider: pollerErrorProvider,
+		logCtx:              logCtx,
 	}
 }
 
+func (dc *diagnosticsComponent) SetBucketName(bucketName string) {
+	dc.bucketLock.Lock()
+	dc.bucket = bucketName
+	dc.bucketLock.Unlock()
+}
+
+func (dc *diagnosticsComponent) bucketName() string {
+	dc.bucketLock.Lock()
+	defer dc.bucketLock.Unlock()
+	return dc.bucket
+}
+
 func (dc *diagnosticsComponent) pingKV(ctx context.Context, interval time.Duration, deadline time.Time,
 	retryStrat RetryStrategy, user []byte, op *pingOp) {
 
@@ -57,7 +72,7 @@ func (dc *diagnosticsComponent) pingKV(ctx context.Context, interval time.Durati
 	for {
 		iter, err := dc.kvMux.PipelineSnapshot()
 		if err != nil {
-			logErrorf("failed to get pipeline snapshot")
+			dc.logCtx.logErrorf("failed to get pipeline snapshot")
 
 			select {
 			case <-ctx.Done():
@@ -146,19 +161,7 @@ func (dc *diagnosticsComponent) pingKV(ctx context.Context, interval time.Durati
 					if !deadline.IsZero() {
 						start := time.Now()
 						req.SetTimer(time.AfterFunc(deadline.Sub(start), func() {
-							connInfo := req.ConnectionInfo()
-							count, reasons := req.Retries()
-							req.cancelWithCallback(&TimeoutError{
-								InnerError:         errUnambiguousTimeout,
-								OperationID:        "PingKV",
-								Opaque:             req.Identifier(),
-								TimeObserved:       time.Since(start),
-								RetryReasons:       reasons,
-								RetryAttempts:      count,
-								LastDispatchedTo:   connInfo.lastDispatchedTo,
-								LastDispatchedFrom: connInfo.lastDispatchedFrom,
-								LastConnectionID:   connInfo.lastConnectionID,
-							})
+							req.cancelWithCallback(req.makeTimeoutError(errUnambiguousTimeout, "PingKV", start))
 						}))
 					}
 
@@ -291,7 +294,7 @@ func (dc *diagnosticsComponent) pingHTTP(ctx context.Context, service ServiceTyp
 							state = PingStateError
 							b, pErr := ioutil.ReadAll(resp.Body)
 							if pErr != nil {
-								logDebugf("Failed to read response body for ping: %v", pErr)
+								dc.logCtx.logDebugf("Failed to read response body for ping: %v", pErr)
 							}
 
 							err = errors.New(string(b))
@@ -344,8 +347,8 @@ func (dc *diagnosticsComponent) pingHTTP(ctx context.Context, service ServiceTyp
 
 func (dc *diagnosticsComponent) Ping(opts PingOptions, cb PingCallback) (PendingOp, error) {
 	bucketName := ""
-	if dc.bucket != "" {
-		bucketName = redactMetaData(dc.bucket)
+	if bucket := dc.bucketName(); bucket != "" {
+		bucketName = redactMetaData(bucket)
 	}
 
 	ignoreMissingServices := false
@@ -395,9 +398,10 @@ func (dc *diagnosticsComponent) Ping(opts PingOptions, cb PingCallback) (Pending
 
 func (dc *diagnosticsComponent) endpointsFromCapiList(capiEpList []string) []string {
 	var epList []string
+	bucket := dc.bucketName()
 	for _, ep := range capiEpList {
 		// We escape the bucket name when we add it to the ep list so we need to do it here too.
-		epList = append(epList, strings.TrimRight(ep, "/"+url.PathEscape(dc.bucket)))
+		epList = append(epList, strings.TrimRight(ep, "/"+url.PathEscape(bucket)))
 	}
 
 	return epList
@@ -407,6 +411,7 @@ func (dc *diagnosticsComponent) endpointsFromCapiList(capiEpList []string) []str
 // Mainly containing a list of open connections and their current
 // states.
 func (dc *diagnosticsComponent) Diagnostics(opts DiagnosticsOptions) (*DiagnosticInfo, error) {
+	bucket := dc.bucketName()
 	for {
 		iter, err := dc.kvMux.PipelineSnapshot()
 		if err != nil {
@@ -440,8 +445,8 @@ func (dc *diagnosticsComponent) Diagnostics(opts DiagnosticsOptions) (*Diagnosti
 					ID:           fmt.Sprintf("%p", pipecli),
 					State:        pipecli.State(),
 				}
-				if dc.bucket != "" {
-					conn.Scope = redactMetaData(dc.bucket)
+				if bucket != "" {
+					conn.Scope = redactMetaData(bucket)
 				}
 				conns = append(conns, conn)
 			}
@@ -470,9 +475,11 @@ func (dc *diagnosticsComponent) Diagnostics(opts DiagnosticsOptions) (*Diagnosti
 		}
 		if iter.RevID() == endIter.RevID() {
 			return &DiagnosticInfo{
-				ConfigRev: iter.RevID(),
-				MemdConns: conns,
-				State:     state,
+				ConfigRev:   iter.RevID(),
+				MemdConns:   conns,
+				State:       state,
+				ClusterUUID: iter.ClusterUUID(),
+				ClusterName: iter.ClusterName(),
 			}, nil
 		}
 	}
@@ -482,7 +489,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 	for {
 		iter, err := dc.kvMux.PipelineSnapshot()
 		if err != nil {
-			logErrorf("failed to get pipeline snapshot: %v", err)
+			dc.logCtx.logErrorf("failed to get pipeline snapshot: %v", err)
 
 			shouldRetry, until := retryOrchMaybeRetry(op, NoPipelineSnapshotRetryReason)
 			if !shouldRetry {
@@ -508,7 +515,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 				for _, cli := range pipeline.clients {
 					err := cli.Error()
 					if err != nil {
-						logDebugf("Error found in client before config seen: %v", err)
+						dc.logCtx.logDebugf("Error found in client before config seen: %v", err)
 						connectErr = err
 
 						return true
@@ -524,13 +531,13 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 
 				// We don't care about timeouts, they don't tell us anything we want to know.
 				if pollerErr != nil && !errors.Is(pollerErr, ErrTimeout) {
-					logDebugf("Error found in poller before config seen: %v", pollerErr)
+					dc.logCtx.logDebugf("Error found in poller before config seen: %v", pollerErr)
 					connectErr = pollerErr
 				}
 			}
 
 			if connectErr == nil {
-				logDebugf("No config seen yet in kv muxer but no errors found.")
+				dc.logCtx.logDebugf("No config seen yet in kv muxer but no errors found.")
 			}
 		} else if revID > -1 {
 			expected := iter.NumPipelines()
@@ -554,7 +561,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 
 					err := cli.Error()
 					if err != nil {
-						logDebugf("Error found in client after config seen: %v", err)
+						dc.logCtx.logDebugf("Error found in client after config seen: %v", err)
 						connectErr = err
 
 						// If the desired state is degraded then we need to keep trying as a different client or pipeline
@@ -570,7 +577,7 @@ func (dc *diagnosticsComponent) checkKVReady(desiredState ClusterState, op *wait
 
 						// We don't care about timeouts, they don't tell us anything we want to know.
 						if pollerErr != nil && !errors.Is(pollerErr, ErrTimeout) {
-							logDebugf("Error found in poller after config seen: %v", pollerErr)
+							dc.logCtx.logDebugf("Error found in poller after config seen: %v", pollerErr)
 							connectErr = pollerErr
 						}
 					}
@@ -655,7 +662,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 	for {
 		clientMux := muxer.Get()
 		if clientMux.revID == -1 {
-			logDebugf("No config seen yet in http muxer.")
+			dc.logCtx.logDebugf("No config seen yet in http muxer.")
 		} else {
 			var epList []string
 			switch service {
@@ -693,7 +700,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 							return
 						}
 
-						logDebugf("Error returned for HTTP request for service %d: %v", service, err)
+						dc.logCtx.logDebugf("Error returned for HTTP request for service %d: %v", service, err)
 
 						if desiredState == ClusterStateOnline {
 							// Cancel this run entirely, we can't satisfy the requirements
@@ -702,7 +709,7 @@ func (dc *diagnosticsComponent) checkHTTPReady(ctx context.Context, service Serv
 						return
 					}
 					if resp.StatusCode != 200 {
-						logDebugf("Non-200 status code returned for HTTP request for service %d: %d", service, resp.StatusCode)
+						dc.logCtx.logDebugf("Non-200 status code returned for HTTP request for service %d: %d", service, resp.StatusCode)
 						if desiredState == ClusterStateOnline {
 							// Cancel this run entirely, we can't satisfy the requirements
 							cancel()
@@ -820,3 +827,86 @@ func (dc *diagnosticsComponent) WaitUntilReady(deadline time.Time, opts WaitUnti
 
 	return op, nil
 }
+
+// KvEndpointStats returns queue and connection counters for each kv endpoint.
+func (dc *diagnosticsComponent) KvEndpointStats() ([]KvEndpointStats, error) {
+	iter, err := dc.kvMux.PipelineSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []KvEndpointStats
+	iter.Iterate(0, func(pipeline *memdPipeline) bool {
+		queueStats := pipeline.queue.Stats()
+		endpoint := KvEndpointStats{
+			Address:        pipeline.Address(),
+			QueuedOps:      queueStats.NumItems,
+			QueuedBytes:    queueStats.NumBytes,
+			ConsumerStalls: queueStats.FullCount,
+		}
+
+		pipeline.clientsLock.Lock()
+		for _, pipecli := range pipeline.clients {
+			conn := KvConnectionStats{
+				ID:                  fmt.Sprintf("%p", pipecli),
+				State:               pipecli.State(),
+				CircuitBreakerState: CircuitBreakerStateDisabled,
+			}
+
+			pipecli.lock.Lock()
+			if pipecli.client != nil {
+				conn.InFlightOps = pipecli.client.InFlightCount()
+				conn.CircuitBreakerState = CircuitBreakerState(pipecli.client.breaker.State())
+				conn.Features = pipecli.client.Features()
+				conn.ConnectionID = pipecli.client.connID
+				conn.Compression = pipecli.client.CompressionStats()
+			}
+			pipecli.lock.Unlock()
+
+			endpoint.InFlightOps += conn.InFlightOps
+			endpoint.Compression.add(conn.Compression)
+			endpoint.Connections = append(endpoint.Connections, conn)
+		}
+		pipeline.clientsLock.Unlock()
+
+		endpoints = append(endpoints, endpoint)
+		return false
+	})
+
+	return endpoints, nil
+}
+
+// KvEndpointInfo returns the server version and negotiated features for each kv endpoint.
+func (dc *diagnosticsComponent) KvEndpointInfo() ([]KvEndpointInfo, error) {
+	iter, err := dc.kvMux.PipelineSnapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []KvEndpointInfo
+	iter.Iterate(0, func(pipeline *memdPipeline) bool {
+		endpoint := KvEndpointInfo{
+			Address: pipeline.Address(),
+		}
+
+		// Every connection to an endpoint negotiates the same features so we only need to find one which is connected.
+		pipeline.clientsLock.Lock()
+		for _, pipecli := range pipeline.clients {
+			pipecli.lock.Lock()
+			client := pipecli.client
+			pipecli.lock.Unlock()
+
+			if client != nil {
+				endpoint.ServerVersion = client.ServerVersion()
+				endpoint.Features = client.Features()
+				break
+			}
+		}
+		pipeline.clientsLock.Unlock()
+
+		endpoints = append(endpoints, endpoint)
+		return false
+	})
+
+	return endpoints, nil
+}
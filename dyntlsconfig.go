@@ -1,11 +1,67 @@
 package gocbcore
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/base64"
 	"net"
 )
 
+// TLSVerifyPeerCertificateFunc is invoked during every TLS handshake with the certificates presented by the server,
+// see tls.Config.VerifyPeerCertificate. verifiedChains is empty if certificate verification has been disabled.
+type TLSVerifyPeerCertificateFunc func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+
+// ParseTLSPinnedPublicKey parses a base64 encoded SHA-256 hash of a certificates SubjectPublicKeyInfo, as used by
+// the TLSPinnedPublicKeys config option.
+func ParseTLSPinnedPublicKey(pin string) ([]byte, error) {
+	hash, err := base64.StdEncoding.DecodeString(pin)
+	if err != nil || len(hash) != sha256.Size {
+		return nil, wrapError(errInvalidArgument, "pinned public key must be a base64 encoded sha256 hash")
+	}
+
+	return hash, nil
+}
+
+func makeVerifyPeerCertificate(pins [][]byte, verifyFn TLSVerifyPeerCertificateFunc) TLSVerifyPeerCertificateFunc {
+	if len(pins) == 0 {
+		return verifyFn
+	}
+
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		err := verifyPinnedPublicKeys(pins, rawCerts)
+		if err != nil {
+			return err
+		}
+
+		if verifyFn != nil {
+			return verifyFn(rawCerts, verifiedChains)
+		}
+
+		return nil
+	}
+}
+
+// verifyPinnedPublicKeys checks that at least one certificate presented by the server matches a pin.
+func verifyPinnedPublicKeys(pins [][]byte, rawCerts [][]byte) error {
+	for _, rawCert := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCert)
+		if err != nil {
+			return err
+		}
+
+		hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range pins {
+			if bytes.Equal(pin, hash[:]) {
+				return nil
+			}
+		}
+	}
+
+	return wrapError(errInvalidCertificate, "server certificate did not match any pinned public key")
+}
+
 type dynTLSConfig struct {
 	BaseConfig *tls.Config
 	Provider   func() *x509.CertPool
@@ -21,15 +77,11 @@ func (config dynTLSConfig) Clone() *dynTLSConfig {
 func (config dynTLSConfig) MakeForHost(serverName string) (*tls.Config, error) {
 	newConfig := config.BaseConfig.Clone()
 
+	// Without a provided pool we fall back to the system trust store, verification is only ever skipped when
+	// explicitly requested through the base config.
+	newConfig.RootCAs = nil
 	if config.Provider != nil {
-		rootCAs := config.Provider()
-		if rootCAs != nil {
-			newConfig.RootCAs = rootCAs
-			newConfig.InsecureSkipVerify = false
-		} else {
-			newConfig.RootCAs = nil
-			newConfig.InsecureSkipVerify = true
-		}
+		newConfig.RootCAs = config.Provider()
 	}
 
 	newConfig.ServerName = serverName
@@ -0,0 +1,86 @@
+package gocbcore
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/url"
+)
+
+func (suite *UnitTestSuite) TestVerifyPeerCertificatePinnedPublicKeys() {
+	certPEM, _ := suite.generateTestKeyPair()
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	suite.Require().Nil(err)
+
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(hash[:])
+
+	parsedPin, err := ParseTLSPinnedPublicKey(pin)
+	suite.Require().Nil(err)
+
+	var callbackInvoked bool
+	verifyFn := makeVerifyPeerCertificate([][]byte{parsedPin}, func([][]byte, [][]*x509.Certificate) error {
+		callbackInvoked = true
+		return nil
+	})
+	suite.Assert().Nil(verifyFn([][]byte{block.Bytes}, nil))
+	suite.Assert().True(callbackInvoked)
+
+	otherHash := sha256.Sum256([]byte("not a public key"))
+	verifyFn = makeVerifyPeerCertificate([][]byte{otherHash[:]}, nil)
+	suite.Assert().True(errors.Is(verifyFn([][]byte{block.Bytes}, nil), ErrInvalidCertificate))
+
+	suite.Assert().Nil(makeVerifyPeerCertificate(nil, nil))
+
+	config := &AgentConfig{}
+	err = config.FromConnStr("couchbases://10.112.192.101?tls_pinned_public_key=" + url.QueryEscape(pin))
+	suite.Require().Nil(err)
+	suite.Assert().Equal([][]byte{parsedPin}, config.TLSPinnedPublicKeys)
+
+	err = config.FromConnStr("couchbases://10.112.192.101?tls_pinned_public_key=squirrel")
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
+
+func (suite *UnitTestSuite) TestDynTLSConfigRootCAs() {
+	pool := x509.NewCertPool()
+
+	tlsConfig := createTLSConfig(&PasswordAuthProvider{}, nil, false, nil)
+	hostConfig, err := tlsConfig.MakeForHost("10.112.192.101")
+	suite.Require().Nil(err)
+	suite.Assert().Nil(hostConfig.RootCAs)
+	suite.Assert().False(hostConfig.InsecureSkipVerify)
+
+	// A provider returning no pool falls back to the system trust store rather than disabling verification.
+	tlsConfig = createTLSConfig(&PasswordAuthProvider{}, func() *x509.CertPool { return nil }, false, nil)
+	hostConfig, err = tlsConfig.MakeForHost("10.112.192.101")
+	suite.Require().Nil(err)
+	suite.Assert().Nil(hostConfig.RootCAs)
+	suite.Assert().False(hostConfig.InsecureSkipVerify)
+
+	tlsConfig = createTLSConfig(&PasswordAuthProvider{}, func() *x509.CertPool { return pool }, false, nil)
+	hostConfig, err = tlsConfig.Clone().MakeForHost("10.112.192.101")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(pool, hostConfig.RootCAs)
+	suite.Assert().False(hostConfig.InsecureSkipVerify)
+
+	tlsConfig = createTLSConfig(&PasswordAuthProvider{}, func() *x509.CertPool { return pool }, true, nil)
+	hostConfig, err = tlsConfig.MakeForHost("10.112.192.101")
+	suite.Require().Nil(err)
+	suite.Assert().Equal(pool, hostConfig.RootCAs)
+	suite.Assert().True(hostConfig.InsecureSkipVerify)
+	suite.Assert().Equal("10.112.192.101", hostConfig.ServerName)
+
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbases://10.112.192.101"))
+	suite.Assert().True(config.UseTLS)
+	suite.Assert().False(config.TLSSkipVerify)
+
+	config = &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbases://10.112.192.101?tls_skip_verify=true"))
+	suite.Assert().True(config.TLSSkipVerify)
+
+	suite.Assert().NotNil(config.FromConnStr("couchbases://10.112.192.101?tls_skip_verify=squirrel"))
+}
@@ -0,0 +1,56 @@
+package gocbcore
+
+import "time"
+
+// EndpointEventType describes a change in the state of a connection to a memd endpoint.
+type EndpointEventType uint32
+
+const (
+	// EndpointEventConnected indicates that a connection to the endpoint was established.
+	EndpointEventConnected = EndpointEventType(1)
+
+	// EndpointEventAuthenticated indicates that the connection authenticated successfully.
+	EndpointEventAuthenticated = EndpointEventType(2)
+
+	// EndpointEventBucketSelected indicates that a bucket was selected on the connection.
+	EndpointEventBucketSelected = EndpointEventType(3)
+
+	// EndpointEventDisconnected indicates that the connection was closed, either locally or by a failure. The event
+	// carries the error which caused the connection to be dropped, if any.
+	EndpointEventDisconnected = EndpointEventType(4)
+
+	// EndpointEventDeadConnection indicates that a DCP connection went for longer than DCPAgentConfig.MissedNoopLimit
+	// noop intervals without receiving anything, it is followed by EndpointEventDisconnected as the connection is
+	// closed before being reopened.
+	EndpointEventDeadConnection = EndpointEventType(5)
+)
+
+// EndpointEvent describes a single change in the state of a connection to a memd endpoint.
+type EndpointEvent struct {
+	Type         EndpointEventType
+	Address      string
+	ConnectionID string
+	BucketName   string
+	Error        error
+	Time         time.Time
+}
+
+// EndpointEventCallback is invoked for every connection lifecycle event emitted by an agent. It is invoked from the
+// goroutine which owns the connection and so must not block.
+// Volatile: This API is subject to change at any time.
+type EndpointEventCallback func(event EndpointEvent)
+
+func (client *memdClient) emitEndpointEvent(eventType EndpointEventType, bucketName string, err error) {
+	if client.eventCallback == nil {
+		return
+	}
+
+	client.eventCallback(EndpointEvent{
+		Type:         eventType,
+		Address:      client.Address(),
+		ConnectionID: client.connID,
+		BucketName:   bucketName,
+		Error:        err,
+		Time:         time.Now(),
+	})
+}
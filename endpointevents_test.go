@@ -0,0 +1,59 @@
+package gocbcore
+
+import (
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestEndpointEventCallback() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	eventsCh := make(chan EndpointEvent, 100)
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:     "default",
+		Auth:           PasswordAuthProvider{Username: "user", Password: "pass"},
+		AuthMechanisms: []AuthMechanism{PlainAuthMechanism},
+		EndpointEventCallback: func(event EndpointEvent) {
+			eventsCh <- event
+		},
+	})
+	defer agent.Close()
+
+	waitForEvent := func(eventType EndpointEventType) EndpointEvent {
+		timer := time.NewTimer(5 * time.Second)
+		defer timer.Stop()
+		for {
+			select {
+			case event := <-eventsCh:
+				if event.Type == eventType {
+					return event
+				}
+			case <-timer.C:
+				suite.T().Fatalf("Timed out waiting for endpoint event %d", eventType)
+			}
+		}
+	}
+
+	connected := waitForEvent(EndpointEventConnected)
+	suite.Assert().Equal(server.Address(), connected.Address)
+	suite.Assert().NotEmpty(connected.ConnectionID)
+	suite.Assert().False(connected.Time.IsZero())
+
+	authenticated := waitForEvent(EndpointEventAuthenticated)
+	suite.Assert().Equal(connected.ConnectionID, authenticated.ConnectionID)
+
+	selected := waitForEvent(EndpointEventBucketSelected)
+	suite.Assert().Equal("default", selected.BucketName)
+
+	// Dropping the connections from the server side should be reported along with the error.
+	server.Close()
+	disconnected := waitForEvent(EndpointEventDisconnected)
+	suite.Assert().Equal(connected.ConnectionID, disconnected.ConnectionID)
+	suite.Assert().NotNil(disconnected.Error)
+}
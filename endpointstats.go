@@ -0,0 +1,79 @@
+package gocbcore
+
+import "github.com/couchbase/gocbcore/v9/memd"
+
+// CircuitBreakerState represents the current state of a circuit breaker.
+type CircuitBreakerState uint32
+
+const (
+	// CircuitBreakerStateDisabled indicates that circuit breaking is disabled.
+	CircuitBreakerStateDisabled = CircuitBreakerState(circuitBreakerStateDisabled)
+
+	// CircuitBreakerStateClosed indicates that the circuit breaker is allowing requests through.
+	CircuitBreakerStateClosed = CircuitBreakerState(circuitBreakerStateClosed)
+
+	// CircuitBreakerStateHalfOpen indicates that the circuit breaker is allowing a canary request through.
+	CircuitBreakerStateHalfOpen = CircuitBreakerState(circuitBreakerStateHalfOpen)
+
+	// CircuitBreakerStateOpen indicates that the circuit breaker is rejecting requests.
+	CircuitBreakerStateOpen = CircuitBreakerState(circuitBreakerStateOpen)
+)
+
+// KvConnectionStats contains point-in-time counters for a single connection to a kv endpoint.
+type KvConnectionStats struct {
+	ID                  string
+	State               EndpointState
+	InFlightOps         int
+	CircuitBreakerState CircuitBreakerState
+
+	// Features is the list of HELLO features which were negotiated on this connection.
+	Features []memd.HelloFeature
+
+	// ConnectionID is the ID sent to the server in HELLO, this is the ID which appears in the server logs.
+	ConnectionID string
+
+	// Compression describes the compression performed by this connection since it was established.
+	Compression KvCompressionStats
+}
+
+// KvEndpointStats contains point-in-time counters for a single kv endpoint.
+type KvEndpointStats struct {
+	Address string
+
+	// QueuedOps is the number of operations waiting to be written to a connection.
+	QueuedOps int
+
+	// QueuedBytes is the approximate number of bytes that the queued operations will occupy on the wire.
+	QueuedBytes int
+
+	// InFlightOps is the number of operations which have been written and are awaiting a response,
+	// across all connections.
+	InFlightOps int
+
+	// ConsumerStalls is the number of times that an operation could not be queued because the
+	// connections were not keeping up with the rate of dispatch.
+	ConsumerStalls uint64
+
+	// Compression is the total of the compression counters for the endpoint's current connections, the counters
+	// for a connection are lost when it is replaced.
+	Compression KvCompressionStats
+
+	Connections []KvConnectionStats
+}
+
+// KvEndpointInfo describes the capabilities of a single kv endpoint, as reported by the connections made to it.
+type KvEndpointInfo struct {
+	Address string
+
+	// ServerVersion is the version banner reported by the server, this is empty if no connection has been
+	// established or the server did not report its version.
+	ServerVersion string
+
+	// Features is the list of HELLO features which were negotiated with the endpoint.
+	Features []memd.HelloFeature
+}
+
+// SupportsFeature returns whether the given HELLO feature was negotiated with the endpoint.
+func (info KvEndpointInfo) SupportsFeature(feature memd.HelloFeature) bool {
+	return checkSupportsFeature(info.Features, feature)
+}
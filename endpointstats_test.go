@@ -0,0 +1,62 @@
+package gocbcore
+
+import (
+	"encoding/binary"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestKvEndpointInfo() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	server.Handle(memd.CmdHello, func(req *memd.Packet) *memd.Packet {
+		features := make([]byte, 4)
+		binary.BigEndian.PutUint16(features, uint16(memd.FeatureSnappy))
+		binary.BigEndian.PutUint16(features[2:], uint16(memd.FeatureXattr))
+		return &memd.Packet{Status: memd.StatusSuccess, Value: features}
+	})
+	server.Handle(memd.CmdVersion, func(req *memd.Packet) *memd.Packet {
+		return &memd.Packet{Status: memd.StatusSuccess, Value: []byte("7.0.2-6703-enterprise")}
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:     "default",
+		UseCompression: true,
+	})
+	defer agent.Close()
+
+	// Once a kv operation succeeds every endpoint has a connected client.
+	suite.mustSet(agent, "key")
+
+	endpoints, err := agent.KvEndpointInfo()
+	suite.Require().Nil(err)
+	suite.Require().Len(endpoints, 1)
+
+	info := endpoints[0]
+	suite.Assert().Equal(server.Address(), info.Address)
+	suite.Assert().Equal("7.0.2-6703-enterprise", info.ServerVersion)
+	suite.Assert().ElementsMatch([]memd.HelloFeature{memd.FeatureSnappy, memd.FeatureXattr}, info.Features)
+	suite.Assert().True(info.SupportsFeature(memd.FeatureSnappy))
+	suite.Assert().False(info.SupportsFeature(memd.FeatureDurations))
+}
+
+func (suite *UnitTestSuite) TestKvEndpointInfoVersionUnsupported() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	// Failing to fetch the version must not fail bootstrap, the banner is only informational.
+	server.Handle(memd.CmdVersion, nil)
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	suite.mustSet(agent, "key")
+
+	endpoints, err := agent.KvEndpointInfo()
+	suite.Require().Nil(err)
+	suite.Require().Len(endpoints, 1)
+	suite.Assert().Empty(endpoints[0].ServerVersion)
+	suite.Assert().Empty(endpoints[0].Features)
+}
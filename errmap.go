@@ -4,10 +4,16 @@ import (
 	"encoding/json"
 	"strconv"
 	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
 )
 
 type kvErrorMapAttribute string
 
+// kvErrorMapVersion is the highest version of the error map that we understand, the server responds with the
+// highest version that it supports up to this.
+const kvErrorMapVersion = 2
+
 type kvErrorMapRetry struct {
 	Strategy    string
 	Interval    int
@@ -16,6 +22,21 @@ type kvErrorMapRetry struct {
 	MaxDuration int
 }
 
+// IsSet returns whether the error map provided a retry specification.
+func (retry kvErrorMapRetry) IsSet() bool {
+	return retry.Strategy != ""
+}
+
+// HasExpired returns whether an operation first dispatched at dispatchTime has been retrying for longer than the
+// maximum duration permitted by the specification.
+func (retry kvErrorMapRetry) HasExpired(dispatchTime time.Time) bool {
+	if retry.MaxDuration <= 0 || dispatchTime.IsZero() {
+		return false
+	}
+
+	return time.Since(dispatchTime) > time.Duration(retry.MaxDuration)*time.Millisecond
+}
+
 func (retry kvErrorMapRetry) CalculateRetryDelay(retryCount uint32) time.Duration {
 	duraCeil := time.Duration(retry.Ceil) * time.Millisecond
 
@@ -115,3 +136,78 @@ func parseKvErrorMap(data []byte) (*kvErrorMap, error) {
 
 	return &errMap, nil
 }
+
+// ErrorMapRetrySpec describes how the server advises that operations failing with a status should be retried.
+// Volatile: This API is subject to change at any time.
+type ErrorMapRetrySpec struct {
+	// Strategy is one of constant, linear or exponential, it is empty if the error map does not specify one.
+	Strategy    string
+	Interval    time.Duration
+	After       time.Duration
+	Ceil        time.Duration
+	MaxDuration time.Duration
+}
+
+// ErrorMapEntry describes a single status code in the server's error map.
+// Volatile: This API is subject to change at any time.
+type ErrorMapEntry struct {
+	Name        string
+	Description string
+	Attributes  []string
+	Retry       ErrorMapRetrySpec
+}
+
+// HasAttribute returns whether the server advertises the attribute, such as "temp" or "auto-retry", for the status.
+func (entry ErrorMapEntry) HasAttribute(attribute string) bool {
+	for _, attr := range entry.Attributes {
+		if attr == attribute {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorMap is the error map fetched from the server, it describes the status codes which the server may respond with.
+// Volatile: This API is subject to change at any time.
+type ErrorMap struct {
+	Version  int
+	Revision int
+	Errors   map[memd.StatusCode]ErrorMapEntry
+}
+
+// Lookup returns the entry for status, or false if the status is not in the error map.
+func (errMap ErrorMap) Lookup(status memd.StatusCode) (ErrorMapEntry, bool) {
+	entry, ok := errMap.Errors[status]
+	return entry, ok
+}
+
+func (errMap *kvErrorMap) toPublic() *ErrorMap {
+	out := &ErrorMap{
+		Version:  errMap.Version,
+		Revision: errMap.Revision,
+		Errors:   make(map[memd.StatusCode]ErrorMapEntry, len(errMap.Errors)),
+	}
+
+	for code, errData := range errMap.Errors {
+		entry := ErrorMapEntry{
+			Name:        errData.Name,
+			Description: errData.Description,
+			Attributes:  make([]string, len(errData.Attributes)),
+			Retry: ErrorMapRetrySpec{
+				Strategy:    errData.Retry.Strategy,
+				Interval:    time.Duration(errData.Retry.Interval) * time.Millisecond,
+				After:       time.Duration(errData.Retry.After) * time.Millisecond,
+				Ceil:        time.Duration(errData.Retry.Ceil) * time.Millisecond,
+				MaxDuration: time.Duration(errData.Retry.MaxDuration) * time.Millisecond,
+			},
+		}
+		for i, attr := range errData.Attributes {
+			entry.Attributes[i] = string(attr)
+		}
+
+		out.Errors[memd.StatusCode(code)] = entry
+	}
+
+	return out
+}
@@ -1,10 +1,14 @@
 package gocbcore
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/couchbase/gocbcore/v9/jcbmock"
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
 )
 
 func TestKvErrorConstantRetry(t *testing.T) {
@@ -148,3 +152,172 @@ func (suite *StandardTestSuite) TestKvErrorMap7ff1() {
 func (suite *StandardTestSuite) TestKvErrorMap7ff2() {
 	suite.testKvErrorMapGeneric(0x7ff2)
 }
+
+func (suite *UnitTestSuite) TestKvErrorMapRetrySpec() {
+	errMgr := newErrMapManager("default")
+	errMgr.StoreErrorMap([]byte(`{
+		"version": 2,
+		"revision": 1,
+		"errors": {
+			"7ff0": {"name": "AUTO", "desc": "", "attrs": ["auto-retry"],
+				"retry": {"strategy": "constant", "interval": 5, "after": 10, "ceil": 50, "max-duration": 1000}},
+			"7ff1": {"name": "NOW", "desc": "", "attrs": ["retry-now"],
+				"retry": {"strategy": "constant", "interval": 5, "after": 10, "ceil": 50, "max-duration": 1000}},
+			"7ff2": {"name": "NONE", "desc": "", "attrs": ["temp"]}
+		}
+	}`))
+
+	spec, shouldRetry := errMgr.RetrySpec(0x7ff0)
+	suite.Assert().True(shouldRetry)
+	suite.Assert().True(spec.IsSet())
+	suite.Assert().Equal(10*time.Millisecond, spec.CalculateRetryDelay(0))
+	suite.Assert().Equal(5*time.Millisecond, spec.CalculateRetryDelay(1))
+	suite.Assert().False(spec.HasExpired(time.Now()))
+	suite.Assert().True(spec.HasExpired(time.Now().Add(-2 * time.Second)))
+
+	spec, shouldRetry = errMgr.RetrySpec(0x7ff1)
+	suite.Assert().True(shouldRetry)
+	suite.Assert().False(spec.IsSet())
+
+	_, shouldRetry = errMgr.RetrySpec(0x7ff2)
+	suite.Assert().False(shouldRetry)
+}
+
+func (suite *UnitTestSuite) TestKvErrorMapRetrySpecDelay() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	server.Handle(memd.CmdGetErrorMap, func(req *memd.Packet) *memd.Packet {
+		return &memd.Packet{Status: memd.StatusSuccess, Value: []byte(`{"version": 2, "revision": 1, "errors": {
+			"7ff0": {"name": "AUTO", "desc": "", "attrs": ["auto-retry"],
+				"retry": {"strategy": "constant", "interval": 80, "after": 80, "ceil": 100, "max-duration": 5000}}}}`)}
+	})
+
+	var getAttempts uint32
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		if atomic.AddUint32(&getAttempts, 1) <= 3 {
+			return &memd.Packet{Status: 0x7ff0}
+		}
+		return defaultGet(req)
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	// The strategy still decides whether to retry but the delay comes from the error map, not the strategy.
+	strategy := &errMapTestRetryStrategy{}
+	start := time.Now()
+	errCh := make(chan error, 1)
+	_, err := agent.Get(GetOptions{
+		Key:           []byte("missing"),
+		RetryStrategy: strategy,
+		Deadline:      time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(<-errCh, ErrDocumentNotFound))
+
+	suite.Assert().Equal(uint32(4), atomic.LoadUint32(&getAttempts))
+	suite.Assert().Equal(3, strategy.retries)
+	for _, reason := range strategy.reasons {
+		suite.Assert().Equal(KVErrMapRetryReason, reason)
+	}
+	suite.Assert().True(time.Since(start) >= 240*time.Millisecond)
+}
+
+func (suite *UnitTestSuite) TestKvErrorMapRetrySpecMaxDurationRequeued() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	server.Handle(memd.CmdGetErrorMap, func(req *memd.Packet) *memd.Packet {
+		return &memd.Packet{Status: memd.StatusSuccess, Value: []byte(`{"version": 2, "revision": 1, "errors": {
+			"7ff0": {"name": "AUTO", "desc": "", "attrs": ["auto-retry"],
+				"retry": {"strategy": "constant", "interval": 10, "after": 10, "ceil": 10, "max-duration": 100}}}}`)}
+	})
+	server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		return &memd.Packet{Status: 0x7ff0}
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+	suite.mustSet(agent, "key")
+
+	// Requests which wait on a collection ID are first sent by requeueing them, the max duration must still apply.
+	errCh := make(chan error, 1)
+	req := &memdQRequest{
+		Packet: memd.Packet{
+			Magic:   memd.CmdMagicReq,
+			Command: memd.CmdGet,
+			Key:     []byte("key"),
+		},
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			errCh <- err
+		},
+		RetryStrategy: NewBestEffortRetryStrategy(nil),
+	}
+	agent.kvMux.RequeueDirect(req, false)
+
+	select {
+	case err := <-errCh:
+		suite.Assert().NotNil(err)
+	case <-time.After(5 * time.Second):
+		req.Cancel()
+		suite.T().Fatalf("Request was retried beyond the error map max duration")
+	}
+}
+
+func (suite *UnitTestSuite) TestKvErrorMapPublic() {
+	errMgr := newErrMapManager("default")
+	suite.Assert().Nil(errMgr.ErrorMap())
+
+	errMgr.StoreErrorMap([]byte(`{
+		"version": 2,
+		"revision": 3,
+		"errors": {
+			"7ff0": {"name": "AUTO", "desc": "auto retried", "attrs": ["auto-retry", "temp"],
+				"retry": {"strategy": "constant", "interval": 5, "after": 10, "ceil": 50, "max-duration": 1000}},
+			"1": {"name": "KEY_ENOENT", "desc": "key not found", "attrs": ["item-only"]}
+		}
+	}`))
+
+	errMap := errMgr.ErrorMap()
+	suite.Require().NotNil(errMap)
+	suite.Assert().Equal(2, errMap.Version)
+	suite.Assert().Equal(3, errMap.Revision)
+	suite.Assert().Len(errMap.Errors, 2)
+
+	entry, ok := errMap.Lookup(0x7ff0)
+	suite.Require().True(ok)
+	suite.Assert().Equal(ErrorMapEntry{
+		Name:        "AUTO",
+		Description: "auto retried",
+		Attributes:  []string{"auto-retry", "temp"},
+		Retry: ErrorMapRetrySpec{
+			Strategy:    "constant",
+			Interval:    5 * time.Millisecond,
+			After:       10 * time.Millisecond,
+			Ceil:        50 * time.Millisecond,
+			MaxDuration: time.Second,
+		},
+	}, entry)
+	suite.Assert().True(entry.HasAttribute("temp"))
+	suite.Assert().False(entry.HasAttribute("item-only"))
+
+	entry, ok = errMap.Lookup(memd.StatusKeyNotFound)
+	suite.Require().True(ok)
+	suite.Assert().Equal("KEY_ENOENT", entry.Name)
+	suite.Assert().Equal(ErrorMapRetrySpec{}, entry.Retry)
+
+	_, ok = errMap.Lookup(0x7ff1)
+	suite.Assert().False(ok)
+
+	// The returned map is a copy.
+	entry.Attributes[0] = "changed"
+	suite.Assert().Equal("item-only", errMgr.ErrorMap().Errors[memd.StatusKeyNotFound].Attributes[0])
+}
@@ -2,13 +2,21 @@ package gocbcore
 
 import (
 	"encoding/json"
+	"errors"
+	"sync"
+	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
 )
 
+// defaultRateLimitedRetryAfter is how long to wait before retrying a rate limited kv operation when the error map does
+// not specify a delay.
+const defaultRateLimitedRetryAfter = time.Second
+
 type errMapComponent struct {
 	kvErrorMap kvErrorMapPtr
 	bucketName string
+	bucketLock sync.Mutex
 }
 
 func newErrMapManager(bucketName string) *errMapComponent {
@@ -17,6 +25,18 @@ func newErrMapManager(bucketName string) *errMapComponent {
 	}
 }
 
+func (errMgr *errMapComponent) SetBucketName(bucketName string) {
+	errMgr.bucketLock.Lock()
+	errMgr.bucketName = bucketName
+	errMgr.bucketLock.Unlock()
+}
+
+func (errMgr *errMapComponent) getBucketName() string {
+	errMgr.bucketLock.Lock()
+	defer errMgr.bucketLock.Unlock()
+	return errMgr.bucketName
+}
+
 func (errMgr *errMapComponent) getKvErrMapData(code memd.StatusCode) *kvErrorMapError {
 	errMap := errMgr.kvErrorMap.Get()
 	if errMap != nil {
@@ -27,6 +47,16 @@ func (errMgr *errMapComponent) getKvErrMapData(code memd.StatusCode) *kvErrorMap
 	return nil
 }
 
+// ErrorMap returns a copy of the error map, or nil if no error map has been fetched yet.
+func (errMgr *errMapComponent) ErrorMap() *ErrorMap {
+	errMap := errMgr.kvErrorMap.Get()
+	if errMap == nil {
+		return nil
+	}
+
+	return errMap.toPublic()
+}
+
 func (errMgr *errMapComponent) StoreErrorMap(mapBytes []byte) {
 	errMap, err := parseKvErrorMap(mapBytes)
 	if err != nil {
@@ -51,16 +81,38 @@ func (errMgr *errMapComponent) StoreErrorMap(mapBytes []byte) {
 }
 
 func (errMgr *errMapComponent) ShouldRetry(status memd.StatusCode) bool {
+	_, shouldRetry := errMgr.RetrySpec(status)
+	return shouldRetry
+}
+
+// RetrySpec returns the retry specification that the error map provides for status, along with whether the error map
+// indicates that operations failing with status should be retried at all.
+func (errMgr *errMapComponent) RetrySpec(status memd.StatusCode) (kvErrorMapRetry, bool) {
 	kvErrData := errMgr.getKvErrMapData(status)
 	if kvErrData != nil {
 		for _, attr := range kvErrData.Attributes {
-			if attr == "auto-retry" || attr == "retry-now" || attr == "retry-later" {
-				return true
+			switch attr {
+			case "retry-now":
+				// Retry now overrides any specification and retries immediately.
+				return kvErrorMapRetry{}, true
+			case "auto-retry", "retry-later":
+				return kvErrData.Retry, true
 			}
 		}
 	}
 
-	return false
+	return kvErrorMapRetry{}, false
+}
+
+// RateLimitedRetryAfter returns how long to wait before retrying an operation which was rate limited with status. The
+// server does not say when the limit will allow the request so the error map retry specification is used if it has
+// one, otherwise defaultRateLimitedRetryAfter.
+func (errMgr *errMapComponent) RateLimitedRetryAfter(status memd.StatusCode) time.Duration {
+	if spec, _ := errMgr.RetrySpec(status); spec.IsSet() && spec.After > 0 {
+		return time.Duration(spec.After) * time.Millisecond
+	}
+
+	return defaultRateLimitedRetryAfter
 }
 
 func (errMgr *errMapComponent) EnhanceKvError(err error, resp *memdQResponse, req *memdQRequest) error {
@@ -70,10 +122,11 @@ func (errMgr *errMapComponent) EnhanceKvError(err error, resp *memdQResponse, re
 
 	if req != nil {
 		enhErr.DocumentKey = string(req.Key)
-		enhErr.BucketName = errMgr.bucketName
+		enhErr.BucketName = errMgr.getBucketName()
 		enhErr.ScopeName = req.ScopeName
 		enhErr.CollectionName = req.CollectionName
 		enhErr.CollectionID = req.CollectionID
+		enhErr.VbucketID = req.Vbucket
 
 		retryCount, reasons := req.Retries()
 		enhErr.RetryReasons = reasons
@@ -83,12 +136,18 @@ func (errMgr *errMapComponent) EnhanceKvError(err error, resp *memdQResponse, re
 		enhErr.LastDispatchedTo = connInfo.lastDispatchedTo
 		enhErr.LastDispatchedFrom = connInfo.lastDispatchedFrom
 		enhErr.LastConnectionID = connInfo.lastConnectionID
+		enhErr.LastDispatchedAt = connInfo.lastDispatchedAt
+		enhErr.DispatchedTo = req.DispatchedTo()
 	}
 
 	if resp != nil {
 		enhErr.StatusCode = resp.Status
 		enhErr.Opaque = resp.Opaque
 
+		if errors.Is(err, ErrRateLimitedFailure) {
+			enhErr.RetryAfter = errMgr.RateLimitedRetryAfter(resp.Status)
+		}
+
 		errMapData := errMgr.getKvErrMapData(enhErr.StatusCode)
 		if errMapData != nil {
 			enhErr.ErrorName = errMapData.Name
@@ -123,6 +182,10 @@ func translateMemdError(err error, req *memdQRequest) error {
 	case ErrMemdAuthError:
 		return errAuthenticationFailure
 	case ErrMemdTmpFail:
+		// Prior to 7.0 the server reports a locked document as a temporary failure for GetAndLock.
+		if req.Command == memd.CmdGetLocked {
+			return errDocumentLocked
+		}
 		return errTemporaryFailure
 	case ErrMemdBusy:
 		return errTemporaryFailure
@@ -133,6 +196,11 @@ func translateMemdError(err error, req *memdQRequest) error {
 		return errDocumentExists
 	case ErrMemdCollectionNotFound:
 		return errCollectionNotFound
+	case ErrMemdRateLimitedNetworkIngress, ErrMemdRateLimitedNetworkEgress, ErrMemdRateLimitedMaxConnections,
+		ErrMemdRateLimitedMaxCommands:
+		return errRateLimitedFailure
+	case ErrMemdRateLimitedScopeSizeLimitExceeded:
+		return errQuotaLimitedFailure
 	case ErrMemdUnknownCommand:
 		return errUnsupportedOperation
 	case ErrMemdNotSupported:
@@ -63,9 +63,20 @@ var (
 	// ErrBucketAlreadySelected occurs when SelectBucket is called when a bucket is already selected..
 	ErrBucketAlreadySelected = errors.New("bucket already selected")
 
+	// ErrNoConfig occurs when routing information is requested before a cluster config has been received.
+	ErrNoConfig = errors.New("no cluster config has been received")
+
+	// ErrVbucketNotAssigned occurs when routing information is requested for a copy of a vbucket which is not
+	// currently assigned to a server, such as a replica which has not been created yet.
+	ErrVbucketNotAssigned = errors.New("vbucket is not assigned to a server")
+
 	// ErrShutdown occurs when operations are performed on a previously closed Agent.
 	ErrShutdown = errors.New("connection shut down")
 
+	// ErrNotConnected occurs when connection information is requested from an Agent created with LazyConnect
+	// which has not yet started connecting.
+	ErrNotConnected = errors.New("agent has not connected yet")
+
 	// ErrOverload occurs when too many operations are dispatched and all queues are full.
 	ErrOverload = errors.New("queue overflowed")
 
@@ -77,6 +88,9 @@ var (
 
 	// ErrNotMyVBucket occurs when an operation is sent to a node which does not own the vbucket.
 	ErrNotMyVBucket = errors.New("not my vbucket")
+
+	// ErrUnhealthy occurs when a health check finds that one or more of its requirements are not met.
+	ErrUnhealthy = errors.New("health requirements not met")
 )
 
 // Shared Error Definitions RFC#58@15
@@ -107,6 +121,19 @@ var (
 	ErrScopeNotFound       = errors.New("scope not found")
 	ErrIndexNotFound       = errors.New("index not found")
 
+	// ErrRateLimitedFailure occurs when the server rejects a request because a rate limit has been exceeded. The
+	// request was not applied and can be retried, the error context includes how long the server asked to wait first.
+	ErrRateLimitedFailure = errors.New("rate limited failure")
+
+	// ErrQuotaLimitedFailure occurs when the server rejects a request because a quota, such as the amount of data a
+	// scope may hold, has been reached. Retrying will not succeed until usage is reduced or the quota is raised.
+	ErrQuotaLimitedFailure = errors.New("quota limited failure")
+
+	// ErrBucketRecreated occurs when an operation which depends on a mutation token is interrupted by the bucket being
+	// deleted and recreated with the same name. The token refers to the history of the previous bucket.
+	// Volatile: This API is subject to change at any time.
+	ErrBucketRecreated = errors.New("bucket recreated")
+
 	ErrIndexExists = errors.New("index exists")
 )
 
@@ -167,6 +194,10 @@ var (
 	ErrDataverseExists = errors.New("dataverse exists")
 
 	ErrLinkNotFound = errors.New("link not found")
+
+	// ErrLinkExists is returned when creating an analytics link which already exists.
+	// Volatile: This API is subject to change at any time.
+	ErrLinkExists = errors.New("link exists")
 )
 
 // Search Error Definitions RFC#58@15
@@ -52,4 +52,14 @@ var (
 
 	// ErrStreamIDNotEnabled occurs when dcp operations are performed using a stream ID when stream IDs are not enabled.
 	ErrStreamIDNotEnabled = errors.New("stream IDs have not been enabled on this stream")
+
+	// ErrDCPConnectionDead occurs when a DCP connection is closed because it has not received a noop, or anything
+	// else, for longer than DCPAgentConfig.MissedNoopLimit noop intervals. It is carried by the endpoint events
+	// emitted for the connection.
+	// Volatile: This API is subject to change at any time.
+	ErrDCPConnectionDead = errors.New("dcp connection is dead")
+
+	// ErrStreamIDsExhausted occurs when a DCPStreamManager has no free stream IDs left to allocate.
+	// Volatile: This API is subject to change at any time.
+	ErrStreamIDsExhausted = errors.New("all stream IDs are in use")
 )
@@ -79,6 +79,21 @@ var (
 	// yet ready to accept operations on behalf of a particular bucket.
 	ErrMemdNotInitialized = makeKvStatusError(memd.StatusNotInitialized)
 
+	// ErrMemdRateLimitedNetworkIngress occurs when the amount of data sent to the server has exceeded the allowed rate.
+	ErrMemdRateLimitedNetworkIngress = makeKvStatusError(memd.StatusRateLimitedNetworkIngress)
+
+	// ErrMemdRateLimitedNetworkEgress occurs when the amount of data sent from the server has exceeded the allowed rate.
+	ErrMemdRateLimitedNetworkEgress = makeKvStatusError(memd.StatusRateLimitedNetworkEgress)
+
+	// ErrMemdRateLimitedMaxConnections occurs when the maximum number of connections to the server has been exceeded.
+	ErrMemdRateLimitedMaxConnections = makeKvStatusError(memd.StatusRateLimitedMaxConnections)
+
+	// ErrMemdRateLimitedMaxCommands occurs when the number of requests sent to the server has exceeded the allowed rate.
+	ErrMemdRateLimitedMaxCommands = makeKvStatusError(memd.StatusRateLimitedMaxCommands)
+
+	// ErrMemdRateLimitedScopeSizeLimitExceeded occurs when a scope has reached its data size quota.
+	ErrMemdRateLimitedScopeSizeLimitExceeded = makeKvStatusError(memd.StatusRateLimitedScopeSizeLimitExceeded)
+
 	// ErrMemdUnknownCommand occurs when an unknown operation is sent to a server.
 	ErrMemdUnknownCommand = makeKvStatusError(memd.StatusUnknownCommand)
 
@@ -1,9 +1,13 @@
 package gocbcore
 
 import (
+	"encoding/json"
 	"errors"
+	"sync/atomic"
+	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
 
 	"github.com/couchbase/gocbcore/v9/jcbmock"
 )
@@ -87,3 +91,125 @@ func (suite *StandardTestSuite) TestEnhancedErrorOp() {
 		"bucket":  suite.BucketName,
 	}))
 }
+
+func (suite *UnitTestSuite) TestKeyValueErrorContext() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	errCh := make(chan error, 1)
+	_, err := agent.Get(GetOptions{
+		Key:      []byte("keyThatWontExist"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	err = <-errCh
+
+	suite.Assert().True(errors.Is(err, ErrDocumentNotFound))
+
+	var kvErr *KeyValueError
+	suite.Require().True(errors.As(err, &kvErr))
+	suite.Assert().Equal(memd.StatusKeyNotFound, kvErr.StatusCode)
+	suite.Assert().Equal("keyThatWontExist", kvErr.DocumentKey)
+	suite.Assert().Equal("default", kvErr.BucketName)
+	suite.Assert().Equal(server.Address(), kvErr.LastDispatchedTo)
+	suite.Assert().NotEmpty(kvErr.LastConnectionID)
+	suite.Assert().False(kvErr.LastDispatchedAt.IsZero())
+
+	vbID, err := agent.kvMux.KeyToVbucket([]byte("keyThatWontExist"))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(vbID, kvErr.VbucketID)
+
+	// vBucket 0 is a valid vBucket so must still be reported.
+	kvErr = &KeyValueError{InnerError: errDocumentNotFound, StatusCode: memd.StatusKeyNotFound}
+	suite.Assert().Contains(kvErr.Error(), `"vbucket_id":0`)
+	errBytes, err := json.Marshal(kvErr)
+	suite.Require().Nil(err)
+	suite.Assert().Contains(string(errBytes), `"vbucket_id":0`)
+}
+
+func (suite *UnitTestSuite) TestTimeoutErrorDispatchHistory() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	// The server never responds so the operation times out whilst in flight.
+	server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		return nil
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	errCh := make(chan error, 1)
+	_, err := agent.Get(GetOptions{
+		Key:      []byte("key"),
+		Deadline: time.Now().Add(500 * time.Millisecond),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+
+	var timeoutErr *TimeoutError
+	suite.Require().True(errors.As(<-errCh, &timeoutErr))
+	suite.Assert().True(timeoutErr.InFlight)
+	suite.Assert().Equal([]string{server.Address()}, timeoutErr.DispatchedTo)
+
+	// A request which was never written anywhere has no dispatch history.
+	req := &memdQRequest{}
+	timeoutErr = req.makeTimeoutError(errUnambiguousTimeout, "Get", time.Now())
+	suite.Assert().False(timeoutErr.InFlight)
+	suite.Assert().Empty(timeoutErr.DispatchedTo)
+}
+
+func (suite *UnitTestSuite) TestKeyValueRateLimited() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var gets uint32
+	server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		atomic.AddUint32(&gets, 1)
+		return &memd.Packet{Status: memd.StatusRateLimitedMaxCommands}
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	errCh := make(chan error, 1)
+	_, err := agent.Get(GetOptions{
+		Key:      []byte("key"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	err = <-errCh
+
+	suite.Assert().True(errors.Is(err, ErrRateLimitedFailure))
+	var kvErr *KeyValueError
+	suite.Require().True(errors.As(err, &kvErr))
+	suite.Assert().Equal(memd.StatusRateLimitedMaxCommands, kvErr.StatusCode)
+	suite.Assert().Equal(defaultRateLimitedRetryAfter, kvErr.RetryAfter)
+
+	// When retrying, the request must not be sent again until the server is willing to accept it.
+	atomic.StoreUint32(&gets, 0)
+	_, err = agent.Get(GetOptions{
+		Key:           []byte("key"),
+		Deadline:      time.Now().Add(300 * time.Millisecond),
+		RetryStrategy: NewBestEffortRetryStrategy(nil),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+
+	var timeoutErr *TimeoutError
+	suite.Require().True(errors.As(<-errCh, &timeoutErr))
+	suite.Assert().Equal([]RetryReason{RateLimitedRetryReason}, timeoutErr.RetryReasons)
+	suite.Assert().Equal(uint32(1), atomic.LoadUint32(&gets))
+
+	quotaErr := translateMemdError(ErrMemdRateLimitedScopeSizeLimitExceeded, &memdQRequest{})
+	suite.Assert().True(errors.Is(quotaErr, ErrQuotaLimitedFailure))
+}
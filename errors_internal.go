@@ -1,9 +1,11 @@
 package gocbcore
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
@@ -48,6 +50,50 @@ func (err SubDocumentError) Unwrap() error {
 	return err.InnerError
 }
 
+// ConfigValidationError is returned when a config is invalid, it lists every problem which was found.
+type ConfigValidationError struct {
+	Problems []string
+}
+
+// Error returns the string representation of this error.
+func (err ConfigValidationError) Error() string {
+	return "invalid config: " + strings.Join(err.Problems, "; ")
+}
+
+// Unwrap returns the underlying reason for the error.
+func (err ConfigValidationError) Unwrap() error {
+	return ErrInvalidArgument
+}
+
+// FeatureNotAvailableError is returned when a feature is used with a cluster which is known to be too old to support
+// it, rather than the request being rejected by the server with a less descriptive error.
+type FeatureNotAvailableError struct {
+	Feature         ClusterFeature
+	RequiredVersion ClusterVersion
+
+	// ServerVersion is the version of the oldest node which the feature would have been used with.
+	ServerVersion ClusterVersion
+
+	// InnerError is the error which would otherwise have been returned, if any, such as ErrCollectionsUnsupported.
+	InnerError error
+}
+
+// Error returns the string representation of this error.
+func (err FeatureNotAvailableError) Error() string {
+	return fmt.Sprintf("%s: %s requires server version %s or later but the server version is %s",
+		ErrFeatureNotAvailable.Error(), err.Feature, err.RequiredVersion, err.ServerVersion)
+}
+
+// Is allows errors.Is to match this error against ErrFeatureNotAvailable.
+func (err FeatureNotAvailableError) Is(target error) bool {
+	return target == ErrFeatureNotAvailable
+}
+
+// Unwrap returns the error which would otherwise have been returned.
+func (err FeatureNotAvailableError) Unwrap() error {
+	return err.InnerError
+}
+
 func serializeError(err error) string {
 	errBytes, serErr := json.Marshal(err)
 	if serErr != nil {
@@ -56,7 +102,20 @@ func serializeError(err error) string {
 	return string(errBytes)
 }
 
-// KeyValueError wraps key-value errors that occur within the SDK.
+// marshalErrorJSON is json.Marshal without HTML escaping so that log redaction tags remain readable.
+func marshalErrorJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// KeyValueError wraps key-value errors that occur within the SDK. The InnerError is one of the sentinel errors such as
+// ErrDocumentNotFound and can be checked using errors.Is, the context of the failure can be retrieved using errors.As.
 type KeyValueError struct {
 	InnerError         error
 	StatusCode         memd.StatusCode
@@ -65,6 +124,7 @@ type KeyValueError struct {
 	ScopeName          string
 	CollectionName     string
 	CollectionID       uint32
+	VbucketID          uint16
 	ErrorName          string
 	ErrorDescription   string
 	Opaque             uint32
@@ -72,9 +132,32 @@ type KeyValueError struct {
 	Ref                string
 	RetryReasons       []RetryReason
 	RetryAttempts      uint32
+	RetryAfter         time.Duration
 	LastDispatchedTo   string
 	LastDispatchedFrom string
 	LastConnectionID   string
+	LastDispatchedAt   time.Time
+	DispatchedTo       []string
+}
+
+// redacted tags the document key as user data and the keyspace as metadata when the error is logged.
+func (e KeyValueError) redacted() interface{} {
+	if e.DocumentKey != "" {
+		e.DocumentKey = redactUserData(e.DocumentKey)
+	}
+	if isLogRedactionLevelFull() {
+		if e.BucketName != "" {
+			e.BucketName = redactMetaData(e.BucketName)
+		}
+		if e.ScopeName != "" {
+			e.ScopeName = redactMetaData(e.ScopeName)
+		}
+		if e.CollectionName != "" {
+			e.CollectionName = redactMetaData(e.CollectionName)
+		}
+	}
+
+	return e
 }
 
 // MarshalJSON implements the Marshaler interface.
@@ -87,6 +170,7 @@ func (e KeyValueError) MarshalJSON() ([]byte, error) {
 		ScopeName          string          `json:"scope,omitempty"`
 		CollectionName     string          `json:"collection,omitempty"`
 		CollectionID       uint32          `json:"collection_id,omitempty"`
+		VbucketID          uint16          `json:"vbucket_id"`
 		ErrorName          string          `json:"error_name,omitempty"`
 		ErrorDescription   string          `json:"error_description,omitempty"`
 		Opaque             uint32          `json:"opaque,omitempty"`
@@ -94,9 +178,12 @@ func (e KeyValueError) MarshalJSON() ([]byte, error) {
 		Ref                string          `json:"ref,omitempty"`
 		RetryReasons       []RetryReason   `json:"retry_reasons,omitempty"`
 		RetryAttempts      uint32          `json:"retry_attempts,omitempty"`
+		RetryAfter         string          `json:"retry_after,omitempty"`
 		LastDispatchedTo   string          `json:"last_dispatched_to,omitempty"`
 		LastDispatchedFrom string          `json:"last_dispatched_from,omitempty"`
 		LastConnectionID   string          `json:"last_connection_id,omitempty"`
+		LastDispatchedAt   string          `json:"last_dispatched_at,omitempty"`
+		DispatchedTo       []string        `json:"dispatched_to,omitempty"`
 	}{
 		InnerError:         e.InnerError.Error(),
 		StatusCode:         e.StatusCode,
@@ -105,6 +192,7 @@ func (e KeyValueError) MarshalJSON() ([]byte, error) {
 		ScopeName:          e.ScopeName,
 		CollectionName:     e.CollectionName,
 		CollectionID:       e.CollectionID,
+		VbucketID:          e.VbucketID,
 		ErrorName:          e.ErrorName,
 		ErrorDescription:   e.ErrorDescription,
 		Opaque:             e.Opaque,
@@ -112,15 +200,18 @@ func (e KeyValueError) MarshalJSON() ([]byte, error) {
 		Ref:                e.Ref,
 		RetryReasons:       e.RetryReasons,
 		RetryAttempts:      e.RetryAttempts,
+		RetryAfter:         formatErrorDuration(e.RetryAfter),
 		LastDispatchedTo:   e.LastDispatchedTo,
 		LastDispatchedFrom: e.LastDispatchedFrom,
 		LastConnectionID:   e.LastConnectionID,
+		LastDispatchedAt:   formatErrorTime(e.LastDispatchedAt),
+		DispatchedTo:       e.DispatchedTo,
 	})
 }
 
 // Error returns the string representation of this error.
 func (e KeyValueError) Error() string {
-	errBytes, serErr := json.Marshal(struct {
+	errBytes, serErr := marshalErrorJSON(struct {
 		InnerError         error           `json:"-"`
 		StatusCode         memd.StatusCode `json:"status_code,omitempty"`
 		DocumentKey        string          `json:"document_key,omitempty"`
@@ -128,6 +219,7 @@ func (e KeyValueError) Error() string {
 		ScopeName          string          `json:"scope,omitempty"`
 		CollectionName     string          `json:"collection,omitempty"`
 		CollectionID       uint32          `json:"collection_id,omitempty"`
+		VbucketID          uint16          `json:"vbucket_id"`
 		ErrorName          string          `json:"error_name,omitempty"`
 		ErrorDescription   string          `json:"error_description,omitempty"`
 		Opaque             uint32          `json:"opaque,omitempty"`
@@ -135,9 +227,12 @@ func (e KeyValueError) Error() string {
 		Ref                string          `json:"ref,omitempty"`
 		RetryReasons       []RetryReason   `json:"retry_reasons,omitempty"`
 		RetryAttempts      uint32          `json:"retry_attempts,omitempty"`
+		RetryAfter         string          `json:"retry_after,omitempty"`
 		LastDispatchedTo   string          `json:"last_dispatched_to,omitempty"`
 		LastDispatchedFrom string          `json:"last_dispatched_from,omitempty"`
 		LastConnectionID   string          `json:"last_connection_id,omitempty"`
+		LastDispatchedAt   string          `json:"last_dispatched_at,omitempty"`
+		DispatchedTo       []string        `json:"dispatched_to,omitempty"`
 	}{
 		InnerError:         e.InnerError,
 		StatusCode:         e.StatusCode,
@@ -146,6 +241,7 @@ func (e KeyValueError) Error() string {
 		ScopeName:          e.ScopeName,
 		CollectionName:     e.CollectionName,
 		CollectionID:       e.CollectionID,
+		VbucketID:          e.VbucketID,
 		ErrorName:          e.ErrorName,
 		ErrorDescription:   e.ErrorDescription,
 		Opaque:             e.Opaque,
@@ -153,9 +249,12 @@ func (e KeyValueError) Error() string {
 		Ref:                e.Ref,
 		RetryReasons:       e.RetryReasons,
 		RetryAttempts:      e.RetryAttempts,
+		RetryAfter:         formatErrorDuration(e.RetryAfter),
 		LastDispatchedTo:   e.LastDispatchedTo,
 		LastDispatchedFrom: e.LastDispatchedFrom,
 		LastConnectionID:   e.LastConnectionID,
+		LastDispatchedAt:   formatErrorTime(e.LastDispatchedAt),
+		DispatchedTo:       e.DispatchedTo,
 	})
 	if serErr != nil {
 		logErrorf("failed to serialize error to json: %s", serErr.Error())
@@ -253,6 +352,7 @@ type N1QLError struct {
 	Endpoint        string
 	RetryReasons    []RetryReason
 	RetryAttempts   uint32
+	RetryAfter      time.Duration
 }
 
 // MarshalJSON implements the Marshaler interface.
@@ -265,6 +365,7 @@ func (e N1QLError) MarshalJSON() ([]byte, error) {
 		Endpoint        string          `json:"endpoint,omitempty"`
 		RetryReasons    []RetryReason   `json:"retry_reasons,omitempty"`
 		RetryAttempts   uint32          `json:"retry_attempts,omitempty"`
+		RetryAfter      string          `json:"retry_after,omitempty"`
 	}{
 		InnerError:      e.InnerError.Error(),
 		Statement:       e.Statement,
@@ -273,6 +374,7 @@ func (e N1QLError) MarshalJSON() ([]byte, error) {
 		Endpoint:        e.Endpoint,
 		RetryReasons:    e.RetryReasons,
 		RetryAttempts:   e.RetryAttempts,
+		RetryAfter:      formatErrorDuration(e.RetryAfter),
 	})
 }
 
@@ -286,6 +388,7 @@ func (e N1QLError) Error() string {
 		Endpoint        string          `json:"endpoint,omitempty"`
 		RetryReasons    []RetryReason   `json:"retry_reasons,omitempty"`
 		RetryAttempts   uint32          `json:"retry_attempts,omitempty"`
+		RetryAfter      string          `json:"retry_after,omitempty"`
 	}{
 		InnerError:      e.InnerError,
 		Statement:       e.Statement,
@@ -294,6 +397,7 @@ func (e N1QLError) Error() string {
 		Endpoint:        e.Endpoint,
 		RetryReasons:    e.RetryReasons,
 		RetryAttempts:   e.RetryAttempts,
+		RetryAfter:      formatErrorDuration(e.RetryAfter),
 	})
 	if serErr != nil {
 		logErrorf("failed to serialize error to json: %s", serErr.Error())
@@ -322,6 +426,7 @@ type AnalyticsError struct {
 	Endpoint        string
 	RetryReasons    []RetryReason
 	RetryAttempts   uint32
+	RetryAfter      time.Duration
 }
 
 // MarshalJSON implements the Marshaler interface.
@@ -334,6 +439,7 @@ func (e AnalyticsError) MarshalJSON() ([]byte, error) {
 		Endpoint        string               `json:"endpoint,omitempty"`
 		RetryReasons    []RetryReason        `json:"retry_reasons,omitempty"`
 		RetryAttempts   uint32               `json:"retry_attempts,omitempty"`
+		RetryAfter      string               `json:"retry_after,omitempty"`
 	}{
 		InnerError:      e.InnerError.Error(),
 		Statement:       e.Statement,
@@ -342,6 +448,7 @@ func (e AnalyticsError) MarshalJSON() ([]byte, error) {
 		Endpoint:        e.Endpoint,
 		RetryReasons:    e.RetryReasons,
 		RetryAttempts:   e.RetryAttempts,
+		RetryAfter:      formatErrorDuration(e.RetryAfter),
 	})
 }
 
@@ -355,6 +462,7 @@ func (e AnalyticsError) Error() string {
 		Endpoint        string               `json:"endpoint,omitempty"`
 		RetryReasons    []RetryReason        `json:"retry_reasons,omitempty"`
 		RetryAttempts   uint32               `json:"retry_attempts,omitempty"`
+		RetryAfter      string               `json:"retry_after,omitempty"`
 	}{
 		InnerError:      e.InnerError,
 		Statement:       e.Statement,
@@ -363,6 +471,7 @@ func (e AnalyticsError) Error() string {
 		Endpoint:        e.Endpoint,
 		RetryReasons:    e.RetryReasons,
 		RetryAttempts:   e.RetryAttempts,
+		RetryAfter:      formatErrorDuration(e.RetryAfter),
 	})
 	if serErr != nil {
 		logErrorf("failed to serialize error to json: %s", serErr.Error())
@@ -386,6 +495,7 @@ type SearchError struct {
 	Endpoint         string
 	RetryReasons     []RetryReason
 	RetryAttempts    uint32
+	RetryAfter       time.Duration
 }
 
 // MarshalJSON implements the Marshaler interface.
@@ -399,6 +509,7 @@ func (e SearchError) MarshalJSON() ([]byte, error) {
 		Endpoint         string        `json:"endpoint,omitempty"`
 		RetryReasons     []RetryReason `json:"retry_reasons,omitempty"`
 		RetryAttempts    uint32        `json:"retry_attempts,omitempty"`
+		RetryAfter       string        `json:"retry_after,omitempty"`
 	}{
 		InnerError:       e.InnerError.Error(),
 		IndexName:        e.IndexName,
@@ -408,6 +519,7 @@ func (e SearchError) MarshalJSON() ([]byte, error) {
 		Endpoint:         e.Endpoint,
 		RetryReasons:     e.RetryReasons,
 		RetryAttempts:    e.RetryAttempts,
+		RetryAfter:       formatErrorDuration(e.RetryAfter),
 	})
 }
 
@@ -422,6 +534,7 @@ func (e SearchError) Error() string {
 		Endpoint         string        `json:"endpoint,omitempty"`
 		RetryReasons     []RetryReason `json:"retry_reasons,omitempty"`
 		RetryAttempts    uint32        `json:"retry_attempts,omitempty"`
+		RetryAfter       string        `json:"retry_after,omitempty"`
 	}{
 		InnerError:       e.InnerError,
 		IndexName:        e.IndexName,
@@ -431,6 +544,7 @@ func (e SearchError) Error() string {
 		Endpoint:         e.Endpoint,
 		RetryReasons:     e.RetryReasons,
 		RetryAttempts:    e.RetryAttempts,
+		RetryAfter:       formatErrorDuration(e.RetryAfter),
 	})
 	if serErr != nil {
 		logErrorf("failed to serialize error to json: %s", serErr.Error())
@@ -508,6 +622,14 @@ type TimeoutError struct {
 	LastDispatchedTo   string
 	LastDispatchedFrom string
 	LastConnectionID   string
+
+	// DispatchedTo lists every server that the operation was written to, it is empty if the operation was never
+	// dispatched, for example because the circuit breaker was open.
+	DispatchedTo []string
+
+	// InFlight indicates that the operation had been written to a server and was waiting for a response when it
+	// timed out.
+	InFlight bool
 }
 
 type timeoutError struct {
@@ -520,6 +642,8 @@ type timeoutError struct {
 	LastDispatchedTo   string        `json:"r,omitempty"`
 	LastDispatchedFrom string        `json:"l,omitempty"`
 	LastConnectionID   string        `json:"c,omitempty"`
+	DispatchedTo       []string      `json:"d,omitempty"`
+	InFlight           bool          `json:"f,omitempty"`
 }
 
 // MarshalJSON implements the Marshaler interface.
@@ -534,6 +658,8 @@ func (err *TimeoutError) MarshalJSON() ([]byte, error) {
 		LastDispatchedTo:   err.LastDispatchedTo,
 		LastDispatchedFrom: err.LastDispatchedFrom,
 		LastConnectionID:   err.LastConnectionID,
+		DispatchedTo:       err.DispatchedTo,
+		InFlight:           err.InFlight,
 	}
 
 	return json.Marshal(toMarshal)
@@ -557,6 +683,8 @@ func (err *TimeoutError) UnmarshalJSON(data []byte) error {
 	err.LastDispatchedTo = tErr.LastDispatchedTo
 	err.LastDispatchedFrom = tErr.LastDispatchedFrom
 	err.LastConnectionID = tErr.LastConnectionID
+	err.DispatchedTo = tErr.DispatchedTo
+	err.InFlight = tErr.InFlight
 
 	return nil
 }
@@ -585,6 +713,22 @@ func (err ncError) Unwrap() error {
 	return err.InnerError
 }
 
+func formatErrorDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+
+	return d.String()
+}
+
+func formatErrorTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339Nano)
+}
+
 func isErrorStatus(err error, code memd.StatusCode) bool {
 	var kvErr *KeyValueError
 	if errors.As(err, &kvErr) {
@@ -598,6 +742,11 @@ var (
 	// operation was cancelled due to the circuit breaker being open.
 	errCircuitBreakerOpen = errors.New("circuit breaker open")
 	errNoCCCPHosts        = errors.New("no cccp hosts available")
+	// errGCCCPUnavailable is returned by the cccp poller when cluster level config can no longer be fetched, such as
+	// when the cluster has been downgraded to a version which does not support it.
+	errGCCCPUnavailable = errors.New("gcccp polling is unavailable")
+	// errBucketChanged is used to fail requests which were dispatched before the agent switched bucket.
+	errBucketChanged = wrapError(errShutdown, "the selected bucket changed whilst the request was in progress")
 )
 
 // This list contains protected versions of all the errors we throw
@@ -626,6 +775,9 @@ var (
 	errIndexExists           = ncError{ErrIndexExists}
 	errGCCCPInUse            = ncError{ErrGCCCPInUse}
 	errNotMyVBucket          = ncError{ErrNotMyVBucket}
+	errRateLimitedFailure    = ncError{ErrRateLimitedFailure}
+	errQuotaLimitedFailure   = ncError{ErrQuotaLimitedFailure}
+	errBucketRecreated       = ncError{ErrBucketRecreated}
 
 	errDocumentNotFound                  = ncError{ErrDocumentNotFound}
 	errDocumentUnretrievable             = ncError{ErrDocumentUnretrievable}
@@ -668,6 +820,7 @@ var (
 	errDatasetExists      = ncError{ErrDatasetExists}
 	errDataverseExists    = ncError{ErrDataverseExists}
 	errLinkNotFound       = ncError{ErrLinkNotFound}
+	errLinkExists         = ncError{ErrLinkExists}
 
 	errViewNotFound           = ncError{ErrViewNotFound}
 	errDesignDocumentNotFound = ncError{ErrDesignDocumentNotFound}
@@ -685,7 +838,12 @@ var (
 	errInvalidCertificate     = ncError{ErrInvalidCertificate}
 	errCollectionsUnsupported = ncError{ErrCollectionsUnsupported}
 	errBucketAlreadySelected  = ncError{ErrBucketAlreadySelected}
+	errNoConfig               = ncError{ErrNoConfig}
+	errVbucketNotAssigned     = ncError{ErrVbucketNotAssigned}
 	errShutdown               = ncError{ErrShutdown}
+	errNotConnected           = ncError{ErrNotConnected}
 	errOverload               = ncError{ErrOverload}
 	errStreamIDNotEnabled     = ncError{ErrStreamIDNotEnabled}
+	errStreamIDsExhausted     = ncError{ErrStreamIDsExhausted}
+	errDCPConnectionDead      = ncError{ErrDCPConnectionDead}
 )
@@ -0,0 +1,234 @@
+package gocbcore
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+type getDispatchFn func(opts GetOptions, cb GetCallback) (PendingOp, error)
+
+// getCoalescer deduplicates concurrent Get operations for the same document so that they are satisfied by a
+// single network round trip. Every caller receives the same GetResult so callers must not modify it.
+type getCoalescer struct {
+	lock     sync.Mutex
+	groups   map[coalescedGetKey]*coalescedGet
+	dispatch getDispatchFn
+	timeouts *kvTimeoutComponent
+	wheel    *timerWheel
+}
+
+// coalescedGetKey identifies the Gets which can share a request, these must be for the same document as the same
+// user and be retried by the same retry strategy instance.
+type coalescedGetKey struct {
+	collectionID   uint32
+	scopeName      string
+	collectionName string
+	user           string
+	key            string
+	retryStrategy  RetryStrategy
+}
+
+type coalescedGet struct {
+	key      coalescedGetKey
+	op       PendingOp
+	waiters  []*coalescedGetWaiter
+	deadline time.Time
+}
+
+type coalescedGetWaiter struct {
+	parent *getCoalescer
+	group  *coalescedGet
+	cb     GetCallback
+	timer  opTimer
+}
+
+func newGetCoalescer(dispatch getDispatchFn, timeouts *kvTimeoutComponent) *getCoalescer {
+	wheel := timeouts.Wheel()
+	if wheel == nil {
+		// Waiter deadlines are always tracked on the wheel, the goroutine only runs whilst there are timers pending.
+		wheel = newTimerWheel(timerWheelDefaultTick, timerWheelDefaultSlots)
+	}
+
+	return &getCoalescer{
+		groups:   make(map[coalescedGetKey]*coalescedGet),
+		dispatch: dispatch,
+		timeouts: timeouts,
+		wheel:    wheel,
+	}
+}
+
+func newCoalescedGetKey(opts GetOptions) coalescedGetKey {
+	return coalescedGetKey{
+		collectionID:   opts.CollectionID,
+		scopeName:      opts.ScopeName,
+		collectionName: opts.CollectionName,
+		user:           string(opts.User),
+		key:            string(opts.Key),
+		retryStrategy:  opts.RetryStrategy,
+	}
+}
+
+// canJoin returns whether a waiter with deadline can share the request for this group, the request must not time
+// out before the waiter does.
+func (group *coalescedGet) canJoin(deadline time.Time) bool {
+	if group.deadline.IsZero() {
+		return true
+	}
+
+	return !deadline.IsZero() && !deadline.After(group.deadline)
+}
+
+func (gc *getCoalescer) Get(opts GetOptions, cb GetCallback) (PendingOp, error) {
+	// The retry strategy is keyed on its identity, which we only have when it's a pointer. Strategies held by value
+	// could carry state that isn't safe to compare.
+	if opts.RetryStrategy != nil && reflect.TypeOf(opts.RetryStrategy).Kind() != reflect.Ptr {
+		return gc.dispatch(opts, cb)
+	}
+
+	// Waiters track their own deadlines so the default has to be applied here, otherwise a request without a deadline
+	// would look like it can never time out before a waiter does.
+	opts.Deadline = gc.timeouts.Deadline(opts.Deadline)
+
+	key := newCoalescedGetKey(opts)
+
+	gc.lock.Lock()
+	group, ok := gc.groups[key]
+	if ok && group.canJoin(opts.Deadline) {
+		waiter := gc.addWaiterLocked(group, cb, opts.Deadline)
+		gc.lock.Unlock()
+		return waiter, nil
+	}
+
+	// Any existing group is left to complete for its current waiters, new waiters join this group instead.
+	group = &coalescedGet{
+		key:      key,
+		deadline: opts.Deadline,
+	}
+	gc.groups[key] = group
+	waiter := gc.addWaiterLocked(group, cb, opts.Deadline)
+	gc.lock.Unlock()
+
+	// Each waiter has its own deadline, no waiter is allowed to join with a deadline later than that of the request
+	// so the request deadline is always the latest of them. The request is also cancelled once nobody is waiting on
+	// it anymore.
+	op, err := gc.dispatch(opts, func(res *GetResult, err error) {
+		gc.complete(group, res, err)
+	})
+	if err != nil {
+		gc.lock.Lock()
+		gc.removeGroupLocked(group)
+		waiters := group.waiters
+		group.waiters = nil
+		gc.lock.Unlock()
+
+		for _, w := range waiters {
+			w.stopTimer()
+			if w != waiter {
+				w.cb(nil, err)
+			}
+		}
+
+		return nil, err
+	}
+
+	gc.lock.Lock()
+	group.op = op
+	abandoned := len(group.waiters) == 0
+	gc.lock.Unlock()
+
+	if abandoned {
+		op.Cancel()
+	}
+
+	return waiter, nil
+}
+
+func (gc *getCoalescer) addWaiterLocked(group *coalescedGet, cb GetCallback, deadline time.Time) *coalescedGetWaiter {
+	waiter := &coalescedGetWaiter{
+		parent: gc,
+		group:  group,
+		cb:     cb,
+	}
+	group.waiters = append(group.waiters, waiter)
+
+	if !deadline.IsZero() {
+		start := time.Now()
+		waiter.timer = gc.wheel.AfterFunc(deadline.Sub(start), func() {
+			waiter.cancel(&TimeoutError{
+				InnerError:   errUnambiguousTimeout,
+				OperationID:  "Get",
+				TimeObserved: time.Since(start),
+			})
+		})
+	}
+
+	return waiter
+}
+
+func (gc *getCoalescer) removeGroupLocked(group *coalescedGet) {
+	if gc.groups[group.key] == group {
+		delete(gc.groups, group.key)
+	}
+}
+
+func (gc *getCoalescer) complete(group *coalescedGet, res *GetResult, err error) {
+	gc.lock.Lock()
+	gc.removeGroupLocked(group)
+	waiters := group.waiters
+	group.waiters = nil
+	gc.lock.Unlock()
+
+	for _, waiter := range waiters {
+		waiter.stopTimer()
+		waiter.cb(res, err)
+	}
+}
+
+func (w *coalescedGetWaiter) stopTimer() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+func (w *coalescedGetWaiter) cancel(err error) {
+	gc := w.parent
+	group := w.group
+
+	gc.lock.Lock()
+	idx := -1
+	for i, waiter := range group.waiters {
+		if waiter == w {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		// We've already been completed.
+		gc.lock.Unlock()
+		return
+	}
+
+	group.waiters = append(group.waiters[:idx], group.waiters[idx+1:]...)
+	var abandonedOp PendingOp
+	if len(group.waiters) == 0 {
+		// Nobody else is interested in this result so stop any further waiters from joining and cancel the request.
+		gc.removeGroupLocked(group)
+		abandonedOp = group.op
+	}
+	gc.lock.Unlock()
+
+	w.stopTimer()
+
+	if abandonedOp != nil {
+		abandonedOp.Cancel()
+	}
+
+	w.cb(nil, err)
+}
+
+// Cancel cancels this callers interest in the operation, the underlying request is only cancelled once
+// every caller sharing it has cancelled.
+func (w *coalescedGetWaiter) Cancel() {
+	w.cancel(errRequestCanceled)
+}
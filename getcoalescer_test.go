@@ -0,0 +1,202 @@
+package gocbcore
+
+import (
+	"errors"
+	"time"
+)
+
+type testCoalescedOp struct {
+	cb        GetCallback
+	cancelled bool
+}
+
+func (op *testCoalescedOp) Cancel() {
+	op.cancelled = true
+	op.cb(nil, errRequestCanceled)
+}
+
+func (suite *UnitTestSuite) TestGetCoalescerSharesRequest() {
+	var ops []*testCoalescedOp
+	gc := newGetCoalescer(func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+		op := &testCoalescedOp{cb: cb}
+		ops = append(ops, op)
+		return op, nil
+	}, newKvTimeoutComponent(0, newTimerWheel(time.Millisecond, 64)))
+
+	var results []*GetResult
+	cb := func(res *GetResult, err error) {
+		suite.Assert().Nil(err)
+		results = append(results, res)
+	}
+
+	_, err := gc.Get(GetOptions{Key: []byte("key")}, cb)
+	suite.Require().Nil(err)
+	_, err = gc.Get(GetOptions{Key: []byte("key")}, cb)
+	suite.Require().Nil(err)
+	_, err = gc.Get(GetOptions{Key: []byte("other")}, cb)
+	suite.Require().Nil(err)
+
+	suite.Require().Len(ops, 2)
+
+	res := &GetResult{Value: []byte("value")}
+	ops[0].cb(res, nil)
+
+	suite.Require().Len(results, 2)
+	suite.Assert().Equal(res, results[0])
+	suite.Assert().Equal(res, results[1])
+
+	// Once completed a new Get should trigger a new request.
+	_, err = gc.Get(GetOptions{Key: []byte("key")}, cb)
+	suite.Require().Nil(err)
+	suite.Assert().Len(ops, 3)
+}
+
+func (suite *UnitTestSuite) TestGetCoalescerCancel() {
+	var ops []*testCoalescedOp
+	gc := newGetCoalescer(func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+		op := &testCoalescedOp{cb: cb}
+		ops = append(ops, op)
+		return op, nil
+	}, newKvTimeoutComponent(0, newTimerWheel(time.Millisecond, 64)))
+
+	var errs []error
+	cb := func(res *GetResult, err error) {
+		errs = append(errs, err)
+	}
+
+	op1, err := gc.Get(GetOptions{Key: []byte("key")}, cb)
+	suite.Require().Nil(err)
+	op2, err := gc.Get(GetOptions{Key: []byte("key")}, cb)
+	suite.Require().Nil(err)
+	suite.Require().Len(ops, 1)
+
+	op1.Cancel()
+	suite.Assert().False(ops[0].cancelled)
+	suite.Require().Len(errs, 1)
+	suite.Assert().True(errors.Is(errs[0], ErrRequestCanceled))
+
+	op2.Cancel()
+	suite.Assert().True(ops[0].cancelled)
+	suite.Assert().Len(errs, 2)
+}
+
+func (suite *UnitTestSuite) TestGetCoalescerWaiterTimeout() {
+	deadline := time.Now().Add(10 * time.Millisecond)
+	gc := newGetCoalescer(func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+		suite.Assert().Equal(deadline, opts.Deadline)
+		return &testCoalescedOp{cb: cb}, nil
+	}, newKvTimeoutComponent(0, newTimerWheel(time.Millisecond, 64)))
+
+	errCh := make(chan error, 1)
+	_, err := gc.Get(GetOptions{Key: []byte("key"), Deadline: deadline},
+		func(res *GetResult, err error) {
+			errCh <- err
+		})
+	suite.Require().Nil(err)
+
+	err = <-errCh
+	suite.Assert().True(errors.Is(err, ErrTimeout))
+}
+
+func (suite *UnitTestSuite) TestGetCoalescerKey() {
+	var ops []*testCoalescedOp
+	gc := newGetCoalescer(func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+		op := &testCoalescedOp{cb: cb}
+		ops = append(ops, op)
+		return op, nil
+	}, newKvTimeoutComponent(0, newTimerWheel(time.Millisecond, 64)))
+
+	cb := func(res *GetResult, err error) {}
+	strategy := NewBestEffortRetryStrategy(nil)
+	for _, opts := range []GetOptions{
+		{Key: []byte("key")},
+		{Key: []byte("key"), Priority: OperationPriorityHigh},
+		{Key: []byte("key"), CollectionID: 8},
+		{Key: []byte("key"), User: []byte("user")},
+		{Key: []byte("key"), RetryStrategy: strategy},
+		{Key: []byte("key"), RetryStrategy: strategy},
+		{Key: []byte("key"), RetryStrategy: NewBestEffortRetryStrategy(nil)},
+		{Key: []byte("key"), RetryStrategy: testValueRetryStrategy{}},
+		{Key: []byte("key"), RetryStrategy: testValueRetryStrategy{}},
+	} {
+		_, err := gc.Get(opts, cb)
+		suite.Require().Nil(err)
+	}
+
+	// Retry strategies are compared by identity, strategies which aren't pointers are never coalesced.
+	suite.Assert().Len(ops, 7)
+}
+
+type testValueRetryStrategy struct{}
+
+func (rs testValueRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
+	return &NoRetryRetryAction{}
+}
+
+func (suite *UnitTestSuite) TestGetCoalescerDeadline() {
+	var deadlines []time.Time
+	gc := newGetCoalescer(func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+		deadlines = append(deadlines, opts.Deadline)
+		return &testCoalescedOp{cb: cb}, nil
+	}, newKvTimeoutComponent(0, newTimerWheel(time.Millisecond, 64)))
+
+	cb := func(res *GetResult, err error) {}
+	early := time.Now().Add(time.Minute)
+	late := early.Add(time.Minute)
+
+	var waiters []PendingOp
+	for _, deadline := range []time.Time{late, early, {}} {
+		waiter, err := gc.Get(GetOptions{Key: []byte("key"), Deadline: deadline}, cb)
+		suite.Require().Nil(err)
+		waiters = append(waiters, waiter)
+	}
+
+	// The last waiter would outlive the first request so cannot share it.
+	suite.Assert().Equal([]time.Time{late, {}}, deadlines)
+
+	for _, waiter := range waiters {
+		waiter.Cancel()
+	}
+}
+
+func (suite *UnitTestSuite) TestGetCoalescerDefaultDeadline() {
+	var deadlines []time.Time
+	gc := newGetCoalescer(func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+		deadlines = append(deadlines, opts.Deadline)
+		return &testCoalescedOp{cb: cb}, nil
+	}, newKvTimeoutComponent(time.Minute, newTimerWheel(time.Millisecond, 64)))
+
+	cb := func(res *GetResult, err error) {}
+	first, err := gc.Get(GetOptions{Key: []byte("key")}, cb)
+	suite.Require().Nil(err)
+	suite.Require().Len(deadlines, 1)
+	suite.Assert().False(deadlines[0].IsZero())
+
+	// The first request times out after the default timeout, so a waiter which would outlive it can't share it.
+	second, err := gc.Get(GetOptions{Key: []byte("key"), Deadline: time.Now().Add(time.Hour)}, cb)
+	suite.Require().Nil(err)
+	suite.Assert().Len(deadlines, 2)
+
+	first.Cancel()
+	second.Cancel()
+}
+
+func (suite *UnitTestSuite) TestGetCoalescerNilWheel() {
+	gc := newGetCoalescer(func(opts GetOptions, cb GetCallback) (PendingOp, error) {
+		return &testCoalescedOp{cb: cb}, nil
+	}, nil)
+
+	errCh := make(chan error, 1)
+	_, err := gc.Get(GetOptions{Key: []byte("key"), Deadline: time.Now().Add(time.Millisecond)},
+		func(res *GetResult, err error) {
+			errCh <- err
+		})
+	suite.Require().Nil(err)
+
+	select {
+	case err := <-errCh:
+		suite.Assert().True(errors.Is(err, ErrUnambiguousTimeout), err)
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Timed out waiting for the waiter to time out")
+	}
+}
@@ -0,0 +1,41 @@
+package gocbcore
+
+import (
+	"net/http"
+	"time"
+)
+
+// HealthRequirements specifies the conditions which must all hold for an agent to be considered healthy.
+// Requirements left at their zero value are not checked.
+type HealthRequirements struct {
+	// MinKvConnections is the minimum number of kv connections which must be in the connected state.
+	MinKvConnections int
+
+	// ServiceTypes is the list of services which must be reachable, a service is reachable when at least
+	// one of its endpoints responds successfully to a ping.
+	ServiceTypes []ServiceType
+
+	// MaxConfigAge is the maximum amount of time which may have passed since a cluster config was last received.
+	MaxConfigAge time.Duration
+}
+
+// HealthHandler returns a http.Handler which responds with 200 OK when the agent meets the supplied health
+// requirements and 503 Service Unavailable otherwise, making it suitable to back readiness or liveness probes.
+// The deadline for the check is taken from the incoming request context.
+func (agent *Agent) HealthHandler(reqs HealthRequirements) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		healthy, err := agent.Healthy(req.Context(), reqs)
+		if !healthy {
+			msg := "unhealthy"
+			if err != nil {
+				msg = err.Error()
+			}
+			http.Error(w, msg, http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
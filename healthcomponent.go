@@ -0,0 +1,151 @@
+package gocbcore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultHealthCheckTimeout = 5 * time.Second
+
+type lastConfigTimeProvider interface {
+	LastConfigTime() time.Time
+}
+
+type healthComponent struct {
+	diagnostics *diagnosticsComponent
+	cfgProvider lastConfigTimeProvider
+}
+
+func newHealthComponent(diagnostics *diagnosticsComponent, cfgProvider lastConfigTimeProvider) *healthComponent {
+	return &healthComponent{
+		diagnostics: diagnostics,
+		cfgProvider: cfgProvider,
+	}
+}
+
+type healthPingResult struct {
+	result *PingResult
+	err    error
+}
+
+// Healthy checks the agent against the supplied requirements. When any requirement is not met false is returned
+// along with an error wrapping ErrUnhealthy which describes every failed requirement.
+func (hc *healthComponent) Healthy(ctx context.Context, reqs HealthRequirements) (bool, error) {
+	var failures []string
+
+	if reqs.MaxConfigAge > 0 {
+		lastConfig := hc.cfgProvider.LastConfigTime()
+		if lastConfig.IsZero() {
+			failures = append(failures, "no cluster config has been received")
+		} else if age := time.Since(lastConfig); age > reqs.MaxConfigAge {
+			failures = append(failures, fmt.Sprintf("cluster config is %s old, maximum allowed is %s", age, reqs.MaxConfigAge))
+		}
+	}
+
+	if reqs.MinKvConnections > 0 {
+		diag, err := hc.diagnostics.Diagnostics(DiagnosticsOptions{})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("failed to fetch diagnostics: %v", err))
+		} else {
+			connected := 0
+			for _, conn := range diag.MemdConns {
+				if conn.State == EndpointStateConnected {
+					connected++
+				}
+			}
+			if connected < reqs.MinKvConnections {
+				failures = append(failures, fmt.Sprintf("%d of %d required kv connections are connected",
+					connected, reqs.MinKvConnections))
+			}
+		}
+	}
+
+	if len(reqs.ServiceTypes) > 0 {
+		pingFailures, err := hc.pingServices(ctx, reqs.ServiceTypes)
+		if err != nil {
+			return false, err
+		}
+		failures = append(failures, pingFailures...)
+	}
+
+	if len(failures) > 0 {
+		return false, wrapError(ErrUnhealthy, strings.Join(failures, "; "))
+	}
+
+	return true, nil
+}
+
+func (hc *healthComponent) pingServices(ctx context.Context, serviceTypes []ServiceType) ([]string, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultHealthCheckTimeout)
+	}
+
+	resultCh := make(chan healthPingResult, 1)
+	op, err := hc.diagnostics.Ping(PingOptions{
+		KVDeadline:   deadline,
+		CbasDeadline: deadline,
+		N1QLDeadline: deadline,
+		FtsDeadline:  deadline,
+		CapiDeadline: deadline,
+		MgmtDeadline: deadline,
+		ServiceTypes: serviceTypes,
+	}, func(result *PingResult, err error) {
+		resultCh <- healthPingResult{
+			result: result,
+			err:    err,
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res healthPingResult
+	select {
+	case <-ctx.Done():
+		op.Cancel()
+		return nil, ctx.Err()
+	case res = <-resultCh:
+	}
+
+	if res.err != nil {
+		return []string{fmt.Sprintf("ping failed: %v", res.err)}, nil
+	}
+
+	var failures []string
+	for _, serviceType := range serviceTypes {
+		reachable := false
+		for _, endpoint := range res.result.Services[serviceType] {
+			if endpoint.State == PingStateOK {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			failures = append(failures, fmt.Sprintf("%s service is not reachable", healthServiceName(serviceType)))
+		}
+	}
+
+	return failures, nil
+}
+
+func healthServiceName(serviceType ServiceType) string {
+	switch serviceType {
+	case MemdService:
+		return "kv"
+	case MgmtService:
+		return "mgmt"
+	case CapiService:
+		return "views"
+	case N1qlService:
+		return "query"
+	case FtsService:
+		return "search"
+	case CbasService:
+		return "analytics"
+	}
+
+	return fmt.Sprintf("unknown(%d)", serviceType)
+}
@@ -0,0 +1,45 @@
+package gocbcore
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type testLastConfigTimeProvider struct {
+	lastConfig time.Time
+}
+
+func (p *testLastConfigTimeProvider) LastConfigTime() time.Time {
+	return p.lastConfig
+}
+
+func (suite *UnitTestSuite) TestHealthComponentConfigAge() {
+	provider := &testLastConfigTimeProvider{}
+	hc := newHealthComponent(nil, provider)
+	reqs := HealthRequirements{
+		MaxConfigAge: time.Minute,
+	}
+
+	healthy, err := hc.Healthy(context.Background(), reqs)
+	suite.Assert().False(healthy)
+	suite.Assert().True(errors.Is(err, ErrUnhealthy))
+
+	provider.lastConfig = time.Now().Add(-2 * time.Minute)
+	healthy, err = hc.Healthy(context.Background(), reqs)
+	suite.Assert().False(healthy)
+	suite.Assert().True(errors.Is(err, ErrUnhealthy))
+
+	provider.lastConfig = time.Now()
+	healthy, err = hc.Healthy(context.Background(), reqs)
+	suite.Assert().True(healthy)
+	suite.Assert().Nil(err)
+}
+
+func (suite *UnitTestSuite) TestHealthComponentNoRequirements() {
+	hc := newHealthComponent(nil, &testLastConfigTimeProvider{})
+
+	healthy, err := hc.Healthy(context.Background(), HealthRequirements{})
+	suite.Assert().True(healthy)
+	suite.Assert().Nil(err)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net/http"
 	"sort"
+	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -24,6 +26,7 @@ type httpRequest struct {
 	Deadline         time.Time
 	RetryStrategy    RetryStrategy
 	RootTraceContext RequestSpanContext
+	UserAgent        string
 	// Whilst the http component will handle deadlines itself this context can be use from places like Ping which
 	// need to also be able to cancel the context for other reasons.
 	Context    context.Context
@@ -31,6 +34,7 @@ type httpRequest struct {
 
 	retryCount   uint32
 	retryReasons []RetryReason
+	retryAfter   time.Duration
 }
 
 func (hr *httpRequest) retryStrategy() RetryStrategy {
@@ -59,6 +63,10 @@ func (hr *httpRequest) RetryReasons() []RetryReason {
 	return hr.retryReasons
 }
 
+func (hr *httpRequest) RetryAfterHint() time.Duration {
+	return hr.retryAfter
+}
+
 func (hr *httpRequest) recordRetryAttempt(reason RetryReason) {
 	atomic.AddUint32(&hr.retryCount, 1)
 	idx := sort.Search(len(hr.retryReasons), func(i int) bool {
@@ -87,15 +95,139 @@ type HTTPRequest struct {
 	Deadline      time.Time
 	RetryStrategy RetryStrategy
 
+	// UserAgent is appended to the agent user agent for this request only, allowing traffic to be attributed to a
+	// specific component of the embedding application.
+	UserAgent string
+
+	// ResponseWriter, if set, receives the body of successful (2xx) responses as it is read from the network, rather
+	// than the body being left on the HTTPResponse. The callback is invoked once the whole body has been written.
+	// The body is only read as quickly as the writer accepts it.
+	// Volatile: This API is subject to change at any time.
+	ResponseWriter io.Writer
+
 	// Volatile: Tracer API is subject to change.
 	TraceContext RequestSpanContext
 }
 
+// HTTPRoundTripperMiddleware wraps the transport used to send HTTP requests, returning the RoundTripper which should
+// be used in its place. It can be used to apply headers, tracing or proxying to every HTTP request the agent sends.
+// Volatile: This API is subject to change at any time.
+type HTTPRoundTripperMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// middlewareTransport is the result of wrapping a transport with middleware, it keeps hold of the underlying
+// transport so that idle connections can still be closed.
+type middlewareTransport struct {
+	http.RoundTripper
+	base *http.Transport
+}
+
+func (t *middlewareTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+}
+
+// wrapHTTPTransport applies the middleware to the transport, the first middleware being the outermost.
+func wrapHTTPTransport(transport *http.Transport, middleware []HTTPRoundTripperMiddleware) http.RoundTripper {
+	if len(middleware) == 0 {
+		return transport
+	}
+
+	var rt http.RoundTripper = transport
+	for i := len(middleware) - 1; i >= 0; i-- {
+		rt = middleware[i](rt)
+	}
+
+	return &middlewareTransport{
+		RoundTripper: rt,
+		base:         transport,
+	}
+}
+
+// HTTPClientConfig specifies the transport settings used for HTTP requests to a service. Any unset fields use the
+// agent wide settings.
+// Volatile: This API is subject to change at any time.
+type HTTPClientConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	IdleConnectionTimeout time.Duration
+
+	// ConnectTimeout is the maximum time to wait for a connection to be established, defaults to 30s.
+	ConnectTimeout time.Duration
+
+	// TLSHandshakeTimeout is the maximum time to wait for a TLS handshake, zero means no limit.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout is the maximum time to wait for response headers once the request has been written,
+	// zero means no limit.
+	ResponseHeaderTimeout time.Duration
+
+	// DisableHTTP2 prevents HTTP/2 from being negotiated, it is always disabled when HTTPDisableHTTP2 is set on the
+	// agent.
+	DisableHTTP2 bool
+}
+
+// withDefaults returns the config with any unset fields taken from defaults.
+func (config HTTPClientConfig) withDefaults(defaults HTTPClientConfig) HTTPClientConfig {
+	if config.MaxIdleConns == 0 {
+		config.MaxIdleConns = defaults.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost == 0 {
+		config.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+	if config.IdleConnectionTimeout == 0 {
+		config.IdleConnectionTimeout = defaults.IdleConnectionTimeout
+	}
+	if config.ConnectTimeout == 0 {
+		config.ConnectTimeout = defaults.ConnectTimeout
+	}
+	if config.TLSHandshakeTimeout == 0 {
+		config.TLSHandshakeTimeout = defaults.TLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout == 0 {
+		config.ResponseHeaderTimeout = defaults.ResponseHeaderTimeout
+	}
+	config.DisableHTTP2 = config.DisableHTTP2 || defaults.DisableHTTP2
+
+	return config
+}
+
 // HTTPResponse encapsulates the response from an HTTP request.
 type HTTPResponse struct {
 	Endpoint   string
 	StatusCode int
+	Header     http.Header
 	Body       io.ReadCloser
+
+	// BytesWritten is the number of bytes of the body written to HTTPRequest.ResponseWriter, if one was set.
+	// Volatile: This API is subject to change at any time.
+	BytesWritten int64
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header value, which is either a number of seconds or an
+// HTTP date, or zero if there is no valid value.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
+
+// rateLimitedRetryAfter returns how long to wait before retrying a request which was rate limited, using the
+// Retry-After header if the service sent one.
+func rateLimitedRetryAfter(resp *HTTPResponse) time.Duration {
+	if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); retryAfter > 0 {
+		return retryAfter
+	}
+
+	return defaultRateLimitedRetryAfter
 }
 
 func wrapHTTPError(req *httpRequest, err error) HTTPError {
@@ -0,0 +1,330 @@
+package gocbcore
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestServiceHTTPClients() {
+	defaults := HTTPClientConfig{
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   5,
+		IdleConnectionTimeout: time.Second,
+		DisableHTTP2:          false,
+	}
+
+	clis := createServiceHTTPClients(defaults, map[ServiceType]HTTPClientConfig{
+		CbasService: {
+			IdleConnectionTimeout: time.Minute,
+			ResponseHeaderTimeout: 10 * time.Minute,
+			DisableHTTP2:          true,
+		},
+	}, nil, nil)
+	suite.Require().Len(clis, 1)
+
+	tsport := clis[CbasService].Transport.(*http.Transport)
+	suite.Assert().Equal(10, tsport.MaxIdleConns)
+	suite.Assert().Equal(5, tsport.MaxIdleConnsPerHost)
+	suite.Assert().Equal(time.Minute, tsport.IdleConnTimeout)
+	suite.Assert().Equal(10*time.Minute, tsport.ResponseHeaderTimeout)
+	suite.Assert().False(tsport.ForceAttemptHTTP2)
+	suite.Assert().NotNil(tsport.TLSNextProto)
+
+	defaultCli := createHTTPClient(defaults, nil, nil)
+	suite.Assert().True(defaultCli.Transport.(*http.Transport).ForceAttemptHTTP2)
+
+	hc := newHTTPComponent(httpComponentProps{ServiceClients: clis}, defaultCli, nil, nil, nil)
+	suite.Assert().Equal(clis[CbasService], hc.clientForService(CbasService))
+	suite.Assert().Equal(defaultCli, hc.clientForService(MgmtService))
+
+	// Disabling HTTP/2 for the agent can't be undone for a single service.
+	suite.Assert().True(HTTPClientConfig{}.withDefaults(HTTPClientConfig{DisableHTTP2: true}).DisableHTTP2)
+}
+
+func (suite *UnitTestSuite) TestUserAgentComponents() {
+	suite.Assert().Equal("gocb/2.1.0 myapp/1.2.3 worker", buildUserAgent("gocb/2.1.0", []UserAgentComponent{
+		{Product: "myapp", Version: "1.2.3"},
+		{Product: "worker"},
+	}))
+	suite.Assert().Equal("myapp/1.2.3", buildUserAgent("", []UserAgentComponent{{Product: "myapp", Version: "1.2.3"}}))
+	suite.Assert().Equal("gocb/2.1.0", buildUserAgent("gocb/2.1.0", nil))
+
+	var info struct {
+		Agent        string `json:"a"`
+		ConnectionID string `json:"i"`
+	}
+	suite.Require().Nil(json.Unmarshal([]byte(clientInfoString("myclient/0001", "myapp/1.2.3")), &info))
+	suite.Assert().Equal("gocbcore/"+goCbCoreVersionStr+" myapp/1.2.3", info.Agent)
+	suite.Assert().Equal("myclient/0001", info.ConnectionID)
+}
+
+type testHTTPRoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (fn testHTTPRoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func (suite *UnitTestSuite) TestHTTPRoundTripperMiddleware() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Seen", r.Header.Get("X-Chain"))
+	}))
+	defer srv.Close()
+
+	appendHeader := func(value string) HTTPRoundTripperMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return testHTTPRoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("X-Chain", req.Header.Get("X-Chain")+value)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	middleware := []HTTPRoundTripperMiddleware{appendHeader("a"), appendHeader("b")}
+	clis := []*http.Client{
+		createHTTPClient(HTTPClientConfig{}, nil, middleware),
+		createServiceHTTPClients(HTTPClientConfig{}, map[ServiceType]HTTPClientConfig{
+			N1qlService: {},
+		}, nil, middleware)[N1qlService],
+	}
+
+	for _, cli := range clis {
+		resp, err := cli.Get(srv.URL)
+		suite.Require().Nil(err)
+		suite.Require().Nil(resp.Body.Close())
+
+		// The first middleware is the outermost and so sees the request first.
+		suite.Assert().Equal("ab", resp.Header.Get("X-Seen"))
+
+		_, ok := cli.Transport.(interface{ CloseIdleConnections() })
+		suite.Assert().True(ok)
+		closeIdleHTTPConnections(cli)
+	}
+}
+
+type testHTTPRecordingRetryStrategy struct {
+	lock    sync.Mutex
+	reasons []RetryReason
+	hints   []time.Duration
+}
+
+func (rs *testHTTPRecordingRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
+	rs.lock.Lock()
+	rs.reasons = append(rs.reasons, reason)
+	rs.hints = append(rs.hints, retryAfterHint(req))
+	rs.lock.Unlock()
+	return &WithDurationRetryAction{WithDuration: time.Millisecond}
+}
+
+func (suite *UnitTestSuite) TestHTTPRetriesUnavailableOnAlternateEndpoint() {
+	var unavailableHits uint32
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&unavailableHits, 1)
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	var available uint32
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Rate limit the first request which reaches us.
+		if atomic.CompareAndSwapUint32(&available, 0, 1) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer limited.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, ftsEpList: []string{unavailable.URL, limited.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	hc := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, tracer)
+
+	for i := 0; i < 5; i++ {
+		strategy := &testHTTPRecordingRetryStrategy{}
+		req := &httpRequest{
+			Service:       FtsService,
+			Method:        "GET",
+			Path:          "/api/index",
+			IsIdempotent:  true,
+			Deadline:      time.Now().Add(5 * time.Second),
+			RetryStrategy: strategy,
+		}
+		resp, err := hc.DoInternalHTTPRequest(req, false)
+		suite.Require().Nil(err)
+		suite.Assert().Equal(200, resp.StatusCode)
+		suite.Assert().Equal(limited.URL, resp.Endpoint)
+		suite.Require().Nil(resp.Body.Close())
+
+		for j, reason := range strategy.reasons {
+			if reason == ServiceResponseCodeIndicatedRetryReason {
+				suite.Assert().Equal(2*time.Second, strategy.hints[j])
+			} else {
+				suite.Assert().Equal(RateLimitedRetryReason, reason)
+				suite.Assert().Equal(defaultRateLimitedRetryAfter, strategy.hints[j])
+			}
+		}
+		suite.Assert().Equal(len(strategy.reasons), int(req.RetryAttempts()))
+	}
+	suite.Assert().Equal(uint32(1), atomic.LoadUint32(&available))
+
+	// Requests which are not idempotent are not retried and the caller sees the response.
+	resp, err := hc.DoInternalHTTPRequest(&httpRequest{
+		Service:       FtsService,
+		Method:        "POST",
+		Path:          "/api/index",
+		Endpoint:      unavailable.URL,
+		Deadline:      time.Now().Add(5 * time.Second),
+		RetryStrategy: &testHTTPRecordingRetryStrategy{},
+	}, false)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	suite.Require().Nil(resp.Body.Close())
+
+	// Idempotent requests pinned to an unavailable endpoint retry until they time out.
+	hitsBefore := atomic.LoadUint32(&unavailableHits)
+	_, err = hc.DoInternalHTTPRequest(&httpRequest{
+		Service:       FtsService,
+		Method:        "GET",
+		Path:          "/api/index",
+		Endpoint:      unavailable.URL,
+		IsIdempotent:  true,
+		Deadline:      time.Now().Add(100 * time.Millisecond),
+		RetryStrategy: &testHTTPRecordingRetryStrategy{},
+	}, false)
+	suite.Assert().True(errors.Is(err, ErrUnambiguousTimeout), err)
+	suite.Assert().Greater(atomic.LoadUint32(&unavailableHits)-hitsBefore, uint32(1))
+}
+
+func (suite *UnitTestSuite) TestDoHTTPRequestResponseWriter() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+			return
+		}
+		_, _ = w.Write([]byte(strings.Repeat("x", 1<<20)))
+	}))
+	defer srv.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, mgmtEpList: []string{srv.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	hc := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, tracer)
+
+	do := func(path string, w io.Writer) *HTTPResponse {
+		respCh := make(chan *HTTPResponse, 1)
+		_, err := hc.DoHTTPRequest(&HTTPRequest{
+			Service:        MgmtService,
+			Method:         "GET",
+			Path:           path,
+			Deadline:       time.Now().Add(5 * time.Second),
+			ResponseWriter: w,
+		}, func(resp *HTTPResponse, err error) {
+			suite.Assert().Nil(err)
+			respCh <- resp
+		})
+		suite.Require().Nil(err)
+		return <-respCh
+	}
+
+	var buf bytes.Buffer
+	resp := do("/export", &buf)
+	suite.Require().NotNil(resp)
+	suite.Assert().Equal(int64(1<<20), resp.BytesWritten)
+	suite.Assert().Equal(1<<20, buf.Len())
+	body, err := ioutil.ReadAll(resp.Body)
+	suite.Require().Nil(err)
+	suite.Assert().Empty(body)
+
+	// Error responses are left for the caller to read rather than being written to the sink.
+	buf.Reset()
+	resp = do("/missing", &buf)
+	suite.Require().NotNil(resp)
+	suite.Assert().Equal(http.StatusNotFound, resp.StatusCode)
+	suite.Assert().Zero(buf.Len())
+	body, err = ioutil.ReadAll(resp.Body)
+	suite.Require().Nil(err)
+	suite.Assert().Equal("not found", string(body))
+	suite.Require().Nil(resp.Body.Close())
+}
+
+func (suite *UnitTestSuite) TestHTTPResponseCompression() {
+	const payload = `{"results":[{"a":1}],"status":"success"}`
+
+	var lock sync.Mutex
+	var acceptEncodings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		acceptEncodings = append(acceptEncodings, r.Header.Get("Accept-Encoding"))
+		lock.Unlock()
+
+		var buf bytes.Buffer
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(&buf)
+			_, _ = zw.Write([]byte(payload))
+			suite.Assert().Nil(zw.Close())
+		case "/zlib":
+			w.Header().Set("Content-Encoding", "deflate")
+			zw := zlib.NewWriter(&buf)
+			_, _ = zw.Write([]byte(payload))
+			suite.Assert().Nil(zw.Close())
+		case "/deflate":
+			w.Header().Set("Content-Encoding", "deflate")
+			zw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+			suite.Require().Nil(err)
+			_, _ = zw.Write([]byte(payload))
+			suite.Assert().Nil(zw.Close())
+		default:
+			buf.WriteString(payload)
+		}
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, n1qlEpList: []string{srv.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+
+	for _, disabled := range []bool{false, true} {
+		hc := newHTTPComponent(httpComponentProps{DisableCompression: disabled}, &http.Client{}, mux,
+			PasswordAuthProvider{}, tracer)
+
+		paths := []string{"/gzip", "/zlib", "/deflate"}
+		if disabled {
+			paths = []string{"/identity"}
+		}
+		for _, path := range paths {
+			resp, err := hc.DoInternalHTTPRequest(&httpRequest{
+				Service:  N1qlService,
+				Method:   "POST",
+				Path:     path,
+				Deadline: time.Now().Add(5 * time.Second),
+			}, false)
+			suite.Require().Nil(err)
+			suite.Assert().Empty(resp.Header.Get("Content-Encoding"))
+			body, err := ioutil.ReadAll(resp.Body)
+			suite.Require().Nil(err, path)
+			suite.Assert().Equal(payload, string(body), path)
+			suite.Require().Nil(resp.Body.Close())
+		}
+	}
+
+	lock.Lock()
+	suite.Assert().Equal([]string{httpAcceptEncoding, httpAcceptEncoding, httpAcceptEncoding, "identity"}, acceptEncodings)
+	lock.Unlock()
+}
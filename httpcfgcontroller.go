@@ -44,12 +44,15 @@ type httpConfigController struct {
 	confHTTPMaxWait      time.Duration
 	httpComponent        *httpComponent
 	bucketName           string
+	bucketLock           sync.Mutex
 
 	looperStopSig chan struct{}
 	looperDoneSig chan struct{}
 
 	fetchErr error
 	errLock  sync.Mutex
+
+	logCtx logContext
 }
 
 type httpPollerProperties struct {
@@ -57,6 +60,7 @@ type httpPollerProperties struct {
 	confHTTPRedialPeriod time.Duration
 	confHTTPMaxWait      time.Duration
 	httpComponent        *httpComponent
+	logCtx               logContext
 }
 
 func newHTTPConfigController(bucketName string, props httpPollerProperties, muxer *httpMux,
@@ -69,6 +73,7 @@ func newHTTPConfigController(bucketName string, props httpPollerProperties, muxe
 		confHTTPMaxWait:      props.confHTTPMaxWait,
 		httpComponent:        props.httpComponent,
 		bucketName:           bucketName,
+		logCtx:               props.logCtx,
 
 		looperStopSig: make(chan struct{}),
 		looperDoneSig: make(chan struct{}),
@@ -87,6 +92,19 @@ func (hcc *httpConfigController) setError(err error) {
 	hcc.errLock.Unlock()
 }
 
+// SetBucketName sets the bucket which config will be streamed for the next time that the poller connects.
+func (hcc *httpConfigController) SetBucketName(bucketName string) {
+	hcc.bucketLock.Lock()
+	hcc.bucketName = bucketName
+	hcc.bucketLock.Unlock()
+}
+
+func (hcc *httpConfigController) getBucketName() string {
+	hcc.bucketLock.Lock()
+	defer hcc.bucketLock.Unlock()
+	return hcc.bucketName
+}
+
 func (hcc *httpConfigController) Pause(paused bool) {
 }
 
@@ -112,7 +130,7 @@ func (hcc *httpConfigController) DoLoop() {
 	iterSawConfig := false
 	seenNodes := make(map[string]uint64)
 
-	logDebugf("HTTP Looper starting.")
+	hcc.logCtx.logDebugf("HTTP Looper starting.")
 
 Looper:
 	for {
@@ -132,11 +150,11 @@ Looper:
 		}
 
 		if pickedSrv == "" {
-			logDebugf("Pick Failed.")
+			hcc.logCtx.logDebugf("Pick Failed.")
 			// All servers have been visited during this iteration
 
 			if !iterSawConfig {
-				logDebugf("Looper waiting...")
+				hcc.logCtx.logDebugf("Looper waiting...")
 				// Wait for a period before trying again if there was a problem...
 				// We also watch for the client being shut down.
 				select {
@@ -145,32 +163,40 @@ Looper:
 				case <-time.After(waitPeriod):
 				}
 			}
-			logDebugf("Looping again.")
+			hcc.logCtx.logDebugf("Looping again.")
 			// Go to next iteration and try all servers again
 			iterNum++
 			iterSawConfig = false
 			continue
 		}
 
-		logDebugf("Http Picked: %s.", pickedSrv)
+		hcc.logCtx.logDebugf("Http Picked: %s.", logSystemData(pickedSrv))
 
 		seenNodes[pickedSrv] = iterNum
 
 		hostname := hostnameFromURI(pickedSrv)
-		logDebugf("HTTP Hostname: %s.", hostname)
+		hcc.logCtx.logDebugf("HTTP Hostname: %s.", logSystemData(hostname))
 
 		var resp *HTTPResponse
 		// 1 on success, 0 on failure for node, -1 for generic failure
 		var doConfigRequest func(bool) int
 
 		doConfigRequest = func(is2x bool) int {
-			streamPath := "bs"
-			if is2x {
-				streamPath = "bucketsStreaming"
-			}
+			bucketName := hcc.getBucketName()
+
 			// HTTP request time!
-			uri := fmt.Sprintf("/pools/default/%s/%s", streamPath, url.PathEscape(hcc.bucketName))
-			logDebugf("Requesting config from: %s/%s.", pickedSrv, uri)
+			var uri string
+			if bucketName == "" {
+				// Without a bucket we stream the cluster level config instead.
+				uri = "/pools/default/nodeServicesStreaming"
+			} else {
+				streamPath := "bs"
+				if is2x {
+					streamPath = "bucketsStreaming"
+				}
+				uri = fmt.Sprintf("/pools/default/%s/%s", streamPath, url.PathEscape(bucketName))
+			}
+			hcc.logCtx.logDebugf("Requesting config from: %s/%s.", logSystemData(pickedSrv), logMetaData(uri))
 
 			req := &httpRequest{
 				Service:  MgmtService,
@@ -184,7 +210,7 @@ Looper:
 			var err error
 			resp, err = hcc.httpComponent.DoInternalHTTPRequest(req, true)
 			if err != nil {
-				logWarnf("Failed to connect to host. %v", err)
+				hcc.logCtx.logWarnf("Failed to connect to host. %v", err)
 				hcc.setError(err)
 				return 0
 			}
@@ -192,22 +218,27 @@ Looper:
 			if resp.StatusCode != 200 {
 				err := resp.Body.Close()
 				if err != nil {
-					logErrorf("Socket close failed handling status code != 200 (%s)", err)
+					hcc.logCtx.logErrorf("Socket close failed handling status code != 200 (%s)", err)
 				}
 				if resp.StatusCode == 401 {
-					logWarnf("Failed to connect to host, bad auth.")
+					hcc.logCtx.logWarnf("Failed to connect to host, bad auth.")
 					hcc.setError(errAuthenticationFailure)
 					return -1
 				} else if resp.StatusCode == 404 {
+					if bucketName == "" {
+						hcc.logCtx.logWarnf("Failed to connect to host, cluster config streaming is not supported.")
+						hcc.setError(errFeatureNotAvailable)
+						return 0
+					}
 					if is2x {
-						logWarnf("Failed to connect to host, bad bucket.")
+						hcc.logCtx.logWarnf("Failed to connect to host, bad bucket.")
 						hcc.setError(errAuthenticationFailure)
 						return -1
 					}
 
 					return doConfigRequest(true)
 				}
-				logWarnf("Failed to connect to host, unexpected status code: %v.", resp.StatusCode)
+				hcc.logCtx.logWarnf("Failed to connect to host, unexpected status code: %v.", resp.StatusCode)
 				hcc.setError(errCliInternalError)
 				return 0
 			}
@@ -222,24 +253,30 @@ Looper:
 			continue
 		}
 
-		logDebugf("Connected.")
+		hcc.logCtx.logDebugf("Connected.")
 
 		var autoDisconnected int32
 
-		// Autodisconnect eventually
+		// Autodisconnect eventually, unless the redial period is disabled in which case we rely on the stream
+		// to push config changes to us for as long as the connection stays alive.
 		go func() {
+			var redialCh <-chan time.Time
+			if maxConnPeriod > 0 {
+				redialCh = time.After(maxConnPeriod)
+			}
+
 			select {
-			case <-time.After(maxConnPeriod):
+			case <-redialCh:
 			case <-hcc.looperStopSig:
 			}
 
-			logDebugf("Automatically resetting our HTTP connection")
+			hcc.logCtx.logDebugf("Automatically resetting our HTTP connection")
 
 			atomic.StoreInt32(&autoDisconnected, 1)
 
 			err := resp.Body.Close()
 			if err != nil {
-				logErrorf("Socket close failed during auto-dc (%s)", err)
+				hcc.logCtx.logErrorf("Socket close failed during auto-dc (%s)", err)
 			}
 		}()
 
@@ -255,40 +292,40 @@ Looper:
 					break
 				}
 
-				logWarnf("Config block decode failure (%s)", err)
+				hcc.logCtx.logWarnf("Config block decode failure (%s)", err)
 
 				if err != io.EOF {
 					err = resp.Body.Close()
 					if err != nil {
-						logErrorf("Socket close failed after decode fail (%s)", err)
+						hcc.logCtx.logErrorf("Socket close failed after decode fail (%s)", err)
 					}
 				}
 
 				break
 			}
 
-			logDebugf("Got Block: %v", string(configBlock.Bytes))
+			hcc.logCtx.logDebugf("Got Block: %v", string(configBlock.Bytes))
 
 			bkCfg, err := parseConfig(configBlock.Bytes, hostname)
 			if err != nil {
-				logDebugf("Got error while parsing config: %v", err)
+				hcc.logCtx.logDebugf("Got error while parsing config: %v", err)
 
 				err = resp.Body.Close()
 				if err != nil {
-					logErrorf("Socket close failed after parsing fail (%s)", err)
+					hcc.logCtx.logErrorf("Socket close failed after parsing fail (%s)", err)
 				}
 
 				break
 			}
 
-			logDebugf("Got Config.")
+			hcc.logCtx.logDebugf("Got Config.")
 
 			iterSawConfig = true
-			logDebugf("HTTP Config Update")
+			hcc.logCtx.logDebugf("HTTP Config Update")
 			hcc.cfgMgr.OnNewConfig(bkCfg)
 		}
 
-		logDebugf("HTTP, Setting %s to iter %d", pickedSrv, iterNum)
+		hcc.logCtx.logDebugf("HTTP, Setting %s to iter %d", logSystemData(pickedSrv), iterNum)
 	}
 
 	close(hcc.looperDoneSig)
@@ -0,0 +1,57 @@
+package gocbcore
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestHTTPConfigStreamingWithoutBucket() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	var requests uint32
+	var port int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Assert().Equal("/pools/default/nodeServicesStreaming", r.URL.Path)
+		atomic.AddUint32(&requests, 1)
+
+		_, _ = fmt.Fprintf(w, `{"rev":5,"nodesExt":[{"services":{"mgmt":%d,"kv":1},"hostname":"127.0.0.1"}]}`+"\n\n\n\n", port)
+		w.(http.Flusher).Flush()
+
+		// Hold the stream open as the server would, until the client disconnects.
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	_, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	suite.Require().Nil(err)
+	port, err = strconv.Atoi(portStr)
+	suite.Require().Nil(err)
+
+	agent, err := CreateAgent(&AgentConfig{
+		HTTPAddrs:        []string{server.Listener.Addr().String()},
+		Auth:             PasswordAuthProvider{Username: "user", Password: "pass"},
+		HTTPRedialPeriod: -1,
+	})
+	suite.Require().Nil(err)
+	defer agent.Close()
+
+	var revID int64
+	for i := 0; i < 500 && revID != 5; i++ {
+		if snapshot, err := agent.ConfigSnapshot(); err == nil {
+			revID = snapshot.RevID()
+		}
+		time.Sleep(time.Millisecond)
+	}
+	suite.Require().Equal(int64(5), revID)
+
+	// With redialing disabled the stream should be left open rather than reconnected.
+	time.Sleep(50 * time.Millisecond)
+	suite.Assert().Equal(uint32(1), atomic.LoadUint32(&requests))
+}
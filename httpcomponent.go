@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -21,38 +22,89 @@ type httpComponentInterface interface {
 
 type httpComponent struct {
 	cli                  *http.Client
+	serviceClis          map[ServiceType]*http.Client
 	muxer                *httpMux
 	auth                 AuthProvider
 	userAgent            string
 	tracer               *tracerComponent
 	defaultRetryStrategy RetryStrategy
+	connectTrigger       *connectTrigger
+	disableCompression   bool
+	opCounters           *operationCounters
+
+	bucketLock sync.Mutex
+	bucketName string
+
+	logCtx logContext
 }
 
 type httpComponentProps struct {
 	UserAgent            string
 	DefaultRetryStrategy RetryStrategy
+	ConnectTrigger       *connectTrigger
+	ServiceClients       map[ServiceType]*http.Client
+
+	DisableCompression bool
+	OpCounters         *operationCounters
+	BucketName         string
+	LogContext         logContext
 }
 
 func newHTTPComponent(props httpComponentProps, cli *http.Client, muxer *httpMux, auth AuthProvider,
 	tracer *tracerComponent) *httpComponent {
 	return &httpComponent{
 		cli:                  cli,
+		serviceClis:          props.ServiceClients,
 		muxer:                muxer,
 		auth:                 auth,
 		userAgent:            props.UserAgent,
 		defaultRetryStrategy: props.DefaultRetryStrategy,
+		connectTrigger:       props.ConnectTrigger,
+		disableCompression:   props.DisableCompression,
+		opCounters:           props.OpCounters,
 		tracer:               tracer,
+		bucketName:           props.BucketName,
+		logCtx:               props.LogContext,
 	}
 }
 
+// SetBucketName updates the bucket name which is included in credentials requests made to the auth provider.
+func (hc *httpComponent) SetBucketName(bucketName string) {
+	hc.bucketLock.Lock()
+	hc.bucketName = bucketName
+	hc.bucketLock.Unlock()
+}
+
+func (hc *httpComponent) getBucketName() string {
+	hc.bucketLock.Lock()
+	defer hc.bucketLock.Unlock()
+	return hc.bucketName
+}
+
 func (hc *httpComponent) Close() {
-	if tsport, ok := hc.cli.Transport.(*http.Transport); ok {
+	closeIdleHTTPConnections(hc.cli)
+	for _, cli := range hc.serviceClis {
+		closeIdleHTTPConnections(cli)
+	}
+}
+
+func closeIdleHTTPConnections(cli *http.Client) {
+	if tsport, ok := cli.Transport.(interface{ CloseIdleConnections() }); ok {
 		tsport.CloseIdleConnections()
 	} else {
 		logDebugf("Could not close idle connections for transport")
 	}
 }
 
+// clientForService returns the client used to send requests to service.
+func (hc *httpComponent) clientForService(service ServiceType) *http.Client {
+	if cli, ok := hc.serviceClis[service]; ok {
+		return cli
+	}
+
+	return hc.cli
+}
+
 func (hc *httpComponent) DoHTTPRequest(req *HTTPRequest, cb DoHTTPRequestCallback) (PendingOp, error) {
 	tracer := hc.tracer.CreateOpTrace("http", req.TraceContext)
 	defer tracer.Finish()
@@ -79,6 +131,7 @@ func (hc *httpComponent) DoHTTPRequest(req *HTTPRequest, cb DoHTTPRequestCallbac
 		Deadline:         req.Deadline,
 		RetryStrategy:    retryStrategy,
 		RootTraceContext: tracer.RootContext(),
+		UserAgent:        req.UserAgent,
 		Context:          ctx,
 		CancelFunc:       cancel,
 	}
@@ -91,13 +144,47 @@ func (hc *httpComponent) DoHTTPRequest(req *HTTPRequest, cb DoHTTPRequestCallbac
 			return
 		}
 
+		if req.ResponseWriter != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			err := streamHTTPResponse(resp, req.ResponseWriter)
+			if err != nil {
+				cancel()
+				cb(nil, wrapHTTPError(ireq, err))
+				return
+			}
+		}
+
 		cb(resp, nil)
 	}()
 
 	return ireq, nil
 }
 
+// streamHTTPResponse copies the body of resp into w, leaving resp with an empty body.
+func streamHTTPResponse(resp *HTTPResponse, w io.Writer) error {
+	n, err := io.Copy(w, resp.Body)
+	closeErr := resp.Body.Close()
+	if closeErr != nil {
+		logDebugf("Failed to close HTTP response body: %s", closeErr)
+	}
+	resp.Body = http.NoBody
+	resp.BytesWritten = n
+	if err != nil {
+		return wrapError(err, "failed to stream response body")
+	}
+
+	return nil
+}
+
 func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck bool) (*HTTPResponse, error) {
+	hc.opCounters.RecordHTTPDispatched(req.Service)
+	resp, err := hc.doInternalHTTPRequest(req, skipConfigCheck)
+	hc.opCounters.RecordHTTPOutcome(req.Service, err)
+
+	return resp, err
+}
+
+func (hc *httpComponent) doInternalHTTPRequest(req *httpRequest, skipConfigCheck bool) (*HTTPResponse, error) {
+	hc.connectTrigger.Trigger()
 	if req.Service == MemdService {
 		return nil, errInvalidService
 	}
@@ -167,98 +254,41 @@ func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck
 		}
 	}
 
-	// Identify an endpoint to use for the request
-	endpoint := req.Endpoint
-	if endpoint == "" {
-		var err error
-		switch req.Service {
-		case MgmtService:
-			endpoint, err = hc.getMgmtEp()
-		case CapiService:
-			endpoint, err = hc.getCapiEp()
-		case N1qlService:
-			endpoint, err = hc.getN1qlEp()
-		case FtsService:
-			endpoint, err = hc.getFtsEp()
-		case CbasService:
-			endpoint, err = hc.getCbasEp()
-		}
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	// Generate a request URI
-	reqURI := endpoint + req.Path
-
-	// Create a new request
-	hreq, err := http.NewRequest(req.Method, reqURI, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Lets add our context to the httpRequest
-	hreq = hreq.WithContext(ctx)
-
-	body := req.Body
-
-	// Inject credentials into the request
-	if req.Username != "" || req.Password != "" {
-		hreq.SetBasicAuth(req.Username, req.Password)
-	} else {
-		creds, err := hc.auth.Credentials(AuthCredsRequest{
-			Service:  req.Service,
-			Endpoint: endpoint,
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		if req.Service == N1qlService || req.Service == CbasService ||
-			req.Service == FtsService {
-			// Handle service which support multi-bucket authentication using
-			// injection into the body of the request.
-			if len(creds) == 1 {
-				hreq.SetBasicAuth(creds[0].Username, creds[0].Password)
-			} else {
-				body = injectJSONCreds(body, creds)
-			}
-		} else {
-			if len(creds) != 1 {
-				return nil, errInvalidCredentials
-			}
-
-			hreq.SetBasicAuth(creds[0].Username, creds[0].Password)
-		}
-	}
-
-	hreq.Body = ioutil.NopCloser(bytes.NewReader(body))
-
-	if req.ContentType != "" {
-		hreq.Header.Set("Content-Type", req.ContentType)
-	} else {
-		hreq.Header.Set("Content-Type", "application/json")
-	}
-	for key, val := range req.Headers {
-		hreq.Header.Set(key, val)
-	}
-
 	var uniqueID string
 	if req.UniqueID != "" {
 		uniqueID = req.UniqueID
 	} else {
 		uniqueID = uuid.New().String()
 	}
-	hreq.Header.Set("User-Agent", clientInfoString(uniqueID, hc.userAgent))
+
+	// If the caller chose an endpoint then we stick to it, otherwise we pick one for every attempt so that we can
+	// move away from endpoints which tell us they cannot currently serve the request.
+	pinnedEndpoint := req.Endpoint
+	var unavailableEndpoint string
 
 	for {
+		endpoint := pinnedEndpoint
+		if endpoint == "" {
+			var err error
+			endpoint, err = hc.getServiceEp(req.Service, unavailableEndpoint)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		reqURI := endpoint + req.Path
+		hreq, err := hc.createHTTPRequest(ctx, req, endpoint, reqURI, uniqueID)
+		if err != nil {
+			return nil, err
+		}
+
 		dSpan := hc.tracer.StartHTTPDispatchSpan(req, spanNameDispatchToServer)
-		logSchedf("Writing HTTP request to %s ID=%s", reqURI, req.UniqueID)
+		hc.logCtx.logSchedf("Writing HTTP request to %s ID=%s", logSystemData(reqURI), req.UniqueID)
 		// we can't close the body of this response as it's long lived beyond the function
-		hresp, err := hc.cli.Do(hreq) // nolint: bodyclose
+		hresp, err := hc.clientForService(req.Service).Do(hreq) // nolint: bodyclose
 		hc.tracer.StopHTTPDispatchSpan(dSpan, hreq, req.UniqueID)
 		if err != nil {
-			logSchedf("Received HTTP Response for ID=%s, errored", req.UniqueID)
+			hc.logCtx.logSchedf("Received HTTP Response for ID=%s, errored", req.UniqueID)
 			// Because we don't use the http request context itself to perform timeouts we need to do some translation
 			// of the error message here for better UX.
 			if errors.Is(err, context.Canceled) {
@@ -336,15 +366,30 @@ func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck
 				return nil, err
 			}
 
+			hc.opCounters.RecordHTTPRetried(req.Service)
 			continue
 		}
-		logSchedf("Received HTTP Response for ID=%s, status=%d", req.UniqueID, hresp.StatusCode)
+		hc.logCtx.logSchedf("Received HTTP Response for ID=%s, status=%d", req.UniqueID, hresp.StatusCode)
 
 		respOut := HTTPResponse{
 			Endpoint:   endpoint,
 			StatusCode: hresp.StatusCode,
+			Header:     hresp.Header,
 			Body:       hresp.Body,
 		}
+		if hreq.Header.Get("Accept-Encoding") == httpAcceptEncoding {
+			decompressHTTPResponse(&respOut)
+		}
+
+		retry, err := hc.maybeRetryUnavailable(ctx, req, &respOut, start)
+		if err != nil {
+			return nil, err
+		}
+		if retry {
+			unavailableEndpoint = endpoint
+			hc.opCounters.RecordHTTPRetried(req.Service)
+			continue
+		}
 
 		querySuccess = true
 
@@ -352,49 +397,192 @@ func (hc *httpComponent) DoInternalHTTPRequest(req *httpRequest, skipConfigCheck
 	}
 }
 
-/* #nosec G404 */
-func (hc *httpComponent) getMgmtEp() (string, error) {
-	mgmtEps := hc.muxer.MgmtEps()
-	if len(mgmtEps) == 0 {
-		return "", errServiceNotAvailable
+// maybeRetryUnavailable handles responses telling us that the service cannot currently handle the request, for
+// idempotent requests it waits for the retry strategy, honouring any Retry-After sent by the service, and returns
+// true if the request should be sent again. The response is consumed when the request is to be retried.
+func (hc *httpComponent) maybeRetryUnavailable(ctx context.Context, req *httpRequest, resp *HTTPResponse,
+	start time.Time) (bool, error) {
+	if !req.IsIdempotent {
+		return false, nil
 	}
-	return mgmtEps[rand.Intn(len(mgmtEps))], nil
-}
 
-/* #nosec G404 */
-func (hc *httpComponent) getCapiEp() (string, error) {
-	capiEps := hc.muxer.CapiEps()
-	if len(capiEps) == 0 {
-		return "", errServiceNotAvailable
+	var retryReason RetryReason
+	var retryAfter time.Duration
+	switch resp.StatusCode {
+	case http.StatusServiceUnavailable:
+		retryReason = ServiceResponseCodeIndicatedRetryReason
+		retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	case http.StatusTooManyRequests:
+		retryReason = RateLimitedRetryReason
+		retryAfter = rateLimitedRetryAfter(resp)
+	default:
+		return false, nil
+	}
+
+	req.retryAfter = retryAfter
+	shouldRetry, retryTime := retryOrchMaybeRetry(req, retryReason)
+	req.retryAfter = 0
+	if !shouldRetry {
+		// Leave the caller to deal with the response.
+		return false, nil
+	}
+
+	_, err := io.Copy(ioutil.Discard, resp.Body)
+	if err != nil {
+		hc.logCtx.logDebugf("Failed to drain HTTP response body: %s", err)
+	}
+	err = resp.Body.Close()
+	if err != nil {
+		hc.logCtx.logDebugf("Failed to close HTTP response body: %s", err)
 	}
-	return capiEps[rand.Intn(len(capiEps))], nil
+
+	err = waitForHTTPRetry(ctx, req, "http", resp.Endpoint, retryTime, start)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
 
-/* #nosec G404 */
-func (hc *httpComponent) getN1qlEp() (string, error) {
-	n1qlEps := hc.muxer.N1qlEps()
-	if len(n1qlEps) == 0 {
-		return "", errServiceNotAvailable
+// waitForHTTPRetry waits until retryTime before a request is sent again. An error is returned if the deadline of the
+// request is reached, or ctx is done, first.
+func waitForHTTPRetry(ctx context.Context, req *httpRequest, opName, endpoint string, retryTime,
+	start time.Time) error {
+	// Having no deadline is a legitimate case, in which case we only wait for the retry.
+	var deadlineCh <-chan time.Time
+	if !req.Deadline.IsZero() {
+		deadlineCh = time.After(time.Until(req.Deadline))
+	}
+
+	select {
+	case <-time.After(time.Until(retryTime)):
+		return nil
+	case <-deadlineCh:
+	case <-ctx.Done():
+		if req.Context != nil && req.Context.Err() != nil {
+			return errRequestCanceled
+		}
+	}
+
+	return &TimeoutError{
+		InnerError:       errUnambiguousTimeout,
+		OperationID:      opName,
+		Opaque:           req.Identifier(),
+		TimeObserved:     time.Since(start),
+		RetryReasons:     req.retryReasons,
+		RetryAttempts:    req.retryCount,
+		LastDispatchedTo: endpoint,
 	}
-	return n1qlEps[rand.Intn(len(n1qlEps))], nil
 }
 
-/* #nosec G404 */
-func (hc *httpComponent) getFtsEp() (string, error) {
-	ftsEps := hc.muxer.FtsEps()
-	if len(ftsEps) == 0 {
-		return "", errServiceNotAvailable
+func (hc *httpComponent) createHTTPRequest(ctx context.Context, req *httpRequest, endpoint, reqURI,
+	uniqueID string) (*http.Request, error) {
+	// Create a new request
+	hreq, err := http.NewRequest(req.Method, reqURI, nil)
+	if err != nil {
+		return nil, err
 	}
-	return ftsEps[rand.Intn(len(ftsEps))], nil
+
+	// Lets add our context to the httpRequest
+	hreq = hreq.WithContext(ctx)
+
+	body := req.Body
+
+	// Inject credentials into the request
+	if req.Username != "" || req.Password != "" {
+		hreq.SetBasicAuth(req.Username, req.Password)
+	} else {
+		creds, err := hc.auth.Credentials(AuthCredsRequest{
+			Service:    req.Service,
+			Endpoint:   endpoint,
+			BucketName: hc.getBucketName(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if req.Service == N1qlService || req.Service == CbasService ||
+			req.Service == FtsService {
+			// Handle service which support multi-bucket authentication using
+			// injection into the body of the request.
+			if len(creds) == 1 {
+				hreq.SetBasicAuth(creds[0].Username, creds[0].Password)
+			} else {
+				body = injectJSONCreds(body, creds)
+			}
+		} else {
+			if len(creds) != 1 {
+				return nil, errInvalidCredentials
+			}
+
+			hreq.SetBasicAuth(creds[0].Username, creds[0].Password)
+		}
+	}
+
+	hreq.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if req.ContentType != "" {
+		hreq.Header.Set("Content-Type", req.ContentType)
+	} else {
+		hreq.Header.Set("Content-Type", "application/json")
+	}
+	if req.Service == N1qlService || req.Service == CbasService || req.Service == FtsService {
+		// Setting the header ourselves stops the transport from asking for, and decoding, gzip itself.
+		if hc.disableCompression {
+			hreq.Header.Set("Accept-Encoding", "identity")
+		} else {
+			hreq.Header.Set("Accept-Encoding", httpAcceptEncoding)
+		}
+	}
+	for key, val := range req.Headers {
+		hreq.Header.Set(key, val)
+	}
+
+	userAgent := hc.userAgent
+	if req.UserAgent != "" {
+		userAgent = buildUserAgent(userAgent, []UserAgentComponent{{Product: req.UserAgent}})
+	}
+	hreq.Header.Set("User-Agent", clientInfoString(uniqueID, userAgent))
+
+	return hreq, nil
 }
 
+// getServiceEp picks a random endpoint for the service, avoid is only picked if there are no other endpoints.
 /* #nosec G404 */
-func (hc *httpComponent) getCbasEp() (string, error) {
-	cbasEps := hc.muxer.CbasEps()
-	if len(cbasEps) == 0 {
+func (hc *httpComponent) getServiceEp(service ServiceType, avoid string) (string, error) {
+	var eps []string
+	switch service {
+	case MgmtService:
+		eps = hc.muxer.MgmtEps()
+	case CapiService:
+		eps = hc.muxer.CapiEps()
+	case N1qlService:
+		eps = hc.muxer.N1qlEps()
+	case FtsService:
+		eps = hc.muxer.FtsEps()
+	case CbasService:
+		eps = hc.muxer.CbasEps()
+	default:
+		return "", nil
+	}
+
+	if len(eps) == 0 {
 		return "", errServiceNotAvailable
 	}
-	return cbasEps[rand.Intn(len(cbasEps))], nil
+
+	if avoid != "" && len(eps) > 1 {
+		filtered := make([]string, 0, len(eps))
+		for _, ep := range eps {
+			if ep != avoid {
+				filtered = append(filtered, ep)
+			}
+		}
+		if len(filtered) > 0 {
+			eps = filtered
+		}
+	}
+
+	return eps[rand.Intn(len(eps))], nil
 }
 
 func injectJSONCreds(body []byte, creds []UserPassPair) []byte {
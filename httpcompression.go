@@ -0,0 +1,82 @@
+package gocbcore
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+)
+
+// httpAcceptEncoding is sent to the services which support compressing their responses.
+const httpAcceptEncoding = "gzip, deflate"
+
+// decompressHTTPResponse replaces the body of a compressed response with one that decodes it, removing the headers
+// describing the compressed body in the same way that the http transport does when it handles gzip itself.
+func decompressHTTPResponse(resp *HTTPResponse) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "deflate" {
+		return
+	}
+
+	header := resp.Header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	resp.Header = header
+	resp.Body = &decompressingBody{
+		body:     resp.Body,
+		encoding: encoding,
+	}
+}
+
+// decompressingBody lazily creates the decompressor so that reading the compression header happens when the caller
+// first reads the body rather than when the response is received.
+type decompressingBody struct {
+	body     io.ReadCloser
+	encoding string
+	reader   io.Reader
+	err      error
+}
+
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		if b.encoding == "gzip" {
+			b.reader, b.err = gzip.NewReader(b.body)
+		} else {
+			b.reader, b.err = newDeflateReader(b.body)
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	return b.reader.Read(p)
+}
+
+func (b *decompressingBody) Close() error {
+	if closer, ok := b.reader.(io.Closer); ok {
+		err := closer.Close()
+		if err != nil {
+			logDebugf("Failed to close HTTP response decompressor: %s", err)
+		}
+	}
+
+	return b.body.Close()
+}
+
+// newDeflateReader decodes a deflate encoded body. The encoding is meant to be zlib wrapped but some servers send raw
+// deflate data, so we check for the zlib header before choosing.
+func newDeflateReader(body io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(body)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
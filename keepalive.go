@@ -0,0 +1,146 @@
+package gocbcore
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// KeepAliveConfig controls the sending of NOOPs on idle kv connections, allowing connections which have silently
+// died to be detected and replaced before they cause user operations to time out.
+type KeepAliveConfig struct {
+	// Interval is how long a connection must go without receiving any data before a NOOP is sent on it. If zero then
+	// keepalives are disabled.
+	Interval time.Duration
+
+	// Timeout is how long to wait for a response to a NOOP before the connection is considered dead and closed.
+	// Defaults to Interval.
+	Timeout time.Duration
+}
+
+func (c KeepAliveConfig) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return c.Interval
+}
+
+func (client *memdClient) keepAliveLoop(cfg KeepAliveConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-client.closeNotify:
+			return
+		case <-ticker.C:
+		}
+
+		lastActivity := atomic.LoadInt64(&client.lastActivity)
+		if lastActivity != 0 && time.Since(time.Unix(0, lastActivity)) < cfg.Interval {
+			continue
+		}
+
+		_, err := client.sendNoop(cfg.timeout())
+		if err == nil {
+			continue
+		}
+
+		select {
+		case <-client.closeNotify:
+			// The connection was closed whilst the NOOP was in flight, there is nothing more to do.
+			return
+		default:
+		}
+
+		client.logCtx.logWarnf("Keepalive NOOP failed, closing connection: %v", err)
+		closeErr := client.CloseWithError(err)
+		if closeErr != nil {
+			client.logCtx.logErrorf("Failed to close connection after keepalive failure (%s)", closeErr)
+		}
+		return
+	}
+}
+
+// deadConnLoop closes the connection once it has gone for timeout without receiving any data. This is used for DCP
+// connections, where the server sends a noop at a fixed interval on any connection which is otherwise idle. The
+// connection is never considered dead whilst we have stopped reading from it because the DCP queue is full.
+func (client *memdClient) deadConnLoop(timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 4)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-client.closeNotify:
+			return
+		case <-ticker.C:
+		}
+
+		if atomic.LoadUint32(&client.dcpQueueBlocked) != 0 {
+			continue
+		}
+
+		lastActivity := start
+		if nanos := atomic.LoadInt64(&client.lastActivity); nanos != 0 {
+			lastActivity = time.Unix(0, nanos)
+		}
+		if time.Since(lastActivity) < timeout {
+			continue
+		}
+
+		err := wrapError(errDCPConnectionDead, fmt.Sprintf("nothing received for %s", time.Since(lastActivity)))
+		client.logCtx.logWarnf("Connection appears to be dead, closing it: %v", err)
+		client.emitEndpointEvent(EndpointEventDeadConnection, "", err)
+		closeErr := client.CloseWithError(err)
+		if closeErr != nil {
+			client.logCtx.logErrorf("Failed to close dead connection (%s)", closeErr)
+		}
+		return
+	}
+}
+
+// sendNoop sends a NOOP on the connection and waits up to timeout for the response, returning the round trip time.
+// The result is recorded against the latency of the connection.
+func (client *memdClient) sendNoop(timeout time.Duration) (time.Duration, error) {
+	latency, err := client.doSendNoop(timeout)
+	client.latency.record(latency, err)
+	return latency, err
+}
+
+func (client *memdClient) doSendNoop(timeout time.Duration) (time.Duration, error) {
+	errCh := make(chan error, 1)
+	req := &memdQRequest{
+		Packet: memd.Packet{
+			Magic:   memd.CmdMagicReq,
+			Command: memd.CmdNoop,
+		},
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			errCh <- err
+		},
+		RetryStrategy: newFailFastRetryStrategy(),
+	}
+
+	client.logCtx.logSchedf("Sending NOOP")
+	start := time.Now()
+	err := client.internalSendRequest(req)
+	if err != nil {
+		return 0, err
+	}
+
+	timer := AcquireTimer(timeout)
+	select {
+	case err := <-errCh:
+		ReleaseTimer(timer, false)
+		return time.Since(start), err
+	case <-timer.C:
+		ReleaseTimer(timer, true)
+		if !req.internalCancel(errRequestCanceled) {
+			// The response arrived just as we timed out.
+			return time.Since(start), <-errCh
+		}
+		return 0, wrapError(errUnambiguousTimeout, "noop was not responded to in time")
+	}
+}
@@ -0,0 +1,158 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestKeepAliveDetectsDeadConnection() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var noops uint32
+	var dropNoops uint32
+	server.Handle(memd.CmdNoop, func(req *memd.Packet) *memd.Packet {
+		atomic.AddUint32(&noops, 1)
+		if atomic.LoadUint32(&dropNoops) == 1 {
+			return nil
+		}
+		return &memd.Packet{Status: memd.StatusSuccess}
+	})
+
+	disconnectedCh := make(chan EndpointEvent, 10)
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName: "default",
+		KeepAliveConfig: KeepAliveConfig{
+			Interval: 20 * time.Millisecond,
+			Timeout:  50 * time.Millisecond,
+		},
+		EndpointEventCallback: func(event EndpointEvent) {
+			if event.Type == EndpointEventDisconnected {
+				disconnectedCh <- event
+			}
+		},
+	})
+	defer agent.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint32(&noops) < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	suite.Require().True(atomic.LoadUint32(&noops) >= 3)
+
+	select {
+	case event := <-disconnectedCh:
+		suite.T().Fatalf("Connection unexpectedly dropped: %v", event.Error)
+	default:
+	}
+
+	// Once the server stops responding the connection should be closed by the keepalive.
+	atomic.StoreUint32(&dropNoops, 1)
+	select {
+	case event := <-disconnectedCh:
+		suite.Assert().True(errors.Is(event.Error, ErrUnambiguousTimeout), event.Error)
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Timed out waiting for the connection to be closed")
+	}
+
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101?kv_keepalive_interval=30s&kv_keepalive_timeout=5s"))
+	suite.Assert().Equal(KeepAliveConfig{Interval: 30 * time.Second, Timeout: 5 * time.Second}, config.KeepAliveConfig)
+}
+
+func (suite *UnitTestSuite) TestDeadConnectionDetection() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	conn, err := server.Dial(server.Address())
+	suite.Require().Nil(err)
+
+	eventsCh := make(chan EndpointEvent, 10)
+	client := newMemdClient(memdClientProps{
+		ClientID:        "test",
+		DeadConnTimeout: 50 * time.Millisecond,
+		EventCallback: func(event EndpointEvent) {
+			eventsCh <- event
+		},
+	}, conn, CircuitBreakerConfig{}, func(resp *memdQResponse, req *memdQRequest, err error) (bool, error) {
+		return false, err
+	}, newTracerComponent(&noopTracer{}, "", true), nil)
+
+	// The mock server never sends anything unprompted so the connection should be considered dead.
+	for _, expected := range []EndpointEventType{EndpointEventDeadConnection, EndpointEventDisconnected} {
+		select {
+		case event := <-eventsCh:
+			suite.Assert().Equal(expected, event.Type)
+			suite.Assert().True(errors.Is(event.Error, ErrDCPConnectionDead), event.Error)
+		case <-time.After(5 * time.Second):
+			suite.T().Fatalf("Timed out waiting for event %d", expected)
+		}
+	}
+	<-client.CloseNotify()
+
+	config := &DCPAgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101?dcp_noop_interval=30s&dcp_missed_noop_limit=3"))
+	suite.Assert().Equal(30*time.Second, config.NoopInterval)
+	suite.Assert().Equal(3, config.MissedNoopLimit)
+}
+
+func (suite *UnitTestSuite) TestDeadConnectionDetectionSlowConsumer() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	conn, err := server.Dial(server.Address())
+	suite.Require().Nil(err)
+
+	eventsCh := make(chan EndpointEvent, 10)
+	client := newMemdClient(memdClientProps{
+		ClientID:        "test",
+		DeadConnTimeout: 50 * time.Millisecond,
+		EventCallback: func(event EndpointEvent) {
+			eventsCh <- event
+		},
+	}, conn, CircuitBreakerConfig{}, func(resp *memdQResponse, req *memdQRequest, err error) (bool, error) {
+		return false, err
+	}, newTracerComponent(&noopTracer{}, "", true), nil)
+
+	// Nothing is read from the connection whilst the consumer leaves the DCP queue full, so it isn't dead.
+	dcpBufferQ := make(chan *dcpBuffer)
+	queuedCh := make(chan struct{})
+	go func() {
+		client.queueDcpBuffer(dcpBufferQ, &dcpBuffer{})
+		close(queuedCh)
+	}()
+
+	select {
+	case event := <-eventsCh:
+		suite.T().Fatalf("Connection considered dead whilst the DCP queue was full: %v", event.Error)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	<-dcpBufferQ
+	<-queuedCh
+
+	// Once the consumer catches up the connection is dead if the server still sends nothing.
+	select {
+	case event := <-eventsCh:
+		suite.Assert().Equal(EndpointEventDeadConnection, event.Type)
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("Timed out waiting for the connection to be considered dead")
+	}
+	<-client.CloseNotify()
+}
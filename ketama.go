@@ -20,8 +20,55 @@ func (c ketamaSorter) Len() int           { return len(c.elems) }
 func (c ketamaSorter) Swap(i, j int)      { c.elems[i], c.elems[j] = c.elems[j], c.elems[i] }
 func (c ketamaSorter) Less(i, j int) bool { return c.elems[i].point < c.elems[j].point }
 
+// KetamaHasher controls how keys are distributed between the servers of a memcached bucket. Keys and servers are
+// both hashed to points on a continuum, each key is routed to the server owning the first point at or after the
+// key's point. This allows keys to be distributed in the same way as other clients which use a different hash
+// function or number of points per server.
+// Volatile: This API is subject to change at any time.
+type KetamaHasher interface {
+	// HashKey returns the point on the continuum for key.
+	HashKey(key []byte) uint32
+
+	// ServerPoints returns the points on the continuum owned by the server at address. Servers which own more
+	// points receive proportionally more keys.
+	ServerPoints(address string) []uint32
+}
+
+// DefaultKetamaHasher is the KetamaHasher used when none is configured, it is compatible with libcouchbase and the
+// other Couchbase SDKs.
+// Volatile: This API is subject to change at any time.
+type DefaultKetamaHasher struct{}
+
+// HashKey returns the first 4 bytes of the MD5 digest of key, read as a little endian integer.
+func (h DefaultKetamaHasher) HashKey(key []byte) uint32 {
+	return ketamaHash(key)
+}
+
+// ServerPoints returns 160 points for every server, taken from the MD5 digests of address-0 to address-39.
+func (h DefaultKetamaHasher) ServerPoints(address string) []uint32 {
+	points := make([]uint32, 0, 160)
+	for hh := 0; hh < 40; hh++ {
+		hostkey := []byte(fmt.Sprintf("%s-%d", address, hh))
+		digest := md5.Sum(hostkey) // nolint: gosec
+
+		for nn := 0; nn < 4; nn++ {
+
+			var d1 = uint32(digest[3+nn*4]&0xff) << 24
+			var d2 = uint32(digest[2+nn*4]&0xff) << 16
+			var d3 = uint32(digest[1+nn*4]&0xff) << 8
+			var d4 = uint32(digest[0+nn*4] & 0xff)
+			var point = d1 | d2 | d3 | d4
+
+			points = append(points, point)
+		}
+	}
+
+	return points
+}
+
 type ketamaContinuum struct {
 	entries []routeKetamaContinuum
+	hasher  KetamaHasher
 }
 
 func ketamaHash(key []byte) uint32 {
@@ -34,30 +81,23 @@ func ketamaHash(key []byte) uint32 {
 }
 
 func newKetamaContinuum(serverList []string) *ketamaContinuum {
-	continuum := ketamaContinuum{}
+	return newKetamaContinuumWithHasher(serverList, DefaultKetamaHasher{})
+}
+
+func newKetamaContinuumWithHasher(serverList []string, hasher KetamaHasher) *ketamaContinuum {
+	continuum := ketamaContinuum{
+		hasher: hasher,
+	}
 
 	// Libcouchbase presorts this. Might not strictly be required..
 	sort.Strings(serverList)
 
 	for ss, authority := range serverList {
-		// 160 points per server
-		for hh := 0; hh < 40; hh++ {
-			hostkey := []byte(fmt.Sprintf("%s-%d", authority, hh))
-			digest := md5.Sum(hostkey) // nolint: gosec
-
-			for nn := 0; nn < 4; nn++ {
-
-				var d1 = uint32(digest[3+nn*4]&0xff) << 24
-				var d2 = uint32(digest[2+nn*4]&0xff) << 16
-				var d3 = uint32(digest[1+nn*4]&0xff) << 8
-				var d4 = uint32(digest[0+nn*4] & 0xff)
-				var point = d1 | d2 | d3 | d4
-
-				continuum.entries = append(continuum.entries, routeKetamaContinuum{
-					point: point,
-					index: uint32(ss),
-				})
-			}
+		for _, point := range hasher.ServerPoints(authority) {
+			continuum.entries = append(continuum.entries, routeKetamaContinuum{
+				point: point,
+				index: uint32(ss),
+			})
 		}
 	}
 
@@ -113,5 +153,5 @@ func (continuum ketamaContinuum) nodeByHash(hash uint32) (int, error) {
 }
 
 func (continuum ketamaContinuum) NodeByKey(key []byte) (int, error) {
-	return continuum.nodeByHash(ketamaHash(key))
+	return continuum.nodeByHash(continuum.hasher.HashKey(key))
 }
@@ -0,0 +1,91 @@
+package gocbcore
+
+import "encoding/binary"
+
+// testKetamaHasher hashes keys to their first 4 bytes and gives each server the points listed for it.
+type testKetamaHasher struct {
+	points map[string][]uint32
+}
+
+func (h *testKetamaHasher) HashKey(key []byte) uint32 {
+	return binary.BigEndian.Uint32(key)
+}
+
+func (h *testKetamaHasher) ServerPoints(address string) []uint32 {
+	return h.points[address]
+}
+
+func (suite *UnitTestSuite) TestKetamaDefaultHasher() {
+	continuum := newKetamaContinuumWithHasher([]string{"b:11210", "a:11210"}, DefaultKetamaHasher{})
+	suite.Assert().Len(continuum.entries, 320)
+
+	// Changing how keys are distributed would redistribute every application's cache, so these must never change.
+	expected := map[string]int{"foo": 1, "bar": 1, "baz": 1, "key1": 1, "key2": 0}
+	for key, expectedIdx := range expected {
+		idx, err := continuum.NodeByKey([]byte(key))
+		suite.Require().Nil(err)
+		suite.Assert().Equal(expectedIdx, idx, key)
+	}
+}
+
+func (suite *UnitTestSuite) TestKetamaCustomHasher() {
+	hasher := &testKetamaHasher{
+		points: map[string][]uint32{
+			"a:11210": {100, 300, 500},
+			"b:11210": {200},
+		},
+	}
+
+	continuum := newKetamaContinuumWithHasher([]string{"b:11210", "a:11210"}, hasher)
+	suite.Require().Len(continuum.entries, 4)
+
+	nodeFor := func(hash uint32) int {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, hash)
+		idx, err := continuum.NodeByKey(key)
+		suite.Require().Nil(err)
+		return idx
+	}
+
+	// Servers are sorted, so a is index 0 and b is index 1.
+	suite.Assert().Equal(0, nodeFor(50))
+	suite.Assert().Equal(0, nodeFor(100))
+	suite.Assert().Equal(1, nodeFor(150))
+	suite.Assert().Equal(0, nodeFor(250))
+	suite.Assert().Equal(0, nodeFor(450))
+	suite.Assert().Equal(0, nodeFor(600))
+}
+
+func (suite *UnitTestSuite) TestConfigManagementKetamaHasher() {
+	cfgBk, err := parseConfig([]byte(`{
+		"rev": 1,
+		"name": "default",
+		"uuid": "abc",
+		"nodeLocator": "ketama",
+		"nodes": [
+			{"hostname": "10.0.0.1:8091", "ports": {"direct": 11210}},
+			{"hostname": "10.0.0.2:8091", "ports": {"direct": 11210}}
+		]
+	}`), "10.0.0.1")
+	suite.Require().Nil(err)
+
+	hasher := &testKetamaHasher{
+		points: map[string][]uint32{
+			"10.0.0.1:11210": {1000},
+			"10.0.0.2:11210": {2000},
+		},
+	}
+
+	mgr := &testAlternateAddressesRouteConfigMgr{}
+	cm := newConfigManager(configManagerProperties{
+		SrcMemdAddrs: []string{"10.0.0.1:11210"},
+		KetamaHasher: hasher,
+	})
+	cm.AddConfigWatcher(mgr)
+	cm.OnNewConfig(cfgBk)
+
+	suite.Require().True(mgr.cfgCalled)
+	suite.Require().NotNil(mgr.cfg.ketamaMap)
+	suite.Assert().Equal([]routeKetamaContinuum{{index: 0, point: 1000}, {index: 1, point: 2000}},
+		mgr.cfg.ketamaMap.entries)
+}
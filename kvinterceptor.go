@@ -0,0 +1,76 @@
+package gocbcore
+
+import "github.com/couchbase/gocbcore/v9/memd"
+
+// KVInterceptedRequest is the view of a kv request which is given to interceptors.
+type KVInterceptedRequest struct {
+	// Packet is the request packet which is being dispatched, it must not be modified.
+	Packet *memd.Packet
+
+	CollectionName string
+	ScopeName      string
+
+	// Attributes can be used by interceptors to annotate the request. They are shared between all of the
+	// interceptors in the chain, and between the BeforeDispatch and AfterResponse calls for a request.
+	Attributes map[string]interface{}
+}
+
+// KVInterceptor observes kv requests before they are dispatched and their responses before the operation callback
+// is invoked. Interceptors must not block as they are invoked inline with request processing.
+// Volatile: This API is subject to change at any time.
+type KVInterceptor interface {
+	// BeforeDispatch is invoked once for each request before it is first dispatched. Returning an error vetoes the
+	// request, which then fails with that error.
+	BeforeDispatch(req *KVInterceptedRequest) error
+
+	// AfterResponse is invoked with the response to a request, or the error it failed with, before the operation
+	// callback. The response is nil when the request failed without one, such as when it timed out or was vetoed by
+	// a later interceptor in the chain.
+	AfterResponse(req *KVInterceptedRequest, resp *memd.Packet, err error)
+}
+
+// kvInterceptorChain runs each interceptor in order before dispatch, and in reverse order after the response.
+type kvInterceptorChain []KVInterceptor
+
+func (chain kvInterceptorChain) intercept(req *memdQRequest) error {
+	// Requests can pass through dispatch more than once, for example whilst waiting on a collection id, but should
+	// only be intercepted the first time.
+	if len(chain) == 0 || req.intercepted {
+		return nil
+	}
+	req.intercepted = true
+
+	interceptedReq := &KVInterceptedRequest{
+		Packet:         &req.Packet,
+		CollectionName: req.CollectionName,
+		ScopeName:      req.ScopeName,
+		Attributes:     make(map[string]interface{}),
+	}
+
+	for i, interceptor := range chain {
+		err := interceptor.BeforeDispatch(interceptedReq)
+		if err != nil {
+			// Interceptors which have already seen the request need to know that it will not complete.
+			for j := i - 1; j >= 0; j-- {
+				chain[j].AfterResponse(interceptedReq, nil, err)
+			}
+			return err
+		}
+	}
+
+	callback := req.Callback
+	req.Callback = func(resp *memdQResponse, req *memdQRequest, err error) {
+		var respPacket *memd.Packet
+		if resp != nil {
+			respPacket = resp.Packet
+		}
+
+		for i := len(chain) - 1; i >= 0; i-- {
+			chain[i].AfterResponse(interceptedReq, respPacket, err)
+		}
+
+		callback(resp, req, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+type testKVInterceptor struct {
+	name   string
+	veto   []byte
+	record func(event string)
+}
+
+func (i *testKVInterceptor) BeforeDispatch(req *KVInterceptedRequest) error {
+	i.record(i.name + " before " + string(req.Packet.Key))
+
+	req.Attributes[i.name] = time.Now()
+	if i.veto != nil && string(req.Packet.Key) == string(i.veto) {
+		return errors.New("vetoed")
+	}
+	return nil
+}
+
+func (i *testKVInterceptor) AfterResponse(req *KVInterceptedRequest, resp *memd.Packet, err error) {
+	event := i.name + " after " + string(req.Packet.Key)
+	if _, ok := req.Attributes[i.name]; !ok {
+		event += " missing attribute"
+	}
+	if resp != nil {
+		event += " " + resp.Status.KVText()
+	}
+	if err != nil {
+		event += " error"
+	}
+
+	i.record(event)
+}
+
+func (suite *UnitTestSuite) TestKVInterceptors() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var lock sync.Mutex
+	var events []string
+	record := func(event string) {
+		lock.Lock()
+		events = append(events, event)
+		lock.Unlock()
+	}
+	outer := &testKVInterceptor{name: "outer", record: record}
+	inner := &testKVInterceptor{name: "inner", veto: []byte("denied"), record: record}
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:     "default",
+		KVInterceptors: []KVInterceptor{outer, inner},
+	})
+	defer agent.Close()
+
+	get := func(key string) error {
+		errCh := make(chan error, 1)
+		_, err := agent.Get(GetOptions{
+			Key:      []byte(key),
+			Deadline: time.Now().Add(5 * time.Second),
+		}, func(res *GetResult, err error) {
+			errCh <- err
+		})
+		if err != nil {
+			return err
+		}
+		return <-errCh
+	}
+
+	err := get("missing")
+	suite.Assert().True(errors.Is(err, ErrDocumentNotFound))
+
+	err = get("denied")
+	suite.Require().NotNil(err)
+	suite.Assert().Equal("vetoed", err.Error())
+
+	lock.Lock()
+	defer lock.Unlock()
+	suite.Assert().Equal([]string{
+		"outer before missing",
+		"inner before missing",
+		"inner after missing " + memd.StatusKeyNotFound.KVText() + " error",
+		"outer after missing " + memd.StatusKeyNotFound.KVText() + " error",
+		"outer before denied",
+		"inner before denied",
+		"outer after denied error",
+	}, events)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/couchbase/gocbcore/v9/memd"
 	"io"
 	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -13,6 +14,7 @@ import (
 
 type bucketCapabilityVerifier interface {
 	HasBucketCapabilityStatus(cap BucketCapability, status BucketCapabilityStatus) bool
+	CheckClusterFeature(feature ClusterFeature, innerErr error) error
 }
 
 type dispatcher interface {
@@ -25,12 +27,21 @@ type dispatcher interface {
 	PipelineSnapshot() (*pipelineSnapshot, error)
 }
 
+// reconnectPollInterval is how often a rolling reconnect checks whether a pipeline has reconnected.
+const reconnectPollInterval = 10 * time.Millisecond
+
 type kvMux struct {
 	muxPtr unsafe.Pointer
 
 	collectionsEnabled bool
 	queueSize          int
 	poolSize           int
+	backpressure       PipelineBackpressureConfig
+	reconnectBackoff   ReconnectBackoffConfig
+	maxConnAge         time.Duration
+	interceptors       kvInterceptorChain
+	retryClassifier    KVRetryClassifier
+	statusOverrides    map[memd.StatusCode]KVStatusRetryOverride
 	cfgMgr             *configManagementComponent
 	errMapMgr          *errMapComponent
 
@@ -38,12 +49,51 @@ type kvMux struct {
 	dialer *memdClientDialerComponent
 
 	postCompleteErrHandler postCompleteErrorHandler
+
+	connectTrigger *connectTrigger
+	opCounters     *operationCounters
+
+	deadPipeRetry        DeadPipelineRetryConfig
+	deadPipeStartOnce    sync.Once
+	deadPipeStopSig      chan struct{}
+	deadPipeStopOnce     sync.Once
+	deadPipeResetSig     chan struct{}
+	deadPipeIdle         uint32
+	deadPipeRedispatched uint64
+
+	// bucketEpoch is incremented each time that the selected bucket changes, it starts at 1 so that a zero value on
+	// a request means that it has not yet been dispatched.
+	bucketEpoch uint32
+
+	// retiringPipelines tracks the pipelines of nodes which have left the cluster whilst their in flight requests
+	// complete, closing retireAbortSig closes them straight away.
+	retiringPipelines sync.WaitGroup
+	retireAbortSig    chan struct{}
+	retireAbortOnce   sync.Once
+
+	// serverVersions holds the version reported by each kv node in the current config, minVersion caches the oldest
+	// of these so that feature checks don't need to walk the pipelines.
+	serverVersionsLock sync.Mutex
+	serverVersions     map[string]ClusterVersion
+	minVersion         atomic.Value
+
+	logCtx logContext
 }
 
 type kvMuxProps struct {
 	CollectionsEnabled bool
 	QueueSize          int
 	PoolSize           int
+	Backpressure       PipelineBackpressureConfig
+	ReconnectBackoff   ReconnectBackoffConfig
+	MaxConnectionAge   time.Duration
+	Interceptors       []KVInterceptor
+	RetryClassifier    KVRetryClassifier
+	StatusOverrides    map[memd.StatusCode]KVStatusRetryOverride
+	ConnectTrigger     *connectTrigger
+	OpCounters         *operationCounters
+	DeadPipelineRetry  DeadPipelineRetryConfig
+	LogContext         logContext
 }
 
 func newKVMux(props kvMuxProps, cfgMgr *configManagementComponent, errMapMgr *errMapComponent, tracer *tracerComponent,
@@ -51,11 +101,25 @@ func newKVMux(props kvMuxProps, cfgMgr *configManagementComponent, errMapMgr *er
 	mux := &kvMux{
 		queueSize:          props.QueueSize,
 		poolSize:           props.PoolSize,
+		backpressure:       props.Backpressure,
+		reconnectBackoff:   props.ReconnectBackoff,
+		maxConnAge:         props.MaxConnectionAge,
+		interceptors:       props.Interceptors,
+		retryClassifier:    props.RetryClassifier,
+		statusOverrides:    props.StatusOverrides,
+		connectTrigger:     props.ConnectTrigger,
+		opCounters:         props.OpCounters,
 		collectionsEnabled: props.CollectionsEnabled,
 		cfgMgr:             cfgMgr,
 		errMapMgr:          errMapMgr,
 		tracer:             tracer,
 		dialer:             dialer,
+		bucketEpoch:        1,
+		deadPipeRetry:      props.DeadPipelineRetry,
+		deadPipeStopSig:    make(chan struct{}),
+		deadPipeResetSig:   make(chan struct{}, 1),
+		retireAbortSig:     make(chan struct{}),
+		logCtx:             props.LogContext,
 	}
 
 	cfgMgr.AddConfigWatcher(mux)
@@ -74,7 +138,7 @@ func (mux *kvMux) getState() *kvMuxState {
 
 func (mux *kvMux) updateState(old, new *kvMuxState) bool {
 	if new == nil {
-		logErrorf("Attempted to update to nil kvMuxState")
+		mux.logCtx.logErrorf("Attempted to update to nil kvMuxState")
 		return false
 	}
 
@@ -83,7 +147,7 @@ func (mux *kvMux) updateState(old, new *kvMuxState) bool {
 	}
 
 	if atomic.SwapPointer(&mux.muxPtr, unsafe.Pointer(new)) != nil {
-		logErrorf("Updated from nil attempted on initialized kvMuxState")
+		mux.logCtx.logErrorf("Updated from nil attempted on initialized kvMuxState")
 		return false
 	}
 
@@ -102,18 +166,21 @@ func (mux *kvMux) OnNewRouteConfig(cfg *routeConfig) {
 
 	// Attempt to atomically update the routing data
 	if !mux.updateState(oldMuxState, newMuxState) {
-		logWarnf("Someone preempted the config update, skipping update")
+		mux.logCtx.logWarnf("Someone preempted the config update, skipping update")
 		return
 	}
 
+	mux.pruneServerVersions(newMuxState)
+
 	if oldMuxState == nil {
 		if newMuxState.revID > -1 && mux.collectionsEnabled && !newMuxState.collectionsSupported {
-			logDebugf("Collections disabled as unsupported")
+			mux.logCtx.logDebugf("Collections disabled as unsupported")
 		}
 		// There is no existing muxer.  We can simply start the new pipelines.
 		for _, pipeline := range newMuxState.pipelines {
 			pipeline.StartClients()
 		}
+		mux.startDeadPipeRetry()
 	} else {
 		if !mux.collectionsEnabled {
 			// If collections just aren't enabled then we never need to refresh the connections because collections
@@ -130,6 +197,8 @@ func (mux *kvMux) OnNewRouteConfig(cfg *routeConfig) {
 
 		mux.requeueRequests(oldMuxState)
 	}
+
+	mux.resetDeadPipeBackoff()
 }
 
 func (mux *kvMux) SetPostCompleteErrorHandler(handler postCompleteErrorHandler) {
@@ -152,12 +221,35 @@ func (mux *kvMux) ConfigUUID() string {
 	return clientMux.uuid
 }
 
+func (mux *kvMux) ClusterUUID() string {
+	clientMux := mux.getState()
+	if clientMux == nil {
+		return ""
+	}
+	return clientMux.clusterUUID
+}
+
+func (mux *kvMux) ClusterName() string {
+	clientMux := mux.getState()
+	if clientMux == nil {
+		return ""
+	}
+	return clientMux.clusterName
+}
+
 func (mux *kvMux) KeyToVbucket(key []byte) (uint16, error) {
 	clientMux := mux.getState()
-	if clientMux == nil || clientMux.vbMap == nil {
+	if clientMux == nil {
 		return 0, errShutdown
 	}
 
+	if clientMux.revID < 0 {
+		return 0, errNoConfig
+	}
+	if clientMux.vbMap == nil {
+		return 0, errUnsupportedOperation
+	}
+
 	return clientMux.vbMap.VbucketByKey(key), nil
 }
 
@@ -294,16 +386,25 @@ func (mux *kvMux) RouteRequest(req *memdQRequest) (*memdPipeline, error) {
 }
 
 func (mux *kvMux) DispatchDirect(req *memdQRequest) (PendingOp, error) {
+	mux.connectTrigger.Trigger()
+	if !mux.checkBucketEpoch(req) {
+		return nil, errBucketChanged
+	}
+	if err := mux.interceptors.intercept(req); err != nil {
+		return nil, err
+	}
 	mux.tracer.StartCmdTrace(req)
 	req.dispatchTime = time.Now()
+	mux.trackDispatch(req)
 
 	for {
 		pipeline, err := mux.RouteRequest(req)
 		if err != nil {
+			req.counters().RecordKvOutcome(req.Command, err)
 			return nil, err
 		}
 
-		err = pipeline.SendRequest(req)
+		err = mux.sendRequest(pipeline, req)
 		if err == errPipelineClosed {
 			continue
 		} else if err != nil {
@@ -316,28 +417,81 @@ func (mux *kvMux) DispatchDirect(req *memdQRequest) (PendingOp, error) {
 				return req, nil
 			}
 
+			req.counters().RecordKvOutcome(req.Command, routeErr)
 			return nil, routeErr
 		}
 
+		mux.deadPipeQueued(pipeline)
 		break
 	}
 
 	return req, nil
 }
 
+// sendRequest sends the request to the pipeline, applying the configured backpressure policy if the pipeline is full.
+func (mux *kvMux) sendRequest(pipeline *memdPipeline, req *memdQRequest) error {
+	switch mux.backpressure.Mode {
+	case PipelineBackpressureBlock:
+		maxWait := mux.backpressure.MaxWait
+		if maxWait <= 0 {
+			maxWait = defaultPipelineBackpressureMaxWait
+		}
+
+		return pipeline.SendRequestWait(req, time.Now().Add(maxWait))
+	case PipelineBackpressureCallback:
+		var attempt uint32
+		for {
+			err := pipeline.SendRequest(req)
+			if err != errPipelineFull || mux.backpressure.Handler == nil {
+				return err
+			}
+
+			attempt++
+			if !mux.backpressure.Handler(pipeline.Address(), attempt) {
+				return err
+			}
+		}
+	}
+
+	return pipeline.SendRequest(req)
+}
+
+// trackDispatch counts the request as dispatched the first time that it is sent, requests which are sent again are
+// counted as retries instead.
+func (mux *kvMux) trackDispatch(req *memdQRequest) {
+	if mux.opCounters == nil {
+		return
+	}
+
+	if atomic.CompareAndSwapPointer(&req.opCounters, nil, unsafe.Pointer(mux.opCounters)) {
+		mux.opCounters.RecordKvDispatched(req.Command)
+	}
+}
+
 func (mux *kvMux) RequeueDirect(req *memdQRequest, isRetry bool) {
 	mux.tracer.StartCmdTrace(req)
+	if isRetry {
+		mux.opCounters.RecordKvRetried(req.Command)
+	}
+	// Requests waiting on a collection ID are sent for the first time from here.
+	if req.dispatchTime.IsZero() {
+		req.dispatchTime = time.Now()
+	}
+	mux.trackDispatch(req)
 
 	handleError := func(err error) {
-		// We only want to log an error on retries if the error isn't cancelled.
-		if !isRetry || (isRetry && !errors.Is(err, ErrRequestCanceled)) {
-			logErrorf("Reschedule failed, failing request (%s)", err)
-		}
+		// The error is passed to the request's callback, so it is only logged for debugging.
+		mux.logCtx.logDebugf("Reschedule failed, failing request (%s)", err)
 
 		req.tryCallback(nil, err)
 	}
 
-	logDebugf("Request being requeued, Opaque=%d", req.Opaque)
+	mux.logCtx.logDebugf("Request being requeued, Opaque=%d", req.Opaque)
+
+	if !mux.checkBucketEpoch(req) {
+		handleError(errBucketChanged)
+		return
+	}
 
 	for {
 		pipeline, err := mux.RouteRequest(req)
@@ -354,11 +508,30 @@ func (mux *kvMux) RequeueDirect(req *memdQRequest, isRetry bool) {
 			return
 		}
 
+		mux.deadPipeQueued(pipeline)
 		break
 	}
 }
 
+// checkBucketEpoch records the current bucket epoch against the request if it has not been dispatched before,
+// otherwise it checks that the bucket has not changed since it was.
+func (mux *kvMux) checkBucketEpoch(req *memdQRequest) bool {
+	epoch := atomic.LoadUint32(&mux.bucketEpoch)
+	if req.bucketEpoch == 0 {
+		req.bucketEpoch = epoch
+		return true
+	}
+
+	return req.bucketEpoch == epoch
+}
+
 func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, pipeline *memdPipeline) (PendingOp, error) {
+	if !mux.checkBucketEpoch(req) {
+		return nil, errBucketChanged
+	}
+	if err := mux.interceptors.intercept(req); err != nil {
+		return nil, err
+	}
 	mux.tracer.StartCmdTrace(req)
 	req.dispatchTime = time.Now()
 
@@ -368,9 +541,10 @@ func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, pipeline *memdPipel
 		return nil, errInvalidReplica
 	}
 	req.ReplicaIdx = -999999999
+	mux.trackDispatch(req)
 
 	for {
-		err := pipeline.SendRequest(req)
+		err := mux.sendRequest(pipeline, req)
 		if err == errPipelineClosed {
 			continue
 		} else if err != nil {
@@ -383,6 +557,7 @@ func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, pipeline *memdPipel
 				return req, nil
 			}
 
+			req.counters().RecordKvOutcome(req.Command, routeErr)
 			return nil, routeErr
 		}
 
@@ -394,6 +569,11 @@ func (mux *kvMux) DispatchDirectToAddress(req *memdQRequest, pipeline *memdPipel
 
 func (mux *kvMux) Close() error {
 	mux.cfgMgr.RemoveConfigWatcher(mux)
+	mux.deadPipeStopOnce.Do(func() {
+		if mux.deadPipeStopSig != nil {
+			close(mux.deadPipeStopSig)
+		}
+	})
 	clientMux := mux.clear()
 
 	if clientMux == nil {
@@ -406,7 +586,7 @@ func (mux *kvMux) Close() error {
 	for _, pipeline := range clientMux.pipelines {
 		err := pipeline.Close()
 		if err != nil {
-			logErrorf("failed to shut down pipeline: %s", err)
+			mux.logCtx.logErrorf("failed to shut down pipeline: %s", err)
 			muxErr = errCliInternalError
 		}
 	}
@@ -414,22 +594,61 @@ func (mux *kvMux) Close() error {
 	if clientMux.deadPipe != nil {
 		err := clientMux.deadPipe.Close()
 		if err != nil {
-			logErrorf("failed to shut down deadpipe: %s", err)
+			mux.logCtx.logErrorf("failed to shut down deadpipe: %s", err)
 			muxErr = errCliInternalError
 		}
 	}
 
 	// Drain all the pipelines and error their requests, then
-	//  drain the dead queue and error those requests.
+	//  drain the dead queue and error those requests. The error includes the history of each request so that it is
+	//  clear whether it was ever dispatched.
 	cb := func(req *memdQRequest) {
-		req.tryCallback(nil, errShutdown)
+		req.tryCallback(nil, mux.errMapMgr.EnhanceKvError(errShutdown, nil, req))
 	}
 
 	mux.drainPipelines(clientMux, cb)
 
+	// Any pipelines still being retired are closed straight away, failing whatever they still have in flight.
+	mux.retireAbortOnce.Do(func() {
+		close(mux.retireAbortSig)
+	})
+	mux.retiringPipelines.Wait()
+
 	return muxErr
 }
 
+// SelectBucket replaces every pipeline with a new one which will select bucketName when it connects, or no bucket at
+// all if bucketName is empty. Requests belonging to the old pipelines are failed rather than being requeued as they
+// were dispatched against the previous bucket.
+func (mux *kvMux) SelectBucket(bucketName string) error {
+	oldMuxState := mux.getState()
+	if oldMuxState == nil {
+		return errShutdown
+	}
+
+	mux.dialer.SetBucket(bucketName)
+	atomic.AddUint32(&mux.bucketEpoch, 1)
+
+	// We don't know anything about the new bucket yet so we start from a blank config against the same nodes.
+	// The cluster is unchanged so its identity is carried over.
+	newMuxState := mux.newKVMuxState(&routeConfig{
+		kvServerList: oldMuxState.kvServerList,
+		clusterUUID:  oldMuxState.clusterUUID,
+		clusterName:  oldMuxState.clusterName,
+		revID:        -1,
+	})
+	if !mux.updateState(oldMuxState, newMuxState) {
+		return errBucketChanged
+	}
+
+	mux.reconnectPipelines(oldMuxState, newMuxState)
+	mux.drainPipelines(oldMuxState, func(req *memdQRequest) {
+		req.tryCallback(nil, errBucketChanged)
+	})
+
+	return nil
+}
+
 func (mux *kvMux) handleOpRoutingResp(resp *memdQResponse, req *memdQRequest, originalErr error) (bool, error) {
 	// If there is no error, we should return immediately
 	if originalErr == nil {
@@ -441,14 +660,44 @@ func (mux *kvMux) handleOpRoutingResp(resp *memdQResponse, req *memdQRequest, or
 		return false, originalErr
 	}
 
+	if resp != nil && resp.Magic == memd.CmdMagicRes {
+		if override, ok := mux.statusOverrides[resp.Status]; ok {
+			return mux.handleStatusOverride(resp, req, originalErr, override)
+		}
+	}
+
+	if mux.retryClassifier != nil {
+		candidate := KVRetryCandidate{
+			Request: req,
+			Packet:  &req.Packet,
+			Err:     originalErr,
+		}
+		if resp != nil {
+			candidate.Response = resp.Packet
+		}
+
+		if reason := mux.retryClassifier(candidate); reason != nil {
+			if mux.waitAndRetryOperation(req, reason) {
+				return true, nil
+			}
+		}
+	}
+
+	// The connection was closed whilst the request was in flight, so it may or may not have been applied.
+	if errors.Is(originalErr, io.EOF) {
+		if mux.waitAndRetryOperation(req, SocketCloseInFlightRetryReason) {
+			return true, nil
+		}
+	}
+
 	err := translateMemdError(originalErr, req)
 
 	if err == originalErr {
 		// We don't know anything about this error so send it to the error map
 		if resp != nil && resp.Magic == memd.CmdMagicRes {
-			shouldRetry := mux.errMapMgr.ShouldRetry(resp.Status)
+			retrySpec, shouldRetry := mux.errMapMgr.RetrySpec(resp.Status)
 			if shouldRetry {
-				if mux.waitAndRetryOperation(req, KVErrMapRetryReason) {
+				if mux.waitAndRetryErrMapOperation(req, retrySpec) {
 					return true, nil
 				}
 			}
@@ -467,6 +716,10 @@ func (mux *kvMux) handleOpRoutingResp(resp *memdQResponse, req *memdQRequest, or
 			if mux.waitAndRetryOperation(req, KVTemporaryFailureRetryReason) {
 				return true, nil
 			}
+		} else if errors.Is(err, ErrRateLimitedFailure) {
+			if resp != nil && mux.waitAndRetryRateLimitedOperation(req, mux.errMapMgr.RateLimitedRetryAfter(resp.Status)) {
+				return true, nil
+			}
 		} else if errors.Is(err, ErrDurableWriteInProgress) {
 			if mux.waitAndRetryOperation(req, KVSyncWriteInProgressRetryReason) {
 				return true, nil
@@ -475,10 +728,6 @@ func (mux *kvMux) handleOpRoutingResp(resp *memdQResponse, req *memdQRequest, or
 			if mux.waitAndRetryOperation(req, KVSyncWriteRecommitInProgressRetryReason) {
 				return true, nil
 			}
-		} else if errors.Is(err, io.EOF) {
-			if mux.waitAndRetryOperation(req, SocketNotAvailableRetryReason) {
-				return true, nil
-			}
 		} else if errors.Is(err, io.ErrShortWrite) {
 			// This is a special case where the write has failed on the underlying connection and not all of the bytes
 			// were written to the network.
@@ -511,11 +760,58 @@ func (mux *kvMux) waitAndRetryOperation(req *memdQRequest, reason RetryReason) b
 	return false
 }
 
+// waitAndRetryRateLimitedOperation retries an operation which the server rejected because of a rate limit, the retry
+// strategy is told how long to wait so that the operation is not sent again while the limit still applies.
+func (mux *kvMux) waitAndRetryRateLimitedOperation(req *memdQRequest, retryAfter time.Duration) bool {
+	req.setRetryAfterHint(retryAfter)
+	defer req.setRetryAfterHint(0)
+
+	return mux.waitAndRetryOperation(req, RateLimitedRetryReason)
+}
+
+// waitAndRetryErrMapOperation retries an operation which failed with a status that the error map indicated can be
+// retried. The retry strategy still decides whether to retry but the delay is taken from the error map retry
+// specification when one is provided.
+func (mux *kvMux) waitAndRetryErrMapOperation(req *memdQRequest, spec kvErrorMapRetry) bool {
+	if !spec.IsSet() {
+		return mux.waitAndRetryOperation(req, KVErrMapRetryReason)
+	}
+
+	if spec.HasExpired(req.dispatchTime) {
+		mux.logCtx.logDebugf("Won't retry request, error map max duration exceeded. OperationID=%s", req.Identifier())
+		return false
+	}
+
+	retryCount := req.RetryAttempts()
+	shouldRetry, _ := retryOrchMaybeRetry(req, KVErrMapRetryReason)
+	if !shouldRetry {
+		return false
+	}
+
+	delay := spec.CalculateRetryDelay(retryCount)
+	mux.logCtx.logDebugf("Using error map retry delay of %s. OperationID=%s", delay, req.Identifier())
+	go func() {
+		time.Sleep(delay)
+		mux.RequeueDirect(req, true)
+	}()
+
+	return true
+}
+
 func (mux *kvMux) handleNotMyVbucket(resp *memdQResponse, req *memdQRequest) bool {
+	mux.applyNotMyVbucketConfig(resp)
+
+	// Redirect it!  This may actually come back to this server, but I won't tell
+	//   if you don't ;)
+	return mux.waitAndRetryOperation(req, KVNotMyVBucketRetryReason)
+}
+
+// applyNotMyVbucketConfig pushes the config sent in a not my vbucket response, if there is one, upstream.
+func (mux *kvMux) applyNotMyVbucketConfig(resp *memdQResponse) {
 	// Grab just the hostname from the source address
 	sourceHost, err := hostFromHostPort(resp.sourceAddr)
 	if err != nil {
-		logErrorf("NMV response source address was invalid, skipping config update")
+		mux.logCtx.logErrorf("NMV response source address was invalid, skipping config update")
 	} else {
 		// Try to parse the value as a bucket configuration
 		bk, err := parseConfig(resp.Value, sourceHost)
@@ -524,15 +820,11 @@ func (mux *kvMux) handleNotMyVbucket(resp *memdQResponse, req *memdQRequest) boo
 			mux.cfgMgr.OnNewConfig(bk)
 		}
 	}
-
-	// Redirect it!  This may actually come back to this server, but I won't tell
-	//   if you don't ;)
-	return mux.waitAndRetryOperation(req, KVNotMyVBucketRetryReason)
 }
 
 func (mux *kvMux) drainPipelines(clientMux *kvMuxState, cb func(req *memdQRequest)) {
 	for _, pipeline := range clientMux.pipelines {
-		logDebugf("Draining queue %+v", pipeline)
+		mux.logCtx.logDebugf("Draining queue %+v", pipeline)
 		pipeline.Drain(cb)
 	}
 	if clientMux.deadPipe != nil {
@@ -551,9 +843,16 @@ func (mux *kvMux) newKVMuxState(cfg *routeConfig) *kvMuxState {
 		hostPort := hostPort
 
 		getCurClientFn := func(cancelSig <-chan struct{}) (*memdClient, error) {
-			return mux.dialer.SlowDialMemdClient(cancelSig, hostPort, mux.handleOpRoutingResp)
+			client, err := mux.dialer.SlowDialMemdClient(cancelSig, hostPort, mux.handleOpRoutingResp)
+			if err != nil {
+				return nil, err
+			}
+
+			mux.recordServerVersion(hostPort, client.ServerVersion())
+			return client, nil
 		}
-		pipeline := newPipeline(hostPort, poolSize, mux.queueSize, getCurClientFn)
+		pipeline := newPipeline(hostPort, poolSize, mux.queueSize, mux.reconnectBackoff, mux.maxConnAge,
+			getCurClientFn, mux.logCtx)
 
 		pipelines[i] = pipeline
 	}
@@ -565,13 +864,13 @@ func (mux *kvMux) reconnectPipelines(oldMuxState *kvMuxState, newMuxState *kvMux
 	for _, pipeline := range oldMuxState.pipelines {
 		err := pipeline.Close()
 		if err != nil {
-			logErrorf("failed to shut down pipeline: %s", err)
+			mux.logCtx.logErrorf("failed to shut down pipeline: %s", err)
 		}
 	}
 
 	err := oldMuxState.deadPipe.Close()
 	if err != nil {
-		logErrorf("Failed to properly close abandoned dead pipe (%s)", err)
+		mux.logCtx.logErrorf("Failed to properly close abandoned dead pipe (%s)", err)
 	}
 
 	for _, pipeline := range newMuxState.pipelines {
@@ -610,7 +909,7 @@ func (mux *kvMux) pipelineTakeover(oldMux, newMux *kvMuxState) {
 		for e := oldPipelines.Front(); e != nil; e = e.Next() {
 			pipeline, ok := e.Value.(*memdPipeline)
 			if !ok {
-				logErrorf("Failed to cast old pipeline")
+				mux.logCtx.logErrorf("Failed to cast old pipeline")
 				continue
 			}
 
@@ -633,31 +932,143 @@ func (mux *kvMux) pipelineTakeover(oldMux, newMux *kvMuxState) {
 		pipeline.StartClients()
 	}
 
-	// Shut down any pipelines that were not taken over
+	// Shut down any pipelines that were not taken over, these belong to nodes which have left the cluster. They are
+	// retired rather than closed outright so that the requests in flight to them, such as during a swap rebalance, can
+	// complete rather than failing when the connections are torn down.
 	for e := oldPipelines.Front(); e != nil; e = e.Next() {
 		pipeline, ok := e.Value.(*memdPipeline)
 		if !ok {
-			logErrorf("Failed to cast old pipeline")
+			mux.logCtx.logErrorf("Failed to cast old pipeline")
 			continue
 		}
 
-		err := pipeline.Close()
-		if err != nil {
-			logErrorf("Failed to properly close abandoned pipeline (%s)", err)
-		}
+		mux.retirePipeline(pipeline)
 	}
 
 	if oldMux != nil && oldMux.deadPipe != nil {
 		err := oldMux.deadPipe.Close()
 		if err != nil {
-			logErrorf("Failed to properly close abandoned dead pipe (%s)", err)
+			mux.logCtx.logErrorf("Failed to properly close abandoned dead pipe (%s)", err)
+		}
+	}
+}
+
+// retirePipeline gracefully closes a pipeline which is no longer part of the routing state. Its queue is closed before
+// this returns so the requests waiting in it can be requeued against the new pipelines.
+func (mux *kvMux) retirePipeline(pipeline *memdPipeline) {
+	mux.logCtx.logDebugf("Retiring pipeline for %s", pipeline.Address())
+
+	mux.retiringPipelines.Add(1)
+	closedSig := pipeline.CloseGracefully(mux.retireAbortSig)
+	go func() {
+		<-closedSig
+		mux.retiringPipelines.Done()
+	}()
+}
+
+// Reconnect closes and re-establishes the connections of every pipeline in the current state. When rolling is set
+// the pipelines are reconnected one at a time, each being given until deadline to connect again before moving on.
+// Pipelines which are removed from the state whilst reconnecting are skipped.
+func (mux *kvMux) Reconnect(rolling bool, deadline time.Time) error {
+	if rolling && deadline.IsZero() {
+		return wrapError(errInvalidArgument, "a deadline must be provided for a rolling reconnect")
+	}
+
+	clientMux := mux.getState()
+	if clientMux == nil {
+		return errShutdown
+	}
+
+	for _, pipeline := range clientMux.pipelines {
+		type reconnectingClient struct {
+			pipecli   *memdPipelineClient
+			oldClient *memdClient
+		}
+
+		if rolling {
+			currentMux := mux.getState()
+			if currentMux == nil {
+				return errShutdown
+			}
+			if !currentMux.hasPipeline(pipeline) {
+				continue
+			}
+		}
+
+		var reconnecting []reconnectingClient
+		for _, pipecli := range pipeline.Clients() {
+			oldClient := pipecli.Reconnect()
+			if oldClient != nil {
+				reconnecting = append(reconnecting, reconnectingClient{pipecli, oldClient})
+			}
+		}
+
+		if !rolling {
+			continue
+		}
+
+		for _, cli := range reconnecting {
+			for !cli.pipecli.isConnectedWithout(cli.oldClient) {
+				if time.Now().After(deadline) {
+					return wrapError(errTimeout, "timed out waiting for pipeline to reconnect")
+				}
+
+				currentMux := mux.getState()
+				if currentMux == nil {
+					return errShutdown
+				}
+				if !currentMux.hasPipeline(pipeline) {
+					// The pipeline has been removed by a config change, so it will never reconnect.
+					break
+				}
+
+				time.Sleep(reconnectPollInterval)
+			}
 		}
 	}
+
+	return nil
 }
 
+// EndpointLatencies returns the latency measured for each connected kv connection in the current state, scored
+// relative to one another.
+func (mux *kvMux) EndpointLatencies() []EndpointLatency {
+	clientMux := mux.getState()
+	if clientMux == nil {
+		return nil
+	}
+
+	var latencies []EndpointLatency
+	for _, pipeline := range clientMux.pipelines {
+		for _, pipecli := range pipeline.Clients() {
+			pipecli.lock.Lock()
+			client := pipecli.client
+			pipecli.lock.Unlock()
+
+			if client == nil {
+				continue
+			}
+
+			latency := client.latency.snapshot()
+			latency.Address = client.Address()
+			latency.ConnectionID = client.connID
+			latencies = append(latencies, latency)
+		}
+	}
+
+	scoreEndpointLatencies(latencies)
+	return latencies
+}
+
+// PipelineSnapshot returns a snapshot of the current pipelines. This is also used to report on connections so it
+// never triggers a lazy connect, operations must do that themselves.
 func (mux *kvMux) PipelineSnapshot() (*pipelineSnapshot, error) {
 	clientMux := mux.getState()
 	if clientMux == nil {
+		if !mux.connectTrigger.Connected() {
+			return nil, errNotConnected
+		}
+
 		return nil, errShutdown
 	}
 
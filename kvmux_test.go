@@ -1,5 +1,15 @@
 package gocbcore
 
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
 func (suite *StandardTestSuite) TestKvMux_HasBucketCapabilityStatusNoState() {
 	// No mux state, shouldn't actually happen in practise.
 	mux := kvMux{}
@@ -69,3 +79,167 @@ func (suite *StandardTestSuite) TestKvMux_HasBucketCapabilityStatusSupported() {
 	suite.Assert().False(mux.HasBucketCapabilityStatus(9999, BucketCapabilityStatusSupported))
 	suite.Assert().True(mux.HasBucketCapabilityStatus(9999, BucketCapabilityStatusUnsupported))
 }
+
+func (suite *UnitTestSuite) TestKvMux_ClusterIdentity() {
+	mux := kvMux{}
+	suite.Assert().Equal("", mux.ClusterUUID())
+
+	bk, err := parseConfig([]byte(`{"rev":1,"clusterUUID":"d1a8b2e6","clusterName":"east"}`), "127.0.0.1")
+	suite.Require().Nil(err)
+	mux.updateState(nil, newKVMuxState(bk.BuildRouteConfig(false, "default", false), nil, nil))
+
+	suite.Assert().Equal("d1a8b2e6", mux.ClusterUUID())
+	suite.Assert().Equal("east", mux.ClusterName())
+}
+
+func (suite *UnitTestSuite) TestKvMux_BucketEpochChanged() {
+	mux := kvMux{
+		bucketEpoch: 1,
+	}
+
+	req := &memdQRequest{}
+	suite.Assert().True(mux.checkBucketEpoch(req))
+	suite.Assert().Equal(uint32(1), req.bucketEpoch)
+
+	// Retrying against the same bucket is fine.
+	suite.Assert().True(mux.checkBucketEpoch(req))
+
+	mux.bucketEpoch++
+	suite.Assert().False(mux.checkBucketEpoch(req))
+
+	// A request which had never been dispatched picks up the new bucket.
+	suite.Assert().True(mux.checkBucketEpoch(&memdQRequest{}))
+}
+
+func (suite *UnitTestSuite) TestKvMuxReconnect() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var lock sync.Mutex
+	var connIDs []string
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName: "default",
+		EndpointEventCallback: func(event EndpointEvent) {
+			if event.Type == EndpointEventConnected {
+				lock.Lock()
+				connIDs = append(connIDs, event.ConnectionID)
+				lock.Unlock()
+			}
+		},
+	})
+	defer agent.Close()
+
+	suite.mustSet(agent, "key")
+
+	lock.Lock()
+	suite.Require().Len(connIDs, 1)
+	lock.Unlock()
+
+	// A rolling reconnect without a deadline could wait forever.
+	err := agent.Reconnect(ReconnectOptions{Rolling: true})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	suite.Require().Nil(agent.Reconnect(ReconnectOptions{
+		Rolling:  true,
+		Deadline: time.Now().Add(5 * time.Second),
+	}))
+
+	lock.Lock()
+	suite.Require().Len(connIDs, 2)
+	suite.Assert().NotEqual(connIDs[0], connIDs[1])
+	lock.Unlock()
+
+	suite.mustSet(agent, "key")
+}
+
+func (suite *UnitTestSuite) TestKvMuxRemovedNodeCompletesInFlight() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	inHandlerCh := make(chan struct{})
+	releaseCh := make(chan struct{})
+	var defaultSet memdmock.HandlerFunc
+	defaultSet = server.Handle(memd.CmdSet, func(req *memd.Packet) *memd.Packet {
+		close(inHandlerCh)
+		<-releaseCh
+		return defaultSet(req)
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	setCh := make(chan error, 1)
+	_, err := agent.Set(SetOptions{
+		Key:      []byte("key"),
+		Value:    []byte("value"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *StoreResult, err error) {
+		setCh <- err
+	})
+	suite.Require().Nil(err)
+	<-inHandlerCh
+
+	// The node leaves the cluster whilst the set is in flight to it.
+	entries := make([][]int, 64)
+	for i := range entries {
+		entries[i] = []int{0}
+	}
+	agent.kvMux.OnNewRouteConfig(&routeConfig{
+		revID:        1000,
+		uuid:         agent.kvMux.ConfigUUID(),
+		name:         "default",
+		bktType:      bktTypeCouchbase,
+		kvServerList: []string{"127.0.0.2:11210"},
+		vbMap:        newVbucketMap(entries, 0),
+	})
+	suite.Assert().Equal(1, agent.kvMux.NumPipelines())
+
+	close(releaseCh)
+	suite.Assert().Nil(<-setCh)
+}
+
+func (suite *UnitTestSuite) TestAgentSelectBucket() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var selects uint32
+	var defaultSelect memdmock.HandlerFunc
+	defaultSelect = server.Handle(memd.CmdSelectBucket, func(req *memd.Packet) *memd.Packet {
+		atomic.AddUint32(&selects, 1)
+		return defaultSelect(req)
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{})
+	defer agent.Close()
+
+	suite.Assert().Empty(agent.BucketName())
+	suite.Assert().Zero(atomic.LoadUint32(&selects))
+
+	suite.Require().Nil(agent.SelectBucket("default"))
+	suite.Assert().Equal("default", agent.BucketName())
+
+	// Kv operations are routed using the selected bucket once its config has been applied.
+	suite.mustSet(agent, "key")
+	suite.Assert().NotZero(atomic.LoadUint32(&selects))
+
+	getCh := make(chan *GetResult, 1)
+	_, err := agent.Get(GetOptions{
+		Key:      []byte("key"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err)
+		getCh <- res
+	})
+	suite.Require().Nil(err)
+	res := <-getCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal([]byte("value"), res.Value)
+}
@@ -13,7 +13,10 @@ type kvMuxState struct {
 	vbMap        *vbucketMap
 	ketamaMap    *ketamaContinuum
 	uuid         string
+	clusterUUID  string
+	clusterName  string
 	revID        int64
+	revEpoch     int64
 
 	bucketCapabilities   map[BucketCapability]BucketCapabilityStatus
 	collectionsSupported bool
@@ -29,12 +32,16 @@ func newKVMuxState(cfg *routeConfig, pipelines []*memdPipeline, deadpipe *memdPi
 		vbMap:        cfg.vbMap,
 		ketamaMap:    cfg.ketamaMap,
 		uuid:         cfg.uuid,
+		clusterUUID:  cfg.clusterUUID,
+		clusterName:  cfg.clusterName,
 		revID:        cfg.revID,
+		revEpoch:     cfg.revEpoch,
 
 		bucketCapabilities: map[BucketCapability]BucketCapabilityStatus{
 			BucketCapabilityDurableWrites:        BucketCapabilityStatusUnknown,
 			BucketCapabilityCreateAsDeleted:      BucketCapabilityStatusUnknown,
 			BucketCapabilityReplaceBodyWithXattr: BucketCapabilityStatusUnknown,
+			BucketCapabilityReviveDocument:       BucketCapabilityStatusUnknown,
 		},
 
 		collectionsSupported: cfg.ContainsBucketCapability("collections"),
@@ -59,6 +66,12 @@ func newKVMuxState(cfg *routeConfig, pipelines []*memdPipeline, deadpipe *memdPi
 		} else {
 			mux.bucketCapabilities[BucketCapabilityReplaceBodyWithXattr] = BucketCapabilityStatusUnsupported
 		}
+
+		if cfg.ContainsBucketCapability("subdoc.ReviveDocument") {
+			mux.bucketCapabilities[BucketCapabilityReviveDocument] = BucketCapabilityStatusSupported
+		} else {
+			mux.bucketCapabilities[BucketCapabilityReviveDocument] = BucketCapabilityStatusUnsupported
+		}
 	}
 
 	return mux
@@ -79,6 +92,24 @@ func (mux *kvMuxState) GetPipeline(index int) *memdPipeline {
 	return mux.pipelines[index]
 }
 
+func (mux *kvMuxState) hasPipeline(pipeline *memdPipeline) bool {
+	for _, p := range mux.pipelines {
+		if p == pipeline {
+			return true
+		}
+	}
+	return false
+}
+
+func (mux *kvMuxState) hasKvServer(address string) bool {
+	for _, hostPort := range mux.kvServerList {
+		if hostPort == address {
+			return true
+		}
+	}
+	return false
+}
+
 func (mux *kvMuxState) HasBucketCapabilityStatus(cap BucketCapability, status BucketCapabilityStatus) bool {
 	st, ok := mux.bucketCapabilities[cap]
 	if !ok {
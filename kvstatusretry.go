@@ -0,0 +1,82 @@
+package gocbcore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// KVStatusRetryBehavior specifies how kv requests which fail with a particular status are handled.
+// Volatile: This API is subject to change at any time.
+type KVStatusRetryBehavior uint8
+
+const (
+	// KVStatusNeverRetry means that requests are never retried by the agent. The agent's other handling of the
+	// status still applies, for example the config in a not my vbucket response is still used.
+	KVStatusNeverRetry KVStatusRetryBehavior = iota + 1
+
+	// KVStatusRetryConstant means that requests are retried after a constant interval, for as long as their retry
+	// strategy allows retries for KVStatusOverrideRetryReason. Once the retry strategy stops retrying the request it
+	// fails as with KVStatusNeverRetry.
+	KVStatusRetryConstant
+
+	// KVStatusFailFast means that requests fail immediately with the error for the status, skipping all of the
+	// agent's handling of it.
+	KVStatusFailFast
+)
+
+// defaultKVStatusRetryInterval is used for KVStatusRetryConstant overrides which do not specify an interval.
+const defaultKVStatusRetryInterval = 50 * time.Millisecond
+
+// KVStatusRetryOverride overrides how kv requests which fail with a particular status are retried.
+// Volatile: This API is subject to change at any time.
+type KVStatusRetryOverride struct {
+	Behavior KVStatusRetryBehavior
+
+	// Interval is the time to wait between retries when Behavior is KVStatusRetryConstant, defaulting to 50ms.
+	Interval time.Duration
+}
+
+func copyKVStatusRetryOverrides(
+	overrides map[memd.StatusCode]KVStatusRetryOverride) map[memd.StatusCode]KVStatusRetryOverride {
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	copied := make(map[memd.StatusCode]KVStatusRetryOverride, len(overrides))
+	for status, override := range overrides {
+		copied[status] = override
+	}
+
+	return copied
+}
+
+func (mux *kvMux) handleStatusOverride(resp *memdQResponse, req *memdQRequest, originalErr error,
+	override KVStatusRetryOverride) (bool, error) {
+	switch override.Behavior {
+	case KVStatusFailFast:
+		return false, translateMemdError(originalErr, req)
+	case KVStatusRetryConstant:
+		interval := override.Interval
+		if interval == 0 {
+			interval = defaultKVStatusRetryInterval
+		}
+
+		shouldRetry, _ := retryOrchMaybeRetry(req, KVStatusOverrideRetryReason)
+		if shouldRetry {
+			go func() {
+				time.Sleep(interval)
+				mux.RequeueDirect(req, true)
+			}()
+			return true, nil
+		}
+	}
+
+	err := translateMemdError(originalErr, req)
+	if errors.Is(err, ErrNotMyVBucket) {
+		mux.applyNotMyVbucketConfig(resp)
+	}
+
+	return false, mux.errMapMgr.EnhanceKvError(err, resp, req)
+}
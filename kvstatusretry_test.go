@@ -0,0 +1,73 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestKVStatusRetryOverrides() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var busyCount uint32
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		switch string(req.Key) {
+		case "tmpfail":
+			return &memd.Packet{Status: memd.StatusTmpFail}
+		case "locked":
+			return &memd.Packet{Status: memd.StatusLocked}
+		case "busy":
+			// Busy is not normally retried, fail the first two attempts.
+			if atomic.AddUint32(&busyCount, 1) <= 2 {
+				return &memd.Packet{Status: memd.StatusBusy}
+			}
+			return &memd.Packet{Status: memd.StatusSuccess, Extras: []byte{0, 0, 0, 0}, Value: []byte("value")}
+		}
+		return defaultGet(req)
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName: "default",
+		KVStatusRetryOverrides: map[memd.StatusCode]KVStatusRetryOverride{
+			memd.StatusTmpFail: {Behavior: KVStatusNeverRetry},
+			memd.StatusLocked:  {Behavior: KVStatusFailFast},
+			memd.StatusBusy:    {Behavior: KVStatusRetryConstant, Interval: 5 * time.Millisecond},
+		},
+	})
+	defer agent.Close()
+
+	type getResult struct {
+		res *GetResult
+		err error
+	}
+	get := func(key string) getResult {
+		resCh := make(chan getResult, 1)
+		_, err := agent.Get(GetOptions{
+			Key:           []byte(key),
+			Deadline:      time.Now().Add(5 * time.Second),
+			RetryStrategy: NewBestEffortRetryStrategy(nil),
+		}, func(res *GetResult, err error) {
+			resCh <- getResult{res: res, err: err}
+		})
+		suite.Require().Nil(err)
+		return <-resCh
+	}
+
+	// Without the overrides both of these would be retried until they timed out.
+	start := time.Now()
+	res := get("tmpfail")
+	suite.Assert().True(errors.Is(res.err, ErrTemporaryFailure))
+	res = get("locked")
+	suite.Assert().True(errors.Is(res.err, ErrDocumentLocked))
+	suite.Assert().Less(int64(time.Since(start)), int64(time.Second))
+
+	res = get("busy")
+	suite.Require().Nil(res.err)
+	suite.Assert().Equal("value", string(res.res.Value))
+	suite.Assert().Equal(uint32(3), atomic.LoadUint32(&busyCount))
+}
@@ -0,0 +1,59 @@
+package gocbcore
+
+import (
+	"time"
+)
+
+// kvTimeoutComponent applies deadlines to kv requests, using the agent wide default timeout for any request which
+// does not specify a deadline of its own.
+type kvTimeoutComponent struct {
+	defaultTimeout time.Duration
+	wheel          *timerWheel
+}
+
+func newKvTimeoutComponent(defaultTimeout time.Duration, wheel *timerWheel) *kvTimeoutComponent {
+	return &kvTimeoutComponent{
+		defaultTimeout: defaultTimeout,
+		wheel:          wheel,
+	}
+}
+
+// Wheel returns the timer wheel used to track deadlines.
+func (tc *kvTimeoutComponent) Wheel() *timerWheel {
+	if tc == nil {
+		return nil
+	}
+
+	return tc.wheel
+}
+
+// Deadline returns deadline, or the default deadline for a request starting now if deadline is not set.
+func (tc *kvTimeoutComponent) Deadline(deadline time.Time) time.Time {
+	if tc == nil {
+		return deadline
+	}
+
+	if deadline.IsZero() && tc.defaultTimeout > 0 {
+		return time.Now().Add(tc.defaultTimeout)
+	}
+
+	return deadline
+}
+
+// Track cancels req with a TimeoutError wrapping timeoutErr if it has not completed by deadline, or by the default
+// deadline if deadline is not set. It is safe to call on a nil component.
+func (tc *kvTimeoutComponent) Track(req *memdQRequest, deadline time.Time, operationID string, timeoutErr error) {
+	if tc == nil {
+		return
+	}
+
+	deadline = tc.Deadline(deadline)
+	if deadline.IsZero() {
+		return
+	}
+
+	start := time.Now()
+	req.SetTimer(tc.wheel.AfterFunc(deadline.Sub(start), func() {
+		req.cancelWithCallback(req.makeTimeoutError(timeoutErr, operationID, start))
+	}))
+}
@@ -0,0 +1,132 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyEWMAWeight is the weight given to each new latency sample in the smoothed latency of an endpoint.
+const latencyEWMAWeight = 0.2
+
+// LatencyProbeConfig controls the periodic sending of NOOPs on kv connections in order to measure the latency of
+// each endpoint.
+// Volatile: This API is subject to change at any time.
+type LatencyProbeConfig struct {
+	// Interval is how often each connection is probed. If zero then latency probing is disabled, although latencies
+	// measured by keepalives and circuit breaker canaries are still recorded.
+	Interval time.Duration
+
+	// Timeout is how long to wait for a probe response before it is counted as a failure. Defaults to Interval.
+	Timeout time.Duration
+}
+
+func (c LatencyProbeConfig) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return c.Interval
+}
+
+// EndpointLatency describes the latency measured for a single kv connection.
+// Volatile: This API is subject to change at any time.
+type EndpointLatency struct {
+	Address      string
+	ConnectionID string
+
+	// Latency is the smoothed round trip time of probes sent on the connection.
+	Latency time.Duration
+
+	// LastLatency is the round trip time of the most recent successful probe.
+	LastLatency time.Duration
+
+	// LastProbed is the time at which the most recent probe completed, or the zero time if none have.
+	LastProbed time.Time
+
+	// ConsecutiveFailures is the number of probes which have failed since the last successful one.
+	ConsecutiveFailures uint32
+
+	// Score is the health of the endpoint between 0 and 1, relative to the other endpoints. The endpoint with the
+	// lowest latency scores 1, others score the ratio of that latency to their own. Endpoints whose most recent probe
+	// failed score 0, and endpoints which have not been probed yet score 1. The score is exposed for monitoring only,
+	// it is not used when routing requests.
+	Score float64
+}
+
+type endpointLatency struct {
+	lock                sync.Mutex
+	ewma                time.Duration
+	last                time.Duration
+	lastProbed          time.Time
+	consecutiveFailures uint32
+}
+
+func (l *endpointLatency) record(latency time.Duration, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.lastProbed = time.Now()
+	if err != nil {
+		l.consecutiveFailures++
+		return
+	}
+
+	l.consecutiveFailures = 0
+	l.last = latency
+	if l.ewma == 0 {
+		l.ewma = latency
+	} else {
+		l.ewma = time.Duration(latencyEWMAWeight*float64(latency) + (1-latencyEWMAWeight)*float64(l.ewma))
+	}
+}
+
+func (l *endpointLatency) snapshot() EndpointLatency {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return EndpointLatency{
+		Latency:             l.ewma,
+		LastLatency:         l.last,
+		LastProbed:          l.lastProbed,
+		ConsecutiveFailures: l.consecutiveFailures,
+	}
+}
+
+// scoreEndpointLatencies populates the Score of each of the latencies relative to one another.
+func scoreEndpointLatencies(latencies []EndpointLatency) {
+	var best time.Duration
+	for _, latency := range latencies {
+		if latency.ConsecutiveFailures == 0 && latency.Latency > 0 && (best == 0 || latency.Latency < best) {
+			best = latency.Latency
+		}
+	}
+
+	for i := range latencies {
+		latency := &latencies[i]
+		switch {
+		case latency.ConsecutiveFailures > 0:
+			latency.Score = 0
+		case latency.Latency == 0:
+			latency.Score = 1
+		default:
+			latency.Score = float64(best) / float64(latency.Latency)
+		}
+	}
+}
+
+func (client *memdClient) latencyProbeLoop(cfg LatencyProbeConfig) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-client.closeNotify:
+			return
+		case <-ticker.C:
+		}
+
+		_, err := client.sendNoop(cfg.timeout())
+		if err != nil {
+			client.logCtx.logDebugf("Latency probe failed: %v", err)
+		}
+	}
+}
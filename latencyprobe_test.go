@@ -0,0 +1,66 @@
+package gocbcore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestScoreEndpointLatencies() {
+	var fast, slow, failed, unprobed endpointLatency
+	fast.record(10*time.Millisecond, nil)
+	slow.record(40*time.Millisecond, nil)
+	failed.record(10*time.Millisecond, nil)
+	failed.record(0, errors.New("timed out"))
+
+	latencies := []EndpointLatency{fast.snapshot(), slow.snapshot(), failed.snapshot(), unprobed.snapshot()}
+	scoreEndpointLatencies(latencies)
+
+	suite.Assert().Equal(1.0, latencies[0].Score)
+	suite.Assert().Equal(0.25, latencies[1].Score)
+	suite.Assert().Equal(0.0, latencies[2].Score)
+	suite.Assert().Equal(uint32(1), latencies[2].ConsecutiveFailures)
+	suite.Assert().Equal(1.0, latencies[3].Score)
+
+	// Latency is smoothed so a single slow probe does not dominate.
+	fast.record(110*time.Millisecond, nil)
+	snapshot := fast.snapshot()
+	suite.Assert().Equal(30*time.Millisecond, snapshot.Latency)
+	suite.Assert().Equal(110*time.Millisecond, snapshot.LastLatency)
+}
+
+func (suite *UnitTestSuite) TestLatencyProbe() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	server.Handle(memd.CmdNoop, func(req *memd.Packet) *memd.Packet {
+		time.Sleep(5 * time.Millisecond)
+		return &memd.Packet{Status: memd.StatusSuccess}
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName: "default",
+		LatencyProbeConfig: LatencyProbeConfig{
+			Interval: 10 * time.Millisecond,
+		},
+	})
+	defer agent.Close()
+
+	var latencies []EndpointLatency
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		latencies = agent.EndpointLatencies()
+		if len(latencies) == 1 && !latencies[0].LastProbed.IsZero() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	suite.Require().Len(latencies, 1)
+	suite.Assert().Equal(server.Address(), latencies[0].Address)
+	suite.Assert().NotEmpty(latencies[0].ConnectionID)
+	suite.Assert().True(latencies[0].LastLatency >= 5*time.Millisecond, latencies[0].LastLatency.String())
+	suite.Assert().Equal(1.0, latencies[0].Score)
+}
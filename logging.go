@@ -23,15 +23,52 @@ const (
 )
 
 func redactUserData(v interface{}) string {
-	return fmt.Sprintf("<ud>%v<ud>", v)
+	return fmt.Sprintf("<ud>%v</ud>", v)
 }
 
 func redactMetaData(v interface{}) string {
-	return fmt.Sprintf("<md>%v<md>", v)
+	return fmt.Sprintf("<md>%v</md>", v)
 }
 
 func redactSystemData(v interface{}) string {
-	return fmt.Sprintf("<sd>%v<sd>", v)
+	return fmt.Sprintf("<sd>%v</sd>", v)
+}
+
+// redactableValue is a log argument which is wrapped in redaction tags once the redaction level reaches minLevel.
+type redactableValue struct {
+	value    interface{}
+	minLevel LogRedactLevel
+	redactFn func(interface{}) string
+}
+
+func (v redactableValue) redacted() interface{} {
+	if globalLogRedactionLevel < v.minLevel {
+		return v.value
+	}
+
+	return v.redactFn(v.value)
+}
+
+func (v redactableValue) String() string {
+	return fmt.Sprint(v.value)
+}
+
+// logUserData marks a log argument as user data, such as a document key or username. It is redacted when the
+// redaction level is partial or full.
+func logUserData(v interface{}) redactableValue {
+	return redactableValue{value: v, minLevel: RedactPartial, redactFn: redactUserData}
+}
+
+// logMetaData marks a log argument as metadata, such as a bucket, scope or collection name. It is redacted when the
+// redaction level is full.
+func logMetaData(v interface{}) redactableValue {
+	return redactableValue{value: v, minLevel: RedactFull, redactFn: redactMetaData}
+}
+
+// logSystemData marks a log argument as system data, such as a hostname. It is redacted when the redaction level is
+// full.
+func logSystemData(v interface{}) redactableValue {
+	return redactableValue{value: v, minLevel: RedactFull, redactFn: redactSystemData}
 }
 
 // LogRedactLevel specifies the degree with which to redact the logs.
@@ -48,7 +85,9 @@ const (
 	RedactFull
 )
 
-// SetLogRedactionLevel specifies the level with which logs should be redacted.
+// SetLogRedactionLevel specifies the level with which logs should be redacted. Redacted values are wrapped in
+// <ud>, <md> or <sd> tags, for user data, metadata and system data respectively, so that they can be removed by log
+// redaction tooling before logs are shared.
 func SetLogRedactionLevel(level LogRedactLevel) {
 	globalLogRedactionLevel = level
 }
@@ -95,6 +134,31 @@ type Logger interface {
 	Log(level LogLevel, offset int, format string, v ...interface{}) error
 }
 
+// LogField is a key/value pair attached to a log message, such as the agent or endpoint the message relates to.
+type LogField struct {
+	Key   string
+	Value interface{}
+}
+
+// StructuredLogger defines a logging interface which receives messages as a pre-formatted message along with a
+// set of key/value fields, allowing them to be forwarded to structured logging libraries such as zap or logrus.
+// When the logger passed to SetLogger also implements StructuredLogger, LogFields is used in place of Log.
+// Volatile: This API is subject to change at any time.
+type StructuredLogger interface {
+	Logger
+
+	// Outputs logging information:
+	// level is the verbosity level
+	// offset is the position within the calling stack from which the message
+	// originated.
+	// msg is the fully formatted, and redacted, message.
+	// fields are additional context about the message, such as "agent", "endpoint" and "conn".
+	LogFields(level LogLevel, offset int, msg string, fields []LogField) error
+
+	// LogLevelEnabled returns whether messages at level are output, messages at other levels are never formatted.
+	LogLevelEnabled(level LogLevel) bool
+}
+
 type defaultLogger struct {
 	Level    LogLevel
 	GoLogger *log.Logger
@@ -137,7 +201,8 @@ func VerboseStdioLogger() Logger {
 
 // SetLogger sets a logger to be used by the library. A logger can be obtained via
 // the DefaultStdioLogger() or VerboseStdioLogger() functions. You can also implement
-// your own logger using the Logger interface.
+// your own logger using the Logger interface, or the StructuredLogger interface to receive messages along with
+// their contextual fields.
 func SetLogger(logger Logger) {
 	globalLogger = logger
 }
@@ -146,10 +211,14 @@ type redactableLogValue interface {
 	redacted() interface{}
 }
 
-func logExf(level LogLevel, offset int, format string, v ...interface{}) {
+func logExf(level LogLevel, offset int, fields []LogField, format string, v ...interface{}) {
 	if globalLogger != nil {
-		if level <= LogInfo && !isLogRedactionLevelNone() {
-			// We only redact at info level or below.
+		structuredLogger, isStructured := globalLogger.(StructuredLogger)
+		if isStructured && !structuredLogger.LogLevelEnabled(level) {
+			return
+		}
+
+		if !isLogRedactionLevelNone() {
 			for i, iv := range v {
 				if redactable, ok := iv.(redactableLogValue); ok {
 					v[i] = redactable.redacted()
@@ -157,7 +226,20 @@ func logExf(level LogLevel, offset int, format string, v ...interface{}) {
 			}
 		}
 
-		err := globalLogger.Log(level, offset+1, format, v...)
+		var err error
+		if isStructured {
+			logFields := make([]LogField, len(fields))
+			for i, field := range fields {
+				if redactable, ok := field.Value.(redactableLogValue); ok {
+					field.Value = redactable.redacted()
+				}
+				logFields[i] = field
+			}
+
+			err = structuredLogger.LogFields(level, offset+1, fmt.Sprintf(format, v...), logFields)
+		} else {
+			err = globalLogger.Log(level, offset+1, format, v...)
+		}
 		if err != nil {
 			log.Printf("Logger error occurred (%s)\n", err)
 		}
@@ -165,23 +247,53 @@ func logExf(level LogLevel, offset int, format string, v ...interface{}) {
 }
 
 func logDebugf(format string, v ...interface{}) {
-	logExf(LogDebug, 1, format, v...)
+	logExf(LogDebug, 1, nil, format, v...)
 }
 
 func logSchedf(format string, v ...interface{}) {
-	logExf(LogSched, 1, format, v...)
+	logExf(LogSched, 1, nil, format, v...)
 }
 
 func logWarnf(format string, v ...interface{}) {
-	logExf(LogWarn, 1, format, v...)
+	logExf(LogWarn, 1, nil, format, v...)
 }
 
 func logErrorf(format string, v ...interface{}) {
-	logExf(LogError, 1, format, v...)
+	logExf(LogError, 1, nil, format, v...)
 }
 
 func logInfof(format string, v ...interface{}) {
-	logExf(LogInfo, 1, format, v...)
+	logExf(LogInfo, 1, nil, format, v...)
+}
+
+// logContext is a set of fields which are attached to every message logged through it, so that messages logged by
+// a component can be correlated by structured loggers.
+type logContext []LogField
+
+func (c logContext) With(key string, value interface{}) logContext {
+	fields := make(logContext, len(c), len(c)+1)
+	copy(fields, c)
+	return append(fields, LogField{Key: key, Value: value})
+}
+
+func (c logContext) logDebugf(format string, v ...interface{}) {
+	logExf(LogDebug, 1, c, format, v...)
+}
+
+func (c logContext) logSchedf(format string, v ...interface{}) {
+	logExf(LogSched, 1, c, format, v...)
+}
+
+func (c logContext) logWarnf(format string, v ...interface{}) {
+	logExf(LogWarn, 1, c, format, v...)
+}
+
+func (c logContext) logErrorf(format string, v ...interface{}) {
+	logExf(LogError, 1, c, format, v...)
+}
+
+func (c logContext) logInfof(format string, v ...interface{}) {
+	logExf(LogInfo, 1, c, format, v...)
 }
 
 func reindentLog(indent, message string) string {
@@ -0,0 +1,145 @@
+package gocbcore
+
+import (
+	"fmt"
+)
+
+type captureLogger struct {
+	messages []string
+}
+
+func (l *captureLogger) Log(level LogLevel, offset int, format string, v ...interface{}) error {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+	return nil
+}
+
+func (suite *UnitTestSuite) TestLogRedaction() {
+	origLogger, origLevel := globalLogger, globalLogRedactionLevel
+	defer func() {
+		globalLogger = origLogger
+		globalLogRedactionLevel = origLevel
+	}()
+
+	logger := &captureLogger{}
+	SetLogger(logger)
+	logLine := func() string {
+		kvErr := &KeyValueError{InnerError: errDocumentNotFound, DocumentKey: "key", BucketName: "bucket"}
+		logDebugf("%s %s %s %v %+v", logUserData("key"), logMetaData("bucket"), logSystemData("10.0.0.1"), kvErr,
+			PasswordAuthProvider{Username: "user", Password: "secret"})
+		return logger.messages[len(logger.messages)-1]
+	}
+
+	SetLogRedactionLevel(RedactNone)
+	suite.Assert().Equal(`key bucket 10.0.0.1 document not found | {"document_key":"key","bucket":"bucket","vbucket_id":0} `+
+		`{Username:user Password:***}`, logLine())
+
+	SetLogRedactionLevel(RedactPartial)
+	suite.Assert().Equal(`<ud>key</ud> bucket 10.0.0.1 document not found | {"document_key":"<ud>key</ud>","bucket":"bucket","vbucket_id":0} `+
+		`{Username:<ud>user</ud> Password:***}`, logLine())
+
+	SetLogRedactionLevel(RedactFull)
+	line := logLine()
+	suite.Assert().Contains(line, "<ud>key</ud> <md>bucket</md> <sd>10.0.0.1</sd>")
+	suite.Assert().Contains(line, `<md>bucket</md>`)
+	suite.Assert().NotContains(line, "secret")
+}
+
+type captureStructuredLogger struct {
+	captureLogger
+	level  LogLevel
+	fields [][]LogField
+}
+
+func (l *captureStructuredLogger) LogLevelEnabled(level LogLevel) bool {
+	return level <= l.level
+}
+
+func (l *captureStructuredLogger) LogFields(level LogLevel, offset int, msg string, fields []LogField) error {
+	l.messages = append(l.messages, msg)
+	l.fields = append(l.fields, fields)
+	return nil
+}
+
+func (suite *UnitTestSuite) TestStructuredLogger() {
+	origLogger, origLevel := globalLogger, globalLogRedactionLevel
+	defer func() {
+		globalLogger = origLogger
+		globalLogRedactionLevel = origLevel
+	}()
+
+	logger := &captureStructuredLogger{level: LogDebug}
+	SetLogger(logger)
+	SetLogRedactionLevel(RedactNone)
+
+	logDebugf("no fields %d", 1)
+	suite.Require().Len(logger.messages, 1)
+	suite.Assert().Equal("no fields 1", logger.messages[0])
+	suite.Assert().Empty(logger.fields[0])
+
+	ctx := logContext{{Key: "agent", Value: "agentid"}}
+	clientCtx := ctx.With("endpoint", logSystemData("10.0.0.1:11210"))
+	clientCtx.logWarnf("fetching %s", logUserData("key"))
+	suite.Require().Len(logger.messages, 2)
+	suite.Assert().Equal("fetching key", logger.messages[1])
+	suite.Assert().Equal([]LogField{
+		{Key: "agent", Value: "agentid"},
+		{Key: "endpoint", Value: "10.0.0.1:11210"},
+	}, logger.fields[1])
+
+	// Deriving a context must not modify the parent's fields.
+	suite.Assert().Len(ctx, 1)
+
+	SetLogRedactionLevel(RedactFull)
+	clientCtx.logWarnf("fetching %s", logUserData("key"))
+	suite.Assert().Equal("fetching <ud>key</ud>", logger.messages[2])
+	suite.Assert().Equal("<sd>10.0.0.1:11210</sd>", logger.fields[2][1].Value)
+
+	// Messages at disabled levels must not be formatted.
+	formatted := &countingStringer{}
+	clientCtx.logSchedf("dispatching %s", formatted)
+	suite.Assert().Len(logger.messages, 3)
+	suite.Assert().Zero(formatted.calls)
+
+	clientCtx.logDebugf("dispatching %s", formatted)
+	suite.Assert().Len(logger.messages, 4)
+	suite.Assert().Equal(1, formatted.calls)
+}
+
+type countingStringer struct {
+	calls int
+}
+
+func (s *countingStringer) String() string {
+	s.calls++
+	return "op"
+}
+
+func (suite *UnitTestSuite) TestComponentLogContext() {
+	origLogger, origLevel := globalLogger, globalLogRedactionLevel
+	defer func() {
+		globalLogger = origLogger
+		globalLogRedactionLevel = origLevel
+	}()
+
+	logger := &captureStructuredLogger{level: LogDebug}
+	SetLogger(logger)
+	SetLogRedactionLevel(RedactNone)
+
+	cm := newConfigManager(configManagerProperties{LogContext: logContext{{Key: "agent", Value: "agentid"}}})
+	cm.updateRouteConfig(&routeConfig{revID: 1, bktType: bktTypeNone})
+	cm.updateRouteConfig(&routeConfig{revID: 1, bktType: bktTypeNone})
+
+	suite.Require().NotEmpty(logger.messages)
+	suite.Assert().Equal("Ignoring configuration with identical revision number",
+		logger.messages[len(logger.messages)-1])
+	for _, fields := range logger.fields {
+		suite.Assert().Equal([]LogField{{Key: "agent", Value: "agentid"}}, fields)
+	}
+
+	pipeline := newPipeline("10.0.0.1:11210", 1, 10, ReconnectBackoffConfig{}, 0, nil, cm.logCtx)
+	newMemdPipelineClient(pipeline).logCtx.logDebugf("connecting")
+	suite.Assert().Equal([]LogField{
+		{Key: "agent", Value: "agentid"},
+		{Key: "endpoint", Value: "10.0.0.1:11210"},
+	}, logger.fields[len(logger.fields)-1])
+}
@@ -16,6 +16,7 @@ const (
 	CmdIncrement                  = CmdCode(0x05)
 	CmdDecrement                  = CmdCode(0x06)
 	CmdNoop                       = CmdCode(0x0a)
+	CmdVersion                    = CmdCode(0x0b)
 	CmdAppend                     = CmdCode(0x0e)
 	CmdPrepend                    = CmdCode(0x0f)
 	CmdStat                       = CmdCode(0x10)
@@ -94,6 +95,8 @@ func (command CmdCode) Name() string {
 		return "CMD_DECREMENT"
 	case CmdNoop:
 		return "CMD_NOOP"
+	case CmdVersion:
+		return "CMD_VERSION"
 	case CmdAppend:
 		return "CMD_APPEND"
 	case CmdPrepend:
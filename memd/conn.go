@@ -31,12 +31,19 @@ func releaseWriteBuf(buf *bytes.Buffer) {
 	bufPool.Put(buf)
 }
 
+// ValueWriterFunc is called by ReadPacket once the header of a packet has been decoded, before its body is read. If it
+// returns a writer then the value is copied to that writer as it is read from the stream, rather than being buffered
+// in the packet. Errors returned by the writer are not reported, the rest of the value is read and discarded so that
+// the stream stays intact.
+type ValueWriterFunc func(pkt *Packet, valueLen int) io.Writer
+
 // Conn represents a memcached protocol connection.
 type Conn struct {
 	reader io.Reader
 	writer io.Writer
 
-	headerBuf [24]byte
+	headerBuf     [24]byte
+	valueWriterFn ValueWriterFunc
 
 	collectionsEnabled bool
 	enabledFeatures    map[HelloFeature]bool
@@ -74,9 +81,22 @@ func (c *Conn) IsFeatureEnabled(feature HelloFeature) bool {
 	return ok && enabled
 }
 
+// SetValueWriterFunc sets the function used to decide whether the value of each packet read should be streamed to a
+// writer, it must not be called concurrently with ReadPacket.
+func (c *Conn) SetValueWriterFunc(fn ValueWriterFunc) {
+	c.valueWriterFn = fn
+}
+
 // WritePacket writes a packet to the network.
 func (c *Conn) WritePacket(pkt *Packet) error {
-	encodedKey := pkt.Key
+	// The collection ID prefix and GetRandom extras are encoded into arrays on the stack so that writing a packet
+	// doesn't need any allocations beyond the pooled write buffer.
+	var (
+		collectionPrefixBuf [5]byte
+		collectionExtrasBuf [4]byte
+		collectionPrefix    []byte
+	)
+
 	extras := pkt.Extras
 	if c.collectionsEnabled {
 		if pkt.Command == CmdObserve {
@@ -87,16 +107,13 @@ func (c *Conn) WritePacket(pkt *Packet) error {
 		}
 
 		if IsCommandCollectionEncoded(pkt.Command) {
-			collEncodedKey := make([]byte, 0, len(encodedKey)+5)
-			collEncodedKey = AppendULEB128_32(collEncodedKey, pkt.CollectionID)
-			collEncodedKey = append(collEncodedKey, encodedKey...)
-			encodedKey = collEncodedKey
+			collectionPrefix = AppendULEB128_32(collectionPrefixBuf[:0], pkt.CollectionID)
 		} else if pkt.Command == CmdGetRandom {
 			// GetRandom expects the cid to be in the extras
 			// GetRandom MUST not have any extras if not using collections so we're ok to just set it.
 			// It also doesn't expect the collection ID to be leb encoded.
-			extras = make([]byte, 4)
-			binary.BigEndian.PutUint32(extras, pkt.CollectionID)
+			binary.BigEndian.PutUint32(collectionExtrasBuf[:], pkt.CollectionID)
+			extras = collectionExtrasBuf[:]
 		} else {
 			if pkt.CollectionID > 0 {
 				return errors.New("cannot encode collection id with a non-collection command")
@@ -109,7 +126,7 @@ func (c *Conn) WritePacket(pkt *Packet) error {
 	}
 
 	extLen := len(extras)
-	keyLen := len(encodedKey)
+	keyLen := len(collectionPrefix) + len(pkt.Key)
 	valLen := len(pkt.Value)
 
 	framesLen := 0
@@ -137,6 +154,15 @@ func (c *Conn) WritePacket(pkt *Packet) error {
 	if pkt.ServerDurationFrame != nil {
 		framesLen += 3
 	}
+	if pkt.ReadUnitsFrame != nil {
+		framesLen += 3
+	}
+	if pkt.WriteUnitsFrame != nil {
+		framesLen += 3
+	}
+	if pkt.ThrottleDurationFrame != nil {
+		framesLen += 3
+	}
 	if pkt.UserImpersonationFrame != nil {
 		userLen := len(pkt.UserImpersonationFrame.User)
 		if userLen < 15 {
@@ -283,6 +309,29 @@ func (c *Conn) WritePacket(pkt *Packet) error {
 		writeUint16(buffer, EncodeSrvDura16(pkt.ServerDurationFrame.ServerDuration))
 	}
 
+	if pkt.ReadUnitsFrame != nil || pkt.WriteUnitsFrame != nil || pkt.ThrottleDurationFrame != nil {
+		if pkt.Magic != CmdMagicRes {
+			return errors.New("cannot use unit usage frames in non-response packets")
+		}
+
+		if !c.IsFeatureEnabled(FeatureReportUnitUsage) {
+			return errors.New("cannot use unit usage frames without enabling the feature")
+		}
+
+		if pkt.ReadUnitsFrame != nil {
+			writeFrameHeader(buffer, frameTypeResReadUnits, 2)
+			writeUint16(buffer, pkt.ReadUnitsFrame.ReadUnits)
+		}
+		if pkt.WriteUnitsFrame != nil {
+			writeFrameHeader(buffer, frameTypeResWriteUnits, 2)
+			writeUint16(buffer, pkt.WriteUnitsFrame.WriteUnits)
+		}
+		if pkt.ThrottleDurationFrame != nil {
+			writeFrameHeader(buffer, frameTypeResThrottleDuration, 2)
+			writeUint16(buffer, EncodeSrvDura16(pkt.ThrottleDurationFrame.ThrottleDuration))
+		}
+	}
+
 	if pkt.UserImpersonationFrame != nil {
 		if pkt.Magic != CmdMagicReq {
 			return errors.New("cannot use user impersonation frame in non-request packets")
@@ -307,7 +356,8 @@ func (c *Conn) WritePacket(pkt *Packet) error {
 	buffer.Write(extras)
 
 	// Copy the encoded key into the body of the packet
-	buffer.Write(encodedKey)
+	buffer.Write(collectionPrefix)
+	buffer.Write(pkt.Key)
 
 	// Copy the value into the body of the packet
 	buffer.Write(pkt.Value)
@@ -337,13 +387,6 @@ func (c *Conn) ReadPacket() (*Packet, int, error) {
 	// Grab the length of the full body
 	bodyLen := binary.BigEndian.Uint32(c.headerBuf[8:])
 
-	// Read the remaining bytes of the body
-	bodyBuf := make([]byte, bodyLen)
-	_, err = io.ReadFull(c.reader, bodyBuf)
-	if err != nil {
-		return nil, 0, err
-	}
-
 	pktMagic := CmdMagic(c.headerBuf[0])
 	switch pktMagic {
 	case CmdMagicReq, cmdMagicReqExt:
@@ -372,6 +415,28 @@ func (c *Conn) ReadPacket() (*Packet, int, error) {
 		keyLen = int(c.headerBuf[3])
 	}
 
+	prefixLen := framesLen + extLen + keyLen
+	if prefixLen > int(bodyLen) {
+		return nil, 0, errors.New("packet body is shorter than its frames, extras and key")
+	}
+
+	var valueWriter io.Writer
+	if c.valueWriterFn != nil {
+		valueWriter = c.valueWriterFn(pkt, int(bodyLen)-prefixLen)
+	}
+
+	// Read everything up to the value, there's no need to allocate space for the value if it's being streamed.
+	var bodyBuf []byte
+	if valueWriter != nil {
+		bodyBuf = make([]byte, prefixLen)
+	} else {
+		bodyBuf = make([]byte, bodyLen)
+	}
+	_, err = io.ReadFull(c.reader, bodyBuf[:prefixLen])
+	if err != nil {
+		return nil, 0, err
+	}
+
 	if framesLen > 0 {
 		var (
 			framesBuf = bodyBuf[:framesLen]
@@ -438,6 +503,18 @@ func (c *Conn) ReadPacket() (*Packet, int, error) {
 					pkt.ServerDurationFrame = &ServerDurationFrame{
 						ServerDuration: DecodeSrvDura16(serverDurationEnc),
 					}
+				} else if frType == frameTypeResReadUnits && frameLen == 2 {
+					pkt.ReadUnitsFrame = &ReadUnitsFrame{
+						ReadUnits: binary.BigEndian.Uint16(frameBody),
+					}
+				} else if frType == frameTypeResWriteUnits && frameLen == 2 {
+					pkt.WriteUnitsFrame = &WriteUnitsFrame{
+						WriteUnits: binary.BigEndian.Uint16(frameBody),
+					}
+				} else if frType == frameTypeResThrottleDuration && frameLen == 2 {
+					pkt.ThrottleDurationFrame = &ThrottleDurationFrame{
+						ThrottleDuration: DecodeSrvDura16(binary.BigEndian.Uint16(frameBody)),
+					}
 				} else {
 					// If we don't understand this frame type, we record it as an
 					// UnsupportedFrame (as opposed to dropping it blindly)
@@ -453,8 +530,7 @@ func (c *Conn) ReadPacket() (*Packet, int, error) {
 	}
 
 	pkt.Extras = bodyBuf[framesLen : framesLen+extLen]
-	pkt.Key = bodyBuf[framesLen+extLen : framesLen+extLen+keyLen]
-	pkt.Value = bodyBuf[framesLen+extLen+keyLen:]
+	pkt.Key = bodyBuf[framesLen+extLen : prefixLen]
 
 	if c.collectionsEnabled {
 		if pkt.Command == CmdObserve {
@@ -475,9 +551,34 @@ func (c *Conn) ReadPacket() (*Packet, int, error) {
 		}
 	}
 
+	if valueWriter != nil {
+		_, err = io.CopyN(&discardOnErrorWriter{w: valueWriter}, c.reader, int64(bodyLen)-int64(prefixLen))
+	} else {
+		pkt.Value = bodyBuf[prefixLen:]
+		_, err = io.ReadFull(c.reader, pkt.Value)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
 	return pkt, 24 + int(bodyLen), nil
 }
 
+// discardOnErrorWriter stops writing to the underlying writer once it returns an error, whilst still reporting
+// success so that the remainder of a value is consumed from the stream.
+type discardOnErrorWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (d *discardOnErrorWriter) Write(p []byte) (int, error) {
+	if d.err == nil {
+		_, d.err = d.w.Write(p)
+	}
+
+	return len(p), nil
+}
+
 // writeUint16 - Similar to 'bytes.BigEndian.PutUint16' accept we write directly into the provided buffer.
 func writeUint16(buffer *bytes.Buffer, n uint16) {
 	buffer.WriteByte(byte(n >> 8))
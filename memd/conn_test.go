@@ -2,6 +2,9 @@ package memd
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
 	"reflect"
 	"testing"
 	"time"
@@ -66,6 +69,7 @@ var allFeatures = []HelloFeature{
 	FeatureSyncReplication,
 	FeatureCollections,
 	FeatureOpenTracing,
+	FeatureReportUnitUsage,
 }
 
 func TestPktRtBasicReq(t *testing.T) {
@@ -141,3 +145,133 @@ func TestPktRtBasicResExt(t *testing.T) {
 		},
 	}, allFeatures)
 }
+
+func TestPktRtUnitUsageResExt(t *testing.T) {
+	testPktRoundTrip(t, &Packet{
+		Magic:   CmdMagicRes,
+		Command: CmdSet,
+		Status:  StatusSuccess,
+		Opaque:  0x87654321,
+		Cas:     0x7654321076543210,
+		Key:     []byte{},
+		Extras:  []byte{},
+		Value:   []byte{},
+		ServerDurationFrame: &ServerDurationFrame{
+			ServerDuration: 119973 * time.Microsecond,
+		},
+		ReadUnitsFrame: &ReadUnitsFrame{
+			ReadUnits: 3,
+		},
+		WriteUnitsFrame: &WriteUnitsFrame{
+			WriteUnits: 0x1234,
+		},
+		ThrottleDurationFrame: &ThrottleDurationFrame{
+			ThrottleDuration: 119973 * time.Microsecond,
+		},
+	}, allFeatures)
+}
+
+type testFailingWriter struct{}
+
+func (w testFailingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestReadPacketStreamsValue(t *testing.T) {
+	buf := &bytes.Buffer{}
+	conn := NewConn(buf)
+
+	for i, value := range []string{"streamed", "buffered", "discarded", "after"} {
+		err := conn.WritePacket(&Packet{
+			Magic:   CmdMagicRes,
+			Command: CmdGet,
+			Opaque:  uint32(i),
+			Extras:  []byte{0, 0, 0, 1},
+			Value:   []byte(value),
+		})
+		if err != nil {
+			t.Fatalf("packet writing failed: %s", err)
+		}
+	}
+
+	streamed := &bytes.Buffer{}
+	conn.SetValueWriterFunc(func(pkt *Packet, valueLen int) io.Writer {
+		if pkt.Command != CmdGet || pkt.Magic != CmdMagicRes {
+			t.Errorf("expected the header to be decoded before the value")
+		}
+
+		switch pkt.Opaque {
+		case 0:
+			if valueLen != len("streamed") {
+				t.Errorf("unexpected value length %d", valueLen)
+			}
+			return streamed
+		case 2:
+			return testFailingWriter{}
+		}
+		return nil
+	})
+
+	expected := []string{"", "buffered", "", "after"}
+	for i, value := range expected {
+		pkt, n, err := conn.ReadPacket()
+		if err != nil {
+			t.Fatalf("packet reading failed: %s", err)
+		}
+		if string(pkt.Value) != value {
+			t.Errorf("packet %d had value %s, expected %s", i, pkt.Value, value)
+		}
+		if !bytes.Equal(pkt.Extras, []byte{0, 0, 0, 1}) {
+			t.Errorf("packet %d had unexpected extras %v", i, pkt.Extras)
+		}
+		if n == 0 {
+			t.Errorf("expected the full packet length to be reported")
+		}
+	}
+
+	if streamed.String() != "streamed" {
+		t.Errorf("unexpected streamed value %s", streamed.String())
+	}
+}
+
+func TestWritePacketAllocations(t *testing.T) {
+	conn := NewConn(struct {
+		io.Reader
+		io.Writer
+	}{&bytes.Buffer{}, ioutil.Discard})
+	for _, feature := range allFeatures {
+		conn.EnableFeature(feature)
+	}
+
+	pkts := []*Packet{
+		{
+			Magic:        CmdMagicReq,
+			Command:      CmdSet,
+			Vbucket:      0x9f9e,
+			Opaque:       0x87654321,
+			CollectionID: 99,
+			Key:          []byte("Hello"),
+			Extras:       []byte("12345678"),
+			Value:        []byte("World"),
+			DurabilityLevelFrame: &DurabilityLevelFrame{
+				DurabilityLevel: DurabilityLevelMajority,
+			},
+		},
+		{
+			Magic:        CmdMagicReq,
+			Command:      CmdGetRandom,
+			CollectionID: 99,
+		},
+	}
+
+	for _, pkt := range pkts {
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := conn.WritePacket(pkt); err != nil {
+				t.Fatalf("packet writing failed: %s", err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("expected writing %s to not allocate but saw %.0f allocations", pkt.Command.Name(), allocs)
+		}
+	}
+}
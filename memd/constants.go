@@ -31,6 +31,9 @@ const (
 	frameTypeReqOpenTracing       = frameType(3)
 	frameTypeReqUserImpersonation = frameType(4)
 	frameTypeResSrvDuration       = frameType(0)
+	frameTypeResReadUnits         = frameType(1)
+	frameTypeResWriteUnits        = frameType(2)
+	frameTypeResThrottleDuration  = frameType(3)
 )
 
 // HelloFeature represents a feature code included in a memcached
@@ -99,6 +102,9 @@ const (
 
 	// FeatureReplaceBodyWithXattr indicates support for the replace body with xattr feature.
 	FeatureReplaceBodyWithXattr = HelloFeature(0x19)
+
+	// FeatureReportUnitUsage indicates support for reporting the read and write units consumed by operations.
+	FeatureReportUnitUsage = HelloFeature(0x1a)
 )
 
 // StreamEndStatus represents the reason for a DCP stream ending
@@ -374,13 +380,18 @@ const (
 	SubdocDocFlagAddDoc = SubdocDocFlag(0x02)
 
 	// SubdocDocFlagAccessDeleted indicates that you wish to receive soft-deleted documents.
-	// Internal: This should never be used and is not supported.
+	// Uncommitted: This API may change in the future.
 	SubdocDocFlagAccessDeleted = SubdocDocFlag(0x04)
 
 	// SubdocDocFlagCreateAsDeleted indicates that the document should be created as deleted.
 	// That is, to create a tombstone only.
-	// Internal: This should never be used and is not supported.
+	// Uncommitted: This API may change in the future.
 	SubdocDocFlagCreateAsDeleted = SubdocDocFlag(0x08)
+
+	// SubdocDocFlagReviveDocument indicates that a deleted document should be revived, that is its body and user
+	// xattrs are made live again. This must be used together with SubdocDocFlagAccessDeleted.
+	// Uncommitted: This API may change in the future.
+	SubdocDocFlagReviveDocument = SubdocDocFlag(0x10)
 )
 
 // DurabilityLevel specifies the level to use for enhanced durability requirements.
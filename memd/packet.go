@@ -44,6 +44,24 @@ type ServerDurationFrame struct {
 	ServerDuration time.Duration
 }
 
+// ReadUnitsFrame allows the server to return the number of read units
+// consumed by an operation on a metered deployment.
+type ReadUnitsFrame struct {
+	ReadUnits uint16
+}
+
+// WriteUnitsFrame allows the server to return the number of write units
+// consumed by an operation on a metered deployment.
+type WriteUnitsFrame struct {
+	WriteUnits uint16
+}
+
+// ThrottleDurationFrame allows the server to return the period of time an
+// operation was delayed because the throttle limit had been reached.
+type ThrottleDurationFrame struct {
+	ThrottleDuration time.Duration
+}
+
 // UnsupportedFrame is used to include an unsupported frame type in the
 // packet data to enable further processing if needed.
 type UnsupportedFrame struct {
@@ -78,6 +96,9 @@ type Packet struct {
 	StreamIDFrame          *StreamIDFrame
 	OpenTracingFrame       *OpenTracingFrame
 	ServerDurationFrame    *ServerDurationFrame
+	ReadUnitsFrame         *ReadUnitsFrame
+	WriteUnitsFrame        *WriteUnitsFrame
+	ThrottleDurationFrame  *ThrottleDurationFrame
 	UserImpersonationFrame *UserImpersonationFrame
 	UnsupportedFrames      []UnsupportedFrame
 }
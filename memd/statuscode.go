@@ -62,6 +62,26 @@ const (
 	// yet ready to accept operations on behalf of a particular bucket.
 	StatusNotInitialized = StatusCode(0x25)
 
+	// StatusRateLimitedNetworkIngress occurs when the server rejects a request because the
+	// amount of data sent to it has exceeded the allowed rate.
+	StatusRateLimitedNetworkIngress = StatusCode(0x30)
+
+	// StatusRateLimitedNetworkEgress occurs when the server rejects a request because the
+	// amount of data sent from it has exceeded the allowed rate.
+	StatusRateLimitedNetworkEgress = StatusCode(0x31)
+
+	// StatusRateLimitedMaxConnections occurs when the server rejects a request because the
+	// maximum number of connections has been exceeded.
+	StatusRateLimitedMaxConnections = StatusCode(0x32)
+
+	// StatusRateLimitedMaxCommands occurs when the server rejects a request because the
+	// number of requests sent to it has exceeded the allowed rate.
+	StatusRateLimitedMaxCommands = StatusCode(0x33)
+
+	// StatusRateLimitedScopeSizeLimitExceeded occurs when the server rejects a request because
+	// the scope has reached its data size quota.
+	StatusRateLimitedScopeSizeLimitExceeded = StatusCode(0x34)
+
 	// StatusUnknownCommand occurs when an unknown operation is sent to a server.
 	StatusUnknownCommand = StatusCode(0x81)
 
@@ -220,6 +240,16 @@ func (code StatusCode) KVText() string {
 		return "cluster is being initialized, requests are blocked"
 	case StatusRollback:
 		return "rollback is required"
+	case StatusRateLimitedNetworkIngress:
+		return "network ingress rate limit exceeded"
+	case StatusRateLimitedNetworkEgress:
+		return "network egress rate limit exceeded"
+	case StatusRateLimitedMaxConnections:
+		return "maximum number of connections exceeded"
+	case StatusRateLimitedMaxCommands:
+		return "request rate limit exceeded"
+	case StatusRateLimitedScopeSizeLimitExceeded:
+		return "scope size limit exceeded"
 	case StatusUnknownCommand:
 		return "unknown command was received"
 	case StatusOutOfMemory:
@@ -40,28 +40,48 @@ type memdClient struct {
 	closeNotify           chan bool
 	connID                string
 	closed                bool
-	conn                  memdConn
+	closeErr              error
+	conn                  MemdConn
 	opList                *memdOpMap
 	features              []memd.HelloFeature
+	serverVersion         string
 	lock                  sync.Mutex
 	streamEndNotSupported bool
 	breaker               circuitBreaker
+	resourceUnits         *resourceUnitCounters
+	opCounters            *operationCounters
+	maxInFlight           int
+	inFlightSpaceSig      chan struct{}
+	canaryRequest         func() *memd.Packet
+	canaryCheck           func(resp *memd.Packet, err error) bool
 	postErrHandler        postCompleteErrorHandler
 	tracer                *tracerComponent
 	zombieLogger          *zombieLoggerComponent
 
 	dcpQueueSize         int
+	dcpQueueBlocked      uint32
 	compressionMinSize   int
 	compressionMinRatio  float64
 	disableDecompression bool
+	dcpDecompressor      *dcpDecompressionPool
+	compression          *compressionCounters
 
 	cancelBootstrapSig <-chan struct{}
+
+	logCtx        logContext
+	eventCallback EndpointEventCallback
+
+	latency endpointLatency
 }
 
 type dcpBuffer struct {
 	resp       *memdQResponse
 	packetLen  int
 	isInternal bool
+
+	// decompressed is set when the packet is being decompressed by a dcpDecompressionPool, it is closed once done.
+	decompressed chan struct{}
+	compression  *compressionCounters
 }
 
 type memdClientProps struct {
@@ -71,9 +91,18 @@ type memdClientProps struct {
 	CompressionMinSize   int
 	CompressionMinRatio  float64
 	DisableDecompression bool
+	DCPDecompressor      *dcpDecompressionPool
+	EventCallback        EndpointEventCallback
+	KeepAlive            KeepAliveConfig
+	DeadConnTimeout      time.Duration
+	LatencyProbe         LatencyProbeConfig
+	WireCapture          *wireCaptureComponent
+	ResourceUnits        *resourceUnitCounters
+	OpCounters           *operationCounters
+	MaxInFlight          int
 }
 
-func newMemdClient(props memdClientProps, conn memdConn, breakerCfg CircuitBreakerConfig, postErrHandler postCompleteErrorHandler,
+func newMemdClient(props memdClientProps, conn MemdConn, breakerCfg CircuitBreakerConfig, postErrHandler postCompleteErrorHandler,
 	tracer *tracerComponent, zombieLogger *zombieLoggerComponent) *memdClient {
 	client := memdClient{
 		closeNotify:    make(chan bool),
@@ -88,15 +117,53 @@ func newMemdClient(props memdClientProps, conn memdConn, breakerCfg CircuitBreak
 		compressionMinRatio:  props.CompressionMinRatio,
 		compressionMinSize:   props.CompressionMinSize,
 		disableDecompression: props.DisableDecompression,
+		dcpDecompressor:      props.DCPDecompressor,
+		compression:          &compressionCounters{},
+		eventCallback:        props.EventCallback,
+		resourceUnits:        props.ResourceUnits,
+		opCounters:           props.OpCounters,
+		maxInFlight:          props.MaxInFlight,
+		inFlightSpaceSig:     make(chan struct{}, 1),
+	}
+	if streamer, ok := conn.(memdValueStreamer); ok {
+		streamer.SetValueWriterFunc(client.responseValueWriter)
+	}
+	if props.WireCapture != nil {
+		client.conn = &wireCaptureConn{
+			MemdConn: conn,
+			capture:  props.WireCapture,
+			connID:   client.connID,
+		}
+	}
+
+	client.logCtx = logContext{
+		{Key: "agent", Value: props.ClientID},
+		{Key: "endpoint", Value: logSystemData(conn.RemoteAddr())},
+		{Key: "conn", Value: client.connID},
 	}
 
 	if breakerCfg.Enabled {
+		client.canaryRequest = breakerCfg.CanaryRequest
+		client.canaryCheck = breakerCfg.CanaryCheck
 		client.breaker = newLazyCircuitBreaker(breakerCfg, client.sendCanary)
 	} else {
 		client.breaker = newNoopCircuitBreaker()
 	}
 
 	client.run()
+
+	if props.KeepAlive.Interval > 0 {
+		go client.keepAliveLoop(props.KeepAlive)
+	}
+
+	if props.DeadConnTimeout > 0 {
+		go client.deadConnLoop(props.DeadConnTimeout)
+	}
+
+	if props.LatencyProbe.Interval > 0 {
+		go client.latencyProbeLoop(props.LatencyProbe)
+	}
+
 	return &client
 }
 
@@ -104,6 +171,17 @@ func (client *memdClient) SupportsFeature(feature memd.HelloFeature) bool {
 	return checkSupportsFeature(client.features, feature)
 }
 
+// ServerVersion returns the version banner reported by the server during bootstrap, or an empty string if it
+// could not be fetched.
+func (client *memdClient) ServerVersion() string {
+	return client.serverVersion
+}
+
+// Features returns the list of features which were negotiated with the server during HELLO.
+func (client *memdClient) Features() []memd.HelloFeature {
+	return append([]memd.HelloFeature(nil), client.features...)
+}
+
 func (client *memdClient) EnableDcpBufferAck(bufferAckSize int) {
 	client.dcpAckSize = bufferAckSize
 }
@@ -125,7 +203,7 @@ func (client *memdClient) maybeSendDcpBufferAck(packetLen int) {
 		Extras:  extrasBuf,
 	})
 	if err != nil {
-		logWarnf("Failed to dispatch DCP buffer ack: %s", err)
+		client.logCtx.logWarnf("Failed to dispatch DCP buffer ack: %s", err)
 	}
 
 	client.dcpFlowRecv -= ackAmt
@@ -144,12 +222,12 @@ func (client *memdClient) takeRequestOwnership(req *memdQRequest) bool {
 	defer client.lock.Unlock()
 
 	if client.closed {
-		logDebugf("Attempted to put dispatched op in drained opmap")
+		client.logCtx.logDebugf("Attempted to put dispatched op in drained opmap")
 		return false
 	}
 
 	if !atomic.CompareAndSwapPointer(&req.waitingIn, nil, unsafe.Pointer(client)) {
-		logDebugf("Attempted to put dispatched op in new opmap")
+		client.logCtx.logDebugf("Attempted to put dispatched op in new opmap")
 		return false
 	}
 
@@ -162,6 +240,7 @@ func (client *memdClient) takeRequestOwnership(req *memdQRequest) bool {
 		lastDispatchedTo:   client.Address(),
 		lastDispatchedFrom: client.conn.LocalAddr(),
 		lastConnectionID:   client.connID,
+		lastDispatchedAt:   time.Now(),
 	}
 	req.SetConnectionInfo(connInfo)
 
@@ -169,24 +248,69 @@ func (client *memdClient) takeRequestOwnership(req *memdQRequest) bool {
 	return true
 }
 
+// InFlightCount returns the number of requests which have been dispatched and are awaiting a response.
+func (client *memdClient) InFlightCount() int {
+	return client.opList.Size()
+}
+
+func (client *memdClient) CompressionStats() KvCompressionStats {
+	return client.compression.Stats()
+}
+
+// WaitForInFlightSpace blocks until fewer than the maximum number of requests are in flight on the connection, or
+// the connection has closed, returning true. False is returned if stopSig is closed first.
+func (client *memdClient) WaitForInFlightSpace(stopSig <-chan struct{}) bool {
+	if client.maxInFlight <= 0 {
+		return true
+	}
+
+	for {
+		client.lock.Lock()
+		hasSpace := client.closed || client.opList.Size() < client.maxInFlight
+		client.lock.Unlock()
+
+		if hasSpace {
+			return true
+		}
+
+		select {
+		case <-client.inFlightSpaceSig:
+		case <-stopSig:
+			return false
+		}
+	}
+}
+
+// signalInFlightSpace wakes WaitForInFlightSpace after a request has left the op map or the connection has closed.
+func (client *memdClient) signalInFlightSpace() {
+	select {
+	case client.inFlightSpaceSig <- struct{}{}:
+	default:
+	}
+}
+
 func (client *memdClient) CancelRequest(req *memdQRequest, err error) bool {
 	client.lock.Lock()
 	defer client.lock.Unlock()
 
 	if client.closed {
-		logDebugf("Attempted to remove op from drained opmap")
+		client.logCtx.logDebugf("Attempted to remove op from drained opmap")
 		return false
 	}
 
 	removed := client.opList.Remove(req)
 	if removed {
 		atomic.CompareAndSwapPointer(&req.waitingIn, unsafe.Pointer(client), nil)
+		client.signalInFlightSpace()
 	}
 
-	if client.breaker.CompletionCallback(err) {
-		client.breaker.MarkSuccessful()
-	} else {
-		client.breaker.MarkFailure()
+	// Whilst the breaker is half open only the canary decides whether it should be closed again.
+	if client.breaker.State() != circuitBreakerStateHalfOpen {
+		if client.breaker.CompletionCallback(err) {
+			client.breaker.MarkSuccessful()
+		} else {
+			client.breaker.MarkFailure()
+		}
 	}
 
 	return removed
@@ -194,7 +318,7 @@ func (client *memdClient) CancelRequest(req *memdQRequest, err error) bool {
 
 func (client *memdClient) SendRequest(req *memdQRequest) error {
 	if !client.breaker.AllowsRequest() {
-		logSchedf("Circuit breaker interrupting request. %s to %s OP=0x%x. Opaque=%d", client.conn.LocalAddr(), client.Address(), req.Command, req.Opaque)
+		client.logCtx.logSchedf("Circuit breaker interrupting request. %s to %s OP=0x%x. Opaque=%d", client.conn.LocalAddr(), client.Address(), req.Command, req.Opaque)
 
 		req.cancelWithCallback(errCircuitBreakerOpen)
 
@@ -214,44 +338,82 @@ func (client *memdClient) internalSendRequest(req *memdQRequest) error {
 	if client.SupportsFeature(memd.FeatureSnappy) {
 		isCompressed := (packet.Datatype & uint8(memd.DatatypeFlagCompressed)) != 0
 		packetSize := len(packet.Value)
-		if !isCompressed && packetSize > client.compressionMinSize && isCompressibleOp(packet.Command) {
-			compressedValue := snappy.Encode(nil, packet.Value)
-			if float64(len(compressedValue))/float64(packetSize) <= client.compressionMinRatio {
-				newPacket := *packet
-				newPacket.Value = compressedValue
-				newPacket.Datatype = newPacket.Datatype | uint8(memd.DatatypeFlagCompressed)
-				packet = &newPacket
+		if !isCompressed && isCompressibleOp(packet.Command) {
+			if packetSize > client.compressionMinSize {
+				// The compressed value and packet are only needed until the packet has been written.
+				compressBuf := acquireCompressBuf()
+				defer releaseCompressBuf(compressBuf)
+
+				compressedValue := snappy.Encode((*compressBuf)[:cap(*compressBuf)], packet.Value)
+				*compressBuf = compressedValue
+				if float64(len(compressedValue))/float64(packetSize) <= client.compressionMinRatio {
+					newPacket := memd.AcquirePacket()
+					defer memd.ReleasePacket(newPacket)
+
+					*newPacket = *packet
+					newPacket.Value = compressedValue
+					newPacket.Datatype = newPacket.Datatype | uint8(memd.DatatypeFlagCompressed)
+					packet = newPacket
+					client.compression.RecordCompressed(packetSize, len(compressedValue))
+				} else {
+					client.compression.RecordSkippedMinRatio()
+				}
+			} else {
+				client.compression.RecordSkippedMinSize()
 			}
 		}
 	}
 
-	logSchedf("Writing request. %s to %s OP=0x%x. Opaque=%d", client.conn.LocalAddr(), client.Address(), req.Command, req.Opaque)
+	client.logCtx.logSchedf("Writing request. %s to %s OP=0x%x. Opaque=%d", client.conn.LocalAddr(), client.Address(), req.Command, req.Opaque)
 
 	client.tracer.StartNetTrace(req)
 
 	err := client.conn.WritePacket(packet)
 	if err != nil {
-		logDebugf("memdClient write failure: %v", err)
+		client.logCtx.logDebugf("memdClient write failure: %v", err)
 		return err
 	}
 
 	return nil
 }
 
+// responseValueWriter is called from the read loop for every packet, before its body is read, to find out whether the
+// request which the packet is a response to wants the value to be streamed.
+func (client *memdClient) responseValueWriter(pkt *memd.Packet, valueLen int) io.Writer {
+	if pkt.Magic != memd.CmdMagicRes || pkt.Status != memd.StatusSuccess || valueLen == 0 ||
+		(pkt.Datatype&uint8(memd.DatatypeFlagCompressed)) != 0 {
+		return nil
+	}
+
+	req := client.opList.Find(pkt.Opaque)
+
+	if req == nil || req.ValueWriter == nil {
+		return nil
+	}
+
+	return req.ValueWriter
+}
+
 func (client *memdClient) resolveRequest(resp *memdQResponse) {
-	defer memd.ReleasePacket(resp.Packet)
+	defer releaseMemdQResponse(resp)
 
-	logSchedf("Handling response data. OP=0x%x. Opaque=%d. Status:%d", resp.Command, resp.Opaque, resp.Status)
+	client.logCtx.logSchedf("Handling response data. OP=0x%x. Opaque=%d. Status:%d", resp.Command, resp.Opaque, resp.Status)
+
+	// The server charges for the request whether or not we are still waiting for the response.
+	if client.resourceUnits != nil {
+		client.resourceUnits.record(resp.Packet)
+	}
 
-	client.lock.Lock()
 	// Find the request that goes with this response, don't check if the client is
-	// closed so that we can handle orphaned responses.
+	// closed so that we can handle orphaned responses. The op map has its own locking so the client lock isn't
+	// needed here.
 	req := client.opList.FindAndMaybeRemove(resp.Opaque, resp.Status != memd.StatusSuccess)
-	client.lock.Unlock()
+	client.signalInFlightSpace()
 
 	if req == nil {
 		// There is no known request that goes with this response.  Ignore it.
-		logDebugf("Received response with no corresponding request.")
+		client.logCtx.logDebugf("Received response with no corresponding request.")
+		client.opCounters.RecordKvOrphaned(resp.Command)
 		if client.zombieLogger != nil {
 			client.zombieLogger.RecordZombieResponse(resp, client.connID, client.LocalAddress(), client.Address())
 		}
@@ -273,12 +435,13 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 		newValue, err := snappy.Decode(nil, resp.Value)
 		if err != nil {
 			req.processingLock.Unlock()
-			logDebugf("Failed to decompress value from the server for key `%s`.", req.Key)
+			client.logCtx.logDebugf("Failed to decompress value from the server for key `%s`.", logUserData(string(req.Key)))
 			return
 		}
 
 		resp.Value = newValue
 		resp.Datatype = resp.Datatype & ^uint8(memd.DatatypeFlagCompressed)
+		client.compression.RecordDecompressed()
 	}
 
 	// Give the agent an opportunity to intercept the response first
@@ -287,10 +450,12 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 		err = getKvStatusCodeError(resp.Status)
 	}
 
-	if client.breaker.CompletionCallback(err) {
-		client.breaker.MarkSuccessful()
-	} else {
-		client.breaker.MarkFailure()
+	if client.breaker.State() != circuitBreakerStateHalfOpen {
+		if client.breaker.CompletionCallback(err) {
+			client.breaker.MarkSuccessful()
+		} else {
+			client.breaker.MarkFailure()
+		}
 	}
 
 	if !req.Persistent {
@@ -302,17 +467,33 @@ func (client *memdClient) resolveRequest(resp *memdQResponse) {
 	if err != nil {
 		shortCircuited, routeErr := client.postErrHandler(resp, req, err)
 		if shortCircuited {
-			logSchedf("Routing callback intercepted response")
+			client.logCtx.logSchedf("Routing callback intercepted response")
 			return
 		}
 		err = routeErr
 	}
 
 	// Call the requests callback handler...
-	logSchedf("Dispatching response callback. OP=0x%x. Opaque=%d", resp.Command, resp.Opaque)
+	client.logCtx.logSchedf("Dispatching response callback. OP=0x%x. Opaque=%d", resp.Command, resp.Opaque)
 	req.tryCallback(resp, err)
 }
 
+// queueDcpBuffer queues buf to be processed once the application has processed the packets before it. Nothing can be
+// read from the connection, including noops, whilst a slow application leaves the queue full, so the time spent
+// waiting is not counted as the connection having gone silent.
+func (client *memdClient) queueDcpBuffer(dcpBufferQ chan *dcpBuffer, buf *dcpBuffer) {
+	select {
+	case dcpBufferQ <- buf:
+		return
+	default:
+	}
+
+	atomic.StoreUint32(&client.dcpQueueBlocked, 1)
+	dcpBufferQ <- buf
+	atomic.StoreInt64(&client.lastActivity, time.Now().UnixNano())
+	atomic.StoreUint32(&client.dcpQueueBlocked, 0)
+}
+
 func (client *memdClient) run() {
 	var (
 		// A queue for DCP commands so we can execute them out-of-band from packet receiving.  This
@@ -342,13 +523,19 @@ func (client *memdClient) run() {
 				return
 			}
 
-			logSchedf("Resolving response OP=0x%x. Opaque=%d", q.resp.Command, q.resp.Opaque)
+			if q.decompressed != nil {
+				<-q.decompressed
+			}
+
+			client.logCtx.logSchedf("Resolving response OP=0x%x. Opaque=%d", q.resp.Command, q.resp.Opaque)
 			client.resolveRequest(q.resp)
 
 			// See below for information on MB-26363 for why this is here.
 			if !q.isInternal && client.dcpAckSize > 0 {
 				client.maybeSendDcpBufferAck(q.packetLen)
 			}
+
+			releaseDcpBuffer(q)
 		}
 	}()
 
@@ -356,17 +543,23 @@ func (client *memdClient) run() {
 		for {
 			packet, n, err := client.conn.ReadPacket()
 			if err != nil {
-				if !client.closed {
-					logWarnf("memdClient read failure on conn `%v` : %v", client.connID, err)
+				client.lock.Lock()
+				closed := client.closed
+				if !closed {
+					client.closeErr = err
+				}
+				client.lock.Unlock()
+
+				if !closed {
+					client.logCtx.logWarnf("memdClient read failure on conn `%v` : %v", client.connID, err)
 				}
 				break
 			}
 
-			resp := &memdQResponse{
-				sourceAddr:   client.conn.RemoteAddr(),
-				sourceConnID: client.connID,
-				Packet:       packet,
-			}
+			resp := acquireMemdQResponse()
+			resp.sourceAddr = client.conn.RemoteAddr()
+			resp.sourceConnID = client.connID
+			resp.Packet = packet
 
 			atomic.StoreInt64(&client.lastActivity, time.Now().UnixNano())
 
@@ -378,8 +571,9 @@ func (client *memdClient) run() {
 					Opaque:  resp.Opaque,
 				})
 				if err != nil {
-					logWarnf("Failed to dispatch DCP noop reply: %s", err)
+					client.logCtx.logWarnf("Failed to dispatch DCP noop reply: %s", err)
 				}
+				releaseMemdQResponse(resp)
 				continue
 			}
 
@@ -395,20 +589,21 @@ func (client *memdClient) run() {
 					if streamReq != nil {
 						endExtras := make([]byte, 4)
 						binary.BigEndian.PutUint32(endExtras, uint32(memd.StreamEndClosed))
-						endResp := &memdQResponse{
-							Packet: &memd.Packet{
-								Magic:   memd.CmdMagicReq,
-								Command: memd.CmdDcpStreamEnd,
-								Vbucket: vbID,
-								Opaque:  streamReq.Opaque,
-								Extras:  endExtras,
-							},
-						}
-						dcpBufferQ <- &dcpBuffer{
-							resp:       endResp,
-							packetLen:  n,
-							isInternal: true,
-						}
+						endPacket := memd.AcquirePacket()
+						endPacket.Magic = memd.CmdMagicReq
+						endPacket.Command = memd.CmdDcpStreamEnd
+						endPacket.Vbucket = vbID
+						endPacket.Opaque = streamReq.Opaque
+						endPacket.Extras = endExtras
+
+						endResp := acquireMemdQResponse()
+						endResp.Packet = endPacket
+
+						endBuf := acquireDcpBuffer()
+						endBuf.resp = endResp
+						endBuf.packetLen = n
+						endBuf.isInternal = true
+						client.queueDcpBuffer(dcpBufferQ, endBuf)
 					}
 				}
 			}
@@ -416,12 +611,17 @@ func (client *memdClient) run() {
 			switch resp.Packet.Command {
 			case memd.CmdDcpDeletion, memd.CmdDcpExpiration, memd.CmdDcpMutation, memd.CmdDcpSnapshotMarker,
 				memd.CmdDcpEvent, memd.CmdDcpOsoSnapshot, memd.CmdDcpSeqNoAdvanced, memd.CmdDcpStreamEnd:
-				dcpBufferQ <- &dcpBuffer{
-					resp:      resp,
-					packetLen: n,
+				buf := acquireDcpBuffer()
+				buf.resp = resp
+				buf.packetLen = n
+				if client.dcpDecompressor != nil && !client.disableDecompression &&
+					(resp.Datatype&uint8(memd.DatatypeFlagCompressed)) != 0 {
+					buf.compression = client.compression
+					client.dcpDecompressor.Submit(buf)
 				}
+				client.queueDcpBuffer(dcpBufferQ, buf)
 			default:
-				logSchedf("Resolving response OP=0x%x. Opaque=%d", resp.Command, resp.Opaque)
+				client.logCtx.logSchedf("Resolving response OP=0x%x. Opaque=%d", resp.Command, resp.Opaque)
 				client.resolveRequest(resp)
 			}
 		}
@@ -430,11 +630,12 @@ func (client *memdClient) run() {
 		if !client.closed {
 			client.closed = true
 			client.lock.Unlock()
+			client.signalInFlightSpace()
 
 			err := client.conn.Close()
 			if err != nil {
 				// Lets log a warning, as this is non-fatal
-				logWarnf("Failed to shut down client connection (%s)", err)
+				client.logCtx.logWarnf("Failed to shut down client connection (%s)", err)
 			}
 		} else {
 			client.lock.Unlock()
@@ -450,7 +651,7 @@ func (client *memdClient) run() {
 
 		client.opList.Drain(func(req *memdQRequest) {
 			if !atomic.CompareAndSwapPointer(&req.waitingIn, unsafe.Pointer(client), nil) {
-				logWarnf("Encountered an unowned request in a client opMap")
+				client.logCtx.logWarnf("Encountered an unowned request in a client opMap")
 			}
 
 			shortCircuited, routeErr := client.postErrHandler(nil, req, io.EOF)
@@ -461,6 +662,11 @@ func (client *memdClient) run() {
 			req.tryCallback(nil, routeErr)
 		})
 
+		client.lock.Lock()
+		closeErr := client.closeErr
+		client.lock.Unlock()
+		client.emitEndpointEvent(EndpointEventDisconnected, "", closeErr)
+
 		close(client.closeNotify)
 	}()
 }
@@ -470,58 +676,102 @@ func (client *memdClient) LocalAddress() string {
 }
 
 func (client *memdClient) Close() error {
+	return client.CloseWithError(nil)
+}
+
+// CloseWithError closes the client, recording err as the reason that the connection was dropped.
+func (client *memdClient) CloseWithError(err error) error {
 	client.lock.Lock()
-	client.closed = true
+	if !client.closed {
+		client.closed = true
+		client.closeErr = err
+	}
 	client.lock.Unlock()
+	client.signalInFlightSpace()
 
 	return client.conn.Close()
 }
 
+type canaryResult struct {
+	resp *memd.Packet
+	err  error
+}
+
 func (client *memdClient) sendCanary() {
-	errChan := make(chan error)
+	// The channel is buffered so that the handler never blocks if we have stopped waiting for it.
+	resultCh := make(chan canaryResult, 1)
 	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
-		errChan <- err
+		// The response is released once we return so the check needs its own copy.
+		var packet *memd.Packet
+		if resp != nil {
+			respCopy := *resp.Packet
+			respCopy.Key = append([]byte(nil), resp.Key...)
+			respCopy.Extras = append([]byte(nil), resp.Extras...)
+			respCopy.Value = append([]byte(nil), resp.Value...)
+			packet = &respCopy
+		}
+		resultCh <- canaryResult{resp: packet, err: err}
+	}
+
+	packet := memd.Packet{
+		Magic:   memd.CmdMagicReq,
+		Command: memd.CmdNoop,
+	}
+	if client.canaryRequest != nil {
+		if custom := client.canaryRequest(); custom != nil {
+			packet = *custom
+			if packet.Magic == 0 {
+				packet.Magic = memd.CmdMagicReq
+			}
+		}
 	}
 
 	req := &memdQRequest{
-		Packet: memd.Packet{
-			Magic:    memd.CmdMagicReq,
-			Command:  memd.CmdNoop,
-			Datatype: 0,
-			Cas:      0,
-			Key:      nil,
-			Value:    nil,
-		},
+		Packet:        packet,
 		Callback:      handler,
 		RetryStrategy: newFailFastRetryStrategy(),
 	}
 
-	logDebugf("Sending NOOP request for %p/%s", client, client.Address())
+	client.logCtx.logDebugf("Sending %s canary request for %p/%s", packet.Command.Name(), client, client.Address())
+	start := time.Now()
 	err := client.internalSendRequest(req)
 	if err != nil {
+		client.logCtx.logDebugf("Canary request failed to send for %p/%s: %v", client, client.Address(), err)
 		client.breaker.MarkFailure()
+		return
 	}
 
+	var result canaryResult
 	timer := AcquireTimer(client.breaker.CanaryTimeout())
 	select {
 	case <-timer.C:
-		if !req.internalCancel(errRequestCanceled) {
-			err := <-errChan
-			if err == nil {
-				logDebugf("NOOP request successful for %p/%s", client, client.Address())
-				client.breaker.MarkSuccessful()
-			} else {
-				logDebugf("NOOP request failed for %p/%s", client, client.Address())
-				client.breaker.MarkFailure()
-			}
-		}
-		client.breaker.MarkFailure()
-	case err := <-errChan:
-		if err == nil {
-			client.breaker.MarkSuccessful()
-		} else {
+		ReleaseTimer(timer, true)
+		if req.internalCancel(errRequestCanceled) {
+			client.logCtx.logDebugf("Canary request timed out for %p/%s", client, client.Address())
 			client.breaker.MarkFailure()
+			return
 		}
+
+		// The response arrived as we timed out.
+		result = <-resultCh
+	case result = <-resultCh:
+		ReleaseTimer(timer, false)
+		if packet.Command == memd.CmdNoop {
+			client.latency.record(time.Since(start), result.err)
+		}
+	}
+
+	succeeded := result.err == nil
+	if client.canaryCheck != nil {
+		succeeded = client.canaryCheck(result.resp, result.err)
+	}
+
+	if succeeded {
+		client.logCtx.logDebugf("Canary request successful for %p/%s", client, client.Address())
+		client.breaker.MarkSuccessful()
+	} else {
+		client.logCtx.logDebugf("Canary request failed for %p/%s: %v", client, client.Address(), result.err)
+		client.breaker.MarkFailure()
 	}
 }
 
@@ -561,6 +811,10 @@ func (client *memdClient) helloFeatures(props helloProps) []memd.HelloFeature {
 		features = append(features, memd.FeatureDurations)
 	}
 
+	if props.ResourceUnitsEnabled {
+		features = append(features, memd.FeatureReportUnitUsage)
+	}
+
 	if props.CollectionsEnabled {
 		features = append(features, memd.FeatureCollections)
 	}
@@ -578,6 +832,22 @@ func (client *memdClient) helloFeatures(props helloProps) []memd.HelloFeature {
 		features = append(features, memd.FeatureSyncReplication)
 	}
 
+	for _, feature := range props.ExtraFeatures {
+		if !checkSupportsFeature(features, feature) {
+			features = append(features, feature)
+		}
+	}
+
+	if len(props.DisabledFeatures) > 0 {
+		enabled := features[:0]
+		for _, feature := range features {
+			if !checkSupportsFeature(props.DisabledFeatures, feature) {
+				enabled = append(enabled, feature)
+			}
+		}
+		features = enabled
+	}
+
 	return features
 }
 
@@ -586,10 +856,13 @@ type helloProps struct {
 	CollectionsEnabled     bool
 	CompressionEnabled     bool
 	DurationsEnabled       bool
+	ResourceUnitsEnabled   bool
 	OutOfOrderEnabled      bool
 	JSONFeatureEnabled     bool
 	XErrorFeatureEnabled   bool
 	SyncReplicationEnabled bool
+	ExtraFeatures          []memd.HelloFeature
+	DisabledFeatures       []memd.HelloFeature
 }
 
 type bootstrapProps struct {
@@ -599,12 +872,21 @@ type bootstrapProps struct {
 	AuthHandler    authFuncHandler
 	ErrMapManager  *errMapComponent
 	HelloProps     helloProps
+
+	// ConfigHandler, if set, causes the cluster config to be requested as part of bootstrap. The request is pipelined
+	// straight after select bucket rather than waiting for the connection to become ready, and the handler is called
+	// with the config if it was fetched successfully.
+	ConfigHandler func(client *memdClient, config []byte)
+
+	// ConfigWanted, if set, is called with the address being bootstrapped and the config is only requested if it
+	// returns true.
+	ConfigWanted func(address string) bool
 }
 
 type memdInitFunc func(*memdClient, time.Time) error
 
 func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstrapProps, deadline time.Time, cb memdInitFunc) error {
-	logDebugf("Memdclient `%s/%p` Fetching cluster client data", client.Address(), client)
+	client.logCtx.logDebugf("Memdclient `%s/%p` Fetching cluster client data", client.Address(), client)
 
 	bucket := settings.Bucket
 	features := client.helloFeatures(settings.HelloProps)
@@ -614,24 +896,30 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 
 	helloCh, err := client.ExecHello(clientInfoStr, features, deadline)
 	if err != nil {
-		logDebugf("Memdclient `%s/%p` Failed to execute HELLO (%v)", client.Address(), client, err)
+		client.logCtx.logDebugf("Memdclient `%s/%p` Failed to execute HELLO (%v)", client.Address(), client, err)
 		return err
 	}
 
-	errMapCh, err := client.ExecGetErrorMap(1, deadline)
+	errMapCh, err := client.ExecGetErrorMap(kvErrorMapVersion, deadline)
 	if err != nil {
 		// GetErrorMap isn't integral to bootstrap succeeding
-		logDebugf("Memdclient `%s/%p`Failed to execute Get error map (%v)", client.Address(), client, err)
+		client.logCtx.logDebugf("Memdclient `%s/%p`Failed to execute Get error map (%v)", client.Address(), client, err)
+	}
+
+	versionCh, err := client.ExecVersion(deadline)
+	if err != nil {
+		// Neither is Version, it's purely informational.
+		client.logCtx.logDebugf("Memdclient `%s/%p` Failed to execute Version (%v)", client.Address(), client, err)
 	}
 
 	var listMechsCh chan SaslListMechsCompleted
-	firstAuthMethod := settings.AuthHandler(client, deadline, authMechanisms[0])
+	firstAuthMethod := settings.AuthHandler(client, deadline, authMechanisms[0], bucket)
 	// If the auth method is nil then we don't actually need to do any auth so no need to Get the mechanisms.
 	if firstAuthMethod != nil {
 		listMechsCh = make(chan SaslListMechsCompleted, 1)
 		err = client.SaslListMechs(deadline, func(mechs []AuthMechanism, err error) {
 			if err != nil {
-				logDebugf("Memdclient `%s/%p` Failed to fetch list auth mechs (%v)", client.Address(), client, err)
+				client.logCtx.logDebugf("Memdclient `%s/%p` Failed to fetch list auth mechs (%v)", client.Address(), client, err)
 			}
 			listMechsCh <- SaslListMechsCompleted{
 				Err:   err,
@@ -639,7 +927,7 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 			}
 		})
 		if err != nil {
-			logDebugf("Memdclient `%s/%p` Failed to execute list auth mechs (%v)", client.Address(), client, err)
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to execute list auth mechs (%v)", client.Address(), client, err)
 		}
 	}
 
@@ -648,27 +936,25 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 	if firstAuthMethod != nil {
 		completedAuthCh, continueAuthCh, err = firstAuthMethod()
 		if err != nil {
-			logDebugf("Memdclient `%s/%p` Failed to execute auth (%v)", client.Address(), client, err)
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to execute auth (%v)", client.Address(), client, err)
 			return err
 		}
 	}
 
-	var selectCh chan BytesAndError
+	fetchConfig := settings.ConfigHandler != nil && (settings.ConfigWanted == nil || settings.ConfigWanted(client.Address()))
+	var selectCh, configCh chan BytesAndError
 	if continueAuthCh == nil {
-		if bucket != "" {
-			selectCh, err = client.ExecSelectBucket([]byte(bucket), deadline)
-			if err != nil {
-				logDebugf("Memdclient `%s/%p` Failed to execute select bucket (%v)", client.Address(), client, err)
-				return err
-			}
+		selectCh, configCh, err = client.execPostAuth(bucket, fetchConfig, deadline)
+		if err != nil {
+			return err
 		}
 	} else {
-		selectCh = client.continueAfterAuth(bucket, continueAuthCh, deadline)
+		selectCh, configCh = client.continueAfterAuth(bucket, fetchConfig, continueAuthCh, deadline)
 	}
 
 	helloResp := <-helloCh
 	if helloResp.Err != nil {
-		logDebugf("Memdclient `%s/%p` Failed to hello with server (%v)", client.Address(), client, helloResp.Err)
+		client.logCtx.logDebugf("Memdclient `%s/%p` Failed to hello with server (%v)", client.Address(), client, helloResp.Err)
 		return helloResp.Err
 	}
 
@@ -676,7 +962,16 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 	if errMapResp.Err == nil {
 		settings.ErrMapManager.StoreErrorMap(errMapResp.Bytes)
 	} else {
-		logDebugf("Memdclient `%s/%p` Failed to fetch kv error map (%s)", client.Address(), client, errMapResp.Err)
+		client.logCtx.logDebugf("Memdclient `%s/%p` Failed to fetch kv error map (%s)", client.Address(), client, errMapResp.Err)
+	}
+
+	if versionCh != nil {
+		versionResp := <-versionCh
+		if versionResp.Err == nil {
+			client.serverVersion = string(versionResp.Bytes)
+		} else {
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to fetch server version (%s)", client.Address(), client, versionResp.Err)
+		}
 	}
 
 	var serverAuthMechanisms []AuthMechanism
@@ -684,9 +979,9 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 		listMechsResp := <-listMechsCh
 		if listMechsResp.Err == nil {
 			serverAuthMechanisms = listMechsResp.Mechs
-			logDebugf("Memdclient `%s/%p` Server supported auth mechanisms: %v", client.Address(), client, serverAuthMechanisms)
+			client.logCtx.logDebugf("Memdclient `%s/%p` Server supported auth mechanisms: %v", client.Address(), client, serverAuthMechanisms)
 		} else {
-			logDebugf("Memdclient `%s/%p` Failed to fetch auth mechs from server (%v)", client.Address(), client, listMechsResp.Err)
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to fetch auth mechs from server (%v)", client.Address(), client, listMechsResp.Err)
 		}
 	}
 
@@ -694,7 +989,7 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 	if completedAuthCh != nil {
 		authResp := <-completedAuthCh
 		if authResp.Err != nil {
-			logDebugf("Memdclient `%s/%p` Failed to perform auth against server (%v)", client.Address(), client, authResp.Err)
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to perform auth against server (%v)", client.Address(), client, authResp.Err)
 			if errors.Is(authResp.Err, ErrRequestCanceled) {
 				// There's no point in us trying different mechanisms if something has cancelled bootstrapping.
 				return authResp.Err
@@ -713,7 +1008,7 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 
 				// If we've got here then the auth mechanism we tried is unsupported so let's keep trying with the next
 				// supported mechanism.
-				logInfof("Memdclient `%p` Unsupported authentication mechanism, will attempt to find next supported mechanism", client)
+				client.logCtx.logInfof("Memdclient `%p` Unsupported authentication mechanism, will attempt to find next supported mechanism", client)
 			}
 
 			for {
@@ -721,59 +1016,68 @@ func (client *memdClient) Bootstrap(cancelSig <-chan struct{}, settings bootstra
 				var mech AuthMechanism
 				found, mech, authMechanisms = findNextAuthMechanism(authMechanisms, serverAuthMechanisms)
 				if !found {
-					logDebugf("Memdclient `%s/%p` Failed to authenticate, all options exhausted", client.Address(), client)
+					client.logCtx.logDebugf("Memdclient `%s/%p` Failed to authenticate, all options exhausted", client.Address(), client)
 					return authResp.Err
 				}
 
-				logDebugf("Memdclient `%s/%p` Retrying authentication with found supported mechanism: %s", client.Address(), client, mech)
-				nextAuthFunc := settings.AuthHandler(client, deadline, mech)
+				client.logCtx.logDebugf("Memdclient `%s/%p` Retrying authentication with found supported mechanism: %s", client.Address(), client, mech)
+				nextAuthFunc := settings.AuthHandler(client, deadline, mech, bucket)
 				if nextAuthFunc == nil {
 					// This can't really happen but just in case it somehow does.
-					logInfof("Memdclient `%p` Failed to authenticate, no available credentials", client)
+					client.logCtx.logInfof("Memdclient `%p` Failed to authenticate, no available credentials", client)
 					return authResp.Err
 				}
 				completedAuthCh, continueAuthCh, err = nextAuthFunc()
 				if err != nil {
-					logDebugf("Memdclient `%s/%p` Failed to execute auth (%v)", client.Address(), client, err)
+					client.logCtx.logDebugf("Memdclient `%s/%p` Failed to execute auth (%v)", client.Address(), client, err)
 					return err
 				}
 				if continueAuthCh == nil {
-					if bucket != "" {
-						selectCh, err = client.ExecSelectBucket([]byte(bucket), deadline)
-						if err != nil {
-							logDebugf("Memdclient `%s/%p` Failed to execute select bucket (%v)", client.Address(), client, err)
-							return err
-						}
+					selectCh, configCh, err = client.execPostAuth(bucket, fetchConfig, deadline)
+					if err != nil {
+						return err
 					}
 				} else {
-					selectCh = client.continueAfterAuth(bucket, continueAuthCh, deadline)
+					selectCh, configCh = client.continueAfterAuth(bucket, fetchConfig, continueAuthCh, deadline)
 				}
 				authResp = <-completedAuthCh
 				if authResp.Err == nil {
 					break
 				}
 
-				logDebugf("Memdclient `%s/%p` Failed to perform auth against server (%v)", client.Address(), client, authResp.Err)
+				client.logCtx.logDebugf("Memdclient `%s/%p` Failed to perform auth against server (%v)", client.Address(), client, authResp.Err)
 				if errors.Is(authResp.Err, ErrAuthenticationFailure) || errors.Is(err, ErrRequestCanceled) {
 					return authResp.Err
 				}
 			}
 		}
-		logDebugf("Memdclient `%s/%p` Authenticated successfully", client.Address(), client)
+		client.logCtx.logDebugf("Memdclient `%s/%p` Authenticated successfully", client.Address(), client)
+		client.emitEndpointEvent(EndpointEventAuthenticated, "", nil)
 	}
 
 	if selectCh != nil {
 		selectResp := <-selectCh
 		if selectResp.Err != nil {
-			logDebugf("Memdclient `%s/%p` Failed to perform select bucket against server (%v)", client.Address(), client, selectResp.Err)
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to perform select bucket against server (%v)", client.Address(), client, selectResp.Err)
 			return selectResp.Err
 		}
+		client.emitEndpointEvent(EndpointEventBucketSelected, bucket, nil)
+	}
+
+	if configCh != nil {
+		configResp := <-configCh
+		if configResp.Err == nil && len(configResp.Bytes) > 0 {
+			settings.ConfigHandler(client, configResp.Bytes)
+		} else {
+			// The config poller will fetch the config once the connection is ready instead.
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to fetch cluster config during bootstrap (%v)", client.Address(), client, configResp.Err)
+		}
 	}
 
 	client.features = helloResp.SrvFeatures
 
-	logDebugf("Memdclient `%s/%p` Client Features: %+v", client.Address(), client, features)
-	logDebugf("Memdclient `%s/%p` Server Features: %+v", client.Address(), client, client.features)
+	client.logCtx.logDebugf("Memdclient `%s/%p` Client Features: %+v", client.Address(), client, features)
+	client.logCtx.logDebugf("Memdclient `%s/%p` Server Features: %+v", client.Address(), client, client.features)
 
 	for _, feature := range client.features {
 		client.conn.EnableFeature(feature)
@@ -823,6 +1127,12 @@ func (client *memdClient) SaslAuth(k, v []byte, deadline time.Time, cb func(b []
 }
 
 func (client *memdClient) SaslStep(k, v []byte, deadline time.Time, cb func(err error)) error {
+	return client.SaslContinue(k, v, deadline, func(_ []byte, err error) {
+		cb(err)
+	})
+}
+
+func (client *memdClient) SaslContinue(k, v []byte, deadline time.Time, cb func(b []byte, err error)) error {
 	err := client.doBootstrapRequest(
 		&memdQRequest{
 			Packet: memd.Packet{
@@ -832,12 +1142,13 @@ func (client *memdClient) SaslStep(k, v []byte, deadline time.Time, cb func(err
 				Value:   v,
 			},
 			Callback: func(resp *memdQResponse, _ *memdQRequest, err error) {
-				if err != nil {
-					cb(err)
-					return
+				// As with SaslAuth, auth continue is surfaced as an error
+				var val []byte
+				if resp != nil {
+					val = resp.Value
 				}
 
-				cb(nil)
+				cb(val, err)
 			},
 			RetryStrategy: newFailFastRetryStrategy(),
 		},
@@ -887,6 +1198,37 @@ func (client *memdClient) ExecSelectBucket(b []byte, deadline time.Time) (chan B
 	return completedCh, nil
 }
 
+func (client *memdClient) ExecGetClusterConfig(deadline time.Time) (chan BytesAndError, error) {
+	completedCh := make(chan BytesAndError, 1)
+	err := client.doBootstrapRequest(
+		&memdQRequest{
+			Packet: memd.Packet{
+				Magic:   memd.CmdMagicReq,
+				Command: memd.CmdGetClusterConfig,
+			},
+			Callback: func(resp *memdQResponse, _ *memdQRequest, err error) {
+				if err != nil {
+					completedCh <- BytesAndError{
+						Err: err,
+					}
+					return
+				}
+
+				completedCh <- BytesAndError{
+					Bytes: resp.Value,
+				}
+			},
+			RetryStrategy: newFailFastRetryStrategy(),
+		},
+		deadline,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return completedCh, nil
+}
+
 func (client *memdClient) ExecGetErrorMap(version uint16, deadline time.Time) (chan BytesAndError, error) {
 	completedCh := make(chan BytesAndError, 1)
 	valueBuf := make([]byte, 2)
@@ -922,6 +1264,37 @@ func (client *memdClient) ExecGetErrorMap(version uint16, deadline time.Time) (c
 	return completedCh, nil
 }
 
+func (client *memdClient) ExecVersion(deadline time.Time) (chan BytesAndError, error) {
+	completedCh := make(chan BytesAndError, 1)
+	err := client.doBootstrapRequest(
+		&memdQRequest{
+			Packet: memd.Packet{
+				Magic:   memd.CmdMagicReq,
+				Command: memd.CmdVersion,
+			},
+			Callback: func(resp *memdQResponse, _ *memdQRequest, err error) {
+				if err != nil {
+					completedCh <- BytesAndError{
+						Err: err,
+					}
+					return
+				}
+
+				completedCh <- BytesAndError{
+					Bytes: resp.Value,
+				}
+			},
+			RetryStrategy: newFailFastRetryStrategy(),
+		},
+		deadline,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return completedCh, nil
+}
+
 func (client *memdClient) SaslListMechs(deadline time.Time, cb func(mechs []AuthMechanism, err error)) error {
 	err := client.doBootstrapRequest(
 		&memdQRequest{
@@ -1021,19 +1394,7 @@ func (client *memdClient) doBootstrapRequest(req *memdQRequest, deadline time.Ti
 	req.Callback = handler
 	start := time.Now()
 	req.SetTimer(time.AfterFunc(deadline.Sub(start), func() {
-		connInfo := req.ConnectionInfo()
-		count, reasons := req.Retries()
-		req.cancelWithCallback(&TimeoutError{
-			InnerError:         errAmbiguousTimeout,
-			OperationID:        req.Command.Name(),
-			Opaque:             req.Identifier(),
-			TimeObserved:       time.Since(start),
-			RetryReasons:       reasons,
-			RetryAttempts:      count,
-			LastDispatchedTo:   connInfo.lastDispatchedTo,
-			LastDispatchedFrom: connInfo.lastDispatchedFrom,
-			LastConnectionID:   connInfo.lastConnectionID,
-		})
+		req.cancelWithCallback(req.makeTimeoutError(errAmbiguousTimeout, req.Command.Name(), start))
 	}))
 
 	go func() {
@@ -1055,30 +1416,82 @@ func (client *memdClient) doBootstrapRequest(req *memdQRequest, deadline time.Ti
 	return nil
 }
 
-func (client *memdClient) continueAfterAuth(bucketName string, continueAuthCh chan bool, deadline time.Time) chan BytesAndError {
-	if bucketName == "" {
-		return nil
+// execPostAuth sends the requests which must follow authentication without waiting for their responses, select bucket
+// if there is a bucket followed by get cluster config if fetchConfig is set. The server handles requests in order so
+// the config is for the selected bucket.
+func (client *memdClient) execPostAuth(bucketName string, fetchConfig bool,
+	deadline time.Time) (chan BytesAndError, chan BytesAndError, error) {
+	var selectCh chan BytesAndError
+	if bucketName != "" {
+		var err error
+		selectCh, err = client.ExecSelectBucket([]byte(bucketName), deadline)
+		if err != nil {
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to execute select bucket (%v)", client.Address(), client, err)
+			return nil, nil, err
+		}
+	}
+
+	var configCh chan BytesAndError
+	if fetchConfig {
+		var err error
+		configCh, err = client.ExecGetClusterConfig(deadline)
+		if err != nil {
+			// Fetching the config isn't integral to bootstrap succeeding
+			client.logCtx.logDebugf("Memdclient `%s/%p` Failed to execute get cluster config (%v)", client.Address(), client, err)
+		}
+	}
+
+	return selectCh, configCh, nil
+}
+
+func (client *memdClient) continueAfterAuth(bucketName string, fetchConfig bool, continueAuthCh chan bool,
+	deadline time.Time) (chan BytesAndError, chan BytesAndError) {
+	if bucketName == "" && !fetchConfig {
+		return nil, nil
+	}
+
+	var selectCh, configCh chan BytesAndError
+	if bucketName != "" {
+		selectCh = make(chan BytesAndError, 1)
+	}
+	if fetchConfig {
+		configCh = make(chan BytesAndError, 1)
 	}
 
-	selectCh := make(chan BytesAndError, 1)
 	go func() {
 		success := <-continueAuthCh
 		if !success {
-			selectCh <- BytesAndError{}
+			if selectCh != nil {
+				selectCh <- BytesAndError{}
+			}
+			if configCh != nil {
+				configCh <- BytesAndError{}
+			}
 			return
 		}
-		execCh, err := client.ExecSelectBucket([]byte(bucketName), deadline)
+
+		execSelectCh, execConfigCh, err := client.execPostAuth(bucketName, fetchConfig, deadline)
 		if err != nil {
-			logDebugf("Memdclient `%s/%p` Failed to execute select bucket (%v)", client.Address(), client, err)
 			selectCh <- BytesAndError{Err: err}
+			if configCh != nil {
+				configCh <- BytesAndError{Err: err}
+			}
 			return
 		}
 
-		execResp := <-execCh
-		selectCh <- execResp
+		if selectCh != nil {
+			selectCh <- <-execSelectCh
+		}
+		if configCh != nil {
+			if execConfigCh == nil {
+				configCh <- BytesAndError{}
+			} else {
+				configCh <- <-execConfigCh
+			}
+		}
 	}()
 
-	return selectCh
+	return selectCh, configCh
 }
 
 func checkSupportsFeature(srvFeatures []memd.HelloFeature, feature memd.HelloFeature) bool {
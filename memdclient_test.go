@@ -0,0 +1,92 @@
+package gocbcore
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+	"github.com/golang/snappy"
+)
+
+func (suite *UnitTestSuite) TestMemdClientHelloFeaturesOverrides() {
+	client := &memdClient{}
+	features := client.helloFeatures(helloProps{
+		JSONFeatureEnabled:   true,
+		XErrorFeatureEnabled: true,
+		ExtraFeatures:        []memd.HelloFeature{memd.FeatureDuplex, memd.FeatureJSON},
+		DisabledFeatures:     []memd.HelloFeature{memd.FeatureXerror},
+	})
+
+	suite.Assert().True(checkSupportsFeature(features, memd.FeatureDuplex))
+	suite.Assert().True(checkSupportsFeature(features, memd.FeatureJSON))
+	suite.Assert().False(checkSupportsFeature(features, memd.FeatureXerror))
+
+	numJSON := 0
+	for _, feature := range features {
+		if feature == memd.FeatureJSON {
+			numJSON++
+		}
+	}
+	suite.Assert().Equal(1, numJSON)
+}
+
+func (suite *UnitTestSuite) TestMemdClientCompressedWrites() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var lock sync.Mutex
+	var received [][]byte
+	var h memdmock.HandlerFunc
+	h = server.Handle(memd.CmdSet, func(req *memd.Packet) *memd.Packet {
+		suite.Assert().NotZero(req.Datatype & uint8(memd.DatatypeFlagCompressed))
+		value, err := snappy.Decode(nil, req.Value)
+		suite.Assert().Nil(err)
+
+		lock.Lock()
+		received = append(received, value)
+		lock.Unlock()
+		return h(req)
+	})
+
+	conn, err := server.Dial(server.Address())
+	suite.Require().Nil(err)
+
+	client := newMemdClient(memdClientProps{
+		ClientID:            "test",
+		CompressionMinSize:  32,
+		CompressionMinRatio: 0.83,
+	}, conn, CircuitBreakerConfig{}, func(resp *memdQResponse, req *memdQRequest, err error) (bool, error) {
+		return false, err
+	}, newTracerComponent(&noopTracer{}, "", true), nil)
+	defer client.Close()
+	client.features = []memd.HelloFeature{memd.FeatureSnappy}
+
+	// The compression buffers are pooled so every value must arrive intact even though the buffers are reused.
+	var expected [][]byte
+	for i := 0; i < 10; i++ {
+		value := bytes.Repeat([]byte{byte('a' + i)}, 64*(i+1))
+		expected = append(expected, value)
+
+		errCh := make(chan error, 1)
+		req := &memdQRequest{
+			Packet: memd.Packet{
+				Magic:   memd.CmdMagicReq,
+				Command: memd.CmdSet,
+				Key:     []byte("key"),
+				Extras:  make([]byte, 8),
+				Value:   value,
+			},
+			Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+				errCh <- err
+			},
+		}
+		suite.Require().Nil(client.SendRequest(req))
+		suite.Require().Nil(<-errCh)
+		suite.Assert().Equal(value, req.Value)
+	}
+
+	lock.Lock()
+	suite.Assert().Equal(expected, received)
+	lock.Unlock()
+}
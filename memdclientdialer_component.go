@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"sort"
 	"sync"
 	"time"
 )
@@ -14,21 +15,39 @@ type memdClientDialerComponent struct {
 	clientID          string
 	breakerCfg        CircuitBreakerConfig
 	tlsConfig         *dynTLSConfig
+	dialer            MemdDialFunc
+	eventCallback     EndpointEventCallback
+	keepAlive         KeepAliveConfig
+	deadConnTimeout   time.Duration
+	latencyProbe      LatencyProbeConfig
+	wireCapture       *wireCaptureComponent
+	resourceUnits     *resourceUnitCounters
+	opCounters        *operationCounters
+	maxInFlight       int
 
 	dcpQueueSize         int
 	compressionMinSize   int
 	compressionMinRatio  float64
 	disableDecompression bool
+	dcpDecompressor      *dcpDecompressionPool
 
 	serverFailuresLock sync.Mutex
-	serverFailures     map[string]time.Time
+	// serverFailures maps the address of each quarantined server to the time at which it may next be dialed.
+	serverFailures map[string]time.Time
+	// serverFailuresChangedSig is closed, and replaced, whenever a quarantine is manually changed so that any
+	// dials waiting on it can re-evaluate.
+	serverFailuresChangedSig chan struct{}
 
-	tracer       *tracerComponent
-	zombieLogger *zombieLoggerComponent
+	tracer          *tracerComponent
+	zombieLogger    *zombieLoggerComponent
+	bootstrapStatus *bootstrapStatusComponent
 
+	bootstrapLock        sync.Mutex
 	bootstrapProps       bootstrapProps
 	bootstrapCB          memdInitFunc
 	bootstrapFailHandler memdBoostrapFailHandler
+
+	logCtx logContext
 }
 
 type memdClientDialerProps struct {
@@ -40,6 +59,17 @@ type memdClientDialerProps struct {
 	CompressionMinSize   int
 	CompressionMinRatio  float64
 	DisableDecompression bool
+	DCPDecompressor      *dcpDecompressionPool
+	BootstrapStatus      *bootstrapStatusComponent
+	Dialer               MemdDialFunc
+	EventCallback        EndpointEventCallback
+	KeepAlive            KeepAliveConfig
+	DeadConnTimeout      time.Duration
+	LatencyProbe         LatencyProbeConfig
+	WireCapture          *wireCaptureComponent
+	ResourceUnits        *resourceUnitCounters
+	OpCounters           *operationCounters
+	MaxInFlight          int
 }
 
 type memdBoostrapFailHandler interface {
@@ -48,16 +78,34 @@ type memdBoostrapFailHandler interface {
 
 func newMemdClientDialerComponent(props memdClientDialerProps, bSettings bootstrapProps, breakerCfg CircuitBreakerConfig,
 	zLogger *zombieLoggerComponent, tracer *tracerComponent, bootstrapCB memdInitFunc, failCB memdBoostrapFailHandler) *memdClientDialerComponent {
+	dialer := props.Dialer
+	if dialer == nil {
+		dialer = dialMemdConn
+	}
+
 	return &memdClientDialerComponent{
+		dialer:            dialer,
+		eventCallback:     props.EventCallback,
+		keepAlive:         props.KeepAlive,
+		deadConnTimeout:   props.DeadConnTimeout,
+		latencyProbe:      props.LatencyProbe,
+		wireCapture:       props.WireCapture,
+		resourceUnits:     props.ResourceUnits,
+		opCounters:        props.OpCounters,
+		maxInFlight:       props.MaxInFlight,
 		kvConnectTimeout:  props.KVConnectTimeout,
 		serverWaitTimeout: props.ServerWaitTimeout,
 		clientID:          props.ClientID,
+		logCtx:            logContext{{Key: "agent", Value: props.ClientID}},
 		tlsConfig:         props.TLSConfig,
 		breakerCfg:        breakerCfg,
 		zombieLogger:      zLogger,
 		tracer:            tracer,
 		serverFailures:    make(map[string]time.Time),
 
+		serverFailuresChangedSig: make(chan struct{}),
+		bootstrapStatus:          props.BootstrapStatus,
+
 		bootstrapProps:       bSettings,
 		bootstrapCB:          bootstrapCB,
 		bootstrapFailHandler: failCB,
@@ -66,23 +114,31 @@ func newMemdClientDialerComponent(props memdClientDialerProps, bSettings bootstr
 		compressionMinSize:   props.CompressionMinSize,
 		compressionMinRatio:  props.CompressionMinRatio,
 		disableDecompression: props.DisableDecompression,
+		dcpDecompressor:      props.DCPDecompressor,
 	}
 }
 
 func (mcc *memdClientDialerComponent) SlowDialMemdClient(cancelSig <-chan struct{}, address string,
 	postCompleteHandler postCompleteErrorHandler) (*memdClient, error) {
-	mcc.serverFailuresLock.Lock()
-	failureTime := mcc.serverFailures[address]
-	mcc.serverFailuresLock.Unlock()
+	for {
+		mcc.serverFailuresLock.Lock()
+		quarantinedUntil := mcc.serverFailures[address]
+		changedSig := mcc.serverFailuresChangedSig
+		mcc.serverFailuresLock.Unlock()
+
+		wait := time.Until(quarantinedUntil)
+		if wait <= 0 {
+			break
+		}
 
-	if !failureTime.IsZero() {
-		waitedTime := time.Since(failureTime)
-		if waitedTime < mcc.serverWaitTimeout {
-			select {
-			case <-cancelSig:
-				return nil, errRequestCanceled
-			case <-time.After(mcc.serverWaitTimeout - waitedTime):
-			}
+		timer := time.NewTimer(wait)
+		select {
+		case <-cancelSig:
+			timer.Stop()
+			return nil, errRequestCanceled
+		case <-changedSig:
+			timer.Stop()
+		case <-timer.C:
 		}
 	}
 
@@ -90,24 +146,28 @@ func (mcc *memdClientDialerComponent) SlowDialMemdClient(cancelSig <-chan struct
 	client, err := mcc.dialMemdClient(cancelSig, address, deadline, postCompleteHandler)
 	if err != nil {
 		if !errors.Is(err, ErrRequestCanceled) {
-			mcc.serverFailuresLock.Lock()
-			mcc.serverFailures[address] = time.Now()
-			mcc.serverFailuresLock.Unlock()
+			mcc.bootstrapStatus.RecordAttempt(address, BootstrapResultDialFailed, err)
+
+			mcc.recordServerFailure(address)
 		}
 
 		return nil, err
 	}
 
-	err = client.Bootstrap(cancelSig, mcc.bootstrapProps, deadline, mcc.bootstrapCB)
+	mcc.bootstrapLock.Lock()
+	bSettings := mcc.bootstrapProps
+	mcc.bootstrapLock.Unlock()
+
+	err = client.Bootstrap(cancelSig, bSettings, deadline, mcc.bootstrapCB)
 	if err != nil {
 		closeErr := client.Close()
 		if closeErr != nil {
-			logWarnf("Failed to close authentication client (%s)", closeErr)
+			mcc.logCtx.logWarnf("Failed to close authentication client (%s)", closeErr)
 		}
 		if !errors.Is(err, ErrRequestCanceled) {
-			mcc.serverFailuresLock.Lock()
-			mcc.serverFailures[address] = time.Now()
-			mcc.serverFailuresLock.Unlock()
+			mcc.bootstrapStatus.RecordBootstrapError(address, err)
+
+			mcc.recordServerFailure(address)
 		}
 
 		mcc.bootstrapFailHandler.onBootstrapFail(err)
@@ -115,9 +175,73 @@ func (mcc *memdClientDialerComponent) SlowDialMemdClient(cancelSig <-chan struct
 		return nil, err
 	}
 
+	mcc.bootstrapStatus.RecordAttempt(address, BootstrapResultSuccess, nil)
+
 	return client, nil
 }
 
+func (mcc *memdClientDialerComponent) recordServerFailure(address string) {
+	if mcc.serverWaitTimeout <= 0 {
+		return
+	}
+
+	mcc.serverFailuresLock.Lock()
+	until := time.Now().Add(mcc.serverWaitTimeout)
+	// Never shorten a quarantine which has been manually extended.
+	if until.After(mcc.serverFailures[address]) {
+		mcc.serverFailures[address] = until
+	}
+	mcc.serverFailuresLock.Unlock()
+}
+
+// QuarantinedServers returns the servers which will not be dialed until their quarantine expires.
+func (mcc *memdClientDialerComponent) QuarantinedServers() []QuarantinedServer {
+	now := time.Now()
+
+	mcc.serverFailuresLock.Lock()
+	defer mcc.serverFailuresLock.Unlock()
+
+	var servers []QuarantinedServer
+	for address, until := range mcc.serverFailures {
+		if !until.After(now) {
+			delete(mcc.serverFailures, address)
+			continue
+		}
+
+		servers = append(servers, QuarantinedServer{
+			Address: address,
+			Until:   until,
+		})
+	}
+
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Address < servers[j].Address
+	})
+
+	return servers
+}
+
+// SetQuarantine quarantines address until the given time, a zero time clears any existing quarantine.
+func (mcc *memdClientDialerComponent) SetQuarantine(address string, until time.Time) {
+	mcc.serverFailuresLock.Lock()
+	if until.IsZero() {
+		delete(mcc.serverFailures, address)
+	} else {
+		mcc.serverFailures[address] = until
+	}
+	close(mcc.serverFailuresChangedSig)
+	mcc.serverFailuresChangedSig = make(chan struct{})
+	mcc.serverFailuresLock.Unlock()
+}
+
+// SetBucket sets the bucket which will be selected by any clients dialed from now on, an empty name means that no
+// bucket will be selected.
+func (mcc *memdClientDialerComponent) SetBucket(bucketName string) {
+	mcc.bootstrapLock.Lock()
+	mcc.bootstrapProps.Bucket = bucketName
+	mcc.bootstrapLock.Unlock()
+}
+
 func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{}, address string, deadline time.Time,
 	postCompleteHandler postCompleteErrorHandler) (*memdClient, error) {
 	// Copy the tls configuration since we need to provide the hostname for each
@@ -142,13 +266,13 @@ func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{},
 		}
 	}()
 
-	conn, err := dialMemdConn(ctx, address, tlsConfig, deadline)
+	conn, err := mcc.dialer(ctx, address, tlsConfig, deadline)
 	cancel()
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			err = errRequestCanceled
 		}
-		logDebugf("Failed to connect. %v", err)
+		mcc.logCtx.logDebugf("Failed to connect. %v", err)
 		return nil, err
 	}
 
@@ -157,8 +281,17 @@ func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{},
 			ClientID:             mcc.clientID,
 			DCPQueueSize:         mcc.dcpQueueSize,
 			DisableDecompression: mcc.disableDecompression,
+			DCPDecompressor:      mcc.dcpDecompressor,
 			CompressionMinRatio:  mcc.compressionMinRatio,
 			CompressionMinSize:   mcc.compressionMinSize,
+			EventCallback:        mcc.eventCallback,
+			KeepAlive:            mcc.keepAlive,
+			DeadConnTimeout:      mcc.deadConnTimeout,
+			LatencyProbe:         mcc.latencyProbe,
+			WireCapture:          mcc.wireCapture,
+			ResourceUnits:        mcc.resourceUnits,
+			OpCounters:           mcc.opCounters,
+			MaxInFlight:          mcc.maxInFlight,
 		},
 		conn,
 		mcc.breakerCfg,
@@ -166,6 +299,7 @@ func (mcc *memdClientDialerComponent) dialMemdClient(cancelSig <-chan struct{},
 		mcc.tracer,
 		mcc.zombieLogger,
 	)
+	client.emitEndpointEvent(EndpointEventConnected, "", nil)
 
 	return client, err
 }
@@ -0,0 +1,138 @@
+package gocbcore
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestServerQuarantine() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var dials uint32
+	mockDialer := memdMockDialer(server)
+	dialer := func(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time) (MemdConn, error) {
+		if atomic.AddUint32(&dials, 1) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return mockDialer(ctx, address, tlsConfig, deadline)
+	}
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:        "default",
+		MemdDialer:        dialer,
+		ServerWaitTimeout: time.Hour,
+	})
+	defer agent.Close()
+
+	var quarantined []QuarantinedServer
+	for i := 0; i < 100 && len(quarantined) == 0; i++ {
+		quarantined = agent.QuarantinedServers()
+		time.Sleep(time.Millisecond)
+	}
+	suite.Require().Len(quarantined, 1)
+	suite.Assert().Equal(server.Address(), quarantined[0].Address)
+	suite.Assert().True(quarantined[0].Until.After(time.Now().Add(59 * time.Minute)))
+
+	// Changes to the quarantine should take effect for the dial which is already waiting on it.
+	agent.QuarantineServer(server.Address(), 2*time.Hour)
+	suite.Assert().True(agent.QuarantinedServers()[0].Until.After(time.Now().Add(119 * time.Minute)))
+	agent.ClearServerQuarantine(server.Address())
+	suite.Assert().Empty(agent.QuarantinedServers())
+
+	suite.mustSet(agent, "key")
+	suite.Assert().Equal(uint32(2), atomic.LoadUint32(&dials))
+}
+
+func (suite *UnitTestSuite) TestServerWaitTimeoutConnStr() {
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101?server_wait_timeout=250ms"))
+	suite.Assert().Equal(250*time.Millisecond, config.ServerWaitTimeout)
+
+	suite.Assert().NotNil(config.FromConnStr("couchbase://10.112.192.101?server_wait_timeout=squirrel"))
+}
+
+func (suite *UnitTestSuite) TestBootstrapFetchesConfig() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var commandsLock sync.Mutex
+	var commands []memd.CmdCode
+	for _, cmd := range []memd.CmdCode{memd.CmdSelectBucket, memd.CmdGetClusterConfig} {
+		var defaultHandler memdmock.HandlerFunc
+		handler := &defaultHandler
+		defaultHandler = server.Handle(cmd, func(req *memd.Packet) *memd.Packet {
+			commandsLock.Lock()
+			commands = append(commands, req.Command)
+			commandsLock.Unlock()
+			return (*handler)(req)
+		})
+	}
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:  "default",
+		LazyConnect: true,
+	})
+	defer agent.Close()
+
+	dialer := agent.kvMux.dialer
+	suite.Require().NotNil(dialer.bootstrapProps.ConfigHandler)
+
+	// The config isn't applied so that the agent doesn't connect, the node is marked as onBootstrapConfig would.
+	var received []byte
+	dialer.bootstrapProps.ConfigHandler = func(client *memdClient, config []byte) {
+		received = config
+		agent.bootstrapConfig.Received(client.Address())
+	}
+
+	client, err := dialer.SlowDialMemdClient(nil, server.Address(),
+		func(resp *memdQResponse, req *memdQRequest, err error) (bool, error) {
+			return false, err
+		})
+	suite.Require().Nil(err)
+
+	// The config is fetched whilst bootstrapping, after the bucket has been selected.
+	suite.Assert().Equal(server.ClusterConfig(), received)
+	commandsLock.Lock()
+	suite.Assert().Equal([]memd.CmdCode{memd.CmdSelectBucket, memd.CmdGetClusterConfig}, commands)
+	commandsLock.Unlock()
+
+	suite.Require().Nil(client.Close())
+	<-client.CloseNotify()
+
+	// Further connections to the same node don't fetch the config again.
+	received = nil
+	client, err = dialer.SlowDialMemdClient(nil, server.Address(),
+		func(resp *memdQResponse, req *memdQRequest, err error) (bool, error) {
+			return false, err
+		})
+	suite.Require().Nil(err)
+
+	suite.Assert().Nil(received)
+	commandsLock.Lock()
+	suite.Assert().Equal([]memd.CmdCode{memd.CmdSelectBucket, memd.CmdGetClusterConfig, memd.CmdSelectBucket}, commands)
+	commandsLock.Unlock()
+
+	suite.Require().Nil(client.Close())
+	<-client.CloseNotify()
+
+	agent = suite.newMockAgent(server, AgentConfig{
+		BucketName:           "default",
+		LazyConnect:          true,
+		DisableConfigPolling: true,
+		SeedConfig:           server.ClusterConfig(),
+	})
+	defer agent.Close()
+	suite.Assert().Nil(agent.kvMux.dialer.bootstrapProps.ConfigHandler)
+}
@@ -10,7 +10,11 @@ import (
 	"github.com/couchbase/gocbcore/v9/memd"
 )
 
-type memdConn interface {
+// MemdConn is a connection to a memcached server which reads and writes whole packets. Custom implementations can be
+// supplied using AgentConfig.MemdDialer, for example to run against an in-memory mock server in unit tests.
+// Packets passed to WritePacket, along with their buffers, are reused once it returns so must not be retained.
+// Volatile: This API is subject to change at any time.
+type MemdConn interface {
 	LocalAddr() string
 	RemoteAddr() string
 	WritePacket(*memd.Packet) error
@@ -21,6 +25,29 @@ type memdConn interface {
 	IsFeatureEnabled(feature memd.HelloFeature) bool
 }
 
+// memdValueStreamer is implemented by MemdConns which are able to stream the values of responses to a writer as they
+// are read, see memd.ValueWriterFunc.
+type memdValueStreamer interface {
+	SetValueWriterFunc(fn memd.ValueWriterFunc)
+}
+
+// MemdDialFunc opens a MemdConn to address, tlsConfig is nil unless TLS is in use. The connection must be established
+// before deadline and the dial aborted if ctx is cancelled.
+// Volatile: This API is subject to change at any time.
+type MemdDialFunc func(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time) (MemdConn, error)
+
+// NewMemdConn creates a MemdConn which encodes packets onto an existing stream, this allows a MemdDialFunc to
+// customise how the underlying connection is established whilst keeping the standard wire format.
+// Volatile: This API is subject to change at any time.
+func NewMemdConn(stream io.ReadWriteCloser, localAddr, remoteAddr string) MemdConn {
+	return &memdConnWrap{
+		conn:       memd.NewConn(stream),
+		baseConn:   stream,
+		localAddr:  localAddr,
+		remoteAddr: remoteAddr,
+	}
+}
+
 type memdConnWrap struct {
 	localAddr  string
 	remoteAddr string
@@ -44,6 +71,10 @@ func (s *memdConnWrap) ReadPacket() (*memd.Packet, int, error) {
 	return s.conn.ReadPacket()
 }
 
+func (s *memdConnWrap) SetValueWriterFunc(fn memd.ValueWriterFunc) {
+	s.conn.SetValueWriterFunc(fn)
+}
+
 func (s *memdConnWrap) EnableFeature(feature memd.HelloFeature) {
 	s.conn.EnableFeature(feature)
 }
@@ -56,7 +87,7 @@ func (s *memdConnWrap) Close() error {
 	return s.baseConn.Close()
 }
 
-func dialMemdConn(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time) (memdConn, error) {
+func dialMemdConn(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time) (MemdConn, error) {
 	d := net.Dialer{
 		Deadline: deadline,
 	}
@@ -87,10 +118,5 @@ func dialMemdConn(ctx context.Context, address string, tlsConfig *tls.Config, de
 		conn = tlsConn
 	}
 
-	return &memdConnWrap{
-		conn:       memd.NewConn(conn),
-		baseConn:   conn,
-		localAddr:  baseConn.LocalAddr().String(),
-		remoteAddr: address,
-	}, nil
+	return NewMemdConn(conn, baseConn.LocalAddr().String(), address), nil
 }
@@ -0,0 +1,120 @@
+package gocbcore
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func memdMockDialer(server *memdmock.Server) MemdDialFunc {
+	return func(ctx context.Context, address string, _ *tls.Config, _ time.Time) (MemdConn, error) {
+		conn, err := server.Dial(address)
+		if err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+// newMockAgent creates an agent connected to server, the addresses, auth and dialer are filled in on config where
+// they have not been set.
+func (suite *UnitTestSuite) newMockAgent(server *memdmock.Server, config AgentConfig) *Agent {
+	if len(config.MemdAddrs) == 0 {
+		config.MemdAddrs = []string{server.Address()}
+	}
+	if config.Auth == nil {
+		config.Auth = PasswordAuthProvider{}
+	}
+	if config.MemdDialer == nil {
+		config.MemdDialer = memdMockDialer(server)
+	}
+
+	agent, err := CreateAgent(&config)
+	suite.Require().Nil(err)
+
+	return agent
+}
+
+// mustSet stores a document against key, failing the test if it cannot be stored.
+func (suite *UnitTestSuite) mustSet(agent *Agent, key string) *StoreResult {
+	type setResult struct {
+		res *StoreResult
+		err error
+	}
+	setCh := make(chan setResult, 1)
+	_, err := agent.Set(SetOptions{
+		Key:      []byte(key),
+		Value:    []byte("value"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *StoreResult, err error) {
+		setCh <- setResult{res, err}
+	})
+	suite.Require().Nil(err)
+	result := <-setCh
+	suite.Require().Nil(result.err)
+
+	return result.res
+}
+
+func (suite *UnitTestSuite) TestAgentMemdMockServer() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	// Fail the first set with a temporary failure so that the operation has to be retried.
+	var setAttempts uint32
+	var defaultSet memdmock.HandlerFunc
+	defaultSet = server.Handle(memd.CmdSet, func(req *memd.Packet) *memd.Packet {
+		if atomic.AddUint32(&setAttempts, 1) == 1 {
+			return &memd.Packet{Status: memd.StatusTmpFail}
+		}
+		return defaultSet(req)
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:           "default",
+		DefaultRetryStrategy: NewBestEffortRetryStrategy(nil),
+	})
+	defer agent.Close()
+
+	setCh := make(chan error, 1)
+	_, err := agent.Set(SetOptions{
+		Key:      []byte("key"),
+		Value:    []byte("value"),
+		Flags:    1,
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *StoreResult, err error) {
+		setCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-setCh)
+	suite.Assert().Equal(uint32(2), atomic.LoadUint32(&setAttempts))
+
+	getCh := make(chan *GetResult, 1)
+	_, err = agent.Get(GetOptions{
+		Key:      []byte("key"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err)
+		getCh <- res
+	})
+	suite.Require().Nil(err)
+	res := <-getCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal([]byte("value"), res.Value)
+	suite.Assert().Equal(uint32(1), res.Flags)
+
+	getCh2 := make(chan error, 1)
+	_, err = agent.Get(GetOptions{
+		Key:      []byte("missing"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		getCh2 <- err
+	})
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(<-getCh2, ErrDocumentNotFound))
+}
@@ -0,0 +1,137 @@
+package memdmock
+
+import (
+	"io"
+	"sync"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// Conn is an in-memory connection to a Server, it satisfies the gocbcore.MemdConn interface.
+type Conn struct {
+	server     *Server
+	localAddr  string
+	remoteAddr string
+
+	lock     sync.Mutex
+	features map[memd.HelloFeature]bool
+	closed   bool
+
+	valueWriterFn memd.ValueWriterFunc
+
+	respCh  chan *memd.Packet
+	closeCh chan struct{}
+}
+
+func newConn(server *Server, localAddr, remoteAddr string) *Conn {
+	return &Conn{
+		server:     server,
+		localAddr:  localAddr,
+		remoteAddr: remoteAddr,
+		features:   make(map[memd.HelloFeature]bool),
+		respCh:     make(chan *memd.Packet, 1024),
+		closeCh:    make(chan struct{}),
+	}
+}
+
+// LocalAddr returns the address of the client side of the connection.
+func (c *Conn) LocalAddr() string {
+	return c.localAddr
+}
+
+// RemoteAddr returns the address of the server.
+func (c *Conn) RemoteAddr() string {
+	return c.remoteAddr
+}
+
+// WritePacket sends a request to the server, the response (if any) is made available to ReadPacket.
+func (c *Conn) WritePacket(pkt *memd.Packet) error {
+	c.lock.Lock()
+	closed := c.closed
+	c.lock.Unlock()
+	if closed {
+		return io.ErrClosedPipe
+	}
+
+	// The client owns the packet so take a copy before handing it to the handlers.
+	req := *pkt
+	req.Key = copyBytes(pkt.Key)
+	req.Extras = copyBytes(pkt.Extras)
+	req.Value = copyBytes(pkt.Value)
+
+	resp := c.server.handle(c, &req)
+	if resp == nil {
+		return nil
+	}
+
+	resp.Magic = memd.CmdMagicRes
+	resp.Command = req.Command
+	resp.Opaque = req.Opaque
+
+	select {
+	case c.respCh <- resp:
+		return nil
+	case <-c.closeCh:
+		return io.ErrClosedPipe
+	}
+}
+
+// ReadPacket blocks until a response is available or the connection is closed.
+func (c *Conn) ReadPacket() (*memd.Packet, int, error) {
+	select {
+	case resp := <-c.respCh:
+		n := 24 + len(resp.Key) + len(resp.Extras) + len(resp.Value)
+		if c.valueWriterFn != nil {
+			if w := c.valueWriterFn(resp, len(resp.Value)); w != nil {
+				// Errors are ignored in the same way as they are by memd.Conn.
+				_, _ = w.Write(resp.Value)
+				resp.Value = nil
+			}
+		}
+		return resp, n, nil
+	case <-c.closeCh:
+		return nil, 0, io.EOF
+	}
+}
+
+// SetValueWriterFunc sets the function used to decide whether the value of each response should be streamed to a
+// writer rather than being returned in the packet, as with memd.Conn.
+func (c *Conn) SetValueWriterFunc(fn memd.ValueWriterFunc) {
+	c.valueWriterFn = fn
+}
+
+// Close closes the connection, causing any blocked ReadPacket calls to return io.EOF.
+func (c *Conn) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.closeCh)
+
+	return nil
+}
+
+// EnableFeature marks a HELLO feature as enabled on this connection.
+func (c *Conn) EnableFeature(feature memd.HelloFeature) {
+	c.lock.Lock()
+	c.features[feature] = true
+	c.lock.Unlock()
+}
+
+// IsFeatureEnabled returns whether a HELLO feature has been enabled on this connection.
+func (c *Conn) IsFeatureEnabled(feature memd.HelloFeature) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.features[feature]
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+
+	return append([]byte{}, b...)
+}
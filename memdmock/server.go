@@ -0,0 +1,312 @@
+// Package memdmock provides a simple in-memory memcached server for unit testing code built on gocbcore without a
+// live cluster. Connections to the server are created using Server.Dial which can be wrapped in a
+// gocbcore.MemdDialFunc and set as AgentConfig.MemdDialer.
+//
+// The server hosts a single node couchbase bucket and supports enough of the protocol to bootstrap an agent along
+// with basic get, set, add, replace and delete operations against the default collection. Authentication always
+// succeeds using PLAIN so agents should either not supply credentials or set AuthMechanisms to PLAIN. The behaviour
+// of any command can be replaced using Server.Handle, for example to inject errors when testing retries.
+package memdmock
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// ErrConnectionRefused is returned by Dial when the address does not match that of the server.
+var ErrConnectionRefused = errors.New("connection refused")
+
+// HandlerFunc handles a single request, returning the response to send or nil if no response should be sent. The
+// command and opaque of the response are filled in automatically.
+type HandlerFunc func(req *memd.Packet) *memd.Packet
+
+type document struct {
+	value    []byte
+	flags    uint32
+	datatype uint8
+	cas      uint64
+}
+
+// Server is an in-memory memcached server hosting a single bucket.
+type Server struct {
+	address     string
+	bucketName  string
+	numVbuckets int
+
+	handlersLock sync.Mutex
+	handlers     map[memd.CmdCode]HandlerFunc
+
+	docsLock sync.Mutex
+	docs     map[string]*document
+	cas      uint64
+
+	connsLock sync.Mutex
+	conns     []*Conn
+	connCount uint64
+}
+
+// NewServer creates a server listening (in-memory) on address, which must be in host:port form, and hosting a
+// bucket with the given name and number of vbuckets.
+func NewServer(address, bucketName string, numVbuckets int) *Server {
+	s := &Server{
+		address:     address,
+		bucketName:  bucketName,
+		numVbuckets: numVbuckets,
+		docs:        make(map[string]*document),
+	}
+
+	s.handlers = map[memd.CmdCode]HandlerFunc{
+		memd.CmdHello:            s.handleHello,
+		memd.CmdSASLListMechs:    s.handleSASLListMechs,
+		memd.CmdSASLAuth:         s.handleSuccess,
+		memd.CmdSelectBucket:     s.handleSelectBucket,
+		memd.CmdVersion:          s.handleVersion,
+		memd.CmdNoop:             s.handleSuccess,
+		memd.CmdGetClusterConfig: s.handleGetClusterConfig,
+		memd.CmdGet:              s.handleGet,
+		memd.CmdGetMeta:          s.handleGetMeta,
+		memd.CmdSet:              s.handleStore,
+		memd.CmdAdd:              s.handleStore,
+		memd.CmdReplace:          s.handleStore,
+		memd.CmdDelete:           s.handleDelete,
+	}
+
+	return s
+}
+
+// Address returns the address of the server.
+func (s *Server) Address() string {
+	return s.address
+}
+
+// Handle sets the handler used for a command, returning the handler which was previously in use so that it can be
+// delegated to. Commands without a handler receive a StatusUnknownCommand response.
+func (s *Server) Handle(cmd memd.CmdCode, fn HandlerFunc) HandlerFunc {
+	s.handlersLock.Lock()
+	defer s.handlersLock.Unlock()
+
+	prev := s.handlers[cmd]
+	s.handlers[cmd] = fn
+	return prev
+}
+
+// Dial opens a new connection to the server.
+func (s *Server) Dial(address string) (*Conn, error) {
+	if address != s.address {
+		return nil, ErrConnectionRefused
+	}
+
+	connNum := atomic.AddUint64(&s.connCount, 1)
+	conn := newConn(s, "127.0.0.1:"+strconv.FormatUint(40000+connNum, 10), address)
+
+	s.connsLock.Lock()
+	s.conns = append(s.conns, conn)
+	s.connsLock.Unlock()
+
+	return conn, nil
+}
+
+// Close closes all connections which have been made to the server.
+func (s *Server) Close() {
+	s.connsLock.Lock()
+	conns := s.conns
+	s.conns = nil
+	s.connsLock.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+// ClusterConfig returns the bucket config served by the server, in the format returned by Couchbase Server.
+func (s *Server) ClusterConfig() []byte {
+	host, portStr, _ := net.SplitHostPort(s.address)
+	port, _ := strconv.Atoi(portStr)
+
+	vbMap := make([][]int, s.numVbuckets)
+	for i := range vbMap {
+		vbMap[i] = []int{0}
+	}
+
+	config, _ := json.Marshal(map[string]interface{}{
+		"rev":         1,
+		"name":        s.bucketName,
+		"uuid":        "6d656d646d6f636b",
+		"nodeLocator": "vbucket",
+		"nodes": []map[string]interface{}{
+			{
+				"hostname": net.JoinHostPort(host, "8091"),
+				"ports": map[string]int{
+					"direct": port,
+				},
+			},
+		},
+		"nodesExt": []map[string]interface{}{
+			{
+				"hostname": host,
+				"thisNode": true,
+				"services": map[string]int{
+					"kv":   port,
+					"mgmt": 8091,
+				},
+			},
+		},
+		"vBucketServerMap": map[string]interface{}{
+			"hashAlgorithm": "CRC",
+			"numReplicas":   0,
+			"serverList":    []string{s.address},
+			"vBucketMap":    vbMap,
+		},
+	})
+
+	return config
+}
+
+func (s *Server) handle(conn *Conn, req *memd.Packet) *memd.Packet {
+	s.handlersLock.Lock()
+	handler := s.handlers[req.Command]
+	s.handlersLock.Unlock()
+
+	if handler == nil {
+		return &memd.Packet{Status: memd.StatusUnknownCommand}
+	}
+
+	resp := handler(req)
+	if resp != nil && req.Command == memd.CmdHello && resp.Status == memd.StatusSuccess {
+		for i := 0; i+1 < len(resp.Value); i += 2 {
+			conn.EnableFeature(memd.HelloFeature(binary.BigEndian.Uint16(resp.Value[i:])))
+		}
+	}
+
+	return resp
+}
+
+func (s *Server) handleSuccess(req *memd.Packet) *memd.Packet {
+	return &memd.Packet{Status: memd.StatusSuccess}
+}
+
+// By default no features are negotiated, keeping the protocol as simple as possible.
+func (s *Server) handleHello(req *memd.Packet) *memd.Packet {
+	return &memd.Packet{Status: memd.StatusSuccess}
+}
+
+func (s *Server) handleSASLListMechs(req *memd.Packet) *memd.Packet {
+	return &memd.Packet{Status: memd.StatusSuccess, Value: []byte("PLAIN")}
+}
+
+func (s *Server) handleSelectBucket(req *memd.Packet) *memd.Packet {
+	if string(req.Key) != s.bucketName {
+		return &memd.Packet{Status: memd.StatusAccessError}
+	}
+
+	return &memd.Packet{Status: memd.StatusSuccess}
+}
+
+func (s *Server) handleVersion(req *memd.Packet) *memd.Packet {
+	return &memd.Packet{Status: memd.StatusSuccess, Value: []byte("7.0.0-memdmock")}
+}
+
+func (s *Server) handleGetClusterConfig(req *memd.Packet) *memd.Packet {
+	return &memd.Packet{Status: memd.StatusSuccess, Value: s.ClusterConfig(), Datatype: uint8(memd.DatatypeFlagJSON)}
+}
+
+func (s *Server) handleGet(req *memd.Packet) *memd.Packet {
+	s.docsLock.Lock()
+	defer s.docsLock.Unlock()
+
+	doc, ok := s.docs[string(req.Key)]
+	if !ok {
+		return &memd.Packet{Status: memd.StatusKeyNotFound}
+	}
+
+	extras := make([]byte, 4)
+	binary.BigEndian.PutUint32(extras, doc.flags)
+
+	return &memd.Packet{
+		Status:   memd.StatusSuccess,
+		Extras:   extras,
+		Value:    doc.value,
+		Datatype: doc.datatype,
+		Cas:      doc.cas,
+	}
+}
+
+func (s *Server) handleGetMeta(req *memd.Packet) *memd.Packet {
+	s.docsLock.Lock()
+	defer s.docsLock.Unlock()
+
+	// Deleted documents are removed entirely so there are never any tombstones to report.
+	doc, ok := s.docs[string(req.Key)]
+	if !ok {
+		return &memd.Packet{Status: memd.StatusKeyNotFound}
+	}
+
+	extras := make([]byte, 21)
+	binary.BigEndian.PutUint32(extras[4:], doc.flags)
+	binary.BigEndian.PutUint64(extras[12:], doc.cas)
+	extras[20] = doc.datatype
+
+	return &memd.Packet{
+		Status: memd.StatusSuccess,
+		Extras: extras,
+		Cas:    doc.cas,
+	}
+}
+
+func (s *Server) handleStore(req *memd.Packet) *memd.Packet {
+	if len(req.Extras) != 8 {
+		return &memd.Packet{Status: memd.StatusInvalidArgs}
+	}
+
+	s.docsLock.Lock()
+	defer s.docsLock.Unlock()
+
+	key := string(req.Key)
+	existing, exists := s.docs[key]
+	switch {
+	case req.Command == memd.CmdAdd && exists:
+		return &memd.Packet{Status: memd.StatusKeyExists}
+	case req.Command == memd.CmdReplace && !exists:
+		return &memd.Packet{Status: memd.StatusKeyNotFound}
+	case req.Cas != 0 && !exists:
+		return &memd.Packet{Status: memd.StatusKeyNotFound}
+	case req.Cas != 0 && existing.cas != req.Cas:
+		return &memd.Packet{Status: memd.StatusKeyExists}
+	}
+
+	s.cas++
+	s.docs[key] = &document{
+		value:    req.Value,
+		flags:    binary.BigEndian.Uint32(req.Extras),
+		datatype: req.Datatype,
+		cas:      s.cas,
+	}
+
+	return &memd.Packet{Status: memd.StatusSuccess, Cas: s.cas}
+}
+
+func (s *Server) handleDelete(req *memd.Packet) *memd.Packet {
+	s.docsLock.Lock()
+	defer s.docsLock.Unlock()
+
+	key := string(req.Key)
+	existing, exists := s.docs[key]
+	if !exists {
+		return &memd.Packet{Status: memd.StatusKeyNotFound}
+	}
+	if req.Cas != 0 && existing.cas != req.Cas {
+		return &memd.Packet{Status: memd.StatusKeyExists}
+	}
+
+	delete(s.docs, key)
+	s.cas++
+
+	return &memd.Packet{Status: memd.StatusSuccess, Cas: s.cas}
+}
@@ -1,45 +1,91 @@
 package gocbcore
 
 import (
+	"sync"
 	"sync/atomic"
 
 	"github.com/couchbase/gocbcore/v9/memd"
 )
 
-// memdOpMap - Uses the requests opaque to map requests to responses. Note that this structure is not thread safe, and
-// uses should be guarded by a mutex.
-type memdOpMap struct {
-	opaque   uint32
+// memdOpMapShardCount - The number of shards that the requests are spread over, this must be a power of two. Opaques
+// are allocated sequentially so consecutive requests always land in different shards.
+const memdOpMapShardCount = 16
+
+type memdOpMapShard struct {
+	lock     sync.Mutex
 	requests map[uint32]*memdQRequest
 }
 
+// memdOpMap - Uses the requests opaque to map requests to responses. The requests are sharded by opaque, each shard
+// having its own lock, so that the goroutines dispatching requests and the one reading responses don't contend on a
+// single lock at high throughput. This structure is thread safe.
+type memdOpMap struct {
+	opaque uint32
+	size   int32
+	shards [memdOpMapShardCount]memdOpMapShard
+}
+
 // newMemdOpMap - Creates a new empty 'memdOpMap' initializing any internal structures. Note that the requests opaque
 // will begin at one and monotonically increase from there.
 func newMemdOpMap() *memdOpMap {
-	return &memdOpMap{requests: make(map[uint32]*memdQRequest)}
+	m := &memdOpMap{}
+	for i := range m.shards {
+		m.shards[i].requests = make(map[uint32]*memdQRequest)
+	}
+
+	return m
+}
+
+func (m *memdOpMap) shard(opaque uint32) *memdOpMapShard {
+	return &m.shards[opaque&(memdOpMapShardCount-1)]
 }
 
 // Add - Add a new request to the map, the provided requests opaque value will be updated atomically.
 func (m *memdOpMap) Add(req *memdQRequest) {
-	m.opaque++
-	atomic.StoreUint32(&req.Opaque, m.opaque)
-	m.requests[m.opaque] = req
+	opaque := atomic.AddUint32(&m.opaque, 1)
+	atomic.StoreUint32(&req.Opaque, opaque)
+
+	shard := m.shard(opaque)
+	shard.lock.Lock()
+	shard.requests[opaque] = req
+	shard.lock.Unlock()
+
+	atomic.AddInt32(&m.size, 1)
 }
 
 // Remove - Remove the provided request from the map.
 func (m *memdOpMap) Remove(req *memdQRequest) bool {
-	_, ok := m.requests[req.Opaque]
-	delete(m.requests, req.Opaque)
+	opaque := atomic.LoadUint32(&req.Opaque)
+
+	shard := m.shard(opaque)
+	shard.lock.Lock()
+	existing, ok := shard.requests[opaque]
+	ok = ok && existing == req
+	if ok {
+		delete(shard.requests, opaque)
+	}
+	shard.lock.Unlock()
+
+	if ok {
+		atomic.AddInt32(&m.size, -1)
+	}
+
 	return ok
 }
 
 // FindOpenStream - This allows searching through the list of requests for a specific request. This is only used to fix
 // the DCP server bug MB-26363.
 func (m *memdOpMap) FindOpenStream(vbID uint16) *memdQRequest {
-	for _, req := range m.requests {
-		if req.Magic == memd.CmdMagicReq && req.Command == memd.CmdDcpStreamReq && req.Vbucket == vbID {
-			return req
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.lock.Lock()
+		for _, req := range shard.requests {
+			if req.Magic == memd.CmdMagicReq && req.Command == memd.CmdDcpStreamReq && req.Vbucket == vbID {
+				shard.lock.Unlock()
+				return req
+			}
 		}
+		shard.lock.Unlock()
 	}
 
 	return nil
@@ -47,29 +93,56 @@ func (m *memdOpMap) FindOpenStream(vbID uint16) *memdQRequest {
 
 // Find - Lookup a request using its opaque, note that this function by return a <nil> pointer.
 func (m *memdOpMap) Find(opaque uint32) *memdQRequest {
-	return m.requests[opaque]
+	shard := m.shard(opaque)
+	shard.lock.Lock()
+	req := shard.requests[opaque]
+	shard.lock.Unlock()
+
+	return req
 }
 
 // FindAndMaybeRemove - Lookup a request using its opaque and then remove it from the map if it's not persistent or the
 // 'force' argument is true.
 func (m *memdOpMap) FindAndMaybeRemove(opaque uint32, force bool) *memdQRequest {
-	req, ok := m.requests[opaque]
+	shard := m.shard(opaque)
+	shard.lock.Lock()
+	req, ok := shard.requests[opaque]
 	if !ok {
+		shard.lock.Unlock()
 		return nil
 	}
 
-	if force || !req.Persistent {
-		delete(m.requests, opaque)
+	removed := force || !req.Persistent
+	if removed {
+		delete(shard.requests, opaque)
+	}
+	shard.lock.Unlock()
+
+	if removed {
+		atomic.AddInt32(&m.size, -1)
 	}
 
 	return req
 }
 
-// Drain - Remove all the requests from the map whilst running the provided callback for each request.
+// Size - Returns the number of requests currently held in the map.
+func (m *memdOpMap) Size() int {
+	return int(atomic.LoadInt32(&m.size))
+}
+
+// Drain - Remove all the requests from the map whilst running the provided callback for each request. The callback is
+// run without holding any of the map's locks.
 func (m *memdOpMap) Drain(callback func(req *memdQRequest)) {
-	for _, req := range m.requests {
-		callback(req)
-	}
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.lock.Lock()
+		requests := shard.requests
+		shard.requests = make(map[uint32]*memdQRequest)
+		shard.lock.Unlock()
 
-	m.requests = make(map[uint32]*memdQRequest)
+		atomic.AddInt32(&m.size, -int32(len(requests)))
+		for _, req := range requests {
+			callback(req)
+		}
+	}
 }
@@ -1,6 +1,8 @@
 package gocbcore
 
 import (
+	"sync"
+
 	"github.com/couchbase/gocbcore/v9/memd"
 )
 
@@ -126,3 +128,61 @@ func (suite *StandardTestSuite) TestOpMap() {
 		suite.T().Fatalf("Drain behaved incorrected")
 	}
 }
+
+func (suite *UnitTestSuite) TestOpMapConcurrent() {
+	rd := newMemdOpMap()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(persistent bool) {
+			defer wg.Done()
+
+			for j := 0; j < 500; j++ {
+				req := &memdQRequest{Persistent: persistent}
+				rd.Add(req)
+				if rd.Find(req.Opaque) != req {
+					suite.T().Errorf("The op should have been found")
+					return
+				}
+				if j%2 == 0 {
+					if !rd.Remove(req) {
+						suite.T().Errorf("The op should be there")
+						return
+					}
+				} else if rd.FindAndMaybeRemove(req.Opaque, true) != req {
+					suite.T().Errorf("The op should have been found")
+					return
+				}
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+
+	suite.Assert().Equal(0, rd.Size())
+
+	// Opaques are unique across shards.
+	seen := make(map[uint32]struct{})
+	for i := 0; i < 100; i++ {
+		req := &memdQRequest{}
+		rd.Add(req)
+		_, dup := seen[req.Opaque]
+		suite.Require().False(dup)
+		seen[req.Opaque] = struct{}{}
+	}
+	suite.Assert().Equal(100, rd.Size())
+
+	// A request is only removed by itself, not by another request with the same opaque.
+	other := &memdQRequest{}
+	other.Opaque = 1
+	suite.Assert().False(rd.Remove(other))
+	suite.Assert().Equal(100, rd.Size())
+
+	drained := 0
+	rd.Drain(func(req *memdQRequest) {
+		drained++
+	})
+	suite.Assert().Equal(100, drained)
+	suite.Assert().Equal(0, rd.Size())
+	suite.Assert().Nil(rd.FindOpenStream(0))
+}
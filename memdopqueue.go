@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -16,8 +17,9 @@ var (
 )
 
 type memdOpConsumer struct {
-	parent   *memdOpQueue
-	isClosed bool
+	parent    *memdOpQueue
+	isClosed  bool
+	closedSig chan struct{}
 }
 
 func (c *memdOpConsumer) Queue() *memdOpQueue {
@@ -28,23 +30,54 @@ func (c *memdOpConsumer) Pop() *memdQRequest {
 	return c.parent.pop(c)
 }
 
+// ClosedSig returns a channel which is closed when the consumer is closed.
+func (c *memdOpConsumer) ClosedSig() <-chan struct{} {
+	return c.closedSig
+}
+
 func (c *memdOpConsumer) Close() {
 	c.parent.closeConsumer(c)
 }
 
+// maxHighPriorityStreak is the number of high priority requests which may be popped in a row whilst low priority
+// requests are waiting, before a low priority request is popped to prevent it from being starved.
+const maxHighPriorityStreak = 8
+
 type memdOpQueue struct {
-	lock   sync.Mutex
-	signal *sync.Cond
-	items  *list.List
-	isOpen bool
+	lock      sync.Mutex
+	signal    *sync.Cond
+	items     *list.List
+	highItems *list.List
+	isOpen    bool
+
+	highStreak int
+
+	// spaceSignal is used to wake pushers which are waiting for items to be removed from a full queue.
+	spaceSignal *sync.Cond
+
+	numBytes  int
+	fullCount uint64
+}
+
+type memdOpQueueStats struct {
+	NumItems  int
+	NumBytes  int
+	FullCount uint64
+}
+
+// queuedRequestSize approximates the number of bytes that a request will occupy on the wire.
+func queuedRequestSize(req *memdQRequest) int {
+	return 24 + len(req.Extras) + len(req.Key) + len(req.Value)
 }
 
 func newMemdOpQueue() *memdOpQueue {
 	q := memdOpQueue{
-		isOpen: true,
-		items:  list.New(),
+		isOpen:    true,
+		items:     list.New(),
+		highItems: list.New(),
 	}
 	q.signal = sync.NewCond(&q.lock)
+	q.spaceSignal = sync.NewCond(&q.lock)
 	return &q
 }
 
@@ -54,6 +87,7 @@ func (q *memdOpQueue) debugString() string {
 	q.lock.Lock()
 
 	outStr += fmt.Sprintf("Num Items: %d\n", q.items.Len())
+	outStr += fmt.Sprintf("Num High Priority Items: %d\n", q.highItems.Len())
 	outStr += fmt.Sprintf("Is Open: %t", q.isOpen)
 
 	q.lock.Unlock()
@@ -68,26 +102,101 @@ func (q *memdOpQueue) Remove(req *memdQRequest) bool {
 		return false
 	}
 
-	for e := q.items.Front(); e != nil; e = e.Next() {
+	items := q.listFor(req)
+	for e := items.Front(); e != nil; e = e.Next() {
 		if e.Value.(*memdQRequest) == req {
-			q.items.Remove(e)
+			items.Remove(e)
+			q.numBytes -= queuedRequestSize(req)
 			break
 		}
 	}
 
 	q.lock.Unlock()
 
+	q.spaceSignal.Broadcast()
+
 	return true
 }
 
+// listFor returns the list which the request should be queued in based on its priority.
+func (q *memdOpQueue) listFor(req *memdQRequest) *list.List {
+	if req.Priority == OperationPriorityHigh {
+		return q.highItems
+	}
+
+	return q.items
+}
+
+func (q *memdOpQueue) lenLocked() int {
+	return q.items.Len() + q.highItems.Len()
+}
+
+// nextListLocked returns the list which the next request should be popped from, the queue must not be empty.
+// High priority requests are always preferred unless low priority requests have been passed over too many
+// times in a row.
+func (q *memdOpQueue) nextListLocked() *list.List {
+	if q.highItems.Len() == 0 {
+		q.highStreak = 0
+		return q.items
+	}
+
+	if q.items.Len() > 0 && q.highStreak >= maxHighPriorityStreak {
+		q.highStreak = 0
+		return q.items
+	}
+
+	q.highStreak++
+	return q.highItems
+}
+
 func (q *memdOpQueue) Push(req *memdQRequest, maxItems int) error {
 	q.lock.Lock()
+	return q.pushLocked(req, maxItems)
+}
+
+// PushWait behaves like Push except that if the queue is full then it will wait until either space becomes
+// available, the request is cancelled or the deadline is reached, whichever happens first. The deadline is capped
+// at the deadline of the request itself.
+func (q *memdOpQueue) PushWait(req *memdQRequest, maxItems int, deadline time.Time) error {
+	if !req.Deadline.IsZero() && req.Deadline.Before(deadline) {
+		deadline = req.Deadline
+	}
+
+	q.lock.Lock()
+
+	if maxItems > 0 && q.isOpen && q.lenLocked() >= maxItems {
+		atomic.StorePointer(&req.pushWaitingIn, unsafe.Pointer(q))
+
+		// sync.Cond has no notion of a timeout so we wake ourselves up once the deadline has passed.
+		timer := time.AfterFunc(time.Until(deadline), q.wakePushWaiters)
+
+		for q.isOpen && q.lenLocked() >= maxItems && !req.isCancelled() && time.Now().Before(deadline) {
+			q.spaceSignal.Wait()
+		}
+
+		timer.Stop()
+		atomic.StorePointer(&req.pushWaitingIn, nil)
+	}
+
+	return q.pushLocked(req, maxItems)
+}
+
+// wakePushWaiters wakes every pusher waiting for space so that they can recheck whether they should give up.
+func (q *memdOpQueue) wakePushWaiters() {
+	q.lock.Lock()
+	q.spaceSignal.Broadcast()
+	q.lock.Unlock()
+}
+
+// pushLocked must be called with the queue lock held, it will release the lock before returning.
+func (q *memdOpQueue) pushLocked(req *memdQRequest, maxItems int) error {
 	if !q.isOpen {
 		q.lock.Unlock()
 		return errOpQueueClosed
 	}
 
-	if maxItems > 0 && q.items.Len() >= maxItems {
+	if maxItems > 0 && q.lenLocked() >= maxItems {
+		q.fullCount++
 		q.lock.Unlock()
 		return errOpQueueFull
 	}
@@ -104,23 +213,39 @@ func (q *memdOpQueue) Push(req *memdQRequest, maxItems int) error {
 		return errRequestCanceled
 	}
 
-	q.items.PushBack(req)
+	q.listFor(req).PushBack(req)
+	q.numBytes += queuedRequestSize(req)
 	q.lock.Unlock()
 
 	q.signal.Broadcast()
 	return nil
 }
 
+func (q *memdOpQueue) Stats() memdOpQueueStats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return memdOpQueueStats{
+		NumItems:  q.lenLocked(),
+		NumBytes:  q.numBytes,
+		FullCount: q.fullCount,
+	}
+}
+
 func (q *memdOpQueue) Consumer() *memdOpConsumer {
 	return &memdOpConsumer{
-		parent:   q,
-		isClosed: false,
+		parent:    q,
+		isClosed:  false,
+		closedSig: make(chan struct{}),
 	}
 }
 
 func (q *memdOpQueue) closeConsumer(c *memdOpConsumer) {
 	q.lock.Lock()
-	c.isClosed = true
+	if !c.isClosed {
+		c.isClosed = true
+		close(c.closedSig)
+	}
 	q.lock.Unlock()
 
 	q.signal.Broadcast()
@@ -129,7 +254,7 @@ func (q *memdOpQueue) closeConsumer(c *memdOpConsumer) {
 func (q *memdOpQueue) pop(c *memdOpConsumer) *memdQRequest {
 	q.lock.Lock()
 
-	for q.isOpen && !c.isClosed && q.items.Len() == 0 {
+	for q.isOpen && !c.isClosed && q.lenLocked() == 0 {
 		q.signal.Wait()
 	}
 
@@ -138,19 +263,23 @@ func (q *memdOpQueue) pop(c *memdOpConsumer) *memdQRequest {
 		return nil
 	}
 
-	e := q.items.Front()
-	q.items.Remove(e)
+	items := q.nextListLocked()
+	e := items.Front()
+	items.Remove(e)
 
 	req, ok := e.Value.(*memdQRequest)
 	if !ok {
 		logErrorf("Encountered incorrect type in memdOpQueue")
 		return q.pop(c)
 	}
+	q.numBytes -= queuedRequestSize(req)
 
 	atomic.CompareAndSwapPointer(&req.queuedWith, unsafe.Pointer(q), nil)
 
 	q.lock.Unlock()
 
+	q.spaceSignal.Broadcast()
+
 	return req
 }
 
@@ -165,19 +294,54 @@ func (q *memdOpQueue) Drain(cb drainCallback) {
 		return
 	}
 
-	for e := q.items.Front(); e != nil; e = e.Next() {
-		req, ok := e.Value.(*memdQRequest)
-		if !ok {
-			logErrorf("Encountered incorrect type in memdOpQueue")
-			continue
+	for _, items := range []*list.List{q.highItems, q.items} {
+		for e := items.Front(); e != nil; e = e.Next() {
+			req, ok := e.Value.(*memdQRequest)
+			if !ok {
+				logErrorf("Encountered incorrect type in memdOpQueue")
+				continue
+			}
+
+			atomic.CompareAndSwapPointer(&req.queuedWith, unsafe.Pointer(q), nil)
+
+			cb(req)
 		}
+	}
 
-		atomic.CompareAndSwapPointer(&req.queuedWith, unsafe.Pointer(q), nil)
+	q.lock.Unlock()
+}
+
+// TakeAll removes and returns every request in an open queue, high priority requests first. Nothing is returned once
+// the queue has been closed, as its requests are then handled by Drain.
+func (q *memdOpQueue) TakeAll() []*memdQRequest {
+	q.lock.Lock()
 
-		cb(req)
+	if !q.isOpen {
+		q.lock.Unlock()
+		return nil
+	}
+
+	reqs := make([]*memdQRequest, 0, q.lenLocked())
+	for _, items := range []*list.List{q.highItems, q.items} {
+		for e := items.Front(); e != nil; e = e.Next() {
+			req, ok := e.Value.(*memdQRequest)
+			if !ok {
+				logErrorf("Encountered incorrect type in memdOpQueue")
+				continue
+			}
+
+			atomic.CompareAndSwapPointer(&req.queuedWith, unsafe.Pointer(q), nil)
+			reqs = append(reqs, req)
+		}
+		items.Init()
 	}
+	q.numBytes = 0
 
 	q.lock.Unlock()
+
+	q.spaceSignal.Broadcast()
+
+	return reqs
 }
 
 func (q *memdOpQueue) Close() {
@@ -186,4 +350,5 @@ func (q *memdOpQueue) Close() {
 	q.lock.Unlock()
 
 	q.signal.Broadcast()
+	q.spaceSignal.Broadcast()
 }
@@ -0,0 +1,139 @@
+package gocbcore
+
+import (
+	"time"
+)
+
+func (suite *UnitTestSuite) TestMemdOpQueuePushWaitTimesOut() {
+	q := newMemdOpQueue()
+
+	err := q.Push(&memdQRequest{}, 1)
+	suite.Require().Nil(err)
+
+	start := time.Now()
+	err = q.PushWait(&memdQRequest{}, 1, time.Now().Add(50*time.Millisecond))
+	suite.Assert().Equal(errOpQueueFull, err)
+	suite.Assert().True(time.Since(start) >= 50*time.Millisecond)
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueuePushWaitSpaceFreed() {
+	q := newMemdOpQueue()
+	consumer := q.Consumer()
+
+	err := q.Push(&memdQRequest{}, 1)
+	suite.Require().Nil(err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		consumer.Pop()
+	}()
+
+	err = q.PushWait(&memdQRequest{}, 1, time.Now().Add(time.Second))
+	suite.Assert().Nil(err)
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueuePushWaitClosed() {
+	q := newMemdOpQueue()
+
+	err := q.Push(&memdQRequest{}, 1)
+	suite.Require().Nil(err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.Close()
+	}()
+
+	err = q.PushWait(&memdQRequest{}, 1, time.Now().Add(time.Second))
+	suite.Assert().Equal(errOpQueueClosed, err)
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueuePushWaitCancelled() {
+	q := newMemdOpQueue()
+
+	err := q.Push(&memdQRequest{}, 1)
+	suite.Require().Nil(err)
+
+	req := &memdQRequest{}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		req.internalCancel(errRequestCanceled)
+	}()
+
+	start := time.Now()
+	err = q.PushWait(req, 1, time.Now().Add(5*time.Second))
+	suite.Assert().Equal(errOpQueueFull, err)
+	suite.Assert().True(time.Since(start) < time.Second)
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueuePushWaitRequestDeadline() {
+	q := newMemdOpQueue()
+
+	err := q.Push(&memdQRequest{}, 1)
+	suite.Require().Nil(err)
+
+	start := time.Now()
+	err = q.PushWait(&memdQRequest{Deadline: time.Now().Add(20 * time.Millisecond)}, 1, time.Now().Add(5*time.Second))
+	suite.Assert().Equal(errOpQueueFull, err)
+	suite.Assert().True(time.Since(start) < time.Second)
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueueStats() {
+	q := newMemdOpQueue()
+	consumer := q.Consumer()
+
+	req := &memdQRequest{}
+	req.Key = []byte("key")
+	req.Value = []byte("value")
+
+	err := q.Push(req, 1)
+	suite.Require().Nil(err)
+
+	err = q.Push(&memdQRequest{}, 1)
+	suite.Require().Equal(errOpQueueFull, err)
+
+	stats := q.Stats()
+	suite.Assert().Equal(1, stats.NumItems)
+	suite.Assert().Equal(32, stats.NumBytes)
+	suite.Assert().Equal(uint64(1), stats.FullCount)
+
+	consumer.Pop()
+
+	stats = q.Stats()
+	suite.Assert().Equal(0, stats.NumItems)
+	suite.Assert().Equal(0, stats.NumBytes)
+	suite.Assert().Equal(uint64(1), stats.FullCount)
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueuePriority() {
+	q := newMemdOpQueue()
+	consumer := q.Consumer()
+
+	low := &memdQRequest{}
+	high := &memdQRequest{Priority: OperationPriorityHigh}
+
+	suite.Require().Nil(q.Push(low, 0))
+	suite.Require().Nil(q.Push(high, 0))
+
+	suite.Assert().Equal(high, consumer.Pop())
+	suite.Assert().Equal(low, consumer.Pop())
+}
+
+func (suite *UnitTestSuite) TestMemdOpQueuePriorityStarvation() {
+	q := newMemdOpQueue()
+	consumer := q.Consumer()
+
+	low := &memdQRequest{}
+	suite.Require().Nil(q.Push(low, 0))
+
+	for i := 0; i < maxHighPriorityStreak+1; i++ {
+		suite.Require().Nil(q.Push(&memdQRequest{Priority: OperationPriorityHigh}, 0))
+	}
+
+	for i := 0; i < maxHighPriorityStreak; i++ {
+		req := consumer.Pop()
+		suite.Assert().Equal(OperationPriorityHigh, req.Priority)
+	}
+
+	suite.Assert().Equal(low, consumer.Pop())
+	suite.Assert().Equal(OperationPriorityHigh, consumer.Pop().Priority)
+}
@@ -3,7 +3,9 @@ package gocbcore
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"sync"
+	"time"
 )
 
 var (
@@ -14,27 +16,34 @@ var (
 type memdGetClientFn func(cancelSig <-chan struct{}) (*memdClient, error)
 
 type memdPipeline struct {
-	address     string
-	getClientFn memdGetClientFn
-	maxItems    int
-	queue       *memdOpQueue
-	maxClients  int
-	clients     []*memdPipelineClient
-	clientsLock sync.Mutex
+	address          string
+	getClientFn      memdGetClientFn
+	maxItems         int
+	queue            *memdOpQueue
+	maxClients       int
+	clients          []*memdPipelineClient
+	clientsLock      sync.Mutex
+	reconnectBackoff ReconnectBackoffConfig
+	maxConnAge       time.Duration
+	logCtx           logContext
 }
 
-func newPipeline(address string, maxClients, maxItems int, getClientFn memdGetClientFn) *memdPipeline {
+func newPipeline(address string, maxClients, maxItems int, reconnectBackoff ReconnectBackoffConfig,
+	maxConnAge time.Duration, getClientFn memdGetClientFn, logCtx logContext) *memdPipeline {
 	return &memdPipeline{
-		address:     address,
-		getClientFn: getClientFn,
-		maxClients:  maxClients,
-		maxItems:    maxItems,
-		queue:       newMemdOpQueue(),
+		address:          address,
+		getClientFn:      getClientFn,
+		maxClients:       maxClients,
+		maxItems:         maxItems,
+		queue:            newMemdOpQueue(),
+		reconnectBackoff: reconnectBackoff,
+		maxConnAge:       maxConnAge,
+		logCtx:           logCtx.With("endpoint", logSystemData(address)),
 	}
 }
 
 func newDeadPipeline(maxItems int) *memdPipeline {
-	return newPipeline("", 0, maxItems, nil)
+	return newPipeline("", 0, maxItems, ReconnectBackoffConfig{}, 0, nil, nil)
 }
 
 // nolint: unused
@@ -79,7 +88,10 @@ func (pipeline *memdPipeline) StartClients() {
 }
 
 func (pipeline *memdPipeline) sendRequest(req *memdQRequest, maxItems int) error {
-	err := pipeline.queue.Push(req, maxItems)
+	return pipeline.translateQueueError(pipeline.queue.Push(req, maxItems))
+}
+
+func (pipeline *memdPipeline) translateQueueError(err error) error {
 	if err == errOpQueueClosed {
 		return errPipelineClosed
 	} else if err == errOpQueueFull {
@@ -99,19 +111,25 @@ func (pipeline *memdPipeline) SendRequest(req *memdQRequest) error {
 	return pipeline.sendRequest(req, pipeline.maxItems)
 }
 
+// SendRequestWait sends a request to the pipeline, if the pipeline is full then this will block until either
+// space becomes available or deadline is reached.
+func (pipeline *memdPipeline) SendRequestWait(req *memdQRequest, deadline time.Time) error {
+	return pipeline.translateQueueError(pipeline.queue.PushWait(req, pipeline.maxItems, deadline))
+}
+
 // Performs a takeover of another pipeline.  Note that this does not
 //  take over the requests queued in the old pipeline, and those must
 //  be drained and processed separately.
 func (pipeline *memdPipeline) Takeover(oldPipeline *memdPipeline) {
 	if oldPipeline.address != pipeline.address {
-		logErrorf("Attempted pipeline takeover for differing address")
+		pipeline.logCtx.logErrorf("Attempted pipeline takeover for differing address")
 
 		// We try to 'gracefully' error here by resolving all the requests as
 		//  errors, but allowing the application to continue.
 		err := oldPipeline.Close()
 		if err != nil {
 			// Log and continue with this non-fatal error.
-			logDebugf("Failed to shutdown old pipeline (%s)", err)
+			pipeline.logCtx.logDebugf("Failed to shutdown old pipeline (%s)", err)
 		}
 
 		// Drain all the requests as an internal error so they are not lost
@@ -154,7 +172,7 @@ func (pipeline *memdPipeline) Close() error {
 	for _, pipecli := range clients {
 		err := pipecli.Close()
 		if err != nil {
-			logErrorf("failed to shutdown pipeline client: %s", err)
+			pipeline.logCtx.logErrorf("failed to shutdown pipeline client: %s", err)
 			hadErrors = true
 		}
 	}
@@ -169,6 +187,40 @@ func (pipeline *memdPipeline) Close() error {
 	return nil
 }
 
+// CloseGracefully closes the pipeline without failing the requests which are in flight. Its connections stop sending
+// requests and its queue is closed before returning, so the queued requests can be drained and dispatched elsewhere,
+// but each connection is only closed once its in flight requests complete, for up to connRetireTimeout or until
+// abortSig is closed. The returned channel is closed once every connection has been closed.
+func (pipeline *memdPipeline) CloseGracefully(abortSig <-chan struct{}) <-chan struct{} {
+	pipeline.clientsLock.Lock()
+	clients := pipeline.clients
+	pipeline.clients = nil
+	pipeline.clientsLock.Unlock()
+
+	for _, pipecli := range clients {
+		pipecli.startClose(abortSig)
+	}
+
+	pipeline.queue.Close()
+
+	closedSig := make(chan struct{})
+	go func() {
+		for _, pipecli := range clients {
+			pipecli.waitForClose()
+		}
+		close(closedSig)
+	}()
+
+	return closedSig
+}
+
 func (pipeline *memdPipeline) Drain(cb func(*memdQRequest)) {
 	pipeline.queue.Drain(cb)
 }
+
+// connectionLifetime returns how long a connection should live for given the maximum connection age. Up to a quarter
+// of the maximum age is removed at random so that connections which were opened together are not all recycled at
+// the same time.
+func connectionLifetime(maxConnAge time.Duration) time.Duration {
+	return maxConnAge - time.Duration(rand.Float64()*float64(maxConnAge)/4) // #nosec G404
+}
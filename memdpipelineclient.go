@@ -5,8 +5,13 @@ import (
 	"io"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// connRetireTimeout is how long a connection which has reached its maximum age is given for the requests in flight
+// on it to complete before it is closed.
+const connRetireTimeout = 10 * time.Second
+
 type clientWait struct {
 	client *memdClient
 	err    error
@@ -23,6 +28,15 @@ type memdPipelineClient struct {
 	state         uint32
 
 	connectError error
+
+	// retireAbortSig is set when the client is closed gracefully, the connection is then retired rather than being
+	// closed straight away. Closing the channel cuts the retirement short.
+	retireAbortSig <-chan struct{}
+
+	// retiring tracks connections which have handed over to a replacement and are being retired in the background.
+	retiring sync.WaitGroup
+
+	logCtx logContext
 }
 
 func newMemdPipelineClient(parent *memdPipeline) *memdPipelineClient {
@@ -32,6 +46,7 @@ func newMemdPipelineClient(parent *memdPipeline) *memdPipelineClient {
 		closedSig:     make(chan struct{}),
 		cancelDialSig: make(chan struct{}),
 		state:         uint32(EndpointStateDisconnected),
+		logCtx:        parent.logCtx,
 	}
 }
 
@@ -57,23 +72,49 @@ func (pipecli *memdPipelineClient) ReassignTo(parent *memdPipeline) {
 	}
 }
 
-func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
+// ioLoop sends requests from the pipeline using client until it is closed. If client reaches its maximum age then
+// ioLoop returns the replacement connection which it has handed over to.
+func (pipecli *memdPipelineClient) ioLoop(client *memdClient) *memdClient {
 	pipecli.lock.Lock()
 	if pipecli.parent == nil {
-		logDebugf("Pipeline client ioLoop started with no parent pipeline")
+		pipecli.logCtx.logDebugf("Pipeline client ioLoop started with no parent pipeline")
 		pipecli.lock.Unlock()
 
 		err := client.Close()
 		if err != nil {
-			logErrorf("Failed to close client for shut down ioLoop (%s)", err)
+			pipecli.logCtx.logErrorf("Failed to close client for shut down ioLoop (%s)", err)
 		}
 
-		return
+		return nil
 	}
 
 	pipecli.client = client
+	pipeline := pipecli.parent
 	pipecli.lock.Unlock()
 
+	// When the connection reaches its maximum age a replacement is dialled whilst the connection carries on sending
+	// requests. Once the replacement is ready we stop consuming requests from the pipeline, the loop below then hands
+	// over to the replacement and retires the connection once the requests already in flight have completed.
+	var ageTimer *time.Timer
+	var replacementCh chan clientWait
+	if pipeline.maxConnAge > 0 {
+		replacementCh = make(chan clientWait, 1)
+		ageTimer = time.AfterFunc(connectionLifetime(pipeline.maxConnAge), func() {
+			pipecli.logCtx.logDebugf("Pipeline client `%s/%p` dialling replacement for expired client %p", pipecli.address, pipecli, client)
+			replacement, err := pipeline.getClientFn(pipecli.cancelDialSig)
+
+			pipecli.lock.Lock()
+			replacementCh <- clientWait{client: replacement, err: err}
+			activeConsumer := pipecli.consumer
+			pipecli.consumer = nil
+			pipecli.lock.Unlock()
+
+			if activeConsumer != nil {
+				activeConsumer.Close()
+			}
+		})
+	}
+
 	killSig := make(chan struct{})
 
 	// This goroutine is responsible for monitoring the client and handling
@@ -81,19 +122,22 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 	// shut down flow through this goroutine, even cases where we may already
 	// be aware that the client is shutdown, outside this scope.
 	go func() {
-		logDebugf("Pipeline client `%s/%p` client watcher starting...", pipecli.address, pipecli)
+		pipecli.logCtx.logDebugf("Pipeline client `%s/%p` client watcher starting...", pipecli.address, pipecli)
 
 		<-client.CloseNotify()
 
-		logDebugf("Pipeline client `%s/%p` client died", pipecli.address, pipecli)
+		pipecli.logCtx.logDebugf("Pipeline client `%s/%p` client died", pipecli.address, pipecli)
 
 		pipecli.lock.Lock()
-		pipecli.client = nil
-		activeConsumer := pipecli.consumer
-		pipecli.consumer = nil
+		var activeConsumer *memdOpConsumer
+		if pipecli.client == client {
+			pipecli.client = nil
+			activeConsumer = pipecli.consumer
+			pipecli.consumer = nil
+		}
 		pipecli.lock.Unlock()
 
-		logDebugf("Pipeline client `%s/%p` closing consumer %p", pipecli.address, pipecli, activeConsumer)
+		pipecli.logCtx.logDebugf("Pipeline client `%s/%p` closing consumer %p", pipecli.address, pipecli, activeConsumer)
 
 		// If we have a consumer, we need to close it to signal the loop below that
 		// something has happened.  If there is no consumer, we don't need to signal
@@ -106,12 +150,14 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 		killSig <- struct{}{}
 	}()
 
-	logDebugf("Pipeline client `%s/%p` IO loop starting...", pipecli.address, pipecli)
+	pipecli.logCtx.logDebugf("Pipeline client `%s/%p` IO loop starting...", pipecli.address, pipecli)
 
 	var localConsumer *memdOpConsumer
+	var handover *memdClient
+	var replacementReceived bool
 	for {
 		if localConsumer == nil {
-			logDebugf("Pipeline client `%s/%p` fetching new consumer", pipecli.address, pipecli)
+			pipecli.logCtx.logDebugf("Pipeline client `%s/%p` fetching new consumer", pipecli.address, pipecli)
 
 			pipecli.lock.Lock()
 
@@ -130,18 +176,46 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 
 			if pipecli.parent == nil {
 				// This pipelineClient has been shut down
-				logDebugf("Pipeline client `%s/%p` found no parent pipeline", pipecli.address, pipecli)
+				pipecli.logCtx.logDebugf("Pipeline client `%s/%p` found no parent pipeline", pipecli.address, pipecli)
+				retireAbortSig := pipecli.retireAbortSig
 				pipecli.lock.Unlock()
 
+				if retireAbortSig != nil {
+					pipecli.retireClient(client, retireAbortSig)
+					break
+				}
+
 				// Close our client to force the watcher goroutine above to clean it up
 				err := client.Close()
 				if err != nil {
-					logErrorf("Pipeline client `%s/%p` failed to shut down client socket (%s)", pipecli.address, pipecli, err)
+					pipecli.logCtx.logErrorf("Pipeline client `%s/%p` failed to shut down client socket (%s)", pipecli.address, pipecli, err)
 				}
 
 				break
 			}
 
+			if replacement, ok := receiveReplacement(replacementCh); ok {
+				pipecli.lock.Unlock()
+				replacementReceived = true
+
+				if replacement.err != nil {
+					// Without a replacement we retire the connection first and then dial a new one as normal.
+					pipecli.logCtx.logDebugf("Pipeline client `%s/%p` failed to dial replacement for expired client (%v)", pipecli.address, pipecli, replacement.err)
+					pipecli.retireClient(client, nil)
+					break
+				}
+
+				// The retiring connection is closed straight away if this pipeline client is shut down.
+				handover = replacement.client
+				pipecli.retiring.Add(1)
+				go func() {
+					pipecli.retireClient(client, pipecli.cancelDialSig)
+					<-killSig
+					pipecli.retiring.Done()
+				}()
+				break
+			}
+
 			// Fetch a new consumer to use for this iteration
 			localConsumer = pipecli.parent.queue.Consumer()
 			pipecli.consumer = localConsumer
@@ -149,6 +223,13 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 			pipecli.lock.Unlock()
 		}
 
+		// Requests are left in the queue whilst the connection is at its in flight limit so that any other
+		// connections to the node can send them instead.
+		if !client.WaitForInFlightSpace(localConsumer.ClosedSig()) {
+			localConsumer = nil
+			continue
+		}
+
 		req := localConsumer.Pop()
 		if req == nil {
 			// Set the local consumer to null, this will force our normal logic to run
@@ -163,7 +244,7 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 
 		err := client.SendRequest(req)
 		if err != nil {
-			logDebugf("Pipeline client `%s/%p` encountered a socket write error: %v", pipecli.address, pipecli, err)
+			pipecli.logCtx.logDebugf("Pipeline client `%s/%p` encountered a socket write error: %v", pipecli.address, pipecli, err)
 
 			if !errors.Is(err, io.EOF) {
 				// If we errored the write, and the client was not already closed,
@@ -171,9 +252,9 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 				// logic via the client watcher above.  If the socket error was EOF
 				// we already did shut down, and the watcher should already be
 				// cleaning up.
-				err := client.Close()
-				if err != nil {
-					logErrorf("Pipeline client `%s/%p` failed to shut down errored client socket (%s)", pipecli.address, pipecli, err)
+				closeErr := client.CloseWithError(err)
+				if closeErr != nil {
+					pipecli.logCtx.logErrorf("Pipeline client `%s/%p` failed to shut down errored client socket (%s)", pipecli.address, pipecli, closeErr)
 				}
 			}
 
@@ -190,18 +271,60 @@ func (pipecli *memdPipelineClient) ioLoop(client *memdClient) {
 		}
 	}
 
+	if ageTimer != nil && !ageTimer.Stop() && !replacementReceived {
+		// A replacement is being dialled for a connection which has gone away anyway, we wait for it so that nothing
+		// is left running once this loop has exited.
+		pipecli.closeReplacement(<-replacementCh)
+	}
+
+	if handover != nil {
+		pipecli.logCtx.logDebugf("Pipeline client `%s/%p` handing over from client %p to %p", pipecli.address, pipecli, client, handover)
+		return handover
+	}
+
 	atomic.StoreUint32(&pipecli.state, uint32(EndpointStateDisconnecting))
-	logDebugf("Pipeline client `%s/%p` waiting for client shutdown", pipecli.address, pipecli)
+	pipecli.logCtx.logDebugf("Pipeline client `%s/%p` waiting for client shutdown", pipecli.address, pipecli)
 
 	// We must wait for the close wait goroutine to die as well before we can continue.
 	<-killSig
 
-	logDebugf("Pipeline client `%s/%p` received client shutdown notification", pipecli.address, pipecli)
+	pipecli.logCtx.logDebugf("Pipeline client `%s/%p` received client shutdown notification", pipecli.address, pipecli)
+	return nil
+}
+
+// receiveReplacement returns the replacement connection dialled for an expired connection, if it is ready.
+func receiveReplacement(replacementCh <-chan clientWait) (clientWait, bool) {
+	select {
+	case replacement := <-replacementCh:
+		return replacement, true
+	default:
+		return clientWait{}, false
+	}
+}
+
+// closeReplacement closes a replacement connection which is no longer needed.
+func (pipecli *memdPipelineClient) closeReplacement(replacement clientWait) {
+	if replacement.err != nil {
+		return
+	}
+
+	err := replacement.client.Close()
+	if err != nil {
+		pipecli.logCtx.logErrorf("Pipeline client `%s/%p` failed to close unused replacement client (%s)", pipecli.address, pipecli, err)
+	}
 }
 
 func (pipecli *memdPipelineClient) Run() {
+	var failedAttempts uint32
+	var handover *memdClient
 	for {
-		logDebugf("Pipeline Client `%s/%p` preparing for new client loop", pipecli.address, pipecli)
+		if handover != nil {
+			// The previous connection reached its maximum age and has already dialled its replacement.
+			handover = pipecli.ioLoop(handover)
+			continue
+		}
+
+		pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` preparing for new client loop", pipecli.address, pipecli)
 		atomic.StoreUint32(&pipecli.state, uint32(EndpointStateConnecting))
 
 		pipecli.lock.Lock()
@@ -210,11 +333,11 @@ func (pipecli *memdPipelineClient) Run() {
 
 		if pipeline == nil {
 			// If our pipeline is nil, it indicates that we need to shut down.
-			logDebugf("Pipeline Client `%s/%p` is shutting down", pipecli.address, pipecli)
+			pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` is shutting down", pipecli.address, pipecli)
 			break
 		}
 
-		logDebugf("Pipeline Client `%s/%p` retrieving new client connection for parent %p", pipecli.address, pipecli, pipeline)
+		pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` retrieving new client connection for parent %p", pipecli.address, pipecli, pipeline)
 		wait := make(chan clientWait, 1)
 		go func() {
 			client, err := pipeline.getClientFn(pipecli.cancelDialSig)
@@ -230,12 +353,28 @@ func (pipecli *memdPipelineClient) Run() {
 			pipecli.lock.Lock()
 			if pipecli.parent != nil {
 				// If we know that we're shutting then don't log the error, it isn't unexpected.
-				logWarnf("Pipeline Client %p failed to bootstrap: %s", pipecli, cli.err)
+				pipecli.logCtx.logWarnf("Pipeline Client %p failed to bootstrap: %s", pipecli, cli.err)
 			}
 			pipecli.connectError = cli.err
 			pipecli.lock.Unlock()
+
+			failedAttempts++
+			backoff := pipeline.reconnectBackoff
+			delay := backoff.Delay(failedAttempts)
+			if backoff.FailureCallback != nil {
+				backoff.FailureCallback(pipecli.address, failedAttempts, cli.err, delay)
+			}
+
+			if delay > 0 {
+				pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` waiting %s before reconnecting", pipecli.address, pipecli, delay)
+				select {
+				case <-pipecli.cancelDialSig:
+				case <-time.After(delay):
+				}
+			}
 			continue
 		}
+		failedAttempts = 0
 
 		pipecli.lock.Lock()
 		pipecli.connectError = nil
@@ -243,18 +382,79 @@ func (pipecli *memdPipelineClient) Run() {
 		atomic.StoreUint32(&pipecli.state, uint32(EndpointStateConnected))
 
 		// Runs until the connection has died (for whatever reason)
-		logDebugf("Pipeline Client `%s/%p` starting new client loop for %p", pipecli.address, pipecli, cli.client)
-		pipecli.ioLoop(cli.client)
+		pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` starting new client loop for %p", pipecli.address, pipecli, cli.client)
+		handover = pipecli.ioLoop(cli.client)
 	}
 
 	// Lets notify anyone who is watching that we are now shut down
 	close(pipecli.closedSig)
 }
 
+// retireClient waits for the requests in flight on client to complete, up to connRetireTimeout or until abortSig is
+// closed, and then closes it.
+func (pipecli *memdPipelineClient) retireClient(client *memdClient, abortSig <-chan struct{}) {
+	pipecli.logCtx.logDebugf("Pipeline client `%s/%p` retiring client %p", pipecli.address, pipecli, client)
+
+	deadline := time.Now().Add(connRetireTimeout)
+	for client.InFlightCount() > 0 && time.Now().Before(deadline) {
+		select {
+		case <-client.CloseNotify():
+			return
+		case <-abortSig:
+			deadline = time.Time{}
+		case <-time.After(reconnectPollInterval):
+		}
+	}
+
+	err := client.Close()
+	if err != nil {
+		pipecli.logCtx.logErrorf("Pipeline client `%s/%p` failed to close retired client (%s)", pipecli.address, pipecli, err)
+	}
+}
+
+// Reconnect closes the current connection, if there is one, which causes a new connection to be dialled. It returns
+// the connection which was closed.
+func (pipecli *memdPipelineClient) Reconnect() *memdClient {
+	pipecli.lock.Lock()
+	client := pipecli.client
+	pipecli.lock.Unlock()
+
+	if client == nil {
+		return nil
+	}
+
+	pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` reconnecting client %p", pipecli.address, pipecli, client)
+	err := client.Close()
+	if err != nil {
+		pipecli.logCtx.logErrorf("Pipeline Client `%s/%p` failed to close client for reconnect (%s)", pipecli.address, pipecli, err)
+	}
+
+	return client
+}
+
+// isConnectedWithout returns whether this pipeline client is connected using a connection other than oldClient.
+func (pipecli *memdPipelineClient) isConnectedWithout(oldClient *memdClient) bool {
+	pipecli.lock.Lock()
+	client := pipecli.client
+	pipecli.lock.Unlock()
+
+	return client != nil && client != oldClient && pipecli.State() == EndpointStateConnected
+}
+
 // Close will close this pipeline client.  Note that this method will not wait for
 // everything to be cleaned up before returning.
 func (pipecli *memdPipelineClient) Close() error {
-	logDebugf("Pipeline Client `%s/%p` received close request", pipecli.address, pipecli)
+	pipecli.startClose(nil)
+	pipecli.waitForClose()
+
+	return nil
+}
+
+// startClose begins shutting down the client without waiting for it to finish. If retireAbortSig is not nil then the
+// current connection stops sending requests immediately but is only closed once the requests in flight on it have
+// completed, as when a connection reaches its maximum age.
+func (pipecli *memdPipelineClient) startClose(retireAbortSig <-chan struct{}) {
+	pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` received close request", pipecli.address, pipecli)
 	atomic.StoreUint32(&pipecli.state, uint32(EndpointStateDisconnecting))
 
 	// To shut down the client, we remove our reference to the parent. This
@@ -262,6 +462,7 @@ func (pipecli *memdPipelineClient) Close() error {
 	// before exiting.
 	pipecli.lock.Lock()
 	pipecli.parent = nil
+	pipecli.retireAbortSig = retireAbortSig
 	activeConsumer := pipecli.consumer
 	pipecli.consumer = nil
 	pipecli.lock.Unlock()
@@ -278,12 +479,13 @@ func (pipecli *memdPipelineClient) Close() error {
 	if activeConsumer != nil {
 		activeConsumer.Close()
 	}
+}
 
+func (pipecli *memdPipelineClient) waitForClose() {
 	// Lets wait till the ioLoop has shut everything down before returning.
 	<-pipecli.closedSig
+	pipecli.retiring.Wait()
 	atomic.StoreUint32(&pipecli.state, uint32(EndpointStateDisconnected))
 
-	logDebugf("Pipeline Client `%s/%p` has exited", pipecli.address, pipecli)
-
-	return nil
+	pipecli.logCtx.logDebugf("Pipeline Client `%s/%p` has exited", pipecli.address, pipecli)
 }
@@ -0,0 +1,159 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestConnectionLifetimeStaggered() {
+	for i := 0; i < 100; i++ {
+		lifetime := connectionLifetime(time.Minute)
+		suite.Assert().True(lifetime > 45*time.Second && lifetime <= time.Minute, lifetime.String())
+	}
+}
+
+func (suite *UnitTestSuite) TestMaxConnectionAgeRecyclesConnections() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var connects uint32
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:       "default",
+		MaxConnectionAge: 50 * time.Millisecond,
+		EndpointEventCallback: func(event EndpointEvent) {
+			if event.Type == EndpointEventConnected {
+				atomic.AddUint32(&connects, 1)
+			}
+		},
+	})
+	defer agent.Close()
+
+	// Operations should keep succeeding whilst the connection is being recycled underneath them.
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint32(&connects) < 3 && time.Now().Before(deadline) {
+		suite.mustSet(agent, "key")
+	}
+
+	suite.Assert().True(atomic.LoadUint32(&connects) >= 3)
+
+	config := &AgentConfig{}
+	suite.Require().Nil(config.FromConnStr("couchbase://10.112.192.101?max_connection_age=10m"))
+	suite.Assert().Equal(10*time.Minute, config.MaxConnectionAge)
+}
+
+func (suite *UnitTestSuite) TestMaxConnectionAgeHandsOverBeforeRetiring() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		if string(req.Key) == "hang" {
+			return nil
+		}
+		return defaultGet(req)
+	})
+
+	var connects uint32
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:       "default",
+		MaxConnectionAge: 50 * time.Millisecond,
+		EndpointEventCallback: func(event EndpointEvent) {
+			if event.Type == EndpointEventConnected {
+				atomic.AddUint32(&connects, 1)
+			}
+		},
+	})
+	defer agent.Close()
+
+	suite.mustSet(agent, "key")
+
+	// The hung request keeps the first connection from being retired until the retire timeout.
+	hangCh := make(chan error, 1)
+	hangOp, err := agent.Get(GetOptions{Key: []byte("hang"), Deadline: time.Now().Add(30 * time.Second)},
+		func(res *GetResult, err error) {
+			hangCh <- err
+		})
+	suite.Require().Nil(err)
+
+	for atomic.LoadUint32(&connects) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The replacement must already be serving requests whilst the first connection is still retiring.
+	start := time.Now()
+	suite.mustSet(agent, "key")
+	suite.Assert().True(time.Since(start) < connRetireTimeout/2, time.Since(start).String())
+
+	hangOp.Cancel()
+	<-hangCh
+}
+
+func (suite *UnitTestSuite) TestKvMaxInFlight() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var gets uint32
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		atomic.AddUint32(&gets, 1)
+		if string(req.Key) == "hang" {
+			return nil
+		}
+		return defaultGet(req)
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName:    "default",
+		KvMaxInFlight: 2,
+	})
+	defer agent.Close()
+
+	errCh := make(chan error, 3)
+	var ops []PendingOp
+	for i := 0; i < 3; i++ {
+		op, err := agent.Get(GetOptions{Key: []byte("hang"), Deadline: time.Now().Add(5 * time.Second)},
+			func(res *GetResult, err error) {
+				errCh <- err
+			})
+		suite.Require().Nil(err)
+		ops = append(ops, op)
+	}
+
+	waitForStats := func(inFlight, queued int) {
+		var stats []KvEndpointStats
+		var err error
+		for i := 0; i < 100; i++ {
+			stats, err = agent.KvEndpointStats()
+			suite.Require().Nil(err)
+			if len(stats) == 1 && stats[0].InFlightOps == inFlight && stats[0].QueuedOps == queued {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		suite.T().Fatalf("expected %d in flight and %d queued but got %+v", inFlight, queued, stats)
+	}
+
+	// The third request can't be sent until one of the first two completes.
+	waitForStats(2, 1)
+	suite.Assert().Equal(uint32(2), atomic.LoadUint32(&gets))
+
+	ops[0].Cancel()
+	suite.Assert().True(errors.Is(<-errCh, ErrRequestCanceled))
+	waitForStats(2, 0)
+	suite.Assert().Equal(uint32(3), atomic.LoadUint32(&gets))
+
+	ops[1].Cancel()
+	ops[2].Cancel()
+	<-errCh
+	<-errCh
+
+	config := &AgentConfig{}
+	_, err := config.FromConnStrWithOptions("couchbase://10.112.192.101?kv_max_in_flight=16",
+		FromConnStrOptions{Strict: true})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(16, config.KvMaxInFlight)
+}
@@ -2,6 +2,7 @@ package gocbcore
 
 import (
 	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,16 @@ type memdQResponse struct {
 	sourceConnID string
 }
 
+// ServerDuration returns the time which the server reported spending processing the request, or zero if the
+// server did not report it.
+func (resp *memdQResponse) ServerDuration() time.Duration {
+	if resp.Packet == nil || resp.ServerDurationFrame == nil {
+		return 0
+	}
+
+	return resp.ServerDurationFrame.ServerDuration
+}
+
 type callback func(*memdQResponse, *memdQRequest, error)
 
 // The data for a request that can be queued with a memdqueueconn,
@@ -32,12 +43,25 @@ type memdQRequest struct {
 	ReplicaIdx int
 	Callback   callback
 	Persistent bool
+	Priority   OperationPriority
+
+	// ValueWriter, if set, receives the value of a successful response as it is read from the connection rather than
+	// the value being buffered in the response. Compressed values are always buffered.
+	ValueWriter io.Writer
 
 	// This tracks when the request was dispatched so that we can
 	//  properly prioritize older requests to try and meet timeout
 	//  requirements.
 	dispatchTime time.Time
 
+	// This stores a pointer to the operationCounters of the agent which
+	//  dispatched the request, it is set when the request is first sent.
+	opCounters unsafe.Pointer
+
+	// bucketEpoch records which bucket selection the request was first dispatched under, so that it is never
+	// retried against a different bucket.
+	bucketEpoch uint32
+
 	// This stores a pointer to the server that currently own
 	//   this request.  This allows us to remove it from that list
 	//   whenever the request is cancelled.
@@ -48,6 +72,11 @@ type memdQRequest struct {
 	//  whenever the request is cancelled
 	waitingIn unsafe.Pointer
 
+	// This stores a pointer to the queue that this request is waiting
+	//  for space in.  This allows us to wake the waiter whenever the
+	//  request is cancelled.
+	pushWaitingIn unsafe.Pointer
+
 	// This keeps track of whether the request has been 'completed'
 	//  which is synonymous with the callback having been invoked.
 	//  This is an integer to allow us to atomically control it.
@@ -70,6 +99,16 @@ type memdQRequest struct {
 	// This is the set of reasons why this request has been retried.
 	retryReasons []RetryReason
 
+	// This is how long the server asked to wait before the request is retried, it is only set whilst the retry is
+	// being considered.
+	retryAfterHint time.Duration
+
+	// This is the address of every server that the request has been written to, in order.
+	dispatchedTo []string
+
+	// This tracks whether the request has been passed through the kv interceptor chain.
+	intercepted bool
+
 	// This is used to lock access to the request when processing
 	// retry reasons or attempts.
 	retryLock sync.Mutex
@@ -87,12 +126,17 @@ type memdQRequest struct {
 
 	CollectionName string
 	ScopeName      string
+
+	// Deadline is the time by which the request must have completed, if set then dispatching the request will
+	// never block beyond it.
+	Deadline time.Time
 }
 
 type memdQRequestConnInfo struct {
 	lastDispatchedTo   string
 	lastDispatchedFrom string
 	lastConnectionID   string
+	lastDispatchedAt   time.Time
 }
 
 func (req *memdQRequest) RetryAttempts() uint32 {
@@ -107,6 +151,19 @@ func (req *memdQRequest) RetryReasons() []RetryReason {
 	return req.retryReasons
 }
 
+// RetryAfterHint returns how long the server has asked to wait before the request is sent again.
+func (req *memdQRequest) RetryAfterHint() time.Duration {
+	req.retryLock.Lock()
+	defer req.retryLock.Unlock()
+	return req.retryAfterHint
+}
+
+func (req *memdQRequest) setRetryAfterHint(hint time.Duration) {
+	req.retryLock.Lock()
+	req.retryAfterHint = hint
+	req.retryLock.Unlock()
+}
+
 // Retries is here because we're locked into a publically exposed interface for RetryAttempts/RetryReasons.
 // This function allows us to internally get count and reasons together preventing any races causing the count and
 // reasons to mismatch.
@@ -139,19 +196,58 @@ func (req *memdQRequest) ConnectionInfo() memdQRequestConnInfo {
 
 func (req *memdQRequest) SetConnectionInfo(info memdQRequestConnInfo) {
 	req.connInfo.Store(info)
+
+	req.retryLock.Lock()
+	req.dispatchedTo = append(req.dispatchedTo, info.lastDispatchedTo)
+	req.retryLock.Unlock()
+}
+
+// DispatchedTo returns the address of every server that the request has been written to.
+func (req *memdQRequest) DispatchedTo() []string {
+	req.retryLock.Lock()
+	defer req.retryLock.Unlock()
+	return append([]string(nil), req.dispatchedTo...)
+}
+
+// InFlight returns whether the request has been written to a server and is waiting for a response.
+func (req *memdQRequest) InFlight() bool {
+	return atomic.LoadPointer(&req.waitingIn) != nil
 }
 
-func (req *memdQRequest) SetTimer(t *time.Timer) {
-	req.timer.Store(t)
+// makeTimeoutError creates a TimeoutError containing the dispatch and retry history of the request.
+func (req *memdQRequest) makeTimeoutError(innerErr error, operationID string, start time.Time) *TimeoutError {
+	connInfo := req.ConnectionInfo()
+	count, reasons := req.Retries()
+	return &TimeoutError{
+		InnerError:         innerErr,
+		OperationID:        operationID,
+		Opaque:             req.Identifier(),
+		TimeObserved:       time.Since(start),
+		RetryReasons:       reasons,
+		RetryAttempts:      count,
+		LastDispatchedTo:   connInfo.lastDispatchedTo,
+		LastDispatchedFrom: connInfo.lastDispatchedFrom,
+		LastConnectionID:   connInfo.lastConnectionID,
+		DispatchedTo:       req.DispatchedTo(),
+		InFlight:           req.InFlight(),
+	}
+}
+
+type memdQRequestTimer struct {
+	timer opTimer
+}
+
+func (req *memdQRequest) SetTimer(t opTimer) {
+	req.timer.Store(memdQRequestTimer{timer: t})
 }
 
-func (req *memdQRequest) Timer() *time.Timer {
+func (req *memdQRequest) Timer() opTimer {
 	t := req.timer.Load()
 	if t == nil {
 		return nil
 	}
 
-	return t.(*time.Timer)
+	return t.(memdQRequestTimer).timer
 }
 
 func (req *memdQRequest) recordRetryAttempt(retryReason RetryReason) {
@@ -172,6 +268,10 @@ func (req *memdQRequest) recordRetryAttempt(retryReason RetryReason) {
 	}
 }
 
+func (req *memdQRequest) counters() *operationCounters {
+	return (*operationCounters)(atomic.LoadPointer(&req.opCounters))
+}
+
 func (req *memdQRequest) tryCallback(resp *memdQResponse, err error) {
 	if t := req.Timer(); t != nil {
 		t.Stop()
@@ -180,6 +280,7 @@ func (req *memdQRequest) tryCallback(resp *memdQResponse, err error) {
 	if req.Persistent {
 		if err != nil {
 			if req.internalCancel(err) {
+				req.counters().RecordKvOutcome(req.Command, err)
 				req.Callback(resp, req, err)
 			}
 		} else {
@@ -189,6 +290,7 @@ func (req *memdQRequest) tryCallback(resp *memdQResponse, err error) {
 		}
 	} else {
 		if atomic.SwapUint32(&req.isCompleted, 1) == 0 {
+			req.counters().RecordKvOutcome(req.Command, err)
 			req.Callback(resp, req, err)
 		}
 	}
@@ -219,6 +321,11 @@ func (req *memdQRequest) internalCancel(err error) bool {
 		queuedWith.Remove(req)
 	}
 
+	pushWaitingIn := (*memdOpQueue)(atomic.LoadPointer(&req.pushWaitingIn))
+	if pushWaitingIn != nil {
+		pushWaitingIn.wakePushWaiters()
+	}
+
 	waitingIn := (*memdClient)(atomic.LoadPointer(&req.waitingIn))
 	if waitingIn != nil {
 		waitingIn.CancelRequest(req, err)
@@ -234,6 +341,7 @@ func (req *memdQRequest) cancelWithCallback(err error) {
 	// Try to perform the cancellation, if it succeeds, we call the
 	// callback immediately on the users behalf.
 	if req.internalCancel(err) {
+		req.counters().RecordKvOutcome(req.Command, err)
 		req.Callback(nil, req, err)
 	}
 }
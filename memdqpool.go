@@ -0,0 +1,68 @@
+package gocbcore
+
+import (
+	"sync"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// Note that memdQRequest is intentionally not pooled. Requests are handed to users as a PendingOp which may be
+// cancelled at any point after the callback has been invoked, and the pipeline client can still reference a
+// request after its callback has been invoked when a write fails, so there is no point at which a request is
+// known to be unreferenced.
+
+var memdQResponsePool = sync.Pool{
+	New: func() interface{} {
+		return &memdQResponse{}
+	},
+}
+
+var dcpBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &dcpBuffer{}
+	},
+}
+
+// acquireMemdQResponse retrieves a response from the pool, the response should be returned using
+// releaseMemdQResponse once it has been resolved.
+func acquireMemdQResponse() *memdQResponse {
+	return memdQResponsePool.Get().(*memdQResponse)
+}
+
+// releaseMemdQResponse resets the response and returns it, along with its packet, to the pool. Responses are only
+// valid for the duration of the request callback so this must only be called once the callback has returned.
+func releaseMemdQResponse(resp *memdQResponse) {
+	if resp.Packet != nil {
+		memd.ReleasePacket(resp.Packet)
+	}
+
+	*resp = memdQResponse{}
+	memdQResponsePool.Put(resp)
+}
+
+// compressBufPool holds the buffers which request values are compressed into before being written.
+var compressBufPool = sync.Pool{
+	New: func() interface{} {
+		return new([]byte)
+	},
+}
+
+// acquireCompressBuf retrieves a buffer from the pool, the buffer may be replaced by a larger one whilst in use. The
+// buffer must only be returned once the packet referencing it has been written.
+func acquireCompressBuf() *[]byte {
+	return compressBufPool.Get().(*[]byte)
+}
+
+func releaseCompressBuf(buf *[]byte) {
+	*buf = (*buf)[:0]
+	compressBufPool.Put(buf)
+}
+
+func acquireDcpBuffer() *dcpBuffer {
+	return dcpBufferPool.Get().(*dcpBuffer)
+}
+
+func releaseDcpBuffer(buf *dcpBuffer) {
+	*buf = dcpBuffer{}
+	dcpBufferPool.Put(buf)
+}
@@ -0,0 +1,236 @@
+package gocbcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MgmtStreamNode describes a node as reported by a streaming management endpoint.
+// Volatile: This API is subject to change at any time.
+type MgmtStreamNode struct {
+	Hostname          string   `json:"hostname"`
+	OTPNode           string   `json:"otpNode"`
+	Status            string   `json:"status"`
+	ClusterMembership string   `json:"clusterMembership"`
+	Services          []string `json:"services"`
+	Version           string   `json:"version"`
+}
+
+// MgmtStreamUpdate is a single update received from a streaming management endpoint.
+// Volatile: This API is subject to change at any time.
+type MgmtStreamUpdate struct {
+	// Endpoint is the management endpoint that the update was received from.
+	Endpoint        string
+	Nodes           []MgmtStreamNode
+	RebalanceStatus string
+	Balanced        bool
+
+	// Raw is the full update as sent by the server, for accessing fields which are not parsed.
+	Raw []byte
+}
+
+type jsonMgmtStreamUpdate struct {
+	Nodes           []MgmtStreamNode `json:"nodes"`
+	RebalanceStatus string           `json:"rebalanceStatus"`
+	Balanced        bool             `json:"balanced"`
+}
+
+// MgmtStreamCallback is invoked with each update received by a MgmtStreamWatcher. It is invoked with an error,
+// and a nil update, whenever the watcher fails to connect to or loses its connection to an endpoint. The watcher
+// keeps reconnecting after an error until it is closed.
+// Volatile: This API is subject to change at any time.
+type MgmtStreamCallback func(update *MgmtStreamUpdate, err error)
+
+// WatchMgmtStreamOptions encapsulates the parameters for a WatchMgmtStream operation.
+// Volatile: This API is subject to change at any time.
+type WatchMgmtStreamOptions struct {
+	// Path is the streaming endpoint to watch, for example /poolsStreaming/default.
+	Path string
+
+	// ConnectTimeout is how long to wait when connecting to an endpoint. Defaults to 10 seconds.
+	ConnectTimeout time.Duration
+
+	// RetryDelay is how long to wait before reconnecting once every endpoint has failed. Defaults to 1 second.
+	RetryDelay time.Duration
+}
+
+// MgmtStreamWatcher watches a streaming management endpoint, moving to another node whenever the connection fails.
+// Volatile: This API is subject to change at any time.
+type MgmtStreamWatcher struct {
+	path           string
+	connectTimeout time.Duration
+	retryDelay     time.Duration
+	httpComponent  *httpComponent
+	muxer          *httpMux
+	callback       MgmtStreamCallback
+	logCtx         logContext
+
+	// Cancelling the context both aborts connection attempts and closes the stream currently being watched.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	doneSig chan struct{}
+}
+
+func newMgmtStreamWatcher(opts WatchMgmtStreamOptions, httpComponent *httpComponent, muxer *httpMux,
+	cb MgmtStreamCallback, logCtx logContext) (*MgmtStreamWatcher, error) {
+	if opts.Path == "" {
+		return nil, wrapError(errInvalidArgument, "path cannot be empty")
+	}
+	if cb == nil {
+		return nil, wrapError(errInvalidArgument, "callback cannot be nil")
+	}
+
+	connectTimeout := 10 * time.Second
+	if opts.ConnectTimeout > 0 {
+		connectTimeout = opts.ConnectTimeout
+	}
+	retryDelay := 1 * time.Second
+	if opts.RetryDelay > 0 {
+		retryDelay = opts.RetryDelay
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := &MgmtStreamWatcher{
+		path:           opts.Path,
+		connectTimeout: connectTimeout,
+		retryDelay:     retryDelay,
+		httpComponent:  httpComponent,
+		muxer:          muxer,
+		callback:       cb,
+		logCtx:         logCtx,
+		ctx:            ctx,
+		cancel:         cancel,
+		doneSig:        make(chan struct{}),
+	}
+	go watcher.loop()
+
+	return watcher, nil
+}
+
+// Close stops the watcher, blocking until the callback will no longer be invoked. Close must not be called from
+// within the callback.
+func (w *MgmtStreamWatcher) Close() {
+	w.cancel()
+	<-w.doneSig
+}
+
+func (w *MgmtStreamWatcher) isClosed() bool {
+	return w.ctx.Err() != nil
+}
+
+func (w *MgmtStreamWatcher) loop() {
+	defer close(w.doneSig)
+
+	var lastEndpoint string
+	failures := 0
+	for !w.isClosed() {
+		endpoints := w.muxer.MgmtEps()
+		if len(endpoints) == 0 || failures >= len(endpoints) {
+			failures = 0
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-time.After(w.retryDelay):
+			}
+			continue
+		}
+
+		// Start from the endpoint after the one we were last connected to so that a node going away doesn't stop
+		// us from getting updates.
+		endpoint := endpoints[0]
+		for i, ep := range endpoints {
+			if ep == lastEndpoint {
+				endpoint = endpoints[(i+1)%len(endpoints)]
+				break
+			}
+		}
+		lastEndpoint = endpoint
+
+		sawUpdate, err := w.watchEndpoint(endpoint)
+		if w.isClosed() {
+			return
+		}
+		if sawUpdate {
+			failures = 0
+		} else {
+			failures++
+		}
+
+		w.logCtx.logDebugf("Management stream from %s disconnected (%v)", logSystemData(endpoint), err)
+		w.callback(nil, err)
+	}
+}
+
+func (w *MgmtStreamWatcher) watchEndpoint(endpoint string) (bool, error) {
+	req := &httpRequest{
+		Service:      MgmtService,
+		Method:       "GET",
+		Path:         w.path,
+		Endpoint:     endpoint,
+		IsIdempotent: true,
+		UniqueID:     uuid.New().String(),
+		Deadline:     time.Now().Add(w.connectTimeout),
+		Context:      w.ctx,
+	}
+
+	resp, err := w.httpComponent.DoInternalHTTPRequest(req, true)
+	if err != nil {
+		return false, err
+	}
+
+	if resp.StatusCode != 200 {
+		closeErr := resp.Body.Close()
+		if closeErr != nil {
+			w.logCtx.logDebugf("Failed to close management stream body (%s)", closeErr)
+		}
+
+		innerErr := errInternalServerFailure
+		if resp.StatusCode == 401 {
+			innerErr = errAuthenticationFailure
+		} else if resp.StatusCode == 404 {
+			innerErr = errFeatureNotAvailable
+		}
+
+		return false, HTTPError{
+			InnerError: wrapError(innerErr, fmt.Sprintf("unexpected status code %d", resp.StatusCode)),
+			UniqueID:   req.UniqueID,
+			Endpoint:   endpoint,
+		}
+	}
+
+	defer func() {
+		closeErr := resp.Body.Close()
+		if closeErr != nil {
+			w.logCtx.logDebugf("Failed to close management stream body (%s)", closeErr)
+		}
+	}()
+
+	sawUpdate := false
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err != nil {
+			return sawUpdate, err
+		}
+
+		var parsed jsonMgmtStreamUpdate
+		err = json.Unmarshal(raw, &parsed)
+		if err != nil {
+			return sawUpdate, err
+		}
+
+		sawUpdate = true
+		w.callback(&MgmtStreamUpdate{
+			Endpoint:        endpoint,
+			Nodes:           parsed.Nodes,
+			RebalanceStatus: parsed.RebalanceStatus,
+			Balanced:        parsed.Balanced,
+			Raw:             raw,
+		}, nil)
+	}
+}
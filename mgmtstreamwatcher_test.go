@@ -0,0 +1,75 @@
+package gocbcore
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestMgmtStreamWatcherReconnects() {
+	var connections uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.Assert().Equal("/poolsStreaming/default", r.URL.Path)
+
+		switch atomic.AddUint32(&connections, 1) {
+		case 1:
+			_, _ = w.Write([]byte(`{"rebalanceStatus":"none","balanced":true,"nodes":[{"hostname":"10.0.0.1:8091",` +
+				`"status":"healthy","clusterMembership":"active","services":["kv","n1ql"]}]}` + "\n\n\n\n"))
+		case 2:
+			w.WriteHeader(503)
+		default:
+			_, _ = w.Write([]byte(`{"rebalanceStatus":"running","balanced":false,"nodes":[]}` + "\n\n\n\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, mgmtEpList: []string{srv.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	httpCpt := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, tracer)
+
+	updates := make(chan *MgmtStreamUpdate, 10)
+	errs := make(chan error, 10)
+	watcher, err := newMgmtStreamWatcher(WatchMgmtStreamOptions{
+		Path:       "/poolsStreaming/default",
+		RetryDelay: time.Millisecond,
+	}, httpCpt, mux, func(update *MgmtStreamUpdate, err error) {
+		if err != nil {
+			errs <- err
+			return
+		}
+		updates <- update
+	}, nil)
+	suite.Require().Nil(err)
+
+	update := <-updates
+	suite.Assert().Equal(srv.URL, update.Endpoint)
+	suite.Assert().Equal("none", update.RebalanceStatus)
+	suite.Assert().True(update.Balanced)
+	suite.Require().Len(update.Nodes, 1)
+	suite.Assert().Equal(MgmtStreamNode{
+		Hostname:          "10.0.0.1:8091",
+		Status:            "healthy",
+		ClusterMembership: "active",
+		Services:          []string{"kv", "n1ql"},
+	}, update.Nodes[0])
+
+	// The server closing the stream and then failing the next attempt are both reported before we reconnect.
+	suite.Assert().NotNil(<-errs)
+	suite.Assert().True(errors.Is(<-errs, ErrInternalServerFailure))
+
+	update = <-updates
+	suite.Assert().Equal("running", update.RebalanceStatus)
+	suite.Assert().False(update.Balanced)
+
+	watcher.Close()
+	suite.Assert().Len(errs, 0)
+	suite.Assert().Len(updates, 0)
+
+	_, err = newMgmtStreamWatcher(WatchMgmtStreamOptions{}, httpCpt, mux, func(*MgmtStreamUpdate, error) {}, nil)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
@@ -0,0 +1,308 @@
+package gocbcore
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClusterRole identifies one of the clusters of a MultiClusterAgent.
+// Volatile: This API is subject to change at any time.
+type ClusterRole uint32
+
+const (
+	// ClusterRolePrimary is the cluster which operations are sent to unless it becomes unreachable.
+	ClusterRolePrimary = ClusterRole(0)
+
+	// ClusterRoleStandby is the cluster which operations are sent to whilst the primary is unreachable.
+	ClusterRoleStandby = ClusterRole(1)
+)
+
+func (role ClusterRole) String() string {
+	switch role {
+	case ClusterRolePrimary:
+		return "primary"
+	case ClusterRoleStandby:
+		return "standby"
+	}
+
+	return "unknown"
+}
+
+// ClusterSwitchoverEvent describes a change of the active cluster of a MultiClusterAgent.
+// Volatile: This API is subject to change at any time.
+type ClusterSwitchoverEvent struct {
+	From ClusterRole
+	To   ClusterRole
+
+	// Automatic is whether the switch was made by the agent, rather than requested using SwitchTo.
+	Automatic bool
+}
+
+// MultiClusterAgentConfig specifies the configuration options for creation of a MultiClusterAgent.
+// Volatile: This API is subject to change at any time.
+type MultiClusterAgentConfig struct {
+	// Primary and Standby are the configs of the agents for each cluster, typically populated using FromConnStr.
+	Primary AgentConfig
+	Standby AgentConfig
+
+	// FailoverAfter is how long the primary cluster must be fully unreachable, with no kv connections established,
+	// before operations are failed over to the standby. The standby is pinged first and operations are only failed
+	// over once it responds. Defaults to 30 seconds.
+	FailoverAfter time.Duration
+
+	// FailbackAfter is how long the primary cluster must be reachable again before operations are failed back to it.
+	// If zero then the agent stays on the standby until SwitchTo is called.
+	FailbackAfter time.Duration
+
+	// CheckInterval is how often the reachability of the primary cluster is checked. Defaults to 1 second.
+	CheckInterval time.Duration
+
+	// SwitchoverCallback, if set, is invoked whenever the active cluster changes.
+	SwitchoverCallback func(ClusterSwitchoverEvent)
+}
+
+// MultiClusterAgent maintains agents connected to a primary and a standby cluster, failing over to the standby when
+// the primary becomes unreachable. Operations should be dispatched to the agent returned by Active, both agents are
+// kept connected so that switching between them is immediate.
+// Volatile: This API is subject to change at any time.
+type MultiClusterAgent struct {
+	agents [2]*Agent
+	active uint32
+
+	failoverAfter      time.Duration
+	failbackAfter      time.Duration
+	checkInterval      time.Duration
+	switchoverCallback func(ClusterSwitchoverEvent)
+	logCtx             logContext
+
+	// isReachable reports whether any kv connections to the cluster are established.
+	isReachable func(role ClusterRole) bool
+
+	// probe reports whether the cluster responds to a kv ping within the timeout.
+	probe func(role ClusterRole, timeout time.Duration) bool
+
+	lock sync.Mutex
+	// transitionSince is when the primary was first seen in the state which would trigger a switch, that is
+	// unreachable whilst it is active or reachable whilst the standby is active, or the zero time if it is not.
+	transitionSince time.Time
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// CreateMultiClusterAgent creates an agent for each of the primary and standby clusters.
+// Volatile: This API is subject to change at any time.
+func CreateMultiClusterAgent(config *MultiClusterAgentConfig) (*MultiClusterAgent, error) {
+	primary, err := CreateAgent(&config.Primary)
+	if err != nil {
+		return nil, err
+	}
+
+	standby, err := CreateAgent(&config.Standby)
+	if err != nil {
+		if closeErr := primary.Close(); closeErr != nil {
+			primary.logCtx.logDebugf("Failed to close primary agent: %v", closeErr)
+		}
+		return nil, err
+	}
+
+	agent := newMultiClusterAgent(config, [2]*Agent{primary, standby})
+	agent.logCtx = logContext{{Key: "primary", Value: primary.clientID}, {Key: "standby", Value: standby.clientID}}
+	agent.isReachable = func(role ClusterRole) bool {
+		return agentIsReachable(agent.agents[role])
+	}
+	agent.probe = func(role ClusterRole, timeout time.Duration) bool {
+		return probeAgent(agent.agents[role], timeout)
+	}
+
+	agent.wg.Add(1)
+	go agent.checkLoop()
+
+	return agent, nil
+}
+
+func newMultiClusterAgent(config *MultiClusterAgentConfig, agents [2]*Agent) *MultiClusterAgent {
+	agent := &MultiClusterAgent{
+		agents:             agents,
+		active:             uint32(ClusterRolePrimary),
+		failoverAfter:      30 * time.Second,
+		failbackAfter:      config.FailbackAfter,
+		checkInterval:      time.Second,
+		switchoverCallback: config.SwitchoverCallback,
+		closeCh:            make(chan struct{}),
+	}
+
+	if config.FailoverAfter > 0 {
+		agent.failoverAfter = config.FailoverAfter
+	}
+	if config.CheckInterval > 0 {
+		agent.checkInterval = config.CheckInterval
+	}
+
+	return agent
+}
+
+func agentIsReachable(agent *Agent) bool {
+	info, err := agent.Diagnostics(DiagnosticsOptions{})
+	if err != nil {
+		return false
+	}
+
+	for _, conn := range info.MemdConns {
+		if conn.State == EndpointStateConnected {
+			return true
+		}
+	}
+
+	return false
+}
+
+// probeAgent pings the kv service of the agent, returning whether any of the nodes responded.
+func probeAgent(agent *Agent, timeout time.Duration) bool {
+	resultCh := make(chan *PingResult, 1)
+	_, err := agent.Ping(PingOptions{
+		KVDeadline:   time.Now().Add(timeout),
+		ServiceTypes: []ServiceType{MemdService},
+	}, func(res *PingResult, err error) {
+		resultCh <- res
+	})
+	if err != nil {
+		return false
+	}
+
+	res := <-resultCh
+	if res == nil {
+		return false
+	}
+
+	for _, endpoint := range res.Services[MemdService] {
+		if endpoint.State == PingStateOK {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Active returns the agent for the cluster which operations should currently be dispatched to.
+func (agent *MultiClusterAgent) Active() *Agent {
+	return agent.agents[agent.ActiveRole()]
+}
+
+// ActiveRole returns which of the clusters is currently active.
+func (agent *MultiClusterAgent) ActiveRole() ClusterRole {
+	return ClusterRole(atomic.LoadUint32(&agent.active))
+}
+
+// Agent returns the agent for the given cluster, regardless of whether it is active.
+func (agent *MultiClusterAgent) Agent(role ClusterRole) *Agent {
+	return agent.agents[role]
+}
+
+// SwitchTo makes the given cluster active. If the primary is made active whilst it is unreachable then it will be
+// failed over again after FailoverAfter.
+func (agent *MultiClusterAgent) SwitchTo(role ClusterRole) {
+	agent.lock.Lock()
+	agent.transitionSince = time.Time{}
+	agent.lock.Unlock()
+
+	agent.switchTo(role, false)
+}
+
+func (agent *MultiClusterAgent) switchTo(role ClusterRole, automatic bool) {
+	from := ClusterRole(atomic.SwapUint32(&agent.active, uint32(role)))
+	if from == role {
+		return
+	}
+
+	agent.logCtx.logInfof("Switched active cluster from %s to %s", from, role)
+
+	if agent.switchoverCallback != nil {
+		agent.switchoverCallback(ClusterSwitchoverEvent{
+			From:      from,
+			To:        role,
+			Automatic: automatic,
+		})
+	}
+}
+
+func (agent *MultiClusterAgent) checkLoop() {
+	defer agent.wg.Done()
+
+	ticker := time.NewTicker(agent.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-agent.closeCh:
+			return
+		case now := <-ticker.C:
+			agent.check(now)
+		}
+	}
+}
+
+// check switches the active cluster if the primary has been unreachable, or reachable again, for long enough.
+func (agent *MultiClusterAgent) check(now time.Time) {
+	active := agent.ActiveRole()
+	if active == ClusterRoleStandby && agent.failbackAfter <= 0 {
+		return
+	}
+
+	reachable := agent.isReachable(ClusterRolePrimary)
+
+	// Whilst the primary is active we're waiting for it to go down, otherwise for it to come back up.
+	target := ClusterRoleStandby
+	waitFor := agent.failoverAfter
+	transitioning := !reachable
+	if active == ClusterRoleStandby {
+		target = ClusterRolePrimary
+		waitFor = agent.failbackAfter
+		transitioning = reachable
+	}
+
+	agent.lock.Lock()
+	if !transitioning {
+		agent.transitionSince = time.Time{}
+		agent.lock.Unlock()
+		return
+	}
+
+	if agent.transitionSince.IsZero() {
+		agent.transitionSince = now
+	}
+
+	if now.Sub(agent.transitionSince) < waitFor {
+		agent.lock.Unlock()
+		return
+	}
+
+	agent.lock.Unlock()
+
+	// There's no point failing over to a standby which can't serve operations either, the transition is kept so that
+	// we fail over as soon as the standby responds.
+	if target == ClusterRoleStandby && !agent.probe(ClusterRoleStandby, agent.checkInterval) {
+		agent.logCtx.logDebugf("Not failing over as the standby cluster did not respond to a ping")
+		return
+	}
+
+	agent.lock.Lock()
+	agent.transitionSince = time.Time{}
+	agent.lock.Unlock()
+
+	agent.switchTo(target, true)
+}
+
+// Close shuts down both agents.
+func (agent *MultiClusterAgent) Close() error {
+	close(agent.closeCh)
+	agent.wg.Wait()
+
+	err := agent.agents[ClusterRolePrimary].Close()
+	if standbyErr := agent.agents[ClusterRoleStandby].Close(); err == nil {
+		err = standbyErr
+	}
+
+	return err
+}
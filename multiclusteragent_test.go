@@ -0,0 +1,143 @@
+package gocbcore
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestMultiClusterAgentFailover() {
+	var events []ClusterSwitchoverEvent
+	agent := newMultiClusterAgent(&MultiClusterAgentConfig{
+		FailoverAfter: 10 * time.Second,
+		FailbackAfter: 20 * time.Second,
+		SwitchoverCallback: func(event ClusterSwitchoverEvent) {
+			events = append(events, event)
+		},
+	}, [2]*Agent{})
+
+	reachable := true
+	agent.isReachable = func(role ClusterRole) bool {
+		suite.Assert().Equal(ClusterRolePrimary, role)
+		return reachable
+	}
+	standbyResponds := false
+	agent.probe = func(role ClusterRole, timeout time.Duration) bool {
+		suite.Assert().Equal(ClusterRoleStandby, role)
+		return standbyResponds
+	}
+
+	start := time.Now()
+	at := func(secs int) time.Time {
+		return start.Add(time.Duration(secs) * time.Second)
+	}
+
+	agent.check(at(0))
+	suite.Assert().Equal(ClusterRolePrimary, agent.ActiveRole())
+
+	// A blip shorter than FailoverAfter is ignored.
+	reachable = false
+	agent.check(at(1))
+	reachable = true
+	agent.check(at(5))
+	reachable = false
+	agent.check(at(12))
+	suite.Assert().Equal(ClusterRolePrimary, agent.ActiveRole())
+
+	// We don't fail over until the standby responds.
+	agent.check(at(22))
+	suite.Assert().Equal(ClusterRolePrimary, agent.ActiveRole())
+	suite.Assert().Empty(events)
+
+	standbyResponds = true
+	agent.check(at(23))
+	suite.Assert().Equal(ClusterRoleStandby, agent.ActiveRole())
+	suite.Require().Len(events, 1)
+	suite.Assert().Equal(ClusterSwitchoverEvent{From: ClusterRolePrimary, To: ClusterRoleStandby, Automatic: true}, events[0])
+
+	reachable = true
+	agent.check(at(30))
+	agent.check(at(40))
+	suite.Assert().Equal(ClusterRoleStandby, agent.ActiveRole())
+	agent.check(at(50))
+	suite.Assert().Equal(ClusterRolePrimary, agent.ActiveRole())
+	suite.Require().Len(events, 2)
+	suite.Assert().Equal(ClusterSwitchoverEvent{From: ClusterRoleStandby, To: ClusterRolePrimary, Automatic: true}, events[1])
+
+	agent.SwitchTo(ClusterRoleStandby)
+	agent.SwitchTo(ClusterRoleStandby)
+	suite.Require().Len(events, 3)
+	suite.Assert().False(events[2].Automatic)
+}
+
+func (suite *UnitTestSuite) TestMultiClusterAgentNoFailback() {
+	agent := newMultiClusterAgent(&MultiClusterAgentConfig{}, [2]*Agent{})
+	agent.isReachable = func(role ClusterRole) bool {
+		return true
+	}
+
+	agent.SwitchTo(ClusterRoleStandby)
+	agent.check(time.Now())
+	agent.check(time.Now().Add(time.Hour))
+	suite.Assert().Equal(ClusterRoleStandby, agent.ActiveRole())
+}
+
+func (suite *UnitTestSuite) TestMultiClusterAgentMemdMock() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	primaryServer := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	standbyServer := memdmock.NewServer("127.0.0.2:11210", "default", 64)
+
+	var primaryDown uint32
+	primaryDialer := func(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time) (MemdConn, error) {
+		if atomic.LoadUint32(&primaryDown) == 1 {
+			return nil, errors.New("connection refused")
+		}
+		return memdMockDialer(primaryServer)(ctx, address, tlsConfig, deadline)
+	}
+
+	events := make(chan ClusterSwitchoverEvent, 1)
+	agent, err := CreateMultiClusterAgent(&MultiClusterAgentConfig{
+		Primary: AgentConfig{
+			MemdAddrs:  []string{primaryServer.Address()},
+			BucketName: "default",
+			Auth:       PasswordAuthProvider{},
+			MemdDialer: primaryDialer,
+		},
+		Standby: AgentConfig{
+			MemdAddrs:  []string{standbyServer.Address()},
+			BucketName: "default",
+			Auth:       PasswordAuthProvider{},
+			MemdDialer: memdMockDialer(standbyServer),
+		},
+		FailoverAfter: 50 * time.Millisecond,
+		CheckInterval: 10 * time.Millisecond,
+		SwitchoverCallback: func(event ClusterSwitchoverEvent) {
+			events <- event
+		},
+	})
+	suite.Require().Nil(err)
+	defer agent.Close()
+
+	suite.Assert().Equal(agent.Agent(ClusterRolePrimary), agent.Active())
+	suite.Require().Eventually(func() bool {
+		return agentIsReachable(agent.Agent(ClusterRolePrimary))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	atomic.StoreUint32(&primaryDown, 1)
+	primaryServer.Close()
+
+	select {
+	case event := <-events:
+		suite.Assert().Equal(ClusterRoleStandby, event.To)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("timed out waiting for failover")
+	}
+	suite.Assert().Equal(agent.Agent(ClusterRoleStandby), agent.Active())
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"strings"
@@ -56,6 +57,18 @@ func (q *N1QLRowReader) Close() error {
 	return q.streamer.Close()
 }
 
+// WriteRowsTo streams the remaining rows into w as newline delimited JSON, returning the number of rows written.
+// Rows are only read from the network as quickly as w accepts them.
+// Volatile: This API is subject to change at any time.
+func (q *N1QLRowReader) WriteRowsTo(w io.Writer) (int, error) {
+	numRows, err := q.streamer.WriteRowsTo(w)
+	if err != nil {
+		return numRows, err
+	}
+
+	return numRows, q.Err()
+}
+
 // PreparedName returns the name of the prepared statement created when using enhanced prepared statements.
 // If the prepared name has not been seen on the stream then this will return an error.
 // Volatile: This API is subject to change.
@@ -80,10 +93,22 @@ type N1QLQueryOptions struct {
 	RetryStrategy RetryStrategy
 	Deadline      time.Time
 
+	// QueryContext is the context that unqualified keyspaces in the statement are resolved against, allowing
+	// statements to target the collections of a scope. If set this takes precedence over any query_context in
+	// the payload. See N1QLQueryContext.
+	// Volatile: This API is subject to change at any time.
+	QueryContext string
+
 	// Volatile: Tracer API is subject to change.
 	TraceContext RequestSpanContext
 }
 
+// N1QLQueryContext returns the query context for the given bucket and scope.
+// Volatile: This API is subject to change at any time.
+func N1QLQueryContext(bucketName, scopeName string) string {
+	return fmt.Sprintf("default:`%s`.`%s`", bucketName, scopeName)
+}
+
 func wrapN1QLError(req *httpRequest, statement string, err error) *N1QLError {
 	if err == nil {
 		err = errors.New("query error")
@@ -116,8 +141,15 @@ type jsonN1QLErrorResponse struct {
 
 func parseN1QLErrorResp(req *httpRequest, statement string, resp *HTTPResponse) *N1QLError {
 	errorDescs, err := parseN1QLError(resp.Body)
+	if resp.StatusCode == 429 {
+		err = errRateLimitedFailure
+	}
+
 	errOut := wrapN1QLError(req, statement, err)
 	errOut.Errors = errorDescs
+	if errors.Is(err, ErrRateLimitedFailure) {
+		errOut.RetryAfter = rateLimitedRetryAfter(resp)
+	}
 	return errOut
 }
 
@@ -167,6 +199,13 @@ func parseN1QLError(data io.Reader) ([]N1QLErrorDesc, error) {
 		if errCodeGroup == 10 {
 			err = errAuthenticationFailure
 		}
+		if errCode == 1191 || errCode == 1192 || errCode == 1193 || errCode == 1194 {
+			err = errRateLimitedFailure
+		}
+		if errCode == 5000 && strings.Contains(firstErr.Message,
+			"Limit for number of indexes that can be created per scope has been reached") {
+			err = errQuotaLimitedFailure
+		}
 	}
 
 	return errorDescs, err
@@ -181,6 +220,8 @@ type n1qlQueryComponent struct {
 	cacheLock  sync.RWMutex
 
 	enhancedPreparedSupported uint32
+	// collectionsSupported is a BucketCapabilityStatus, it remains unknown when we only have cluster level configs.
+	collectionsSupported uint32
 }
 
 type n1qlQueryCacheEntry struct {
@@ -215,6 +256,48 @@ func (nqc *n1qlQueryComponent) OnNewRouteConfig(cfg *routeConfig) {
 		nqc.cacheLock.Unlock()
 		atomic.StoreUint32(&nqc.enhancedPreparedSupported, 1)
 	}
+
+	if !cfg.IsGCCCPConfig() {
+		status := BucketCapabilityStatusUnsupported
+		if cfg.ContainsBucketCapability("collections") {
+			status = BucketCapabilityStatusSupported
+		}
+		atomic.StoreUint32(&nqc.collectionsSupported, uint32(status))
+	}
+}
+
+// applyQueryContext sets the query context from the options onto the payload and validates whichever query context
+// the payload ends up with against what we know of the cluster.
+func (nqc *n1qlQueryComponent) applyQueryContext(opts N1QLQueryOptions, payloadMap map[string]interface{}) (string, error) {
+	if opts.QueryContext != "" {
+		payloadMap["query_context"] = opts.QueryContext
+	}
+
+	val, ok := payloadMap["query_context"]
+	if !ok {
+		return "", nil
+	}
+
+	queryContext, ok := val.(string)
+	if !ok || queryContext == "" {
+		return "", wrapError(errInvalidArgument, "query_context must be a non-empty string")
+	}
+
+	if BucketCapabilityStatus(atomic.LoadUint32(&nqc.collectionsSupported)) == BucketCapabilityStatusUnsupported {
+		return "", wrapError(errCollectionsUnsupported, "query_context cannot be used as the bucket does not support collections")
+	}
+
+	return queryContext, nil
+}
+
+// queryCacheKey returns the key for a prepared statement, the same statement refers to different keyspaces
+// depending on its query context.
+func queryCacheKey(statement, queryContext string) string {
+	if queryContext == "" {
+		return statement
+	}
+
+	return queryContext + " " + statement
 }
 
 // N1QLQuery executes a N1QL query
@@ -232,6 +315,11 @@ func (nqc *n1qlQueryComponent) N1QLQuery(opts N1QLQueryOptions, cb N1QLQueryCall
 	clientContextID := getMapValueString(payloadMap, "client_context_id", "")
 	readOnly := getMapValueBool(payloadMap, "readonly", false)
 
+	_, err = nqc.applyQueryContext(opts, payloadMap)
+	if err != nil {
+		return nil, wrapN1QLError(nil, statement, err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	ireq := &httpRequest{
 		Service:          N1qlService,
@@ -283,8 +371,14 @@ func (nqc *n1qlQueryComponent) executeEnhPrepared(opts N1QLQueryOptions, tracer
 	clientContextID := getMapValueString(payloadMap, "client_context_id", "")
 	readOnly := getMapValueBool(payloadMap, "readonly", false)
 
+	queryContext, err := nqc.applyQueryContext(opts, payloadMap)
+	if err != nil {
+		return nil, wrapN1QLError(nil, statement, err)
+	}
+	cacheKey := queryCacheKey(statement, queryContext)
+
 	nqc.cacheLock.RLock()
-	cachedStmt := nqc.queryCache[statement]
+	cachedStmt := nqc.queryCache[cacheKey]
 	nqc.cacheLock.RUnlock()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -357,7 +451,7 @@ func (nqc *n1qlQueryComponent) executeEnhPrepared(opts N1QLQueryOptions, tracer
 		cachedStmt.enhanced = true
 
 		nqc.cacheLock.Lock()
-		nqc.queryCache[statement] = cachedStmt
+		nqc.queryCache[cacheKey] = cachedStmt
 		nqc.cacheLock.Unlock()
 
 		cb(results, nil)
@@ -377,8 +471,14 @@ func (nqc *n1qlQueryComponent) executeOldPrepared(opts N1QLQueryOptions, tracer
 	clientContextID := getMapValueString(payloadMap, "client_context_id", "")
 	readOnly := getMapValueBool(payloadMap, "readonly", false)
 
+	queryContext, err := nqc.applyQueryContext(opts, payloadMap)
+	if err != nil {
+		return nil, wrapN1QLError(nil, statement, err)
+	}
+	cacheKey := queryCacheKey(statement, queryContext)
+
 	nqc.cacheLock.RLock()
-	cachedStmt := nqc.queryCache[statement]
+	cachedStmt := nqc.queryCache[cacheKey]
 	nqc.cacheLock.RUnlock()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -462,7 +562,7 @@ func (nqc *n1qlQueryComponent) executeOldPrepared(opts N1QLQueryOptions, tracer
 		cachedStmt.encodedPlan = prepData.EncodedPlan
 
 		nqc.cacheLock.Lock()
-		nqc.queryCache[statement] = cachedStmt
+		nqc.queryCache[cacheKey] = cachedStmt
 		nqc.cacheLock.Unlock()
 
 		// Attempt to execute our cached query plan
@@ -522,13 +622,18 @@ ExecuteLoop:
 					retryReason = QueryIndexNotFoundRetryReason
 				}
 			}
+			if errors.Is(n1qlErr.InnerError, ErrRateLimitedFailure) {
+				retryReason = RateLimitedRetryReason
+			}
 
 			if retryReason == nil {
 				// n1qlErr is already wrapped here
 				return nil, n1qlErr
 			}
 
+			ireq.retryAfter = n1qlErr.RetryAfter
 			shouldRetry, retryTime := retryOrchMaybeRetry(ireq, retryReason)
+			ireq.retryAfter = 0
 			if !shouldRetry {
 				// n1qlErr is already wrapped here
 				return nil, n1qlErr
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 )
@@ -604,3 +606,172 @@ func (suite *UnitTestSuite) TestN1QLRowStreamerErrorsAndResults() {
 	suite.Assert().Equal(uint32(12009), firstErr.Code)
 	suite.Assert().NotEmpty(firstErr.Message)
 }
+
+func (suite *UnitTestSuite) TestN1QLRateLimitedError() {
+	resp := &HTTPResponse{
+		StatusCode: 429,
+		Header:     http.Header{"Retry-After": []string{"3"}},
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"errors":[{"code":1191,"msg":"User has more requests running than allowed"}]}`)),
+	}
+	nErr := parseN1QLErrorResp(&httpRequest{}, "SELECT 1", resp)
+	suite.Assert().True(errors.Is(nErr, ErrRateLimitedFailure))
+	suite.Assert().Equal(3*time.Second, nErr.RetryAfter)
+
+	// The rate limit codes are recognised regardless of the status, without a header the default delay is used.
+	resp = &HTTPResponse{
+		StatusCode: 500,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"errors":[{"code":1192,"msg":"User has exceeded request rate limit"}]}`)),
+	}
+	nErr = parseN1QLErrorResp(&httpRequest{}, "SELECT 1", resp)
+	suite.Assert().True(errors.Is(nErr, ErrRateLimitedFailure))
+	suite.Assert().Equal(defaultRateLimitedRetryAfter, nErr.RetryAfter)
+
+	resp = &HTTPResponse{
+		StatusCode: 500,
+		Body: ioutil.NopCloser(bytes.NewBufferString(
+			`{"errors":[{"code":5000,"msg":"Limit for number of indexes that can be created per scope has been reached"}]}`)),
+	}
+	nErr = parseN1QLErrorResp(&httpRequest{}, "CREATE INDEX", resp)
+	suite.Assert().True(errors.Is(nErr, ErrQuotaLimitedFailure))
+	suite.Assert().Zero(nErr.RetryAfter)
+}
+
+func (suite *UnitTestSuite) TestN1QLQueryContext() {
+	var lock sync.Mutex
+	var payloads []map[string]interface{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		suite.Assert().Nil(json.NewDecoder(r.Body).Decode(&payload))
+
+		lock.Lock()
+		payloads = append(payloads, payload)
+		lock.Unlock()
+
+		if _, ok := payload["prepared"]; ok {
+			_, _ = w.Write([]byte(`{"results":[],"status":"success"}`))
+			return
+		}
+
+		name := fmt.Sprintf("%v", payload["query_context"])
+		_, _ = w.Write([]byte(`{"prepared":"` + name + `","results":[],"status":"success"}`))
+	}))
+	defer srv.Close()
+
+	cfg := &routeConfig{
+		revID:                  1,
+		bktType:                bktTypeCouchbase,
+		n1qlEpList:             []string{srv.URL},
+		bucketCapabilities:     []string{"collections"},
+		clusterCapabilitiesVer: []int{1, 0},
+		clusterCapabilities:    map[string][]string{"n1ql": {"enhancedPreparedStatements"}},
+	}
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(cfg)
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	httpCpt := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, tracer)
+	n1qlCpt := newN1QLQueryComponent(httpCpt, &configManagementComponent{}, tracer)
+	n1qlCpt.OnNewRouteConfig(cfg)
+
+	query := func(prepared bool, queryContext string) error {
+		opts := N1QLQueryOptions{
+			Payload:      []byte(`{"statement":"SELECT * FROM coll"}`),
+			Deadline:     time.Now().Add(5 * time.Second),
+			QueryContext: queryContext,
+		}
+		errCh := make(chan error, 1)
+		cb := func(reader *N1QLRowReader, err error) {
+			if err == nil {
+				for reader.NextRow() != nil {
+				}
+				err = reader.Err()
+			}
+			errCh <- err
+		}
+
+		var err error
+		if prepared {
+			_, err = n1qlCpt.PreparedN1QLQuery(opts, cb)
+		} else {
+			_, err = n1qlCpt.N1QLQuery(opts, cb)
+		}
+		if err != nil {
+			return err
+		}
+		return <-errCh
+	}
+
+	ctxA := N1QLQueryContext("default", "a")
+	ctxB := N1QLQueryContext("default", "b")
+	suite.Assert().Equal("default:`default`.`a`", ctxA)
+
+	suite.Require().Nil(query(false, ctxA))
+	// The same statement in different contexts must be prepared separately.
+	suite.Require().Nil(query(true, ctxA))
+	suite.Require().Nil(query(true, ctxB))
+	suite.Require().Nil(query(true, ctxB))
+
+	lock.Lock()
+	suite.Require().Len(payloads, 4)
+	suite.Assert().Equal(ctxA, payloads[0]["query_context"])
+	suite.Assert().Equal("SELECT * FROM coll", payloads[0]["statement"])
+	suite.Assert().Equal(ctxA, payloads[1]["query_context"])
+	suite.Assert().Equal("PREPARE SELECT * FROM coll", payloads[1]["statement"])
+	suite.Assert().Equal(ctxB, payloads[2]["query_context"])
+	suite.Assert().Equal("PREPARE SELECT * FROM coll", payloads[2]["statement"])
+	suite.Assert().Equal(ctxB, payloads[3]["query_context"])
+	suite.Assert().Equal(ctxB, payloads[3]["prepared"])
+	lock.Unlock()
+
+	_, err := n1qlCpt.N1QLQuery(N1QLQueryOptions{
+		Payload: []byte(`{"statement":"SELECT 1","query_context":5}`),
+	}, func(*N1QLRowReader, error) {})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	cfg.bucketCapabilities = nil
+	n1qlCpt.OnNewRouteConfig(cfg)
+	err = query(false, ctxA)
+	suite.Assert().True(errors.Is(err, ErrCollectionsUnsupported))
+	suite.Assert().Nil(query(false, ""))
+}
+
+func (suite *UnitTestSuite) TestN1QLRowReaderWriteRowsTo() {
+	body := `{"requestID":"1","results":[{"a":1},{"a":2}],"status":"success","metrics":{"resultCount":2}}`
+	streamer, err := newQueryStreamer(ioutil.NopCloser(bytes.NewBufferString(body)), "results")
+	suite.Require().Nil(err)
+	reader := &N1QLRowReader{streamer: streamer}
+
+	var buf bytes.Buffer
+	numRows, err := reader.WriteRowsTo(&buf)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(2, numRows)
+	suite.Assert().Equal("{\"a\":1}\n{\"a\":2}\n", buf.String())
+
+	meta, err := reader.MetaData()
+	suite.Require().Nil(err)
+	suite.Assert().Contains(string(meta), `"resultCount":2`)
+
+	// A failing writer stops the stream and is reported.
+	streamer, err = newQueryStreamer(ioutil.NopCloser(bytes.NewBufferString(body)), "results")
+	suite.Require().Nil(err)
+	reader = &N1QLRowReader{streamer: streamer}
+	numRows, err = reader.WriteRowsTo(&testFailingWriter{failAfter: 1})
+	suite.Assert().Equal(1, numRows)
+	suite.Assert().True(errors.Is(err, errTestWriteFailed))
+	suite.Assert().True(errors.Is(reader.Err(), errTestWriteFailed))
+}
+
+var errTestWriteFailed = errors.New("write failed")
+
+type testFailingWriter struct {
+	failAfter int
+	writes    int
+}
+
+func (w *testFailingWriter) Write(p []byte) (int, error) {
+	if w.writes >= w.failAfter {
+		return 0, errTestWriteFailed
+	}
+	w.writes++
+	return len(p), nil
+}
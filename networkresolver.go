@@ -0,0 +1,40 @@
+package gocbcore
+
+// NodeNetworkInfo describes a node in a cluster config for the purpose of choosing which of its addresses to use.
+type NodeNetworkInfo struct {
+	// Hostname is the address of the node on the default network.
+	Hostname string
+
+	// AlternateAddresses maps the name of each alternate network the node is reachable on to its address there.
+	AlternateAddresses map[string]string
+
+	// NetworkType is the network type which has been configured or detected for the agent.
+	NetworkType string
+}
+
+// NetworkResolver chooses the network type, "default" or the name of an alternate network, used to connect to a
+// node. Returning an empty string uses the agent's configured or detected network type.
+// Volatile: This API is subject to change at any time.
+type NetworkResolver func(node NodeNetworkInfo) string
+
+func (node *cfgNodeExt) resolveNetworkType(networkType string, resolver NetworkResolver) string {
+	if resolver == nil {
+		return networkType
+	}
+
+	altAddresses := make(map[string]string, len(node.AltAddresses))
+	for name, altAddr := range node.AltAddresses {
+		altAddresses[name] = altAddr.Hostname
+	}
+
+	resolved := resolver(NodeNetworkInfo{
+		Hostname:           node.Hostname,
+		AlternateAddresses: altAddresses,
+		NetworkType:        networkType,
+	})
+	if resolved == "" {
+		return networkType
+	}
+
+	return resolved
+}
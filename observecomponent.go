@@ -2,7 +2,6 @@ package gocbcore
 
 import (
 	"encoding/binary"
-	"time"
 
 	"github.com/couchbase/gocbcore/v9/memd"
 )
@@ -17,15 +16,20 @@ type observeComponent struct {
 	defaultRetryStrategy RetryStrategy
 	tracer               *tracerComponent
 	bucketUtils          bucketUtilsProvider
+	timeouts             *kvTimeoutComponent
+	recreation           *bucketRecreationComponent
 }
 
 func newObserveComponent(cidMgr *collectionsComponent, defaultRetryStrategy RetryStrategy, tracerCmpt *tracerComponent,
-	bucketUtils bucketUtilsProvider) *observeComponent {
+	bucketUtils bucketUtilsProvider, timeouts *kvTimeoutComponent,
+	recreation *bucketRecreationComponent) *observeComponent {
 	return &observeComponent{
 		cidMgr:               cidMgr,
 		defaultRetryStrategy: defaultRetryStrategy,
 		tracer:               tracerCmpt,
 		bucketUtils:          bucketUtils,
+		timeouts:             timeouts,
+		recreation:           recreation,
 	}
 }
 
@@ -107,6 +111,7 @@ func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (Pe
 		CollectionName:   opts.CollectionName,
 		ScopeName:        opts.ScopeName,
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         oc.timeouts.Deadline(opts.Deadline),
 	}
 
 	op, err := oc.cidMgr.Dispatch(req)
@@ -114,24 +119,7 @@ func (oc *observeComponent) Observe(opts ObserveOptions, cb ObserveCallback) (Pe
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errUnambiguousTimeout,
-				OperationID:        "Unlock",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	oc.timeouts.Track(req, req.Deadline, "Observe", errUnambiguousTimeout)
 
 	return op, nil
 }
@@ -144,7 +132,9 @@ func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallbac
 		return nil, errFeatureNotAvailable
 	}
 
-	handler := func(resp *memdQResponse, _ *memdQRequest, err error) {
+	handler := func(resp *memdQResponse, req *memdQRequest, err error) {
+		oc.recreation.Untrack(req)
+
 		if err != nil {
 			tracer.Finish()
 			cb(nil, err)
@@ -242,31 +232,20 @@ func (oc *observeComponent) ObserveVb(opts ObserveVbOptions, cb ObserveVbCallbac
 		Callback:         handler,
 		RootTraceContext: tracer.RootContext(),
 		RetryStrategy:    opts.RetryStrategy,
+		Deadline:         oc.timeouts.Deadline(opts.Deadline),
 	}
 
+	// The vbucket uuid is meaningless if the bucket is recreated, so the request is failed rather than letting the
+	// server compare it against the new bucket's history.
+	oc.recreation.Track(req)
+
 	op, err := oc.cidMgr.Dispatch(req)
 	if err != nil {
+		oc.recreation.Untrack(req)
 		return nil, err
 	}
 
-	if !opts.Deadline.IsZero() {
-		start := time.Now()
-		req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-			connInfo := req.ConnectionInfo()
-			count, reasons := req.Retries()
-			req.cancelWithCallback(&TimeoutError{
-				InnerError:         errUnambiguousTimeout,
-				OperationID:        "Unlock",
-				Opaque:             req.Identifier(),
-				TimeObserved:       time.Since(start),
-				RetryReasons:       reasons,
-				RetryAttempts:      count,
-				LastDispatchedTo:   connInfo.lastDispatchedTo,
-				LastDispatchedFrom: connInfo.lastDispatchedFrom,
-				LastConnectionID:   connInfo.lastConnectionID,
-			})
-		}))
-	}
+	oc.timeouts.Track(req, req.Deadline, "ObserveVb", errUnambiguousTimeout)
 
 	return op, nil
 }
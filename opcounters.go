@@ -0,0 +1,195 @@
+package gocbcore
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// OperationCounts describes how many operations reached each stage of their lifecycle.
+// Volatile: This API is subject to change at any time.
+type OperationCounts struct {
+	// Dispatched is the number of operations which were sent, an operation is only counted once however many times
+	// it is retried.
+	Dispatched uint64
+
+	// Completed is the number of operations which finished with a result or an error, other than a timeout or a
+	// cancellation.
+	Completed uint64
+
+	// Retried is the number of times that operations were sent again.
+	Retried uint64
+
+	Cancelled uint64
+	TimedOut  uint64
+
+	// Orphaned is the number of responses received for operations which had already finished, such as those which
+	// timed out whilst waiting for the response.
+	Orphaned uint64
+}
+
+// OperationStats describes the operations performed by an agent.
+// Volatile: This API is subject to change at any time.
+type OperationStats struct {
+	// Services holds the counts for each service which has been used, the counts for MemdService are the totals of
+	// the counts in KV.
+	Services map[ServiceType]OperationCounts
+
+	// KV holds the counts for each kv opcode which has been used.
+	KV map[memd.CmdCode]OperationCounts
+}
+
+type operationCounterSet struct {
+	dispatched uint64
+	completed  uint64
+	retried    uint64
+	cancelled  uint64
+	timedOut   uint64
+	orphaned   uint64
+}
+
+func (c *operationCounterSet) recordOutcome(err error) {
+	switch {
+	case err == nil:
+		atomic.AddUint64(&c.completed, 1)
+	case errors.Is(err, ErrTimeout):
+		atomic.AddUint64(&c.timedOut, 1)
+	case errors.Is(err, ErrRequestCanceled):
+		atomic.AddUint64(&c.cancelled, 1)
+	default:
+		atomic.AddUint64(&c.completed, 1)
+	}
+}
+
+func (c *operationCounterSet) Counts() OperationCounts {
+	return OperationCounts{
+		Dispatched: atomic.LoadUint64(&c.dispatched),
+		Completed:  atomic.LoadUint64(&c.completed),
+		Retried:    atomic.LoadUint64(&c.retried),
+		Cancelled:  atomic.LoadUint64(&c.cancelled),
+		TimedOut:   atomic.LoadUint64(&c.timedOut),
+		Orphaned:   atomic.LoadUint64(&c.orphaned),
+	}
+}
+
+func (counts *OperationCounts) add(other OperationCounts) {
+	counts.Dispatched += other.Dispatched
+	counts.Completed += other.Completed
+	counts.Retried += other.Retried
+	counts.Cancelled += other.Cancelled
+	counts.TimedOut += other.TimedOut
+	counts.Orphaned += other.Orphaned
+}
+
+// maxCounterServiceType bounds the service types which are counted, it must be larger than every ServiceType.
+const maxCounterServiceType = 16
+
+// operationCounters counts the operations sent by an agent's kv connections and http requests. The counters are
+// fixed size so that recording never needs to take a lock. All methods are safe to call on a nil receiver.
+type operationCounters struct {
+	kv       [256]operationCounterSet
+	services [maxCounterServiceType]operationCounterSet
+}
+
+func (oc *operationCounters) kvCounters(cmd memd.CmdCode) *operationCounterSet {
+	if oc == nil {
+		return nil
+	}
+
+	return &oc.kv[uint8(cmd)]
+}
+
+func (oc *operationCounters) serviceCounters(service ServiceType) *operationCounterSet {
+	if oc == nil || service < 0 || int(service) >= maxCounterServiceType || service == MemdService {
+		return nil
+	}
+
+	return &oc.services[service]
+}
+
+func (oc *operationCounters) RecordKvDispatched(cmd memd.CmdCode) {
+	if c := oc.kvCounters(cmd); c != nil {
+		atomic.AddUint64(&c.dispatched, 1)
+	}
+}
+
+func (oc *operationCounters) RecordKvRetried(cmd memd.CmdCode) {
+	if c := oc.kvCounters(cmd); c != nil {
+		atomic.AddUint64(&c.retried, 1)
+	}
+}
+
+func (oc *operationCounters) RecordKvOutcome(cmd memd.CmdCode, err error) {
+	if c := oc.kvCounters(cmd); c != nil {
+		c.recordOutcome(err)
+	}
+}
+
+func (oc *operationCounters) RecordKvOrphaned(cmd memd.CmdCode) {
+	if c := oc.kvCounters(cmd); c != nil {
+		atomic.AddUint64(&c.orphaned, 1)
+	}
+}
+
+func (oc *operationCounters) RecordHTTPDispatched(service ServiceType) {
+	if c := oc.serviceCounters(service); c != nil {
+		atomic.AddUint64(&c.dispatched, 1)
+	}
+}
+
+func (oc *operationCounters) RecordHTTPRetried(service ServiceType) {
+	if c := oc.serviceCounters(service); c != nil {
+		atomic.AddUint64(&c.retried, 1)
+	}
+}
+
+func (oc *operationCounters) RecordHTTPOutcome(service ServiceType, err error) {
+	if c := oc.serviceCounters(service); c != nil {
+		c.recordOutcome(err)
+	}
+}
+
+// Stats returns a snapshot of the counters, leaving out any opcode or service which has not been used.
+func (oc *operationCounters) Stats() OperationStats {
+	stats := OperationStats{
+		Services: make(map[ServiceType]OperationCounts),
+		KV:       make(map[memd.CmdCode]OperationCounts),
+	}
+	if oc == nil {
+		return stats
+	}
+
+	var kvTotals OperationCounts
+	for i := range oc.kv {
+		counts := oc.kv[i].Counts()
+		if counts == (OperationCounts{}) {
+			continue
+		}
+
+		stats.KV[memd.CmdCode(i)] = counts
+		kvTotals.add(counts)
+	}
+	if kvTotals != (OperationCounts{}) {
+		stats.Services[MemdService] = kvTotals
+	}
+
+	for i := range oc.services {
+		counts := oc.services[i].Counts()
+		if counts == (OperationCounts{}) {
+			continue
+		}
+
+		stats.Services[ServiceType(i)] = counts
+	}
+
+	return stats
+}
+
+// OperationStats returns how many operations this agent has dispatched, completed, retried, cancelled and timed
+// out, and how many responses arrived after their operation had finished, broken down by kv opcode and by service.
+// These are counted from when the agent was created and are intended to be exported to a monitoring system.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) OperationStats() OperationStats {
+	return agent.opCounters.Stats()
+}
@@ -0,0 +1,127 @@
+package gocbcore
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestOperationStats() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	releaseSlowCh := make(chan struct{})
+	var defaultGet memdmock.HandlerFunc
+	defaultGet = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		switch string(req.Key) {
+		case "hang":
+			return nil
+		case "slow":
+			<-releaseSlowCh
+		}
+		return defaultGet(req)
+	})
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	suite.mustSet(agent, "key")
+
+	getCh := make(chan error, 1)
+	getCb := func(res *GetResult, err error) {
+		getCh <- err
+	}
+	_, err := agent.Get(GetOptions{Key: []byte("missing"), Deadline: time.Now().Add(5 * time.Second)}, getCb)
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(<-getCh, ErrDocumentNotFound))
+
+	op, err := agent.Get(GetOptions{Key: []byte("hang"), Deadline: time.Now().Add(5 * time.Second)}, getCb)
+	suite.Require().Nil(err)
+	op.Cancel()
+	suite.Assert().True(errors.Is(<-getCh, ErrRequestCanceled))
+
+	// The response to this arrives after the operation has timed out.
+	_, err = agent.Get(GetOptions{Key: []byte("slow"), Deadline: time.Now().Add(50 * time.Millisecond)}, getCb)
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(<-getCh, ErrTimeout))
+	close(releaseSlowCh)
+
+	var stats OperationStats
+	for i := 0; i < 100; i++ {
+		stats = agent.OperationStats()
+		if stats.KV[memd.CmdGet].Orphaned > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	setCounts := stats.KV[memd.CmdSet]
+	suite.Assert().Equal(uint64(1), setCounts.Dispatched)
+	suite.Assert().Equal(uint64(1), setCounts.Completed)
+
+	getCounts := stats.KV[memd.CmdGet]
+	suite.Assert().Equal(uint64(3), getCounts.Dispatched)
+	suite.Assert().Equal(uint64(1), getCounts.Completed)
+	suite.Assert().Equal(uint64(1), getCounts.Cancelled)
+	suite.Assert().Equal(uint64(1), getCounts.TimedOut)
+	suite.Assert().Equal(uint64(1), getCounts.Orphaned)
+
+	kvTotals := stats.Services[MemdService]
+	suite.Assert().GreaterOrEqual(kvTotals.Dispatched, uint64(4))
+	suite.Assert().GreaterOrEqual(kvTotals.Completed, uint64(2))
+}
+
+func (suite *UnitTestSuite) TestOperationStatsHTTP() {
+	var hits uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Make the first request retry.
+		if atomic.AddUint32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	counters := &operationCounters{}
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, n1qlEpList: []string{srv.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	hc := newHTTPComponent(httpComponentProps{OpCounters: counters}, &http.Client{}, mux, PasswordAuthProvider{},
+		tracer)
+
+	resp, err := hc.DoInternalHTTPRequest(&httpRequest{
+		Service:       N1qlService,
+		Method:        "GET",
+		Path:          "/query",
+		IsIdempotent:  true,
+		Deadline:      time.Now().Add(5 * time.Second),
+		RetryStrategy: &testHTTPRecordingRetryStrategy{},
+	}, false)
+	suite.Require().Nil(err)
+	suite.Require().Nil(resp.Body.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = hc.DoInternalHTTPRequest(&httpRequest{
+		Service:       N1qlService,
+		Method:        "GET",
+		Path:          "/query",
+		Deadline:      time.Now().Add(5 * time.Second),
+		Context:       ctx,
+		RetryStrategy: &testHTTPRecordingRetryStrategy{},
+	}, false)
+	suite.Assert().True(errors.Is(err, ErrRequestCanceled))
+
+	stats := counters.Stats()
+	suite.Assert().Equal(OperationCounts{Dispatched: 2, Completed: 1, Retried: 1, Cancelled: 1},
+		stats.Services[N1qlService])
+	suite.Assert().NotContains(stats.Services, MemdService)
+	suite.Assert().Empty(stats.KV)
+}
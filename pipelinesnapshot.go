@@ -10,6 +10,18 @@ func (pi pipelineSnapshot) RevID() int64 {
 	return pi.state.revID
 }
 
+func (pi pipelineSnapshot) BucketType() bucketType {
+	return pi.state.BucketType()
+}
+
+func (pi pipelineSnapshot) ClusterUUID() string {
+	return pi.state.clusterUUID
+}
+
+func (pi pipelineSnapshot) ClusterName() string {
+	return pi.state.clusterName
+}
+
 func (pi pipelineSnapshot) NumPipelines() int {
 	return pi.state.NumPipelines()
 }
@@ -12,9 +12,14 @@ type pollerController struct {
 	stopped          bool
 	bucketConfigSeen uint32
 
+	// gcccpUnavailable is set once cluster level config polling has failed and the http poller has taken over.
+	gcccpUnavailable bool
+
 	cccpPoller *cccpConfigController
 	httpPoller *httpConfigController
 	cfgMgr     configManager
+
+	logCtx logContext
 }
 
 type configPollerController interface {
@@ -25,11 +30,13 @@ type configPollerController interface {
 	Error() error
 }
 
-func newPollerController(cccpPoller *cccpConfigController, httpPoller *httpConfigController, cfgMgr configManager) *pollerController {
+func newPollerController(cccpPoller *cccpConfigController, httpPoller *httpConfigController, cfgMgr configManager,
+	logCtx logContext) *pollerController {
 	pc := &pollerController{
 		cccpPoller: cccpPoller,
 		httpPoller: httpPoller,
 		cfgMgr:     cfgMgr,
+		logCtx:     logCtx,
 	}
 	cfgMgr.AddConfigWatcher(pc)
 
@@ -55,24 +62,31 @@ func (pc *pollerController) OnNewRouteConfig(cfg *routeConfig) {
 			return
 		}
 		if pc.activeController == pc.httpPoller {
-			logInfof("Found couchbase bucket and HTTP poller in use. Resetting pollers to start cccp.")
-			pc.activeController = nil
-			pc.controllerLock.Unlock()
-
-			pc.httpPoller.Stop()
-			pollerCh := pc.httpPoller.Done()
-			if pollerCh != nil {
-				<-pollerCh
-			}
-			pc.httpPoller.Reset()
-			pc.cccpPoller.Reset()
-			pc.Start()
+			pc.logCtx.logInfof("Found couchbase bucket and HTTP poller in use. Resetting pollers to start cccp.")
+			pc.restartCCCPLocked()
 		} else {
 			pc.controllerLock.Unlock()
 		}
 	}()
 }
 
+// restartCCCPLocked stops the http poller and starts the cccp poller again. It must be called with the controller lock
+// held whilst the http poller is active, and releases the lock.
+func (pc *pollerController) restartCCCPLocked() {
+	pc.activeController = nil
+	pc.gcccpUnavailable = false
+	pc.controllerLock.Unlock()
+
+	pc.httpPoller.Stop()
+	pollerCh := pc.httpPoller.Done()
+	if pollerCh != nil {
+		<-pollerCh
+	}
+	pc.httpPoller.Reset()
+	pc.cccpPoller.Reset()
+	pc.Start()
+}
+
 func (pc *pollerController) Start() {
 	pc.controllerLock.Lock()
 	if pc.stopped {
@@ -92,7 +106,7 @@ func (pc *pollerController) Start() {
 	err := pc.cccpPoller.DoLoop()
 	if err != nil {
 		if pc.httpPoller == nil {
-			logErrorf("CCCP poller has exited for http fallback but no http poller is configured")
+			pc.logCtx.logErrorf("CCCP poller has exited for http fallback but no http poller is configured")
 			return
 		}
 		if isPollingFallbackError(err) {
@@ -103,6 +117,10 @@ func (pc *pollerController) Start() {
 				pc.activeController = nil
 				pc.controllerLock.Unlock()
 			} else {
+				if errors.Is(err, errGCCCPUnavailable) {
+					pc.logCtx.logWarnf("Cluster level config polling failed, falling back to HTTP polling: %v", err)
+					pc.gcccpUnavailable = true
+				}
 				pc.activeController = pc.httpPoller
 				pc.controllerLock.Unlock()
 				pc.httpPoller.DoLoop()
@@ -143,6 +161,56 @@ func (pc *pollerController) Done() chan struct{} {
 	return controller.Done()
 }
 
+// SetBucketName sets the bucket which the pollers fetch config for, it is only supported whilst the cccp poller is
+// active as the http poller has no way to switch bucket without being restarted. The exception is when the http poller
+// took over because cluster level config polling failed, selecting a bucket restarts the cccp poller as bucket level
+// cccp is supported by every server version.
+func (pc *pollerController) SetBucketName(bucketName string) error {
+	pc.controllerLock.Lock()
+
+	if pc.stopped {
+		pc.controllerLock.Unlock()
+		return errShutdown
+	}
+
+	if pc.gcccpUnavailable && bucketName != "" && pc.cccpPoller != nil && pc.activeController == pc.httpPoller {
+		pc.logCtx.logInfof("Bucket selected whilst HTTP poller in use for cluster config. Resetting pollers to start cccp.")
+		pc.httpPoller.SetBucketName(bucketName)
+		pc.controllerLock.Unlock()
+
+		go func() {
+			pc.controllerLock.Lock()
+			if pc.stopped || pc.activeController != pc.httpPoller {
+				pc.controllerLock.Unlock()
+				return
+			}
+			pc.restartCCCPLocked()
+		}()
+		return nil
+	}
+	defer pc.controllerLock.Unlock()
+
+	// Until the controller has started there's no active controller, but cccp is always the one that it starts with.
+	if pc.cccpPoller == nil || (pc.activeController != nil && pc.activeController != pc.cccpPoller) {
+		return wrapError(errUnsupportedOperation, "bucket selection requires the cccp config poller to be in use")
+	}
+
+	if pc.httpPoller != nil {
+		pc.httpPoller.SetBucketName(bucketName)
+	}
+
+	return nil
+}
+
+// UsingGCCCP returns whether cluster level config is being fetched using cccp, false is returned once the http poller
+// has taken over because cluster level cccp polling failed.
+func (pc *pollerController) UsingGCCCP() bool {
+	pc.controllerLock.Lock()
+	defer pc.controllerLock.Unlock()
+
+	return !pc.gcccpUnavailable
+}
+
 type pollerErrorProvider interface {
 	PollerError() error
 }
@@ -163,7 +231,7 @@ func (pc *pollerController) PollerError() error {
 func (pc *pollerController) ForceHTTPPoller() {
 	go func() {
 		if atomic.LoadUint32(&pc.bucketConfigSeen) == 1 {
-			logInfof("Config already seen, not forcing HTTP")
+			pc.logCtx.logInfof("Config already seen, not forcing HTTP")
 			// If we've seen a config already then either cccp or http polling have managed to fetch a config and
 			// bucket type can't have changed so there's no reason to fallback.
 			return
@@ -179,7 +247,7 @@ func (pc *pollerController) ForceHTTPPoller() {
 			return
 		}
 		if pc.activeController == pc.cccpPoller {
-			logInfof("Stopping CCCP poller for HTTP polling takeover")
+			pc.logCtx.logInfof("Stopping CCCP poller for HTTP polling takeover")
 			pc.cccpPoller.Stop()
 			pollerCh := pc.cccpPoller.Done()
 			if pollerCh != nil {
@@ -189,7 +257,7 @@ func (pc *pollerController) ForceHTTPPoller() {
 			pc.cccpPoller.Reset()
 			if atomic.LoadUint32(&pc.bucketConfigSeen) == 1 {
 				pc.controllerLock.Unlock()
-				logInfof("Config seen whilst waiting for CCCP poller to stop, restarting CCCP poller.")
+				pc.logCtx.logInfof("Config seen whilst waiting for CCCP poller to stop, restarting CCCP poller.")
 				// CCCP managed to fetch a config whilst we were waiting for shutdown, in this case we want to just
 				// start CCCP again as the bucket must exist and be a couchbase bucket.
 				pc.Start()
@@ -209,5 +277,5 @@ func (pc *pollerController) ForceHTTPPoller() {
 
 func isPollingFallbackError(err error) bool {
 	return errors.Is(err, ErrDocumentNotFound) || errors.Is(err, ErrUnsupportedOperation) ||
-		errors.Is(err, errNoCCCPHosts) || errors.Is(err, ErrBucketNotFound)
+		errors.Is(err, errNoCCCPHosts) || errors.Is(err, ErrBucketNotFound) || errors.Is(err, errGCCCPUnavailable)
 }
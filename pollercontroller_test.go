@@ -1,8 +1,10 @@
 package gocbcore
 
 import (
-	"github.com/couchbase/gocbcore/v9/memd"
+	"errors"
 	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
 )
 
 // This test tests that after calling stop then force http poller will not attempt to do work.
@@ -16,7 +18,7 @@ func (suite *UnitTestSuite) TestPollerControllerForceHTTPAndStopRace() {
 		looperDoneSig: make(chan struct{}),
 	}
 
-	poller := newPollerController(ccp, htt, &configManagementComponent{})
+	poller := newPollerController(ccp, htt, &configManagementComponent{}, nil)
 	poller.activeController = ccp
 
 	poller.Stop()
@@ -33,7 +35,7 @@ func (suite *UnitTestSuite) TestPollerControllerForceHTTPAndNewConfig() {
 	config, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
 	suite.Require().Nil(err)
 
-	pipeline := newPipeline("127.0.0.1:11210", 1, 10, nil)
+	pipeline := newPipeline("127.0.0.1:11210", 1, 10, ReconnectBackoffConfig{}, 0, nil, nil)
 	muxer := new(mockDispatcher)
 	muxer.On("PipelineSnapshot").Return(&pipelineSnapshot{
 		state: &kvMuxState{
@@ -65,7 +67,7 @@ func (suite *UnitTestSuite) TestPollerControllerForceHTTPAndNewConfig() {
 		looperDoneSig: make(chan struct{}),
 	}
 
-	poller := newPollerController(ccp, htt, cfgMgr)
+	poller := newPollerController(ccp, htt, cfgMgr, nil)
 	poller.activeController = ccp
 
 	go ccp.DoLoop()
@@ -94,3 +96,184 @@ func (suite *UnitTestSuite) TestPollerControllerForceHTTPAndNewConfig() {
 
 	poller.Stop()
 }
+
+func (suite *UnitTestSuite) newGCCCPTestPoller(maxWait time.Duration) (*cccpConfigController, *memdPipeline) {
+	pipeline := newPipeline("127.0.0.1:11210", 1, 10, ReconnectBackoffConfig{}, 0, nil, nil)
+	muxer := new(mockDispatcher)
+	muxer.On("PipelineSnapshot").Return(&pipelineSnapshot{
+		state: &kvMuxState{
+			revID:   5,
+			bktType: bktTypeNone,
+			pipelines: []*memdPipeline{
+				pipeline,
+			},
+		},
+		idx: 0,
+	}, nil)
+
+	ccp := &cccpConfigController{
+		looperStopSig:      make(chan struct{}),
+		looperDoneSig:      make(chan struct{}),
+		cfgMgr:             &configManagementComponent{currentConfig: &routeConfig{revID: 5}},
+		muxer:              muxer,
+		confCccpPollPeriod: time.Millisecond,
+		confCccpMaxWait:    maxWait,
+	}
+
+	return ccp, pipeline
+}
+
+// This test tests that the cccp poller gives up on cluster level config once a node reports that it needs a bucket
+// selecting, as nodes before 6.5 do.
+func (suite *UnitTestSuite) TestCCCPPollerGCCCPNoBucket() {
+	ccp, pipeline := suite.newGCCCPTestPoller(5 * time.Second)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ccp.DoLoop()
+	}()
+
+	c := &memdOpConsumer{
+		parent:   pipeline.queue,
+		isClosed: false,
+	}
+	req := pipeline.queue.pop(c)
+	suite.Require().Equal(memd.CmdGetClusterConfig, req.Command)
+	req.Callback(nil, req, ErrMemdNoBucket)
+
+	err := <-errCh
+	suite.Assert().True(errors.Is(err, errGCCCPUnavailable))
+	suite.Assert().True(isPollingFallbackError(err))
+}
+
+// This test tests that the cccp poller keeps polling cluster level config when it fails to fetch it for reasons other
+// than the nodes not supporting it, such as timeouts.
+func (suite *UnitTestSuite) TestCCCPPollerGCCCPRepeatedFailures() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	ccp, _ := suite.newGCCCPTestPoller(5 * time.Millisecond)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ccp.DoLoop()
+	}()
+
+	select {
+	case err := <-errCh:
+		suite.T().Fatalf("Poller should not have given up on GCCCP: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	ccp.Stop()
+	suite.Assert().Nil(<-errCh)
+}
+
+func (suite *UnitTestSuite) TestPollerControllerGCCCPFallbackSelectBucket() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	ccp := &cccpConfigController{
+		looperStopSig: make(chan struct{}),
+		looperDoneSig: make(chan struct{}),
+	}
+	htt := &httpConfigController{
+		looperStopSig: make(chan struct{}),
+		looperDoneSig: make(chan struct{}),
+	}
+
+	poller := newPollerController(ccp, htt, &configManagementComponent{}, nil)
+	suite.Assert().True(poller.UsingGCCCP())
+
+	poller.activeController = htt
+	poller.gcccpUnavailable = true
+	suite.Assert().False(poller.UsingGCCCP())
+
+	// Without the fallback the http poller can't switch bucket.
+	poller.gcccpUnavailable = false
+	suite.Assert().True(errors.Is(poller.SetBucketName("default"), ErrUnsupportedOperation))
+
+	// Selecting a bucket stops the http poller so that cccp can take over again.
+	poller.gcccpUnavailable = true
+	suite.Require().Nil(poller.SetBucketName("default"))
+	suite.Assert().Equal("default", htt.getBucketName())
+	select {
+	case <-htt.looperStopSig:
+	case <-time.After(5 * time.Second):
+		suite.T().Fatalf("HTTP poller should have been stopped")
+	}
+
+	// Stop the controller before the http poller reports that it's done so that cccp isn't started.
+	poller.Stop()
+	close(htt.looperDoneSig)
+	time.Sleep(50 * time.Millisecond)
+}
+
+// This test tests that before a config has been seen the cccp poller asks several nodes at once, so that a node which
+// doesn't respond doesn't delay bootstrap.
+func (suite *UnitTestSuite) TestCCCPPollerBootstrapParallel() {
+	config, err := suite.LoadRawTestDataset("bucket_config_with_external_addresses")
+	suite.Require().Nil(err)
+
+	deadPipeline := newPipeline("127.0.0.1:11210", 1, 10, ReconnectBackoffConfig{}, 0, nil, nil)
+	livePipeline := newPipeline("127.0.0.2:11210", 1, 10, ReconnectBackoffConfig{}, 0, nil, nil)
+	muxer := new(mockDispatcher)
+	muxer.On("PipelineSnapshot").Return(&pipelineSnapshot{
+		state: &kvMuxState{
+			revID: -1,
+			pipelines: []*memdPipeline{
+				deadPipeline,
+				livePipeline,
+			},
+		},
+		idx: 0,
+	}, nil)
+
+	cfgMgr := &configManagementComponent{
+		currentConfig: &routeConfig{
+			revID: -1,
+		},
+	}
+
+	ccp := &cccpConfigController{
+		looperStopSig:      make(chan struct{}),
+		looperDoneSig:      make(chan struct{}),
+		cfgMgr:             cfgMgr,
+		muxer:              muxer,
+		confCccpPollPeriod: 10 * time.Second,
+		confCccpMaxWait:    10 * time.Second,
+	}
+
+	go ccp.DoLoop()
+
+	c := &memdOpConsumer{
+		parent:   livePipeline.queue,
+		isClosed: false,
+	}
+	req := livePipeline.queue.pop(c)
+	suite.Require().Equal(memd.CmdGetClusterConfig, req.Command)
+	req.Callback(&memdQResponse{
+		Packet: &memd.Packet{
+			Value: config,
+		},
+	}, req, nil)
+
+	var lastConfig *cfgBucket
+	for i := 0; i < 100; i++ {
+		cfgMgr.configLock.Lock()
+		lastConfig = cfgMgr.lastConfig
+		cfgMgr.configLock.Unlock()
+		if lastConfig != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	suite.Require().NotNil(lastConfig)
+	suite.Assert().Equal("127.0.0.2", lastConfig.SourceHostname)
+	suite.Assert().Nil(ccp.Error())
+
+	ccp.Stop()
+	<-ccp.Done()
+}
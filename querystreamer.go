@@ -51,6 +51,26 @@ func (r *queryStreamer) NextRow() []byte {
 	return rowBytes
 }
 
+// WriteRowsTo writes each remaining row to w, followed by a newline, returning the number of rows written. Rows are
+// only read from the network as quickly as w accepts them.
+func (r *queryStreamer) WriteRowsTo(w io.Writer) (int, error) {
+	var numRows int
+	for {
+		rowBytes := r.NextRow()
+		if rowBytes == nil {
+			return numRows, r.Err()
+		}
+
+		_, err := w.Write(append(rowBytes, '\n'))
+		if err != nil {
+			r.finishWithError(err)
+			return numRows, err
+		}
+
+		numRows++
+	}
+}
+
 // Err returns any errors that have occurred on the stream
 func (r *queryStreamer) Err() error {
 	r.lock.Lock()
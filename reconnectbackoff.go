@@ -0,0 +1,46 @@
+package gocbcore
+
+import (
+	"math/rand"
+	"time"
+)
+
+// ReconnectFailureCallback is invoked each time that a pipeline client fails to connect to address. Attempt is the
+// number of consecutive failed attempts and nextAttemptIn is how long the client will wait before trying again. It is
+// invoked from the pipeline client's goroutine and so must not block.
+type ReconnectFailureCallback func(address string, attempt uint32, err error, nextAttemptIn time.Duration)
+
+// ReconnectBackoffConfig controls how long pipeline clients wait between consecutive failed attempts to connect
+// to a node.
+type ReconnectBackoffConfig struct {
+	// Calculator returns the base delay before the next attempt given the number of consecutive failed attempts,
+	// see ExponentialBackoff. If nil then a failed node is not redialed until 5 seconds after the failure.
+	Calculator BackoffCalculator
+
+	// Jitter is the fraction of the delay, between 0 and 1, which is randomised to avoid every client reconnecting
+	// to a recovering node at the same time.
+	Jitter float64
+
+	// FailureCallback, if set, is invoked for every failed connection attempt.
+	FailureCallback ReconnectFailureCallback
+}
+
+// Delay returns how long to wait before the next attempt following the given number of consecutive failures.
+func (c ReconnectBackoffConfig) Delay(attempt uint32) time.Duration {
+	if c.Calculator == nil {
+		return 0
+	}
+
+	delay := c.Calculator(attempt)
+	if c.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+
+	jitter := c.Jitter
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	// Remove up to jitter of the delay so that the calculated delay remains the upper bound.
+	return delay - time.Duration(rand.Float64()*jitter*float64(delay)) // #nosec G404
+}
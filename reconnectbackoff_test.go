@@ -0,0 +1,75 @@
+package gocbcore
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestReconnectBackoffDelay() {
+	suite.Assert().Zero(ReconnectBackoffConfig{}.Delay(1))
+
+	config := ReconnectBackoffConfig{
+		Calculator: ExponentialBackoff(10*time.Millisecond, 100*time.Millisecond, 2),
+	}
+	suite.Assert().Equal(20*time.Millisecond, config.Delay(1))
+	suite.Assert().Equal(100*time.Millisecond, config.Delay(10))
+
+	config.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay := config.Delay(10)
+		suite.Assert().True(delay >= 50*time.Millisecond && delay <= 100*time.Millisecond, delay.String())
+	}
+}
+
+func (suite *UnitTestSuite) TestReconnectBackoffFailureCallback() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	var dials uint32
+	mockDialer := memdMockDialer(server)
+	dialer := func(ctx context.Context, address string, tlsConfig *tls.Config, deadline time.Time) (MemdConn, error) {
+		if atomic.AddUint32(&dials, 1) <= 3 {
+			return nil, errors.New("connection refused")
+		}
+		return mockDialer(ctx, address, tlsConfig, deadline)
+	}
+
+	var lock sync.Mutex
+	var attempts []uint32
+	var delays []time.Duration
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName: "default",
+		MemdDialer: dialer,
+		ReconnectBackoffConfig: ReconnectBackoffConfig{
+			Calculator: func(attempt uint32) time.Duration {
+				return time.Duration(attempt) * time.Millisecond
+			},
+			FailureCallback: func(address string, attempt uint32, err error, nextAttemptIn time.Duration) {
+				suite.Assert().Equal(server.Address(), address)
+				suite.Assert().NotNil(err)
+				lock.Lock()
+				attempts = append(attempts, attempt)
+				delays = append(delays, nextAttemptIn)
+				lock.Unlock()
+			},
+		},
+	})
+	defer agent.Close()
+
+	suite.mustSet(agent, "key")
+
+	lock.Lock()
+	defer lock.Unlock()
+	suite.Assert().Equal([]uint32{1, 2, 3}, attempts)
+	suite.Assert().Equal([]time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, delays)
+}
@@ -0,0 +1,94 @@
+package gocbcore
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// ResourceUnitResult describes the resources consumed by an operation on a metered deployment. It is only reported
+// when UseResourceUnits is enabled and the server supports it.
+// Volatile: This API is subject to change at any time.
+type ResourceUnitResult struct {
+	ReadUnits  uint16
+	WriteUnits uint16
+
+	// ThrottleDuration is how long the server delayed the operation because a throttle limit had been reached.
+	ThrottleDuration time.Duration
+}
+
+// ResourceUnitTotals describes the resources consumed by all of the operations an agent has sent.
+// Volatile: This API is subject to change at any time.
+type ResourceUnitTotals struct {
+	ReadUnits  uint64
+	WriteUnits uint64
+
+	// ThrottledOperations is the number of operations which the server delayed because a throttle limit had been
+	// reached, ThrottleDuration is the total time that they were delayed for.
+	ThrottledOperations uint64
+	ThrottleDuration    time.Duration
+}
+
+func resourceUnitsFromPacket(pkt *memd.Packet) *ResourceUnitResult {
+	if pkt == nil || (pkt.ReadUnitsFrame == nil && pkt.WriteUnitsFrame == nil && pkt.ThrottleDurationFrame == nil) {
+		return nil
+	}
+
+	units := &ResourceUnitResult{}
+	if pkt.ReadUnitsFrame != nil {
+		units.ReadUnits = pkt.ReadUnitsFrame.ReadUnits
+	}
+	if pkt.WriteUnitsFrame != nil {
+		units.WriteUnits = pkt.WriteUnitsFrame.WriteUnits
+	}
+	if pkt.ThrottleDurationFrame != nil {
+		units.ThrottleDuration = pkt.ThrottleDurationFrame.ThrottleDuration
+	}
+
+	return units
+}
+
+// ResourceUnits returns the resources which the server reported the request consuming, or nil if it did not report
+// any.
+func (resp *memdQResponse) ResourceUnits() *ResourceUnitResult {
+	return resourceUnitsFromPacket(resp.Packet)
+}
+
+// resourceUnitCounters accumulates the resources reported in every response received by an agent's connections.
+type resourceUnitCounters struct {
+	readUnits        uint64
+	writeUnits       uint64
+	throttledOps     uint64
+	throttleDuration int64
+}
+
+func (c *resourceUnitCounters) record(pkt *memd.Packet) {
+	units := resourceUnitsFromPacket(pkt)
+	if units == nil {
+		return
+	}
+
+	atomic.AddUint64(&c.readUnits, uint64(units.ReadUnits))
+	atomic.AddUint64(&c.writeUnits, uint64(units.WriteUnits))
+	if units.ThrottleDuration > 0 {
+		atomic.AddUint64(&c.throttledOps, 1)
+		atomic.AddInt64(&c.throttleDuration, int64(units.ThrottleDuration))
+	}
+}
+
+func (c *resourceUnitCounters) Totals() ResourceUnitTotals {
+	return ResourceUnitTotals{
+		ReadUnits:           atomic.LoadUint64(&c.readUnits),
+		WriteUnits:          atomic.LoadUint64(&c.writeUnits),
+		ThrottledOperations: atomic.LoadUint64(&c.throttledOps),
+		ThrottleDuration:    time.Duration(atomic.LoadInt64(&c.throttleDuration)),
+	}
+}
+
+// ResourceUnits returns the resources consumed by all of the operations sent by this agent, as reported by the
+// server when UseResourceUnits is enabled.
+// Volatile: This API is subject to change at any time.
+func (agent *Agent) ResourceUnits() ResourceUnitTotals {
+	return agent.resourceUnits.Totals()
+}
@@ -19,6 +19,24 @@ type RetryRequest interface {
 	recordRetryAttempt(reason RetryReason)
 }
 
+// RetryAfterHinter is implemented by RetryRequests which can report how long the server has asked to wait before the
+// request is sent again.
+// Volatile: This API is subject to change at any time.
+type RetryAfterHinter interface {
+	// RetryAfterHint returns how long the server has asked to wait before the request is sent again, or zero if it has
+	// not. Retrying sooner than this is likely to be rejected again.
+	RetryAfterHint() time.Duration
+}
+
+// retryAfterHint returns the retry after hint of req, or zero if req doesn't provide one.
+func retryAfterHint(req RetryRequest) time.Duration {
+	if hinter, ok := req.(RetryAfterHinter); ok {
+		return hinter.RetryAfterHint()
+	}
+
+	return 0
+}
+
 // RetryReason represents the reason for an operation possibly being retried.
 type RetryReason interface {
 	AllowsNonIdempotentRetry() bool
@@ -129,6 +147,14 @@ var (
 
 	// MemdWriteFailure indicates that the operation failed because the write failed on the connection.
 	MemdWriteFailure = retryReason{allowsNonIdempotentRetry: true, alwaysRetry: true, description: "MEMD_WRITE_FAILURE"}
+
+	// RateLimitedRetryReason indicates that the operation was rejected, without being applied, because a rate limit
+	// was exceeded. The request's RetryAfterHint, see RetryAfterHinter, reports how long the server asked to wait.
+	RateLimitedRetryReason = retryReason{allowsNonIdempotentRetry: true, alwaysRetry: false, description: "RATE_LIMITED"}
+
+	// KVStatusOverrideRetryReason indicates that the operation failed with a status which the agent was configured to
+	// retry through AgentConfig.KVStatusRetryOverrides.
+	KVStatusOverrideRetryReason = retryReason{allowsNonIdempotentRetry: true, alwaysRetry: false, description: "KV_STATUS_OVERRIDE"}
 )
 
 // MaybeRetryRequest will possibly retry a request according to the strategy belonging to the request.
@@ -222,7 +248,8 @@ type BackoffCalculator func(retryAttempts uint32) time.Duration
 // BestEffortRetryStrategy represents a strategy that will keep retrying until it succeeds (or the caller times out
 // the request).
 type BestEffortRetryStrategy struct {
-	backoffCalculator BackoffCalculator
+	backoffCalculator  BackoffCalculator
+	retryNonIdempotent bool
 }
 
 // NewBestEffortRetryStrategy returns a new BestEffortRetryStrategy which will use the supplied calculator function
@@ -235,12 +262,64 @@ func NewBestEffortRetryStrategy(calculator BackoffCalculator) *BestEffortRetrySt
 	return &BestEffortRetryStrategy{backoffCalculator: calculator}
 }
 
-// RetryAfter calculates and returns a RetryAction describing how long to wait before retrying an operation.
+// WithNonIdempotentRetries returns a copy of the strategy which also retries operations that are not idempotent after
+// failures where it is unknown whether the operation was applied, such as the connection closing whilst the operation
+// was in flight. This should only be used for operations where being applied more than once is acceptable.
+func (rs *BestEffortRetryStrategy) WithNonIdempotentRetries() *BestEffortRetryStrategy {
+	return &BestEffortRetryStrategy{
+		backoffCalculator:  rs.backoffCalculator,
+		retryNonIdempotent: true,
+	}
+}
+
+// RetryAfter calculates and returns a RetryAction describing how long to wait before retrying an operation. If the
+// server has indicated how long to wait then the operation is not retried any sooner than that.
 func (rs *BestEffortRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
-	if req.Idempotent() || reason.AllowsNonIdempotentRetry() {
+	if req.Idempotent() || reason.AllowsNonIdempotentRetry() || rs.retryNonIdempotent {
+		duration := rs.backoffCalculator(req.RetryAttempts())
+		if hint := retryAfterHint(req); hint > duration {
+			duration = hint
+		}
+
+		return &WithDurationRetryAction{WithDuration: duration}
+	}
+
+	return &NoRetryRetryAction{}
+}
+
+// LockedRetryStrategy represents a strategy that will keep retrying operations which failed because the document
+// was locked, or because of a temporary failure, until they succeed (or the caller times out the request). This is
+// useful for GetAndLock against documents which are likely to be locked by another actor. Any other reason is
+// handled by the fallback strategy, if one is set.
+type LockedRetryStrategy struct {
+	backoffCalculator BackoffCalculator
+	fallback          RetryStrategy
+}
+
+// NewLockedRetryStrategy returns a new LockedRetryStrategy which will use the supplied calculator function
+// to calculate retry durations. If calculator is nil then ControlledBackoff will be used. If fallback is nil then
+// operations which failed for any other reason will not be retried.
+func NewLockedRetryStrategy(calculator BackoffCalculator, fallback RetryStrategy) *LockedRetryStrategy {
+	if calculator == nil {
+		calculator = ControlledBackoff
+	}
+
+	return &LockedRetryStrategy{
+		backoffCalculator: calculator,
+		fallback:          fallback,
+	}
+}
+
+// RetryAfter calculates and returns a RetryAction describing how long to wait before retrying an operation.
+func (rs *LockedRetryStrategy) RetryAfter(req RetryRequest, reason RetryReason) RetryAction {
+	if reason == KVLockedRetryReason || reason == KVTemporaryFailureRetryReason {
 		return &WithDurationRetryAction{WithDuration: rs.backoffCalculator(req.RetryAttempts())}
 	}
 
+	if rs.fallback != nil {
+		return rs.fallback.RetryAfter(req, reason)
+	}
+
 	return &NoRetryRetryAction{}
 }
 
@@ -299,12 +378,15 @@ var idempotentOps = map[memd.CmdCode]bool{
 	memd.CmdSubDocGet:              true,
 	memd.CmdSubDocExists:           true,
 	memd.CmdSubDocGetCount:         true,
+	memd.CmdSubDocMultiLookup:      true,
 	memd.CmdNoop:                   true,
 	memd.CmdStat:                   true,
 	memd.CmdGetRandom:              true,
 	memd.CmdCollectionsGetID:       true,
 	memd.CmdCollectionsGetManifest: true,
 	memd.CmdGetClusterConfig:       true,
+	memd.CmdGetErrorMap:            true,
+	memd.CmdGetAllVBSeqnos:         true,
 	memd.CmdObserve:                true,
 	memd.CmdObserveSeqNo:           true,
 }
@@ -1,10 +1,14 @@
 package gocbcore
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
 )
 
 type mockRetryRequest struct {
@@ -14,6 +18,11 @@ type mockRetryRequest struct {
 	reasons    []RetryReason
 	cancelFunc func() bool
 	strategy   RetryStrategy
+	retryAfter time.Duration
+}
+
+func (mgr *mockRetryRequest) RetryAfterHint() time.Duration {
+	return mgr.retryAfter
 }
 
 func (mgr *mockRetryRequest) retryStrategy() RetryStrategy {
@@ -404,3 +413,211 @@ func (suite *StandardTestSuite) TestExponentialBackoffNonDefaults() {
 		}
 	}
 }
+
+func (suite *UnitTestSuite) TestLockedRetryStrategy() {
+	fallback := &mockRetryStrategy{action: &WithDurationRetryAction{WithDuration: time.Second}}
+	strategy := NewLockedRetryStrategy(mockBackoffCalculator, fallback)
+	req := &mockRetryRequest{attempts: 2}
+
+	action := strategy.RetryAfter(req, KVLockedRetryReason)
+	suite.Assert().Equal(2*time.Millisecond, action.Duration())
+	action = strategy.RetryAfter(req, KVTemporaryFailureRetryReason)
+	suite.Assert().Equal(2*time.Millisecond, action.Duration())
+	suite.Assert().False(fallback.retried)
+
+	action = strategy.RetryAfter(req, KVNotMyVBucketRetryReason)
+	suite.Assert().Equal(time.Second, action.Duration())
+	suite.Assert().True(fallback.retried)
+
+	action = NewLockedRetryStrategy(nil, nil).RetryAfter(req, KVNotMyVBucketRetryReason)
+	suite.Assert().Zero(action.Duration())
+}
+
+func (suite *UnitTestSuite) TestTranslateGetAndLockTmpFail() {
+	err := translateMemdError(ErrMemdTmpFail, &memdQRequest{
+		Packet: memd.Packet{Command: memd.CmdGetLocked},
+	})
+	suite.Assert().True(errors.Is(err, ErrDocumentLocked))
+
+	err = translateMemdError(ErrMemdTmpFail, &memdQRequest{
+		Packet: memd.Packet{Command: memd.CmdGet},
+	})
+	suite.Assert().True(errors.Is(err, ErrTemporaryFailure))
+}
+
+func (suite *UnitTestSuite) TestBestEffortRetryStrategyNonIdempotent() {
+	strategy := NewBestEffortRetryStrategy(mockBackoffCalculator)
+	req := &mockRetryRequest{attempts: 1}
+
+	suite.Assert().Zero(strategy.RetryAfter(req, SocketCloseInFlightRetryReason).Duration())
+	suite.Assert().NotZero(strategy.RetryAfter(req, KVLockedRetryReason).Duration())
+	suite.Assert().NotZero(strategy.RetryAfter(&mockRetryRequest{attempts: 1, idempotent: true}, SocketCloseInFlightRetryReason).Duration())
+
+	optIn := strategy.WithNonIdempotentRetries()
+	suite.Assert().Equal(time.Millisecond, optIn.RetryAfter(req, SocketCloseInFlightRetryReason).Duration())
+
+	suite.Assert().True((&memdQRequest{Packet: memd.Packet{Command: memd.CmdSubDocMultiLookup}}).Idempotent())
+	suite.Assert().False((&memdQRequest{Packet: memd.Packet{Command: memd.CmdSubDocMultiMutation}}).Idempotent())
+}
+
+func (suite *UnitTestSuite) TestRetrySocketClosedInFlight() {
+	// This test purposefully triggers error cases.
+	globalTestLogger.SuppressWarnings(true)
+	defer globalTestLogger.SuppressWarnings(false)
+
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	// The first request for each command is swallowed so that it is in flight when the connection is closed.
+	arrived := make(chan struct{}, 2)
+	swallowFirst := func(cmd memd.CmdCode) {
+		var handler memdmock.HandlerFunc
+		var seen bool
+		handler = server.Handle(cmd, func(req *memd.Packet) *memd.Packet {
+			if !seen {
+				seen = true
+				arrived <- struct{}{}
+				return nil
+			}
+			return handler(req)
+		})
+	}
+	swallowFirst(memd.CmdGet)
+	swallowFirst(memd.CmdSet)
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	getErrCh := make(chan error, 1)
+	_, err := agent.Get(GetOptions{
+		Key:           []byte("key"),
+		Deadline:      time.Now().Add(5 * time.Second),
+		RetryStrategy: NewBestEffortRetryStrategy(nil),
+	}, func(res *GetResult, err error) {
+		getErrCh <- err
+	})
+	suite.Require().Nil(err)
+	<-arrived
+	server.Close()
+
+	// The get is idempotent so is retried on the new connection, where the document is not found.
+	err = <-getErrCh
+	suite.Assert().True(errors.Is(err, ErrDocumentNotFound), err)
+
+	setErrCh := make(chan error, 1)
+	_, err = agent.Set(SetOptions{
+		Key:           []byte("key"),
+		Value:         []byte("value"),
+		Deadline:      time.Now().Add(5 * time.Second),
+		RetryStrategy: NewBestEffortRetryStrategy(nil),
+	}, func(res *StoreResult, err error) {
+		setErrCh <- err
+	})
+	suite.Require().Nil(err)
+	<-arrived
+	server.Close()
+
+	// The set may have been applied so it isn't retried without opting in.
+	err = <-setErrCh
+	suite.Assert().True(errors.Is(err, ErrSocketClosed), err)
+}
+
+func (suite *UnitTestSuite) TestRegisterRetryReason() {
+	// Registrations are global so the description must be unique to each run of the test.
+	description := fmt.Sprintf("TEST_APP_THROTTLED_%d", time.Now().UnixNano())
+	reason, err := RegisterRetryReason(description, false, false)
+	suite.Require().Nil(err)
+	suite.Assert().Equal(description, reason.Description())
+	suite.Assert().False(reason.AllowsNonIdempotentRetry())
+
+	_, err = RegisterRetryReason(description, true, false)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+	_, err = RegisterRetryReason(KVLockedRetryReason.Description(), true, false)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+	_, err = RegisterRetryReason("", true, false)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
+
+func (suite *UnitTestSuite) TestKVRetryClassifier() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	// The document only becomes visible on the third attempt, as if it were still being replicated.
+	var handler memdmock.HandlerFunc
+	attempts := 0
+	handler = server.Handle(memd.CmdGet, func(req *memd.Packet) *memd.Packet {
+		attempts++
+		if attempts < 3 {
+			return &memd.Packet{Status: memd.StatusKeyNotFound}
+		}
+		return handler(req)
+	})
+
+	notVisible, err := RegisterRetryReason(fmt.Sprintf("TEST_NOT_YET_VISIBLE_%d", time.Now().UnixNano()), false, false)
+	suite.Require().Nil(err)
+
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName: "default",
+		KVRetryClassifier: func(candidate KVRetryCandidate) RetryReason {
+			if candidate.Response != nil && candidate.Response.Status == memd.StatusKeyNotFound &&
+				string(candidate.Packet.Key) == "key" {
+				return notVisible
+			}
+			return nil
+		},
+	})
+	defer agent.Close()
+
+	suite.mustSet(agent, "key")
+
+	getCh := make(chan *GetResult, 1)
+	_, err = agent.Get(GetOptions{
+		Key:           []byte("key"),
+		Deadline:      time.Now().Add(5 * time.Second),
+		RetryStrategy: NewBestEffortRetryStrategy(nil),
+	}, func(res *GetResult, err error) {
+		suite.Assert().Nil(err)
+		getCh <- res
+	})
+	suite.Require().Nil(err)
+	res := <-getCh
+	suite.Require().NotNil(res)
+	suite.Assert().Equal([]byte("value"), res.Value)
+	suite.Assert().Equal(3, attempts)
+
+	// Without a retry strategy the classifier's reason is not acted upon.
+	attempts = 0
+	errCh := make(chan error, 1)
+	_, err = agent.Get(GetOptions{
+		Key:      []byte("key"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(<-errCh, ErrDocumentNotFound))
+}
+
+func (suite *UnitTestSuite) TestBestEffortRetryStrategyRetryAfterHint() {
+	strategy := NewBestEffortRetryStrategy(nil)
+
+	// The hint is only used if it is longer than the backoff would have been.
+	req := &mockRetryRequest{attempts: 0, retryAfter: 2 * time.Second}
+	action := strategy.RetryAfter(req, RateLimitedRetryReason)
+	suite.Assert().Equal(2*time.Second, action.Duration())
+
+	req = &mockRetryRequest{attempts: 10, retryAfter: time.Millisecond}
+	action = strategy.RetryAfter(req, RateLimitedRetryReason)
+	suite.Assert().Equal(ControlledBackoff(10), action.Duration())
+
+	// Requests which can't provide a hint just use the backoff.
+	action = strategy.RetryAfter(&waitUntilOp{}, RateLimitedRetryReason)
+	suite.Assert().Equal(ControlledBackoff(0), action.Duration())
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	suite.Assert().Equal(120*time.Second, parseRetryAfter("120", now))
+	suite.Assert().Equal(30*time.Second, parseRetryAfter("Wed, 01 Jan 2020 12:00:30 GMT", now))
+	suite.Assert().Equal(time.Duration(0), parseRetryAfter("Wed, 01 Jan 2020 11:00:00 GMT", now))
+	suite.Assert().Equal(time.Duration(0), parseRetryAfter("soon", now))
+	suite.Assert().Equal(time.Duration(0), parseRetryAfter("", now))
+}
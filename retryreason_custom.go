@@ -0,0 +1,93 @@
+package gocbcore
+
+import (
+	"sync"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+var builtinRetryReasons = []RetryReason{
+	UnknownRetryReason,
+	SocketNotAvailableRetryReason,
+	ServiceNotAvailableRetryReason,
+	NodeNotAvailableRetryReason,
+	KVNotMyVBucketRetryReason,
+	KVCollectionOutdatedRetryReason,
+	KVErrMapRetryReason,
+	KVLockedRetryReason,
+	KVTemporaryFailureRetryReason,
+	KVSyncWriteInProgressRetryReason,
+	KVSyncWriteRecommitInProgressRetryReason,
+	ServiceResponseCodeIndicatedRetryReason,
+	SocketCloseInFlightRetryReason,
+	PipelineOverloadedRetryReason,
+	CircuitBreakerOpenRetryReason,
+	QueryIndexNotFoundRetryReason,
+	QueryPreparedStatementFailureRetryReason,
+	AnalyticsTemporaryFailureRetryReason,
+	SearchTooManyRequestsRetryReason,
+	NotReadyRetryReason,
+	NoPipelineSnapshotRetryReason,
+	BucketNotReadyReason,
+	ConnectionErrorRetryReason,
+	MemdWriteFailure,
+	RateLimitedRetryReason,
+	KVStatusOverrideRetryReason,
+}
+
+var (
+	registeredRetryReasons     map[string]RetryReason
+	registeredRetryReasonsLock sync.Mutex
+)
+
+// RegisterRetryReason creates a RetryReason for an application defined condition, which can then be returned from
+// a KVRetryClassifier or passed to Agent.MaybeRetryRequest. Retry strategies see it in the same way as the built in
+// reasons. The description must be unique across both the built in and registered reasons.
+// Volatile: This API is subject to change at any time.
+func RegisterRetryReason(description string, allowsNonIdempotentRetry, alwaysRetry bool) (RetryReason, error) {
+	if description == "" {
+		return nil, wrapError(errInvalidArgument, "retry reason description must not be empty")
+	}
+
+	registeredRetryReasonsLock.Lock()
+	defer registeredRetryReasonsLock.Unlock()
+
+	if registeredRetryReasons == nil {
+		registeredRetryReasons = make(map[string]RetryReason, len(builtinRetryReasons))
+		for _, reason := range builtinRetryReasons {
+			registeredRetryReasons[reason.Description()] = reason
+		}
+	}
+
+	if _, ok := registeredRetryReasons[description]; ok {
+		return nil, wrapError(errInvalidArgument, "retry reason "+description+" is already registered")
+	}
+
+	reason := retryReason{
+		allowsNonIdempotentRetry: allowsNonIdempotentRetry,
+		alwaysRetry:              alwaysRetry,
+		description:              description,
+	}
+	registeredRetryReasons[description] = reason
+
+	return reason, nil
+}
+
+// KVRetryCandidate describes a kv request which has failed, it is given to a KVRetryClassifier.
+type KVRetryCandidate struct {
+	Request RetryRequest
+
+	// Packet is the request packet, it must not be modified.
+	Packet *memd.Packet
+
+	// Response is the response packet, or nil if the request failed without a response.
+	Response *memd.Packet
+
+	Err error
+}
+
+// KVRetryClassifier is invoked whenever a kv request fails, before the agent's own handling of the failure. Returning
+// a RetryReason causes the request to be retried according to its retry strategy, returning nil leaves the failure to
+// be handled as normal. Classifiers must not block as they are invoked inline with response processing.
+// Volatile: This API is subject to change at any time.
+type KVRetryClassifier func(candidate KVRetryCandidate) RetryReason
@@ -4,8 +4,11 @@ import "fmt"
 
 type routeConfig struct {
 	revID        int64
+	revEpoch     int64
 	uuid         string
 	name         string
+	clusterUUID  string
+	clusterName  string
 	bktType      bucketType
 	kvServerList []string
 	capiEpList   []string
@@ -27,6 +30,8 @@ func (config *routeConfig) DebugString() string {
 	var outStr string
 
 	outStr += fmt.Sprintf("Revision ID: %d\n", config.revID)
+	outStr += fmt.Sprintf("Revision Epoch: %d\n", config.revEpoch)
+	outStr += fmt.Sprintf("Cluster: %s (%s)\n", config.clusterName, config.clusterUUID)
 
 	outStr += "Capi Eps:\n"
 	for _, ep := range config.capiEpList {
@@ -89,6 +94,16 @@ func (config *routeConfig) IsValid() bool {
 	}
 }
 
+// IsNewerThan returns whether config is a later revision than other. Configs are ordered by their revision epoch,
+// which is incremented when a cluster is rebuilt or restored, before their revision id.
+func (config *routeConfig) IsNewerThan(other *routeConfig) bool {
+	if config.revEpoch != other.revEpoch {
+		return config.revEpoch > other.revEpoch
+	}
+
+	return config.revID > other.revID
+}
+
 func (config *routeConfig) IsGCCCPConfig() bool {
 	return config.bktType == bktTypeNone
 }
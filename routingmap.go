@@ -0,0 +1,114 @@
+package gocbcore
+
+// RoutingMapServer describes a single kv server in a RoutingMap.
+// Volatile: This API is subject to change at any time.
+type RoutingMapServer struct {
+	Index   int    `json:"index"`
+	Address string `json:"address"`
+
+	// ActiveVbuckets is the list of vbuckets which are active on the server.
+	ActiveVbuckets []uint16 `json:"activeVbuckets,omitempty"`
+
+	// ReplicaVbuckets holds the list of vbuckets for which the server is each replica, ReplicaVbuckets[0] is the
+	// list of vbuckets for which it is the first replica.
+	ReplicaVbuckets [][]uint16 `json:"replicaVbuckets,omitempty"`
+}
+
+// RoutingMapKetamaPoint is a single point on the ketama continuum used to route keys for memcached buckets, keys
+// which hash to a value greater than the previous point and no greater than this point are sent to ServerIndex.
+// Volatile: This API is subject to change at any time.
+type RoutingMapKetamaPoint struct {
+	Point       uint32 `json:"point"`
+	ServerIndex int    `json:"server"`
+}
+
+// RoutingMap describes how keys are routed to kv servers by a config, it is intended to be marshalled to JSON for
+// debugging routing issues or for checking that an application's view of the cluster matches the SDK's.
+// Volatile: This API is subject to change at any time.
+type RoutingMap struct {
+	RevID    int64 `json:"rev"`
+	RevEpoch int64 `json:"revEpoch,omitempty"`
+
+	// BucketType is "couchbase" for buckets which route using a vbucket map, "memcached" for buckets which route
+	// using a ketama continuum, and "none" when no bucket is selected.
+	BucketType string `json:"bucketType"`
+
+	NumReplicas int                `json:"numReplicas"`
+	Servers     []RoutingMapServer `json:"servers"`
+
+	// VbucketMap holds the replica chain for each vbucket, the first entry is the index of the server on which the
+	// vbucket is active and the remaining entries are its replicas. An index of -1 means that the copy of the
+	// vbucket is not currently assigned to a server.
+	VbucketMap [][]int `json:"vbucketMap,omitempty"`
+
+	KetamaContinuum []RoutingMapKetamaPoint `json:"ketamaContinuum,omitempty"`
+}
+
+func bucketTypeName(bktType bucketType) string {
+	switch bktType {
+	case bktTypeCouchbase:
+		return "couchbase"
+	case bktTypeMemcached:
+		return "memcached"
+	case bktTypeNone:
+		return "none"
+	}
+
+	return "invalid"
+}
+
+// RoutingMap returns a copy of the routing information in this snapshot.
+// Volatile: This API is subject to change at any time.
+func (pi ConfigSnapshot) RoutingMap() RoutingMap {
+	state := pi.state
+	routingMap := RoutingMap{
+		RevID:      state.revID,
+		RevEpoch:   state.revEpoch,
+		BucketType: bucketTypeName(state.bktType),
+		Servers:    make([]RoutingMapServer, len(state.kvServerList)),
+	}
+
+	for i, address := range state.kvServerList {
+		routingMap.Servers[i] = RoutingMapServer{
+			Index:   i,
+			Address: address,
+		}
+	}
+
+	if state.vbMap != nil {
+		routingMap.NumReplicas = state.vbMap.NumReplicas()
+		routingMap.VbucketMap = make([][]int, len(state.vbMap.entries))
+		for vbID, chain := range state.vbMap.entries {
+			routingMap.VbucketMap[vbID] = append([]int(nil), chain...)
+
+			for replicaIdx, serverIdx := range chain {
+				if serverIdx < 0 || serverIdx >= len(routingMap.Servers) {
+					continue
+				}
+
+				server := &routingMap.Servers[serverIdx]
+				if replicaIdx == 0 {
+					server.ActiveVbuckets = append(server.ActiveVbuckets, uint16(vbID))
+					continue
+				}
+
+				for len(server.ReplicaVbuckets) < replicaIdx {
+					server.ReplicaVbuckets = append(server.ReplicaVbuckets, nil)
+				}
+				server.ReplicaVbuckets[replicaIdx-1] = append(server.ReplicaVbuckets[replicaIdx-1], uint16(vbID))
+			}
+		}
+	}
+
+	if state.ketamaMap != nil {
+		routingMap.KetamaContinuum = make([]RoutingMapKetamaPoint, len(state.ketamaMap.entries))
+		for i, entry := range state.ketamaMap.entries {
+			routingMap.KetamaContinuum[i] = RoutingMapKetamaPoint{
+				Point:       entry.point,
+				ServerIndex: int(entry.index),
+			}
+		}
+	}
+
+	return routingMap
+}
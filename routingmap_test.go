@@ -0,0 +1,69 @@
+package gocbcore
+
+import "encoding/json"
+
+func (suite *UnitTestSuite) TestConfigSnapshotRoutingMap() {
+	cfg := &routeConfig{
+		revID:        5,
+		bktType:      bktTypeCouchbase,
+		kvServerList: []string{"a:11210", "b:11210"},
+		vbMap: newVbucketMap([][]int{
+			{0, 1},
+			{1, 0},
+			{0, -1},
+		}, 1),
+	}
+
+	snapshot := &ConfigSnapshot{state: newKVMuxState(cfg, nil, nil)}
+	routingMap := snapshot.RoutingMap()
+
+	suite.Assert().Equal(RoutingMap{
+		RevID:       5,
+		BucketType:  "couchbase",
+		NumReplicas: 1,
+		Servers: []RoutingMapServer{
+			{Index: 0, Address: "a:11210", ActiveVbuckets: []uint16{0, 2}, ReplicaVbuckets: [][]uint16{{1}}},
+			{Index: 1, Address: "b:11210", ActiveVbuckets: []uint16{1}, ReplicaVbuckets: [][]uint16{{0}}},
+		},
+		VbucketMap: [][]int{{0, 1}, {1, 0}, {0, -1}},
+	}, routingMap)
+
+	// The map must not share memory with the config.
+	routingMap.VbucketMap[0][0] = 1
+	suite.Assert().Equal(0, cfg.vbMap.entries[0][0])
+
+	_, err := json.Marshal(routingMap)
+	suite.Assert().Nil(err)
+}
+
+func (suite *UnitTestSuite) TestConfigSnapshotRoutingMapKetama() {
+	cfg := &routeConfig{
+		revID:        2,
+		bktType:      bktTypeMemcached,
+		kvServerList: []string{"a:11210", "b:11210"},
+	}
+	cfg.ketamaMap = newKetamaContinuum(cfg.kvServerList)
+
+	snapshot := &ConfigSnapshot{state: newKVMuxState(cfg, nil, nil)}
+	routingMap := snapshot.RoutingMap()
+
+	suite.Assert().Equal("memcached", routingMap.BucketType)
+	suite.Assert().Nil(routingMap.VbucketMap)
+	suite.Require().Len(routingMap.KetamaContinuum, 320)
+
+	// Every key must route to the same server using the exported continuum as it does using the agent's.
+	for _, key := range []string{"foo", "bar", "baz"} {
+		expected, err := snapshot.ServerForKey([]byte(key), 0)
+		suite.Require().Nil(err)
+
+		hash := ketamaHash([]byte(key))
+		actual := routingMap.KetamaContinuum[0].ServerIndex
+		for i, point := range routingMap.KetamaContinuum {
+			if hash <= point.Point && (i == 0 || hash > routingMap.KetamaContinuum[i-1].Point) {
+				actual = point.ServerIndex
+				break
+			}
+		}
+		suite.Assert().Equal(expected, actual, key)
+	}
+}
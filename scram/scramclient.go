@@ -29,11 +29,13 @@ import (
 	"bytes"
 	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"hash"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // Client implements a SCRAM-{SHA-1,etc} client per RFC5802.
@@ -51,6 +53,8 @@ type Client struct {
 	serverNonce []byte
 	saltedPass  []byte
 	authMsg     bytes.Buffer
+
+	cache *SaltedPasswordCache
 }
 
 // NewClient returns a new instance of the SCRAM client.
@@ -65,6 +69,12 @@ func NewClient(newHash func() hash.Hash, user, pass string) *Client {
 	return c
 }
 
+// SetSaltedPasswordCache sets the cache used to avoid salting the password again when the server sends the same salt
+// and iteration count as a previous authentication.
+func (c *Client) SetSaltedPasswordCache(cache *SaltedPasswordCache) {
+	c.cache = cache
+}
+
 // Out returns the data to be sent to the server in the current step.
 func (c *Client) Out() []byte {
 	if c.out.Len() == 0 {
@@ -208,6 +218,17 @@ func (c *Client) step3(in []byte) error {
 }
 
 func (c *Client) saltPassword(salt []byte, iterCount int) error {
+	key := saltedPasswordKey{
+		user:      c.user,
+		salt:      string(salt),
+		iterCount: iterCount,
+		hashSize:  c.newHash().Size(),
+	}
+	if saltedPass := c.cache.get(key, c.pass); saltedPass != nil {
+		c.saltedPass = saltedPass
+		return nil
+	}
+
 	mac := hmac.New(c.newHash, []byte(c.pass))
 	if _, err := mac.Write(salt); err != nil {
 		return err
@@ -229,6 +250,7 @@ func (c *Client) saltPassword(salt []byte, iterCount int) error {
 		}
 	}
 	c.saltedPass = hi
+	c.cache.put(key, c.pass, hi)
 	return nil
 }
 
@@ -273,3 +295,116 @@ func (c *Client) serverSignature() ([]byte, error) {
 	b64.Encode(encoded, serverSignature)
 	return encoded, nil
 }
+
+// maxSaltedPasswordCacheEntries bounds the size of a SaltedPasswordCache, there is normally only one entry for each
+// user and hash algorithm so this is only reached if the server keeps changing the salt.
+const maxSaltedPasswordCacheEntries = 64
+
+type saltedPasswordKey struct {
+	user      string
+	salt      string
+	iterCount int
+	hashSize  int
+}
+
+type saltedPasswordEntry struct {
+	passDigest []byte
+	saltedPass []byte
+}
+
+// passwordDigest returns a digest of pass keyed with the password salted from it, so that the cache can check whether
+// the password has changed without holding on to the password itself.
+func passwordDigest(saltedPass []byte, pass string) []byte {
+	mac := hmac.New(sha256.New, saltedPass)
+	_, _ = mac.Write([]byte(pass))
+	return mac.Sum(nil)
+}
+
+// SaltedPasswordCache stores salted passwords so that the expensive key derivation is not repeated for every
+// connection which authenticates as the same user. Entries are only used if the password still matches the password
+// that they were derived from, only a digest of the password is stored. It is safe for concurrent use and all methods
+// are safe to call on a nil cache.
+type SaltedPasswordCache struct {
+	lock    sync.Mutex
+	entries map[saltedPasswordKey]saltedPasswordEntry
+}
+
+// NewSaltedPasswordCache returns a new, empty, salted password cache.
+func NewSaltedPasswordCache() *SaltedPasswordCache {
+	return &SaltedPasswordCache{
+		entries: make(map[saltedPasswordKey]saltedPasswordEntry),
+	}
+}
+
+func (spc *SaltedPasswordCache) get(key saltedPasswordKey, pass string) []byte {
+	if spc == nil {
+		return nil
+	}
+
+	spc.lock.Lock()
+	entry, ok := spc.entries[key]
+	spc.lock.Unlock()
+
+	if !ok || !hmac.Equal(entry.passDigest, passwordDigest(entry.saltedPass, pass)) {
+		return nil
+	}
+
+	return entry.saltedPass
+}
+
+func (spc *SaltedPasswordCache) put(key saltedPasswordKey, pass string, saltedPass []byte) {
+	if spc == nil {
+		return
+	}
+
+	spc.lock.Lock()
+	defer spc.lock.Unlock()
+
+	if _, ok := spc.entries[key]; !ok && len(spc.entries) >= maxSaltedPasswordCacheEntries {
+		spc.entries = make(map[saltedPasswordKey]saltedPasswordEntry)
+	}
+	spc.entries[key] = saltedPasswordEntry{
+		passDigest: passwordDigest(saltedPass, pass),
+		saltedPass: saltedPass,
+	}
+}
+
+// Invalidate removes all of the salted passwords cached for user, this should be called when authentication fails
+// in case the cached value is no longer valid.
+func (spc *SaltedPasswordCache) Invalidate(user string) {
+	if spc == nil {
+		return
+	}
+
+	spc.lock.Lock()
+	defer spc.lock.Unlock()
+
+	for key := range spc.entries {
+		if key.user == user {
+			delete(spc.entries, key)
+		}
+	}
+}
+
+// Clear removes every salted password from the cache.
+func (spc *SaltedPasswordCache) Clear() {
+	if spc == nil {
+		return
+	}
+
+	spc.lock.Lock()
+	spc.entries = make(map[saltedPasswordKey]saltedPasswordEntry)
+	spc.lock.Unlock()
+}
+
+// Len returns the number of salted passwords in the cache.
+func (spc *SaltedPasswordCache) Len() int {
+	if spc == nil {
+		return 0
+	}
+
+	spc.lock.Lock()
+	defer spc.lock.Unlock()
+
+	return len(spc.entries)
+}
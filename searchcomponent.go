@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"strings"
 	"time"
@@ -35,6 +36,13 @@ func (q *SearchRowReader) Close() error {
 	return q.streamer.Close()
 }
 
+// WriteRowsTo streams the remaining rows into w as newline delimited JSON, returning the number of rows written.
+// Rows are only read from the network as quickly as w accepts them.
+// Volatile: This API is subject to change at any time.
+func (q *SearchRowReader) WriteRowsTo(w io.Writer) (int, error) {
+	return q.streamer.WriteRowsTo(w)
+}
+
 // SearchQueryOptions represents the various options available for a search query.
 type SearchQueryOptions struct {
 	IndexName     string
@@ -85,6 +93,9 @@ func parseSearchError(req *httpRequest, indexName string, query interface{}, res
 		parseErr := json.Unmarshal(respBody, &respParse)
 		if parseErr == nil {
 			errMsg = respParse.Status
+		} else {
+			// The index management endpoints report errors as plain text.
+			errMsg = strings.TrimSpace(string(respBody))
 		}
 	}
 
@@ -94,12 +105,21 @@ func parseSearchError(req *httpRequest, indexName string, query interface{}, res
 	if resp.StatusCode == 401 || resp.StatusCode == 403 {
 		err = errAuthenticationFailure
 	}
-	if resp.StatusCode == 400 && strings.Contains(errMsg, "index not found") {
+	if (resp.StatusCode == 400 || resp.StatusCode == 404) && strings.Contains(errMsg, "index not found") {
 		err = errIndexNotFound
 	}
+	if resp.StatusCode == 400 && strings.Contains(errMsg, "index with the same name already exists") {
+		err = errIndexExists
+	}
+	if resp.StatusCode == 429 {
+		err = errRateLimitedFailure
+	}
 
 	errOut := wrapSearchError(req, resp, indexName, query, err)
 	errOut.ErrorText = errMsg
+	if errors.Is(err, ErrRateLimitedFailure) {
+		errOut.RetryAfter = rateLimitedRetryAfter(resp)
+	}
 	return errOut
 }
 
@@ -200,7 +220,9 @@ func (sqc *searchQueryComponent) SearchQuery(opts SearchQueryOptions, cb SearchQ
 					return
 				}
 
+				ireq.retryAfter = searchErr.RetryAfter
 				shouldRetry, retryTime := retryOrchMaybeRetry(ireq, retryReason)
+				ireq.retryAfter = 0
 				if !shouldRetry {
 					cancel()
 					// searchErr is already wrapped here
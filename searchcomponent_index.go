@@ -0,0 +1,388 @@
+package gocbcore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+)
+
+// SearchIndex represents the definition of a search index.
+// Volatile: This API is subject to change at any time.
+type SearchIndex struct {
+	UUID         string                 `json:"uuid,omitempty"`
+	Name         string                 `json:"name"`
+	Type         string                 `json:"type"`
+	Params       map[string]interface{} `json:"params,omitempty"`
+	SourceName   string                 `json:"sourceName,omitempty"`
+	SourceUUID   string                 `json:"sourceUUID,omitempty"`
+	SourceType   string                 `json:"sourceType,omitempty"`
+	SourceParams map[string]interface{} `json:"sourceParams,omitempty"`
+	PlanParams   map[string]interface{} `json:"planParams,omitempty"`
+}
+
+// SearchIndexPartitionNode describes the role a node plays in serving a search index partition.
+// Volatile: This API is subject to change at any time.
+type SearchIndexPartitionNode struct {
+	CanRead  bool `json:"canRead"`
+	CanWrite bool `json:"canWrite"`
+	Priority int  `json:"priority"`
+}
+
+// SearchIndexPartition describes a single planned partition of a search index.
+// Volatile: This API is subject to change at any time.
+type SearchIndexPartition struct {
+	Name             string `json:"name"`
+	UUID             string `json:"uuid"`
+	IndexName        string `json:"indexName"`
+	IndexUUID        string `json:"indexUUID"`
+	SourcePartitions string `json:"sourcePartitions"`
+	// Nodes is keyed by the UUID of the node hosting the partition.
+	Nodes map[string]SearchIndexPartitionNode `json:"nodes"`
+}
+
+// UpsertSearchIndexOptions encapsulates the parameters for a UpsertSearchIndex operation.
+// Volatile: This API is subject to change at any time.
+type UpsertSearchIndexOptions struct {
+	// Index is the definition to create, or to replace if an index with the same name exists.
+	// If UUID is set then the update is only applied if it matches the current definition.
+	Index         SearchIndex
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// UpsertSearchIndexResult encapsulates the result of a UpsertSearchIndex operation.
+// Volatile: This API is subject to change at any time.
+type UpsertSearchIndexResult struct {
+}
+
+// GetSearchIndexOptions encapsulates the parameters for a GetSearchIndex operation.
+// Volatile: This API is subject to change at any time.
+type GetSearchIndexOptions struct {
+	IndexName     string
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// GetSearchIndexResult encapsulates the result of a GetSearchIndex operation.
+// Volatile: This API is subject to change at any time.
+type GetSearchIndexResult struct {
+	Index SearchIndex
+}
+
+// GetAllSearchIndexesOptions encapsulates the parameters for a GetAllSearchIndexes operation.
+// Volatile: This API is subject to change at any time.
+type GetAllSearchIndexesOptions struct {
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// GetAllSearchIndexesResult encapsulates the result of a GetAllSearchIndexes operation.
+// Volatile: This API is subject to change at any time.
+type GetAllSearchIndexesResult struct {
+	Indexes []SearchIndex
+}
+
+// DropSearchIndexOptions encapsulates the parameters for a DropSearchIndex operation.
+// Volatile: This API is subject to change at any time.
+type DropSearchIndexOptions struct {
+	IndexName     string
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// DropSearchIndexResult encapsulates the result of a DropSearchIndex operation.
+// Volatile: This API is subject to change at any time.
+type DropSearchIndexResult struct {
+}
+
+// GetSearchIndexStatusOptions encapsulates the parameters for a GetSearchIndexStatus operation.
+// Volatile: This API is subject to change at any time.
+type GetSearchIndexStatusOptions struct {
+	IndexName     string
+	RetryStrategy RetryStrategy
+	Deadline      time.Time
+
+	// Volatile: Tracer API is subject to change.
+	TraceContext RequestSpanContext
+}
+
+// GetSearchIndexStatusResult encapsulates the result of a GetSearchIndexStatus operation.
+// Volatile: This API is subject to change at any time.
+type GetSearchIndexStatusResult struct {
+	// Partitions are the partitions that the index has been planned into, this is empty until
+	// the index has been planned.
+	Partitions []SearchIndexPartition
+	// Warnings are any problems that the server reported when planning the index.
+	Warnings []string
+}
+
+type jsonSearchIndexResponse struct {
+	IndexDef     SearchIndex            `json:"indexDef"`
+	PlanPIndexes []SearchIndexPartition `json:"planPIndexes"`
+	Warnings     []string               `json:"warnings"`
+}
+
+type jsonSearchIndexesResponse struct {
+	IndexDefs struct {
+		IndexDefs map[string]SearchIndex `json:"indexDefs"`
+	} `json:"indexDefs"`
+}
+
+// UpsertSearchIndex creates or updates a search index definition.
+func (sqc *searchQueryComponent) UpsertSearchIndex(opts UpsertSearchIndexOptions, cb UpsertSearchIndexCallback) (PendingOp, error) {
+	tracer := sqc.tracer.CreateOpTrace("UpsertSearchIndex", opts.TraceContext)
+	defer tracer.Finish()
+
+	indexName := opts.Index.Name
+	if indexName == "" {
+		return nil, wrapSearchError(nil, nil, "", nil, wrapError(errInvalidArgument, "index name cannot be empty"))
+	}
+	if opts.Index.Type == "" {
+		return nil, wrapSearchError(nil, nil, indexName, nil, wrapError(errInvalidArgument, "index type cannot be empty"))
+	}
+
+	body, err := json.Marshal(opts.Index)
+	if err != nil {
+		return nil, wrapSearchError(nil, nil, indexName, nil, wrapError(err, "failed to marshal index definition"))
+	}
+
+	ireq := newSearchIndexRequest("PUT", fmt.Sprintf("/api/index/%s", url.PathEscape(indexName)), body, false,
+		opts.Deadline, opts.RetryStrategy, tracer.RootContext())
+	ireq.ContentType = "application/json"
+	ireq.Headers = map[string]string{
+		"cache-control": "no-cache",
+	}
+
+	sqc.executeIndexRequest("UpsertSearchIndex", ireq, indexName, func(_ []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		cb(&UpsertSearchIndexResult{}, nil)
+	})
+
+	return ireq, nil
+}
+
+// GetSearchIndex fetches a search index definition.
+func (sqc *searchQueryComponent) GetSearchIndex(opts GetSearchIndexOptions, cb GetSearchIndexCallback) (PendingOp, error) {
+	tracer := sqc.tracer.CreateOpTrace("GetSearchIndex", opts.TraceContext)
+	defer tracer.Finish()
+
+	indexName := opts.IndexName
+	if indexName == "" {
+		return nil, wrapSearchError(nil, nil, "", nil, wrapError(errInvalidArgument, "index name cannot be empty"))
+	}
+
+	ireq := newSearchIndexRequest("GET", fmt.Sprintf("/api/index/%s", url.PathEscape(indexName)), nil, true,
+		opts.Deadline, opts.RetryStrategy, tracer.RootContext())
+
+	sqc.executeIndexRequest("GetSearchIndex", ireq, indexName, func(body []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		var resp jsonSearchIndexResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			cb(nil, wrapSearchError(ireq, nil, indexName, nil, wrapError(err, "failed to parse index definition")))
+			return
+		}
+
+		cb(&GetSearchIndexResult{
+			Index: resp.IndexDef,
+		}, nil)
+	})
+
+	return ireq, nil
+}
+
+// GetAllSearchIndexes fetches all of the search index definitions.
+func (sqc *searchQueryComponent) GetAllSearchIndexes(opts GetAllSearchIndexesOptions, cb GetAllSearchIndexesCallback) (PendingOp, error) {
+	tracer := sqc.tracer.CreateOpTrace("GetAllSearchIndexes", opts.TraceContext)
+	defer tracer.Finish()
+
+	ireq := newSearchIndexRequest("GET", "/api/index", nil, true, opts.Deadline, opts.RetryStrategy,
+		tracer.RootContext())
+
+	sqc.executeIndexRequest("GetAllSearchIndexes", ireq, "", func(body []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		var resp jsonSearchIndexesResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			cb(nil, wrapSearchError(ireq, nil, "", nil, wrapError(err, "failed to parse index definitions")))
+			return
+		}
+
+		indexes := make([]SearchIndex, 0, len(resp.IndexDefs.IndexDefs))
+		for _, index := range resp.IndexDefs.IndexDefs {
+			indexes = append(indexes, index)
+		}
+
+		cb(&GetAllSearchIndexesResult{
+			Indexes: indexes,
+		}, nil)
+	})
+
+	return ireq, nil
+}
+
+// DropSearchIndex deletes a search index definition.
+func (sqc *searchQueryComponent) DropSearchIndex(opts DropSearchIndexOptions, cb DropSearchIndexCallback) (PendingOp, error) {
+	tracer := sqc.tracer.CreateOpTrace("DropSearchIndex", opts.TraceContext)
+	defer tracer.Finish()
+
+	indexName := opts.IndexName
+	if indexName == "" {
+		return nil, wrapSearchError(nil, nil, "", nil, wrapError(errInvalidArgument, "index name cannot be empty"))
+	}
+
+	ireq := newSearchIndexRequest("DELETE", fmt.Sprintf("/api/index/%s", url.PathEscape(indexName)), nil, false,
+		opts.Deadline, opts.RetryStrategy, tracer.RootContext())
+	ireq.Headers = map[string]string{
+		"cache-control": "no-cache",
+	}
+
+	sqc.executeIndexRequest("DropSearchIndex", ireq, indexName, func(_ []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		cb(&DropSearchIndexResult{}, nil)
+	})
+
+	return ireq, nil
+}
+
+// GetSearchIndexStatus fetches the partitions that a search index has been planned into.
+func (sqc *searchQueryComponent) GetSearchIndexStatus(opts GetSearchIndexStatusOptions, cb GetSearchIndexStatusCallback) (PendingOp, error) {
+	tracer := sqc.tracer.CreateOpTrace("GetSearchIndexStatus", opts.TraceContext)
+	defer tracer.Finish()
+
+	indexName := opts.IndexName
+	if indexName == "" {
+		return nil, wrapSearchError(nil, nil, "", nil, wrapError(errInvalidArgument, "index name cannot be empty"))
+	}
+
+	ireq := newSearchIndexRequest("GET", fmt.Sprintf("/api/index/%s", url.PathEscape(indexName)), nil, true,
+		opts.Deadline, opts.RetryStrategy, tracer.RootContext())
+
+	sqc.executeIndexRequest("GetSearchIndexStatus", ireq, indexName, func(body []byte, err error) {
+		if err != nil {
+			cb(nil, err)
+			return
+		}
+
+		var resp jsonSearchIndexResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			cb(nil, wrapSearchError(ireq, nil, indexName, nil, wrapError(err, "failed to parse index status")))
+			return
+		}
+
+		cb(&GetSearchIndexStatusResult{
+			Partitions: resp.PlanPIndexes,
+			Warnings:   resp.Warnings,
+		}, nil)
+	})
+
+	return ireq, nil
+}
+
+func newSearchIndexRequest(method, path string, body []byte, idempotent bool, deadline time.Time,
+	retryStrat RetryStrategy, traceCtx RequestSpanContext) *httpRequest {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &httpRequest{
+		Service:          FtsService,
+		Method:           method,
+		Path:             path,
+		Body:             body,
+		IsIdempotent:     idempotent,
+		Deadline:         deadline,
+		RetryStrategy:    retryStrat,
+		RootTraceContext: traceCtx,
+		Context:          ctx,
+		CancelFunc:       cancel,
+	}
+}
+
+// executeIndexRequest dispatches an index management request, retrying it whilst the service is rate limiting us,
+// and invokes cb with the body of the successful response.
+func (sqc *searchQueryComponent) executeIndexRequest(opName string, ireq *httpRequest, indexName string,
+	cb func([]byte, error)) {
+	start := time.Now()
+
+	go func() {
+		defer ireq.CancelFunc()
+
+		for {
+			resp, err := sqc.httpComponent.DoInternalHTTPRequest(ireq, false)
+			if err != nil {
+				cb(nil, wrapSearchError(ireq, nil, indexName, nil, err))
+				return
+			}
+
+			if resp.StatusCode != 200 {
+				searchErr := parseSearchError(ireq, indexName, nil, resp)
+				if closeErr := resp.Body.Close(); closeErr != nil {
+					logDebugf("Failed to close search index response body: %s", closeErr)
+				}
+
+				if searchErr.HTTPResponseCode != 429 {
+					cb(nil, searchErr)
+					return
+				}
+
+				ireq.retryAfter = searchErr.RetryAfter
+				shouldRetry, retryTime := retryOrchMaybeRetry(ireq, SearchTooManyRequestsRetryReason)
+				ireq.retryAfter = 0
+				if !shouldRetry {
+					cb(nil, searchErr)
+					return
+				}
+
+				err := waitForHTTPRetry(ireq.Context, ireq, opName, ireq.Endpoint, retryTime, start)
+				if err != nil {
+					cb(nil, wrapSearchError(ireq, nil, indexName, nil, err))
+					return
+				}
+
+				continue
+			}
+
+			body, err := ioutil.ReadAll(resp.Body)
+			if closeErr := resp.Body.Close(); closeErr != nil {
+				logDebugf("Failed to close search index response body: %s", closeErr)
+			}
+			if err != nil {
+				cb(nil, wrapSearchError(ireq, resp, indexName, nil, err))
+				return
+			}
+
+			cb(body, nil)
+			return
+		}
+	}()
+}
@@ -0,0 +1,186 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestSearchIndexManagement() {
+	var lock sync.Mutex
+	indexes := make(map[string]json.RawMessage)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if r.URL.Path == "/api/index" {
+			resp := map[string]interface{}{
+				"status":    "ok",
+				"indexDefs": map[string]interface{}{"indexDefs": indexes},
+			}
+			suite.Assert().Nil(json.NewEncoder(w).Encode(resp))
+			return
+		}
+
+		name := r.URL.Path[len("/api/index/"):]
+		switch r.Method {
+		case "PUT":
+			suite.Assert().Equal("no-cache", r.Header.Get("Cache-Control"))
+			body, err := ioutil.ReadAll(r.Body)
+			suite.Assert().Nil(err)
+			indexes[name] = body
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		case "GET":
+			def, ok := indexes[name]
+			if !ok {
+				w.WriteHeader(400)
+				_, _ = w.Write([]byte("rest_index: GetIndex, req: " + name + ", err: index not found\n"))
+				return
+			}
+			resp := map[string]interface{}{
+				"status":   "ok",
+				"indexDef": def,
+				"planPIndexes": []interface{}{map[string]interface{}{
+					"name":             name + "_0",
+					"indexName":        name,
+					"sourcePartitions": "0,1",
+					"nodes": map[string]interface{}{
+						"node1": map[string]interface{}{"canRead": true, "canWrite": true, "priority": 0},
+					},
+				}},
+				"warnings": []string{"not enough nodes"},
+			}
+			suite.Assert().Nil(json.NewEncoder(w).Encode(resp))
+		case "DELETE":
+			delete(indexes, name)
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		}
+	}))
+	defer srv.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, ftsEpList: []string{srv.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	httpCpt := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, tracer)
+	searchCpt := newSearchQueryComponent(httpCpt, tracer)
+
+	index := SearchIndex{
+		Name:       "idx",
+		Type:       "fulltext-index",
+		SourceName: "default",
+		SourceType: "couchbase",
+		Params:     map[string]interface{}{"doc_config": map[string]interface{}{"mode": "type_field"}},
+	}
+
+	upsertCh := make(chan error, 1)
+	_, err := searchCpt.UpsertSearchIndex(UpsertSearchIndexOptions{
+		Index:    index,
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *UpsertSearchIndexResult, err error) {
+		upsertCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-upsertCh)
+
+	getCh := make(chan *GetSearchIndexResult, 1)
+	_, err = searchCpt.GetSearchIndex(GetSearchIndexOptions{
+		IndexName: "idx",
+		Deadline:  time.Now().Add(5 * time.Second),
+	}, func(res *GetSearchIndexResult, err error) {
+		suite.Assert().Nil(err)
+		getCh <- res
+	})
+	suite.Require().Nil(err)
+	getRes := <-getCh
+	suite.Require().NotNil(getRes)
+	suite.Assert().Equal(index, getRes.Index)
+
+	allCh := make(chan *GetAllSearchIndexesResult, 1)
+	_, err = searchCpt.GetAllSearchIndexes(GetAllSearchIndexesOptions{
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetAllSearchIndexesResult, err error) {
+		suite.Assert().Nil(err)
+		allCh <- res
+	})
+	suite.Require().Nil(err)
+	allRes := <-allCh
+	suite.Require().NotNil(allRes)
+	suite.Assert().Equal([]SearchIndex{index}, allRes.Indexes)
+
+	statusCh := make(chan *GetSearchIndexStatusResult, 1)
+	_, err = searchCpt.GetSearchIndexStatus(GetSearchIndexStatusOptions{
+		IndexName: "idx",
+		Deadline:  time.Now().Add(5 * time.Second),
+	}, func(res *GetSearchIndexStatusResult, err error) {
+		suite.Assert().Nil(err)
+		statusCh <- res
+	})
+	suite.Require().Nil(err)
+	statusRes := <-statusCh
+	suite.Require().NotNil(statusRes)
+	suite.Require().Len(statusRes.Partitions, 1)
+	suite.Assert().Equal("0,1", statusRes.Partitions[0].SourcePartitions)
+	suite.Assert().Equal(SearchIndexPartitionNode{CanRead: true, CanWrite: true}, statusRes.Partitions[0].Nodes["node1"])
+	suite.Assert().Equal([]string{"not enough nodes"}, statusRes.Warnings)
+
+	dropCh := make(chan error, 1)
+	_, err = searchCpt.DropSearchIndex(DropSearchIndexOptions{
+		IndexName: "idx",
+		Deadline:  time.Now().Add(5 * time.Second),
+	}, func(res *DropSearchIndexResult, err error) {
+		dropCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-dropCh)
+
+	errCh := make(chan error, 1)
+	_, err = searchCpt.GetSearchIndex(GetSearchIndexOptions{
+		IndexName: "idx",
+		Deadline:  time.Now().Add(5 * time.Second),
+	}, func(res *GetSearchIndexResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	err = <-errCh
+	suite.Assert().True(errors.Is(err, ErrIndexNotFound), err)
+
+	_, err = searchCpt.DropSearchIndex(DropSearchIndexOptions{}, func(*DropSearchIndexResult, error) {})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
+
+func (suite *UnitTestSuite) TestSearchIndexRateLimitedWithoutDeadline() {
+	var puts uint32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddUint32(&puts, 1) == 1 {
+			w.WriteHeader(429)
+			_, _ = w.Write([]byte("rest_auth: preparePerms, err: num_concurrent_requests, limit: 1"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, ftsEpList: []string{srv.URL}})
+	tracer := newTracerComponent(&noopTracer{}, "", true)
+	httpCpt := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, tracer)
+	searchCpt := newSearchQueryComponent(httpCpt, tracer)
+
+	// Without a deadline the request must wait for the retry, rather than timing out straight away.
+	upsertCh := make(chan error, 1)
+	_, err := searchCpt.UpsertSearchIndex(UpsertSearchIndexOptions{
+		Index:         SearchIndex{Name: "idx", Type: "fulltext-index"},
+		RetryStrategy: NewBestEffortRetryStrategy(nil),
+	}, func(res *UpsertSearchIndexResult, err error) {
+		upsertCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-upsertCh)
+	suite.Assert().Equal(uint32(2), atomic.LoadUint32(&puts))
+}
@@ -0,0 +1,170 @@
+package gocbcore
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// VbucketHighSeqno is the most recently observed high seqno of an active vbucket.
+type VbucketHighSeqno struct {
+	VbID  uint16
+	SeqNo SeqNo
+
+	// Delta is how far the high seqno advanced between the two most recent polls, it is zero for the first poll and
+	// whenever the seqno went backwards, such as after a failover.
+	Delta uint64
+
+	// Time is when the high seqno was fetched.
+	Time time.Time
+}
+
+type vbucketSeqnoFetcher interface {
+	GetVbucketSeqnos(serverIdx int, state memd.VbucketState, opts GetVbucketSeqnoOptions,
+		cb GetVBucketSeqnosCallback) (PendingOp, error)
+}
+
+type pipelineCounter interface {
+	NumPipelines() int
+}
+
+type seqnoMonitorComponent struct {
+	fetcher  vbucketSeqnoFetcher
+	servers  pipelineCounter
+	interval time.Duration
+	logCtx   logContext
+
+	lock   sync.Mutex
+	seqnos map[uint16]VbucketHighSeqno
+
+	stopSig chan struct{}
+	doneSig chan struct{}
+}
+
+func newSeqnoMonitorComponent(fetcher vbucketSeqnoFetcher, servers pipelineCounter,
+	interval time.Duration, logCtx logContext) *seqnoMonitorComponent {
+	return &seqnoMonitorComponent{
+		fetcher:  fetcher,
+		servers:  servers,
+		interval: interval,
+		logCtx:   logCtx,
+		seqnos:   make(map[uint16]VbucketHighSeqno),
+		stopSig:  make(chan struct{}),
+		doneSig:  make(chan struct{}),
+	}
+}
+
+func (smc *seqnoMonitorComponent) Start() {
+	go smc.loop()
+}
+
+func (smc *seqnoMonitorComponent) Stop() {
+	close(smc.stopSig)
+	<-smc.doneSig
+}
+
+// HighSeqnos returns the high seqno of every vbucket seen so far, ordered by vbucket ID.
+func (smc *seqnoMonitorComponent) HighSeqnos() []VbucketHighSeqno {
+	smc.lock.Lock()
+	seqnos := make([]VbucketHighSeqno, 0, len(smc.seqnos))
+	for _, seqno := range smc.seqnos {
+		seqnos = append(seqnos, seqno)
+	}
+	smc.lock.Unlock()
+
+	sort.Slice(seqnos, func(i, j int) bool {
+		return seqnos[i].VbID < seqnos[j].VbID
+	})
+
+	return seqnos
+}
+
+func (smc *seqnoMonitorComponent) loop() {
+	defer close(smc.doneSig)
+
+	ticker := time.NewTicker(smc.interval)
+	defer ticker.Stop()
+
+	for {
+		smc.poll()
+
+		select {
+		case <-smc.stopSig:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the active vbucket seqnos from every server, any server which has not responded by the time the next
+// poll is due is skipped for this round.
+func (smc *seqnoMonitorComponent) poll() {
+	numServers := smc.servers.NumPipelines()
+	if numServers == 0 {
+		return
+	}
+
+	type fetchResult struct {
+		entries []VbSeqNoEntry
+		err     error
+	}
+	resultCh := make(chan fetchResult, numServers)
+
+	var ops []PendingOp
+	for srvIdx := 1; srvIdx <= numServers; srvIdx++ {
+		op, err := smc.fetcher.GetVbucketSeqnos(srvIdx, memd.VbucketStateActive, GetVbucketSeqnoOptions{},
+			func(entries []VbSeqNoEntry, err error) {
+				resultCh <- fetchResult{entries: entries, err: err}
+			})
+		if err != nil {
+			smc.logCtx.logDebugf("Failed to fetch high seqnos from server %d (%s)", srvIdx, err)
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	timeoutTmr := AcquireTimer(smc.interval)
+	for range ops {
+		select {
+		case res := <-resultCh:
+			if res.err != nil {
+				smc.logCtx.logDebugf("Failed to fetch high seqnos (%s)", res.err)
+				continue
+			}
+			smc.record(res.entries, time.Now())
+		case <-timeoutTmr.C:
+			ReleaseTimer(timeoutTmr, true)
+			for _, op := range ops {
+				op.Cancel()
+			}
+			return
+		case <-smc.stopSig:
+			ReleaseTimer(timeoutTmr, false)
+			for _, op := range ops {
+				op.Cancel()
+			}
+			return
+		}
+	}
+	ReleaseTimer(timeoutTmr, false)
+}
+
+func (smc *seqnoMonitorComponent) record(entries []VbSeqNoEntry, now time.Time) {
+	smc.lock.Lock()
+	defer smc.lock.Unlock()
+
+	for _, entry := range entries {
+		seqno := VbucketHighSeqno{
+			VbID:  entry.VbID,
+			SeqNo: entry.SeqNo,
+			Time:  now,
+		}
+		if prev, ok := smc.seqnos[entry.VbID]; ok && entry.SeqNo >= prev.SeqNo {
+			seqno.Delta = uint64(entry.SeqNo - prev.SeqNo)
+		}
+
+		smc.seqnos[entry.VbID] = seqno
+	}
+}
@@ -0,0 +1,76 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+type testSeqnoFetcher struct {
+	lock   sync.Mutex
+	seqnos map[int][]VbSeqNoEntry
+	hang   map[int]bool
+}
+
+func (f *testSeqnoFetcher) NumPipelines() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.seqnos)
+}
+
+func (f *testSeqnoFetcher) GetVbucketSeqnos(serverIdx int, state memd.VbucketState, opts GetVbucketSeqnoOptions,
+	cb GetVBucketSeqnosCallback) (PendingOp, error) {
+	f.lock.Lock()
+	entries := append([]VbSeqNoEntry(nil), f.seqnos[serverIdx]...)
+	hang := f.hang[serverIdx]
+	f.lock.Unlock()
+
+	if !hang {
+		go cb(entries, nil)
+	}
+
+	return &multiPendingOp{}, nil
+}
+
+func (f *testSeqnoFetcher) set(serverIdx int, entries []VbSeqNoEntry) {
+	f.lock.Lock()
+	f.seqnos[serverIdx] = entries
+	f.lock.Unlock()
+}
+
+func (suite *UnitTestSuite) TestSeqnoMonitor() {
+	fetcher := &testSeqnoFetcher{
+		seqnos: map[int][]VbSeqNoEntry{
+			1: {{VbID: 0, SeqNo: 10}, {VbID: 2, SeqNo: 5}},
+			2: {{VbID: 1, SeqNo: 7}},
+			3: {{VbID: 3, SeqNo: 1}},
+		},
+		hang: map[int]bool{3: true},
+	}
+	smc := newSeqnoMonitorComponent(fetcher, fetcher, 20*time.Millisecond, nil)
+
+	// Server 3 never responds, so only its vbuckets are missing.
+	smc.poll()
+	seqnos := smc.HighSeqnos()
+	suite.Require().Len(seqnos, 3)
+	suite.Assert().Equal(uint16(0), seqnos[0].VbID)
+	suite.Assert().Equal(SeqNo(10), seqnos[0].SeqNo)
+	suite.Assert().Zero(seqnos[0].Delta)
+	suite.Assert().Equal(uint16(1), seqnos[1].VbID)
+	suite.Assert().Equal(uint16(2), seqnos[2].VbID)
+
+	fetcher.set(1, []VbSeqNoEntry{{VbID: 0, SeqNo: 25}, {VbID: 2, SeqNo: 3}})
+	smc.poll()
+	seqnos = smc.HighSeqnos()
+	suite.Require().Len(seqnos, 3)
+	suite.Assert().Equal(SeqNo(25), seqnos[0].SeqNo)
+	suite.Assert().Equal(uint64(15), seqnos[0].Delta)
+	suite.Assert().Zero(seqnos[1].Delta)
+	// A seqno going backwards, such as after a failover, isn't reported as progress.
+	suite.Assert().Equal(SeqNo(3), seqnos[2].SeqNo)
+	suite.Assert().Zero(seqnos[2].Delta)
+
+	smc.Start()
+	smc.Stop()
+}
@@ -11,18 +11,98 @@ type statsComponent struct {
 	kvMux                *kvMux
 	tracer               *tracerComponent
 	defaultRetryStrategy RetryStrategy
+	timeouts             *kvTimeoutComponent
 }
 
-func newStatsComponent(kvMux *kvMux, defaultRetry RetryStrategy, tracer *tracerComponent) *statsComponent {
+func newStatsComponent(kvMux *kvMux, defaultRetry RetryStrategy, tracer *tracerComponent,
+	timeouts *kvTimeoutComponent) *statsComponent {
 	return &statsComponent{
 		kvMux:                kvMux,
 		tracer:               tracer,
 		defaultRetryStrategy: defaultRetry,
+		timeouts:             timeouts,
 	}
 }
 
 func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp, error) {
-	tracer := sc.tracer.CreateOpTrace("Stats", opts.TraceContext)
+	stats := make(map[string]SingleServerStats)
+	var statsLock sync.Mutex
+
+	// Fetch the specific stats entry for a server, creating a new entry if we did not previously have one.
+	getServerStats := func(serverAddress string) SingleServerStats {
+		curStats, ok := stats[serverAddress]
+		if !ok {
+			curStats = SingleServerStats{
+				Stats: make(map[string]string),
+			}
+		}
+		return curStats
+	}
+
+	return sc.stream("Stats", opts, func(serverAddress string, key, value []byte) {
+		statsLock.Lock()
+		defer statsLock.Unlock()
+
+		curStats := getServerStats(serverAddress)
+		curStats.StatsKeys = append(curStats.StatsKeys, key)
+		curStats.StatsChunks = append(curStats.StatsChunks, value)
+		if len(key) == 0 {
+			// We do this for the sake of consistency.
+			curStats.Stats[""] += string(value)
+		} else {
+			// Add the stat for this server to the list of stats.
+			curStats.Stats[string(key)] += string(value)
+		}
+		// If we don't reassign this then we lose any values added to StatsKeys and StatsChunks.
+		stats[serverAddress] = curStats
+	}, func(serverAddress string, err error) {
+		statsLock.Lock()
+		defer statsLock.Unlock()
+
+		curStats := getServerStats(serverAddress)
+		// Store the first (and hopefully only) error into the Error field of this
+		// server's stats entry.
+		if curStats.Error == nil {
+			curStats.Error = err
+		} else {
+			sc.kvMux.logCtx.logDebugf("Got additional error for stats: %s: %v", serverAddress, err)
+		}
+		stats[serverAddress] = curStats
+	}, func() {
+		cb(&StatsResult{
+			Servers: stats,
+		}, nil)
+	})
+}
+
+func (sc *statsComponent) StatsStream(opts StatsOptions, valueCb StatsStreamValueCallback,
+	cb StatsStreamCallback) (PendingOp, error) {
+	errs := make(map[string]error)
+	var streamLock sync.Mutex
+
+	return sc.stream("StatsStream", opts, func(serverAddress string, key, value []byte) {
+		streamLock.Lock()
+		valueCb(serverAddress, key, value)
+		streamLock.Unlock()
+	}, func(serverAddress string, err error) {
+		streamLock.Lock()
+		if _, ok := errs[serverAddress]; !ok {
+			errs[serverAddress] = err
+		}
+		streamLock.Unlock()
+	}, func() {
+		cb(&StatsStreamResult{
+			Errors: errs,
+		}, nil)
+	})
+}
+
+// stream sends the stats request to each targeted server, invoking valueCb for every stat as it arrives and errCb
+// for any server that fails. completeCb is invoked once every server has finished.
+func (sc *statsComponent) stream(operationName string, opts StatsOptions,
+	valueCb func(serverAddress string, key, value []byte), errCb func(serverAddress string, err error),
+	completeCb func()) (PendingOp, error) {
+	tracer := sc.tracer.CreateOpTrace(operationName, opts.TraceContext)
 
 	iter, err := sc.kvMux.PipelineSnapshot()
 	if err != nil {
@@ -30,8 +110,8 @@ func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp,
 		return nil, err
 	}
 
-	stats := make(map[string]SingleServerStats)
-	var statsLock sync.Mutex
+	// Every server shares the same deadline.
+	opts.Deadline = sc.timeouts.Deadline(opts.Deadline)
 
 	op := new(multiPendingOp)
 	op.isIdempotent = true
@@ -59,13 +139,11 @@ func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp,
 		return nil, errInvalidArgument
 	}
 
-	opHandledLocked := func() {
+	opHandled := func() {
 		completed := op.IncrementCompletedOps()
 		if expected-completed == 0 {
 			tracer.Finish()
-			cb(&StatsResult{
-				Servers: stats,
-			}, nil)
+			completeCb()
 		}
 	}
 
@@ -84,29 +162,9 @@ func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp,
 		serverAddress := pipeline.Address()
 
 		handler := func(resp *memdQResponse, req *memdQRequest, err error) {
-			statsLock.Lock()
-			defer statsLock.Unlock()
-
-			// Fetch the specific stats key for this server.  Creating a new entry
-			// for the server if we did not previously have one.
-			curStats, ok := stats[serverAddress]
-			if !ok {
-				stats[serverAddress] = SingleServerStats{
-					Stats: make(map[string]string),
-				}
-				curStats = stats[serverAddress]
-			}
-
 			if err != nil {
-				// Store the first (and hopefully only) error into the Error field of this
-				// server's stats entry.
-				if curStats.Error == nil {
-					curStats.Error = err
-				} else {
-					logDebugf("Got additional error for stats: %s: %v", serverAddress, err)
-				}
-
-				opHandledLocked()
+				errCb(serverAddress, err)
+				opHandled()
 
 				return
 			}
@@ -118,23 +176,13 @@ func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp,
 				// it from the pending ops list.  To ensure we do not race multiple cancels,
 				// we only handle it as completed the one time cancellation succeeds.
 				if req.internalCancel(err) {
-					opHandledLocked()
+					opHandled()
 				}
 
 				return
 			}
 
-			curStats.StatsKeys = append(curStats.StatsKeys, resp.Key)
-			curStats.StatsChunks = append(curStats.StatsChunks, resp.Value)
-			if len(resp.Key) == 0 {
-				// We do this for the sake of consistency.
-				curStats.Stats[""] += string(resp.Value)
-			} else {
-				// Add the stat for this server to the list of stats.
-				curStats.Stats[string(resp.Key)] += string(resp.Value)
-			}
-			// If we don't reassign this then we lose any values added to StatsKeys and StatsChunks.
-			stats[serverAddress] = curStats
+			valueCb(serverAddress, resp.Key, resp.Value)
 		}
 
 		req := &memdQRequest{
@@ -151,38 +199,18 @@ func (sc *statsComponent) Stats(opts StatsOptions, cb StatsCallback) (PendingOp,
 			Callback:         handler,
 			RootTraceContext: tracer.RootContext(),
 			RetryStrategy:    opts.RetryStrategy,
+			Deadline:         sc.timeouts.Deadline(opts.Deadline),
 		}
 
 		curOp, err := sc.kvMux.DispatchDirectToAddress(req, pipeline)
 		if err != nil {
-			statsLock.Lock()
-			stats[serverAddress] = SingleServerStats{
-				Error: err,
-			}
-			opHandledLocked()
-			statsLock.Unlock()
+			errCb(serverAddress, err)
+			opHandled()
 
 			continue
 		}
 
-		if !opts.Deadline.IsZero() {
-			start := time.Now()
-			req.SetTimer(time.AfterFunc(opts.Deadline.Sub(start), func() {
-				connInfo := req.ConnectionInfo()
-				count, reasons := req.Retries()
-				req.cancelWithCallback(&TimeoutError{
-					InnerError:         errAmbiguousTimeout,
-					OperationID:        "Unlock",
-					Opaque:             req.Identifier(),
-					TimeObserved:       time.Since(start),
-					RetryReasons:       reasons,
-					RetryAttempts:      count,
-					LastDispatchedTo:   connInfo.lastDispatchedTo,
-					LastDispatchedFrom: connInfo.lastDispatchedFrom,
-					LastConnectionID:   connInfo.lastConnectionID,
-				})
-			}))
-		}
+		sc.timeouts.Track(req, req.Deadline, operationName, errAmbiguousTimeout)
 
 		op.ops = append(op.ops, curOp)
 	}
@@ -230,3 +258,9 @@ type StatsOptions struct {
 type StatsResult struct {
 	Servers map[string]SingleServerStats
 }
+
+// StatsStreamResult encapsulates the result of a StatsStream operation.
+type StatsStreamResult struct {
+	// Errors contains the first error encountered by each server which failed to stream its stats.
+	Errors map[string]error
+}
@@ -0,0 +1,182 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// StatsGroupVbucketDetails is the stats key for detailed per-vbucket stats.
+	StatsGroupVbucketDetails = "vbucket-details"
+
+	// StatsGroupDcp is the stats key for DCP connection and stream stats.
+	StatsGroupDcp = "dcp"
+
+	// StatsGroupTimings is the stats key for per-command latency histograms.
+	StatsGroupTimings = "timings"
+)
+
+// VbucketDetailsStatsKey returns the stats key to use to fetch vbucket-details for a single vbucket.
+func VbucketDetailsStatsKey(vbID uint16) string {
+	return fmt.Sprintf("%s %d", StatsGroupVbucketDetails, vbID)
+}
+
+// VbucketDetails contains the stats returned by vbucket-details for a single vbucket.
+type VbucketDetails struct {
+	VbID       uint16
+	State      string
+	UUID       uint64
+	HighSeqno  uint64
+	PurgeSeqno uint64
+	NumItems   uint64
+	MaxCas     uint64
+
+	// Other contains every stat returned for the vbucket which is not parsed into a field above, keyed by the stat
+	// name without the vbucket prefix.
+	Other map[string]string
+}
+
+// ParseVbucketDetailsStats parses the stats returned by a server for the vbucket-details group, keyed by vbucket ID.
+// Volatile: This API is subject to change at any time.
+func ParseVbucketDetailsStats(stats map[string]string) (map[uint16]*VbucketDetails, error) {
+	vbuckets := make(map[uint16]*VbucketDetails)
+	getVbucket := func(vbID uint16) *VbucketDetails {
+		details, ok := vbuckets[vbID]
+		if !ok {
+			details = &VbucketDetails{
+				VbID:  vbID,
+				Other: make(map[string]string),
+			}
+			vbuckets[vbID] = details
+		}
+		return details
+	}
+
+	for key, value := range stats {
+		if !strings.HasPrefix(key, "vb_") {
+			continue
+		}
+
+		vbPart := key[3:]
+		statName := ""
+		if idx := strings.IndexByte(vbPart, ':'); idx >= 0 {
+			statName = vbPart[idx+1:]
+			vbPart = vbPart[:idx]
+		}
+
+		vbID, err := strconv.ParseUint(vbPart, 10, 16)
+		if err != nil {
+			return nil, wrapError(errParsingFailure, fmt.Sprintf("invalid vbucket in stat %s", key))
+		}
+		details := getVbucket(uint16(vbID))
+
+		var field *uint64
+		switch statName {
+		case "":
+			details.State = value
+			continue
+		case "uuid":
+			field = &details.UUID
+		case "high_seqno":
+			field = &details.HighSeqno
+		case "purge_seqno":
+			field = &details.PurgeSeqno
+		case "num_items":
+			field = &details.NumItems
+		case "max_cas":
+			field = &details.MaxCas
+		default:
+			details.Other[statName] = value
+			continue
+		}
+
+		*field, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, wrapError(errParsingFailure, fmt.Sprintf("invalid value for stat %s", key))
+		}
+	}
+
+	return vbuckets, nil
+}
+
+// DcpConnectionStats contains the stats for a single DCP connection.
+type DcpConnectionStats struct {
+	Name string
+
+	// Stats contains the connection and stream level stats for the connection, keyed by the stat name without the
+	// connection prefix.
+	Stats map[string]string
+}
+
+// DcpStats contains the stats returned by a server for the dcp group.
+type DcpStats struct {
+	// Global contains the stats which are not specific to a single connection.
+	Global map[string]string
+
+	// Connections contains the stats for each DCP connection, keyed by connection name.
+	Connections map[string]*DcpConnectionStats
+}
+
+// ParseDcpStats parses the stats returned by a server for the dcp group.
+// Volatile: This API is subject to change at any time.
+func ParseDcpStats(stats map[string]string) *DcpStats {
+	const connPrefix = "eq_dcpq:"
+
+	dcpStats := &DcpStats{
+		Global:      make(map[string]string),
+		Connections: make(map[string]*DcpConnectionStats),
+	}
+
+	for key, value := range stats {
+		// Connection names can themselves contain colons so the stat name is everything after the last one.
+		idx := strings.LastIndexByte(key, ':')
+		if !strings.HasPrefix(key, connPrefix) || idx < len(connPrefix) {
+			dcpStats.Global[key] = value
+			continue
+		}
+
+		name := key[len(connPrefix):idx]
+		conn, ok := dcpStats.Connections[name]
+		if !ok {
+			conn = &DcpConnectionStats{
+				Name:  name,
+				Stats: make(map[string]string),
+			}
+			dcpStats.Connections[name] = conn
+		}
+		conn.Stats[key[idx+1:]] = value
+	}
+
+	return dcpStats
+}
+
+// TimingHistogram is the latency histogram for a single command as returned by the timings group.
+type TimingHistogram struct {
+	BucketsLow uint64 `json:"bucketsLow"`
+
+	// Data contains an entry per bucket of [upper bound in microseconds, count, percentile].
+	Data  [][]float64 `json:"data"`
+	Total uint64      `json:"total"`
+}
+
+// ParseTimingsStats parses the stats returned by a server for the timings group, keyed by command. Only servers
+// which return histograms in JSON format are supported, any other stats are ignored.
+// Volatile: This API is subject to change at any time.
+func ParseTimingsStats(stats map[string]string) (map[string]*TimingHistogram, error) {
+	timings := make(map[string]*TimingHistogram)
+	for key, value := range stats {
+		if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+			continue
+		}
+
+		var histogram TimingHistogram
+		if err := json.Unmarshal([]byte(value), &histogram); err != nil {
+			return nil, wrapError(errParsingFailure, fmt.Sprintf("invalid histogram for %s: %v", key, err))
+		}
+		timings[key] = &histogram
+	}
+
+	return timings, nil
+}
@@ -0,0 +1,68 @@
+package gocbcore
+
+import "errors"
+
+func (suite *UnitTestSuite) TestParseVbucketDetailsStats() {
+	vbuckets, err := ParseVbucketDetailsStats(map[string]string{
+		"vb_0":              "active",
+		"vb_0:high_seqno":   "12",
+		"vb_0:uuid":         "123456789",
+		"vb_0:num_items":    "3",
+		"vb_0:ht_size":      "47",
+		"vb_12":             "replica",
+		"vb_12:max_cas":     "1604486848000000000",
+		"vb_12:purge_seqno": "4",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(vbuckets, 2)
+
+	vb0 := vbuckets[0]
+	suite.Assert().Equal("active", vb0.State)
+	suite.Assert().Equal(uint64(12), vb0.HighSeqno)
+	suite.Assert().Equal(uint64(123456789), vb0.UUID)
+	suite.Assert().Equal(uint64(3), vb0.NumItems)
+	suite.Assert().Equal("47", vb0.Other["ht_size"])
+
+	vb12 := vbuckets[12]
+	suite.Assert().Equal("replica", vb12.State)
+	suite.Assert().Equal(uint64(1604486848000000000), vb12.MaxCas)
+	suite.Assert().Equal(uint64(4), vb12.PurgeSeqno)
+
+	_, err = ParseVbucketDetailsStats(map[string]string{
+		"vb_0:high_seqno": "notanumber",
+	})
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+}
+
+func (suite *UnitTestSuite) TestParseDcpStats() {
+	stats := ParseDcpStats(map[string]string{
+		"ep_dcp_count": "1",
+		"eq_dcpq:replication:ns_1@a->ns_1@b:type":            "producer",
+		"eq_dcpq:replication:ns_1@a->ns_1@b:stream_0_opaque": "5",
+		"eq_dcpq:myconn:paused":                              "false",
+	})
+
+	suite.Assert().Equal("1", stats.Global["ep_dcp_count"])
+	suite.Require().Len(stats.Connections, 2)
+
+	repl := stats.Connections["replication:ns_1@a->ns_1@b"]
+	suite.Require().NotNil(repl)
+	suite.Assert().Equal("producer", repl.Stats["type"])
+	suite.Assert().Equal("5", repl.Stats["stream_0_opaque"])
+	suite.Assert().Equal("false", stats.Connections["myconn"].Stats["paused"])
+}
+
+func (suite *UnitTestSuite) TestParseTimingsStats() {
+	timings, err := ParseTimingsStats(map[string]string{
+		"GET_cmd":   `{"bucketsLow":0,"data":[[1,10,50.0],[2,10,100.0]],"total":20}`,
+		"cmd_get_0": "10",
+	})
+	suite.Require().Nil(err)
+	suite.Require().Len(timings, 1)
+
+	get := timings["GET_cmd"]
+	suite.Require().NotNil(get)
+	suite.Assert().Equal(uint64(20), get.Total)
+	suite.Require().Len(get.Data, 2)
+	suite.Assert().Equal(100.0, get.Data[1][2])
+}
@@ -0,0 +1,106 @@
+package gocbcore
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// SubdocMutateMacro is a macro which the server expands when it is used as the value of a sub-document mutation
+// with the memd.SubdocFlagExpandMacros flag. Macros can only be expanded within xattr paths.
+type SubdocMutateMacro string
+
+const (
+	// SubdocMutateMacroCas expands to the CAS of the mutation.
+	SubdocMutateMacroCas = SubdocMutateMacro("${Mutation.CAS}")
+
+	// SubdocMutateMacroSeqNo expands to the sequence number of the mutation.
+	SubdocMutateMacroSeqNo = SubdocMutateMacro("${Mutation.seqno}")
+
+	// SubdocMutateMacroValueCrc32c expands to the CRC32C checksum of the document body.
+	SubdocMutateMacroValueCrc32c = SubdocMutateMacro("${Mutation.value_crc32c}")
+
+	// SubdocMutateMacroDocumentCas expands to the CAS of the document before the mutation.
+	SubdocMutateMacroDocumentCas = SubdocMutateMacro("${$document.CAS}")
+
+	// SubdocMutateMacroDocumentExpiry expands to the expiry of the document before the mutation.
+	SubdocMutateMacroDocumentExpiry = SubdocMutateMacro("${$document.exptime}")
+
+	// SubdocMutateMacroDocumentRevID expands to the revision id of the document before the mutation.
+	SubdocMutateMacroDocumentRevID = SubdocMutateMacro("${$document.revid}")
+)
+
+// Value returns the macro encoded as a JSON string, ready to be used as the value of a sub-document operation.
+func (m SubdocMutateMacro) Value() []byte {
+	return []byte(strconv.Quote(string(m)))
+}
+
+// NewSubDocMacroOp returns a sub-document operation which sets the xattr at path to the expansion of macro.
+// Any flags provided are applied in addition to those required for macro expansion.
+// Volatile: This API is subject to change at any time.
+func NewSubDocMacroOp(op memd.SubDocOpType, path string, macro SubdocMutateMacro, flags memd.SubdocFlag) SubDocOp {
+	return SubDocOp{
+		Op:    op,
+		Flags: flags | memd.SubdocFlagXattrPath | memd.SubdocFlagExpandMacros,
+		Path:  path,
+		Value: macro.Value(),
+	}
+}
+
+func decodeSubdocMacroHex(value []byte) (uint64, error) {
+	var hexStr string
+	if err := json.Unmarshal(value, &hexStr); err != nil {
+		return 0, wrapError(errParsingFailure, fmt.Sprintf("macro value is not a string: %v", err))
+	}
+
+	parsed, err := strconv.ParseUint(strings.TrimPrefix(hexStr, "0x"), 16, 64)
+	if err != nil {
+		return 0, wrapError(errParsingFailure, fmt.Sprintf("macro value is not a hex number: %v", err))
+	}
+
+	return parsed, nil
+}
+
+// DecodeSubdocMacroCas decodes the value of an xattr which was set using SubdocMutateMacroCas.
+// Volatile: This API is subject to change at any time.
+func DecodeSubdocMacroCas(value []byte) (Cas, error) {
+	parsed, err := decodeSubdocMacroHex(value)
+	if err != nil {
+		return 0, err
+	}
+
+	// The server writes the CAS out in network byte order so we need to swap it back.
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], parsed)
+	return Cas(binary.LittleEndian.Uint64(buf[:])), nil
+}
+
+// DecodeSubdocMacroSeqNo decodes the value of an xattr which was set using SubdocMutateMacroSeqNo.
+// Volatile: This API is subject to change at any time.
+func DecodeSubdocMacroSeqNo(value []byte) (SeqNo, error) {
+	parsed, err := decodeSubdocMacroHex(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return SeqNo(parsed), nil
+}
+
+// DecodeSubdocMacroValueCrc32c decodes the value of an xattr which was set using SubdocMutateMacroValueCrc32c.
+// Volatile: This API is subject to change at any time.
+func DecodeSubdocMacroValueCrc32c(value []byte) (uint32, error) {
+	parsed, err := decodeSubdocMacroHex(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if parsed > 0xFFFFFFFF {
+		return 0, wrapError(errParsingFailure, "crc32c macro value is too large")
+	}
+
+	return uint32(parsed), nil
+}
@@ -0,0 +1,36 @@
+package gocbcore
+
+import (
+	"errors"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+func (suite *UnitTestSuite) TestSubdocMacroOp() {
+	op := NewSubDocMacroOp(memd.SubDocOpDictSet, "txn.cas", SubdocMutateMacroCas, memd.SubdocFlagMkDirP)
+
+	suite.Assert().Equal(memd.SubDocOpDictSet, op.Op)
+	suite.Assert().Equal("txn.cas", op.Path)
+	suite.Assert().Equal(`"${Mutation.CAS}"`, string(op.Value))
+	suite.Assert().Equal(memd.SubdocFlagMkDirP|memd.SubdocFlagXattrPath|memd.SubdocFlagExpandMacros, op.Flags)
+}
+
+func (suite *UnitTestSuite) TestDecodeSubdocMacros() {
+	cas, err := DecodeSubdocMacroCas([]byte(`"0x000058a73ebb1615"`))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(Cas(0x1516bb3ea7580000), cas)
+
+	seqNo, err := DecodeSubdocMacroSeqNo([]byte(`"0x000000000000002a"`))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(SeqNo(42), seqNo)
+
+	crc, err := DecodeSubdocMacroValueCrc32c([]byte(`"0x297bd0aa"`))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(uint32(0x297bd0aa), crc)
+
+	_, err = DecodeSubdocMacroSeqNo([]byte(`42`))
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+
+	_, err = DecodeSubdocMacroSeqNo([]byte(`"0xnothex"`))
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+}
@@ -0,0 +1,172 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+const (
+	// SubdocVattrDocument is the virtual xattr which holds the metadata of a document.
+	SubdocVattrDocument = "$document"
+
+	// SubdocVattrVbucket is the virtual xattr which holds the state of the vbucket which a document belongs to.
+	SubdocVattrVbucket = "$vbucket"
+)
+
+// NewSubDocVattrOp returns a sub-document operation which reads the virtual xattr at path, this can be either a
+// whole virtual xattr such as SubdocVattrDocument or a single field within one such as $document.revid.
+// Volatile: This API is subject to change at any time.
+func NewSubDocVattrOp(path string) SubDocOp {
+	return SubDocOp{
+		Op:    memd.SubDocOpGet,
+		Flags: memd.SubdocFlagXattrPath,
+		Path:  path,
+	}
+}
+
+// DocumentVattr is the parsed value of the $document virtual xattr.
+// Volatile: This API is subject to change at any time.
+type DocumentVattr struct {
+	Cas          Cas
+	VbUUID       VbUUID
+	SeqNo        SeqNo
+	Expiry       uint32
+	Flags        uint32
+	RevID        string
+	ValueBytes   uint64
+	ValueCrc32c  uint32
+	Datatype     []string
+	Deleted      bool
+	LastModified time.Time
+}
+
+type jsonDocumentVattr struct {
+	Cas          string   `json:"CAS"`
+	VbUUID       string   `json:"vbucket_uuid"`
+	SeqNo        string   `json:"seqno"`
+	Expiry       uint32   `json:"exptime"`
+	Flags        uint32   `json:"flags"`
+	RevID        string   `json:"revid"`
+	ValueBytes   uint64   `json:"value_bytes"`
+	ValueCrc32c  string   `json:"value_crc32c"`
+	Datatype     []string `json:"datatype"`
+	Deleted      bool     `json:"deleted"`
+	LastModified string   `json:"last_modified"`
+}
+
+// parseVattrHex parses a hex number of up to bitSize bits from a virtual xattr, unlike mutation macros these are not
+// byte swapped.
+func parseVattrHex(field, value string, bitSize int) (uint64, error) {
+	if len(value) < 2 || value[:2] != "0x" {
+		return 0, wrapError(errParsingFailure, fmt.Sprintf("%s is not a hex number", field))
+	}
+
+	parsed, err := strconv.ParseUint(value[2:], 16, bitSize)
+	if err != nil {
+		return 0, wrapError(errParsingFailure, fmt.Sprintf("%s is not a hex number: %v", field, err))
+	}
+
+	return parsed, nil
+}
+
+// parseVattrUnixTime parses a time from a virtual xattr, these are encoded as a string of seconds since the epoch.
+func parseVattrUnixTime(field, value string) (time.Time, error) {
+	secs, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, wrapError(errParsingFailure, fmt.Sprintf("%s is not a unix time: %v", field, err))
+	}
+
+	return time.Unix(secs, 0), nil
+}
+
+// ParseDocumentVattr parses the value of the $document virtual xattr. Fields which the server did not include are
+// left as their zero value.
+// Volatile: This API is subject to change at any time.
+func ParseDocumentVattr(value []byte) (*DocumentVattr, error) {
+	var jsonVattr jsonDocumentVattr
+	if err := json.Unmarshal(value, &jsonVattr); err != nil {
+		return nil, wrapError(errParsingFailure, fmt.Sprintf("failed to parse $document: %v", err))
+	}
+
+	vattr := &DocumentVattr{
+		Expiry:     jsonVattr.Expiry,
+		Flags:      jsonVattr.Flags,
+		RevID:      jsonVattr.RevID,
+		ValueBytes: jsonVattr.ValueBytes,
+		Datatype:   jsonVattr.Datatype,
+		Deleted:    jsonVattr.Deleted,
+	}
+
+	hexFields := []struct {
+		name    string
+		value   string
+		bitSize int
+		apply   func(uint64)
+	}{
+		{"CAS", jsonVattr.Cas, 64, func(v uint64) { vattr.Cas = Cas(v) }},
+		{"vbucket_uuid", jsonVattr.VbUUID, 64, func(v uint64) { vattr.VbUUID = VbUUID(v) }},
+		{"seqno", jsonVattr.SeqNo, 64, func(v uint64) { vattr.SeqNo = SeqNo(v) }},
+		{"value_crc32c", jsonVattr.ValueCrc32c, 32, func(v uint64) { vattr.ValueCrc32c = uint32(v) }},
+	}
+	for _, field := range hexFields {
+		if field.value == "" {
+			continue
+		}
+
+		parsed, err := parseVattrHex(field.name, field.value, field.bitSize)
+		if err != nil {
+			return nil, err
+		}
+		field.apply(parsed)
+	}
+
+	if jsonVattr.LastModified != "" {
+		lastModified, err := parseVattrUnixTime("last_modified", jsonVattr.LastModified)
+		if err != nil {
+			return nil, err
+		}
+		vattr.LastModified = lastModified
+	}
+
+	return vattr, nil
+}
+
+// VbucketVattr is the parsed value of the $vbucket virtual xattr.
+// Volatile: This API is subject to change at any time.
+type VbucketVattr struct {
+	// HLCNow is the current time according to the vbucket's hybrid logical clock.
+	HLCNow time.Time
+
+	// HLCMode is either real or logical, depending on whether the clock is currently tracking real time.
+	HLCMode string
+}
+
+type jsonVbucketVattr struct {
+	HLC struct {
+		Now  string `json:"now"`
+		Mode string `json:"mode"`
+	} `json:"HLC"`
+}
+
+// ParseVbucketVattr parses the value of the $vbucket virtual xattr.
+// Volatile: This API is subject to change at any time.
+func ParseVbucketVattr(value []byte) (*VbucketVattr, error) {
+	var jsonVattr jsonVbucketVattr
+	if err := json.Unmarshal(value, &jsonVattr); err != nil {
+		return nil, wrapError(errParsingFailure, fmt.Sprintf("failed to parse $vbucket: %v", err))
+	}
+
+	now, err := parseVattrUnixTime("HLC.now", jsonVattr.HLC.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VbucketVattr{
+		HLCNow:  now,
+		HLCMode: jsonVattr.HLC.Mode,
+	}, nil
+}
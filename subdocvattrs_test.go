@@ -0,0 +1,69 @@
+package gocbcore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+func (suite *UnitTestSuite) TestSubDocVattrOp() {
+	op := NewSubDocVattrOp(SubdocVattrDocument + ".revid")
+
+	suite.Assert().Equal(memd.SubDocOpGet, op.Op)
+	suite.Assert().Equal(memd.SubdocFlagXattrPath, op.Flags)
+	suite.Assert().Equal("$document.revid", op.Path)
+}
+
+func (suite *UnitTestSuite) TestParseDocumentVattr() {
+	vattr, err := ParseDocumentVattr([]byte(`{
+		"CAS": "0x1516bb3ea7580000",
+		"vbucket_uuid": "0x0000c4d9c5d7a7c1",
+		"seqno": "0x000000000000002a",
+		"exptime": 1700000000,
+		"value_bytes": 16,
+		"datatype": ["json", "xattr"],
+		"deleted": false,
+		"last_modified": "1571045339",
+		"flags": 33554432,
+		"revid": "3",
+		"value_crc32c": "0x297bd0aa"
+	}`))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(&DocumentVattr{
+		Cas:          Cas(0x1516bb3ea7580000),
+		VbUUID:       VbUUID(0xc4d9c5d7a7c1),
+		SeqNo:        SeqNo(42),
+		Expiry:       1700000000,
+		Flags:        33554432,
+		RevID:        "3",
+		ValueBytes:   16,
+		ValueCrc32c:  0x297bd0aa,
+		Datatype:     []string{"json", "xattr"},
+		LastModified: time.Unix(1571045339, 0),
+	}, vattr)
+
+	// Older servers don't include every field.
+	vattr, err = ParseDocumentVattr([]byte(`{"CAS": "0x10", "deleted": true}`))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(&DocumentVattr{Cas: 16, Deleted: true}, vattr)
+
+	_, err = ParseDocumentVattr([]byte(`{"seqno": "42"}`))
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+
+	// The crc is only 32 bits so anything larger can't be a valid value.
+	_, err = ParseDocumentVattr([]byte(`{"value_crc32c": "0x1297bd0aa"}`))
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+
+	_, err = ParseDocumentVattr([]byte(`{"last_modified": "yesterday"}`))
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+}
+
+func (suite *UnitTestSuite) TestParseVbucketVattr() {
+	vattr, err := ParseVbucketVattr([]byte(`{"HLC": {"now": "1571045339", "mode": "real"}}`))
+	suite.Require().Nil(err)
+	suite.Assert().Equal(&VbucketVattr{HLCNow: time.Unix(1571045339, 0), HLCMode: "real"}, vattr)
+
+	_, err = ParseVbucketVattr([]byte(`{"HLC": {}}`))
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+}
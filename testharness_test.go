@@ -24,6 +24,7 @@ var (
 	srvVer650DP = NodeVersion{6, 5, 0, 0, 0, "dp"}
 	srvVer660   = NodeVersion{6, 6, 0, 0, 0, ""}
 	srvVer700   = NodeVersion{7, 0, 0, 0, 0, ""}
+	srvVer710   = NodeVersion{7, 1, 0, 0, 0, ""}
 	mockVer156  = NodeVersion{1, 5, 6, 0, 0, ""}
 )
 
@@ -49,6 +50,7 @@ var (
 	TestFeatureCreateDeleted        = TestFeatureCode("createasdeleted")
 	TestFeatureReplaceBodyWithXattr = TestFeatureCode("replacebodywithxattr")
 	TestFeatureExpandMacros         = TestFeatureCode("expandmacros")
+	TestFeatureReviveDocument       = TestFeatureCode("revivedocument")
 )
 
 type TestFeatureFlag struct {
@@ -36,6 +36,10 @@ func (logger *testLogger) Log(level LogLevel, offset int, format string, v ...in
 }
 
 func (logger *testLogger) SuppressWarnings(suppress bool) {
+	if logger == nil {
+		return
+	}
+
 	if suppress {
 		atomic.StoreUint32(&logger.suppressWarnings, 1)
 	} else {
@@ -164,6 +164,8 @@ func (suite *StandardTestSuite) SupportsFeature(feature TestFeatureCode) bool {
 		return !suite.IsMockServer() && !suite.ClusterVersion.Lower(srvVer660)
 	case TestFeatureReplaceBodyWithXattr:
 		return !suite.IsMockServer() && !suite.ClusterVersion.Lower(srvVer700)
+	case TestFeatureReviveDocument:
+		return !suite.IsMockServer() && !suite.ClusterVersion.Lower(srvVer710)
 	case TestFeatureExpandMacros:
 		return !suite.IsMockServer() && !suite.ClusterVersion.Lower(srvVer450)
 	}
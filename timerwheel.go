@@ -0,0 +1,174 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	timerWheelDefaultTick  = 10 * time.Millisecond
+	timerWheelDefaultSlots = 512
+)
+
+// opTimer is implemented by anything which can be used to time out a request.
+type opTimer interface {
+	Stop() bool
+}
+
+// timerWheel schedules callbacks against a single ticker shared by every timer added to it, rather than allocating
+// a runtime timer per operation. Timers are only accurate to within a single tick. The ticking goroutine is only
+// running whilst there are pending timers.
+type timerWheel struct {
+	lock    sync.Mutex
+	tick    time.Duration
+	slots   []*wheelTimer
+	cursor  int
+	ticks   int64
+	start   time.Time
+	pending int
+	running bool
+}
+
+type wheelTimer struct {
+	wheel    *timerWheel
+	fn       func()
+	slot     int
+	rounds   int
+	prev     *wheelTimer
+	next     *wheelTimer
+	isQueued bool
+}
+
+func newTimerWheel(tick time.Duration, numSlots int) *timerWheel {
+	if tick <= 0 {
+		tick = timerWheelDefaultTick
+	}
+	if numSlots <= 0 {
+		numSlots = timerWheelDefaultSlots
+	}
+
+	return &timerWheel{
+		tick:  tick,
+		slots: make([]*wheelTimer, numSlots),
+	}
+}
+
+// AfterFunc calls fn in its own goroutine once d has elapsed.
+func (tw *timerWheel) AfterFunc(d time.Duration, fn func()) *wheelTimer {
+	numTicks := int((d + tw.tick - 1) / tw.tick)
+	if numTicks < 1 {
+		numTicks = 1
+	}
+
+	t := &wheelTimer{
+		wheel: tw,
+		fn:    fn,
+	}
+
+	tw.lock.Lock()
+	if !tw.running {
+		tw.running = true
+		tw.cursor = 0
+		tw.ticks = 0
+		tw.start = time.Now()
+		go tw.loop()
+	}
+
+	t.slot = (tw.cursor + numTicks) % len(tw.slots)
+	t.rounds = (numTicks - 1) / len(tw.slots)
+	tw.pushLocked(t)
+	tw.lock.Unlock()
+
+	return t
+}
+
+func (tw *timerWheel) pushLocked(t *wheelTimer) {
+	head := tw.slots[t.slot]
+	t.prev = nil
+	t.next = head
+	if head != nil {
+		head.prev = t
+	}
+	tw.slots[t.slot] = t
+	t.isQueued = true
+	tw.pending++
+}
+
+func (tw *timerWheel) removeLocked(t *wheelTimer) {
+	if t.prev != nil {
+		t.prev.next = t.next
+	} else {
+		tw.slots[t.slot] = t.next
+	}
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+	t.prev = nil
+	t.next = nil
+	t.isQueued = false
+	tw.pending--
+}
+
+func (tw *timerWheel) loop() {
+	ticker := time.NewTicker(tw.tick)
+	defer ticker.Stop()
+
+	var expired []*wheelTimer
+	for now := range ticker.C {
+		tw.lock.Lock()
+		// Tickers drop ticks when the receiver falls behind, so we work out how many ticks should have elapsed
+		// rather than counting them.
+		targetTicks := int64(now.Sub(tw.start) / tw.tick)
+		for tw.ticks < targetTicks {
+			tw.ticks++
+			tw.cursor = (tw.cursor + 1) % len(tw.slots)
+			expired = tw.expireSlotLocked(tw.cursor, expired)
+		}
+
+		if tw.pending == 0 {
+			tw.running = false
+		}
+		running := tw.running
+		tw.lock.Unlock()
+
+		for i, t := range expired {
+			go t.fn()
+			expired[i] = nil
+		}
+		expired = expired[:0]
+
+		if !running {
+			return
+		}
+	}
+}
+
+func (tw *timerWheel) expireSlotLocked(slot int, expired []*wheelTimer) []*wheelTimer {
+	t := tw.slots[slot]
+	for t != nil {
+		next := t.next
+		if t.rounds > 0 {
+			t.rounds--
+		} else {
+			tw.removeLocked(t)
+			expired = append(expired, t)
+		}
+		t = next
+	}
+
+	return expired
+}
+
+// Stop prevents the timer from firing, returning false if the timer has already fired or been stopped.
+func (t *wheelTimer) Stop() bool {
+	tw := t.wheel
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+
+	if !t.isQueued {
+		return false
+	}
+
+	tw.removeLocked(t)
+	return true
+}
@@ -0,0 +1,83 @@
+package gocbcore
+
+import (
+	"errors"
+	"time"
+)
+
+func (suite *UnitTestSuite) TestTimerWheelFires() {
+	wheel := newTimerWheel(time.Millisecond, 8)
+
+	// The second timer needs several rotations of the wheel before it fires.
+	firedCh := make(chan int, 2)
+	wheel.AfterFunc(2*time.Millisecond, func() {
+		firedCh <- 1
+	})
+	wheel.AfterFunc(20*time.Millisecond, func() {
+		firedCh <- 2
+	})
+
+	for _, expected := range []int{1, 2} {
+		select {
+		case fired := <-firedCh:
+			suite.Assert().Equal(expected, fired)
+		case <-time.After(time.Second):
+			suite.T().Fatalf("Timer %d did not fire", expected)
+		}
+	}
+}
+
+func (suite *UnitTestSuite) TestTimerWheelStop() {
+	wheel := newTimerWheel(time.Millisecond, 8)
+
+	firedCh := make(chan struct{}, 1)
+	tmr := wheel.AfterFunc(5*time.Millisecond, func() {
+		firedCh <- struct{}{}
+	})
+	suite.Assert().True(tmr.Stop())
+	suite.Assert().False(tmr.Stop())
+
+	select {
+	case <-firedCh:
+		suite.T().Fatalf("Stopped timer should not have fired")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	wheel.lock.Lock()
+	suite.Assert().Zero(wheel.pending)
+	wheel.lock.Unlock()
+}
+
+func (suite *UnitTestSuite) TestKvTimeoutComponentDefaultDeadline() {
+	deadline := time.Now().Add(time.Hour)
+
+	timeouts := newKvTimeoutComponent(0, nil)
+	suite.Assert().True(timeouts.Deadline(time.Time{}).IsZero())
+	suite.Assert().Equal(deadline, timeouts.Deadline(deadline))
+
+	timeouts = newKvTimeoutComponent(time.Minute, nil)
+	suite.Assert().WithinDuration(time.Now().Add(time.Minute), timeouts.Deadline(time.Time{}), time.Second)
+	suite.Assert().Equal(deadline, timeouts.Deadline(deadline))
+}
+
+func (suite *UnitTestSuite) TestKvTimeoutComponentTrack() {
+	timeouts := newKvTimeoutComponent(5*time.Millisecond, newTimerWheel(time.Millisecond, 8))
+
+	errCh := make(chan error, 1)
+	req := &memdQRequest{
+		Callback: func(resp *memdQResponse, req *memdQRequest, err error) {
+			errCh <- err
+		},
+	}
+	timeouts.Track(req, time.Time{}, "Get", errUnambiguousTimeout)
+
+	select {
+	case err := <-errCh:
+		var tErr *TimeoutError
+		suite.Require().True(errors.As(err, &tErr))
+		suite.Assert().Equal("Get", tErr.OperationID)
+		suite.Assert().Equal(errUnambiguousTimeout, tErr.InnerError)
+	case <-time.After(time.Second):
+		suite.T().Fatalf("Request was not timed out")
+	}
+}
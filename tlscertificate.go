@@ -0,0 +1,103 @@
+package gocbcore
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"strings"
+)
+
+// KeyDecryptFunc decrypts a PEM encoded private key, returning the DER encoded key. It allows key formats which are
+// not supported natively, such as encrypted PKCS#8 keys, to be used.
+type KeyDecryptFunc func(block *pem.Block) ([]byte, error)
+
+// X509KeyPairOptions are the options available when loading a certificate and private key.
+type X509KeyPairOptions struct {
+	// KeyPassphrase is used to decrypt private keys which are encrypted using RFC 1423 PEM encryption.
+	KeyPassphrase []byte
+
+	// KeyDecrypter, if set, is used to decrypt the private key in place of KeyPassphrase.
+	KeyDecrypter KeyDecryptFunc
+}
+
+// LoadX509KeyPair reads and parses a certificate chain and a, possibly encrypted, private key from a pair of PEM
+// encoded files. The result can be returned from AuthProvider.Certificate.
+// Volatile: This API is subject to change at any time.
+func LoadX509KeyPair(certPath, keyPath string, opts X509KeyPairOptions) (*tls.Certificate, error) {
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return X509KeyPair(certPEM, keyPEM, opts)
+}
+
+// X509KeyPair parses a certificate chain and a, possibly encrypted, private key from PEM encoded data.
+// Volatile: This API is subject to change at any time.
+func X509KeyPair(certPEM, keyPEM []byte, opts X509KeyPairOptions) (*tls.Certificate, error) {
+	var keyBlock *pem.Block
+	for rest := keyPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			keyBlock = block
+			break
+		}
+	}
+	if keyBlock == nil {
+		return nil, wrapError(errInvalidArgument, "no private key found in key data")
+	}
+
+	keyDER, err := decryptPrivateKey(keyBlock, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Once decrypted an encrypted PKCS#8 key is a plain PKCS#8 key.
+	keyType := keyBlock.Type
+	if keyType == "ENCRYPTED PRIVATE KEY" {
+		keyType = "PRIVATE KEY"
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, pem.EncodeToMemory(&pem.Block{
+		Type:  keyType,
+		Bytes: keyDER,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &cert, nil
+}
+
+func decryptPrivateKey(block *pem.Block, opts X509KeyPairOptions) ([]byte, error) {
+	if opts.KeyDecrypter != nil {
+		return opts.KeyDecrypter(block)
+	}
+
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return nil, wrapError(errInvalidArgument, "encrypted PKCS#8 private keys require a KeyDecrypter")
+	}
+
+	// nolint: staticcheck
+	if !x509.IsEncryptedPEMBlock(block) {
+		return block.Bytes, nil
+	}
+
+	if len(opts.KeyPassphrase) == 0 {
+		return nil, wrapError(errInvalidArgument, "private key is encrypted but no passphrase was provided")
+	}
+
+	// nolint: staticcheck
+	return x509.DecryptPEMBlock(block, opts.KeyPassphrase)
+}
@@ -0,0 +1,87 @@
+package gocbcore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+)
+
+func (suite *UnitTestSuite) generateTestKeyPair() ([]byte, *pem.Block) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().Nil(err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gocbcore"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	suite.Require().Nil(err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	suite.Require().Nil(err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}
+}
+
+func (suite *UnitTestSuite) TestX509KeyPairUnencrypted() {
+	certPEM, keyBlock := suite.generateTestKeyPair()
+
+	cert, err := X509KeyPair(certPEM, pem.EncodeToMemory(keyBlock), X509KeyPairOptions{})
+	suite.Require().Nil(err)
+	suite.Assert().NotNil(cert.PrivateKey)
+}
+
+func (suite *UnitTestSuite) TestX509KeyPairPassphrase() {
+	certPEM, keyBlock := suite.generateTestKeyPair()
+
+	// nolint: staticcheck
+	encBlock, err := x509.EncryptPEMBlock(rand.Reader, keyBlock.Type, keyBlock.Bytes, []byte("secret"),
+		x509.PEMCipherAES256)
+	suite.Require().Nil(err)
+	keyPEM := pem.EncodeToMemory(encBlock)
+
+	cert, err := X509KeyPair(certPEM, keyPEM, X509KeyPairOptions{KeyPassphrase: []byte("secret")})
+	suite.Require().Nil(err)
+	suite.Assert().NotNil(cert.PrivateKey)
+
+	_, err = X509KeyPair(certPEM, keyPEM, X509KeyPairOptions{})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	_, err = X509KeyPair(certPEM, keyPEM, X509KeyPairOptions{KeyPassphrase: []byte("wrong")})
+	suite.Assert().NotNil(err)
+}
+
+func (suite *UnitTestSuite) TestX509KeyPairDecrypter() {
+	certPEM, keyBlock := suite.generateTestKeyPair()
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("opaque")})
+
+	_, err := X509KeyPair(certPEM, keyPEM, X509KeyPairOptions{})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(mustParseECKey(suite, keyBlock.Bytes))
+	suite.Require().Nil(err)
+
+	cert, err := X509KeyPair(certPEM, keyPEM, X509KeyPairOptions{
+		KeyDecrypter: func(block *pem.Block) ([]byte, error) {
+			suite.Assert().Equal([]byte("opaque"), block.Bytes)
+			return pkcs8, nil
+		},
+	})
+	suite.Require().Nil(err)
+	suite.Assert().NotNil(cert.PrivateKey)
+}
+
+func mustParseECKey(suite *UnitTestSuite, der []byte) *ecdsa.PrivateKey {
+	key, err := x509.ParseECPrivateKey(der)
+	suite.Require().Nil(err)
+	return key
+}
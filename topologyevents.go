@@ -0,0 +1,158 @@
+package gocbcore
+
+import (
+	"sync"
+	"time"
+)
+
+// VbucketOwnershipChange describes a vbucket copy moving from one node to another.
+type VbucketOwnershipChange struct {
+	VbID uint16
+
+	// ReplicaIdx is 0 for the active copy of the vbucket, or the index of the replica plus one.
+	ReplicaIdx int
+
+	// OldServer and NewServer are the kv addresses of the nodes owning the vbucket copy before and after the change,
+	// either is empty when no node owns the copy.
+	OldServer string
+	NewServer string
+}
+
+// TopologyChange describes the differences between two consecutive cluster configs applied by an agent.
+// The first config applied is diffed against an empty topology, so reports every node and vbucket.
+type TopologyChange struct {
+	PreviousRevID    int64
+	PreviousRevEpoch int64
+	RevID            int64
+	RevEpoch         int64
+
+	// AddedNodes and RemovedNodes contain the kv addresses of the nodes that joined or left the cluster.
+	AddedNodes   []string
+	RemovedNodes []string
+
+	VbucketChanges []VbucketOwnershipChange
+	Time           time.Time
+}
+
+// TopologyChangeCallback is invoked whenever a new cluster config changes the set of kv nodes or the ownership of
+// any vbucket. It is invoked from the goroutine which applies configs and so must not block.
+// Volatile: This API is subject to change at any time.
+type TopologyChangeCallback func(change TopologyChange)
+
+type topologyEventsComponent struct {
+	callback TopologyChangeCallback
+
+	lock       sync.Mutex
+	lastConfig *routeConfig
+}
+
+func newTopologyEventsComponent(cfgMgr configManager, callback TopologyChangeCallback) *topologyEventsComponent {
+	tec := &topologyEventsComponent{
+		callback: callback,
+		lastConfig: &routeConfig{
+			revID: -1,
+		},
+	}
+	cfgMgr.AddConfigWatcher(tec)
+
+	return tec
+}
+
+func (tec *topologyEventsComponent) OnNewRouteConfig(cfg *routeConfig) {
+	tec.lock.Lock()
+	defer tec.lock.Unlock()
+
+	change := diffTopology(tec.lastConfig, cfg)
+	tec.lastConfig = cfg
+
+	if len(change.AddedNodes) == 0 && len(change.RemovedNodes) == 0 && len(change.VbucketChanges) == 0 {
+		return
+	}
+
+	tec.callback(change)
+}
+
+func diffTopology(oldCfg, newCfg *routeConfig) TopologyChange {
+	change := TopologyChange{
+		PreviousRevID:    oldCfg.revID,
+		PreviousRevEpoch: oldCfg.revEpoch,
+		RevID:            newCfg.revID,
+		RevEpoch:         newCfg.revEpoch,
+		Time:             time.Now(),
+	}
+
+	oldNodes := make(map[string]struct{}, len(oldCfg.kvServerList))
+	for _, addr := range oldCfg.kvServerList {
+		oldNodes[addr] = struct{}{}
+	}
+	newNodes := make(map[string]struct{}, len(newCfg.kvServerList))
+	for _, addr := range newCfg.kvServerList {
+		newNodes[addr] = struct{}{}
+		if _, ok := oldNodes[addr]; !ok {
+			change.AddedNodes = append(change.AddedNodes, addr)
+		}
+	}
+	for _, addr := range oldCfg.kvServerList {
+		if _, ok := newNodes[addr]; !ok {
+			change.RemovedNodes = append(change.RemovedNodes, addr)
+		}
+	}
+
+	var oldEntries, newEntries [][]int
+	if oldCfg.vbMap != nil {
+		oldEntries = oldCfg.vbMap.entries
+	}
+	if newCfg.vbMap != nil {
+		newEntries = newCfg.vbMap.entries
+	}
+
+	numVbuckets := len(newEntries)
+	if len(oldEntries) > numVbuckets {
+		numVbuckets = len(oldEntries)
+	}
+
+	for vbID := 0; vbID < numVbuckets; vbID++ {
+		var oldServers, newServers []int
+		if vbID < len(oldEntries) {
+			oldServers = oldEntries[vbID]
+		}
+		if vbID < len(newEntries) {
+			newServers = newEntries[vbID]
+		}
+
+		numCopies := len(newServers)
+		if len(oldServers) > numCopies {
+			numCopies = len(oldServers)
+		}
+
+		for replicaIdx := 0; replicaIdx < numCopies; replicaIdx++ {
+			oldServer := topologyServerAddress(oldCfg, oldServers, replicaIdx)
+			newServer := topologyServerAddress(newCfg, newServers, replicaIdx)
+			if oldServer == newServer {
+				continue
+			}
+
+			change.VbucketChanges = append(change.VbucketChanges, VbucketOwnershipChange{
+				VbID:       uint16(vbID),
+				ReplicaIdx: replicaIdx,
+				OldServer:  oldServer,
+				NewServer:  newServer,
+			})
+		}
+	}
+
+	return change
+}
+
+func topologyServerAddress(cfg *routeConfig, servers []int, replicaIdx int) string {
+	if replicaIdx >= len(servers) {
+		return ""
+	}
+
+	srvIdx := servers[replicaIdx]
+	if srvIdx < 0 || srvIdx >= len(cfg.kvServerList) {
+		return ""
+	}
+
+	return cfg.kvServerList[srvIdx]
+}
@@ -0,0 +1,48 @@
+package gocbcore
+
+func (suite *UnitTestSuite) TestTopologyEvents() {
+	cfgMgr := &configManagementComponent{}
+	var changes []TopologyChange
+	tec := newTopologyEventsComponent(cfgMgr, func(change TopologyChange) {
+		changes = append(changes, change)
+	})
+
+	tec.OnNewRouteConfig(&routeConfig{
+		revID:        1,
+		kvServerList: []string{"a:11210", "b:11210"},
+		vbMap:        newVbucketMap([][]int{{0, 1}, {1, 0}}, 1),
+	})
+	suite.Require().Len(changes, 1)
+	suite.Assert().Equal(int64(-1), changes[0].PreviousRevID)
+	suite.Assert().Equal(int64(1), changes[0].RevID)
+	suite.Assert().Equal([]string{"a:11210", "b:11210"}, changes[0].AddedNodes)
+	suite.Assert().Empty(changes[0].RemovedNodes)
+	suite.Assert().Len(changes[0].VbucketChanges, 4)
+	suite.Assert().Equal(VbucketOwnershipChange{VbID: 1, ReplicaIdx: 0, NewServer: "b:11210"},
+		changes[0].VbucketChanges[2])
+
+	// A config which only changes the order of the server list moves nothing.
+	tec.OnNewRouteConfig(&routeConfig{
+		revID:        2,
+		kvServerList: []string{"b:11210", "a:11210"},
+		vbMap:        newVbucketMap([][]int{{1, 0}, {0, 1}}, 1),
+	})
+	suite.Require().Len(changes, 1)
+
+	// Node b is swapped out for node c, which takes over its vbuckets.
+	tec.OnNewRouteConfig(&routeConfig{
+		revID:        3,
+		kvServerList: []string{"a:11210", "c:11210"},
+		vbMap:        newVbucketMap([][]int{{0, 1}, {1, -1}}, 1),
+	})
+	suite.Require().Len(changes, 2)
+	change := changes[1]
+	suite.Assert().Equal(int64(2), change.PreviousRevID)
+	suite.Assert().Equal([]string{"c:11210"}, change.AddedNodes)
+	suite.Assert().Equal([]string{"b:11210"}, change.RemovedNodes)
+	suite.Assert().Equal([]VbucketOwnershipChange{
+		{VbID: 0, ReplicaIdx: 1, OldServer: "b:11210", NewServer: "c:11210"},
+		{VbID: 1, ReplicaIdx: 0, OldServer: "b:11210", NewServer: "c:11210"},
+		{VbID: 1, ReplicaIdx: 1, OldServer: "a:11210", NewServer: ""},
+	}, change.VbucketChanges)
+}
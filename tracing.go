@@ -4,7 +4,10 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // RequestTracer describes the tracing abstraction in the SDK.
@@ -52,9 +55,68 @@ func (span noopSpan) SetAttribute(key string, value interface{}) {
 func (span noopSpan) AddEvent(key string, timestamp time.Time) {
 }
 
+// compositeSpanContext is handed out in place of the tracer's own span contexts during a composite operation so that
+// every span created on behalf of the operation, whether KV or HTTP, can be tagged with its ID. It is always unwrapped
+// before being passed to the tracer.
+type compositeSpanContext struct {
+	parent      RequestSpanContext
+	operationID string
+}
+
+// unwrapSpanContext returns the tracer's span context and the ID of the composite operation it belongs to, if any.
+func unwrapSpanContext(ctx RequestSpanContext) (RequestSpanContext, string) {
+	if composite, ok := ctx.(*compositeSpanContext); ok {
+		return composite.parent, composite.operationID
+	}
+
+	return ctx, ""
+}
+
+func wrapSpanContext(ctx RequestSpanContext, operationID string) RequestSpanContext {
+	if operationID == "" {
+		return ctx
+	}
+
+	return &compositeSpanContext{
+		parent:      ctx,
+		operationID: operationID,
+	}
+}
+
+// CompositeOperation represents a flow made up of several operations, such as a query which must observe the
+// mutations performed before it. Passing TraceContext as the TraceContext of each of the operations parents their
+// spans, both KV and HTTP, under the span for the composite operation and tags them all with its ID.
+// Volatile: This API is subject to change at any time.
+type CompositeOperation struct {
+	id         string
+	span       RequestSpan
+	traceCtx   RequestSpanContext
+	finishOnce sync.Once
+}
+
+// ID returns the ID shared by all of the operations making up the composite operation.
+func (op *CompositeOperation) ID() string {
+	return op.id
+}
+
+// TraceContext returns the context to pass as the TraceContext of each of the operations.
+func (op *CompositeOperation) TraceContext() RequestSpanContext {
+	return op.traceCtx
+}
+
+// Finish ends the span for the composite operation, it must be called once all of the operations have completed.
+func (op *CompositeOperation) Finish() {
+	op.finishOnce.Do(func() {
+		if op.span != nil {
+			op.span.End()
+		}
+	})
+}
+
 type opTracer struct {
 	parentContext RequestSpanContext
 	opSpan        RequestSpan
+	compositeID   string
 }
 
 func (tracer *opTracer) Finish() {
@@ -65,7 +127,7 @@ func (tracer *opTracer) Finish() {
 
 func (tracer *opTracer) RootContext() RequestSpanContext {
 	if tracer.opSpan != nil {
-		return tracer.opSpan.Context()
+		return wrapSpanContext(tracer.opSpan.Context(), tracer.compositeID)
 	}
 
 	return tracer.parentContext
@@ -101,17 +163,47 @@ func (tc *tracerComponent) CreateOpTrace(operationName string, parentContext Req
 		}
 	}
 
-	opSpan := tc.tracer.RequestSpan(parentContext, operationName)
+	tracerParent, compositeID := unwrapSpanContext(parentContext)
+	opSpan := tc.tracer.RequestSpan(tracerParent, operationName)
 	opSpan.SetAttribute(spanAttribDBSystemKey, spanAttribDBSystemValue)
+	if compositeID != "" {
+		opSpan.SetAttribute(spanAttribCompositeOperationIDKey, compositeID)
+	}
 
 	return &opTracer{
 		parentContext: parentContext,
 		opSpan:        opSpan,
+		compositeID:   compositeID,
 	}
 }
 
+// StartCompositeOperation starts a composite operation, parented under parentContext.
+func (tc *tracerComponent) StartCompositeOperation(operationName string,
+	parentContext RequestSpanContext) *CompositeOperation {
+	tracerParent, _ := unwrapSpanContext(parentContext)
+	op := &CompositeOperation{
+		id: uuid.New().String(),
+	}
+
+	if tc.noRootTraceSpans {
+		op.traceCtx = wrapSpanContext(tracerParent, op.id)
+		return op
+	}
+
+	op.span = tc.tracer.RequestSpan(tracerParent, operationName)
+	op.span.SetAttribute(spanAttribDBSystemKey, spanAttribDBSystemValue)
+	op.span.SetAttribute(spanAttribCompositeOperationIDKey, op.id)
+	op.traceCtx = wrapSpanContext(op.span.Context(), op.id)
+
+	return op
+}
+
 func (tc *tracerComponent) StartHTTPDispatchSpan(req *httpRequest, name string) RequestSpan {
-	span := tc.tracer.RequestSpan(req.RootTraceContext, name)
+	tracerParent, compositeID := unwrapSpanContext(req.RootTraceContext)
+	span := tc.tracer.RequestSpan(tracerParent, name)
+	if compositeID != "" {
+		span.SetAttribute(spanAttribCompositeOperationIDKey, compositeID)
+	}
 	return span
 }
 
@@ -141,8 +233,13 @@ func (tc *tracerComponent) StartCmdTrace(req *memdQRequest) {
 		return
 	}
 
+	tracerParent, compositeID := unwrapSpanContext(req.RootTraceContext)
+
 	req.processingLock.Lock()
-	req.cmdTraceSpan = tc.tracer.RequestSpan(req.RootTraceContext, req.Packet.Command.Name())
+	req.cmdTraceSpan = tc.tracer.RequestSpan(tracerParent, req.Packet.Command.Name())
+	if compositeID != "" {
+		req.cmdTraceSpan.SetAttribute(spanAttribCompositeOperationIDKey, compositeID)
+	}
 
 	req.processingLock.Unlock()
 }
@@ -157,8 +254,13 @@ func (tc *tracerComponent) StartNetTrace(req *memdQRequest) {
 		return
 	}
 
+	_, compositeID := unwrapSpanContext(req.RootTraceContext)
+
 	req.processingLock.Lock()
 	req.netTraceSpan = tc.tracer.RequestSpan(req.cmdTraceSpan.Context(), spanNameDispatchToServer)
+	if compositeID != "" {
+		req.netTraceSpan.SetAttribute(spanAttribCompositeOperationIDKey, compositeID)
+	}
 	req.processingLock.Unlock()
 }
 
@@ -1,8 +1,13 @@
 package gocbcore
 
 import (
-	"github.com/couchbase/gocbcore/v9/memd"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
 )
 
 type testSpan struct {
@@ -244,3 +249,156 @@ func (suite *StandardTestSuite) TestBasicOpsTracingParentRoot() {
 		}
 	}
 }
+
+type testRecordedSpan struct {
+	name   string
+	parent RequestSpanContext
+
+	lock     sync.Mutex
+	attribs  map[string]interface{}
+	finished bool
+}
+
+func (s *testRecordedSpan) End() {
+	s.lock.Lock()
+	s.finished = true
+	s.lock.Unlock()
+}
+
+func (s *testRecordedSpan) Context() RequestSpanContext {
+	return s
+}
+
+func (s *testRecordedSpan) SetAttribute(key string, value interface{}) {
+	s.lock.Lock()
+	s.attribs[key] = value
+	s.lock.Unlock()
+}
+
+func (s *testRecordedSpan) AddEvent(key string, timestamp time.Time) {
+}
+
+func (s *testRecordedSpan) Attribute(key string) interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.attribs[key]
+}
+
+// testRecordingTracer records every span created, unlike testTracer it is safe for concurrent use.
+type testRecordingTracer struct {
+	lock  sync.Mutex
+	spans []*testRecordedSpan
+}
+
+func (tt *testRecordingTracer) RequestSpan(parentContext RequestSpanContext, operationName string) RequestSpan {
+	span := &testRecordedSpan{
+		name:    operationName,
+		parent:  parentContext,
+		attribs: make(map[string]interface{}),
+	}
+
+	tt.lock.Lock()
+	tt.spans = append(tt.spans, span)
+	tt.lock.Unlock()
+
+	return span
+}
+
+func (tt *testRecordingTracer) SpansWithAttribute(key string, value interface{}) []*testRecordedSpan {
+	tt.lock.Lock()
+	defer tt.lock.Unlock()
+
+	var spans []*testRecordedSpan
+	for _, span := range tt.spans {
+		if span.Attribute(key) == value {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func (suite *UnitTestSuite) TestCompositeOperationTracing() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[],"status":"success"}`))
+	}))
+	defer srv.Close()
+
+	recorder := &testRecordingTracer{}
+	agent := suite.newMockAgent(server, AgentConfig{
+		BucketName: "default",
+		Tracer:     recorder,
+	})
+	defer agent.Close()
+
+	mux := newHTTPMux(CircuitBreakerConfig{Enabled: false}, &configManagementComponent{})
+	mux.OnNewRouteConfig(&routeConfig{revID: 1, n1qlEpList: []string{srv.URL}})
+	httpCpt := newHTTPComponent(httpComponentProps{}, &http.Client{}, mux, PasswordAuthProvider{}, agent.tracer)
+	n1qlCpt := newN1QLQueryComponent(httpCpt, &configManagementComponent{}, agent.tracer)
+
+	composite := agent.StartCompositeOperation("query_after_write", nil)
+	suite.Require().NotEmpty(composite.ID())
+
+	setCh := make(chan error, 1)
+	_, err := agent.Set(SetOptions{
+		Key:          []byte("key"),
+		Value:        []byte("value"),
+		Deadline:     time.Now().Add(5 * time.Second),
+		TraceContext: composite.TraceContext(),
+	}, func(res *StoreResult, err error) {
+		setCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-setCh)
+
+	queryCh := make(chan error, 1)
+	_, err = n1qlCpt.N1QLQuery(N1QLQueryOptions{
+		Payload:      []byte(`{"statement":"SELECT 1","scan_consistency":"at_plus"}`),
+		Deadline:     time.Now().Add(5 * time.Second),
+		TraceContext: composite.TraceContext(),
+	}, func(reader *N1QLRowReader, err error) {
+		if err == nil {
+			for reader.NextRow() != nil {
+			}
+			err = reader.Err()
+		}
+		queryCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Require().Nil(<-queryCh)
+	composite.Finish()
+
+	spans := recorder.SpansWithAttribute(spanAttribCompositeOperationIDKey, composite.ID())
+	findSpan := func(name string, parent RequestSpanContext) *testRecordedSpan {
+		for _, span := range spans {
+			if span.name == name && span.parent == parent {
+				return span
+			}
+		}
+		return nil
+	}
+
+	root := findSpan("query_after_write", nil)
+	suite.Require().NotNil(root)
+	suite.Assert().True(root.finished)
+
+	set := findSpan("Set", root)
+	suite.Require().NotNil(set)
+	// The command may have been dispatched more than once if it was sent before the first config arrived.
+	var netSpan *testRecordedSpan
+	for _, span := range spans {
+		if span.name == memd.CmdSet.Name() {
+			suite.Assert().Equal(set, span.parent)
+			if dispatched := findSpan(spanNameDispatchToServer, span); dispatched != nil {
+				netSpan = dispatched
+			}
+		}
+	}
+	suite.Assert().NotNil(netSpan)
+
+	query := findSpan("N1QLQuery", root)
+	suite.Require().NotNil(query)
+	suite.Assert().NotNil(findSpan(spanNameDispatchToServer, query))
+}
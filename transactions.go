@@ -0,0 +1,530 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+// The sub-document paths used by transactions. Staged mutations are recorded within the txn xattr of the document
+// being mutated, and the state of each attempt is recorded within the attempts xattr of an active transaction record
+// (ATR) document.
+const (
+	// TransactionXattrPath is the xattr which holds a document's staged mutation.
+	TransactionXattrPath = "txn"
+
+	// TransactionATRAttemptsPath is the xattr which holds the attempts of an ATR document.
+	TransactionATRAttemptsPath = "attempts"
+
+	transactionPathTxnID        = "txn.id.txn"
+	transactionPathAttemptID    = "txn.id.atmpt"
+	transactionPathATRID        = "txn.atr.id"
+	transactionPathATRBucket    = "txn.atr.bkt"
+	transactionPathATRScope     = "txn.atr.scp"
+	transactionPathATRColl      = "txn.atr.coll"
+	transactionPathOpType       = "txn.op.type"
+	transactionPathOpStaged     = "txn.op.stgd"
+	transactionPathOpCrc32      = "txn.op.crc32"
+	transactionPathRestoreCas   = "txn.restore.CAS"
+	transactionPathRestoreExp   = "txn.restore.exptime"
+	transactionPathRestoreRevID = "txn.restore.revid"
+
+	// transactionDocumentRevIDPath is the virtual xattr which holds the revision id of a document.
+	transactionDocumentRevIDPath = "$document.revid"
+)
+
+// TransactionStagedMutationType is the type of a mutation staged by a transaction.
+// Volatile: This API is subject to change at any time.
+type TransactionStagedMutationType string
+
+const (
+	// TransactionStagedInsert indicates that the document is being inserted, the document is a tombstone until the
+	// transaction commits.
+	TransactionStagedInsert = TransactionStagedMutationType("insert")
+
+	// TransactionStagedReplace indicates that the document body is being replaced.
+	TransactionStagedReplace = TransactionStagedMutationType("replace")
+
+	// TransactionStagedRemove indicates that the document is being removed.
+	TransactionStagedRemove = TransactionStagedMutationType("remove")
+)
+
+// TransactionATRLocation identifies the active transaction record which an attempt is recorded in.
+// Volatile: This API is subject to change at any time.
+type TransactionATRLocation struct {
+	BucketName     string
+	ScopeName      string
+	CollectionName string
+	DocID          string
+}
+
+// TransactionRestoreMetadata is the metadata of a document before a mutation was staged against it, this is used
+// to detect whether the document has since been changed outside of the transaction.
+// Volatile: This API is subject to change at any time.
+type TransactionRestoreMetadata struct {
+	Cas    Cas
+	Expiry uint32
+	RevID  string
+}
+
+// TransactionStagedMutation is a mutation which has been staged within a document by a transaction attempt.
+// Volatile: This API is subject to change at any time.
+type TransactionStagedMutation struct {
+	TransactionID string
+	AttemptID     string
+	ATR           TransactionATRLocation
+	Type          TransactionStagedMutationType
+
+	// Staged is the body which the document will have once the transaction commits, this is nil for removes.
+	Staged json.RawMessage
+
+	// Restore is only populated when parsing a staged mutation, and only for replaces and removes.
+	Restore *TransactionRestoreMetadata
+}
+
+// transactionJSONString encodes s as a JSON string, encoding a string can never fail.
+func transactionJSONString(s string) []byte {
+	value, _ := json.Marshal(s)
+	return value
+}
+
+func transactionXattrOp(op memd.SubDocOpType, path string, value []byte) SubDocOp {
+	return SubDocOp{
+		Op:    op,
+		Flags: memd.SubdocFlagXattrPath | memd.SubdocFlagMkDirP,
+		Path:  path,
+		Value: value,
+	}
+}
+
+// Ops returns the sub-document operations which stage the mutation within the document's txn xattr. For replaces
+// and removes the document's current metadata is also recorded so that it can be restored. Inserts should be
+// performed with memd.SubdocDocFlagCreateAsDeleted and memd.SubdocDocFlagAccessDeleted.
+// Volatile: This API is subject to change at any time.
+func (m TransactionStagedMutation) Ops() []SubDocOp {
+	ops := []SubDocOp{
+		transactionXattrOp(memd.SubDocOpDictSet, transactionPathTxnID, transactionJSONString(m.TransactionID)),
+		transactionXattrOp(memd.SubDocOpDictSet, transactionPathAttemptID, transactionJSONString(m.AttemptID)),
+		transactionXattrOp(memd.SubDocOpDictSet, transactionPathATRID, transactionJSONString(m.ATR.DocID)),
+		transactionXattrOp(memd.SubDocOpDictSet, transactionPathATRBucket, transactionJSONString(m.ATR.BucketName)),
+		transactionXattrOp(memd.SubDocOpDictSet, transactionPathATRScope, transactionJSONString(m.ATR.ScopeName)),
+		transactionXattrOp(memd.SubDocOpDictSet, transactionPathATRColl,
+			transactionJSONString(m.ATR.CollectionName)),
+		transactionXattrOp(memd.SubDocOpDictSet, transactionPathOpType, transactionJSONString(string(m.Type))),
+	}
+
+	if m.Type != TransactionStagedRemove {
+		ops = append(ops, transactionXattrOp(memd.SubDocOpDictSet, transactionPathOpStaged, m.Staged))
+	}
+
+	ops = append(ops, NewSubDocMacroOp(memd.SubDocOpDictSet, transactionPathOpCrc32, SubdocMutateMacroValueCrc32c,
+		memd.SubdocFlagMkDirP))
+
+	if m.Type != TransactionStagedInsert {
+		ops = append(ops,
+			NewSubDocMacroOp(memd.SubDocOpDictSet, transactionPathRestoreCas, SubdocMutateMacroDocumentCas,
+				memd.SubdocFlagMkDirP),
+			NewSubDocMacroOp(memd.SubDocOpDictSet, transactionPathRestoreExp, SubdocMutateMacroDocumentExpiry,
+				memd.SubdocFlagMkDirP),
+			NewSubDocMacroOp(memd.SubDocOpDictSet, transactionPathRestoreRevID, SubdocMutateMacroDocumentRevID,
+				memd.SubdocFlagMkDirP),
+		)
+	}
+
+	return ops
+}
+
+// TransactionUnstageOps returns the sub-document operations which commit a staged insert or replace, the staged body
+// becomes the document body and the txn xattr is removed. Inserts must also revive the document using
+// memd.SubdocDocFlagReviveDocument, staged removes are committed by deleting the document.
+// Volatile: This API is subject to change at any time.
+func TransactionUnstageOps() []SubDocOp {
+	return []SubDocOp{
+		{
+			Op:    memd.SubDocOpReplaceBodyWithXattr,
+			Flags: memd.SubdocFlagXattrPath,
+			Path:  transactionPathOpStaged,
+		},
+		{
+			Op:    memd.SubDocOpDelete,
+			Flags: memd.SubdocFlagXattrPath,
+			Path:  TransactionXattrPath,
+		},
+	}
+}
+
+// TransactionRollbackOps returns the sub-document operations which discard a staged mutation.
+// Volatile: This API is subject to change at any time.
+func TransactionRollbackOps() []SubDocOp {
+	return []SubDocOp{
+		{
+			Op:    memd.SubDocOpDelete,
+			Flags: memd.SubdocFlagXattrPath,
+			Path:  TransactionXattrPath,
+		},
+	}
+}
+
+// TransactionLookupOps returns the sub-document operations which read a document along with any mutation staged
+// against it, the result can be parsed with ParseTransactionLookupResult. The lookup should be performed with
+// memd.SubdocDocFlagAccessDeleted so that staged inserts are visible.
+// Volatile: This API is subject to change at any time.
+func TransactionLookupOps() []SubDocOp {
+	return []SubDocOp{
+		{
+			Op:    memd.SubDocOpGet,
+			Flags: memd.SubdocFlagXattrPath,
+			Path:  TransactionXattrPath,
+		},
+		{
+			Op:    memd.SubDocOpGet,
+			Flags: memd.SubdocFlagXattrPath,
+			Path:  transactionDocumentRevIDPath,
+		},
+		{
+			Op: memd.SubDocOpGetDoc,
+		},
+	}
+}
+
+// TransactionLookupResult is a document read using TransactionLookupOps.
+// Volatile: This API is subject to change at any time.
+type TransactionLookupResult struct {
+	Cas   Cas
+	RevID string
+
+	// Body is nil if the document is a tombstone.
+	Body []byte
+
+	// StagedMutation is nil if no mutation is staged against the document.
+	StagedMutation *TransactionStagedMutation
+}
+
+// ParseTransactionLookupResult parses the result of a lookup performed with TransactionLookupOps.
+// Volatile: This API is subject to change at any time.
+func ParseTransactionLookupResult(res *LookupInResult) (*TransactionLookupResult, error) {
+	if len(res.Ops) != 3 {
+		return nil, wrapError(errInvalidArgument, "lookup result was not performed using TransactionLookupOps")
+	}
+
+	parsed := &TransactionLookupResult{
+		Cas: res.Cas,
+	}
+
+	if err := res.Ops[1].Err; err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(res.Ops[1].Value, &parsed.RevID); err != nil {
+		return nil, wrapError(errParsingFailure, fmt.Sprintf("revid is not a string: %v", err))
+	}
+
+	if res.Ops[0].Err == nil {
+		staged, err := ParseTransactionXattr(res.Ops[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		parsed.StagedMutation = staged
+	} else if !errors.Is(res.Ops[0].Err, ErrPathNotFound) {
+		return nil, res.Ops[0].Err
+	}
+
+	if res.Ops[2].Err == nil && !res.Internal.IsDeleted {
+		parsed.Body = res.Ops[2].Value
+	}
+
+	return parsed, nil
+}
+
+type jsonTransactionXattr struct {
+	ID struct {
+		Transaction string `json:"txn"`
+		Attempt     string `json:"atmpt"`
+	} `json:"id"`
+	ATR struct {
+		DocID          string `json:"id"`
+		BucketName     string `json:"bkt"`
+		ScopeName      string `json:"scp"`
+		CollectionName string `json:"coll"`
+	} `json:"atr"`
+	Operation struct {
+		Type   string          `json:"type"`
+		Staged json.RawMessage `json:"stgd,omitempty"`
+	} `json:"op"`
+	Restore *struct {
+		Cas    string `json:"CAS"`
+		Expiry uint32 `json:"exptime"`
+		RevID  string `json:"revid"`
+	} `json:"restore,omitempty"`
+}
+
+// parseTransactionRestoreCas parses a CAS copied from $document.CAS, which is a hex number in its natural byte order.
+func parseTransactionRestoreCas(value string) (Cas, error) {
+	if len(value) < 2 || value[:2] != "0x" {
+		return 0, wrapError(errParsingFailure, "restore.CAS is not a hex number")
+	}
+
+	cas, err := strconv.ParseUint(value[2:], 16, 64)
+	if err != nil {
+		return 0, wrapError(errParsingFailure, fmt.Sprintf("restore.CAS is not a hex number: %v", err))
+	}
+
+	return Cas(cas), nil
+}
+
+// ParseTransactionXattr parses the value of a document's txn xattr.
+// Volatile: This API is subject to change at any time.
+func ParseTransactionXattr(value []byte) (*TransactionStagedMutation, error) {
+	var xattr jsonTransactionXattr
+	if err := json.Unmarshal(value, &xattr); err != nil {
+		return nil, wrapError(errParsingFailure, fmt.Sprintf("failed to parse txn xattr: %v", err))
+	}
+
+	mutation := &TransactionStagedMutation{
+		TransactionID: xattr.ID.Transaction,
+		AttemptID:     xattr.ID.Attempt,
+		ATR: TransactionATRLocation{
+			BucketName:     xattr.ATR.BucketName,
+			ScopeName:      xattr.ATR.ScopeName,
+			CollectionName: xattr.ATR.CollectionName,
+			DocID:          xattr.ATR.DocID,
+		},
+		Type:   TransactionStagedMutationType(xattr.Operation.Type),
+		Staged: xattr.Operation.Staged,
+	}
+
+	if xattr.Restore != nil {
+		// The CAS was copied from $document so isn't byte swapped like the mutation macros.
+		cas, err := parseTransactionRestoreCas(xattr.Restore.Cas)
+		if err != nil {
+			return nil, err
+		}
+
+		mutation.Restore = &TransactionRestoreMetadata{
+			Cas:    cas,
+			Expiry: xattr.Restore.Expiry,
+			RevID:  xattr.Restore.RevID,
+		}
+	}
+
+	return mutation, nil
+}
+
+// TransactionAttemptState is the state of a transaction attempt as recorded in its ATR entry.
+// Volatile: This API is subject to change at any time.
+type TransactionAttemptState string
+
+const (
+	// TransactionAttemptPending indicates that the attempt is staging mutations.
+	TransactionAttemptPending = TransactionAttemptState("PENDING")
+
+	// TransactionAttemptCommitted indicates that the attempt has committed and its mutations are being unstaged.
+	TransactionAttemptCommitted = TransactionAttemptState("COMMITTED")
+
+	// TransactionAttemptCompleted indicates that all of the attempt's mutations have been unstaged.
+	TransactionAttemptCompleted = TransactionAttemptState("COMPLETED")
+
+	// TransactionAttemptAborted indicates that the attempt has aborted and its mutations are being rolled back.
+	TransactionAttemptAborted = TransactionAttemptState("ABORTED")
+
+	// TransactionAttemptRolledBack indicates that all of the attempt's mutations have been rolled back.
+	TransactionAttemptRolledBack = TransactionAttemptState("ROLLED_BACK")
+)
+
+// stateTimestampField returns the ATR entry field which records when the attempt entered the state.
+func (state TransactionAttemptState) stateTimestampField() string {
+	switch state {
+	case TransactionAttemptPending:
+		return "tst"
+	case TransactionAttemptCommitted:
+		return "tsc"
+	case TransactionAttemptCompleted:
+		return "tsco"
+	case TransactionAttemptAborted:
+		return "tsrs"
+	case TransactionAttemptRolledBack:
+		return "tsrc"
+	}
+
+	return ""
+}
+
+// TransactionATRDocRecord identifies a document which is mutated by a transaction attempt.
+// Volatile: This API is subject to change at any time.
+type TransactionATRDocRecord struct {
+	BucketName     string `json:"bkt"`
+	ScopeName      string `json:"scp"`
+	CollectionName string `json:"col"`
+	DocID          string `json:"id"`
+}
+
+// TransactionATRDocs are the documents mutated by a transaction attempt, recorded in the ATR when the attempt
+// commits or aborts so that it can be completed by another actor.
+// Volatile: This API is subject to change at any time.
+type TransactionATRDocs struct {
+	Inserts  []TransactionATRDocRecord
+	Replaces []TransactionATRDocRecord
+	Removes  []TransactionATRDocRecord
+}
+
+// TransactionATRAttemptPath returns the path of an attempt's entry within an ATR document.
+// Volatile: This API is subject to change at any time.
+func TransactionATRAttemptPath(attemptID string) string {
+	return TransactionATRAttemptsPath + "." + attemptID
+}
+
+// NewTransactionATRPendingOps returns the sub-document operations which add a pending entry for an attempt to an
+// ATR document. The operations fail with a path exists error if the attempt already has an entry.
+// Volatile: This API is subject to change at any time.
+func NewTransactionATRPendingOps(transactionID, attemptID string, expiry time.Duration) []SubDocOp {
+	attemptPath := TransactionATRAttemptPath(attemptID)
+
+	return []SubDocOp{
+		transactionXattrOp(memd.SubDocOpDictAdd, attemptPath+".tid", transactionJSONString(transactionID)),
+		transactionXattrOp(memd.SubDocOpDictSet, attemptPath+".st",
+			transactionJSONString(string(TransactionAttemptPending))),
+		NewSubDocMacroOp(memd.SubDocOpDictSet, attemptPath+".tst", SubdocMutateMacroCas, memd.SubdocFlagMkDirP),
+		transactionXattrOp(memd.SubDocOpDictSet, attemptPath+".exp",
+			[]byte(strconv.FormatInt(int64(expiry/time.Millisecond), 10))),
+	}
+}
+
+// NewTransactionATRSetStateOps returns the sub-document operations which move an attempt's ATR entry into state,
+// recording when the state was entered. If docs is not nil then the documents mutated by the attempt are recorded.
+// Volatile: This API is subject to change at any time.
+func NewTransactionATRSetStateOps(attemptID string, state TransactionAttemptState,
+	docs *TransactionATRDocs) ([]SubDocOp, error) {
+	timestampField := state.stateTimestampField()
+	if timestampField == "" {
+		return nil, wrapError(errInvalidArgument, fmt.Sprintf("unknown attempt state %s", state))
+	}
+
+	attemptPath := TransactionATRAttemptPath(attemptID)
+	ops := []SubDocOp{
+		transactionXattrOp(memd.SubDocOpDictSet, attemptPath+".st", transactionJSONString(string(state))),
+		NewSubDocMacroOp(memd.SubDocOpDictSet, attemptPath+"."+timestampField, SubdocMutateMacroCas,
+			memd.SubdocFlagMkDirP),
+	}
+
+	if docs != nil {
+		fields := []string{"ins", "rep", "rem"}
+		for i, records := range [][]TransactionATRDocRecord{docs.Inserts, docs.Replaces, docs.Removes} {
+			if records == nil {
+				records = []TransactionATRDocRecord{}
+			}
+
+			value, err := json.Marshal(records)
+			if err != nil {
+				return nil, err
+			}
+
+			ops = append(ops, transactionXattrOp(memd.SubDocOpDictSet, attemptPath+"."+fields[i], value))
+		}
+	}
+
+	return ops, nil
+}
+
+// NewTransactionATRRemoveAttemptOp returns the sub-document operation which removes an attempt's entry from an ATR
+// document, once the attempt has completed or rolled back.
+// Volatile: This API is subject to change at any time.
+func NewTransactionATRRemoveAttemptOp(attemptID string) SubDocOp {
+	return SubDocOp{
+		Op:    memd.SubDocOpDelete,
+		Flags: memd.SubdocFlagXattrPath,
+		Path:  TransactionATRAttemptPath(attemptID),
+	}
+}
+
+// TransactionATREntry is the entry for a transaction attempt within an ATR document.
+// Volatile: This API is subject to change at any time.
+type TransactionATREntry struct {
+	TransactionID string
+	State         TransactionAttemptState
+
+	// StartCas is the CAS of the ATR document when the entry was added, this is the server's clock at that time.
+	StartCas Cas
+	Expiry   time.Duration
+
+	// StateCas holds the CAS of the ATR document when the attempt entered each state.
+	StateCas map[TransactionAttemptState]Cas
+
+	// Docs is nil until the attempt has committed or aborted.
+	Docs *TransactionATRDocs
+}
+
+type jsonTransactionATREntry struct {
+	TransactionID string                     `json:"tid"`
+	State         string                     `json:"st"`
+	ExpiryMillis  int64                      `json:"exp"`
+	Inserts       *[]TransactionATRDocRecord `json:"ins,omitempty"`
+	Replaces      *[]TransactionATRDocRecord `json:"rep,omitempty"`
+	Removes       *[]TransactionATRDocRecord `json:"rem,omitempty"`
+}
+
+// ParseTransactionATR parses the value of the attempts xattr of an ATR document, returning the entries keyed by
+// attempt id.
+// Volatile: This API is subject to change at any time.
+func ParseTransactionATR(value []byte) (map[string]TransactionATREntry, error) {
+	var rawAttempts map[string]json.RawMessage
+	if err := json.Unmarshal(value, &rawAttempts); err != nil {
+		return nil, wrapError(errParsingFailure, fmt.Sprintf("failed to parse atr attempts: %v", err))
+	}
+
+	states := []TransactionAttemptState{TransactionAttemptPending, TransactionAttemptCommitted,
+		TransactionAttemptCompleted, TransactionAttemptAborted, TransactionAttemptRolledBack}
+
+	entries := make(map[string]TransactionATREntry, len(rawAttempts))
+	for attemptID, rawAttempt := range rawAttempts {
+		var jsonEntry jsonTransactionATREntry
+		if err := json.Unmarshal(rawAttempt, &jsonEntry); err != nil {
+			return nil, wrapError(errParsingFailure, fmt.Sprintf("failed to parse atr entry %s: %v", attemptID, err))
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(rawAttempt, &fields); err != nil {
+			return nil, wrapError(errParsingFailure, fmt.Sprintf("failed to parse atr entry %s: %v", attemptID, err))
+		}
+
+		entry := TransactionATREntry{
+			TransactionID: jsonEntry.TransactionID,
+			State:         TransactionAttemptState(jsonEntry.State),
+			Expiry:        time.Duration(jsonEntry.ExpiryMillis) * time.Millisecond,
+			StateCas:      make(map[TransactionAttemptState]Cas),
+		}
+
+		for _, state := range states {
+			rawCas, ok := fields[state.stateTimestampField()]
+			if !ok {
+				continue
+			}
+
+			cas, err := DecodeSubdocMacroCas(rawCas)
+			if err != nil {
+				return nil, err
+			}
+			entry.StateCas[state] = cas
+		}
+		entry.StartCas = entry.StateCas[TransactionAttemptPending]
+
+		if jsonEntry.Inserts != nil || jsonEntry.Replaces != nil || jsonEntry.Removes != nil {
+			entry.Docs = &TransactionATRDocs{}
+			if jsonEntry.Inserts != nil {
+				entry.Docs.Inserts = *jsonEntry.Inserts
+			}
+			if jsonEntry.Replaces != nil {
+				entry.Docs.Replaces = *jsonEntry.Replaces
+			}
+			if jsonEntry.Removes != nil {
+				entry.Docs.Removes = *jsonEntry.Removes
+			}
+		}
+
+		entries[attemptID] = entry
+	}
+
+	return entries, nil
+}
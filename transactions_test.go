@@ -0,0 +1,164 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+func (suite *UnitTestSuite) TestTransactionStagedMutationOps() {
+	mutation := TransactionStagedMutation{
+		TransactionID: "txn1",
+		AttemptID:     "attempt1",
+		ATR: TransactionATRLocation{
+			BucketName:     "default",
+			ScopeName:      "_default",
+			CollectionName: "_default",
+			DocID:          "_txn:atr-1",
+		},
+		Type:   TransactionStagedReplace,
+		Staged: json.RawMessage(`{"name":"new"}`),
+	}
+
+	paths := make(map[string]string)
+	for _, op := range mutation.Ops() {
+		suite.Assert().Equal(memd.SubDocOpDictSet, op.Op)
+		suite.Assert().NotZero(op.Flags & memd.SubdocFlagXattrPath)
+		paths[op.Path] = string(op.Value)
+	}
+
+	suite.Assert().Equal(map[string]string{
+		"txn.id.txn":          `"txn1"`,
+		"txn.id.atmpt":        `"attempt1"`,
+		"txn.atr.id":          `"_txn:atr-1"`,
+		"txn.atr.bkt":         `"default"`,
+		"txn.atr.scp":         `"_default"`,
+		"txn.atr.coll":        `"_default"`,
+		"txn.op.type":         `"replace"`,
+		"txn.op.stgd":         `{"name":"new"}`,
+		"txn.op.crc32":        `"${Mutation.value_crc32c}"`,
+		"txn.restore.CAS":     `"${$document.CAS}"`,
+		"txn.restore.exptime": `"${$document.exptime}"`,
+		"txn.restore.revid":   `"${$document.revid}"`,
+	}, paths)
+
+	// Inserts don't have any metadata to restore and removes don't have a staged body.
+	mutation.Type = TransactionStagedInsert
+	for _, op := range mutation.Ops() {
+		suite.Assert().NotContains(op.Path, "txn.restore")
+	}
+
+	mutation.Type = TransactionStagedRemove
+	for _, op := range mutation.Ops() {
+		suite.Assert().NotEqual("txn.op.stgd", op.Path)
+	}
+}
+
+func (suite *UnitTestSuite) TestParseTransactionLookupResult() {
+	// The restore CAS is copied from $document.CAS so, unlike the mutation macros, it is not byte swapped.
+	xattr := []byte(`{
+		"id": {"txn": "txn1", "atmpt": "attempt1"},
+		"atr": {"id": "_txn:atr-1", "bkt": "default", "scp": "_default", "coll": "_default"},
+		"op": {"type": "replace", "stgd": {"name": "new"}, "crc32": "0x297bd0aa"},
+		"restore": {"CAS": "0x1616b8c1c8a30000", "exptime": 0, "revid": "3"}
+	}`)
+
+	parsed, err := ParseTransactionLookupResult(&LookupInResult{
+		Cas: 10,
+		Ops: []SubDocResult{
+			{Value: xattr},
+			{Value: []byte(`"4"`)},
+			{Value: []byte(`{"name":"old"}`)},
+		},
+	})
+	suite.Require().Nil(err)
+	suite.Assert().Equal(Cas(10), parsed.Cas)
+	suite.Assert().Equal("4", parsed.RevID)
+	suite.Assert().Equal(`{"name":"old"}`, string(parsed.Body))
+	suite.Require().NotNil(parsed.StagedMutation)
+	suite.Assert().Equal(&TransactionStagedMutation{
+		TransactionID: "txn1",
+		AttemptID:     "attempt1",
+		ATR: TransactionATRLocation{
+			BucketName:     "default",
+			ScopeName:      "_default",
+			CollectionName: "_default",
+			DocID:          "_txn:atr-1",
+		},
+		Type:   TransactionStagedReplace,
+		Staged: json.RawMessage(`{"name": "new"}`),
+		Restore: &TransactionRestoreMetadata{
+			Cas:   Cas(0x1616b8c1c8a30000),
+			RevID: "3",
+		},
+	}, parsed.StagedMutation)
+
+	// A document without a staged mutation.
+	parsed, err = ParseTransactionLookupResult(&LookupInResult{
+		Ops: []SubDocResult{
+			{Err: SubDocumentError{InnerError: errPathNotFound}},
+			{Value: []byte(`"1"`)},
+			{Value: []byte(`{}`)},
+		},
+	})
+	suite.Require().Nil(err)
+	suite.Assert().Nil(parsed.StagedMutation)
+
+	_, err = ParseTransactionLookupResult(&LookupInResult{Ops: []SubDocResult{{}}})
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+}
+
+func (suite *UnitTestSuite) TestTransactionATR() {
+	ops := NewTransactionATRPendingOps("txn1", "attempt1", 15*time.Second)
+	suite.Require().Len(ops, 4)
+	suite.Assert().Equal(memd.SubDocOpDictAdd, ops[0].Op)
+	suite.Assert().Equal("attempts.attempt1.tid", ops[0].Path)
+	suite.Assert().Equal("attempts.attempt1.exp", ops[3].Path)
+	suite.Assert().Equal("15000", string(ops[3].Value))
+
+	ops, err := NewTransactionATRSetStateOps("attempt1", TransactionAttemptCommitted, &TransactionATRDocs{
+		Replaces: []TransactionATRDocRecord{{BucketName: "default", ScopeName: "_default",
+			CollectionName: "_default", DocID: "doc"}},
+	})
+	suite.Require().Nil(err)
+	paths := make(map[string]string)
+	for _, op := range ops {
+		paths[op.Path] = string(op.Value)
+	}
+	suite.Assert().Equal(map[string]string{
+		"attempts.attempt1.st":  `"COMMITTED"`,
+		"attempts.attempt1.tsc": `"${Mutation.CAS}"`,
+		"attempts.attempt1.ins": `[]`,
+		"attempts.attempt1.rep": `[{"bkt":"default","scp":"_default","col":"_default","id":"doc"}]`,
+		"attempts.attempt1.rem": `[]`,
+	}, paths)
+
+	_, err = NewTransactionATRSetStateOps("attempt1", TransactionAttemptState("UNKNOWN"), nil)
+	suite.Assert().True(errors.Is(err, ErrInvalidArgument))
+
+	entries, err := ParseTransactionATR([]byte(`{
+		"attempt1": {"tid": "txn1", "st": "COMMITTED", "tst": "0x000058a73ebb1615", "tsc": "0x000059a73ebb1615",
+			"exp": 15000, "ins": [], "rep": [{"bkt": "default", "scp": "_default", "col": "_default", "id": "doc"}],
+			"rem": []},
+		"attempt2": {"tid": "txn2", "st": "PENDING", "tst": "0x000058a73ebb1615", "exp": 15000}
+	}`))
+	suite.Require().Nil(err)
+	suite.Require().Len(entries, 2)
+
+	committed := entries["attempt1"]
+	suite.Assert().Equal("txn1", committed.TransactionID)
+	suite.Assert().Equal(TransactionAttemptCommitted, committed.State)
+	suite.Assert().Equal(15*time.Second, committed.Expiry)
+	suite.Assert().Equal(Cas(0x1516bb3ea7580000), committed.StartCas)
+	suite.Assert().Equal(Cas(0x1516bb3ea7590000), committed.StateCas[TransactionAttemptCommitted])
+	suite.Require().NotNil(committed.Docs)
+	suite.Assert().Len(committed.Docs.Replaces, 1)
+	suite.Assert().Empty(committed.Docs.Inserts)
+
+	suite.Assert().Nil(entries["attempt2"].Docs)
+
+	_, err = ParseTransactionATR([]byte(`{"attempt1": {"tst": 10}}`))
+	suite.Assert().True(errors.Is(err, ErrParsingFailure))
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 func getMapValueString(dict map[string]interface{}, key string, def string) string {
@@ -42,6 +43,30 @@ func formatCbUID(data []byte) string {
 		data[0], data[1], data[2], data[3], data[4], data[5], data[6], data[7])
 }
 
+// UserAgentComponent identifies a product which is embedding the SDK, such as an application or a higher level
+// library, it is included in the user agent sent to the server as product/version.
+type UserAgentComponent struct {
+	Product string
+	Version string
+}
+
+// buildUserAgent appends each component to userAgent.
+func buildUserAgent(userAgent string, components []UserAgentComponent) string {
+	parts := make([]string, 0, len(components)+1)
+	if userAgent != "" {
+		parts = append(parts, userAgent)
+	}
+	for _, component := range components {
+		if component.Version == "" {
+			parts = append(parts, component.Product)
+			continue
+		}
+		parts = append(parts, component.Product+"/"+component.Version)
+	}
+
+	return strings.Join(parts, " ")
+}
+
 func clientInfoString(connID, userAgent string) string {
 	agentName := "gocbcore/" + goCbCoreVersionStr
 	if userAgent != "" {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
 	"strings"
@@ -36,6 +37,13 @@ func (q *ViewQueryRowReader) Close() error {
 	return q.streamer.Close()
 }
 
+// WriteRowsTo streams the remaining rows into w as newline delimited JSON, returning the number of rows written.
+// Rows are only read from the network as quickly as w accepts them.
+// Volatile: This API is subject to change at any time.
+func (q *ViewQueryRowReader) WriteRowsTo(w io.Writer) (int, error) {
+	return q.streamer.WriteRowsTo(w)
+}
+
 // ViewQueryOptions represents the various options available for a view query.
 type ViewQueryOptions struct {
 	DesignDocumentName string
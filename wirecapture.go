@@ -0,0 +1,236 @@
+package gocbcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+)
+
+const defaultWireCaptureMaxPackets = 1000
+
+// WireCaptureDirection indicates whether a captured packet was sent or received.
+type WireCaptureDirection int
+
+const (
+	// WireCaptureSent indicates a packet which was sent to the server.
+	WireCaptureSent = WireCaptureDirection(1)
+
+	// WireCaptureReceived indicates a packet which was received from the server.
+	WireCaptureReceived = WireCaptureDirection(2)
+)
+
+// CapturedPacket is the record of a single memd packet captured by a wire capture.
+type CapturedPacket struct {
+	Time         time.Time
+	Direction    WireCaptureDirection
+	Address      string
+	ConnectionID string
+
+	Magic    memd.CmdMagic
+	Command  memd.CmdCode
+	Status   memd.StatusCode
+	Opaque   uint32
+	Vbucket  uint16
+	Cas      uint64
+	Datatype uint8
+
+	KeyLen    int
+	ExtrasLen int
+	ValueLen  int
+
+	// Key is only populated when IncludeBodies is set, it is wrapped in redaction tags when log redaction is enabled.
+	Key string
+	// Extras is only populated when IncludeBodies is set.
+	Extras []byte
+	// Value is only populated when IncludeBodies is set and log redaction is disabled. It is never populated for
+	// authentication packets.
+	Value []byte
+}
+
+// WireCaptureOptions are the options available when starting a wire capture.
+type WireCaptureOptions struct {
+	// Duration is how long to capture for, after which the capture stops automatically. If zero then the capture
+	// runs until it is stopped.
+	Duration time.Duration
+
+	// MaxPackets is the number of packets kept in the capture ring buffer, older packets are discarded once it is
+	// full. Defaults to 1000.
+	MaxPackets int
+
+	// IncludeBodies captures the key, extras and value of packets as well as their headers.
+	IncludeBodies bool
+
+	// Writer, if set, is written each captured packet as a line of JSON as it is captured. It is called inline
+	// with packet processing so must not block.
+	Writer io.Writer
+}
+
+type wireCaptureComponent struct {
+	active uint32
+
+	lock      sync.Mutex
+	opts      WireCaptureOptions
+	packets   []CapturedPacket
+	next      int
+	full      bool
+	stopTimer *time.Timer
+	encoder   *json.Encoder
+	logCtx    logContext
+}
+
+func newWireCaptureComponent(logCtx logContext) *wireCaptureComponent {
+	return &wireCaptureComponent{
+		logCtx: logCtx,
+	}
+}
+
+// Start begins a new capture, discarding any packets from a previous one.
+func (wc *wireCaptureComponent) Start(opts WireCaptureOptions) error {
+	if opts.MaxPackets <= 0 {
+		opts.MaxPackets = defaultWireCaptureMaxPackets
+	}
+
+	wc.lock.Lock()
+	defer wc.lock.Unlock()
+
+	if atomic.LoadUint32(&wc.active) == 1 {
+		return wrapError(errInvalidArgument, "a wire capture is already in progress")
+	}
+
+	wc.opts = opts
+	wc.packets = make([]CapturedPacket, opts.MaxPackets)
+	wc.next = 0
+	wc.full = false
+	wc.encoder = nil
+	if opts.Writer != nil {
+		wc.encoder = json.NewEncoder(opts.Writer)
+	}
+	if opts.Duration > 0 {
+		wc.stopTimer = time.AfterFunc(opts.Duration, func() {
+			wc.Stop()
+		})
+	}
+
+	atomic.StoreUint32(&wc.active, 1)
+	wc.logCtx.logInfof("Started wire capture")
+	return nil
+}
+
+// Stop ends the current capture, returning the packets which were captured.
+func (wc *wireCaptureComponent) Stop() []CapturedPacket {
+	wc.lock.Lock()
+	defer wc.lock.Unlock()
+
+	if atomic.CompareAndSwapUint32(&wc.active, 1, 0) {
+		wc.logCtx.logInfof("Stopped wire capture")
+	}
+	if wc.stopTimer != nil {
+		wc.stopTimer.Stop()
+		wc.stopTimer = nil
+	}
+
+	return wc.packetsLocked()
+}
+
+// Packets returns the packets captured so far by the current, or most recent, capture in the order they were
+// captured.
+func (wc *wireCaptureComponent) Packets() []CapturedPacket {
+	wc.lock.Lock()
+	defer wc.lock.Unlock()
+
+	return wc.packetsLocked()
+}
+
+func (wc *wireCaptureComponent) packetsLocked() []CapturedPacket {
+	if !wc.full {
+		return append([]CapturedPacket(nil), wc.packets[:wc.next]...)
+	}
+
+	packets := make([]CapturedPacket, 0, len(wc.packets))
+	packets = append(packets, wc.packets[wc.next:]...)
+	return append(packets, wc.packets[:wc.next]...)
+}
+
+func (wc *wireCaptureComponent) record(direction WireCaptureDirection, address, connID string, pkt *memd.Packet) {
+	if wc == nil || atomic.LoadUint32(&wc.active) == 0 {
+		return
+	}
+
+	captured := CapturedPacket{
+		Time:         time.Now(),
+		Direction:    direction,
+		Address:      address,
+		ConnectionID: connID,
+		Magic:        pkt.Magic,
+		Command:      pkt.Command,
+		Status:       pkt.Status,
+		Opaque:       pkt.Opaque,
+		Vbucket:      pkt.Vbucket,
+		Cas:          pkt.Cas,
+		Datatype:     pkt.Datatype,
+		KeyLen:       len(pkt.Key),
+		ExtrasLen:    len(pkt.Extras),
+		ValueLen:     len(pkt.Value),
+	}
+
+	wc.lock.Lock()
+	defer wc.lock.Unlock()
+
+	// The capture may have been stopped whilst we were waiting for the lock.
+	if atomic.LoadUint32(&wc.active) == 0 {
+		return
+	}
+
+	if wc.opts.IncludeBodies {
+		if len(pkt.Key) > 0 {
+			captured.Key = fmt.Sprint(logUserData(string(pkt.Key)).redacted())
+		}
+		captured.Extras = append([]byte(nil), pkt.Extras...)
+		if globalLogRedactionLevel == RedactNone && !isAuthCommand(pkt.Command) {
+			captured.Value = append([]byte(nil), pkt.Value...)
+		}
+	}
+
+	wc.packets[wc.next] = captured
+	wc.next++
+	if wc.next == len(wc.packets) {
+		wc.next = 0
+		wc.full = true
+	}
+
+	if wc.encoder != nil {
+		err := wc.encoder.Encode(captured)
+		if err != nil {
+			wc.logCtx.logDebugf("Failed to write captured packet: %v", err)
+		}
+	}
+}
+
+func isAuthCommand(cmd memd.CmdCode) bool {
+	return cmd == memd.CmdSASLAuth || cmd == memd.CmdSASLStep
+}
+
+// wireCaptureConn records every packet written to or read from the wrapped connection.
+type wireCaptureConn struct {
+	MemdConn
+	capture *wireCaptureComponent
+	connID  string
+}
+
+func (c *wireCaptureConn) WritePacket(pkt *memd.Packet) error {
+	c.capture.record(WireCaptureSent, c.RemoteAddr(), c.connID, pkt)
+	return c.MemdConn.WritePacket(pkt)
+}
+
+func (c *wireCaptureConn) ReadPacket() (*memd.Packet, int, error) {
+	pkt, n, err := c.MemdConn.ReadPacket()
+	if err == nil {
+		c.capture.record(WireCaptureReceived, c.RemoteAddr(), c.connID, pkt)
+	}
+	return pkt, n, err
+}
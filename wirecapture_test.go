@@ -0,0 +1,129 @@
+package gocbcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/couchbase/gocbcore/v9/memd"
+	"github.com/couchbase/gocbcore/v9/memdmock"
+)
+
+func (suite *UnitTestSuite) TestWireCaptureRingBuffer() {
+	wc := newWireCaptureComponent(nil)
+	wc.record(WireCaptureSent, "127.0.0.1:11210", "conn", &memd.Packet{Opaque: 99})
+	suite.Assert().Empty(wc.Packets())
+
+	suite.Require().Nil(wc.Start(WireCaptureOptions{MaxPackets: 3}))
+	suite.Assert().True(errors.Is(wc.Start(WireCaptureOptions{}), ErrInvalidArgument))
+
+	for i := 0; i < 5; i++ {
+		wc.record(WireCaptureSent, "127.0.0.1:11210", "conn", &memd.Packet{
+			Command: memd.CmdSASLAuth,
+			Opaque:  uint32(i),
+			Key:     []byte("PLAIN"),
+			Value:   []byte("secret"),
+		})
+	}
+
+	packets := wc.Stop()
+	suite.Require().Len(packets, 3)
+	for i, packet := range packets {
+		suite.Assert().Equal(uint32(i+2), packet.Opaque)
+		suite.Assert().Equal(6, packet.ValueLen)
+		suite.Assert().Empty(packet.Key)
+		suite.Assert().Nil(packet.Value)
+	}
+
+	// Bodies of authentication packets are never captured.
+	suite.Require().Nil(wc.Start(WireCaptureOptions{IncludeBodies: true}))
+	wc.record(WireCaptureSent, "127.0.0.1:11210", "conn", &memd.Packet{
+		Command: memd.CmdSASLAuth,
+		Key:     []byte("PLAIN"),
+		Value:   []byte("secret"),
+	})
+	packets = wc.Stop()
+	suite.Require().Len(packets, 1)
+	suite.Assert().Equal("PLAIN", packets[0].Key)
+	suite.Assert().Nil(packets[0].Value)
+
+	// Captures stop by themselves once their duration has elapsed.
+	suite.Require().Nil(wc.Start(WireCaptureOptions{Duration: 10 * time.Millisecond}))
+	time.Sleep(50 * time.Millisecond)
+	wc.record(WireCaptureSent, "127.0.0.1:11210", "conn", &memd.Packet{})
+	suite.Assert().Empty(wc.Packets())
+}
+
+func (suite *UnitTestSuite) TestWireCaptureRedaction() {
+	SetLogRedactionLevel(RedactPartial)
+	defer SetLogRedactionLevel(RedactNone)
+
+	wc := newWireCaptureComponent(nil)
+	suite.Require().Nil(wc.Start(WireCaptureOptions{IncludeBodies: true}))
+	wc.record(WireCaptureReceived, "127.0.0.1:11210", "conn", &memd.Packet{
+		Command: memd.CmdGet,
+		Key:     []byte("key"),
+		Value:   []byte("value"),
+	})
+
+	packets := wc.Stop()
+	suite.Require().Len(packets, 1)
+	suite.Assert().Equal("<ud>key</ud>", packets[0].Key)
+	suite.Assert().Nil(packets[0].Value)
+	suite.Assert().Equal(5, packets[0].ValueLen)
+}
+
+func (suite *UnitTestSuite) TestAgentWireCapture() {
+	server := memdmock.NewServer("127.0.0.1:11210", "default", 64)
+	defer server.Close()
+
+	agent := suite.newMockAgent(server, AgentConfig{BucketName: "default"})
+	defer agent.Close()
+
+	var buf bytes.Buffer
+	suite.Require().Nil(agent.StartWireCapture(WireCaptureOptions{
+		IncludeBodies: true,
+		Writer:        &buf,
+	}))
+
+	errCh := make(chan error, 1)
+	_, err := agent.Get(GetOptions{
+		Key:      []byte("missing"),
+		Deadline: time.Now().Add(5 * time.Second),
+	}, func(res *GetResult, err error) {
+		errCh <- err
+	})
+	suite.Require().Nil(err)
+	suite.Assert().True(errors.Is(<-errCh, ErrDocumentNotFound))
+
+	packets := agent.StopWireCapture()
+
+	var sent, received *CapturedPacket
+	for i := range packets {
+		if packets[i].Command != memd.CmdGet {
+			continue
+		}
+		if packets[i].Direction == WireCaptureSent {
+			sent = &packets[i]
+		} else {
+			received = &packets[i]
+		}
+	}
+	suite.Require().NotNil(sent)
+	suite.Require().NotNil(received)
+	suite.Assert().Equal("missing", sent.Key)
+	suite.Assert().Equal(server.Address(), sent.Address)
+	suite.Assert().NotEmpty(sent.ConnectionID)
+	suite.Assert().Equal(sent.Opaque, received.Opaque)
+	suite.Assert().Equal(memd.StatusKeyNotFound, received.Status)
+
+	dec := json.NewDecoder(&buf)
+	var written []CapturedPacket
+	for dec.More() {
+		var packet CapturedPacket
+		suite.Require().Nil(dec.Decode(&packet))
+		written = append(written, packet)
+	}
+	suite.Assert().Len(written, len(packets))
+}